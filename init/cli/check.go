@@ -0,0 +1,62 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+)
+
+var checkCliCommand = cli.Command{
+	Name: "check",
+	Usage: `
+Checks whether the service defined by the static and custom configurations at service/bin/launcher-static.yml and
+var/conf/launcher-custom.yml is running, for supervisors such as monit, s6 and runit that poll it. Only reads the
+configuration, pid and state files, and writes nothing but a line describing the status to stdout. Exits, whatever
+statusExitCodes is set to:
+- 0 if all of its processes are running
+- 1 if at least one process is not running but there is a record of processes having been started, or, with --health,
+  if a running process fails its health check
+- 2 if the status cannot be determined
+- 3 if no processes are running and there is no record of processes having been started
+- 4 if at least one process is crash-looping, 'supervise' having given up restarting it
+With --health, each running process with a healthCheck is checked once rather than waited for to pass it.
+If process names are given, only those processes are checked.`,
+	Flags: []flag.Flag{
+		flag.BoolFlag{
+			Name:  healthFlagName,
+			Usage: "Check each running process with a healthCheck once",
+		},
+		allFlag,
+		processesParam,
+	},
+	Action: withService(check),
+}
+
+const healthFlagName = "health"
+
+func check(ctx cli.Context, svc *lib.Service) error {
+	names, err := selectedNames(ctx)
+	if err != nil {
+		fmt.Fprintln(ctx.App.Stdout, errors.Wrap(err, "failed to determine service status"))
+		return cli.WithExitCode(2, errors.New(""))
+	}
+	return svc.Check(ctx.Bool(healthFlagName), names...)
+}
@@ -15,12 +15,13 @@
 package cli
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
 	"github.com/pkg/errors"
 
+	"github.com/palantir/go-java-launcher/init/lib"
 	"github.com/palantir/go-java-launcher/launchlib"
 )
 
@@ -28,44 +29,107 @@ func App() *cli.App {
 	app := cli.NewApp()
 	app.Name = "go-init"
 	app.Usage = "A simple init.sh-style service launcher CLI."
+	app.Version = launchlib.Version
+	app.Flags = append(append([]flag.Flag(nil), pathFlags...), strictKeysFlag, profileFlag)
+	app.Completion = completionProviders
+	app.Before = func(ctx cli.Context) error {
+		if err := launchlib.ChdirServiceRoot(ctx.String(serviceRootFlagName)); err != nil {
+			return err
+		}
+		launchlib.StrictKeys = ctx.Bool(strictKeysFlagName)
+		launchlib.Profile = ctx.String(profileFlagName)
+		if launchlib.DebugFromEnv() {
+			launchlib.TraceOutput = os.Stderr
+		}
+		return nil
+	}
 
-	app.Subcommands = []cli.Command{startCliCommand, statusCliCommand, stopCliCommand}
+	app.Subcommands = []cli.Command{
+		checkCliCommand,
+		completionCliCommand,
+		consoleCliCommand,
+		envCliCommand,
+		gcInfoCliCommand,
+		generateCliCommand,
+		heapDumpCliCommand,
+		logsCliCommand,
+		migrateConfigCliCommand,
+		reloadCliCommand,
+		rotateLogsCliCommand,
+		runCliCommand,
+		signalCliCommand,
+		startCliCommand,
+		statusCliCommand,
+		stopCliCommand,
+		superviseCliCommand,
+		threadDumpCliCommand,
+		validateCliCommand,
+		versionCliCommand,
+		windowsServiceCliCommand,
+	}
 	return app
 }
 
-func executeWithLoggers(action func(cli.Context, launchlib.ServiceLoggers) error, flags FileFlags) func(cli.Context) error {
-	return func(ctx cli.Context) (rErr error) {
-		// Fall back to default stdout if error opening log file
-		if err := os.MkdirAll(logDir, 0755); err != nil {
-			return logErrorAndReturnWithExitCode(
-				ctx, errors.Wrapf(err, "Error trying to make log directory '%s'", logDir), 4)
-		}
+const (
+	strictKeysFlagName = "strict-keys"
+	profileFlagName    = "profile"
+)
 
-		loggers := &FileLoggers{
-			flags: flags,
-			mode:  outputFileMode,
-		}
+var strictKeysFlag = flag.BoolFlag{
+	Name:   strictKeysFlagName,
+	Usage:  "Fail on keys that are not part of the configuration format, as configVersion 2 always does",
+	EnvVar: "GO_INIT_STRICT_KEYS",
+}
+
+var profileFlag = flag.StringFlag{
+	Name:   profileFlagName,
+	Usage:  "The profile of the static configuration to apply, such as dev or prod",
+	EnvVar: launchlib.ProfileEnvVar,
+}
+
+const (
+	processesParamName = "processes"
+	allFlagName        = "all"
+)
+
+var (
+	processesParam = flag.StringSlice{
+		Name:     processesParamName,
+		Usage:    "The names of the processes to act on, defaulting to all configured processes",
+		Optional: true,
+	}
+	allFlag = flag.BoolFlag{
+		Name:  allFlagName,
+		Usage: "Act on all configured processes, the default if no process names are given",
+	}
+)
+
+// selectedNames returns the names of the processes given by the processes parameter.
+func selectedNames(ctx cli.Context) ([]string, error) {
+	names := ctx.Slice(processesParamName)
+	if len(names) > 0 && ctx.Bool(allFlagName) {
+		return nil, errors.New("process names cannot be given along with --all")
+	}
+	return names, nil
+}
 
-		outputFile, err := loggers.PrimaryLogger()
+// withService returns the action run on the service at the paths given by the path flags, which writes to the stdout
+// and stderr of the App.
+func withService(action func(cli.Context, *lib.Service) error) func(cli.Context) error {
+	return func(ctx cli.Context) error {
+		svc, err := newService(ctx)
 		if err != nil {
-			return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "Error opening startup log file"), 4)
+			return err
 		}
-		defer func() {
-			if cErr := outputFile.Close(); rErr == nil && cErr != nil {
-				/*
-				 * Exit 0 and communicate "success with errors" because although we failed to close the
-				 * output file, we're a cli and the OS will close it for us momentarily
-				 */
-				rErr = cli.WithExitCode(0, errors.Errorf("failed to close primary output file"))
-			}
-		}()
-		ctx.App.Stdout = outputFile
-		return action(ctx, loggers)
+		return action(ctx, svc)
 	}
 }
 
-func logErrorAndReturnWithExitCode(ctx cli.Context, err error, exitCode int) cli.ExitCoder {
-	// We still want to write the error to stderr if we can't write it to the startup log file.
-	_, _ = fmt.Fprintln(ctx.App.Stdout, err)
-	return cli.WithExitCode(exitCode, err)
+// audited is withService that records each invocation of the action in the audit log of the service.
+func audited(action func(cli.Context, *lib.Service) error) func(cli.Context) error {
+	return withService(func(ctx cli.Context, svc *lib.Service) error {
+		return svc.Audit(ctx.Command.Name, os.Args[1:], func() error {
+			return action(ctx, svc)
+		})
+	})
 }
@@ -0,0 +1,225 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/completion"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+const shellParamName = "shell"
+
+var completionCliCommand = cli.Command{
+	Name: "completion",
+	Usage: `
+Writes a script to stdout that completes the subcommands and flags of go-init in the given shell, one of bash, zsh or
+fish, along with the names of the processes of the service defined by the static configuration given by
+--static-config or at service/bin/launcher-static.yml. The completions are generated by go-init itself as they are
+requested, so the script need not be regenerated when go-init or the configuration change. Load it with, for example,
+'source <(go-init completion bash)' in ~/.bashrc, or 'go-init completion fish | source' in
+~/.config/fish/config.fish. Exits 1 and writes an error message to stderr if the shell is not supported.`,
+	Flags: []flag.Flag{
+		flag.StringParam{
+			Name:  shellParamName,
+			Usage: "The shell to complete in, one of bash, zsh or fish",
+		},
+	},
+	Action: printCompletionScript,
+}
+
+// completionScripts are the templates of the completion scripts of each shell, given the name of the program to
+// complete. Each script runs the program being completed with the words typed so far followed by cli.CompletionFlag,
+// which prints the completions of the last word, or exits with a completion code to have the shell complete paths. The
+// markers of the cli library that begin with #- are not offered as completions.
+var completionScripts = map[string]string{
+	"bash": `_go_init_complete() {
+	local cur opts
+	COMPREPLY=()
+	cur="${COMP_WORDS[COMP_CWORD]}"
+	opts=$("${COMP_WORDS[0]}" "${COMP_WORDS[@]:1:COMP_CWORD}" {{.Flag}} 2>/dev/null)
+	case $? in
+	{{.FilepathCode}})
+		type compopt &>/dev/null && compopt -o filenames
+		local IFS=$'\n'
+		COMPREPLY=($(compgen -f -- "${cur}"))
+		;;
+	{{.DirectoryCode}})
+		type compopt &>/dev/null && compopt -o filenames
+		local IFS=$'\n'
+		COMPREPLY=($(compgen -d -- "${cur}"))
+		;;
+	*)
+		local IFS=$'\n'
+		COMPREPLY=($(compgen -W "${opts}" -- "${cur}" | grep -v '^#-'))
+		;;
+	esac
+	return 0
+}
+complete -F _go_init_complete {{.Prog}}
+`,
+	"zsh": `#compdef {{.Prog}}
+_go_init_complete() {
+	local -a opts
+	opts=("${(@f)$("${words[1]}" "${words[@]:1:$CURRENT-1}" {{.Flag}} 2>/dev/null)}")
+	case $? in
+	{{.FilepathCode}})
+		_path_files
+		;;
+	{{.DirectoryCode}})
+		_path_files -/
+		;;
+	*)
+		opts=(${opts:#\#-*})
+		compadd -Q -a opts
+		;;
+	esac
+}
+compdef _go_init_complete {{.Prog}}
+`,
+	"fish": `function __go_init_complete
+	set -l args (commandline -opc)
+	set -l prog $args[1]
+	set -e args[1]
+	set -l opts ($prog $args (commandline -ct) {{.Flag}} 2>/dev/null)
+	switch $status
+		case {{.FilepathCode}}
+			__fish_complete_path (commandline -ct)
+		case {{.DirectoryCode}}
+			__fish_complete_directories (commandline -ct)
+		case '*'
+			string match -v -- '#-*' $opts
+	end
+end
+complete -c {{.Prog}} -f -a '(__go_init_complete)'
+`,
+}
+
+// completionProviders complete the values of the flags and params of go-init by name.
+var completionProviders = map[string]completion.Provider{
+	serviceRootFlagName:  completion.Directory,
+	staticConfigFlagName: completion.Filepath,
+	customConfigFlagName: completion.Filepath,
+	pidfileFlagName:      completion.Filepath,
+	stateDirFlagName:     completion.Directory,
+	outFlagName:          completion.Filepath,
+	processesParamName:   completeProcessNames,
+	profileFlagName:      completeProfileNames,
+	shellParamName: func(ctx *completion.ProviderCtx) []string {
+		return completionShells()
+	},
+}
+
+func printCompletionScript(ctx cli.Context) error {
+	shell := ctx.String(shellParamName)
+	script, ok := completionScripts[shell]
+	if !ok {
+		return cli.WithExitCode(1, errors.Errorf("unsupported shell '%s', expected one of %s", shell,
+			strings.Join(completionShells(), ", ")))
+	}
+	return template.Must(template.New(shell).Parse(script)).Execute(ctx.App.Stdout, map[string]interface{}{
+		"Prog":          filepath.Base(os.Args[0]),
+		"Flag":          cli.CompletionFlag,
+		"FilepathCode":  completion.FilepathCode,
+		"DirectoryCode": completion.DirectoryCode,
+	})
+}
+
+func completionShells() []string {
+	shells := make([]string, 0, len(completionScripts))
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+	return shells
+}
+
+// completeProcessNames completes the names of the processes of the service, read from the static configuration given
+// on the command line being completed, or otherwise by the environment or default of the path flags. Completes nothing
+// if the configuration cannot be read.
+func completeProcessNames(ctx *completion.ProviderCtx) []string {
+	staticConfig, ok := completionStaticConfig(ctx)
+	if !ok {
+		return nil
+	}
+	names := []string{staticConfig.ServiceName}
+	for name := range staticConfig.SubProcesses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// completeProfileNames completes the names of the profiles of the service, read as by completeProcessNames.
+func completeProfileNames(ctx *completion.ProviderCtx) []string {
+	staticConfig, ok := completionStaticConfig(ctx)
+	if !ok {
+		return nil
+	}
+	var names []string
+	for name := range staticConfig.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func completionStaticConfig(ctx *completion.ProviderCtx) (launchlib.PrimaryStaticLauncherConfig, bool) {
+	staticConfigFile, err := launchlib.ExpandHome(completionFlagValue(ctx, staticConfigFlagName))
+	if err != nil {
+		return launchlib.PrimaryStaticLauncherConfig{}, false
+	}
+	customConfigFile, err := launchlib.ExpandHome(completionFlagValue(ctx, customConfigFlagName))
+	if err != nil {
+		return launchlib.PrimaryStaticLauncherConfig{}, false
+	}
+	if root, err := launchlib.ExpandHome(completionFlagValue(ctx, serviceRootFlagName)); err == nil && root != "" {
+		if !filepath.IsAbs(staticConfigFile) {
+			staticConfigFile = filepath.Join(root, staticConfigFile)
+		}
+		if !filepath.IsAbs(customConfigFile) {
+			customConfigFile = filepath.Join(root, customConfigFile)
+		}
+	}
+	staticConfig, _, err := launchlib.GetConfigsFromFiles(staticConfigFile, customConfigFile, ioutil.Discard)
+	return staticConfig, err == nil
+}
+
+// completionFlagValue returns the value of the path flag of the given name on the command line being completed, or
+// otherwise from its environment variable or default, as the flags of go-init are not parsed when completing.
+func completionFlagValue(ctx *completion.ProviderCtx, name string) string {
+	if value, ok := ctx.Flags[name]; ok {
+		return value
+	}
+	for _, pathFlag := range pathFlags {
+		if stringFlag, ok := pathFlag.(flag.StringFlag); ok && stringFlag.Name == name {
+			if value := os.Getenv(stringFlag.EnvVar); value != "" {
+				return value
+			}
+			return stringFlag.Value
+		}
+	}
+	return ""
+}
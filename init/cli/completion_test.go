@@ -0,0 +1,106 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/completion"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitCompletion_DefaultParameters(t *testing.T) {
+	assert.Equal(t, []flag.Flag{
+		flag.StringParam{
+			Name:  "shell",
+			Usage: "The shell to complete in, one of bash, zsh or fish",
+		},
+	}, completionCliCommand.Flags)
+}
+
+func TestPrintCompletionScript(t *testing.T) {
+	for i, currCase := range []struct {
+		shell    string
+		contains string
+	}{
+		{"bash", "complete -F _go_init_complete"},
+		{"zsh", "compdef _go_init_complete"},
+		{"fish", "complete -c"},
+	} {
+		stdout := &bytes.Buffer{}
+		code := runCompletionApp(stdout, "completion", currCase.shell)
+		assert.Equal(t, 0, code, "Case %d", i)
+		assert.Contains(t, stdout.String(), currCase.contains, "Case %d", i)
+		assert.Contains(t, stdout.String(), cli.CompletionFlag, "Case %d", i)
+	}
+
+	stdout := &bytes.Buffer{}
+	assert.Equal(t, 1, runCompletionApp(stdout, "completion", "tcsh"))
+	assert.Empty(t, stdout.String())
+}
+
+func TestCompleteProcessNames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-completion")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	staticFile := filepath.Join(dir, "launcher-static.yml")
+	require.NoError(t, ioutil.WriteFile(staticFile, []byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: postgres
+`), 0644))
+
+	for i, currCase := range []struct {
+		flags map[string]string
+		want  []string
+	}{
+		{map[string]string{
+			staticConfigFlagName: staticFile,
+			customConfigFlagName: filepath.Join(dir, "launcher-custom.yml"),
+		}, []string{"primary", "sidecar"}},
+		{map[string]string{
+			serviceRootFlagName:  dir,
+			staticConfigFlagName: "launcher-static.yml",
+			customConfigFlagName: "launcher-custom.yml",
+		}, []string{"primary", "sidecar"}},
+		{map[string]string{
+			staticConfigFlagName: filepath.Join(dir, "nonexistent.yml"),
+		}, nil},
+	} {
+		names := completeProcessNames(&completion.ProviderCtx{Flags: currCase.flags})
+		assert.Equal(t, currCase.want, names, "Case %d", i)
+	}
+}
+
+func runCompletionApp(stdout *bytes.Buffer, args ...string) int {
+	app := App()
+	app.Stdout = stdout
+	app.Stderr = &bytes.Buffer{}
+	return app.Run(append([]string{"go-init"}, args...))
+}
@@ -0,0 +1,41 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/pkg/cli"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+)
+
+var consoleCliCommand = cli.Command{
+	Name: "console",
+	Usage: `
+Runs the service defined by the static and custom configurations at service/bin/launcher-static.yml and
+var/conf/launcher-custom.yml in the foreground attached to the terminal, for local development and debugging. The
+configuration is resolved exactly as by 'start', but the output of every process is written to the console whatever its
+outputMode, and the input of the console is forwarded to the primary process. The processes run in their own process
+group, so that Ctrl-C only reaches go-init, which stops the service as 'stop' does: running the preStop hooks,
+requesting the processes with a stop URL to stop, sending SIGTERM to the rest and SIGKILL to any that have not stopped
+within their stopTimeout, 240 seconds by default. Exits 0 once stopped by Ctrl-C or SIGTERM, with the exit code of the
+primary process if it exits by itself, or 1 writing an error message to stderr if the service could not be started.
+Arguments given after --, or otherwise by the GO_INIT_EXTRA_ARGS environment variable, are appended to the args of the
+primary process as by 'start'.`,
+	Action: audited(console),
+}
+
+func console(ctx cli.Context, svc *lib.Service) error {
+	return svc.Console()
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+)
+
+var envCliCommand = cli.Command{
+	Name: "env",
+	Usage: `
+Prints the environment, working directory and command line with which the primary process, or the process given by
+--process, of the service defined by the static and custom configurations at service/bin/launcher-static.yml and
+var/conf/launcher-custom.yml is started, without starting it. By default, prints a shell script that exports the
+environment, changes to the working directory and sets the positional parameters to the command line, so that
+'eval "$(go-init env)" && exec "$@"' runs the process as go-init would, e.g. in a 'docker exec' session or under a
+profiler. With --json, prints a JSON document instead. Sensitive values are printed as they are unless --redact is
+given. Exits 0 if the command was printed, otherwise exits 1 and writes an error message to stderr.`,
+	Flags: []flag.Flag{
+		flag.StringFlag{
+			Name:  processFlagName,
+			Usage: "The name of the process to print, defaulting to the primary process",
+		},
+		flag.BoolFlag{
+			Name:  jsonFlagName,
+			Usage: "Print the command as a JSON document",
+		},
+		flag.BoolFlag{
+			Name:  redactFlagName,
+			Usage: "Redact the values of sensitive environment variables and arguments",
+		},
+	},
+	Action: withService(printEnv),
+}
+
+const (
+	processFlagName = "process"
+	redactFlagName  = "redact"
+)
+
+func printEnv(ctx cli.Context, svc *lib.Service) error {
+	return svc.PrintEnv(ctx.String(processFlagName), lib.EnvOptions{
+		JSON:   ctx.Bool(jsonFlagName),
+		Redact: ctx.Bool(redactFlagName),
+	})
+}
@@ -0,0 +1,39 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitEnv_DefaultParameters(t *testing.T) {
+	assert.Equal(t, []flag.Flag{
+		flag.StringFlag{
+			Name:  "process",
+			Usage: "The name of the process to print, defaulting to the primary process",
+		},
+		flag.BoolFlag{
+			Name:  "json",
+			Usage: "Print the command as a JSON document",
+		},
+		flag.BoolFlag{
+			Name:  "redact",
+			Usage: "Redact the values of sensitive environment variables and arguments",
+		},
+	}, envCliCommand.Flags)
+}
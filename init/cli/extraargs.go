@@ -0,0 +1,35 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+// extraArgs are the arguments after -- on the command line of go-init, set by Run, which 'go-init start' and 'go-init
+// run' append to the args of the primary process. nil unless the command line has --.
+var extraArgs []string
+
+// Run runs the App with the given command line, of which the arguments after -- are the extra arguments of the primary
+// process rather than arguments of go-init.
+func Run(args []string) int {
+	args, extraArgs = splitExtraArgs(args)
+	return App().Run(args)
+}
+
+func splitExtraArgs(args []string) ([]string, []string) {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], append([]string{}, args[i+1:]...)
+		}
+	}
+	return args, nil
+}
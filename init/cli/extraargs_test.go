@@ -0,0 +1,41 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitExtraArgs(t *testing.T) {
+	for i, currCase := range []struct {
+		args      []string
+		wantArgs  []string
+		wantExtra []string
+	}{
+		{args: []string{"go-init", "start"}, wantArgs: []string{"go-init", "start"}, wantExtra: nil},
+		{
+			args:      []string{"go-init", "start", "--", "--migrate-only", "--", "x"},
+			wantArgs:  []string{"go-init", "start"},
+			wantExtra: []string{"--migrate-only", "--", "x"},
+		},
+		{args: []string{"go-init", "run", "--"}, wantArgs: []string{"go-init", "run"}, wantExtra: []string{}},
+	} {
+		args, extra := splitExtraArgs(currCase.args)
+		assert.Equal(t, currCase.wantArgs, args, "Case %d", i)
+		assert.Equal(t, currCase.wantExtra, extra, "Case %d", i)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+)
+
+var generateCliCommand = cli.Command{
+	Name:  "generate",
+	Usage: "Generates the files with which other service managers run the service.",
+	Subcommands: []cli.Command{
+		generateLaunchdCliCommand,
+		generateSystemdCliCommand,
+	},
+}
+
+const (
+	unitTypeFlagName   = "type"
+	executableFlagName = "executable"
+	labelFlagName      = "label"
+)
+
+var (
+	installDirFlag = flag.StringFlag{
+		Name:  dirFlagName,
+		Usage: "The directory the service is installed in, defaulting to the working directory",
+	}
+	executableFlag = flag.StringFlag{
+		Name: executableFlagName,
+		Usage: "The path of go-init in the installed service, relative to the directory of the service unless " +
+			"absolute, defaulting to that of the running go-init",
+	}
+)
+
+var generateSystemdCliCommand = cli.Command{
+	Name: "systemd",
+	Usage: `
+Writes a systemd unit file to stdout that starts the service defined by the static and custom configurations at
+service/bin/launcher-static.yml and var/conf/launcher-custom.yml with 'go-init start' and stops it with 'go-init stop',
+running as the user and group and with the rlimits and umask of the primary process. Exits 0 if the unit was written,
+otherwise exits 1 and writes an error message to stderr.`,
+	Flags: []flag.Flag{
+		flag.StringFlag{
+			Name:  unitTypeFlagName,
+			Value: lib.SystemdUnitTypeNotify,
+			Usage: "The type of the unit, either notify, with which go-init reports the primary process to systemd, " +
+				"or forking, with which systemd reads it from its pidfile",
+		},
+		installDirFlag,
+		executableFlag,
+	},
+	Action: withService(generateSystemd),
+}
+
+var generateLaunchdCliCommand = cli.Command{
+	Name: "launchd",
+	Usage: `
+Writes a launchd property list to stdout that runs the service defined by the static and custom configurations at
+service/bin/launcher-static.yml and var/conf/launcher-custom.yml in the foreground with 'go-init run', starting it
+again if it fails and writing its output to var/log/startup.log. Exits 0 if the property list was written, otherwise
+exits 1 and writes an error message to stderr.`,
+	Flags: []flag.Flag{
+		flag.StringFlag{
+			Name:  labelFlagName,
+			Usage: "The label of the launchd job, defaulting to the service name",
+		},
+		installDirFlag,
+		executableFlag,
+	},
+	Action: withService(generateLaunchd),
+}
+
+func generateSystemd(ctx cli.Context, svc *lib.Service) error {
+	return svc.GenerateSystemdUnit(installation(ctx), ctx.String(unitTypeFlagName))
+}
+
+func generateLaunchd(ctx cli.Context, svc *lib.Service) error {
+	return svc.GenerateLaunchdPlist(installation(ctx), ctx.String(labelFlagName))
+}
+
+// installation returns where the service is installed as given by the flags, and the path flags given to go-init, with
+// which the service manager runs it so that it acts on the same files.
+func installation(ctx cli.Context) lib.Installation {
+	installation := lib.Installation{
+		Dir:        ctx.String(dirFlagName),
+		Executable: ctx.String(executableFlagName),
+	}
+	for _, pathFlag := range pathFlags {
+		stringFlag := pathFlag.(flag.StringFlag)
+		if value := ctx.String(stringFlag.Name); value != stringFlag.Value {
+			installation.Args = append(installation.Args, "--"+stringFlag.Name, value)
+		}
+	}
+	return installation
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateLaunchd_DefaultParameters(t *testing.T) {
+	assert.Equal(t, []flag.Flag{
+		flag.StringFlag{
+			Name:  "label",
+			Usage: "The label of the launchd job, defaulting to the service name",
+		},
+		flag.StringFlag{
+			Name:  "dir",
+			Usage: "The directory the service is installed in, defaulting to the working directory",
+		},
+		flag.StringFlag{
+			Name: "executable",
+			Usage: "The path of go-init in the installed service, relative to the directory of the service unless " +
+				"absolute, defaulting to that of the running go-init",
+		},
+	}, generateLaunchdCliCommand.Flags)
+}
+
+func TestGenerateSystemd_DefaultParameters(t *testing.T) {
+	assert.Equal(t, []flag.Flag{
+		flag.StringFlag{
+			Name:  "type",
+			Value: "notify",
+			Usage: "The type of the unit, either notify, with which go-init reports the primary process to systemd, " +
+				"or forking, with which systemd reads it from its pidfile",
+		},
+		flag.StringFlag{
+			Name:  "dir",
+			Usage: "The directory the service is installed in, defaulting to the working directory",
+		},
+		flag.StringFlag{
+			Name: "executable",
+			Usage: "The path of go-init in the installed service, relative to the directory of the service unless " +
+				"absolute, defaulting to that of the running go-init",
+		},
+	}, generateSystemdCliCommand.Flags)
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+)
+
+var heapDumpCliCommand = cli.Command{
+	Name: "heapdump",
+	Usage: `
+Writes a heap dump of each running JVM of the service defined by the static and custom configurations at
+service/bin/launcher-static.yml and var/conf/launcher-custom.yml to ${DIR}/heapdump-${PROCESS}-${TIME}.hprof, using
+jcmd or jmap of the java installation of the process, and prints the path of each dump to stdout. A process is not
+dumped unless the directory has at least as much free space as the resident memory of the process. If process names
+are given, only those processes are dumped, each of which must be a java process.
+Exits:
+- 0 if every process was dumped
+- 1 if a process could not be dumped
+- 3 if a process is not running, in which case no process is dumped
+- 4 if the status of the processes cannot be determined
+If exit code is nonzero, writes an error message to stderr and var/log/startup.log.`,
+	Flags: []flag.Flag{
+		flag.StringFlag{
+			Name:  dirFlagName,
+			Usage: "The directory the heap dumps are written to, defaulting to that of the output files",
+		},
+		allFlag,
+		processesParam,
+	},
+	Action: audited(heapDump),
+}
+
+var gcInfoCliCommand = cli.Command{
+	Name: "gcinfo",
+	Usage: `
+Prints the heap and garbage collection statistics of each running JVM of the service defined by the static and custom
+configurations at service/bin/launcher-static.yml and var/conf/launcher-custom.yml to stdout, using
+'jcmd <pid> GC.heap_info', or 'jstat -gc <pid>' if the java installation of the process has no jcmd. If process names
+are given, only those processes are reported, each of which must be a java process.
+Exits with the same codes as heapdump.`,
+	Flags: []flag.Flag{
+		allFlag,
+		processesParam,
+	},
+	Action: withService(gcInfo),
+}
+
+const dirFlagName = "dir"
+
+func heapDump(ctx cli.Context, svc *lib.Service) error {
+	names, err := selectedNames(ctx)
+	if err != nil {
+		return cli.WithExitCode(4, err)
+	}
+	return svc.HeapDump(ctx.String(dirFlagName), names...)
+}
+
+func gcInfo(ctx cli.Context, svc *lib.Service) error {
+	names, err := selectedNames(ctx)
+	if err != nil {
+		return cli.WithExitCode(4, err)
+	}
+	return svc.GCInfo(names...)
+}
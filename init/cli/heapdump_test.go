@@ -0,0 +1,40 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitHeapDump_DefaultParameters(t *testing.T) {
+	assert.Equal(t, []flag.Flag{
+		flag.StringFlag{
+			Name:  "dir",
+			Usage: "The directory the heap dumps are written to, defaulting to that of the output files",
+		},
+		flag.BoolFlag{
+			Name:  "all",
+			Usage: "Act on all configured processes, the default if no process names are given",
+		},
+		flag.StringSlice{
+			Name:     "processes",
+			Usage:    "The names of the processes to act on, defaulting to all configured processes",
+			Optional: true,
+		},
+	}, heapDumpCliCommand.Flags)
+}
@@ -0,0 +1,70 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"strconv"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+)
+
+var logsCliCommand = cli.Command{
+	Name: "logs",
+	Usage: `
+Prints the last lines of the output files of the service defined by the static and custom configurations at
+service/bin/launcher-static.yml and var/conf/launcher-custom.yml, such as var/log/startup.log and
+var/log/${SUB_PROCESS}-startup.log, along with their separated stderr files. If process names are given, only the
+output files of those processes are printed. With --follow, keeps printing lines as they are written, continuing with
+the new file once an output file has been rotated, until interrupted. Processes whose outputMode is not file have no
+output files. Exits 1 and writes an error message to stderr if the output files could not be read.`,
+	Flags: []flag.Flag{
+		flag.BoolFlag{
+			Name:  followFlagName,
+			Alias: "f",
+			Usage: "Keep printing lines as they are written to the output files",
+		},
+		flag.StringFlag{
+			Name:  linesFlagName,
+			Alias: "n",
+			Value: "10",
+			Usage: "The number of lines to print from the end of each output file",
+		},
+		allFlag,
+		processesParam,
+	},
+	Action: withService(logs),
+}
+
+const (
+	followFlagName = "follow"
+	linesFlagName  = "lines"
+)
+
+func logs(ctx cli.Context, svc *lib.Service) error {
+	lines, err := strconv.Atoi(ctx.String(linesFlagName))
+	if err != nil || lines < 0 {
+		return cli.WithExitCode(1, errors.Errorf("--%s must be a non-negative number of lines, got '%s'",
+			linesFlagName, ctx.String(linesFlagName)))
+	}
+	names, err := selectedNames(ctx)
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	return svc.Logs(lines, ctx.Bool(followFlagName), names...)
+}
@@ -0,0 +1,47 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+var migrateConfigCliCommand = cli.Command{
+	Name: "migrate-config",
+	Usage: fmt.Sprintf(`
+Rewrites the static and custom configurations at service/bin/launcher-static.yml and var/conf/launcher-custom.yml,
+including the custom configurations they include and overlay, in configVersion %d. Comments and formatting are kept
+where the changes are to lines in block style, otherwise the configuration is written out anew without them. Files
+already in configVersion %d, and custom overlays without a configVersion, are left as they are. With --dry-run, prints
+each migrated file to stdout instead of rewriting it. Exits 0 if every file was migrated, otherwise exits 1 and writes
+an error message to stderr.`, launchlib.LatestConfigVersion, launchlib.LatestConfigVersion),
+	Flags: []flag.Flag{
+		flag.BoolFlag{
+			Name:  dryRunFlagName,
+			Usage: "Print each migrated file to stdout instead of rewriting it",
+		},
+	},
+	Action: withService(migrateConfig),
+}
+
+func migrateConfig(ctx cli.Context, svc *lib.Service) error {
+	return svc.MigrateConfig(ctx.Bool(dryRunFlagName))
+}
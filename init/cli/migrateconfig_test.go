@@ -0,0 +1,78 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitMigrateConfig_DefaultParameters(t *testing.T) {
+	assert.Equal(t, []flag.Flag{
+		flag.BoolFlag{
+			Name:  "dry-run",
+			Usage: "Print each migrated file to stdout instead of rewriting it",
+		},
+	}, migrateConfigCliCommand.Flags)
+}
+
+func TestMigrateConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-migrate-config")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	staticFile := filepath.Join(dir, "launcher-static.yml")
+	require.NoError(t, ioutil.WriteFile(staticFile, []byte(`configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+# Waits for the port to be released
+retryDelaySeconds: 5
+`), 0600))
+	customFile := filepath.Join(dir, "launcher-custom.yml")
+	require.NoError(t, ioutil.WriteFile(customFile, []byte("configType: executable\nconfigVersion: 1\n"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "launcher-custom.d"), 0755))
+	overlayFile := filepath.Join(dir, "launcher-custom.d", "a.yml")
+	require.NoError(t, ioutil.WriteFile(overlayFile, []byte("env:\n  A: b\n"), 0644))
+
+	code, stderr := runApp("--static-config", staticFile, "--custom-config", customFile, "migrate-config")
+	require.Equal(t, 0, code, stderr)
+
+	staticData, err := ioutil.ReadFile(staticFile)
+	require.NoError(t, err)
+	assert.Equal(t, `configType: executable
+configVersion: 2
+serviceName: primary
+executable: postgres
+# Waits for the port to be released
+retryDelay: 5s
+`, string(staticData))
+	info, err := os.Stat(staticFile)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	customData, err := ioutil.ReadFile(customFile)
+	require.NoError(t, err)
+	assert.Equal(t, "configType: executable\nconfigVersion: 2\n", string(customData))
+	overlayData, err := ioutil.ReadFile(overlayFile)
+	require.NoError(t, err)
+	assert.Equal(t, "env:\n  A: b\n", string(overlayData))
+}
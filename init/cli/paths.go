@@ -0,0 +1,103 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+const (
+	serviceRootFlagName  = "service-root"
+	staticConfigFlagName = "static-config"
+	customConfigFlagName = "custom-config"
+	pidfileFlagName      = "pidfile"
+	stateDirFlagName     = "state-dir"
+	outFlagName          = "out"
+)
+
+// defaultPaths are the defaults of the path flags.
+var defaultPaths = lib.DefaultPaths()
+
+var pathFlags = []flag.Flag{
+	flag.StringFlag{
+		Name: serviceRootFlagName,
+		Usage: "The directory relative paths are resolved against, the directory go-init is invoked from if it is not " +
+			"given",
+		EnvVar: "GO_INIT_SERVICE_ROOT",
+	},
+	flag.StringFlag{
+		Name:   staticConfigFlagName,
+		Value:  defaultPaths.StaticConfigFile,
+		Usage:  "The path of the static launcher configuration",
+		EnvVar: "GO_INIT_STATIC_CONFIG",
+	},
+	flag.StringFlag{
+		Name:   customConfigFlagName,
+		Value:  defaultPaths.CustomConfigFile,
+		Usage:  "The path of the custom launcher configuration",
+		EnvVar: "GO_INIT_CUSTOM_CONFIG",
+	},
+	flag.StringFlag{
+		Name:   pidfileFlagName,
+		Value:  defaultPaths.PidfileFormat,
+		Usage:  "The path of the pidfile of each process, in which %s is replaced by the process name",
+		EnvVar: "GO_INIT_PIDFILE",
+	},
+	flag.StringFlag{
+		Name:   stateDirFlagName,
+		Value:  defaultPaths.StateDir,
+		Usage:  "The directory of the state file of each process",
+		EnvVar: "GO_INIT_STATE_DIR",
+	},
+	flag.StringFlag{
+		Name:  outFlagName,
+		Value: defaultPaths.OutputFile,
+		Usage: "The path of the output file of the primary process, the output files of subProcesses are kept " +
+			"alongside it prefixed with their name",
+		EnvVar: "GO_INIT_OUT",
+	},
+}
+
+// newService returns the service at the paths given by the path flags, with a leading ~ expanded to the home
+// directory, which writes to the stdout and stderr of the App and appends the arguments after -- to those of the
+// primary process.
+func newService(ctx cli.Context) (*lib.Service, error) {
+	paths := make(map[string]string)
+	for _, name := range []string{staticConfigFlagName, customConfigFlagName, pidfileFlagName, stateDirFlagName,
+		outFlagName} {
+		path, err := launchlib.ExpandHome(ctx.String(name))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid --%s", name)
+		}
+		paths[name] = path
+	}
+	svc, err := lib.NewService(lib.Paths{
+		StaticConfigFile: paths[staticConfigFlagName],
+		CustomConfigFile: paths[customConfigFlagName],
+		PidfileFormat:    paths[pidfileFlagName],
+		StateDir:         paths[stateDirFlagName],
+		OutputFile:       paths[outFlagName],
+	}, ctx.App.Stdout, ctx.App.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	svc.ExtraArgs = extraArgs
+	return svc, nil
+}
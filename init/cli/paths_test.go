@@ -0,0 +1,97 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runApp(args ...string) (int, string) {
+	app := App()
+	stderr := &bytes.Buffer{}
+	app.Stdout = &bytes.Buffer{}
+	app.Stderr = stderr
+	return app.Run(append([]string{"go-init"}, args...)), stderr.String()
+}
+
+func TestPathFlags(t *testing.T) {
+	code, stderr := runApp("--static-config", "/nonexistent/static.yml", "--custom-config",
+		"/nonexistent/custom.yml", "--pidfile", "/run/pids/%s.pid", "--state-dir", "/run/state", "--out",
+		"/logs/out.log", "validate")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "Failed to read static config file: /nonexistent/static.yml")
+}
+
+func TestPathFlagsFromEnv(t *testing.T) {
+	require.NoError(t, os.Setenv("GO_INIT_STATIC_CONFIG", "/env/static.yml"))
+	defer func() {
+		require.NoError(t, os.Unsetenv("GO_INIT_STATIC_CONFIG"))
+	}()
+
+	code, stderr := runApp("validate")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "Failed to read static config file: /env/static.yml")
+}
+
+func TestPathFlagsServiceRoot(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	root, err := ioutil.TempDir("", "go-init-root")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(root))
+	}()
+	root, err = filepath.EvalSymlinks(root)
+	require.NoError(t, err)
+	require.NoError(t, os.Setenv("HOME", root))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "service", "bin"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "service", "bin", "launcher-static.yml"), []byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+`), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "custom.yml"), []byte("configVersion: ["), 0644))
+
+	// The static configuration is found relative to the service root, and the custom configuration in the home
+	// directory, which is then reported invalid.
+	code, stderr := runApp("--service-root", root, "--custom-config", "~/custom.yml", "validate")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "invalid custom config file "+filepath.Join(root, "custom.yml"))
+	rootWd, err := os.Getwd()
+	require.NoError(t, err)
+	assert.Equal(t, root, rootWd)
+
+	code, stderr = runApp("--service-root", filepath.Join(root, "missing"), "validate")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "failed to change to the service root")
+}
+
+func TestPathFlagsInvalidPidfile(t *testing.T) {
+	code, stderr := runApp("--pidfile", "/run/service.pid", "validate")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "pidfile format must contain %s exactly once")
+}
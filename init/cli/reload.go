@@ -0,0 +1,50 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+)
+
+var reloadCliCommand = cli.Command{
+	Name: "reload",
+	Usage: `
+Asks the running processes of the service defined by the static and custom configurations at
+service/bin/launcher-static.yml and var/conf/launcher-custom.yml to reload their configuration, by sending each the
+signal configured by its reloadSignal, SIGHUP by default. If process names are given, only those processes are
+signalled.
+Exits:
+- 0 if every process was signalled
+- 1 if a process could not be signalled
+- 3 if a process is not running, in which case no process is signalled
+- 4 if the status of the processes cannot be determined
+If exit code is nonzero, writes an error message to stderr and var/log/startup.log.`,
+	Flags: []flag.Flag{
+		allFlag,
+		processesParam,
+	},
+	Action: audited(reload),
+}
+
+func reload(ctx cli.Context, svc *lib.Service) error {
+	names, err := selectedNames(ctx)
+	if err != nil {
+		return cli.WithExitCode(4, err)
+	}
+	return svc.Reload(names...)
+}
@@ -0,0 +1,40 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/pkg/cli"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+)
+
+var rotateLogsCliCommand = cli.Command{
+	Name: "rotate-logs",
+	Usage: `
+Makes the running 'go-init supervise' of the service reopen the output files of its processes and its own, by sending
+it SIGUSR2, once they have been renamed by an external log rotation such as logrotate. Processes started by 'start'
+write to their output files directly, so cannot reopen them; use the outputRotation block of the static configuration
+or the copytruncate option of logrotate instead.
+Exits:
+- 0 if the supervisor was signalled
+- 1 if the supervisor could not be signalled
+- 3 if the supervisor is not running
+If exit code is nonzero, writes an error message to stderr and var/log/startup.log.`,
+	Action: audited(rotateLogs),
+}
+
+func rotateLogs(ctx cli.Context, svc *lib.Service) error {
+	return svc.RotateLogs()
+}
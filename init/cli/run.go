@@ -0,0 +1,48 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+)
+
+var runCliCommand = cli.Command{
+	Name: "run",
+	Usage: `
+Runs the service defined by the static and custom configurations at service/bin/launcher-static.yml and
+var/conf/launcher-custom.yml in the foreground, as expected of a container entrypoint. All output is written to stdout.
+SIGTERM and SIGINT are forwarded to every process, and once the primary process exits any remaining subProcesses are
+stopped. Exits with the exit code of the primary process, or 128 plus the signal number if it was killed by a signal.
+Exits 1 and writes an error message to stderr if the service could not be started.
+With --tee, the output of each process and of go-init is also written to the same files as by 'start', such as
+var/log/startup.log, unless the outputMode of the process is not file. Arguments given after --, or otherwise by the
+GO_INIT_EXTRA_ARGS environment variable, are appended to the args of the primary process as by 'start'.`,
+	Flags: []flag.Flag{
+		flag.BoolFlag{
+			Name:  teeFlagName,
+			Usage: "Also write the output of each process to its output file",
+		},
+	},
+	Action: audited(run),
+}
+
+const teeFlagName = "tee"
+
+func run(ctx cli.Context, svc *lib.Service) error {
+	return svc.Run(ctx.Bool(teeFlagName))
+}
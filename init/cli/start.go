@@ -15,16 +15,10 @@
 package cli
 
 import (
-	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
-	"strconv"
-
 	"github.com/palantir/pkg/cli"
-	"github.com/pkg/errors"
+	"github.com/palantir/pkg/cli/flag"
 
-	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/go-java-launcher/init/lib"
 )
 
 var startCliCommand = cli.Command{
@@ -32,59 +26,34 @@ var startCliCommand = cli.Command{
 	Usage: `
 Ensures the service defined by the static and custom configurations at service/bin/launcher-static.yml and
 var/conf/launcher-custom.yml is running and its outputs are redirecting to var/log/startup.log and other
-var/log/${SUB_PROCESS}-startup.log files. If successful, exits 0, otherwise exits 1 and writes an error message to
-stderr and var/log/startup.log.`,
-	Action: executeWithLoggers(start, NewTruncatingFirst()),
-}
-
-func start(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
-	serviceStatus, err := getServiceStatus(ctx, loggers)
-	if err != nil {
-		return logErrorAndReturnWithExitCode(ctx,
-			errors.Wrap(err, "failed to determine service status to determine what commands to run"), 1)
-	}
-	if err := startService(ctx, serviceStatus.notRunningCmds); err != nil {
-		return logErrorAndReturnWithExitCode(ctx, errors.Wrap(err, "failed to start service"), 1)
-	}
-	return nil
+var/log/${SUB_PROCESS}-startup.log files. If process names are given, only those processes are started. Processes
+with a configured healthCheck must pass it, and processes with a configured startupWindow must not exit within it,
+after being started again up to startRetries times, before the service is considered started. If successful, exits 0.
+If a health check does not pass, exits 7, otherwise exits 1, and writes an error message to stderr and
+var/log/startup.log.
+With --dry-run, prints the command line, working directory and environment of each process to stdout instead of
+starting it. Arguments given after --, or otherwise by the GO_INIT_EXTRA_ARGS environment variable separated by
+whitespace, are appended to the args of the primary process if it is started.`,
+	Flags: []flag.Flag{
+		flag.BoolFlag{
+			Name:  dryRunFlagName,
+			Usage: "Print the command of each process to stdout instead of starting it",
+		},
+		allFlag,
+		processesParam,
+	},
+	Action: audited(start),
 }
 
-func startService(ctx cli.Context, notRunningCmds map[string]CommandContext) error {
-	for name, cmd := range notRunningCmds {
-		if err := startCommand(ctx, cmd); err != nil {
-			return errors.Wrapf(err, "failed to start command '%s'", name)
-		}
-
-		pidfile := fmt.Sprintf(pidfileFormat, name)
-		if err := os.MkdirAll(filepath.Dir(pidfile), 0755); err != nil {
-			return errors.Wrapf(err, "unable to create pidfile directory.")
-		}
-
-		if err := ioutil.WriteFile(pidfile, []byte(strconv.Itoa(cmd.Command.Process.Pid)), 0644); err != nil {
-			return errors.Wrapf(err, "failed to save pid to file for command '%s'", name)
-		}
-	}
-	return nil
-}
-
-func startCommand(ctx cli.Context, cmdCtx CommandContext) error {
-	if err := launchlib.MkDirs(cmdCtx.Dirs, ctx.App.Stdout); err != nil {
-		return errors.Wrap(err, "failed to create directories")
-	}
+const dryRunFlagName = "dry-run"
 
-	logger, err := cmdCtx.Logger()
+func start(ctx cli.Context, svc *lib.Service) error {
+	names, err := selectedNames(ctx)
 	if err != nil {
-		return err
+		return cli.WithExitCode(1, err)
 	}
-	defer func() {
-		if cErr := logger.Close(); cErr != nil {
-			fmt.Fprintf(ctx.App.Stdout, "failed to close logger for command")
-		}
-	}()
-	cmdCtx.Command.Stdout = logger
-	cmdCtx.Command.Stderr = logger
-	if err := cmdCtx.Command.Start(); err != nil {
-		return errors.Wrap(err, "failed to start command")
+	if ctx.Bool(dryRunFlagName) {
+		return svc.DryRunStart(names...)
 	}
-	return nil
+	return svc.Start(names...)
 }
@@ -15,13 +15,10 @@
 package cli
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/palantir/pkg/cli"
-	"github.com/pkg/errors"
+	"github.com/palantir/pkg/cli/flag"
 
-	"github.com/palantir/go-java-launcher/launchlib"
+	"github.com/palantir/go-java-launcher/init/lib"
 )
 
 var statusCliCommand = cli.Command{
@@ -29,99 +26,49 @@ var statusCliCommand = cli.Command{
 	Usage: `
 Determines the status of the service defined by the static and custom configurations at service/bin/launcher-static.yml
 and var/conf/launcher-custom.yml.
-Exits:
+Exits, following the status action of LSB init scripts unless another scheme is set by statusExitCodes in the static
+configuration:
 - 0 if all of its processes are running
 - 1 if at least one process is not running but there is a record of processes having been started
 - 3 if no processes are running and there is no record of processes having been started
 - 4 if the status cannot be determined
-If exit code is nonzero, writes an error message to stderr and var/log/startup.log.`,
-	Action: executeWithLoggers(status, NewAlwaysAppending()),
-}
-
-var (
-	Running = ServiceState{
-		Description: "Running",
-		Applicable: func(serviceStatus *serviceStatus, err error) bool {
-			return err == nil && len(serviceStatus.notRunningCmds) == 0
-		},
-		ExitStatus: func(serviceStatus *serviceStatus, err error) (int, error) {
-			return 0, nil
-		},
-	}
-	Dead = ServiceState{
-		Description: "Process dead but pidfile exists.",
-		Applicable: func(serviceStatus *serviceStatus, err error) bool {
-			return err == nil && len(serviceStatus.notRunningCmds) > 0 && len(serviceStatus.writtenPids) > 0
-		},
-		ExitStatus: func(serviceStatus *serviceStatus, err error) (int, error) {
-			return 1, errors.Errorf("commands '%v' are not running but there is a record of commands '%v' "+
-				"having been started", commandNames(serviceStatus.notRunningCmds), serviceStatus.writtenPids)
-		},
-	}
-	NotRunning = ServiceState{
-		Description: "Service not running",
-		Applicable: func(serviceStatus *serviceStatus, err error) bool {
-			return err == nil && len(serviceStatus.notRunningCmds) > 0 && len(serviceStatus.writtenPids) == 0
-		},
-		ExitStatus: func(serviceStatus *serviceStatus, err error) (int, error) {
-			return 3, errors.Errorf("commands '%v' are not running", commandNames(serviceStatus.notRunningCmds))
-		},
-	}
-	ErrorState = ServiceState{
-		Description: "Failed to determine service status",
-		Applicable: func(serviceStatus *serviceStatus, err error) bool {
-			return err != nil
+- 150 if at least one process is not running as 'supervise' gave up restarting it, having been restarted more than
+  maxRestarts times within restartWindow
+With statusExitCodes monit, exits 1 instead of 3, 4 and 150, and with s6, exits 1 instead of 3 and 150 and 111 instead
+of 4.
+If exit code is nonzero, writes an error message to stderr and var/log/startup.log.
+With --json, prints a machine-readable document describing each process to stdout instead, and with --verbose, also
+prints the uptime, resident memory, CPU usage, thread count and open files of each running process.
+If the configuration of a running process has changed since it was started, so that it must be restarted to apply the
+changes, writes a warning to stderr, or with --json, sets configChanged for the process.
+If process names are given, only the status of those processes is determined.`,
+	Flags: []flag.Flag{
+		flag.BoolFlag{
+			Name:  jsonFlagName,
+			Usage: "Print the status of each process as a JSON document to stdout",
 		},
-		ExitStatus: func(serviceStatus *serviceStatus, err error) (int, error) {
-			if err != nil {
-				return 4, errors.Wrap(err, "failed to determine service status")
-			}
-			return 4, errors.Errorf("failed to determine service status")
+		flag.BoolFlag{
+			Name:  verboseFlagName,
+			Usage: "Print the status and resource usage of each process to stdout",
 		},
-	}
-)
-
-func status(ctx cli.Context, loggers launchlib.ServiceLoggers) error {
-	// Executed with logging for errors, however we discard the verbose logging of getServiceStatus
-	serviceStatus, err := getServiceStatus(ctx, &DevNullLoggers{})
-	var matched *ServiceState
-	for _, state := range []ServiceState{ErrorState, NotRunning, Dead, Running} {
-		if state.Applicable(serviceStatus, err) {
-			matched = &state
-			break
-		}
-	}
-
-	// If no state has matched, default to error state
-	if matched == nil {
-		matched = &ErrorState
-	}
-
-	code, err := matched.ExitStatus(serviceStatus, err)
-	if code != 0 {
-		fmt.Fprintln(os.Stderr, matched.Description)
-		if err != nil {
-			return logErrorAndReturnWithExitCode(ctx, err, code)
-		}
-		// Non-zero exit codes can only be reported through cli.WithExitCode which must include an errors, though that
-		// error can be empty.  Returning no error gives an exit code of 0
-		return cli.WithExitCode(code, errors.New(""))
-	}
-
-	fmt.Println(matched.Description)
-	return nil
+		allFlag,
+		processesParam,
+	},
+	Action: withService(status),
 }
 
-type ServiceState struct {
-	Description string
-	Applicable  func(serviceStatus *serviceStatus, err error) bool
-	ExitStatus  func(serviceStatus *serviceStatus, err error) (int, error)
-}
+const (
+	jsonFlagName    = "json"
+	verboseFlagName = "verbose"
+)
 
-func commandNames(commands map[string]CommandContext) []string {
-	names := make([]string, 0, len(commands))
-	for name := range commands {
-		names = append(names, name)
+func status(ctx cli.Context, svc *lib.Service) error {
+	names, err := selectedNames(ctx)
+	if err != nil {
+		return cli.WithExitCode(4, err)
 	}
-	return names
+	return svc.PrintStatus(lib.StatusOptions{
+		JSON:    ctx.Bool(jsonFlagName),
+		Verbose: ctx.Bool(verboseFlagName),
+	}, names...)
 }
@@ -21,7 +21,24 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-// To prevent accidental changes to parameter default values
 func TestInitStatus_DefaultParameters(t *testing.T) {
-	assert.Equal(t, []flag.Flag(nil), statusCliCommand.Flags)
+	assert.Equal(t, []flag.Flag{
+		flag.BoolFlag{
+			Name:  "json",
+			Usage: "Print the status of each process as a JSON document to stdout",
+		},
+		flag.BoolFlag{
+			Name:  "verbose",
+			Usage: "Print the status and resource usage of each process to stdout",
+		},
+		flag.BoolFlag{
+			Name:  "all",
+			Usage: "Act on all configured processes, the default if no process names are given",
+		},
+		flag.StringSlice{
+			Name:     "processes",
+			Usage:    "The names of the processes to act on, defaulting to all configured processes",
+			Optional: true,
+		},
+	}, statusCliCommand.Flags)
 }
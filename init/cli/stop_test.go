@@ -21,7 +21,16 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-// To prevent accidental changes to parameter default values
 func TestInitStop_DefaultParameters(t *testing.T) {
-	assert.Equal(t, []flag.Flag(nil), stopCliCommand.Flags)
+	assert.Equal(t, []flag.Flag{
+		flag.BoolFlag{
+			Name:  "all",
+			Usage: "Act on all configured processes, the default if no process names are given",
+		},
+		flag.StringSlice{
+			Name:     "processes",
+			Usage:    "The names of the processes to act on, defaulting to all configured processes",
+			Optional: true,
+		},
+	}, stopCliCommand.Flags)
 }
@@ -0,0 +1,38 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/pkg/cli"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+)
+
+var superviseCliCommand = cli.Command{
+	Name: "supervise",
+	Usage: `
+Runs the service defined by the static and custom configurations at service/bin/launcher-static.yml and
+var/conf/launcher-custom.yml in the foreground, restarting any process that exits with an exponential backoff as
+configured by the 'supervision' block of the static configuration. Outputs are redirected as for 'start', and the
+number of restarts and last exit code of each process are reported by 'status --json'. Exits 0 once stopped by SIGTERM,
+SIGINT or 'stop', otherwise exits 1 and writes an error message to stderr and var/log/startup.log if the service could
+not be started or a process was restarted more than maxRestarts times within restartWindow, in which case the process
+is recorded as crash-looping for 'status' until it is next started.`,
+	Action: audited(supervise),
+}
+
+func supervise(ctx cli.Context, svc *lib.Service) error {
+	return svc.Supervise()
+}
@@ -0,0 +1,35 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/pkg/cli"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+)
+
+var validateCliCommand = cli.Command{
+	Name: "validate",
+	Usage: `
+Validates the static and custom configurations at service/bin/launcher-static.yml and var/conf/launcher-custom.yml
+without starting anything, checking for unknown keys, invalid values, and java installations, classpath entries, jars,
+agents and executables that do not exist. Exits 0 if the configuration is valid, otherwise exits 1 and writes each of
+the problems found to stderr.`,
+	Action: withService(validate),
+}
+
+func validate(ctx cli.Context, svc *lib.Service) error {
+	return svc.Validate()
+}
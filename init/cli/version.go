@@ -0,0 +1,54 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+var versionCliCommand = cli.Command{
+	Name: "version",
+	Usage: `
+Prints the version of go-init, the git commit and date it was built from, and the configVersions of the launcher
+configuration that it reads. With --json, prints a JSON document instead, so that deployment tooling can check that
+the launcher supports a configuration before relying on it. Exits 0.`,
+	Flags: []flag.Flag{
+		flag.BoolFlag{
+			Name:  jsonFlagName,
+			Usage: "Print the version as a JSON document",
+		},
+	},
+	Action: printVersion,
+}
+
+func printVersion(ctx cli.Context) error {
+	info := launchlib.GetBuildInfo()
+	if !ctx.Bool(jsonFlagName) {
+		info.Write(ctx.App.Stdout, ctx.App.Name)
+		return nil
+	}
+	encoder := json.NewEncoder(ctx.App.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(info); err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to print version"))
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/palantir/pkg/cli/flag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestInitVersion_DefaultParameters(t *testing.T) {
+	assert.Equal(t, []flag.Flag{
+		flag.BoolFlag{
+			Name:  "json",
+			Usage: "Print the version as a JSON document",
+		},
+	}, versionCliCommand.Flags)
+}
+
+func TestPrintVersionJSON(t *testing.T) {
+
+	stdout := &bytes.Buffer{}
+	app := App()
+	app.Stdout = stdout
+	app.Stderr = &bytes.Buffer{}
+	require.Equal(t, 0, app.Run([]string{"go-init", "version", "--json"}))
+
+	var info launchlib.BuildInfo
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &info))
+	assert.Equal(t, launchlib.GetBuildInfo(), info)
+}
@@ -0,0 +1,40 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/palantir/pkg/cli"
+
+	"github.com/palantir/go-java-launcher/init/lib"
+)
+
+var windowsServiceCliCommand = cli.Command{
+	Name: "windows-service",
+	Usage: `
+Runs the service defined by the static and custom configurations at service/bin/launcher-static.yml and
+var/conf/launcher-custom.yml as a Windows service, which must be started by the service control manager once registered
+with it, for example by 'sc.exe create my-service binPath= "C:\my-service\service\bin\windows-amd64\go-init.exe
+--service-root C:\my-service windows-service"'. Outputs are redirected as for 'start'. Stopping the Windows service, or
+shutting down Windows, stops the service as 'stop' does: running the preStop hooks, requesting the processes with a stop
+URL to stop, and killing the rest, as Windows cannot send them SIGTERM. If the primary process exits by itself, the
+Windows service stops with its exit code as the service-specific exit code. Exits 1 and writes an error message to
+var/log/startup.log if not run by the service control manager, or if the service could not be started. Only supported
+on Windows.`,
+	Action: audited(windowsService),
+}
+
+func windowsService(ctx cli.Context, svc *lib.Service) error {
+	return svc.RunWindowsService()
+}
@@ -0,0 +1,50 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowsService_OnlyOnWindows(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-windows-service")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	executable := filepath.Join(dir, "postgres")
+	require.NoError(t, ioutil.WriteFile(executable, []byte("#!/bin/sh\nsleep 10\n"), 0755))
+	staticFile := filepath.Join(dir, "launcher-static.yml")
+	require.NoError(t, ioutil.WriteFile(staticFile, []byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: `+executable+`
+`), 0644))
+
+	code, stderr := runApp("--static-config", staticFile, "--custom-config", filepath.Join(dir, "launcher-custom.yml"),
+		"--pidfile", filepath.Join(dir, "run", "%s.pid"), "--state-dir", filepath.Join(dir, "state"), "--out",
+		filepath.Join(dir, "startup.log"), "windows-service")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "failed to run windows service: windows-service is only supported on windows")
+	_, err = os.Stat(filepath.Join(dir, "run", "primary.pid"))
+	assert.True(t, os.IsNotExist(err), "the service was started")
+}
@@ -0,0 +1,119 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+)
+
+const auditLogFile = "launcher-audit.log"
+
+// auditRecord is a line of the audit log, recorded for each invocation of a command that acts on the processes of the
+// service.
+type auditRecord struct {
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	Args     []string  `json:"args"`
+	User     string    `json:"user"`
+	SudoUser string    `json:"sudoUser,omitempty"`
+	// Pids are the pids of the processes that are running according to their pidfiles once the command has finished.
+	Pids           map[string]int `json:"pids"`
+	ExitCode       int            `json:"exitCode"`
+	Error          string         `json:"error,omitempty"`
+	DurationMillis int64          `json:"durationMillis"`
+}
+
+// Audit runs the operation of the given name and arguments, recording it in the audit log in the same directory as the
+// output files. Failing to record it is reported on stderr, but does not fail it.
+func (svc *Service) Audit(action string, args []string, operation func() error) error {
+	started := Clock.Now()
+	err := operation()
+	record := auditRecord{
+		Time:           started,
+		Action:         action,
+		Args:           args,
+		User:           currentUser(),
+		SudoUser:       os.Getenv("SUDO_USER"),
+		Pids:           svc.runningPids(),
+		DurationMillis: int64(Clock.Now().Sub(started) / time.Millisecond),
+	}
+	if err != nil {
+		record.ExitCode = 1
+		if exitCoder, ok := err.(cli.ExitCoder); ok {
+			record.ExitCode = exitCoder.ExitCode()
+		}
+		record.Error = err.Error()
+	}
+	if auditErr := svc.writeAuditRecord(record); auditErr != nil {
+		fmt.Fprintln(svc.stderr, "failed to record invocation in audit log:", auditErr)
+	}
+	return err
+}
+
+func (svc *Service) writeAuditRecord(record auditRecord) error {
+	recordBytes, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize audit record")
+	}
+	if err := os.MkdirAll(svc.logDir, svc.dirMode); err != nil {
+		return errors.Wrap(err, "unable to create audit log directory")
+	}
+	file, err := os.OpenFile(filepath.Join(svc.logDir, auditLogFile), appendOutputFileFlag, svc.fileMode)
+	if err != nil {
+		return errors.Wrap(err, "failed to open audit log")
+	}
+	// A single write of a line to a file opened for appending is not interleaved with those of other invocations.
+	if _, err := file.Write(append(recordBytes, '\n')); err != nil {
+		_ = file.Close()
+		return errors.Wrap(err, "failed to write audit log")
+	}
+	return file.Close()
+}
+
+// currentUser returns the name of the user running go-init, or its uid if it has no name.
+func currentUser() string {
+	if current, err := user.Current(); err == nil {
+		return current.Username
+	}
+	return strconv.Itoa(os.Getuid())
+}
+
+// runningPids returns the pids of the processes with a pidfile that are running.
+func (svc *Service) runningPids() map[string]int {
+	pids := map[string]int{}
+	pidfiles, err := filepath.Glob(fmt.Sprintf(svc.pidfileFormat, "*"))
+	if err != nil {
+		return pids
+	}
+	// The pidfile format contains %s exactly once, in place of the process name.
+	affixes := strings.SplitN(svc.pidfileFormat, "%s", 2)
+	for _, pidfile := range pidfiles {
+		name := strings.TrimSuffix(strings.TrimPrefix(pidfile, affixes[0]), affixes[1])
+		if pid, proc, err := svc.getCmdProcess(name); err == nil && proc != nil {
+			pids[name] = *pid
+		}
+	}
+	return pids
+}
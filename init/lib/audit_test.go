@@ -0,0 +1,74 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudited(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-audit")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc := newTestService(t)
+	svc.logDir = filepath.Join(dir, "log")
+	svc.pidfileFormat, svc.statefileFormat = filepath.Join(dir, "%s.pid"), filepath.Join(dir, "%s.state")
+
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	require.NoError(t, svc.Audit("start", []string{"start"}, func() error {
+		return svc.writePidfile("primary", cmd.Process.Pid)
+	}))
+	err = svc.Audit("stop", []string{"stop"}, func() error {
+		return cli.WithExitCode(4, errors.New("failed to stop service"))
+	})
+	require.Error(t, err)
+
+	content, err := ioutil.ReadFile(filepath.Join(svc.logDir, "launcher-audit.log"))
+	require.NoError(t, err)
+	var records []auditRecord
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	for decoder.More() {
+		var record auditRecord
+		require.NoError(t, decoder.Decode(&record))
+		records = append(records, record)
+	}
+	require.Len(t, records, 2)
+	assert.Equal(t, "start", records[0].Action)
+	assert.Equal(t, map[string]int{"primary": cmd.Process.Pid}, records[0].Pids)
+	assert.Equal(t, 0, records[0].ExitCode)
+	assert.NotEmpty(t, records[0].User)
+	assert.Equal(t, "stop", records[1].Action)
+	assert.Equal(t, 4, records[1].ExitCode)
+	assert.Equal(t, "failed to stop service", records[1].Error)
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// Check writes a line describing the status of the processes of the given names, or of all processes if none are given,
+// to stdout, as 'go-init check' does, returning an error with its exit code unless they are all running. Only reads the
+// configuration, pid and state files. If health is set, each running process with a healthCheck is checked once.
+func (svc *Service) Check(health bool, processes ...string) error {
+	code, description := svc.checkService(health, processes)
+	fmt.Fprintln(svc.stdout, description)
+	if code != 0 {
+		return cli.WithExitCode(code, errors.New(""))
+	}
+	return nil
+}
+
+// checkService returns the exit code of check and a description of the status of the service. Unlike status, the
+// commands of the processes are not compiled, as that writes the argument files of the JVMs.
+func (svc *Service) checkService(health bool, names []string) (int, string) {
+	var serviceStatus *serviceStatus
+	cmds, err := svc.checkedCommands(names)
+	if err == nil {
+		serviceStatus, err = svc.getCommandsStatus(cmds)
+	}
+	for _, state := range []struct {
+		state ServiceState
+		code  int
+	}{
+		{state: ErrorState, code: 2},
+		{state: CrashLooping, code: 4},
+		{state: NotRunning, code: 3},
+		{state: Dead, code: 1},
+	} {
+		if state.state.Applicable(serviceStatus, err) {
+			_, reason := state.state.ExitStatus(serviceStatus, err)
+			return state.code, reason.Error()
+		}
+	}
+
+	if health {
+		names := make([]string, 0, len(serviceStatus.runningProcs))
+		for name := range serviceStatus.runningProcs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if healthCheck := cmds[name].HealthCheck; healthCheck != nil {
+				if err := checkHealth(healthCheck.WithDefaults()); err != nil {
+					return 1, fmt.Sprintf("Process '%s' failed its health check: %v", name, err)
+				}
+			}
+		}
+	}
+	return 0, Running.Description
+}
+
+// checkedCommands returns the processes of the given names, or all processes if none are given, with their health
+// checks, read from the static configuration without reporting its warnings.
+func (svc *Service) checkedCommands(names []string) (map[string]CommandContext, error) {
+	staticConfig, _, err := launchlib.GetConfigsFromFiles(svc.launcherStaticFile, svc.launcherCustomFile,
+		ioutil.Discard)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read static and custom configuration files")
+	}
+	cmds := map[string]CommandContext{
+		staticConfig.ServiceName: {HealthCheck: staticConfig.HealthCheck, Primary: true},
+	}
+	for name, subProcess := range staticConfig.SubProcesses {
+		cmds[name] = CommandContext{HealthCheck: subProcess.HealthCheck}
+	}
+	return selectNamedCommands(names, cmds)
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-check")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	// A port that nothing listens on, so that the health check of the primary process fails.
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+	staticFile := filepath.Join(dir, "launcher-static.yml")
+	require.NoError(t, ioutil.WriteFile(staticFile, []byte(fmt.Sprintf(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+healthCheck:
+  port: %d
+`, port)), 0644))
+
+	exited := exec.Command("true")
+	require.NoError(t, exited.Run())
+	for i, currCase := range []struct {
+		pid          int
+		crashLooping bool
+		health       bool
+		processes    []string
+		want         int
+	}{
+		{want: 3},
+		{pid: exited.Process.Pid, want: 1},
+		{pid: exited.Process.Pid, crashLooping: true, want: 4},
+		{pid: os.Getpid(), want: 0},
+		{pid: os.Getpid(), health: true, want: 1},
+		{pid: os.Getpid(), processes: []string{"sidecar"}, want: 2},
+	} {
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, "run")), "Case %d", i)
+		require.NoError(t, os.RemoveAll(filepath.Join(dir, "state")), "Case %d", i)
+		svc, err := NewService(Paths{
+			StaticConfigFile: staticFile,
+			CustomConfigFile: filepath.Join(dir, "launcher-custom.yml"),
+			PidfileFormat:    filepath.Join(dir, "run", "%s.pid"),
+			StateDir:         filepath.Join(dir, "state"),
+			OutputFile:       filepath.Join(dir, "log", "startup.log"),
+		}, &bytes.Buffer{}, &bytes.Buffer{})
+		require.NoError(t, err, "Case %d", i)
+		if currCase.pid != 0 {
+			require.NoError(t, svc.writePidfile("primary", currCase.pid), "Case %d", i)
+		}
+		if currCase.crashLooping {
+			require.NoError(t, svc.writeProcessState("primary", processState{CrashLooping: true}), "Case %d", i)
+		}
+
+		var code int
+		if err := svc.Check(currCase.health, currCase.processes...); err != nil {
+			code = err.(cli.ExitCoder).ExitCode()
+		}
+		assert.Equal(t, currCase.want, code, "Case %d", i)
+		_, err = os.Stat(filepath.Join(dir, "log"))
+		assert.True(t, os.IsNotExist(err), "Case %d: check wrote to the log directory", i)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// Console runs the service in the foreground attached to the terminal as 'go-init console' does, until it is stopped by
+// SIGINT or SIGTERM or its primary process exits, returning an error with the exit code of the primary process if it
+// exits by itself.
+func (svc *Service) Console() error {
+	serviceStatus, err := svc.getServiceStatus(launchlib.NewSimpleWriterLogger(svc.stdout))
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(
+			errors.Wrap(err, "failed to determine service status to determine what commands to run"), 1)
+	}
+	if len(serviceStatus.runningProcs) > 0 {
+		return svc.logErrorAndReturnWithExitCode(errors.Errorf("commands '%v' are already running",
+			processNames(serviceStatus.runningProcs)), 1)
+	}
+	svc.appendExtraArgs(svc.stdout, serviceStatus.notRunningCmds)
+	if err := launchlib.VerifyFiles(svc.verifiedFiles, svc.stdout); err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+	if err := svc.runPreflightChecks(svc.stdout, serviceStatus.notRunningCmds); err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+
+	for name, cmd := range serviceStatus.notRunningCmds {
+		// go-init remains to stop the processes, so the primary process is never exec'd.
+		cmd.OutputMode = launchlib.OutputModeConsole
+		cmd.Exec = false
+		detachFromTerminalSignals(cmd.Command)
+		if cmd.Primary {
+			closeStdin, err := forwardStdin(cmd.Command)
+			if err != nil {
+				return svc.logErrorAndReturnWithExitCode(err, 1)
+			}
+			defer closeStdin()
+		}
+		serviceStatus.notRunningCmds[name] = cmd
+	}
+
+	signals, stopSignals := captureSignals()
+	defer stopSignals()
+
+	code, err := svc.runService(serviceStatus.notRunningCmds, signals, true)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(errors.Wrap(err, "failed to run service"), 1)
+	}
+	if code != 0 {
+		return cli.WithExitCode(code, errors.New(""))
+	}
+	return nil
+}
+
+// detachFromTerminalSignals runs the command in its own process group, so that the signals sent by the terminal to its
+// foreground process group, such as SIGINT on Ctrl-C, only reach go-init.
+func detachFromTerminalSignals(cmd *exec.Cmd) {
+	inOwnProcessGroup(cmd)
+}
+
+// forwardStdin copies the stdin of go-init to that of the command through a pipe, as the terminal would stop a process
+// outside of its foreground process group that reads from it directly. Returns a function that closes the end of the
+// pipe held by go-init.
+func forwardStdin(cmd *exec.Cmd) (func(), error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create pipe for stdin")
+	}
+	cmd.Stdin = reader
+	go func() {
+		_, _ = io.Copy(writer, os.Stdin)
+		_ = writer.Close()
+	}()
+	return func() {
+		_ = reader.Close()
+	}, nil
+}
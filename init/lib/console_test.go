@@ -0,0 +1,74 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestRunServiceStopOnSignal(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-console")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	svc.setPaths(Paths{
+		StaticConfigFile: svc.launcherStaticFile,
+		CustomConfigFile: svc.launcherCustomFile,
+		PidfileFormat:    filepath.Join(dir, "%s.pid"),
+		StateDir:         dir,
+		OutputFile:       filepath.Join(dir, "startup.log"),
+	})
+
+	stdout := &bytes.Buffer{}
+	svc.stdout = stdout
+	signals := make(chan os.Signal, 1)
+	signals <- syscall.SIGINT
+	done := make(chan int)
+	go func() {
+		code, err := svc.runService(map[string]CommandContext{
+			"primary": {
+				Command:    exec.Command("/bin/sleep", "60"),
+				OutputMode: launchlib.OutputModeConsole,
+				Primary:    true,
+			},
+		}, signals, true)
+		assert.NoError(t, err)
+		done <- code
+	}()
+
+	select {
+	case code := <-done:
+		assert.Equal(t, 0, code)
+	case <-time.After(30 * time.Second):
+		t.Fatal("service was not stopped on signal")
+	}
+	assert.Contains(t, stdout.String(), "Received signal interrupt, stopping processes '[primary]'")
+	_, err = os.Stat(filepath.Join(dir, "primary.pid"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,57 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package lib
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardStdin(t *testing.T) {
+	stdinReader, stdinWriter, err := os.Pipe()
+	require.NoError(t, err)
+	originalStdin := os.Stdin
+	os.Stdin = stdinReader
+	defer func() {
+		os.Stdin = originalStdin
+		_ = stdinWriter.Close()
+	}()
+
+	cmd := exec.Command("/bin/sh", "-c", "read line; echo \"got $line\"")
+	stdout := &bytes.Buffer{}
+	cmd.Stdout = stdout
+	detachFromTerminalSignals(cmd)
+	closeStdin, err := forwardStdin(cmd)
+	require.NoError(t, err)
+	defer closeStdin()
+	require.NoError(t, cmd.Start())
+	pgid, err := syscall.Getpgid(cmd.Process.Pid)
+	require.NoError(t, err)
+	assert.Equal(t, cmd.Process.Pid, pgid)
+
+	_, err = stdinWriter.Write([]byte("hello\n"))
+	require.NoError(t, err)
+	require.NoError(t, cmd.Wait())
+	assert.Equal(t, "got hello\n", stdout.String())
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// daemonizeTimeout is how long a command that daemonizes is given to exit and leave its service process running.
+	daemonizeTimeout = 30 * time.Second
+	// daemonizePollInterval is how often the children of go-init are listed for the process left running.
+	daemonizePollInterval = 100 * time.Millisecond
+)
+
+// adoptDaemon waits for the started command, which daemonizes, to exit and replaces its process with the one it left
+// running, which was re-parented to go-init as its child subreaper and so can be waited on through the command. That
+// process is the only running child of go-init started since the command, once found twice in a row, as the
+// intermediate processes of a double fork exit shortly after they are re-parented.
+func adoptDaemon(cmd *exec.Cmd) error {
+	startTicks, err := processStartTicks(cmd.Process.Pid)
+	if err != nil {
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() {
+		// Waiting on the process rather than the command leaves the command to be waited on for the adopted process.
+		state, err := cmd.Process.Wait()
+		if err == nil && !state.Success() {
+			err = errors.Errorf("command exited with %v before daemonizing", state)
+		}
+		exited <- err
+	}()
+
+	timer := Clock.NewTimer(daemonizeTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-exited:
+		if err != nil {
+			return err
+		}
+	case <-timer.Chan():
+		_ = cmd.Process.Kill()
+		return errors.Errorf("command did not exit within %v to daemonize", daemonizeTimeout)
+	}
+
+	ticker := Clock.NewTicker(daemonizePollInterval)
+	defer ticker.Stop()
+	candidate := 0
+	for {
+		select {
+		case <-ticker.Chan():
+			children, err := orphanedChildren(startTicks)
+			if err != nil {
+				return err
+			}
+			if len(children) != 1 {
+				candidate = 0
+				continue
+			}
+			if children[0] != candidate {
+				candidate = children[0]
+				continue
+			}
+			proc, err := os.FindProcess(candidate)
+			if err != nil {
+				return errors.Wrapf(err, "failed to find process %d left running by command", candidate)
+			}
+			cmd.Process = proc
+			return nil
+		case <-timer.Chan():
+			return errors.Errorf("command left no single process running within %v", daemonizeTimeout)
+		}
+	}
+}
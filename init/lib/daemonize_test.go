@@ -0,0 +1,55 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"os/exec"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdoptDaemon(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("child subreapers are only supported on Linux")
+	}
+	require.NoError(t, becomeSubreaper())
+
+	cmd := exec.Command("/bin/sh", "-c", `(/bin/sh -c "sleep 1; exit 3" &); exit 0`)
+	require.NoError(t, cmd.Start())
+	wrapperPid := cmd.Process.Pid
+	require.NoError(t, adoptDaemon(cmd))
+	assert.NotEqual(t, wrapperPid, cmd.Process.Pid)
+
+	err := cmd.Wait()
+	require.IsType(t, &exec.ExitError{}, err)
+	assert.Equal(t, 3, err.(*exec.ExitError).Sys().(syscall.WaitStatus).ExitStatus())
+}
+
+func TestAdoptDaemon_CommandFails(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("child subreapers are only supported on Linux")
+	}
+	require.NoError(t, becomeSubreaper())
+
+	cmd := exec.Command("/bin/sh", "-c", "exit 2")
+	require.NoError(t, cmd.Start())
+	err := adoptDaemon(cmd)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit status 2 before daemonizing")
+}
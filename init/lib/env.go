@@ -0,0 +1,85 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"encoding/json"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// EnvOptions are how PrintEnv prints the command of a process.
+type EnvOptions struct {
+	// JSON prints a JSON document rather than a shell script.
+	JSON bool
+	// Redact prints the values of sensitive environment variables and arguments redacted.
+	Redact bool
+}
+
+// PrintEnv prints the environment, working directory and command line with which the process of the given name, or the
+// primary process if the name is empty, is started to stdout, as 'go-init env' does, without starting it.
+func (svc *Service) PrintEnv(process string, options EnvOptions) error {
+	// Notices about the configuration are written to stderr to keep stdout evaluable.
+	staticConfig, customConfig, err := launchlib.GetConfigsFromFiles(svc.launcherStaticFile, svc.launcherCustomFile,
+		svc.stderr)
+	if err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to read static and custom configuration files"))
+	}
+	cmds, err := svc.compileCommands(&staticConfig, &customConfig, &DevNullLoggers{})
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	name, cmd, err := selectEnvCommand(process, cmds)
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+
+	var redactor *launchlib.Redactor
+	if options.Redact {
+		redactor = &cmd.Redactor
+	}
+	compiled := launchlib.NewCompiledCommand(name, cmd.Command, redactor)
+	if !options.JSON {
+		compiled.WriteShell(svc.stdout)
+		return nil
+	}
+	encoder := json.NewEncoder(svc.stdout)
+	encoder.SetIndent("", "  ")
+	// Redacted values are printed as such rather than escaped.
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(compiled); err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to print command"))
+	}
+	return nil
+}
+
+// selectEnvCommand returns the command of the given name, or the primary command if the name is empty.
+func selectEnvCommand(name string, cmds map[string]CommandContext) (string, CommandContext, error) {
+	if name == "" {
+		for cmdName, cmd := range cmds {
+			if cmd.Primary {
+				return cmdName, cmd, nil
+			}
+		}
+	}
+	selected, err := selectNamedCommands([]string{name}, cmds)
+	if err != nil {
+		return "", CommandContext{}, err
+	}
+	return name, selected[name], nil
+}
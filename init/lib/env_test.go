@@ -0,0 +1,47 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// To prevent accidental changes to parameter default values
+func TestSelectEnvCommand(t *testing.T) {
+	cmds := map[string]CommandContext{
+		"primary": {Primary: true},
+		"sidecar": {},
+	}
+	for i, currCase := range []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{name: "", want: "primary"},
+		{name: "sidecar", want: "sidecar"},
+		{name: "other", wantErr: "no process named 'other' is configured"},
+	} {
+		name, cmd, err := selectEnvCommand(currCase.name, cmds)
+		if currCase.wantErr != "" {
+			assert.Contains(t, err.Error(), currCase.wantErr, "Case %d", i)
+			continue
+		}
+		assert.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, name, "Case %d", i)
+		assert.Equal(t, cmds[currCase.want], cmd, "Case %d", i)
+	}
+}
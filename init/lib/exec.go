@@ -0,0 +1,89 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// execCommand replaces go-init with the command, as 'go-init run' does for a primary process configured with execMode
+// exec. The command is prepared as by startCommand, and the resource limits, cgroup, priority, isolation and user of
+// the command are applied to go-init itself, which keeps its pid and outputs once replaced by the command. Only
+// returns if the command could not be executed.
+func (svc *Service) execCommand(name string, cmdCtx CommandContext) error {
+	if err := svc.runPreStartPlugins(svc.stdout, name, cmdCtx.Command); err != nil {
+		return err
+	}
+	if err := launchlib.MkDirs(cmdCtx.Dirs, svc.stdout); err != nil {
+		return errors.Wrap(err, "failed to create directories")
+	}
+	if err := launchlib.ChownDirs(cmdCtx.Dirs, cmdCtx.Command); err != nil {
+		return err
+	}
+	if err := launchlib.PrepareDiagnostics(cmdCtx.Diagnostics, cmdCtx.WorkingDirectory, cmdCtx.Command,
+		svc.stdout); err != nil {
+		return err
+	}
+	if err := launchlib.CollectCoreDumps(cmdCtx.CoreDumps, cmdCtx.WorkingDirectory, cmdCtx.Command,
+		svc.stdout); err != nil {
+		return err
+	}
+	if err := launchlib.PrepareTmpDir(cmdCtx.TmpDir, cmdCtx.WorkingDirectory, cmdCtx.Command,
+		svc.stdout); err != nil {
+		return err
+	}
+	if err := launchlib.WaitForDependencies(cmdCtx.DependsOn, svc.stdout); err != nil {
+		return err
+	}
+	if err := svc.runHooks("preStart", name, cmdCtx, cmdCtx.Hooks.PreStart); err != nil {
+		return err
+	}
+	if err := svc.writeCommandPidfile(name, cmdCtx, os.Getpid()); err != nil {
+		return err
+	}
+
+	if _, err := launchlib.SetRlimits(cmdCtx.Rlimits); err != nil {
+		return err
+	}
+	launchlib.SetUmask(cmdCtx.Umask)
+	if err := launchlib.JoinCgroup(0, cmdCtx.Cgroup); err != nil {
+		return errors.Wrap(err, "failed to move go-init into the cgroup of command")
+	}
+	if err := launchlib.SetPriority(0, cmdCtx.Priority); err != nil {
+		return errors.Wrap(err, "failed to set the priority of command")
+	}
+	if err := launchlib.SetCPUAffinity(0, cmdCtx.CPUSet); err != nil {
+		return errors.Wrap(err, "failed to set the CPU affinity of command")
+	}
+	// Capabilities and mounts are isolated per thread, so the thread that execs the command is the one isolated.
+	runtime.LockOSThread()
+	if err := launchlib.Isolate(cmdCtx.Isolation); err != nil {
+		return errors.Wrap(err, "failed to isolate command")
+	}
+	if err := launchlib.EnterSandbox(cmdCtx.Command); err != nil {
+		return err
+	}
+	if err := launchlib.DropPrivileges(cmdCtx.Command); err != nil {
+		return err
+	}
+	cmd := cmdCtx.Command
+	return errors.Wrapf(syscall.Exec(cmd.Path, cmd.Args, cmd.Env), "failed to execute command '%s'", name)
+}
@@ -0,0 +1,52 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// extraArgsEnvVar gives the extra arguments of the primary process, separated by whitespace, if none are given after --
+// on the command line.
+const extraArgsEnvVar = "GO_INIT_EXTRA_ARGS"
+
+// primaryExtraArgs returns the ExtraArgs of the service, or those given by extraArgsEnvVar if nil.
+func (svc *Service) primaryExtraArgs() []string {
+	if svc.ExtraArgs != nil {
+		return svc.ExtraArgs
+	}
+	return strings.Fields(os.Getenv(extraArgsEnvVar))
+}
+
+// appendExtraArgs appends the extra arguments to the args of the primary process if it is among the commands being
+// started, so that one-off invocations need not edit the configuration.
+func (svc *Service) appendExtraArgs(stdout io.Writer, cmds map[string]CommandContext) {
+	args := svc.primaryExtraArgs()
+	if len(args) == 0 {
+		return
+	}
+	for _, cmd := range cmds {
+		if cmd.Primary {
+			cmd.Command.Args = append(cmd.Command.Args, args...)
+			fmt.Fprintln(stdout, "Appended extra arguments to the primary process:",
+				cmd.Redactor.RedactArgs(args))
+			return
+		}
+	}
+	fmt.Fprintln(stdout, "Ignoring extra arguments, as the primary process is not being started")
+}
@@ -0,0 +1,70 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestAppendExtraArgs(t *testing.T) {
+	svc := newTestService(t)
+	defer func() {
+		_ = os.Unsetenv(extraArgsEnvVar)
+	}()
+	newCmds := func() map[string]CommandContext {
+		return map[string]CommandContext{
+			"primary": {
+				Command:  exec.Command("service", "arg1"),
+				Primary:  true,
+				Redactor: launchlib.StaticLauncherConfig{}.Redactor(),
+			},
+			"sidecar": {Command: exec.Command("sidecar", "arg1")},
+		}
+	}
+
+	for i, currCase := range []struct {
+		extraArgs []string
+		env       string
+		want      []string
+	}{
+		{want: []string{"service", "arg1"}},
+		{extraArgs: []string{"--migrate-only", "password=secret"},
+			want: []string{"service", "arg1", "--migrate-only", "password=secret"}},
+		{env: "--migrate-only  --verbose", want: []string{"service", "arg1", "--migrate-only", "--verbose"}},
+		// Arguments after -- take precedence over the environment variable, even if there are none.
+		{extraArgs: []string{}, env: "--migrate-only", want: []string{"service", "arg1"}},
+	} {
+		svc.ExtraArgs = currCase.extraArgs
+		_ = os.Setenv(extraArgsEnvVar, currCase.env)
+		cmds := newCmds()
+		stdout := &bytes.Buffer{}
+		svc.appendExtraArgs(stdout, cmds)
+		assert.Equal(t, currCase.want, cmds["primary"].Command.Args, "Case %d", i)
+		assert.Equal(t, []string{"sidecar", "arg1"}, cmds["sidecar"].Command.Args, "Case %d", i)
+		assert.NotContains(t, stdout.String(), "secret", "Case %d", i)
+	}
+
+	svc.ExtraArgs = []string{"--migrate-only"}
+	stdout := &bytes.Buffer{}
+	svc.appendExtraArgs(stdout, map[string]CommandContext{"sidecar": {Command: exec.Command("sidecar")}})
+	assert.Equal(t, "Ignoring extra arguments, as the primary process is not being started\n", stdout.String())
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package lib
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32               = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx         = kernel32.NewProc("LockFileEx")
+	procGetDiskFreeSpaceEx = kernel32.NewProc("GetDiskFreeSpaceExW")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+)
+
+// errLockHeld is returned by lockExclusive when another process holds the lock and it was not to be waited for. It is
+// ERROR_LOCK_VIOLATION.
+var errLockHeld error = syscall.Errno(33)
+
+// lockExclusive takes an exclusive lock on the file, which is released once the file is closed, waiting for any other
+// process that holds it if wait is set.
+func lockExclusive(file *os.File, wait bool) error {
+	flags := uintptr(lockfileExclusiveLock)
+	if !wait {
+		flags |= lockfileFailImmediately
+	}
+	var overlapped syscall.Overlapped
+	// The first byte of the file is locked, which is all that any invocation of go-init locks.
+	if ok, _, err := procLockFileEx.Call(file.Fd(), flags, 0, 1, 0, uintptr(unsafe.Pointer(&overlapped))); ok == 0 {
+		return err
+	}
+	return nil
+}
+
+// freeBytes returns the number of bytes free to the user of go-init on the volume of the path.
+func freeBytes(path string) (uint64, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if ok, _, err := procGetDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(pathPtr)), uintptr(unsafe.Pointer(&free)), 0,
+		0); ok == 0 {
+		return 0, err
+	}
+	return free, nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// installedService is where the service is installed and how go-init is run by the service manager.
+type installedService struct {
+	dir        string
+	executable string
+	// pathArgs are the path flags given to go-init, with which the service manager runs it so that it acts on the same
+	// files.
+	pathArgs []string
+}
+
+// systemdUnit is how the unit of an installed service runs go-init.
+type systemdUnit struct {
+	installedService
+	unitType string
+}
+
+const (
+	// SystemdUnitTypeNotify is the type of systemd unit with which go-init reports the primary process to systemd.
+	SystemdUnitTypeNotify = "notify"
+	// SystemdUnitTypeForking is the type of systemd unit with which systemd reads the primary process from its pidfile.
+	SystemdUnitTypeForking = "forking"
+)
+
+// Installation is where a service is installed and how the service manager that runs it runs go-init.
+type Installation struct {
+	// Dir is the directory the service is installed in, the working directory if empty.
+	Dir string
+	// Executable is the path of go-init in the installed service, relative to Dir unless absolute, that of the running
+	// go-init if empty.
+	Executable string
+	// Args are the global flags with which the service manager runs go-init, so that it acts on the same files.
+	Args []string
+}
+
+// GenerateSystemdUnit writes a systemd unit file of the given type to stdout that starts the installed service with
+// 'go-init start' and stops it with 'go-init stop', as 'go-init generate systemd' does.
+func (svc *Service) GenerateSystemdUnit(installation Installation, unitType string) error {
+	unit := systemdUnit{unitType: unitType}
+	if unit.unitType != SystemdUnitTypeNotify && unit.unitType != SystemdUnitTypeForking {
+		return cli.WithExitCode(1, errors.Errorf("unit type must be %s or %s but was '%s'", SystemdUnitTypeNotify,
+			SystemdUnitTypeForking, unit.unitType))
+	}
+	service, config, err := svc.readInstalledService(installation)
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	unit.installedService = service
+	fmt.Fprint(svc.stdout, svc.formatSystemdUnit(config, unit))
+	return nil
+}
+
+// GenerateLaunchdPlist writes a launchd property list to stdout that runs the installed service with 'go-init run', as
+// 'go-init generate launchd' does, labelled with the service name if the label is empty.
+func (svc *Service) GenerateLaunchdPlist(installation Installation, label string) error {
+	service, config, err := svc.readInstalledService(installation)
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	if label == "" {
+		label = config.ServiceName
+	}
+	fmt.Fprint(svc.stdout, svc.formatLaunchdPlist(config, service, label))
+	return nil
+}
+
+// readInstalledService returns where the service is installed, resolving the defaults of the installation, and its
+// static configuration.
+func (svc *Service) readInstalledService(installation Installation) (installedService,
+	launchlib.PrimaryStaticLauncherConfig, error) {
+	service := installedService{
+		dir:        installation.Dir,
+		executable: installation.Executable,
+		pathArgs:   installation.Args,
+	}
+	var err error
+	if service.dir, err = filepath.Abs(service.dir); err != nil {
+		return installedService{}, launchlib.PrimaryStaticLauncherConfig{},
+			errors.Wrap(err, "failed to determine the directory of the service")
+	}
+	if service.executable == "" {
+		if service.executable, err = os.Executable(); err != nil {
+			return installedService{}, launchlib.PrimaryStaticLauncherConfig{},
+				errors.Wrap(err, "failed to determine the path of go-init")
+		}
+	} else {
+		service.executable = service.path(service.executable)
+	}
+
+	config, _, err := launchlib.GetConfigsFromFiles(svc.launcherStaticFile, svc.launcherCustomFile, ioutil.Discard)
+	if err != nil {
+		return installedService{}, launchlib.PrimaryStaticLauncherConfig{},
+			errors.Wrap(err, "failed to read static and custom configuration files")
+	}
+	return service, config, nil
+}
+
+// path returns the given path of the service relative to the directory it is installed in.
+func (s installedService) path(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(s.dir, path)
+}
+
+// args returns the arguments with which the service manager runs the given go-init command.
+func (s installedService) args(command string) []string {
+	return append(append([]string{s.executable}, s.pathArgs...), command)
+}
+
+// formatSystemdUnit returns the unit file of the service. It gives 'go-init stop' time to kill the processes that do
+// not stop within their stop timeouts before systemd kills them itself.
+func (svc *Service) formatSystemdUnit(config launchlib.PrimaryStaticLauncherConfig, unit systemdUnit) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Unit]\nDescription=%s\nAfter=network.target\n\n", config.ServiceName)
+
+	fmt.Fprintf(&b, "[Service]\nType=%s\n", unit.unitType)
+	if unit.unitType == SystemdUnitTypeNotify {
+		// go-init stop reports STOPPING=1 but is not the main process.
+		b.WriteString("NotifyAccess=all\n")
+	} else {
+		fmt.Fprintf(&b, "PIDFile=%s\n", escapeSpecifiers(unit.path(fmt.Sprintf(svc.pidfileFormat, config.ServiceName))))
+	}
+	if config.User != "" {
+		fmt.Fprintf(&b, "User=%s\n", config.User)
+	}
+	if config.Group != "" {
+		fmt.Fprintf(&b, "Group=%s\n", config.Group)
+	}
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", escapeSpecifiers(unit.dir))
+	fmt.Fprintf(&b, "ExecStart=%s\n", unit.execLine("start"))
+	fmt.Fprintf(&b, "ExecStop=%s\n", unit.execLine("stop"))
+	fmt.Fprintf(&b, "ExecReload=%s\n", unit.execLine("reload"))
+	fmt.Fprintf(&b, "TimeoutStopSec=%d\n", stopSeconds(config)+30)
+
+	names := make([]string, 0, len(config.Rlimits))
+	for name := range config.Rlimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "Limit%s=%s\n", strings.ToUpper(name),
+			strings.Replace(config.Rlimits[name], "unlimited", "infinity", -1))
+	}
+	if config.Umask != nil {
+		fmt.Fprintf(&b, "UMask=%04o\n", *config.Umask)
+	}
+
+	b.WriteString("\n[Install]\nWantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// execLine returns the command line with which the unit runs the given go-init command.
+func (u systemdUnit) execLine(command string) string {
+	args := u.args(command)
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteExecArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// escapeSpecifiers escapes the '%' with which systemd substitutes specifiers, such as %n, in the settings of a unit.
+func escapeSpecifiers(value string) string {
+	return strings.Replace(value, "%", "%%", -1)
+}
+
+// quoteExecArg quotes an argument of a command line of a unit as parsed by systemd, escaping its specifiers and the
+// '$' with which systemd substitutes environment variables.
+func quoteExecArg(arg string) string {
+	arg = strings.Replace(escapeSpecifiers(arg), "$", "$$", -1)
+	if !strings.ContainsAny(arg, " \t\"'\\;") {
+		return arg
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// formatLaunchdPlist returns the property list of a launchd job that runs the service in the foreground, as in a
+// container, so that launchd sees it exit. launchd starts it again only if it fails, and gives 'go-init run' time to
+// stop the processes after sending it SIGTERM.
+func (svc *Service) formatLaunchdPlist(config launchlib.PrimaryStaticLauncherConfig, service installedService,
+	label string) string {
+	outputFile := service.path(svc.primaryOutputFile)
+	errorFile := outputFile
+	if stderrFile := stderrOutputFile(config.StaticLauncherConfig, svc.primaryOutputFile); stderrFile != "" {
+		errorFile = service.path(stderrFile)
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+`)
+	fmt.Fprintf(&b, "\t<key>Label</key>\n\t<string>%s</string>\n", escapeXML(label))
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range service.args("run") {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", escapeXML(arg))
+	}
+	b.WriteString("\t</array>\n")
+	fmt.Fprintf(&b, "\t<key>WorkingDirectory</key>\n\t<string>%s</string>\n", escapeXML(service.dir))
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	fmt.Fprintf(&b, "\t<key>ExitTimeOut</key>\n\t<integer>%d</integer>\n", stopSeconds(config))
+	fmt.Fprintf(&b, "\t<key>StandardOutPath</key>\n\t<string>%s</string>\n", escapeXML(outputFile))
+	fmt.Fprintf(&b, "\t<key>StandardErrorPath</key>\n\t<string>%s</string>\n", escapeXML(errorFile))
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+// stopSeconds returns the longest that go-init may take to stop the processes of the service, in whole seconds.
+func stopSeconds(config launchlib.PrimaryStaticLauncherConfig) int {
+	cmds := map[string]CommandContext{config.ServiceName: {
+		Stop:        config.Stop,
+		StopTimeout: config.StopTimeout,
+		DependsOn:   config.DependsOn,
+	}}
+	for name, subProcess := range config.SubProcesses {
+		cmds[name] = CommandContext{
+			Stop:        subProcess.Stop,
+			StopTimeout: subProcess.StopTimeout,
+			DependsOn:   subProcess.DependsOn,
+		}
+	}
+	return int(math.Ceil(serviceStopTimeout(cmds, config.StopOrder).Seconds()))
+}
+
+func escapeXML(value string) string {
+	var b strings.Builder
+	// Writing to a strings.Builder never fails.
+	_ = xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
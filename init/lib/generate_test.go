@@ -0,0 +1,185 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestFormatSystemdUnit(t *testing.T) {
+	svc := newTestService(t)
+	umask := os.FileMode(027)
+	config := launchlib.PrimaryStaticLauncherConfig{
+		ServiceName: "my-service",
+		StaticLauncherConfig: launchlib.StaticLauncherConfig{
+			User:    "service",
+			Group:   "service",
+			Rlimits: map[string]string{"nofile": "65536", "core": "0:unlimited"},
+			Umask:   &umask,
+		},
+	}
+
+	for i, currCase := range []struct {
+		name string
+		unit systemdUnit
+		want string
+	}{
+		{
+			name: "notify",
+			unit: systemdUnit{
+				installedService: installedService{
+					dir:        "/opt/my-service",
+					executable: "/opt/my-service/service/bin/linux-amd64/go-init",
+				},
+				unitType: SystemdUnitTypeNotify,
+			},
+			want: `[Unit]
+Description=my-service
+After=network.target
+
+[Service]
+Type=notify
+NotifyAccess=all
+User=service
+Group=service
+WorkingDirectory=/opt/my-service
+ExecStart=/opt/my-service/service/bin/linux-amd64/go-init start
+ExecStop=/opt/my-service/service/bin/linux-amd64/go-init stop
+ExecReload=/opt/my-service/service/bin/linux-amd64/go-init reload
+TimeoutStopSec=270
+LimitCORE=0:infinity
+LimitNOFILE=65536
+UMask=0027
+
+[Install]
+WantedBy=multi-user.target
+`,
+		},
+		{
+			name: "forking with path flags",
+			unit: systemdUnit{
+				installedService: installedService{
+					dir:        "/opt/my service",
+					executable: "/usr/bin/go-init",
+					pathArgs:   []string{"--pidfile", "/run/my-service/%s.pid"},
+				},
+				unitType: SystemdUnitTypeForking,
+			},
+			want: `[Unit]
+Description=my-service
+After=network.target
+
+[Service]
+Type=forking
+PIDFile=/run/my-service/my-service.pid
+User=service
+Group=service
+WorkingDirectory=/opt/my service
+ExecStart=/usr/bin/go-init --pidfile /run/my-service/%%s.pid start
+ExecStop=/usr/bin/go-init --pidfile /run/my-service/%%s.pid stop
+ExecReload=/usr/bin/go-init --pidfile /run/my-service/%%s.pid reload
+TimeoutStopSec=270
+LimitCORE=0:infinity
+LimitNOFILE=65536
+UMask=0027
+
+[Install]
+WantedBy=multi-user.target
+`,
+		},
+	} {
+		if currCase.unit.pathArgs != nil {
+			svc.pidfileFormat = currCase.unit.pathArgs[1]
+		}
+		assert.Equal(t, currCase.want, svc.formatSystemdUnit(config, currCase.unit), "Case %d: %s", i, currCase.name)
+	}
+}
+
+func TestQuoteExecArg(t *testing.T) {
+	for i, currCase := range []struct {
+		arg  string
+		want string
+	}{
+		{arg: "var/run/%s.pid", want: "var/run/%%s.pid"},
+		{arg: "$HOME/conf.yml", want: "$$HOME/conf.yml"},
+		{arg: "my service/conf.yml", want: `"my service/conf.yml"`},
+		{arg: `say "hi"\n`, want: `"say \"hi\"\\n"`},
+	} {
+		assert.Equal(t, currCase.want, quoteExecArg(currCase.arg), "Case %d", i)
+	}
+}
+
+func TestFormatLaunchdPlist(t *testing.T) {
+	svc := newTestService(t)
+	service := installedService{
+		dir:        "/Users/me/dev/my-service",
+		executable: "/Users/me/dev/my-service/service/bin/darwin-amd64/go-init",
+		pathArgs:   []string{"--custom-config", "var/conf/dev&test.yml"},
+	}
+
+	for i, currCase := range []struct {
+		name       string
+		config     launchlib.StaticLauncherConfig
+		wantStderr string
+	}{
+		{
+			name:       "interleaved stderr",
+			wantStderr: "/Users/me/dev/my-service/var/log/startup.log",
+		},
+		{
+			name:       "separate stderr",
+			config:     launchlib.StaticLauncherConfig{SeparateStderr: true},
+			wantStderr: "/Users/me/dev/my-service/var/log/startup-error.log",
+		},
+	} {
+		config := launchlib.PrimaryStaticLauncherConfig{ServiceName: "my-service", StaticLauncherConfig: currCase.config}
+		assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>com.example.my-service</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>/Users/me/dev/my-service/service/bin/darwin-amd64/go-init</string>
+		<string>--custom-config</string>
+		<string>var/conf/dev&amp;test.yml</string>
+		<string>run</string>
+	</array>
+	<key>WorkingDirectory</key>
+	<string>/Users/me/dev/my-service</string>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ExitTimeOut</key>
+	<integer>240</integer>
+	<key>StandardOutPath</key>
+	<string>/Users/me/dev/my-service/var/log/startup.log</string>
+	<key>StandardErrorPath</key>
+	<string>`+currCase.wantStderr+`</string>
+</dict>
+</plist>
+`, svc.formatLaunchdPlist(config, service, "com.example.my-service"), "Case %d: %s", i, currCase.name)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// healthCheckFailedExitCode is the exit code of 'start' when a process does not pass its health check, which matches
+// the LSB exit code for a program that is not running.
+const healthCheckFailedExitCode = 7
+
+// exitedBeforeHealthyError is returned when a process exits before passing its health check, which is a failure to
+// start rather than a failed health check.
+type exitedBeforeHealthyError struct {
+	name   string
+	status string
+}
+
+func (e *exitedBeforeHealthyError) Error() string {
+	if e.status == "" {
+		return fmt.Sprintf("process '%s' exited before passing its health check", e.name)
+	}
+	return fmt.Sprintf("process '%s' exited with %s before passing its health check", e.name, e.status)
+}
+
+// healthCheckExitCode returns the exit code of 'start' when waiting for the service to be healthy failed with err.
+func healthCheckExitCode(err error) int {
+	if _, ok := errors.Cause(err).(*exitedBeforeHealthyError); ok {
+		return 1
+	}
+	return healthCheckFailedExitCode
+}
+
+// waitForServiceToBeHealthy waits for each of the started processes that has a health check to pass it.
+func (svc *Service) waitForServiceToBeHealthy(startedCmds map[string]CommandContext) error {
+	for name, cmd := range startedCmds {
+		if cmd.HealthCheck == nil {
+			continue
+		}
+		err := svc.waitUntilHealthy(name, cmd.Command.Process, cmd.exit, cmd.HealthCheck.WithDefaults())
+		if err != nil {
+			if _, ok := err.(*exitedBeforeHealthyError); ok {
+				return err
+			}
+			return errors.Wrapf(err, "process '%s' failed its health check", name)
+		}
+	}
+	return nil
+}
+
+// waitUntilHealthy waits for the process to pass its health check, failing as soon as it exits. The exit of a process
+// go-init started is watched for through the goroutine waiting for it, while any other process is checked for on each
+// attempt.
+func (svc *Service) waitUntilHealthy(name string, proc *os.Process, exit *commandExit,
+	config launchlib.HealthCheckConfig) error {
+	fmt.Fprintf(svc.stdout, "Waiting up to %v for process '%s' to pass its health check\n", config.MaxWait, name)
+	var exited <-chan struct{}
+	if exit != nil {
+		exited = exit.exited
+	}
+	timer := Clock.NewTimer(config.MaxWait)
+	defer timer.Stop()
+	ticker := Clock.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-exited:
+			return &exitedBeforeHealthyError{name: name, status: exitDescription(exit.err)}
+		case <-ticker.Chan():
+			if exit == nil && !isProcRunning(proc) {
+				return &exitedBeforeHealthyError{name: name}
+			}
+			if lastErr = checkHealth(config); lastErr == nil {
+				fmt.Fprintf(svc.stdout, "Process '%s' passed its health check\n", name)
+				return nil
+			}
+		case <-timer.Chan():
+			if lastErr == nil {
+				return errors.Errorf("health check did not run within %v", config.MaxWait)
+			}
+			return errors.Wrapf(lastErr, "health check did not pass within %v", config.MaxWait)
+		}
+	}
+}
+
+func checkHealth(config launchlib.HealthCheckConfig) error {
+	if config.URL != "" {
+		client := http.Client{Timeout: config.Timeout}
+		resp, err := client.Get(config.URL)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return errors.Errorf("%s responded with status %d", config.URL, resp.StatusCode)
+		}
+		return nil
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("localhost", strconv.Itoa(config.Port)), config.Timeout)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
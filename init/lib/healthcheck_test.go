@@ -0,0 +1,125 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestCheckHealth_URL(t *testing.T) {
+	healthy := int32(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	config := launchlib.HealthCheckConfig{URL: server.URL, Timeout: time.Second}
+	assert.NoError(t, checkHealth(config))
+
+	atomic.StoreInt32(&healthy, 0)
+	assert.EqualError(t, checkHealth(config), server.URL+" responded with status 503")
+}
+
+func TestCheckHealth_Port(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	config := launchlib.HealthCheckConfig{Port: port, Timeout: time.Second}
+	assert.NoError(t, checkHealth(config))
+
+	require.NoError(t, listener.Close())
+	assert.Error(t, checkHealth(config))
+}
+
+func TestWaitUntilHealthy_ProcessExited(t *testing.T) {
+	svc := newTestService(t)
+	// Nothing listens on the port once the listener is closed, so the health check never passes.
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	cmd := exec.Command("/bin/sh", "-c", "exit 3")
+	require.NoError(t, cmd.Start())
+	svc.stdout = ioutil.Discard
+	err = svc.waitForServiceToBeHealthy(map[string]CommandContext{"primary": {
+		Command:     cmd,
+		HealthCheck: &launchlib.HealthCheckConfig{Port: port, Interval: 10 * time.Millisecond, MaxWait: time.Minute},
+		exit:        waitInBackground(cmd),
+	}})
+	assert.EqualError(t, err, "process 'primary' exited with exit status 3 before passing its health check")
+	assert.Equal(t, 1, healthCheckExitCode(err))
+}
+
+func TestWaitUntilHealthy_ProcessNotStartedExited(t *testing.T) {
+	svc := newTestService(t)
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	// A process that go-init did not start has no exit to watch, so it is checked for on each attempt instead.
+	cmd := exec.Command("/bin/sh", "-c", "exit 3")
+	require.NoError(t, cmd.Start())
+	require.Error(t, cmd.Wait())
+	svc.stdout = ioutil.Discard
+	err = svc.waitForServiceToBeHealthy(map[string]CommandContext{"primary": {
+		Command:     cmd,
+		HealthCheck: &launchlib.HealthCheckConfig{Port: port, Interval: 10 * time.Millisecond, MaxWait: time.Minute},
+	}})
+	assert.EqualError(t, err, "process 'primary' exited before passing its health check")
+	assert.Equal(t, 1, healthCheckExitCode(err))
+}
+
+func TestWaitUntilHealthy_NotPassed(t *testing.T) {
+	svc := newTestService(t)
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	require.NoError(t, listener.Close())
+
+	cmd := exec.Command("/bin/sleep", "10")
+	require.NoError(t, cmd.Start())
+	exit := waitInBackground(cmd)
+	defer func() {
+		_ = cmd.Process.Kill()
+		<-exit.exited
+	}()
+	svc.stdout = ioutil.Discard
+	err = svc.waitForServiceToBeHealthy(map[string]CommandContext{"primary": {
+		Command: cmd,
+		HealthCheck: &launchlib.HealthCheckConfig{Port: port, Interval: 10 * time.Millisecond,
+			MaxWait: 100 * time.Millisecond, Timeout: time.Second},
+		exit: exit,
+	}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "process 'primary' failed its health check: health check did not pass within 100ms")
+	assert.Equal(t, healthCheckFailedExitCode, healthCheckExitCode(err))
+}
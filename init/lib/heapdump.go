@@ -0,0 +1,151 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// HeapDump writes a heap dump of each running JVM of the given names, or of all JVMs if none are given, to dir, or the
+// log directory if empty, and prints the path of each dump, as 'go-init heapdump' does.
+func (svc *Service) HeapDump(dir string, processes ...string) error {
+	return svc.executeWithLoggers(NewAlwaysAppending(), func(svc *Service, loggers launchlib.ServiceLoggers) error {
+		return svc.heapDump(loggers, dir, processes)
+	})
+}
+
+func (svc *Service) heapDump(loggers launchlib.ServiceLoggers, dir string, names []string) error {
+	procs, cmds, err := svc.getRunningJavaProcesses(loggers, names)
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		dir = svc.logDir
+	}
+
+	var failed []string
+	for _, name := range processNames(procs) {
+		path, err := svc.captureHeapDump(dir, name, cmds[name], procs[name])
+		if err != nil {
+			fmt.Fprintf(svc.stdout, "failed to capture a heap dump of '%s' process: %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		fmt.Fprintf(svc.stdout, "Wrote heap dump of process '%s' to %s\n", name, path)
+		fmt.Fprintln(svc.out, path)
+	}
+	if len(failed) > 0 {
+		return svc.logErrorAndReturnWithExitCode(errors.Errorf("failed to dump processes '%v'", failed), 1)
+	}
+	return nil
+}
+
+// captureHeapDump writes a heap dump of the process to a timestamped file in dir, returning its path.
+func (svc *Service) captureHeapDump(dir, name string, cmd CommandContext, proc *os.Process) (string, error) {
+	// The JVM writes the dump itself, so the path must not be relative to the working directory of go-init.
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resolve heap dump directory")
+	}
+	if err := os.MkdirAll(dir, svc.dirMode); err != nil {
+		return "", errors.Wrapf(err, "failed to create heap dump directory %s", dir)
+	}
+	if err := launchlib.ChownDirs([]launchlib.DirConfig{{Path: dir}}, cmd.Command); err != nil {
+		return "", err
+	}
+	if err := checkHeapDumpSpace(dir, proc.Pid); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, filepath.Base(svc.dumpFile("heapdump", name, "hprof")))
+	pid := strconv.Itoa(proc.Pid)
+	if jcmd := jdkToolPath(cmd, "jcmd"); jcmd != "" {
+		_, err = runJDKTool(jcmd, cmd, pid, "GC.heap_dump", path)
+	} else if jmap := jdkToolPath(cmd, "jmap"); jmap != "" {
+		_, err = runJDKTool(jmap, cmd, "-dump:format=b,file="+path, pid)
+	} else {
+		err = errors.New("the java installation of the process has neither jcmd nor jmap")
+	}
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// checkHeapDumpSpace returns an error if dir has less free space than the resident memory of the process, which bounds
+// the size of its heap dump. The check is skipped where the resident memory cannot be determined.
+func checkHeapDumpSpace(dir string, pid int) error {
+	resident, err := processResidentBytes(pid)
+	if err != nil {
+		return nil
+	}
+	free, err := freeBytes(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine the free space of %s", dir)
+	}
+	if free < resident {
+		return errors.Errorf("%s has %d bytes free, less than the %d bytes of resident memory of the process", dir,
+			free, resident)
+	}
+	return nil
+}
+
+// GCInfo prints the heap and garbage collection statistics of each running JVM of the given names, or of all JVMs if
+// none are given, as 'go-init gcinfo' does.
+func (svc *Service) GCInfo(processes ...string) error {
+	return svc.executeWithLoggers(NewAlwaysAppending(), func(svc *Service, loggers launchlib.ServiceLoggers) error {
+		return svc.gcInfo(loggers, processes)
+	})
+}
+
+func (svc *Service) gcInfo(loggers launchlib.ServiceLoggers, names []string) error {
+	procs, cmds, err := svc.getRunningJavaProcesses(loggers, names)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, name := range processNames(procs) {
+		info, err := gcStatistics(cmds[name], procs[name])
+		if err != nil {
+			fmt.Fprintf(svc.stdout, "failed to get the GC statistics of '%s' process: %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		fmt.Fprintf(svc.out, "%s (pid %d):\n%s\n", name, procs[name].Pid, info)
+	}
+	if len(failed) > 0 {
+		return svc.logErrorAndReturnWithExitCode(errors.Errorf("failed to get the GC statistics of processes '%v'",
+			failed), 1)
+	}
+	return nil
+}
+
+func gcStatistics(cmd CommandContext, proc *os.Process) ([]byte, error) {
+	pid := strconv.Itoa(proc.Pid)
+	if jcmd := jdkToolPath(cmd, "jcmd"); jcmd != "" {
+		return runJDKTool(jcmd, cmd, pid, "GC.heap_info")
+	}
+	if jstat := jdkToolPath(cmd, "jstat"); jstat != "" {
+		return runJDKTool(jstat, cmd, "-gc", pid)
+	}
+	return nil, errors.New("the java installation of the process has neither jcmd nor jstat")
+}
@@ -0,0 +1,85 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// To prevent accidental changes to parameter default values
+func TestCaptureHeapDump(t *testing.T) {
+	svc := newTestService(t)
+	for i, currCase := range []struct {
+		tool   string
+		script string
+	}{
+		{tool: "jcmd", script: "[ \"$2\" = GC.heap_dump ] && echo \"$1\" > \"$3\""},
+		{tool: "jmap", script: "echo \"$2\" > \"${1#-dump:format=b,file=}\""},
+	} {
+		dir, err := ioutil.TempDir("", "go-init-heapdump")
+		require.NoError(t, err, "Case %d", i)
+		defer func() {
+			_ = os.RemoveAll(dir)
+		}()
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, currCase.tool),
+			[]byte("#!/bin/sh\n"+currCase.script+"\n"), 0755), "Case %d", i)
+
+		cmd := CommandContext{Command: exec.Command(filepath.Join(dir, "java")), Java: true}
+		path, err := svc.captureHeapDump(filepath.Join(dir, "dumps"), "primary", cmd, &os.Process{Pid: os.Getpid()})
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, filepath.Join(dir, "dumps"), filepath.Dir(path), "Case %d", i)
+		assert.True(t, strings.HasPrefix(filepath.Base(path), "heapdump-primary-"), "Case %d", i)
+		assert.True(t, strings.HasSuffix(path, ".hprof"), "Case %d", i)
+		dump, err := ioutil.ReadFile(path)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, strconv.Itoa(os.Getpid())+"\n", string(dump), "Case %d", i)
+	}
+}
+
+func TestCaptureHeapDump_NoTools(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-heapdump")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	cmd := CommandContext{Command: exec.Command(filepath.Join(dir, "java")), Java: true}
+	_, err = svc.captureHeapDump(dir, "primary", cmd, &os.Process{Pid: os.Getpid()})
+	assert.EqualError(t, err, "the java installation of the process has neither jcmd nor jmap")
+}
+
+func TestGCStatistics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-gcinfo")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "jstat"), []byte("#!/bin/sh\necho \"$@\"\n"), 0755))
+
+	cmd := CommandContext{Command: exec.Command(filepath.Join(dir, "java")), Java: true}
+	info, err := gcStatistics(cmd, &os.Process{Pid: 1234})
+	require.NoError(t, err)
+	assert.Equal(t, "-gc 1234\n", string(info))
+}
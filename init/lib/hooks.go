@@ -0,0 +1,99 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// hookStderrTailLines is the number of lines of the stderr of a failed hook that are reported.
+const hookStderrTailLines = 20
+
+// runHooks runs the given hooks of the named process in order, stopping at the first that fails. Hooks run with the
+// environment and working directory of the process, and their output is written to its output file.
+func (svc *Service) runHooks(phase, name string, cmdCtx CommandContext, hooks []launchlib.HookConfig) (rErr error) {
+	if len(hooks) == 0 {
+		return nil
+	}
+	logger, err := cmdCtx.Logger()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cErr := logger.Close(); cErr != nil && rErr == nil {
+			rErr = errors.Wrap(cErr, "failed to close logger for hooks")
+		}
+	}()
+
+	for i, hook := range hooks {
+		fmt.Fprintf(svc.stdout, "Running %s hook %d of process '%s': %s\n", phase, i, name,
+			strings.Join(hook.Command, " "))
+		if err := runHook(hook, cmdCtx.Command, logger); err != nil {
+			return errors.Wrapf(err, "%s hook %d of process '%s' failed", phase, i, name)
+		}
+	}
+	return nil
+}
+
+func runHook(hook launchlib.HookConfig, cmd *exec.Cmd, output io.Writer) error {
+	timeout := hook.TimeoutOrDefault()
+	hookCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	hookCmd := exec.CommandContext(hookCtx, hook.Command[0], hook.Command[1:]...)
+	hookCmd.Env = cmd.Env
+	// The working directory of a chrooted process is only meaningful within the chroot.
+	if !chrooted(cmd) {
+		hookCmd.Dir = cmd.Dir
+	}
+	stderr := &bytes.Buffer{}
+	hookCmd.Stdout = output
+	hookCmd.Stderr = io.MultiWriter(output, stderr)
+
+	err := hookCmd.Run()
+	if err == nil {
+		return nil
+	}
+	if hookCtx.Err() == context.DeadlineExceeded {
+		err = errors.Errorf("timed out after %v", timeout)
+	}
+	if stderr.Len() > 0 {
+		return errors.Errorf("%v\nstderr:\n%s", err, tailLines(stderr.String(), hookStderrTailLines))
+	}
+	return err
+}
+
+// runPreStopHooks runs the preStop hooks of each of the named processes, reporting rather than returning failures as
+// the processes are stopped regardless.
+func (svc *Service) runPreStopHooks(cmds map[string]CommandContext, names []string) {
+	for _, name := range names {
+		cmd, ok := cmds[name]
+		if !ok {
+			continue
+		}
+		if err := svc.runHooks("preStop", name, cmd, cmd.Hooks.PreStop); err != nil {
+			fmt.Fprintln(svc.stdout, err)
+		}
+	}
+}
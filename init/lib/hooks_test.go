@@ -0,0 +1,58 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestRunHooks(t *testing.T) {
+	svc := newTestService(t)
+	svc.stdout = ioutil.Discard
+	output := &bytes.Buffer{}
+	cmd := exec.Command("/bin/true")
+	cmd.Env = []string{"GREETING=hello"}
+	cmdCtx := CommandContext{
+		Command: cmd,
+		Logger:  launchlib.NewSimpleWriterLogger(output).SubProcessLogger("primary"),
+	}
+
+	require.NoError(t, svc.runHooks("preStart", "primary", cmdCtx, []launchlib.HookConfig{
+		{Command: []string{"/bin/sh", "-c", "echo $GREETING"}},
+		{Command: []string{"/bin/sh", "-c", "echo world"}},
+	}))
+	assert.Equal(t, "hello\nworld\n", output.String())
+
+	err := svc.runHooks("preStart", "primary", cmdCtx, []launchlib.HookConfig{
+		{Command: []string{"/bin/sh", "-c", "echo 'migration failed' >&2; exit 3"}},
+		{Command: []string{"/bin/sh", "-c", "echo not run"}},
+	})
+	assert.EqualError(t, err, "preStart hook 0 of process 'primary' failed: exit status 3\nstderr:\nmigration failed")
+	assert.NotContains(t, output.String(), "not run")
+
+	err = svc.runHooks("postStart", "primary", cmdCtx, []launchlib.HookConfig{
+		{Command: []string{"/bin/sleep", "10"}, Timeout: 100 * time.Millisecond},
+	})
+	assert.EqualError(t, err, "postStart hook 0 of process 'primary' failed: timed out after 100ms")
+}
@@ -0,0 +1,60 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// jdkToolTimeout is how long a JDK diagnostic tool may run before it is killed.
+	jdkToolTimeout = 5 * time.Minute
+	// jdkToolOutputTailLines is the number of lines of the output of a failed JDK tool that are reported.
+	jdkToolOutputTailLines = 20
+)
+
+// jdkToolPath returns the path of the named tool, e.g. jcmd, of the java installation that runs the command, or the
+// empty string if it has none, as is the case for JREs.
+func jdkToolPath(cmd CommandContext, name string) string {
+	if !cmd.Java {
+		return ""
+	}
+	path := filepath.Join(filepath.Dir(cmd.Command.Path), name)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// runJDKTool runs the given JDK tool against a JVM of the command, returning its output. The tools can only attach to
+// JVMs of the same user, so run as the user of the command.
+func runJDKTool(tool string, cmd CommandContext, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jdkToolTimeout)
+	defer cancel()
+	toolCmd := exec.CommandContext(ctx, tool, args...)
+	runAsUserOf(toolCmd, cmd.Command)
+	output, err := toolCmd.CombinedOutput()
+	if err != nil {
+		return nil, errors.Errorf("%s %v failed: %v\n%s", filepath.Base(tool), args, err,
+			tailLines(string(output), jdkToolOutputTailLines))
+	}
+	return output, nil
+}
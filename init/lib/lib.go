@@ -0,0 +1,428 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+const (
+	// Output files are always appended to, even when truncated first, so that a process keeps writing at their end
+	// once they are truncated by rotation.
+	outputFileFlag       = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	truncOutputFileFlag  = outputFileFlag | os.O_TRUNC
+	appendOutputFileFlag = outputFileFlag
+
+	outputLogFile = "startup.log"
+)
+
+type CommandContext struct {
+	Command    *exec.Cmd
+	Logger     launchlib.CreateLogger
+	OutputFile string
+	// ErrorLogger and ErrorOutputFile are where the stderr of the command is written if it is separated from its
+	// stdout, otherwise they are unset and its stderr is written to Logger.
+	ErrorLogger     launchlib.CreateLogger
+	ErrorOutputFile string
+	// OutputMode is where the stdout and stderr of the command are written, to Logger and ErrorLogger unless it is
+	// one of the other launchlib.OutputMode constants.
+	OutputMode string
+	Dirs       []launchlib.DirConfig
+	Primary    bool
+	// Java is whether the command runs a JVM, which can be diagnosed by 'go-init threaddump'.
+	Java        bool
+	HealthCheck *launchlib.HealthCheckConfig
+	// LivenessCheck is checked while the command is supervised, restarting it if hung.
+	LivenessCheck *launchlib.HealthCheckConfig
+	// Stop is requested of the command by 'go-init stop' before it is sent SIGTERM, if set.
+	Stop *launchlib.StopConfig
+	// StopTimeout is the time the command is given to exit after SIGTERM before it is sent SIGKILL, numSecondsToWait
+	// if zero.
+	StopTimeout   time.Duration
+	StartupWindow time.Duration
+	StartRetries  int
+	RetryDelay    time.Duration
+	// StartupPattern, if set, is matched against the output of the command until a line matches or PatternTimeout
+	// passes.
+	StartupPattern *regexp.Regexp
+	PatternTimeout time.Duration
+	// Redactor redacts the sensitive values of the command when it is printed.
+	Redactor launchlib.Redactor
+	Rlimits  map[string]string
+	Umask    *os.FileMode
+	Priority launchlib.Priority
+	CPUSet   string
+	// Isolation limits the capabilities and mounts of the command when it is started.
+	Isolation launchlib.Isolation
+	Cgroup    *launchlib.CgroupConfig
+	Hooks     launchlib.HooksConfig
+	DependsOn []launchlib.DependencyConfig
+	// OutputStreams are the files passed to the command as file descriptors from 3 up when it is started.
+	OutputStreams []launchlib.OutputStreamConfig
+	// Ports are the TCP ports the command listens on, which are checked to be free before it is started.
+	Ports []int
+	// Diagnostics is the directory of the heap dumps and fatal error logs of the command, relative to its
+	// WorkingDirectory, which is prepared before it is started.
+	Diagnostics *launchlib.DiagnosticsConfig
+	// CoreDumps is the directory the core files of the command are collected into before it is started.
+	CoreDumps *launchlib.CoreDumpsConfig
+	// TmpDir is the temporary directory of the command, relative to its WorkingDirectory, which is created, and
+	// cleaned if configured, before it is started.
+	TmpDir           *launchlib.TmpDirConfig
+	WorkingDirectory string
+	// ReloadSignal is the name of the signal sent by 'go-init reload', defaulting to launchlib.DefaultReloadSignal.
+	ReloadSignal string
+	// Daemonizes is whether the command exits once it has forked the process that is tracked in its place.
+	Daemonizes bool
+	// Exec is whether 'go-init run' replaces itself with the command rather than starting it, as configured by
+	// execMode.
+	Exec bool
+	// ConfigHash is the hash of the static and custom configuration of the command, recorded when it is started.
+	ConfigHash string
+	// Timings are how long each step of starting the command took, which 'go-init start' completes as it starts it.
+	Timings *StartupTimings
+	// exit is the exit of the command once go-init has started it and waits for it, nil if go-init did not start it.
+	exit *commandExit
+}
+
+type servicePids map[string]int
+
+type serviceStatus struct {
+	notRunningCmds map[string]CommandContext
+	writtenPids    servicePids
+	runningProcs   map[string]*os.Process
+	// changedConfigs are the running processes whose configuration has changed since they were started.
+	changedConfigs map[string]bool
+	// crashLoopingCmds are the processes that are not running as 'supervise' gave up restarting them.
+	crashLoopingCmds map[string]bool
+}
+
+func (svc *Service) getServiceStatus(loggers launchlib.ServiceLoggers) (*serviceStatus, error) {
+	cmds, err := svc.getConfiguredCommands(loggers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get commands from static and custom configuration files")
+	}
+	return svc.getCommandsStatus(cmds)
+}
+
+// getSelectedServiceStatus is getServiceStatus restricted to the processes of the given names, or all processes if none
+// are given.
+func (svc *Service) getSelectedServiceStatus(loggers launchlib.ServiceLoggers, names []string) (*serviceStatus,
+	error) {
+	cmds, err := svc.getConfiguredCommands(loggers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get commands from static and custom configuration files")
+	}
+	selected, err := selectNamedCommands(names, cmds)
+	if err != nil {
+		return nil, err
+	}
+	return svc.getCommandsStatus(selected)
+}
+
+// selectNamedCommands returns the commands of the given names, or all commands if none are given.
+func selectNamedCommands(names []string, cmds map[string]CommandContext) (map[string]CommandContext, error) {
+	if len(names) == 0 {
+		return cmds, nil
+	}
+
+	selected := make(map[string]CommandContext, len(names))
+	for _, name := range names {
+		cmd, ok := cmds[name]
+		if !ok {
+			return nil, errors.Errorf("no process named '%s' is configured, expected one of '%v'", name,
+				commandNames(cmds))
+		}
+		selected[name] = cmd
+	}
+	return selected, nil
+}
+
+func (svc *Service) getCommandsStatus(cmds map[string]CommandContext) (*serviceStatus, error) {
+	currentStatus := &serviceStatus{
+		notRunningCmds:   map[string]CommandContext{},
+		runningProcs:     map[string]*os.Process{},
+		writtenPids:      servicePids{},
+		changedConfigs:   map[string]bool{},
+		crashLoopingCmds: map[string]bool{},
+	}
+
+	for name, cmd := range cmds {
+		pid, process, err := svc.getCmdProcess(name)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to determine running processes")
+		}
+
+		if pid != nil {
+			currentStatus.writtenPids[name] = *pid
+		}
+
+		if process != nil {
+			currentStatus.runningProcs[name] = process
+			if svc.configChanged(name, cmd.ConfigHash) {
+				currentStatus.changedConfigs[name] = true
+			}
+		} else {
+			currentStatus.notRunningCmds[name] = cmd
+			if svc.crashLooping(name) {
+				currentStatus.crashLoopingCmds[name] = true
+			}
+		}
+	}
+	return currentStatus, nil
+}
+
+func (svc *Service) getCmdProcess(name string) (*int, *os.Process, error) {
+	pidBytes, err := ioutil.ReadFile(fmt.Sprintf(svc.pidfileFormat, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, errors.Wrap(err, "failed to read pidfile")
+	}
+
+	pid, err := strconv.Atoi(string(pidBytes))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "pid file did not contain an integer")
+	}
+
+	if running, proc := isPidRunning(pid); running && svc.isRecordedProcess(name, pid) {
+		return &pid, proc, nil
+	}
+	return &pid, nil, nil
+}
+
+// isRecordedProcess returns whether the running process with the pid is the one its pidfile was written for, rather
+// than a later process that reused the pid, by comparing its start time with that recorded in the state file. A
+// process whose identity was not recorded, such as one started by an older go-init, is trusted.
+func (svc *Service) isRecordedProcess(name string, pid int) bool {
+	state, err := svc.readProcessState(name)
+	if err != nil || state.Pid != pid || state.StartTicks == 0 {
+		return true
+	}
+	startTicks, err := processStartTicks(pid)
+	if err != nil {
+		// The process may have exited since it was found running.
+		return true
+	}
+	return startTicks == state.StartTicks
+}
+
+// CompileCommands returns the commands of the processes of the service, compiled from its static and custom
+// configurations as go-init starts them, which write their output to the given loggers.
+func (svc *Service) CompileCommands(loggers launchlib.ServiceLoggers) (map[string]CommandContext, error) {
+	return svc.getConfiguredCommands(loggers)
+}
+
+func (svc *Service) getConfiguredCommands(loggers launchlib.ServiceLoggers) (map[string]CommandContext, error) {
+	started := Clock.Now()
+	staticConfig, customConfig, err := svc.readConfigs()
+	if err != nil {
+		return nil, err
+	}
+	configDuration := Clock.Now().Sub(started)
+	cmds, err := svc.compileCommands(&staticConfig, &customConfig, loggers)
+	if err != nil {
+		return nil, err
+	}
+	for _, cmd := range cmds {
+		cmd.Timings.ConfigMillis = millis(configDuration)
+	}
+	return cmds, nil
+}
+
+func (svc *Service) readConfigs() (launchlib.PrimaryStaticLauncherConfig, launchlib.PrimaryCustomLauncherConfig,
+	error) {
+	staticConfig, customConfig, err := launchlib.GetConfigsFromFiles(svc.launcherStaticFile, svc.launcherCustomFile,
+		svc.stdout)
+	if err != nil {
+		return launchlib.PrimaryStaticLauncherConfig{}, launchlib.PrimaryCustomLauncherConfig{},
+			errors.Wrap(err, "failed to read static and custom configuration files")
+	}
+	return staticConfig, customConfig, nil
+}
+
+func (svc *Service) compileCommands(staticConfig *launchlib.PrimaryStaticLauncherConfig,
+	customConfig *launchlib.PrimaryCustomLauncherConfig, loggers launchlib.ServiceLoggers) (
+	map[string]CommandContext, error) {
+	// The configurations are hashed before they are compiled, which may modify them.
+	hashes, err := processConfigHashes(staticConfig, customConfig)
+	if err != nil {
+		return nil, err
+	}
+	serviceCmds, err := launchlib.CompileCmdsFromConfig(staticConfig, customConfig, loggers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compile commands from static and custom configurations")
+	}
+
+	cmds := make(map[string]CommandContext)
+	primary := newCommandContext(staticConfig.StaticLauncherConfig, serviceCmds.Primary, loggers.PrimaryLogger,
+		svc.primaryOutputFile, loggers)
+	primary.Primary = true
+	primary.Exec = staticConfig.ExecMode == launchlib.ExecModeExec
+	primary.ConfigHash = hashes[staticConfig.ServiceName]
+	primary.Timings = newStartupTimings(serviceCmds.PrimaryTimings)
+	cmds[staticConfig.ServiceName] = primary
+	for name, subProc := range serviceCmds.SubProcesses {
+		subStatic, ok := staticConfig.SubProcesses[name]
+		if !ok {
+			return nil, errors.Errorf("command given for non-existent subProcess '%s'", name)
+		}
+
+		cmd := newCommandContext(subStatic, subProc, loggers.SubProcessLogger(name), svc.subProcessOutputFile(name),
+			loggers)
+		cmd.ConfigHash = hashes[name]
+		cmd.Timings = newStartupTimings(serviceCmds.SubProcessTimings[name])
+		cmds[name] = cmd
+	}
+	return cmds, nil
+}
+
+// newCommandContext returns the context of the command of a process from its static configuration, which is common to
+// the primary process and the subProcesses.
+func newCommandContext(config launchlib.StaticLauncherConfig, cmd *exec.Cmd, logger launchlib.CreateLogger,
+	outputFile string, loggers launchlib.ServiceLoggers) CommandContext {
+	errorOutputFile := stderrOutputFile(config, outputFile)
+	return CommandContext{
+		Command:          cmd,
+		Logger:           logger,
+		OutputFile:       outputFile,
+		ErrorLogger:      errorLogger(loggers, errorOutputFile),
+		ErrorOutputFile:  errorOutputFile,
+		OutputMode:       config.OutputMode,
+		Dirs:             config.Dirs,
+		Java:             config.Type == "java" && config.NativeImage == "",
+		HealthCheck:      config.HealthCheck,
+		LivenessCheck:    config.LivenessCheck,
+		Stop:             config.Stop,
+		StopTimeout:      config.StopTimeout,
+		Hooks:            config.Hooks,
+		DependsOn:        config.DependsOn,
+		Ports:            config.Ports,
+		OutputStreams:    config.OutputStreams,
+		StartupWindow:    config.StartupWindow,
+		StartRetries:     config.StartRetries,
+		RetryDelay:       config.RetryDelay,
+		StartupPattern:   config.StartupRegexp(),
+		PatternTimeout:   startupPatternTimeout(config),
+		Redactor:         config.Redactor(),
+		Rlimits:          config.Rlimits,
+		Umask:            config.Umask,
+		Priority:         config.Priority(),
+		CPUSet:           config.CPUSet,
+		Isolation:        config.Isolation(),
+		Cgroup:           config.Cgroup,
+		ReloadSignal:     config.ReloadSignal,
+		Diagnostics:      config.Diagnostics,
+		CoreDumps:        config.CoreDumps,
+		TmpDir:           config.TmpDir,
+		WorkingDirectory: config.WorkingDirectory,
+		Daemonizes:       config.Daemonizes,
+	}
+}
+
+// writeFileAtomically writes data to a temporary file alongside path that is then renamed to it, so that the file
+// is never seen partially written.
+func (svc *Service) writeFileAtomically(path string, data []byte) error {
+	return writeFileAtomicallyWithMode(path, data, svc.fileMode)
+}
+
+func writeFileAtomicallyWithMode(path string, data []byte, mode os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// The temporary file no longer exists once it has been renamed.
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// stderrOutputFile returns the file the stderr of the process is written to if it is separated from its stdout, which
+// is written to outputFile, or the empty string otherwise.
+func stderrOutputFile(config launchlib.StaticLauncherConfig, outputFile string) string {
+	if config.StderrFile != "" {
+		return config.StderrFile
+	}
+	if !config.SeparateStderr {
+		return ""
+	}
+	return errorOutputFile(outputFile)
+}
+
+// subProcessOutputFile returns the file the output of the named subProcess is written to, its outputFile if it sets
+// one and var/log/<name>-startup.log by default.
+func (svc *Service) subProcessOutputFile(name string) string {
+	if outputFile, ok := svc.subProcessOutputFiles[name]; ok {
+		return outputFile
+	}
+	return fmt.Sprintf(svc.subProcessOutputFileFormat, name)
+}
+
+// errorOutputFile returns the file alongside the output file that the stderr of its process is written to, such as
+// var/log/startup-error.log for var/log/startup.log.
+func errorOutputFile(outputFile string) string {
+	ext := filepath.Ext(outputFile)
+	return strings.TrimSuffix(outputFile, ext) + "-error" + ext
+}
+
+// errorLoggers are the loggers that can write the stderr of a process to a file of its own.
+type errorLoggers interface {
+	ErrorLogger(path string) launchlib.CreateLogger
+}
+
+// errorLogger returns the logger of the stderr of a process that is separated from its stdout into the file at path,
+// or nil if it is not separated. Loggers that do not write to files write the stderr of the process to that of go-init.
+func errorLogger(loggers launchlib.ServiceLoggers, path string) launchlib.CreateLogger {
+	if path == "" {
+		return nil
+	}
+	if fileLoggers, ok := loggers.(errorLoggers); ok {
+		return fileLoggers.ErrorLogger(path)
+	}
+	return launchlib.NewSimpleWriterLogger(os.Stderr).PrimaryLogger
+}
+
+func isPidRunning(pid int) (bool, *os.Process) {
+	// Docs say FindProcess always succeeds on Unix.
+	proc, _ := os.FindProcess(pid)
+	if isProcRunning(proc) {
+		return true, proc
+	}
+	return false, nil
+}
@@ -0,0 +1,234 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestGetCmdProcess_VerifiesIdentity(t *testing.T) {
+	svc := newTestService(t)
+	if runtime.GOOS != "linux" {
+		t.Skip("process start times are only available on Linux")
+	}
+	dir, err := ioutil.TempDir("", "go-init-lib")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc.pidfileFormat, svc.statefileFormat = filepath.Join(dir, "%s.pid"), filepath.Join(dir, "%s.state")
+
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	require.NoError(t, svc.writePidfile("primary", cmd.Process.Pid))
+
+	pid, proc, err := svc.getCmdProcess("primary")
+	require.NoError(t, err)
+	assert.Equal(t, cmd.Process.Pid, *pid)
+	assert.NotNil(t, proc)
+
+	// A process that started at another time has reused the pid, so is not the recorded process.
+	state, err := svc.readProcessState("primary")
+	require.NoError(t, err)
+	assert.Equal(t, cmd.Process.Pid, state.Pid)
+	state.StartTicks++
+	require.NoError(t, svc.writeProcessState("primary", state))
+
+	pid, proc, err = svc.getCmdProcess("primary")
+	require.NoError(t, err)
+	assert.Equal(t, cmd.Process.Pid, *pid)
+	assert.Nil(t, proc)
+}
+
+func TestRecordProcessExit(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-lib")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc.statefileFormat = filepath.Join(dir, "%s.state")
+	require.NoError(t, svc.writeProcessState("primary", processState{Restarts: 2}))
+
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	require.NoError(t, cmd.Process.Kill())
+	require.NoError(t, svc.recordProcessExit("primary", cmd.Wait()))
+
+	state, err := svc.readProcessState("primary")
+	require.NoError(t, err)
+	assert.Equal(t, 2, state.Restarts)
+	require.NotNil(t, state.LastExitCode)
+	assert.Equal(t, 137, *state.LastExitCode)
+	assert.Equal(t, "SIGKILL", state.LastExitSignal)
+	assert.NotNil(t, state.LastExitTime)
+}
+
+func TestProcessStateVersion(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-lib")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc.statefileFormat = filepath.Join(dir, "%s.state")
+
+	for i, currCase := range []struct {
+		content string
+		want    processState
+		wantErr string
+	}{
+		{content: `{"restarts":2}`, want: processState{Restarts: 2}},
+		{content: `{"version":1,"restarts":2}`, want: processState{Version: 1, Restarts: 2}},
+		{content: `{"version":2,"restarts":2}`,
+			wantErr: "state file for 'primary' has version 2, but this go-init only reads version 1"},
+	} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "primary.state"), []byte(currCase.content), 0644),
+			"Case %d", i)
+		state, err := svc.readProcessState("primary")
+		if currCase.wantErr != "" {
+			assert.EqualError(t, err, currCase.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, state, "Case %d", i)
+	}
+
+	require.NoError(t, svc.writeProcessState("primary", processState{Restarts: 3}))
+	content, err := ioutil.ReadFile(filepath.Join(dir, "primary.state"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"version":1,"restarts":3}`, string(content))
+}
+
+func TestUpdateProcessStateConcurrently(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-lib")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc.statefileFormat = filepath.Join(dir, "%s.state")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, svc.updateProcessState("primary", func(state *processState) {
+				state.Restarts++
+			}))
+		}()
+	}
+	wg.Wait()
+
+	state, err := svc.readProcessState("primary")
+	require.NoError(t, err)
+	assert.Equal(t, 20, state.Restarts)
+}
+
+func TestGetCommandsStatus_ConfigChanged(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-lib")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc.pidfileFormat, svc.statefileFormat = filepath.Join(dir, "%s.pid"), filepath.Join(dir, "%s.state")
+
+	staticConfig := launchlib.PrimaryStaticLauncherConfig{
+		ServiceName: "primary",
+		StaticLauncherConfig: launchlib.StaticLauncherConfig{
+			Executable: "/usr/bin/postgres",
+			Args:       []string{"-D", "var/data"},
+		},
+	}
+	customConfig := launchlib.PrimaryCustomLauncherConfig{}
+	hashes, err := processConfigHashes(&staticConfig, &customConfig)
+	require.NoError(t, err)
+
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	cmds := map[string]CommandContext{"primary": {Command: cmd, ConfigHash: hashes["primary"]}}
+	require.NoError(t, svc.writeCommandPidfile("primary", cmds["primary"], cmd.Process.Pid))
+
+	serviceStatus, err := svc.getCommandsStatus(cmds)
+	require.NoError(t, err)
+	assert.Empty(t, serviceStatus.changedConfigs)
+
+	customConfig.Env = map[string]string{"PGDATA": "var/data"}
+	changedHashes, err := processConfigHashes(&staticConfig, &customConfig)
+	require.NoError(t, err)
+	assert.NotEqual(t, hashes["primary"], changedHashes["primary"])
+	cmds["primary"] = CommandContext{Command: cmd, ConfigHash: changedHashes["primary"]}
+
+	serviceStatus, err = svc.getCommandsStatus(cmds)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"primary": true}, serviceStatus.changedConfigs)
+}
+
+func TestWriteCommandPidfile_KeepsStateOutOfPidfileDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-lib")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc := newTestService(t)
+	svc.setPaths(Paths{
+		StaticConfigFile: svc.launcherStaticFile,
+		CustomConfigFile: svc.launcherCustomFile,
+		PidfileFormat:    filepath.Join(dir, "run", "%s.pid"),
+		StateDir:         filepath.Join(dir, "state"),
+		OutputFile:       filepath.Join(dir, "startup.log"),
+	})
+
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	require.NoError(t, svc.writeCommandPidfile("primary", CommandContext{Command: cmd}, cmd.Process.Pid))
+	unlock, err := svc.lockService(ioutil.Discard)
+	require.NoError(t, err)
+	unlock()
+
+	pidfiles, err := ioutil.ReadDir(filepath.Join(dir, "run"))
+	require.NoError(t, err)
+	require.Len(t, pidfiles, 1)
+	assert.Equal(t, "primary.pid", pidfiles[0].Name())
+	state, err := svc.readProcessState("primary")
+	require.NoError(t, err)
+	assert.Equal(t, cmd.Process.Pid, state.Pid)
+	assert.NotNil(t, state.StartTime)
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// lockService takes an exclusive lock on the lockfile, waiting for any other invocation of go-init that holds it, and
+// returns a function that releases it.
+func (svc *Service) lockService(stdout io.Writer) (func(), error) {
+	unlock, err := svc.lockFile(svc.lockfile, func() {
+		fmt.Fprintf(stdout, "Waiting for another invocation of go-init to release %s\n", svc.lockfile)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to lock lockfile")
+	}
+	return unlock, nil
+}
+
+// lockFile takes an exclusive lock on the file at the path, creating it and its directory if they do not exist, and
+// returns a function that releases it. If another process holds the lock, waiting is called before waiting for it.
+func (svc *Service) lockFile(path string, waiting func()) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), svc.dirMode); err != nil {
+		return nil, errors.Wrapf(err, "unable to create directory of %s", path)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, svc.fileMode)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open %s", path)
+	}
+	err = lockExclusive(file, false)
+	if err == errLockHeld {
+		waiting()
+		err = lockExclusive(file, true)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, errors.Wrapf(err, "failed to lock %s", path)
+	}
+	return func() {
+		// Closing the file releases the lock.
+		_ = file.Close()
+	}, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockService(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-lock")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc.lockfile = filepath.Join(dir, "run", "go-init.lock")
+
+	unlock, err := svc.lockService(ioutil.Discard)
+	require.NoError(t, err)
+
+	locked := make(chan struct{})
+	go func() {
+		unlockSecond, err := svc.lockService(ioutil.Discard)
+		require.NoError(t, err)
+		unlockSecond()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("lock was taken while it was held")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock was not taken once it was released")
+	}
+}
+
+func TestWriteFileAtomically(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-lock")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	path := filepath.Join(dir, "primary.pid")
+	require.NoError(t, svc.writeFileAtomically(path, []byte("123")))
+	require.NoError(t, svc.writeFileAtomically(path, []byte("45")))
+	content, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "45", string(content))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, svc.fileMode, info.Mode())
+
+	// No temporary files are left behind.
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+}
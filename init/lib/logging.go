@@ -0,0 +1,159 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+type FileFlags interface {
+	Get(path string) int
+}
+
+type truncatingFirst struct {
+	mu      sync.Mutex
+	created map[string]struct{}
+}
+
+func (t *truncatingFirst) Get(name string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.created[name]; ok {
+		return appendOutputFileFlag
+	}
+	t.created[name] = struct{}{}
+	return truncOutputFileFlag
+}
+
+func NewTruncatingFirst() FileFlags {
+	return &truncatingFirst{
+		created: make(map[string]struct{}),
+	}
+}
+
+type alwaysAppending struct{}
+
+func (a *alwaysAppending) Get(path string) int {
+	return appendOutputFileFlag
+}
+
+func NewAlwaysAppending() FileFlags {
+	return &alwaysAppending{}
+}
+
+type FileLoggers struct {
+	service *Service
+	flags   FileFlags
+}
+
+func (f *FileLoggers) PrimaryLogger() (io.WriteCloser, error) {
+	return f.OpenFile(f.service.primaryOutputFile)
+}
+
+func (f *FileLoggers) SubProcessLogger(name string) launchlib.CreateLogger {
+	return func() (io.WriteCloser, error) {
+		return f.OpenFile(f.service.subProcessOutputFile(name))
+	}
+}
+
+func (f *FileLoggers) ErrorLogger(path string) launchlib.CreateLogger {
+	return func() (io.WriteCloser, error) {
+		return f.OpenFile(path)
+	}
+}
+
+func (f *FileLoggers) OpenFile(path string) (*os.File, error) {
+	flags := f.flags.Get(path)
+	// A file is truncated when its process is started, so its previous output is rotated first.
+	if err := f.service.rotateOutputFile(path, flags&os.O_TRUNC != 0); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, flags, f.service.fileMode)
+	if err != nil {
+		return file, errors.Wrapf(err, "could not open logging file '%s'", path)
+	}
+	return file, nil
+}
+
+var devNull = launchlib.NoopClosingWriter{Writer: ioutil.Discard}
+
+type DevNullLoggers struct{}
+
+func (d *DevNullLoggers) PrimaryLogger() (io.WriteCloser, error) {
+	return &devNull, nil
+}
+
+func (d *DevNullLoggers) SubProcessLogger(name string) launchlib.CreateLogger {
+	return d.PrimaryLogger
+}
+
+func (d *DevNullLoggers) ErrorLogger(path string) launchlib.CreateLogger {
+	return d.PrimaryLogger
+}
+
+// teeLoggers write the output of each process to both its output file and the output of go-init, so that it is seen by
+// the log collector of a container while the files remain available for debugging. The output is copied by go-init
+// until the process exits, so the files are only closed by close.
+type teeLoggers struct {
+	files  launchlib.ServiceLoggers
+	stdout io.Writer
+	stderr io.Writer
+	mu     sync.Mutex
+	opened []io.Closer
+}
+
+func (t *teeLoggers) PrimaryLogger() (io.WriteCloser, error) {
+	return t.tee(t.files.PrimaryLogger, t.stdout)
+}
+
+func (t *teeLoggers) SubProcessLogger(name string) launchlib.CreateLogger {
+	return func() (io.WriteCloser, error) {
+		return t.tee(t.files.SubProcessLogger(name), t.stdout)
+	}
+}
+
+// ErrorLogger tees a separated stderr to the stderr of go-init.
+func (t *teeLoggers) ErrorLogger(path string) launchlib.CreateLogger {
+	return func() (io.WriteCloser, error) {
+		return t.tee(errorLogger(t.files, path), t.stderr)
+	}
+}
+
+func (t *teeLoggers) tee(createLogger launchlib.CreateLogger, output io.Writer) (io.WriteCloser, error) {
+	file, err := createLogger()
+	if err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.opened = append(t.opened, file)
+	return &launchlib.NoopClosingWriter{Writer: io.MultiWriter(file, output)}, nil
+}
+
+func (t *teeLoggers) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, file := range t.opened {
+		_ = file.Close()
+	}
+	t.opened = nil
+}
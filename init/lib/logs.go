@@ -0,0 +1,218 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// followInterval is how often followed output files are checked for new lines.
+const followInterval = 250 * time.Millisecond
+
+// Logs prints the given number of last lines of the output files of the processes of the given names, or of all
+// processes if none are given, as 'go-init logs' does. If follow is set, keeps printing lines as they are written.
+func (svc *Service) Logs(lines int, follow bool, processes ...string) error {
+	cmds, err := svc.getConfiguredCommands(&DevNullLoggers{})
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	selected, err := selectNamedCommands(processes, cmds)
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	paths := outputFiles(selected)
+	if len(paths) == 0 {
+		return cli.WithExitCode(1, errors.New("no process writes its output to files"))
+	}
+
+	files := make([]*followedFile, 0, len(paths))
+	defer func() {
+		for _, file := range files {
+			file.close()
+		}
+	}()
+	for _, path := range paths {
+		file := &followedFile{path: path}
+		files = append(files, file)
+		if len(paths) > 1 {
+			fmt.Fprintf(svc.stdout, "==> %s <==\n", path)
+		}
+		if err := file.tail(svc.stdout, lines); err != nil {
+			return cli.WithExitCode(1, err)
+		}
+	}
+	if !follow {
+		return nil
+	}
+	if err := followFiles(svc.stdout, files, nil); err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	return nil
+}
+
+// outputFiles returns the output files of the commands, ordered by the name of their process.
+func outputFiles(cmds map[string]CommandContext) []string {
+	names := make([]string, 0, len(cmds))
+	for name := range cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var paths []string
+	for _, name := range names {
+		cmd := cmds[name]
+		if cmd.OutputMode != "" && cmd.OutputMode != launchlib.OutputModeFile {
+			continue
+		}
+		paths = append(paths, cmd.OutputFile)
+		if cmd.ErrorOutputFile != "" {
+			paths = append(paths, cmd.ErrorOutputFile)
+		}
+	}
+	return paths
+}
+
+// followFiles prints lines written to the files until stopped, prefixing them with the path of their file whenever
+// more than one file is followed and the lines are from a different file than the previous ones.
+func followFiles(stdout io.Writer, files []*followedFile, stop <-chan struct{}) error {
+	ticker := Clock.NewTicker(followInterval)
+	defer ticker.Stop()
+	last := files[len(files)-1]
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.Chan():
+		}
+		for _, file := range files {
+			var header string
+			if len(files) > 1 && file != last {
+				header = fmt.Sprintf("\n==> %s <==\n", file.path)
+			}
+			written, err := file.poll(&headerWriter{writer: stdout, header: header})
+			if err != nil {
+				return err
+			}
+			if written {
+				last = file
+			}
+		}
+	}
+}
+
+// followedFile is an output file that is read as it is written, following it across rotations, whether it is
+// truncated after being copied or renamed and created anew.
+type followedFile struct {
+	path   string
+	file   *os.File
+	offset int64
+}
+
+// tail prints at most the last n lines of the file, from where it is then followed.
+func (f *followedFile) tail(w io.Writer, n int) error {
+	if err := f.open(); err != nil || f.file == nil {
+		return err
+	}
+	content, err := ioutil.ReadAll(f.file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read output file '%s'", f.path)
+	}
+	f.offset = int64(len(content))
+	if n > 0 && len(content) > 0 {
+		fmt.Fprintln(w, tailLines(string(content), n))
+	}
+	return nil
+}
+
+// poll prints what has been written to the file since it was last polled, returning whether anything was.
+func (f *followedFile) poll(w io.Writer) (bool, error) {
+	if f.file == nil {
+		if err := f.open(); err != nil || f.file == nil {
+			return false, err
+		}
+	}
+	// Once the file has been renamed, what remains of it is printed before continuing with the new file at its path.
+	current, err := os.Stat(f.path)
+	renamed := err != nil
+	info, err := f.file.Stat()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to read output file '%s'", f.path)
+	}
+	if !renamed {
+		renamed = !os.SameFile(info, current)
+	}
+	if info.Size() < f.offset {
+		// The file has been truncated once copied, so its new content starts from the beginning.
+		f.offset = 0
+	}
+	if _, err := f.file.Seek(f.offset, io.SeekStart); err != nil {
+		return false, errors.Wrapf(err, "failed to read output file '%s'", f.path)
+	}
+	n, err := io.Copy(w, f.file)
+	f.offset += n
+	if err != nil {
+		return n > 0, errors.Wrapf(err, "failed to read output file '%s'", f.path)
+	}
+	if renamed {
+		f.close()
+	}
+	return n > 0, nil
+}
+
+// open opens the file at its path, leaving it unopened if it does not exist yet.
+func (f *followedFile) open() error {
+	file, err := os.Open(f.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to open output file '%s'", f.path)
+	}
+	f.file = file
+	f.offset = 0
+	return nil
+}
+
+func (f *followedFile) close() {
+	if f.file != nil {
+		_ = f.file.Close()
+		f.file = nil
+	}
+}
+
+// headerWriter writes its header before the first write to the writer.
+type headerWriter struct {
+	writer io.Writer
+	header string
+}
+
+func (h *headerWriter) Write(p []byte) (int, error) {
+	if h.header != "" && len(p) > 0 {
+		if _, err := io.WriteString(h.writer, h.header); err != nil {
+			return 0, err
+		}
+		h.header = ""
+	}
+	return h.writer.Write(p)
+}
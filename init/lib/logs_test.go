@@ -0,0 +1,88 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestOutputFiles(t *testing.T) {
+	assert.Equal(t, []string{"var/log/startup.log", "var/log/sidecar-startup.log", "var/log/sidecar-error.log"},
+		outputFiles(map[string]CommandContext{
+			"primary": {OutputFile: "var/log/startup.log"},
+			"sidecar": {OutputFile: "var/log/sidecar-startup.log", ErrorOutputFile: "var/log/sidecar-error.log"},
+			"journal": {OutputFile: "var/log/journal-startup.log", OutputMode: launchlib.OutputModeJournald},
+		}))
+}
+
+func TestFollowedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-logs")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := filepath.Join(dir, "startup.log")
+	appendLines := func(path, lines string) {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		require.NoError(t, err)
+		_, err = file.WriteString(lines)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+	}
+
+	file := &followedFile{path: path}
+	defer file.close()
+	output := &bytes.Buffer{}
+	poll := func() string {
+		output.Reset()
+		_, err := file.poll(output)
+		require.NoError(t, err)
+		return output.String()
+	}
+
+	// Files that do not exist yet are followed once they are created.
+	require.NoError(t, file.tail(output, 2))
+	assert.Equal(t, "", poll())
+	appendLines(path, "one\ntwo\nthree\n")
+	assert.Equal(t, "one\ntwo\nthree\n", poll())
+
+	file.close()
+	output.Reset()
+	require.NoError(t, file.tail(output, 2))
+	assert.Equal(t, "two\nthree\n", output.String())
+	appendLines(path, "four\n")
+	assert.Equal(t, "four\n", poll())
+
+	// Files rotated by copying and truncating them are read again from the beginning.
+	require.NoError(t, os.Truncate(path, 0))
+	appendLines(path, "five\n")
+	assert.Equal(t, "five\n", poll())
+
+	// Files rotated by renaming them are read to their end before continuing with the new file.
+	appendLines(path, "six\n")
+	require.NoError(t, os.Rename(path, path+".1"))
+	appendLines(path, "seven\n")
+	assert.Equal(t, "six\n", poll())
+	assert.Equal(t, "seven\n", poll())
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// metricFamilies are the gauges written for each supervised process, given its status.
+var metricFamilies = []struct {
+	name  string
+	help  string
+	value func(process ProcessStatus) (float64, bool)
+}{
+	{
+		name: "go_init_process_up",
+		help: "Whether the process is running.",
+		value: func(process ProcessStatus) (float64, bool) {
+			if process.Running {
+				return 1, true
+			}
+			return 0, true
+		},
+	},
+	{
+		name: "go_init_process_restarts",
+		help: "The number of times the process has been restarted by go-init supervise.",
+		value: func(process ProcessStatus) (float64, bool) {
+			return float64(process.Restarts), true
+		},
+	},
+	{
+		name: "go_init_process_uptime_seconds",
+		help: "The number of seconds since the running process started.",
+		value: func(process ProcessStatus) (float64, bool) {
+			return float64(process.UptimeSeconds), process.Running
+		},
+	},
+	{
+		name: "go_init_process_last_exit_code",
+		help: "The exit code of the process when it last exited.",
+		value: func(process ProcessStatus) (float64, bool) {
+			if process.LastExitCode == nil {
+				return 0, false
+			}
+			return float64(*process.LastExitCode), true
+		},
+	},
+	{
+		name: "go_init_process_resident_memory_bytes",
+		help: "The resident memory of the running process in bytes.",
+		value: func(process ProcessStatus) (float64, bool) {
+			return float64(process.ResidentBytes), process.Running && process.ResidentBytes > 0
+		},
+	},
+}
+
+// formatMetrics returns the metrics of the processes of the service in the Prometheus text exposition format, which
+// the textfile collector of the node_exporter reads. Process names cannot contain characters that must be escaped in
+// label values.
+func formatMetrics(service string, processes []ProcessStatus) string {
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].Name < processes[j].Name
+	})
+	var metrics strings.Builder
+	for _, family := range metricFamilies {
+		fmt.Fprintf(&metrics, "# HELP %s %s\n# TYPE %s gauge\n", family.name, family.help, family.name)
+		for _, process := range processes {
+			if value, ok := family.value(process); ok {
+				fmt.Fprintf(&metrics, "%s{service=%q,process=%q} %s\n", family.name, service, process.Name,
+					strconv.FormatFloat(value, 'f', -1, 64))
+			}
+		}
+	}
+	return metrics.String()
+}
+
+// writeMetrics replaces the textfile at path with the metrics of the processes, so that the node_exporter never reads
+// it partially written.
+func (svc *Service) writeMetrics(path, service string, processes []ProcessStatus) error {
+	if err := svc.writeFileAtomically(path, []byte(formatMetrics(service, processes))); err != nil {
+		return errors.Wrapf(err, "failed to write metrics to '%s'", path)
+	}
+	return nil
+}
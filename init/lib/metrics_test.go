@@ -0,0 +1,46 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatMetrics(t *testing.T) {
+	exitCode := 137
+	assert.Equal(t, `# HELP go_init_process_up Whether the process is running.
+# TYPE go_init_process_up gauge
+go_init_process_up{service="my-service",process="my-service"} 1
+go_init_process_up{service="my-service",process="sidecar"} 0
+# HELP go_init_process_restarts The number of times the process has been restarted by go-init supervise.
+# TYPE go_init_process_restarts gauge
+go_init_process_restarts{service="my-service",process="my-service"} 0
+go_init_process_restarts{service="my-service",process="sidecar"} 3
+# HELP go_init_process_uptime_seconds The number of seconds since the running process started.
+# TYPE go_init_process_uptime_seconds gauge
+go_init_process_uptime_seconds{service="my-service",process="my-service"} 90
+# HELP go_init_process_last_exit_code The exit code of the process when it last exited.
+# TYPE go_init_process_last_exit_code gauge
+go_init_process_last_exit_code{service="my-service",process="sidecar"} 137
+# HELP go_init_process_resident_memory_bytes The resident memory of the running process in bytes.
+# TYPE go_init_process_resident_memory_bytes gauge
+go_init_process_resident_memory_bytes{service="my-service",process="my-service"} 268435456
+`, formatMetrics("my-service", []ProcessStatus{
+		{Name: "sidecar", Restarts: 3, LastExitCode: &exitCode},
+		{Name: "my-service", Running: true, UptimeSeconds: 90, ResidentBytes: 256 << 20},
+	}))
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// MigrateConfig rewrites the static and custom configurations, including the custom configurations they include and
+// overlay, in the latest configVersion, as 'go-init migrate-config' does. If dryRun is set, prints each migrated file
+// instead of rewriting it.
+func (svc *Service) MigrateConfig(dryRun bool) error {
+	if err := svc.migrateConfigFile(svc.launcherStaticFile, launchlib.MigrateStaticConfig, true, dryRun); err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	customFiles, err := launchlib.CustomConfigFiles(svc.launcherCustomFile)
+	if err != nil {
+		return cli.WithExitCode(1, errors.Wrap(err, "failed to read custom configuration files"))
+	}
+	for _, customFile := range customFiles {
+		// Only the custom configuration itself must set a configVersion.
+		required := customFile == svc.launcherCustomFile
+		if err := svc.migrateConfigFile(customFile, launchlib.MigrateCustomConfig, required, dryRun); err != nil {
+			return cli.WithExitCode(1, err)
+		}
+	}
+	return nil
+}
+
+// migrateConfigFile rewrites the given configuration file in the latest configVersion with migrate, unless it is
+// already in it, or does not set a configVersion and is not required to.
+func (svc *Service) migrateConfigFile(file string, migrate func([]byte) ([]byte, bool, error), required,
+	dryRun bool) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read configuration file %s", file)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read configuration file %s", file)
+	}
+	version, err := launchlib.ConfigVersion(data)
+	if err != nil {
+		return errors.Wrapf(err, "invalid configuration file %s", file)
+	}
+	if version == launchlib.LatestConfigVersion {
+		fmt.Fprintf(svc.stdout, "%s: already in configVersion %d\n", file, launchlib.LatestConfigVersion)
+		return nil
+	}
+	if version == 0 && !required {
+		fmt.Fprintf(svc.stdout, "%s: does not set a configVersion\n", file)
+		return nil
+	}
+
+	migrated, formatted, err := migrate(data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to migrate configuration file %s", file)
+	}
+	if !formatted {
+		fmt.Fprintf(svc.stderr, "%s: comments and formatting could not be kept\n", file)
+	}
+	if dryRun {
+		fmt.Fprintf(svc.stdout, "# %s\n%s", file, migrated)
+		return nil
+	}
+	if err := writeFileAtomicallyWithMode(file, migrated, info.Mode().Perm()); err != nil {
+		return errors.Wrapf(err, "failed to write configuration file %s", file)
+	}
+	fmt.Fprintf(svc.stdout, "%s: migrated from configVersion %d to %d\n", file, version,
+		launchlib.LatestConfigVersion)
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// notification is the JSON payload of a notification.
+type notification struct {
+	Event   string    `json:"event"`
+	Service string    `json:"service"`
+	Process string    `json:"process"`
+	Host    string    `json:"host,omitempty"`
+	Time    time.Time `json:"time"`
+	Pid     int       `json:"pid,omitempty"`
+	// ExitCode and ExitSignal describe how a process that crashed or was stopped exited, where it was observed.
+	ExitCode   *int   `json:"exitCode,omitempty"`
+	ExitSignal string `json:"exitSignal,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// exitNotification returns the notification of the event of the named process given the error returned when waiting
+// for it.
+func exitNotification(event, name string, waitErr error) notification {
+	code := exitCode(waitErr)
+	n := notification{Event: event, Process: name, ExitCode: &code}
+	if signal, ok := exitSignal(waitErr); ok {
+		n.ExitSignal = launchlib.SignalName(signal)
+	}
+	return n
+}
+
+// notify passes the notification to the plugins, and sends it if notifications are enabled for its event. Failures
+// are reported to stdout rather than returned, as notifying must not affect the service.
+func (svc *Service) notify(stdout io.Writer, n notification) {
+	n.Service = svc.notificationService
+	n.Time = Clock.Now()
+	n.Host, _ = os.Hostname()
+	svc.notifyPlugins(stdout, n)
+	if !svc.notifications.Enabled() || !svc.notifiedEvent(n.Event) {
+		return
+	}
+	if err := sendNotification(svc.notifications, n); err != nil {
+		fmt.Fprintf(stdout, "failed to send %s notification of process '%s': %v\n", n.Event, n.Process, err)
+	}
+}
+
+func (svc *Service) notifiedEvent(event string) bool {
+	for _, notified := range svc.notifications.Events {
+		if notified == event {
+			return true
+		}
+	}
+	return false
+}
+
+// sendNotification POSTs the payload of the notification to the URL of the config, or runs its command with the
+// payload on stdin, within its timeout.
+func sendNotification(config launchlib.NotificationsConfig, n notification) error {
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return errors.Wrap(err, "failed to serialize notification")
+	}
+	if config.URL != "" {
+		client := http.Client{Timeout: config.Timeout}
+		resp, err := client.Post(config.URL, "application/json", bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return errors.Errorf("%s responded with status %d", config.URL, resp.StatusCode)
+		}
+		return nil
+	}
+
+	cmdCtx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(cmdCtx, config.Command[0], config.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if cmdCtx.Err() == context.DeadlineExceeded {
+		err = errors.Errorf("timed out after %v", config.Timeout)
+	}
+	if len(output) > 0 {
+		return errors.Errorf("%v\noutput:\n%s", err, tailLines(string(output), hookStderrTailLines))
+	}
+	return err
+}
@@ -0,0 +1,101 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestNotifyWebhook(t *testing.T) {
+	svc := newTestService(t)
+	received := make(chan notification, 1)
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notification
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&n))
+		received <- n
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	svc.notifications = launchlib.NotificationsConfig{URL: server.URL}.WithDefaults()
+	svc.notificationService = "my-service"
+	stdout := &bytes.Buffer{}
+	svc.notify(stdout, exitNotification(launchlib.NotificationEventCrash, "primary", nil))
+	n := <-received
+	assert.Equal(t, "crash", n.Event)
+	assert.Equal(t, "my-service", n.Service)
+	assert.Equal(t, "primary", n.Process)
+	require.NotNil(t, n.ExitCode)
+	assert.Equal(t, 0, *n.ExitCode)
+	assert.WithinDuration(t, time.Now(), n.Time, time.Minute)
+	assert.Empty(t, stdout.String())
+
+	status = http.StatusInternalServerError
+	svc.notify(stdout, notification{Event: launchlib.NotificationEventStart, Process: "primary", Pid: 12345})
+	assert.Equal(t, 12345, (<-received).Pid)
+	assert.Equal(t, "failed to send start notification of process 'primary': "+server.URL+" responded with "+
+		"status 500\n", stdout.String())
+
+	// Events that are not configured are not notified, which the server would otherwise block on.
+	svc.notifications.Events = []string{launchlib.NotificationEventCrash}
+	svc.notify(stdout, notification{Event: launchlib.NotificationEventStop, Process: "primary"})
+	assert.Empty(t, received)
+}
+
+func TestNotifyCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("notification commands are run with sh")
+	}
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-notifications")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	payloadFile := filepath.Join(dir, "payload.json")
+
+	svc.notifications = launchlib.NotificationsConfig{Command: []string{"sh", "-c", "cat > " + payloadFile}}.WithDefaults()
+	svc.notificationService = "my-service"
+	stdout := &bytes.Buffer{}
+	svc.notify(stdout, notification{Event: launchlib.NotificationEventRestart, Process: "sidecar", Pid: 12345})
+	assert.Empty(t, stdout.String())
+	payload, err := ioutil.ReadFile(payloadFile)
+	require.NoError(t, err)
+	var n notification
+	require.NoError(t, json.Unmarshal(payload, &n))
+	assert.Equal(t, notification{Event: "restart", Service: "my-service", Process: "sidecar", Host: n.Host,
+		Time: n.Time, Pid: 12345}, n)
+
+	svc.notifications.Command = []string{"sh", "-c", "echo unreachable >&2; exit 3"}
+	svc.notify(stdout, notification{Event: launchlib.NotificationEventRestart, Process: "sidecar"})
+	assert.Equal(t, "failed to send restart notification of process 'sidecar': exit status 3\noutput:\n"+
+		"unreachable\n", stdout.String())
+}
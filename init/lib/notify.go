@@ -0,0 +1,58 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+const notifySocketEnvVar = "NOTIFY_SOCKET"
+
+// sdNotify sends the given state to systemd, as described by sd_notify(3), if go-init was started by a unit with
+// Type=notify. Does nothing otherwise.
+func sdNotify(state string) error {
+	socketPath := os.Getenv(notifySocketEnvVar)
+	if socketPath == "" {
+		return nil
+	}
+	// Sockets in the abstract namespace are denoted by a leading '@'.
+	if socketPath[0] == '@' {
+		socketPath = "\x00" + socketPath[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to systemd notification socket")
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return errors.Wrap(err, "failed to send notification to systemd")
+	}
+	return nil
+}
+
+// notifySystemd sends the state to systemd, logging rather than failing if it cannot, as the service itself is
+// unaffected.
+func (svc *Service) notifySystemd(state string) {
+	if err := sdNotify(state); err != nil {
+		fmt.Fprintln(svc.stdout, err)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSdNotify_SendsStateToSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "notify")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+
+	socketPath := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, conn.Close())
+	}()
+
+	original := os.Getenv(notifySocketEnvVar)
+	defer func() { require.NoError(t, os.Setenv(notifySocketEnvVar, original)) }()
+	require.NoError(t, os.Setenv(notifySocketEnvVar, socketPath))
+
+	require.NoError(t, sdNotify("READY=1"))
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "READY=1", string(buf[:n]))
+}
+
+func TestSdNotify_NoopWithoutSocket(t *testing.T) {
+	original := os.Getenv(notifySocketEnvVar)
+	defer func() { require.NoError(t, os.Setenv(notifySocketEnvVar, original)) }()
+	require.NoError(t, os.Unsetenv(notifySocketEnvVar))
+
+	assert.NoError(t, sdNotify("READY=1"))
+}
@@ -0,0 +1,122 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// startWaves returns the names of the commands grouped into the waves they are started in, in order. Each wave holds
+// the commands whose process dependencies are in earlier waves or are not among the commands, as they are either
+// running already or not being started, so the commands of a wave can be started in parallel. Each wave is sorted.
+func startWaves(cmds map[string]CommandContext) ([][]string, error) {
+	placed := make(map[string]bool, len(cmds))
+	var waves [][]string
+	for len(placed) < len(cmds) {
+		var wave []string
+		for name, cmd := range cmds {
+			if !placed[name] && dependenciesPlaced(cmd, cmds, placed) {
+				wave = append(wave, name)
+			}
+		}
+		if len(wave) == 0 {
+			return nil, errors.New("processes depend on each other in a cycle")
+		}
+		sort.Strings(wave)
+		for _, name := range wave {
+			placed[name] = true
+		}
+		waves = append(waves, wave)
+	}
+	return waves, nil
+}
+
+func dependenciesPlaced(cmd CommandContext, cmds map[string]CommandContext, placed map[string]bool) bool {
+	for _, dependency := range launchlib.ProcessDependencies(cmd.DependsOn) {
+		if _, ok := cmds[dependency]; ok && !placed[dependency] {
+			return false
+		}
+	}
+	return true
+}
+
+// waitForDependedUpon waits for the commands of the wave that other commands depend on to match their startup patterns
+// and pass their health checks, so that the commands that depend on them are only started once they are ready.
+func (svc *Service) waitForDependedUpon(cmds map[string]CommandContext, wave []string) error {
+	dependedUpon := map[string]struct{}{}
+	for _, cmd := range cmds {
+		for _, dependency := range launchlib.ProcessDependencies(cmd.DependsOn) {
+			dependedUpon[dependency] = struct{}{}
+		}
+	}
+	ready := map[string]CommandContext{}
+	for _, name := range wave {
+		if _, ok := dependedUpon[name]; ok {
+			ready[name] = cmds[name]
+		}
+	}
+	if err := svc.waitForStartupPatterns(ready); err != nil {
+		return err
+	}
+	return svc.waitForServiceToBeHealthy(ready)
+}
+
+// startInParallel calls start for each of the names concurrently, returning the error of the first of the names that
+// failed. The output of go-init is synchronized while they run.
+func (svc *Service) startInParallel(names []string, start func(name string) error) error {
+	if len(names) == 1 {
+		return start(names[0])
+	}
+	stdout := svc.stdout
+	svc.stdout = &syncWriter{writer: stdout}
+	defer func() {
+		svc.stdout = stdout
+	}()
+
+	errs := make([]error, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			errs[i] = start(name)
+		}(i, name)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncWriter serializes the writes to writer.
+type syncWriter struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writer.Write(p)
+}
@@ -0,0 +1,138 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func dependsOnProcesses(names ...string) CommandContext {
+	var dependencies []launchlib.DependencyConfig
+	for _, name := range names {
+		dependencies = append(dependencies, launchlib.DependencyConfig{Process: name})
+	}
+	return CommandContext{DependsOn: dependencies}
+}
+
+func TestStartWaves(t *testing.T) {
+	for i, currCase := range []struct {
+		cmds map[string]CommandContext
+		want [][]string
+	}{
+		{cmds: map[string]CommandContext{}, want: nil},
+		{
+			cmds: map[string]CommandContext{"primary": {}, "sidecar": {}, "agent": {}},
+			want: [][]string{{"agent", "primary", "sidecar"}},
+		},
+		{
+			cmds: map[string]CommandContext{
+				"primary":  dependsOnProcesses("database", "cache"),
+				"database": {},
+				"cache":    dependsOnProcesses("database"),
+				"metrics":  {},
+			},
+			want: [][]string{{"database", "metrics"}, {"cache"}, {"primary"}},
+		},
+		// The database is not being started, so is running already or not selected.
+		{
+			cmds: map[string]CommandContext{"primary": dependsOnProcesses("database"), "sidecar": {}},
+			want: [][]string{{"primary", "sidecar"}},
+		},
+	} {
+		waves, err := startWaves(currCase.cmds)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, waves, "Case %d", i)
+	}
+
+	_, err := startWaves(map[string]CommandContext{"a": dependsOnProcesses("b"), "b": dependsOnProcesses("a")})
+	assert.EqualError(t, err, "processes depend on each other in a cycle")
+}
+
+func TestStartService_Ordered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-order")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc := newTestService(t)
+	svc.pidfileFormat, svc.statefileFormat = filepath.Join(dir, "%s.pid"), filepath.Join(dir, "%s.state")
+
+	cmds := map[string]CommandContext{
+		"primary":  dependsOnProcesses("database"),
+		"database": {},
+		"metrics":  {},
+	}
+	for name, cmd := range cmds {
+		cmd.Command = exec.Command("/bin/sh", "-c", "exit 0")
+		cmd.Logger = launchlib.NewSimpleWriterLogger(ioutil.Discard).PrimaryLogger
+		cmds[name] = cmd
+	}
+	svc.stdout = ioutil.Discard
+	require.NoError(t, svc.startService(cmds))
+
+	for name, cmd := range cmds {
+		<-cmd.exit.exited
+		require.NoError(t, cmd.exit.err, name)
+		pid, err := ioutil.ReadFile(filepath.Join(dir, name+".pid"))
+		require.NoError(t, err, name)
+		assert.Equal(t, strconv.Itoa(cmd.Command.ProcessState.Pid()), string(pid), name)
+	}
+}
+
+func TestStartService_WaveUmasks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-order")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc := newTestService(t)
+	svc.pidfileFormat, svc.statefileFormat = filepath.Join(dir, "%s.pid"), filepath.Join(dir, "%s.state")
+
+	umasks := map[string]os.FileMode{"primary": 0027, "sidecar": 0077}
+	// The processes of a wave are started in parallel, so are started repeatedly to catch them starting with each
+	// other's umask.
+	for i := 0; i < 20; i++ {
+		cmds := map[string]CommandContext{}
+		for name, umask := range umasks {
+			umask := umask
+			cmds[name] = CommandContext{
+				Command: exec.Command("/bin/sh", "-c", "umask > "+filepath.Join(dir, name+".umask")),
+				Logger:  launchlib.NewSimpleWriterLogger(ioutil.Discard).PrimaryLogger,
+				Umask:   &umask,
+			}
+		}
+		svc.stdout = ioutil.Discard
+		require.NoError(t, svc.startService(cmds))
+
+		for name, cmd := range cmds {
+			<-cmd.exit.exited
+			require.NoError(t, cmd.exit.err, name)
+			umask, err := ioutil.ReadFile(filepath.Join(dir, name+".umask"))
+			require.NoError(t, err, name)
+			assert.Equal(t, fmt.Sprintf("%04o\n", umasks[name]), string(umask), "Iteration %d: %s", i, name)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+var (
+	// journalStreamSocket is the socket of the systemd journal that accepts output streams.
+	journalStreamSocket = "/run/systemd/journal/stdout"
+	// loggerCommand is the command that writes its input to syslog.
+	loggerCommand = "logger"
+)
+
+const (
+	// The priorities of the stdout and stderr of a process, informational and error respectively.
+	stdoutPriority = 6
+	stderrPriority = 3
+)
+
+var syslogLevels = map[int]string{
+	stdoutPriority: "info",
+	stderrPriority: "err",
+}
+
+// openOutputs sets the stdout and stderr of the command according to its output mode, returning the outputs that are
+// closed once it has started.
+func openOutputs(name string, cmdCtx CommandContext) ([]io.Closer, error) {
+	switch cmdCtx.OutputMode {
+	case launchlib.OutputModeConsole:
+		cmdCtx.Command.Stdout = os.Stdout
+		cmdCtx.Command.Stderr = os.Stderr
+		return nil, nil
+	case launchlib.OutputModeJournald:
+		return openOutputStreams(cmdCtx.Command, func(priority int) (*os.File, error) {
+			return openJournalStream(name, priority)
+		})
+	case launchlib.OutputModeSyslog:
+		return openOutputStreams(cmdCtx.Command, func(priority int) (*os.File, error) {
+			return openSyslogStream(name, priority)
+		})
+	}
+
+	logger, err := cmdCtx.Logger()
+	if err != nil {
+		return nil, err
+	}
+	cmdCtx.Command.Stdout = logger
+	cmdCtx.Command.Stderr = logger
+	if cmdCtx.ErrorLogger == nil {
+		return []io.Closer{logger}, nil
+	}
+	errorLogger, err := cmdCtx.ErrorLogger()
+	if err != nil {
+		_ = logger.Close()
+		return nil, err
+	}
+	cmdCtx.Command.Stderr = errorLogger
+	return []io.Closer{logger, errorLogger}, nil
+}
+
+// openOutputStreams sets the stdout and stderr of the command to streams opened with their priorities.
+func openOutputStreams(cmd *exec.Cmd, open func(priority int) (*os.File, error)) ([]io.Closer, error) {
+	stdout, err := open(stdoutPriority)
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := open(stderrPriority)
+	if err != nil {
+		_ = stdout.Close()
+		return nil, err
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return []io.Closer{stdout, stderr}, nil
+}
+
+// openJournalStream connects to the stream socket of the systemd journal, returning a file whose lines are logged with
+// the process name as their identifier and the priority, unless prefixed with another such as <4>. The process writes
+// to the journal directly, so its output is logged even once go-init has exited.
+func openJournalStream(name string, priority int) (*os.File, error) {
+	conn, err := net.Dial("unix", journalStreamSocket)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to the systemd journal")
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	// The header is the identifier, unit, priority, whether lines may be prefixed with their priority, and whether
+	// they are forwarded to syslog, the kernel log and the console.
+	if _, err := fmt.Fprintf(conn, "%s\n\n%d\n1\n0\n0\n0\n", name, priority); err != nil {
+		return nil, errors.Wrap(err, "failed to open a stream to the systemd journal")
+	}
+	file, err := conn.(*net.UnixConn).File()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open a stream to the systemd journal")
+	}
+	return file, nil
+}
+
+// openSyslogStream starts a logger command that logs each line written to the returned file to syslog with the
+// process name as its tag and the priority in the user facility. The logger exits once the process has closed the
+// file, so its output is logged even once go-init has exited.
+func openSyslogStream(name string, priority int) (*os.File, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create pipe to syslog")
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	logger := exec.Command(loggerCommand, "-t", name, "-p", "user."+syslogLevels[priority])
+	logger.Stdin = reader
+	if err := logger.Start(); err != nil {
+		_ = writer.Close()
+		return nil, errors.Wrap(err, "failed to start logger to write output to syslog")
+	}
+	// The logger is reaped if go-init is still running when it exits.
+	go func() {
+		_ = logger.Wait()
+	}()
+	return writer, nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestOpenOutputs_Journald(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-output")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	socket := journalStreamSocket
+	journalStreamSocket = filepath.Join(dir, "stdout")
+	defer func() {
+		journalStreamSocket = socket
+	}()
+	listener, err := net.Listen("unix", journalStreamSocket)
+	require.NoError(t, err)
+	defer func() {
+		_ = listener.Close()
+	}()
+	streams := make(chan string, 2)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				content, _ := ioutil.ReadAll(conn)
+				streams <- string(content)
+			}()
+		}
+	}()
+
+	cmd := exec.Command("/bin/sh", "-c", "echo out; echo err >&2")
+	outputs, err := openOutputs("primary", CommandContext{Command: cmd, OutputMode: launchlib.OutputModeJournald})
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	for _, output := range outputs {
+		require.NoError(t, output.Close())
+	}
+	require.NoError(t, cmd.Wait())
+
+	var received []string
+	for range outputs {
+		select {
+		case stream := <-streams:
+			received = append(received, stream)
+		case <-time.After(5 * time.Second):
+			t.Fatal("journal stream was not closed")
+		}
+	}
+	assert.Contains(t, received, "primary\n\n6\n1\n0\n0\n0\nout\n")
+	assert.Contains(t, received, "primary\n\n3\n1\n0\n0\n0\nerr\n")
+}
+
+func TestOpenOutputs_Syslog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-output")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	// The fake logger records its arguments along with each line it is given.
+	logged := filepath.Join(dir, "logged")
+	fakeLogger := filepath.Join(dir, "logger")
+	require.NoError(t, ioutil.WriteFile(fakeLogger, []byte("#!/bin/sh\nwhile read line; do echo \"$* $line\" >> "+
+		logged+"; done\n"), 0755))
+	command := loggerCommand
+	loggerCommand = fakeLogger
+	defer func() {
+		loggerCommand = command
+	}()
+
+	cmd := exec.Command("/bin/sh", "-c", "echo out; echo err >&2")
+	outputs, err := openOutputs("primary", CommandContext{Command: cmd, OutputMode: launchlib.OutputModeSyslog})
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	for _, output := range outputs {
+		require.NoError(t, output.Close())
+	}
+	require.NoError(t, cmd.Wait())
+
+	// The loggers exit once the process has exited, after logging its output.
+	var lines []string
+	for start := time.Now(); len(lines) < 2 && time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if file, err := os.Open(logged); err == nil {
+			lines = nil
+			for scanner := bufio.NewScanner(file); scanner.Scan(); {
+				lines = append(lines, scanner.Text())
+			}
+			_ = file.Close()
+		}
+	}
+	assert.Contains(t, lines, "-t primary -p user.info out")
+	assert.Contains(t, lines, "-t primary -p user.err err")
+}
+
+func TestOpenOutputs_Console(t *testing.T) {
+	cmd := exec.Command("true")
+	outputs, err := openOutputs("primary", CommandContext{Command: cmd, OutputMode: launchlib.OutputModeConsole})
+	require.NoError(t, err)
+	assert.Empty(t, outputs)
+	assert.Equal(t, os.Stdout, cmd.Stdout)
+	assert.Equal(t, os.Stderr, cmd.Stderr)
+}
@@ -0,0 +1,129 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// Paths are the paths of the files of a service, relative to the working directory unless absolute.
+type Paths struct {
+	// StaticConfigFile and CustomConfigFile are the paths of the static and custom launcher configurations.
+	StaticConfigFile string
+	CustomConfigFile string
+	// PidfileFormat is the path of the pidfile of each process, in which %s is replaced by the process name.
+	PidfileFormat string
+	// StateDir is the directory of the state file of each process and of the lockfile of the service. It is kept
+	// apart from the pidfiles so that the pidfile directory only ever contains pidfiles, as tools that read them
+	// expect.
+	StateDir string
+	// OutputFile is the output file of the primary process, alongside which the output files of the subProcesses
+	// are kept prefixed with their name.
+	OutputFile string
+}
+
+// DefaultPaths returns the paths of the standard distribution layout.
+func DefaultPaths() Paths {
+	return Paths{
+		StaticConfigFile: "service/bin/launcher-static.yml",
+		CustomConfigFile: "var/conf/launcher-custom.yml",
+		PidfileFormat:    "var/run/%s.pid",
+		StateDir:         "var/state",
+		OutputFile:       filepath.Join("var/log", outputLogFile),
+	}
+}
+
+// validate returns an error if the paths cannot be those of a service.
+func (p Paths) validate() error {
+	if strings.Count(p.PidfileFormat, "%s") != 1 || strings.Count(p.PidfileFormat, "%") != 1 {
+		return errors.Errorf("pidfile format must contain %%s exactly once, in place of the process name, but was '%s'",
+			p.PidfileFormat)
+	}
+	if p.StateDir == "" {
+		return errors.New("state directory must not be empty")
+	}
+	if p.OutputFile == "" {
+		return errors.New("output file must not be empty")
+	}
+	return nil
+}
+
+// setPaths sets the paths of the configuration files, and those of the pid, state, lock and output files derived from
+// the pidfile, state directory and output file.
+func (svc *Service) setPaths(paths Paths) {
+	svc.launcherStaticFile = paths.StaticConfigFile
+	svc.launcherCustomFile = paths.CustomConfigFile
+	svc.pidfileFormat = paths.PidfileFormat
+	svc.stateDir = paths.StateDir
+	svc.statefileFormat = filepath.Join(escapeFormat(paths.StateDir), "%s.state")
+	svc.lockfile = filepath.Join(paths.StateDir, "go-init.lock")
+	svc.logDir = filepath.Dir(paths.OutputFile)
+	svc.primaryOutputFile = paths.OutputFile
+	svc.subProcessOutputFileFormat = filepath.Join(escapeFormat(svc.logDir),
+		"%s-"+escapeFormat(filepath.Base(paths.OutputFile)))
+}
+
+// applyFileSettings sets the permissions of the pid, state and output files and their directories, the rotation of the
+// output files, the output files of the subProcesses, the output streams of the processes, the startup timeout, the
+// exit codes of status, the notifications, the preflight checks, the stop order and the plugins from the static
+// configuration. The defaults are kept if the configuration cannot be read, which the operations report themselves.
+func (svc *Service) applyFileSettings() {
+	svc.fileMode, svc.dirMode = launchlib.DefaultFileMode, launchlib.DefaultDirMode
+	svc.statusExitCodes = launchlib.StatusExitCodesLSB
+	svc.subProcessOutputFiles = map[string]string{}
+	svc.stopOrder = launchlib.StopOrderParallel
+	svc.plugins = launchlib.DefaultPluginsConfig
+	staticConfig, _, err := launchlib.GetConfigsFromFiles(svc.launcherStaticFile, svc.launcherCustomFile,
+		ioutil.Discard)
+	if err != nil {
+		return
+	}
+	if staticConfig.FileMode != 0 {
+		svc.fileMode = staticConfig.FileMode
+	}
+	if staticConfig.DirMode != 0 {
+		svc.dirMode = staticConfig.DirMode
+	}
+	svc.outputRotation = staticConfig.OutputRotation
+	if staticConfig.StatusExitCodes != "" {
+		svc.statusExitCodes = staticConfig.StatusExitCodes
+	}
+	svc.startupTimeout = staticConfig.StartupTimeout
+	svc.verifiedFiles = staticConfig.Verify
+	svc.preflight = staticConfig.Preflight
+	if staticConfig.StopOrder != "" {
+		svc.stopOrder = staticConfig.StopOrder
+	}
+	svc.plugins = staticConfig.Plugins.WithDefaults()
+	svc.notifications, svc.notificationService = staticConfig.Notifications.WithDefaults(), staticConfig.ServiceName
+	for name, subProcess := range staticConfig.SubProcesses {
+		if subProcess.OutputFile != "" {
+			svc.subProcessOutputFiles[name] = subProcess.OutputFile
+		}
+		svc.outputStreamFiles = append(svc.outputStreamFiles, outputStreamPaths(subProcess.OutputStreams)...)
+	}
+	svc.outputStreamFiles = append(svc.outputStreamFiles, outputStreamPaths(staticConfig.OutputStreams)...)
+}
+
+// escapeFormat escapes the given path for use in a format string.
+func escapeFormat(path string) string {
+	return strings.Replace(path, "%", "%%", -1)
+}
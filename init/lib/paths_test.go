@@ -0,0 +1,141 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// newTestService returns a service of the default paths that writes to buffers, which tests point at their own
+// directories.
+func newTestService(t *testing.T) *Service {
+	svc, err := NewService(DefaultPaths(), &bytes.Buffer{}, &bytes.Buffer{})
+	require.NoError(t, err)
+	return svc
+}
+
+func TestNewService(t *testing.T) {
+	svc, err := NewService(Paths{
+		StaticConfigFile: "/nonexistent/static.yml",
+		CustomConfigFile: "/nonexistent/custom.yml",
+		PidfileFormat:    "/run/pids/%s.pid",
+		StateDir:         "/run/state",
+		OutputFile:       "/logs/out.log",
+	}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "/nonexistent/static.yml", svc.launcherStaticFile)
+	assert.Equal(t, "/nonexistent/custom.yml", svc.launcherCustomFile)
+	assert.Equal(t, "/run/pids/%s.pid", svc.pidfileFormat)
+	assert.Equal(t, "/run/state", svc.stateDir)
+	assert.Equal(t, "/run/state/%s.state", svc.statefileFormat)
+	assert.Equal(t, "/run/state/go-init.lock", svc.lockfile)
+	assert.Equal(t, "/logs", svc.logDir)
+	assert.Equal(t, "/logs/out.log", svc.primaryOutputFile)
+	assert.Equal(t, "/logs/%s-out.log", svc.subProcessOutputFileFormat)
+	assert.Equal(t, os.Stdout, svc.stdout)
+	assert.Equal(t, os.Stderr, svc.stderr)
+}
+
+func TestNewServiceInvalidPidfile(t *testing.T) {
+	paths := DefaultPaths()
+	paths.PidfileFormat = "/run/service.pid"
+	_, err := NewService(paths, nil, nil)
+	assert.EqualError(t, err, "pidfile format must contain %s exactly once, in place of the process name, but was "+
+		"'/run/service.pid'")
+}
+
+func TestServicesOfDifferentPaths(t *testing.T) {
+	first, err := NewService(Paths{PidfileFormat: "first/%s.pid", StateDir: "first", OutputFile: "first/out.log"},
+		nil, nil)
+	require.NoError(t, err)
+	second, err := NewService(Paths{PidfileFormat: "second/%s.pid", StateDir: "second", OutputFile: "second/out.log"},
+		nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "first/sidecar-out.log", first.subProcessOutputFile("sidecar"))
+	assert.Equal(t, "second/sidecar-out.log", second.subProcessOutputFile("sidecar"))
+}
+
+func TestFileModesFromStaticConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-paths")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	staticFile := filepath.Join(dir, "launcher-static.yml")
+	require.NoError(t, ioutil.WriteFile(staticFile, []byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+fileMode: 0640
+dirMode: 0750
+statusExitCodes: s6
+`), 0644))
+
+	svc, err := NewService(Paths{
+		StaticConfigFile: staticFile,
+		CustomConfigFile: filepath.Join(dir, "launcher-custom.yml"),
+		PidfileFormat:    DefaultPaths().PidfileFormat,
+		StateDir:         DefaultPaths().StateDir,
+		OutputFile:       DefaultPaths().OutputFile,
+	}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), svc.fileMode)
+	assert.Equal(t, os.FileMode(0750), svc.dirMode)
+	assert.Equal(t, launchlib.StatusExitCodesS6, svc.statusExitCodes)
+}
+
+func TestSubProcessOutputFilesFromStaticConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-paths")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	staticFile := filepath.Join(dir, "launcher-static.yml")
+	require.NoError(t, ioutil.WriteFile(staticFile, []byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: envoy
+    outputFile: var/log/envoy.log
+  other:
+    configType: executable
+    executable: envoy
+`), 0644))
+
+	svc, err := NewService(Paths{
+		StaticConfigFile: staticFile,
+		CustomConfigFile: filepath.Join(dir, "launcher-custom.yml"),
+		PidfileFormat:    DefaultPaths().PidfileFormat,
+		StateDir:         DefaultPaths().StateDir,
+		OutputFile:       DefaultPaths().OutputFile,
+	}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "var/log/envoy.log", svc.subProcessOutputFile("sidecar"))
+	assert.Equal(t, "var/log/other-startup.log", svc.subProcessOutputFile("other"))
+}
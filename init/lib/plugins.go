@@ -0,0 +1,175 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// pluginResponse is what a plugin may write to its stdout on the preStart event of a process.
+type pluginResponse struct {
+	// Env are the environment variables to set for the process, which must be allowed by the envAllowlist.
+	Env map[string]string `json:"env"`
+}
+
+// pluginPaths returns the paths of the plugins, the executable files of the plugins directory other than hidden files,
+// in the order of their names. There are none if the directory does not exist.
+func (svc *Service) pluginPaths() ([]string, error) {
+	infos, err := ioutil.ReadDir(svc.plugins.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list plugins")
+	}
+	var paths []string
+	for _, info := range infos {
+		path := filepath.Join(svc.plugins.Dir, info.Name())
+		// Plugins may be symlinks to executables installed elsewhere.
+		if stat, err := os.Stat(path); err != nil || strings.HasPrefix(info.Name(), ".") || !stat.Mode().IsRegular() ||
+			stat.Mode()&0111 == 0 {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// runPlugin runs the plugin with the payload on its stdin within the timeout of the plugins, returning its stdout.
+func (svc *Service) runPlugin(path string, payload []byte) ([]byte, error) {
+	pluginCtx, cancel := context.WithTimeout(context.Background(), svc.plugins.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(pluginCtx, path)
+	cmd.Stdin = bytes.NewReader(payload)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	err := cmd.Run()
+	if err == nil {
+		return stdout.Bytes(), nil
+	}
+	if pluginCtx.Err() == context.DeadlineExceeded {
+		err = errors.Errorf("timed out after %v", svc.plugins.Timeout)
+	}
+	if stderr.Len() > 0 {
+		return nil, errors.Errorf("%v\nstderr:\n%s", err, tailLines(stderr.String(), hookStderrTailLines))
+	}
+	return nil, err
+}
+
+// notifyPlugins passes the notification to each of the plugins. Failures are reported to stdout rather than returned,
+// as the event has already happened.
+func (svc *Service) notifyPlugins(stdout io.Writer, n notification) {
+	paths, err := svc.pluginPaths()
+	if err != nil {
+		fmt.Fprintln(stdout, err)
+		return
+	}
+	if len(paths) == 0 {
+		return
+	}
+	payload, err := json.Marshal(n)
+	if err != nil {
+		fmt.Fprintf(stdout, "failed to serialize %s event of process '%s': %v\n", n.Event, n.Process, err)
+		return
+	}
+	for _, path := range paths {
+		if _, err := svc.runPlugin(path, payload); err != nil {
+			fmt.Fprintf(stdout, "plugin %s failed on %s event of process '%s': %v\n", filepath.Base(path), n.Event,
+				n.Process, err)
+		}
+	}
+}
+
+// runPreStartPlugins passes the preStart event of the named process to each of the plugins in turn. A plugin vetoes the
+// start of the process by failing, and may respond with the environment variables to set for it, which must be allowed
+// by the envAllowlist of the plugins.
+func (svc *Service) runPreStartPlugins(stdout io.Writer, name string, cmd *exec.Cmd) error {
+	paths, err := svc.pluginPaths()
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	n := notification{Event: launchlib.PluginEventPreStart, Service: svc.notificationService, Process: name,
+		Time: Clock.Now()}
+	n.Host, _ = os.Hostname()
+	payload, err := json.Marshal(n)
+	if err != nil {
+		return errors.Wrapf(err, "failed to serialize preStart event of process '%s'", name)
+	}
+
+	for _, path := range paths {
+		plugin := filepath.Base(path)
+		output, err := svc.runPlugin(path, payload)
+		if err != nil {
+			return errors.Wrapf(err, "plugin %s vetoed the start of process '%s'", plugin, name)
+		}
+		if len(bytes.TrimSpace(output)) == 0 {
+			continue
+		}
+		var response pluginResponse
+		if err := json.Unmarshal(output, &response); err != nil {
+			return errors.Wrapf(err, "plugin %s responded to the preStart event of process '%s' with invalid JSON",
+				plugin, name)
+		}
+		keys := make([]string, 0, len(response.Env))
+		for key := range response.Env {
+			if key == "" || strings.ContainsAny(key, "=\x00") || !svc.plugins.AllowsEnv(key) {
+				return errors.Errorf("plugin %s may not set environment variable '%s' of process '%s', as it is "+
+					"not allowed by the envAllowlist of the plugins", plugin, key, name)
+			}
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		for _, key := range keys {
+			cmd.Env = setEnv(cmd.Env, key, response.Env[key])
+		}
+		if len(keys) > 0 {
+			fmt.Fprintf(stdout, "Plugin %s set environment variables %v of process '%s'\n", plugin, keys, name)
+		}
+	}
+	return nil
+}
+
+// setEnv returns env, of the form KEY=VALUE, with the variable key set to value in place of any value it had, so that
+// a process restarted with the same command does not accumulate values.
+func setEnv(env []string, key, value string) []string {
+	updated := make([]string, 0, len(env)+1)
+	for _, entry := range env {
+		if !strings.HasPrefix(entry, key+"=") {
+			updated = append(updated, entry)
+		}
+	}
+	return append(updated, key+"="+value)
+}
@@ -0,0 +1,115 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func writePlugin(t *testing.T, dir, name, script string, mode os.FileMode) {
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+script+"\n"), mode))
+}
+
+func readPluginEvent(t *testing.T, path string) notification {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	var n notification
+	require.NoError(t, json.Unmarshal(data, &n))
+	return n
+}
+
+func TestRunPreStartPlugins(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "plugins")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	writePlugin(t, dir, "10-license", `cat > "$0.event"; echo '{"env": {"LICENSE_KEY": "abc"}}'`, 0755)
+	writePlugin(t, dir, "20-inventory", `cat > "$0.event"`, 0755)
+	// Hidden and non-executable files are not plugins.
+	writePlugin(t, dir, ".disabled", "exit 1", 0755)
+	writePlugin(t, dir, "README", "exit 1", 0644)
+	svc.notificationService = "my-service"
+
+	svc.plugins = launchlib.PluginsConfig{Dir: dir, Timeout: 5 * time.Second, EnvAllowlist: []string{"LICENSE_*"}}
+	cmd := exec.Command("true")
+	cmd.Env = []string{"PATH=/bin", "LICENSE_KEY=old"}
+	stdout := &bytes.Buffer{}
+	require.NoError(t, svc.runPreStartPlugins(stdout, "primary", cmd))
+	assert.Equal(t, []string{"PATH=/bin", "LICENSE_KEY=abc"}, cmd.Env)
+	assert.Contains(t, stdout.String(), "Plugin 10-license set environment variables [LICENSE_KEY] of process 'primary'")
+	for _, plugin := range []string{"10-license", "20-inventory"} {
+		event := readPluginEvent(t, filepath.Join(dir, plugin+".event"))
+		assert.Equal(t, launchlib.PluginEventPreStart, event.Event, plugin)
+		assert.Equal(t, "my-service", event.Service, plugin)
+		assert.Equal(t, "primary", event.Process, plugin)
+	}
+
+	// Plugins may only set the environment variables of the envAllowlist.
+	svc.plugins.EnvAllowlist = nil
+	assert.EqualError(t, svc.runPreStartPlugins(stdout, "primary", cmd), "plugin 10-license may not set environment "+
+		"variable 'LICENSE_KEY' of process 'primary', as it is not allowed by the envAllowlist of the plugins")
+
+	// A failing plugin vetoes the start, and later plugins are not run.
+	require.NoError(t, os.Remove(filepath.Join(dir, "20-inventory.event")))
+	writePlugin(t, dir, "10-license", `echo "license expired" >&2; exit 3`, 0755)
+	assert.EqualError(t, svc.runPreStartPlugins(stdout, "primary", cmd), "plugin 10-license vetoed the start of process "+
+		"'primary': exit status 3\nstderr:\nlicense expired")
+	_, err = os.Stat(filepath.Join(dir, "20-inventory.event"))
+	assert.True(t, os.IsNotExist(err))
+
+	// There are no plugins if the directory does not exist.
+	svc.plugins.Dir = filepath.Join(dir, "nonexistent")
+	assert.NoError(t, svc.runPreStartPlugins(stdout, "primary", cmd))
+}
+
+func TestNotifyPlugins(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "plugins")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	writePlugin(t, dir, "10-inventory", `cat > "$0.event"`, 0755)
+	writePlugin(t, dir, "20-failing", `echo "registry unavailable" >&2; exit 1`, 0755)
+	svc.plugins = launchlib.PluginsConfig{Dir: dir, Timeout: 5 * time.Second}
+	svc.notifications, svc.notificationService = launchlib.NotificationsConfig{}, "my-service"
+
+	stdout := &bytes.Buffer{}
+	svc.notify(stdout, exitNotification(launchlib.NotificationEventCrash, "primary", exec.Command("false").Run()))
+
+	// Plugins are passed every event whether or not notifications are enabled.
+	event := readPluginEvent(t, filepath.Join(dir, "10-inventory.event"))
+	assert.Equal(t, launchlib.NotificationEventCrash, event.Event)
+	assert.Equal(t, "my-service", event.Service)
+	assert.Equal(t, "primary", event.Process)
+	require.NotNil(t, event.ExitCode)
+	assert.Equal(t, 1, *event.ExitCode)
+	assert.Contains(t, stdout.String(), "plugin 20-failing failed on crash event of process 'primary': exit status 1\n"+
+		"stderr:\nregistry unavailable")
+}
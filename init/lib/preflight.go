@@ -0,0 +1,130 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// runPreflightChecks makes each of the preflight checks, and checks that the ports of the commands about to be started
+// are free, returning an error describing every check that failed so that the service is not started.
+func (svc *Service) runPreflightChecks(stdout io.Writer, cmds map[string]CommandContext) error {
+	var failures []string
+	checks := 0
+	minFreeBytes := svc.preflight.MinFreeBytes()
+	paths := make([]string, 0, len(minFreeBytes))
+	for path := range minFreeBytes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		checks++
+		if err := checkFreeSpace(path, minFreeBytes[path]); err != nil {
+			failures = append(failures, "- "+err.Error())
+		}
+	}
+	if svc.preflight.WritablePidfileDir {
+		checks++
+		if err := checkWritableDir(filepath.Dir(fmt.Sprintf(svc.pidfileFormat, "preflight"))); err != nil {
+			failures = append(failures, "- "+err.Error())
+		}
+	}
+	names := commandNames(cmds)
+	sort.Strings(names)
+	for _, name := range names {
+		for _, port := range cmds[name].Ports {
+			checks++
+			if err := checkPortFree(name, port); err != nil {
+				failures = append(failures, "- "+err.Error())
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return errors.Errorf("%d preflight checks failed:\n%s", len(failures), strings.Join(failures, "\n"))
+	}
+	if checks > 0 {
+		fmt.Fprintf(stdout, "Passed %d preflight checks\n", checks)
+	}
+	return nil
+}
+
+// checkFreeSpace returns an error if the volume of path, or of its closest existing ancestor if it does not exist yet,
+// has less than minFreeBytes free.
+func checkFreeSpace(path string, minFreeBytes uint64) error {
+	existing := path
+	for {
+		if _, err := os.Stat(existing); err == nil || filepath.Dir(existing) == existing {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	free, err := freeBytes(existing)
+	if err != nil {
+		return errors.Wrapf(err, "failed to determine the free space of %s", path)
+	}
+	if free < minFreeBytes {
+		return errors.Errorf("%s has %d bytes free, less than its minFreeSpace of %d bytes", path, free,
+			minFreeBytes)
+	}
+	return nil
+}
+
+// checkPortFree returns an error naming the process that listens on the port, where it can be found, if the command of
+// the named process could not listen on it. Other failures to listen, such as on a privileged port that go-init itself
+// may not listen on, are ignored, as they do not apply to the command.
+func checkPortFree(name string, port int) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err == nil {
+		_ = listener.Close()
+		return nil
+	}
+	if !isAddrInUse(err) {
+		return nil
+	}
+	if pid, err := portOwner(port); err == nil {
+		return errors.Errorf("port %d of process '%s' is already in use by pid %d", port, name, pid)
+	}
+	return errors.Errorf("port %d of process '%s' is already in use", port, name)
+}
+
+func isAddrInUse(err error) bool {
+	if opErr, ok := err.(*net.OpError); ok {
+		err = opErr.Err
+	}
+	if syscallErr, ok := err.(*os.SyscallError); ok {
+		err = syscallErr.Err
+	}
+	return err == syscall.EADDRINUSE
+}
+
+// checkWritableDir returns an error if a file cannot be created in dir.
+func checkWritableDir(dir string) error {
+	file, err := ioutil.TempFile(dir, ".preflight")
+	if err != nil {
+		return errors.Wrapf(err, "pidfile directory %s is not writable", dir)
+	}
+	_ = file.Close()
+	return os.Remove(file.Name())
+}
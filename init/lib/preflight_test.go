@@ -0,0 +1,118 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestRunPreflightChecks(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-preflight")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	readOnly := filepath.Join(dir, "readonly")
+	require.NoError(t, os.Mkdir(readOnly, 0555))
+	writable := filepath.Join(dir, "writable")
+	require.NoError(t, os.Mkdir(writable, 0755))
+
+	for i, currCase := range []struct {
+		config     launchlib.PreflightConfig
+		pidfileDir string
+		want       string
+		err        string
+	}{
+		{want: ""},
+		{config: launchlib.PreflightConfig{
+			MinFreeSpace:       map[string]string{filepath.Join(dir, "var/log"): "1K"},
+			WritablePidfileDir: true,
+		}, pidfileDir: writable, want: "Passed 2 preflight checks\n"},
+		{config: launchlib.PreflightConfig{
+			MinFreeSpace: map[string]string{"var/data": "1024T", "var/log": "1024T"},
+		}, err: "2 preflight checks failed:\n- var/data has "},
+	} {
+		if currCase.pidfileDir != "" {
+			svc.setPaths(Paths{
+				StaticConfigFile: svc.launcherStaticFile,
+				CustomConfigFile: svc.launcherCustomFile,
+				PidfileFormat:    filepath.Join(currCase.pidfileDir, "%s.pid"),
+				StateDir:         svc.stateDir,
+				OutputFile:       svc.primaryOutputFile,
+			})
+		}
+		svc.preflight = currCase.config
+		stdout := &bytes.Buffer{}
+		err := svc.runPreflightChecks(stdout, nil)
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+			assert.Equal(t, currCase.want, stdout.String(), "Case %d", i)
+		} else {
+			require.Error(t, err, "Case %d", i)
+			assert.Contains(t, err.Error(), currCase.err, "Case %d", i)
+			assert.Contains(t, err.Error(), "\n- var/log has ", "Case %d", i)
+		}
+	}
+
+	if os.Geteuid() != 0 {
+		svc.setPaths(Paths{
+			StaticConfigFile: svc.launcherStaticFile,
+			CustomConfigFile: svc.launcherCustomFile,
+			PidfileFormat:    filepath.Join(readOnly, "%s.pid"),
+			StateDir:         svc.stateDir,
+			OutputFile:       svc.primaryOutputFile,
+		})
+		svc.preflight = launchlib.PreflightConfig{WritablePidfileDir: true}
+		err := svc.runPreflightChecks(&bytes.Buffer{}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "pidfile directory "+readOnly+" is not writable")
+	}
+}
+
+func TestRunPreflightChecks_Ports(t *testing.T) {
+	svc := newTestService(t)
+	svc.preflight = launchlib.PreflightConfig{}
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	used := listener.Addr().(*net.TCPAddr).Port
+	free, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	unused := free.Addr().(*net.TCPAddr).Port
+	require.NoError(t, free.Close())
+
+	stdout := &bytes.Buffer{}
+	require.NoError(t, svc.runPreflightChecks(stdout, map[string]CommandContext{"primary": {Ports: []int{unused}}}))
+	assert.Equal(t, "Passed 1 preflight checks\n", stdout.String())
+
+	err = svc.runPreflightChecks(stdout, map[string]CommandContext{
+		"primary": {Ports: []int{unused}},
+		"sidecar": {Ports: []int{used}},
+	})
+	require.NoError(t, listener.Close())
+	assert.EqualError(t, err, fmt.Sprintf("1 preflight checks failed:\n- port %d of process 'sidecar' is already in "+
+		"use by pid %d", used, os.Getpid()))
+}
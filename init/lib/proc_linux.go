@@ -0,0 +1,205 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// clockTicksPerSecond is the value of sysconf(_SC_CLK_TCK), which is fixed at 100 on all supported Linux platforms.
+const clockTicksPerSecond = 100
+
+// processStartTime returns the time at which the process with the given pid was started, as recorded in /proc.
+func processStartTime(pid int) (time.Time, error) {
+	startTicks, err := processStartTicks(pid)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	bootTime, err := systemBootTime()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return bootTime.Add(time.Duration(startTicks) * time.Second / clockTicksPerSecond), nil
+}
+
+// processStartTicks returns the start time of the process in clock ticks since boot, which, unlike its pid, is not
+// reused by a later process.
+func processStartTicks(pid int) (uint64, error) {
+	fields, err := readProcessStat(pid)
+	if err != nil {
+		return 0, err
+	}
+	startTicks, err := strconv.ParseUint(fields[statStartTime], 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse start time for pid %d", pid)
+	}
+	return startTicks, nil
+}
+
+// processCPUTime returns the user and system CPU time consumed by the process since it started.
+func processCPUTime(pid int) (time.Duration, error) {
+	fields, err := readProcessStat(pid)
+	if err != nil {
+		return 0, err
+	}
+	var ticks int64
+	for _, field := range []int{statUserTime, statSystemTime} {
+		fieldTicks, err := strconv.ParseInt(fields[field], 10, 64)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to parse CPU time for pid %d", pid)
+		}
+		ticks += fieldTicks
+	}
+	return time.Duration(ticks) * time.Second / clockTicksPerSecond, nil
+}
+
+func processThreads(pid int) (int, error) {
+	fields, err := readProcessStat(pid)
+	if err != nil {
+		return 0, err
+	}
+	threads, err := strconv.Atoi(fields[statThreads])
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse thread count for pid %d", pid)
+	}
+	return threads, nil
+}
+
+// processOpenFiles returns the number of file descriptors the process has open, which can only be read for processes
+// of the same user unless running as root.
+func processOpenFiles(pid int) (int, error) {
+	fds, err := ioutil.ReadDir(fmt.Sprintf("/proc/%d/fd", pid))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read file descriptors for pid %d", pid)
+	}
+	return len(fds), nil
+}
+
+// Indices of the fields returned by readProcessStat, which are offset by two from those documented in proc(5) as the
+// pid and command name are omitted.
+const (
+	statState      = 0
+	statParentPid  = 1
+	statUserTime   = 11
+	statSystemTime = 12
+	statThreads    = 17
+	statStartTime  = 19
+)
+
+// readProcessStat returns the fields of /proc/<pid>/stat that follow the pid and command name.
+func readProcessStat(pid int) ([]string, error) {
+	statBytes, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read stat for pid %d", pid)
+	}
+	// The command name is surrounded by parentheses and may itself contain spaces, so only split what follows it.
+	stat := string(statBytes)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) <= statStartTime {
+		return nil, errors.Errorf("unexpected format of stat for pid %d", pid)
+	}
+	return fields, nil
+}
+
+func systemBootTime() (time.Time, error) {
+	statBytes, err := ioutil.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "failed to read /proc/stat")
+	}
+	for _, line := range strings.Split(string(statBytes), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "btime" {
+			seconds, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return time.Time{}, errors.Wrap(err, "failed to parse boot time")
+			}
+			return time.Unix(seconds, 0), nil
+		}
+	}
+	return time.Time{}, errors.New("no boot time found in /proc/stat")
+}
+
+// processResidentBytes returns the resident memory of the process with the given pid, as recorded in /proc.
+func processResidentBytes(pid int) (uint64, error) {
+	statusBytes, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to read status for pid %d", pid)
+	}
+	for _, line := range strings.Split(string(statusBytes), "\n") {
+		// The resident memory is given in kB, e.g. "VmRSS:	  123456 kB".
+		if fields := strings.Fields(line); len(fields) == 3 && fields[0] == "VmRSS:" {
+			kilobytes, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, errors.Wrapf(err, "failed to parse resident memory for pid %d", pid)
+			}
+			return kilobytes * 1024, nil
+		}
+	}
+	return 0, errors.Errorf("no resident memory found in status for pid %d", pid)
+}
+
+// tcpListenState is the state of a listening socket in /proc/net/tcp, TCP_LISTEN in hexadecimal.
+const tcpListenState = "0A"
+
+// portOwner returns the pid of a process with a socket listening on the TCP port, as found in /proc, where the sockets
+// of the processes of other users are only visible to root.
+func portOwner(port int) (int, error) {
+	sockets := map[string]bool{}
+	localPort := fmt.Sprintf(":%04X", port)
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		tableBytes, err := ioutil.ReadFile(table)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(tableBytes), "\n") {
+			// The fields are the slot, local and remote addresses, state, queues, timer, retransmits, uid, timeout and
+			// inode of the socket, following a header line that has fewer fields.
+			fields := strings.Fields(line)
+			if len(fields) >= 10 && fields[3] == tcpListenState && strings.HasSuffix(fields[1], localPort) {
+				sockets[fmt.Sprintf("socket:[%s]", fields[9])] = true
+			}
+		}
+	}
+	if len(sockets) == 0 {
+		return 0, errors.Errorf("no socket listening on port %d found", port)
+	}
+
+	fdDirs, err := filepath.Glob("/proc/[0-9]*/fd")
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to list processes")
+	}
+	for _, fdDir := range fdDirs {
+		dir, err := os.Open(fdDir)
+		if err != nil {
+			continue
+		}
+		fds, _ := dir.Readdirnames(-1)
+		_ = dir.Close()
+		for _, fd := range fds {
+			if link, err := os.Readlink(filepath.Join(fdDir, fd)); err == nil && sockets[link] {
+				return strconv.Atoi(filepath.Base(filepath.Dir(fdDir)))
+			}
+		}
+	}
+	return 0, errors.Errorf("no process listening on port %d found", port)
+}
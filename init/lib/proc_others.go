@@ -0,0 +1,52 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package lib
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func processStartTime(pid int) (time.Time, error) {
+	return time.Time{}, errors.New("process start time is only available on Linux")
+}
+
+func processStartTicks(pid int) (uint64, error) {
+	return 0, errors.New("process start time is only available on Linux")
+}
+
+func processCPUTime(pid int) (time.Duration, error) {
+	return 0, errors.New("process CPU time is only available on Linux")
+}
+
+func processThreads(pid int) (int, error) {
+	return 0, errors.New("process thread count is only available on Linux")
+}
+
+func processOpenFiles(pid int) (int, error) {
+	return 0, errors.New("process open files are only available on Linux")
+}
+
+func processResidentBytes(pid int) (uint64, error) {
+	return 0, errors.New("process resident memory is only available on Linux")
+}
+
+func portOwner(port int) (int, error) {
+	return 0, errors.New("the processes listening on ports are only known on Linux")
+}
@@ -0,0 +1,44 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package lib
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// reopenSignal makes 'go-init supervise' reopen its output files.
+const reopenSignal = syscall.SIGUSR2
+
+func isProcRunning(proc *os.Process) bool {
+	// This is the way to check if a process exists: https://linux.die.net/man/2/kill.
+	return proc.Signal(syscall.Signal(0)) == nil
+}
+
+// runAsUserOf makes the tool command run as the user and group that the command runs as.
+func runAsUserOf(tool *exec.Cmd, cmd *exec.Cmd) {
+	if attr := cmd.SysProcAttr; attr != nil && attr.Credential != nil {
+		tool.SysProcAttr = &syscall.SysProcAttr{Credential: attr.Credential}
+	}
+}
+
+// chrooted returns whether the command runs in a chroot.
+func chrooted(cmd *exec.Cmd) bool {
+	return cmd.SysProcAttr != nil && cmd.SysProcAttr.Chroot != ""
+}
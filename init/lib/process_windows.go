@@ -0,0 +1,51 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package lib
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// reopenSignal would make 'go-init supervise' reopen its output files, but cannot be sent on windows, which has no
+// SIGUSR2, so rotate-logs fails there.
+const reopenSignal = syscall.Signal(0x1f)
+
+// stillActive is the exit code of a process that has not exited, STILL_ACTIVE.
+const stillActive = 259
+
+func isProcRunning(proc *os.Process) bool {
+	handle, err := syscall.OpenProcess(syscall.PROCESS_QUERY_INFORMATION, false, uint32(proc.Pid))
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = syscall.CloseHandle(handle)
+	}()
+	var code uint32
+	return syscall.GetExitCodeProcess(handle, &code) == nil && code == stillActive
+}
+
+// runAsUserOf does nothing, as commands always run as the user of go-init on windows.
+func runAsUserOf(tool *exec.Cmd, cmd *exec.Cmd) {}
+
+// chrooted returns false, as windows has no chroot.
+func chrooted(cmd *exec.Cmd) bool {
+	return false
+}
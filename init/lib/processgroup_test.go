@@ -0,0 +1,79 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessGroup_NotLeader(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	assert.Equal(t, 0, processGroup(cmd.Process))
+}
+
+func TestSignalProcessGroup(t *testing.T) {
+	// The spawned sleep ignores SIGTERM and holds stdout open, so reading stdout to its end only completes once it is
+	// killed along with the rest of the group.
+	cmd := exec.Command("/bin/sh", "-c", `(trap "" TERM; sleep 30) & echo started; wait`)
+	stdout, writer, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() {
+		_ = stdout.Close()
+	}()
+	cmd.Stdout = writer
+	inOwnProcessGroup(cmd)
+	require.NoError(t, cmd.Start())
+	require.NoError(t, writer.Close())
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "started\n", line)
+
+	pgid := processGroup(cmd.Process)
+	assert.Equal(t, cmd.Process.Pid, pgid)
+	require.NoError(t, signalProcessGroup(cmd.Process, syscall.SIGTERM))
+	require.Error(t, cmd.Wait())
+
+	killProcessGroupRemnants(pgid)
+	assert.True(t, readsToEnd(stdout, 5*time.Second), "spawned process was not killed")
+}
+
+func readsToEnd(reader io.Reader, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, reader)
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
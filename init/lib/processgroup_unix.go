@@ -0,0 +1,60 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package lib
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// inOwnProcessGroup starts the command as the leader of a new process group, so that the processes it spawns, such as
+// shell-outs of the JVM, can be signalled along with it rather than outliving it and holding its ports and files.
+func inOwnProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// processGroup returns the id of the process group led by the process, or 0 if it does not lead one, as with processes
+// started by earlier versions of go-init or that moved themselves into a group of their own.
+func processGroup(proc *os.Process) int {
+	pgid, err := syscall.Getpgid(proc.Pid)
+	if err != nil || pgid != proc.Pid {
+		return 0
+	}
+	return pgid
+}
+
+// signalProcessGroup sends the signal to every process in the group led by the process, or to only the process if it
+// does not lead one.
+func signalProcessGroup(proc *os.Process, sig syscall.Signal) error {
+	if pgid := processGroup(proc); pgid != 0 {
+		return syscall.Kill(-pgid, sig)
+	}
+	return proc.Signal(sig)
+}
+
+// killProcessGroupRemnants sends a SIGKILL to any processes that remain in the given process group once its leader has
+// exited. The id of a group is not reused while any process remains in it, so this cannot signal unrelated processes.
+func killProcessGroupRemnants(pgid int) {
+	if pgid != 0 {
+		_ = syscall.Kill(-pgid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package lib
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// inOwnProcessGroup starts the command in a new process group, so that it does not receive the console control events
+// of go-init.
+func inOwnProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// processGroup returns 0, as processes cannot be signalled by group on windows.
+func processGroup(proc *os.Process) int {
+	return 0
+}
+
+// signalProcessGroup kills the process, as windows cannot send it any other signal, so that processes are stopped by
+// their stop URL, if any, and otherwise killed.
+func signalProcessGroup(proc *os.Process, sig syscall.Signal) error {
+	return proc.Kill()
+}
+
+// killProcessGroupRemnants does nothing, as processes are not signalled by group on windows.
+func killProcessGroupRemnants(pgid int) {}
@@ -0,0 +1,74 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+)
+
+// startZombieReaper reaps zombie processes that are re-parented to go-init, as happens to orphaned descendants of the
+// service when go-init runs as PID 1 in a container. The managed processes are left for their own callers to wait on.
+// The returned function stops the reaper.
+func (svc *Service) startZombieReaper(managed map[int]struct{}) (stop func()) {
+	sigchld := make(chan os.Signal, 1)
+	signal.Notify(sigchld, syscall.SIGCHLD)
+	done := make(chan struct{})
+	go func() {
+		for {
+			svc.reapZombies(managed)
+			select {
+			case <-sigchld:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigchld)
+		close(done)
+	}
+}
+
+func (svc *Service) reapZombies(managed map[int]struct{}) {
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		fmt.Fprintln(svc.stdout, "failed to list processes to reap:", err)
+		return
+	}
+	self := strconv.Itoa(os.Getpid())
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if _, ok := managed[pid]; ok {
+			continue
+		}
+		// Processes may exit while we iterate, so failing to read one is expected.
+		fields, err := readProcessStat(pid)
+		if err != nil || fields[statParentPid] != self || fields[statState] != "Z" {
+			continue
+		}
+		var waitStatus syscall.WaitStatus
+		if _, err := syscall.Wait4(pid, &waitStatus, syscall.WNOHANG, nil); err != nil {
+			fmt.Fprintf(svc.stdout, "failed to reap zombie process %d: %v\n", pid, err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"syscall"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// Reload asks the running processes of the given names, or all processes if none are given, to reload their
+// configuration by sending each its reloadSignal, as 'go-init reload' does.
+func (svc *Service) Reload(processes ...string) error {
+	return svc.executeWithLoggers(NewAlwaysAppending(), func(svc *Service, loggers launchlib.ServiceLoggers) error {
+		return svc.reload(loggers, processes)
+	})
+}
+
+func (svc *Service) reload(loggers launchlib.ServiceLoggers, names []string) error {
+	allCmds, err := svc.getConfiguredCommands(loggers)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(
+			errors.Wrap(err, "failed to get commands from static and custom configuration files"), 4)
+	}
+	cmds, err := selectNamedCommands(names, allCmds)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 4)
+	}
+
+	signals := make(map[string]syscall.Signal, len(cmds))
+	for name, cmd := range cmds {
+		signals[name] = launchlib.DefaultReloadSignal
+		if cmd.ReloadSignal != "" {
+			if signals[name], err = launchlib.ParseSignal(cmd.ReloadSignal); err != nil {
+				return svc.logErrorAndReturnWithExitCode(errors.Wrapf(err, "invalid reloadSignal of '%s'", name), 4)
+			}
+		}
+	}
+	return svc.signalProcesses(signals)
+}
+
+// signalProcesses sends each of the named processes its signal if all of them are running, returning an error with
+// the exit code of 'go-init reload' and 'go-init signal' otherwise.
+func (svc *Service) signalProcesses(signals map[string]syscall.Signal) error {
+	procs := make(map[string]*os.Process, len(signals))
+	var notRunning []string
+	for name := range signals {
+		_, proc, err := svc.getCmdProcess(name)
+		if err != nil {
+			return svc.logErrorAndReturnWithExitCode(errors.Wrap(err, "failed to determine process status"), 4)
+		}
+		if proc == nil {
+			notRunning = append(notRunning, name)
+			continue
+		}
+		procs[name] = proc
+	}
+	if len(notRunning) > 0 {
+		sort.Strings(notRunning)
+		return svc.logErrorAndReturnWithExitCode(errors.Errorf("commands '%v' are not running", notRunning), 3)
+	}
+
+	names := processNames(procs)
+	sort.Strings(names)
+	var failed []string
+	for _, name := range names {
+		if err := procs[name].Signal(signals[name]); err != nil {
+			fmt.Fprintf(svc.stdout, "failed to send %v to '%s' process: %v\n", signals[name], name, err)
+			failed = append(failed, name)
+			continue
+		}
+		fmt.Fprintf(svc.stdout, "Sent %v to process '%s' with pid %d\n", signals[name], name, procs[name].Pid)
+	}
+	if len(failed) > 0 {
+		return svc.logErrorAndReturnWithExitCode(errors.Errorf("failed to signal processes '%v'", failed), 1)
+	}
+	return nil
+}
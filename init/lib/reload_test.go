@@ -0,0 +1,58 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// To prevent accidental changes to parameter default values
+func TestSignalProcesses(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-reload")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc.pidfileFormat, svc.statefileFormat = filepath.Join(dir, "%s.pid"), filepath.Join(dir, "%s.state")
+
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+	}()
+	require.NoError(t, svc.writePidfile("primary", cmd.Process.Pid))
+
+	svc.stdout = ioutil.Discard
+
+	err = svc.signalProcesses(map[string]syscall.Signal{"primary": syscall.SIGHUP, "sidecar": syscall.SIGHUP})
+	require.Error(t, err)
+	assert.Equal(t, 3, err.(cli.ExitCoder).ExitCode())
+	assert.EqualError(t, err, "commands '[sidecar]' are not running")
+
+	require.NoError(t, svc.signalProcesses(map[string]syscall.Signal{"primary": syscall.SIGHUP}))
+	waitErr := cmd.Wait()
+	require.Error(t, waitErr)
+	assert.Equal(t, "signal: hangup", waitErr.Error())
+}
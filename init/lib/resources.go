@@ -0,0 +1,166 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// resourceLogFileName is the name of the resource log in the log directory, without its extension, which is that of
+// its format.
+const resourceLogFileName = "resources"
+
+var resourceLogColumns = []string{"time", "process", "pid", "residentBytes", "cpuPercent", "openFiles", "threads"}
+
+// resourceSample is the resource usage of a process at a point in time, a line of the resource log. Values that
+// cannot be read, such as the open files of a process of another user, are zero.
+type resourceSample struct {
+	Time          time.Time `json:"time"`
+	Process       string    `json:"process"`
+	Pid           int       `json:"pid"`
+	ResidentBytes uint64    `json:"residentBytes"`
+	CPUPercent    float64   `json:"cpuPercent"`
+	OpenFiles     int       `json:"openFiles"`
+	Threads       int       `json:"threads"`
+}
+
+func (s resourceSample) record() []string {
+	return []string{s.Time.UTC().Format(time.RFC3339), s.Process, strconv.Itoa(s.Pid),
+		strconv.FormatUint(s.ResidentBytes, 10), strconv.FormatFloat(s.CPUPercent, 'f', -1, 64),
+		strconv.Itoa(s.OpenFiles), strconv.Itoa(s.Threads)}
+}
+
+// cpuReading is the CPU time a process had consumed at a point in time, from which the CPU usage until the next sample
+// is derived.
+type cpuReading struct {
+	at  time.Time
+	cpu time.Duration
+}
+
+// resourceSampler appends the resource usage of the running processes to the resource log, which is rotated along
+// with the output files.
+type resourceSampler struct {
+	service *Service
+	path    string
+	format  string
+	// cpuReadings are the readings of the previous sample by pid, so that the CPU usage of each sample is that since
+	// the previous sample rather than since the process started.
+	cpuReadings map[int]cpuReading
+}
+
+func (svc *Service) newResourceSampler(config launchlib.ResourceLogConfig) *resourceSampler {
+	config = config.WithDefaults()
+	return &resourceSampler{
+		service:     svc,
+		path:        filepath.Join(svc.logDir, resourceLogFileName+"."+config.Format),
+		format:      config.Format,
+		cpuReadings: map[int]cpuReading{},
+	}
+}
+
+// sample appends a sample of each of the running processes to the resource log.
+func (r *resourceSampler) sample(running map[string]*os.Process) error {
+	now := Clock.Now()
+	names := make([]string, 0, len(running))
+	for name := range running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	samples := make([]resourceSample, len(names))
+	readings := make(map[int]cpuReading, len(names))
+	for i, name := range names {
+		pid := running[name].Pid
+		samples[i] = resourceSample{Time: now, Process: name, Pid: pid}
+		if residentBytes, err := processResidentBytes(pid); err == nil {
+			samples[i].ResidentBytes = residentBytes
+		}
+		if threads, err := processThreads(pid); err == nil {
+			samples[i].Threads = threads
+		}
+		if openFiles, err := processOpenFiles(pid); err == nil {
+			samples[i].OpenFiles = openFiles
+		}
+		cpu, err := processCPUTime(pid)
+		if err != nil {
+			continue
+		}
+		readings[pid] = cpuReading{at: now, cpu: cpu}
+		previous, ok := r.cpuReadings[pid]
+		if !ok {
+			startTime, err := processStartTime(pid)
+			if err != nil {
+				continue
+			}
+			previous = cpuReading{at: startTime}
+		}
+		if elapsed := now.Sub(previous.at); elapsed > 0 {
+			samples[i].CPUPercent = math.Round(1000*float64(cpu-previous.cpu)/float64(elapsed)) / 10
+		}
+	}
+	r.cpuReadings = readings
+	if len(samples) == 0 {
+		return nil
+	}
+	return errors.Wrapf(r.append(samples), "failed to write resource log '%s'", r.path)
+}
+
+func (r *resourceSampler) append(samples []resourceSample) error {
+	if err := r.service.rotateOutputFile(r.path, false); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(r.path, appendOutputFileFlag, r.service.fileMode)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	if r.format == launchlib.ResourceLogFormatJSON {
+		encoder := json.NewEncoder(file)
+		for _, sample := range samples {
+			if err := encoder.Encode(sample); err != nil {
+				_ = file.Close()
+				return err
+			}
+		}
+		return file.Close()
+	}
+	writer := csv.NewWriter(file)
+	if info.Size() == 0 {
+		_ = writer.Write(resourceLogColumns)
+	}
+	for _, sample := range samples {
+		_ = writer.Write(sample.record())
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
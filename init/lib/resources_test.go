@@ -0,0 +1,94 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestResourceSamplerCSV(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-resources")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	svc.logDir = dir
+	self, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+
+	sampler := svc.newResourceSampler(launchlib.ResourceLogConfig{})
+	for i := 0; i < 2; i++ {
+		require.NoError(t, sampler.sample(map[string]*os.Process{"primary": self}))
+	}
+	require.NoError(t, sampler.sample(map[string]*os.Process{}))
+
+	file, err := os.Open(filepath.Join(dir, "resources.csv"))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, file.Close())
+	}()
+	records, err := csv.NewReader(file).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, resourceLogColumns, records[0])
+	for _, record := range records[1:] {
+		assert.Equal(t, "primary", record[1])
+		assert.Equal(t, strconv.Itoa(os.Getpid()), record[2])
+	}
+}
+
+func TestResourceSamplerJSON(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-resources")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	svc.logDir = dir
+	self, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+
+	sampler := svc.newResourceSampler(launchlib.ResourceLogConfig{Format: launchlib.ResourceLogFormatJSON})
+	require.NoError(t, sampler.sample(map[string]*os.Process{"primary": self, "sidecar": self}))
+
+	file, err := os.Open(filepath.Join(dir, "resources.json"))
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, file.Close())
+	}()
+	var processes []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var sample resourceSample
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &sample))
+		assert.Equal(t, os.Getpid(), sample.Pid)
+		processes = append(processes, sample.Process)
+	}
+	require.NoError(t, scanner.Err())
+	assert.Equal(t, []string{"primary", "sidecar"}, processes)
+}
@@ -0,0 +1,156 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// RotateLogs makes the running 'go-init supervise' of the service reopen the output files, once they have been renamed
+// by an external log rotation, as 'go-init rotate-logs' does.
+func (svc *Service) RotateLogs() error {
+	return svc.executeWithLoggers(NewAlwaysAppending(), func(svc *Service, _ launchlib.ServiceLoggers) error {
+		return svc.rotateLogs()
+	})
+}
+
+func (svc *Service) rotateLogs() error {
+	_, supervisor, err := svc.getCmdProcess(supervisorPidName)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(errors.Wrap(err, "failed to determine supervisor status"), 1)
+	}
+	if supervisor == nil {
+		return svc.logErrorAndReturnWithExitCode(errors.New("the service is not supervised by 'go-init "+
+			"supervise', whose output files are the only ones that can be reopened"), 3)
+	}
+	if err := supervisor.Signal(reopenSignal); err != nil {
+		return svc.logErrorAndReturnWithExitCode(errors.Wrap(err, "failed to signal supervisor"), 1)
+	}
+	fmt.Fprintf(svc.stdout, "Sent %s to supervisor %d to reopen its output files\n",
+		launchlib.SignalName(reopenSignal), supervisor.Pid)
+	return nil
+}
+
+// reopeningFile is an output file that is written through go-init, rather than by the process directly, so that it
+// can be reopened at its path once an external log rotation has renamed it.
+type reopeningFile struct {
+	mu     sync.Mutex
+	path   string
+	mode   os.FileMode
+	writer io.WriteCloser
+}
+
+func (f *reopeningFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writer.Write(p)
+}
+
+// Close does nothing, as the file is shared by every run of its process, so is only closed by
+// reopeningLoggers.close.
+func (f *reopeningFile) Close() error {
+	return nil
+}
+
+func (f *reopeningFile) reopen() error {
+	file, err := os.OpenFile(f.path, appendOutputFileFlag, f.mode)
+	if err != nil {
+		return errors.Wrapf(err, "failed to reopen output file '%s'", f.path)
+	}
+	f.mu.Lock()
+	previous := f.writer
+	f.writer = file
+	f.mu.Unlock()
+	return previous.Close()
+}
+
+// reopeningLoggers are the loggers of 'go-init supervise', which open each output file once, using the files loggers,
+// and can reopen all of them on reopenSignal.
+type reopeningLoggers struct {
+	service *Service
+	files   errorLoggers
+	mu      sync.Mutex
+	opened  map[string]*reopeningFile
+}
+
+func (svc *Service) newReopeningLoggers(files errorLoggers) *reopeningLoggers {
+	return &reopeningLoggers{
+		service: svc,
+		files:   files,
+		opened:  make(map[string]*reopeningFile),
+	}
+}
+
+func (r *reopeningLoggers) PrimaryLogger() (io.WriteCloser, error) {
+	return r.open(r.service.primaryOutputFile)
+}
+
+func (r *reopeningLoggers) SubProcessLogger(name string) launchlib.CreateLogger {
+	return r.ErrorLogger(r.service.subProcessOutputFile(name))
+}
+
+func (r *reopeningLoggers) ErrorLogger(path string) launchlib.CreateLogger {
+	return func() (io.WriteCloser, error) {
+		return r.open(path)
+	}
+}
+
+func (r *reopeningLoggers) open(path string) (io.WriteCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if file, ok := r.opened[path]; ok {
+		return file, nil
+	}
+	writer, err := r.files.ErrorLogger(path)()
+	if err != nil {
+		return nil, err
+	}
+	file := &reopeningFile{path: path, mode: r.service.fileMode, writer: writer}
+	r.opened[path] = file
+	return file, nil
+}
+
+// reopen reopens every output file, returning an error naming those that could not be reopened, which are still
+// written to where they were before.
+func (r *reopeningLoggers) reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var failures []string
+	for _, file := range r.opened {
+		if err := file.reopen(); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, ", "))
+	}
+	return nil
+}
+
+func (r *reopeningLoggers) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, file := range r.opened {
+		_ = file.writer.Close()
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReopeningLoggers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-rotate-logs")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc := newTestService(t)
+	svc.primaryOutputFile = filepath.Join(dir, "startup.log")
+
+	loggers := svc.newReopeningLoggers(&FileLoggers{service: svc, flags: NewAlwaysAppending()})
+	defer loggers.close()
+	primary, err := loggers.PrimaryLogger()
+	require.NoError(t, err)
+	same, err := loggers.ErrorLogger(svc.primaryOutputFile)()
+	require.NoError(t, err)
+	assert.True(t, primary == same, "the same output file must be shared by its writers")
+
+	_, err = primary.Write([]byte("before\n"))
+	require.NoError(t, err)
+	require.NoError(t, os.Rename(svc.primaryOutputFile, svc.primaryOutputFile+".1"))
+	// Closing the writer of one run of a process leaves the file open for the next.
+	require.NoError(t, primary.Close())
+	_, err = primary.Write([]byte("renamed\n"))
+	require.NoError(t, err)
+	require.NoError(t, loggers.reopen())
+	_, err = primary.Write([]byte("after\n"))
+	require.NoError(t, err)
+
+	rotated, err := ioutil.ReadFile(svc.primaryOutputFile + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, "before\nrenamed\n", string(rotated))
+	reopened, err := ioutil.ReadFile(svc.primaryOutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "after\n", string(reopened))
+}
@@ -0,0 +1,129 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// rotateOutputFile rotates the output file at path if rotation is configured: if its process is starting, so that the
+// output of its previous run is kept, or otherwise if it is larger than maxSize. The file is copied and truncated
+// rather than renamed, as a running process keeps writing to it.
+func (svc *Service) rotateOutputFile(path string, starting bool) error {
+	if svc.outputRotation.MaxFiles == 0 {
+		return nil
+	}
+	defer svc.pruneRotatedFiles(path)
+
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "failed to rotate output file '%s'", path)
+	}
+	// The size was validated along with the config.
+	maxSize, _ := svc.outputRotation.MaxSizeBytes()
+	if info.Size() == 0 || !starting && (maxSize == 0 || info.Size() <= maxSize) {
+		return nil
+	}
+
+	for i := svc.outputRotation.MaxFiles; i > 1; i-- {
+		if err := os.Rename(rotatedFile(path, i-1), rotatedFile(path, i)); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "failed to rotate output file '%s'", path)
+		}
+	}
+	if err := svc.copyFile(path, rotatedFile(path, 1)); err != nil {
+		return errors.Wrapf(err, "failed to rotate output file '%s'", path)
+	}
+	if err := os.Truncate(path, 0); err != nil {
+		return errors.Wrapf(err, "failed to truncate rotated output file '%s'", path)
+	}
+	return nil
+}
+
+// rotateOversizedOutputFiles rotates each of the output files, and the files alongside them that the stderr of their
+// process is separated into, and each of the output streams that is larger than maxSize.
+func (svc *Service) rotateOversizedOutputFiles() error {
+	paths := []string{svc.primaryOutputFile, errorOutputFile(svc.primaryOutputFile)}
+	for _, format := range []string{svc.subProcessOutputFileFormat, errorOutputFile(svc.subProcessOutputFileFormat)} {
+		subProcessPaths, err := filepath.Glob(fmt.Sprintf(format, "*"))
+		if err != nil {
+			return err
+		}
+		paths = append(paths, subProcessPaths...)
+	}
+	for _, outputFile := range svc.subProcessOutputFiles {
+		paths = append(paths, outputFile, errorOutputFile(outputFile))
+	}
+	paths = append(paths, svc.outputStreamFiles...)
+	for _, path := range paths {
+		if err := svc.rotateOutputFile(path, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func outputStreamPaths(streams []launchlib.OutputStreamConfig) []string {
+	paths := make([]string, len(streams))
+	for i, stream := range streams {
+		paths[i] = stream.Path()
+	}
+	return paths
+}
+
+// pruneRotatedFiles removes the rotated files of the output file at path that are older than maxAge. Files beyond
+// maxFiles are already replaced when rotating.
+func (svc *Service) pruneRotatedFiles(path string) {
+	if svc.outputRotation.MaxAge == 0 {
+		return
+	}
+	for i := 1; i <= svc.outputRotation.MaxFiles; i++ {
+		rotated := rotatedFile(path, i)
+		if info, err := os.Stat(rotated); err == nil && Clock.Now().Sub(info.ModTime()) > svc.outputRotation.MaxAge {
+			_ = os.Remove(rotated)
+		}
+	}
+}
+
+func rotatedFile(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+func (svc *Service) copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	out, err := os.OpenFile(dst, truncOutputFileFlag, svc.fileMode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,90 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestRotateOutputFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-rotation")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc := newTestService(t)
+	svc.outputRotation = launchlib.OutputRotationConfig{MaxFiles: 2, MaxSize: "10"}
+	path := filepath.Join(dir, "startup.log")
+
+	readFile := func(path string) string {
+		content, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			return ""
+		}
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	// Files within maxSize are only rotated when their process starts.
+	require.NoError(t, ioutil.WriteFile(path, []byte("first\n"), 0644))
+	require.NoError(t, svc.rotateOutputFile(path, false))
+	assert.Equal(t, "first\n", readFile(path))
+	require.NoError(t, svc.rotateOutputFile(path, true))
+	assert.Equal(t, "", readFile(path))
+	assert.Equal(t, "first\n", readFile(path+".1"))
+
+	// Files above maxSize are rotated whenever go-init runs, keeping at most maxFiles.
+	require.NoError(t, ioutil.WriteFile(path, []byte("second run\n"), 0644))
+	require.NoError(t, svc.rotateOutputFile(path, false))
+	require.NoError(t, ioutil.WriteFile(path, []byte("third run!\n"), 0644))
+	require.NoError(t, svc.rotateOutputFile(path, false))
+	assert.Equal(t, "", readFile(path))
+	assert.Equal(t, "third run!\n", readFile(path+".1"))
+	assert.Equal(t, "second run\n", readFile(path+".2"))
+	assert.Equal(t, "", readFile(path+".3"))
+
+	// Rotated files older than maxAge are removed.
+	svc.outputRotation.MaxAge = time.Hour
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(path+".2", old, old))
+	require.NoError(t, svc.rotateOutputFile(path, false))
+	assert.Equal(t, "third run!\n", readFile(path+".1"))
+	_, err = os.Stat(path + ".2")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRotateOutputFile_Disabled(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-rotation")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := filepath.Join(dir, "startup.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("output\n"), 0644))
+
+	require.NoError(t, svc.rotateOutputFile(path, true))
+	_, err = os.Stat(path + ".1")
+	assert.True(t, os.IsNotExist(err))
+}
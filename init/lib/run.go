@@ -0,0 +1,346 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+var forwardedSignals = []os.Signal{syscall.SIGTERM, syscall.SIGINT}
+
+type processExit struct {
+	name string
+	err  error
+}
+
+// Run runs the service in the foreground as 'go-init run' does, writing all output to stdout, until its primary process
+// exits, returning an error with its exit code unless it exits 0. If tee is set, the output of each process and of
+// go-init is also written to the output files as by Start.
+func (svc *Service) Run(tee bool) error {
+	if !tee {
+		return svc.run(launchlib.NewSimpleWriterLogger(svc.stdout))
+	}
+	stdout, stderr := svc.stdout, svc.stderr
+	return svc.executeWithLoggers(NewTruncatingFirst(), func(svc *Service, loggers launchlib.ServiceLoggers) error {
+		tee := &teeLoggers{files: loggers, stdout: stdout, stderr: stderr}
+		defer tee.close()
+		return svc.withStdout(io.MultiWriter(stdout, svc.stdout)).run(tee)
+	})
+}
+
+func (svc *Service) run(loggers launchlib.ServiceLoggers) error {
+	serviceStatus, err := svc.getServiceStatus(loggers)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(
+			errors.Wrap(err, "failed to determine service status to determine what commands to run"), 1)
+	}
+	if len(serviceStatus.runningProcs) > 0 {
+		return svc.logErrorAndReturnWithExitCode(errors.Errorf("commands '%v' are already running",
+			processNames(serviceStatus.runningProcs)), 1)
+	}
+	svc.appendExtraArgs(svc.stdout, serviceStatus.notRunningCmds)
+	if err := launchlib.VerifyFiles(svc.verifiedFiles, svc.stdout); err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+	if err := svc.runPreflightChecks(svc.stdout, serviceStatus.notRunningCmds); err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+
+	for name, cmd := range serviceStatus.notRunningCmds {
+		if !cmd.Exec {
+			continue
+		}
+		if _, ok := loggers.(*teeLoggers); ok {
+			return svc.logErrorAndReturnWithExitCode(errors.Errorf("the output cannot be teed with execMode %s, "+
+				"as go-init does not remain to copy it", launchlib.ExecModeExec), 1)
+		}
+		err := svc.execCommand(name, cmd)
+		return svc.logErrorAndReturnWithExitCode(errors.Wrap(err, "failed to run service"), 1)
+	}
+
+	signals, stopSignals := captureSignals()
+	defer stopSignals()
+
+	code, err := svc.runService(serviceStatus.notRunningCmds, signals, false)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(errors.Wrap(err, "failed to run service"), 1)
+	}
+	if code != 0 {
+		return cli.WithExitCode(code, errors.New(""))
+	}
+	return nil
+}
+
+// captureSignals returns a channel on which the forwarded signals are delivered, replacing the handling of the cli
+// library which exits on SIGTERM and SIGINT and would orphan the processes rather than stop them.
+func captureSignals() (<-chan os.Signal, func()) {
+	signal.Reset(forwardedSignals...)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	return signals, func() {
+		signal.Stop(signals)
+	}
+}
+
+// runService starts the processes and waits for the primary process to exit, returning its exit code. Signals are
+// forwarded to the processes, unless stopOnSignal is set, in which case the first signal stops the service as 'stop'
+// does and 0 is returned once it has stopped.
+func (svc *Service) runService(cmds map[string]CommandContext, signals <-chan os.Signal, stopOnSignal bool) (int,
+	error) {
+	exits := make(chan processExit, len(cmds))
+	running := map[string]*os.Process{}
+	waves, err := startWaves(cmds)
+	if err != nil {
+		return 0, err
+	}
+	for _, wave := range waves {
+		for _, name := range wave {
+			cmd := cmds[name]
+			if err := svc.startCommand(name, cmd); err != nil {
+				svc.stopRunningProcesses(cmds, running, exits, false)
+				return 0, errors.Wrapf(err, "failed to start command '%s'", name)
+			}
+			running[name] = cmd.Command.Process
+			cmd.exit = waitInBackground(cmd.Command)
+			cmds[name] = cmd
+			go func(name string, exit *commandExit) {
+				<-exit.exited
+				exits <- processExit{name: name, err: exit.err}
+			}(name, cmd.exit)
+
+			if err := svc.writeCommandPidfile(name, cmd, cmd.Command.Process.Pid); err != nil {
+				svc.stopRunningProcesses(cmds, running, exits, false)
+				return 0, err
+			}
+		}
+		if err := svc.waitForDependedUpon(cmds, wave); err != nil {
+			svc.stopRunningProcesses(cmds, running, exits, false)
+			return 0, err
+		}
+	}
+
+	// Orphaned descendants of the service are re-parented to go-init when it is PID 1 or a child subreaper.
+	subreaper := false
+	for _, cmd := range cmds {
+		subreaper = subreaper || cmd.Daemonizes
+	}
+	if os.Getpid() == 1 || subreaper {
+		managed := make(map[int]struct{}, len(running))
+		for _, proc := range running {
+			managed[proc.Pid] = struct{}{}
+		}
+		stopReaper := svc.startZombieReaper(managed)
+		defer stopReaper()
+	}
+
+	for name, cmd := range cmds {
+		if err := svc.runHooks("postStart", name, cmd, cmd.Hooks.PostStart); err != nil {
+			svc.stopRunningProcesses(cmds, running, exits, false)
+			return 0, err
+		}
+	}
+
+	svc.notifySystemd("READY=1")
+	for name, proc := range running {
+		svc.notify(svc.stdout, notification{Event: launchlib.NotificationEventStart, Process: name, Pid: proc.Pid})
+	}
+
+	stopping := false
+	for {
+		select {
+		case sig := <-signals:
+			svc.notifySystemd("STOPPING=1")
+			if stopOnSignal {
+				svc.stopServiceOnSignal(cmds, running, exits, sig)
+				return 0, nil
+			}
+			if !stopping {
+				stopping = true
+				svc.runPreStopHooks(cmds, processNames(running))
+			}
+			fmt.Fprintf(svc.stdout, "Forwarding signal %v to processes '%v'\n", sig, processNames(running))
+			for _, proc := range running {
+				// Errors are only possible if the process has already exited, which is reported on exits.
+				_ = proc.Signal(sig)
+			}
+		case exit := <-exits:
+			delete(running, exit.name)
+			svc.removePidfile(exit.name)
+			svc.recordExit(exit)
+			code := exitCode(exit.err)
+			fmt.Fprintf(svc.stdout, "Process '%s' exited with exit code %d\n", exit.name, code)
+			// Processes that exit once signalled have been stopped, while any other exit is a crash.
+			event := launchlib.NotificationEventCrash
+			if stopping {
+				event = launchlib.NotificationEventStop
+			}
+			svc.notify(svc.stdout, exitNotification(event, exit.name, exit.err))
+			if cmds[exit.name].Primary {
+				svc.stopRunningProcesses(cmds, running, exits, false)
+				return code, nil
+			}
+		}
+	}
+}
+
+// stopServiceOnSignal stops the running processes as 'stop' does once a signal has been received: in the stopOrder,
+// running their preStop hooks, requesting those with a stop URL to stop, and then sending SIGTERM to any that remain.
+func (svc *Service) stopServiceOnSignal(cmds map[string]CommandContext, running map[string]*os.Process,
+	exits <-chan processExit, sig os.Signal) {
+	stopped := make(map[string]*os.Process, len(running))
+	for name, proc := range running {
+		stopped[name] = proc
+	}
+	fmt.Fprintf(svc.stdout, "Received signal %v, stopping processes '%v'\n", sig, processNames(running))
+	svc.stopRunningProcesses(cmds, running, exits, true)
+	for name, proc := range stopped {
+		svc.notify(svc.stdout, notification{Event: launchlib.NotificationEventStop, Process: name, Pid: proc.Pid})
+	}
+}
+
+// stopRunningProcesses stops the given child processes in the stopOrder, wave by wave: running the preStop hooks of the
+// processes of the wave and requesting those with a stop URL to stop if graceful, then sending SIGTERM to their process
+// groups, waiting for them to be reaped from exits and killing any that have not stopped within their stop timeouts.
+// Whatever remains of the group of a process once it has been reaped is killed. Reports how each process stopped.
+func (svc *Service) stopRunningProcesses(cmds map[string]CommandContext, running map[string]*os.Process,
+	exits <-chan processExit, graceful bool) {
+	pgids := make(map[string]int, len(running))
+	for name, proc := range running {
+		pgids[name] = processGroup(proc)
+	}
+	reap := func(exit processExit) {
+		delete(running, exit.name)
+		killProcessGroupRemnants(pgids[exit.name])
+		svc.removePidfile(exit.name)
+		svc.recordExit(exit)
+	}
+
+	report := newStopReport()
+	for _, wave := range svc.stopWaves(cmds, running) {
+		procs := make(map[string]*os.Process, len(wave))
+		for _, name := range wave {
+			// Processes of later waves may have exited while earlier waves were stopped.
+			if proc, ok := running[name]; ok {
+				procs[name] = proc
+				report.outcome(name)
+			}
+		}
+		if graceful {
+			svc.runPreStopHooks(cmds, processNames(procs))
+			for _, name := range svc.requestStops(cmds, procs) {
+				report.outcome(name).requestFailed = true
+			}
+		}
+		for _, proc := range procs {
+			_ = signalProcessGroup(proc, syscall.SIGTERM)
+		}
+
+		timeouts := stopTimeouts(cmds, processNames(procs))
+		var elapsed time.Duration
+		for _, timeout := range distinctTimeouts(procs, timeouts) {
+			timer := Clock.NewTimer(timeout - elapsed)
+			expired := false
+			for !expired && anyRunning(running, procs) {
+				select {
+				case exit := <-exits:
+					reap(exit)
+				case <-timer.Chan():
+					expired = true
+				}
+			}
+			timer.Stop()
+			elapsed = timeout
+			if !expired {
+				break
+			}
+			var killedProcs []string
+			for name, proc := range procs {
+				if _, ok := running[name]; ok && timeouts[name] == timeout {
+					_ = signalProcessGroup(proc, syscall.SIGKILL)
+					report.outcome(name).killedAfter = timeout
+					killedProcs = append(killedProcs, name)
+				}
+			}
+			if len(killedProcs) > 0 {
+				sort.Strings(killedProcs)
+				fmt.Fprintf(svc.stdout, "processes '%v' did not stop within %v seconds, so a SIGKILL was "+
+					"sent\n", killedProcs, timeout.Seconds())
+			}
+		}
+		for anyRunning(running, procs) {
+			reap(<-exits)
+		}
+	}
+	report.write(svc.stdout)
+}
+
+// anyRunning returns whether any of the procs is still running, as it has not been reaped.
+func anyRunning(running map[string]*os.Process, procs map[string]*os.Process) bool {
+	for name := range procs {
+		if _, ok := running[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (svc *Service) removePidfile(name string) {
+	if err := os.Remove(fmt.Sprintf(svc.pidfileFormat, name)); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(svc.stderr, "failed to remove stopped process pidfile for '%s'\n", name)
+	}
+}
+
+func (svc *Service) recordExit(exit processExit) {
+	if err := svc.recordProcessExit(exit.name, exit.err); err != nil {
+		fmt.Fprintf(svc.stderr, "failed to record exit of process '%s': %v\n", exit.name, err)
+	}
+}
+
+// exitCode returns the exit code of a process given the error returned when waiting for it, following the shell
+// convention of 128 plus the signal number for processes killed by a signal.
+func exitCode(waitErr error) int {
+	if waitErr == nil {
+		return 0
+	}
+	if exitErr, ok := waitErr.(*exec.ExitError); ok {
+		if waitStatus, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+			if waitStatus.Signaled() {
+				return 128 + int(waitStatus.Signal())
+			}
+			return waitStatus.ExitStatus()
+		}
+	}
+	return 1
+}
+
+func processNames(procs map[string]*os.Process) []string {
+	names := make([]string, 0, len(procs))
+	for name := range procs {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartCommand_Tee(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-run")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	loggers := &teeLoggers{
+		files:  &FileLoggers{service: svc, flags: NewTruncatingFirst()},
+		stdout: stdout,
+		stderr: stderr,
+	}
+	defer loggers.close()
+	outputFile := filepath.Join(dir, "startup.log")
+	errorOutputFile := errorOutputFile(outputFile)
+
+	cmd := CommandContext{
+		Command: exec.Command("/bin/sh", "-c", "echo out; echo err >&2"),
+		Logger: func() (io.WriteCloser, error) {
+			return loggers.tee(loggers.files.(*FileLoggers).ErrorLogger(outputFile), loggers.stdout)
+		},
+		OutputFile:      outputFile,
+		ErrorLogger:     errorLogger(loggers, errorOutputFile),
+		ErrorOutputFile: errorOutputFile,
+	}
+	svc.stdout = ioutil.Discard
+	require.NoError(t, svc.startCommand("primary", cmd))
+	require.NoError(t, cmd.Command.Wait())
+
+	output, err := ioutil.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "out\n", string(output))
+	assert.Equal(t, "out\n", stdout.String())
+	errorOutput, err := ioutil.ReadFile(errorOutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "err\n", string(errorOutput))
+	assert.Equal(t, "err\n", stderr.String())
+}
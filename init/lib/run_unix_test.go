@@ -0,0 +1,166 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// runServiceCmds returns the commands of a service of the primary and sidecar processes, which run the scripts.
+func runServiceCmds(primary, sidecar string) map[string]CommandContext {
+	logger := launchlib.NewSimpleWriterLogger(ioutil.Discard).PrimaryLogger
+	return map[string]CommandContext{
+		"primary": {Command: exec.Command("/bin/sh", "-c", primary), Logger: logger, Primary: true},
+		"sidecar": {Command: exec.Command("/bin/sh", "-c", sidecar), Logger: logger},
+	}
+}
+
+func TestRunService(t *testing.T) {
+	for i, currCase := range []struct {
+		name    string
+		primary string
+		// signal is sent once the primary process has written its ready file, if set.
+		signal   os.Signal
+		wantCode int
+	}{
+		{name: "primary exits", primary: "exit 3", wantCode: 3},
+		{
+			name:     "signal forwarded to primary",
+			primary:  `trap "exit 5" USR1; touch "$READY"; while true; do sleep 0.1; done`,
+			signal:   syscall.SIGUSR1,
+			wantCode: 5,
+		},
+		{
+			name:     "primary killed by forwarded signal",
+			primary:  `touch "$READY"; exec sleep 10`,
+			signal:   syscall.SIGTERM,
+			wantCode: 128 + int(syscall.SIGTERM),
+		},
+	} {
+		func() {
+			dir, err := ioutil.TempDir("", "go-init-run")
+			require.NoError(t, err)
+			defer func() {
+				_ = os.RemoveAll(dir)
+			}()
+			svc := newTestService(t)
+			svc.setPaths(Paths{
+				StaticConfigFile: svc.launcherStaticFile,
+				CustomConfigFile: svc.launcherCustomFile,
+				PidfileFormat:    filepath.Join(dir, "%s.pid"),
+				StateDir:         dir,
+				OutputFile:       filepath.Join(dir, "startup.log"),
+			})
+
+			ready := filepath.Join(dir, "ready")
+			cmds := runServiceCmds(currCase.primary, "exec sleep 10")
+			cmds["primary"].Command.Env = append(os.Environ(), "READY="+ready)
+			signals := make(chan os.Signal, 1)
+			if currCase.signal != nil {
+				go func() {
+					for {
+						if _, err := os.Stat(ready); err == nil {
+							signals <- currCase.signal
+							return
+						}
+						time.Sleep(10 * time.Millisecond)
+					}
+				}()
+			}
+			svc.stdout, svc.stderr = ioutil.Discard, ioutil.Discard
+			code, err := svc.runService(cmds, signals, false)
+			require.NoError(t, err, "Case %d: %s", i, currCase.name)
+			assert.Equal(t, currCase.wantCode, code, "Case %d: %s", i, currCase.name)
+
+			// The sidecar is stopped once the primary process exits, and the pidfiles of both are removed.
+			assert.False(t, isProcRunning(cmds["sidecar"].Command.Process), "Case %d: %s", i, currCase.name)
+			for _, name := range []string{"primary", "sidecar"} {
+				_, err := os.Stat(filepath.Join(dir, name+".pid"))
+				assert.True(t, os.IsNotExist(err), "Case %d: %s: %s", i, currCase.name, name)
+			}
+		}()
+	}
+}
+
+func TestRunService_SidecarExitDoesNotStopService(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-run")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc := newTestService(t)
+	svc.setPaths(Paths{
+		StaticConfigFile: svc.launcherStaticFile,
+		CustomConfigFile: svc.launcherCustomFile,
+		PidfileFormat:    filepath.Join(dir, "%s.pid"),
+		StateDir:         dir,
+		OutputFile:       filepath.Join(dir, "startup.log"),
+	})
+
+	cmds := runServiceCmds(`while [ ! -e "$STOP" ]; do sleep 0.1; done; exit 4`, "exit 2")
+	stop := filepath.Join(dir, "stop")
+	cmds["primary"].Command.Env = append(os.Environ(), "STOP="+stop)
+	svc.stdout, svc.stderr = ioutil.Discard, ioutil.Discard
+	codes := make(chan int, 1)
+	go func() {
+		code, err := svc.runService(cmds, make(chan os.Signal), false)
+		assert.NoError(t, err)
+		codes <- code
+	}()
+
+	// The exit of the sidecar is recorded, while the primary process keeps running until it exits.
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if state, err := svc.readProcessState("sidecar"); err == nil && state.LastExitCode != nil {
+			assert.Equal(t, 2, *state.LastExitCode)
+			break
+		}
+		require.True(t, time.Now().Before(deadline), "exit of the sidecar was not recorded")
+	}
+	select {
+	case code := <-codes:
+		t.Fatalf("service stopped with exit code %d when the sidecar exited", code)
+	default:
+	}
+	require.NoError(t, ioutil.WriteFile(stop, nil, 0644))
+	assert.Equal(t, 4, <-codes)
+}
+
+func TestExitCode(t *testing.T) {
+	for i, currCase := range []struct {
+		cmd  *exec.Cmd
+		want int
+	}{
+		{cmd: exec.Command("/bin/sh", "-c", "exit 0"), want: 0},
+		{cmd: exec.Command("/bin/sh", "-c", "exit 3"), want: 3},
+		{cmd: exec.Command("/bin/sh", "-c", "kill -KILL $$"), want: 128 + int(syscall.SIGKILL)},
+		{cmd: exec.Command("/does/not/exist"), want: 1},
+	} {
+		assert.Equal(t, currCase.want, exitCode(currCase.cmd.Run()), "Case %d", i)
+	}
+}
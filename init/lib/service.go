@@ -0,0 +1,147 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// Service starts, stops and reports the status of the processes of a service as the go-init commands of the same
+// names do, for deployment tools that embed go-init rather than run its binary. A Service keeps the paths of the
+// service and the settings of its static configuration itself, so that Services of different paths can be used
+// alongside each other. The errors of its operations implement cli.ExitCoder, with the exit code of the go-init
+// command.
+type Service struct {
+	// ExtraArgs are appended to the args of the primary process when it is started, or the arguments given by
+	// GO_INIT_EXTRA_ARGS if nil.
+	ExtraArgs []string
+
+	launcherStaticFile string
+	launcherCustomFile string
+	pidfileFormat      string
+	stateDir           string
+	statefileFormat    string
+	// lockfile is locked by Start and Stop for as long as they run, so that concurrent invocations cannot both start
+	// the service or read the pidfiles that the other is writing.
+	lockfile                   string
+	logDir                     string
+	primaryOutputFile          string
+	subProcessOutputFileFormat string
+
+	// fileMode and dirMode are the permissions of the pid, state and output files and their directories.
+	fileMode os.FileMode
+	dirMode  os.FileMode
+	// outputRotation is the rotation of the output files.
+	outputRotation launchlib.OutputRotationConfig
+	// subProcessOutputFiles are the outputFiles of the subProcesses that set their own.
+	subProcessOutputFiles map[string]string
+	// outputStreamFiles are the files of the output streams of the processes.
+	outputStreamFiles []string
+	// statusExitCodes is the scheme of the exit codes of status, which are those of StatusExitCodesLSB by default.
+	statusExitCodes string
+	// startupTimeout bounds the time Start takes to start the service.
+	startupTimeout time.Duration
+	// verifiedFiles are the files that must be verified before the service is started.
+	verifiedFiles []launchlib.VerifyConfig
+	// preflight are the checks of the host made before the service is started.
+	preflight launchlib.PreflightConfig
+	// stopOrder is the order in which the processes are stopped, one of the launchlib StopOrder constants.
+	stopOrder string
+	// plugins are the plugins of the service.
+	plugins launchlib.PluginsConfig
+	// notifications are the notifications of the service, and notificationService its name.
+	notifications       launchlib.NotificationsConfig
+	notificationService string
+
+	// stdout is where the progress of the operations is written, the output file of the primary process while they
+	// run with its loggers, and out otherwise. out is where their results are printed, and stderr their warnings.
+	stdout io.Writer
+	out    io.Writer
+	stderr io.Writer
+}
+
+// NewService returns the service of the given paths, with the settings of its static configuration, which writes to
+// stdout and stderr as go-init does to its own, defaulting to those of the process if nil.
+func NewService(paths Paths, stdout, stderr io.Writer) (*Service, error) {
+	if err := paths.validate(); err != nil {
+		return nil, err
+	}
+	svc := &Service{stdout: os.Stdout, out: os.Stdout, stderr: os.Stderr}
+	if stdout != nil {
+		svc.stdout, svc.out = stdout, stdout
+	}
+	if stderr != nil {
+		svc.stderr = stderr
+	}
+	svc.setPaths(paths)
+	svc.applyFileSettings()
+	return svc, nil
+}
+
+// withStdout returns a copy of the service that writes to stdout in place of its own.
+func (svc *Service) withStdout(stdout io.Writer) *Service {
+	copied := *svc
+	copied.stdout = stdout
+	return &copied
+}
+
+// executeWithLoggers runs the operation with the loggers of the output files, opened with the given flags, on a copy of
+// the service that writes what go-init writes to its stdout to the output file of the primary process.
+func (svc *Service) executeWithLoggers(flags FileFlags,
+	operation func(*Service, launchlib.ServiceLoggers) error) (rErr error) {
+	// Fall back to default stdout if error opening log file
+	if err := os.MkdirAll(svc.logDir, svc.dirMode); err != nil {
+		return svc.logErrorAndReturnWithExitCode(
+			errors.Wrapf(err, "Error trying to make log directory '%s'", svc.logDir), 4)
+	}
+
+	if err := svc.rotateOversizedOutputFiles(); err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 4)
+	}
+
+	loggers := &FileLoggers{
+		service: svc,
+		flags:   flags,
+	}
+
+	outputFile, err := loggers.PrimaryLogger()
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(errors.Wrap(err, "Error opening startup log file"), 4)
+	}
+	defer func() {
+		if cErr := outputFile.Close(); rErr == nil && cErr != nil {
+			/*
+			 * Exit 0 and communicate "success with errors" because although we failed to close the
+			 * output file, we're a cli and the OS will close it for us momentarily
+			 */
+			rErr = cli.WithExitCode(0, errors.Errorf("failed to close primary output file"))
+		}
+	}()
+	return operation(svc.withStdout(outputFile), loggers)
+}
+
+func (svc *Service) logErrorAndReturnWithExitCode(err error, exitCode int) cli.ExitCoder {
+	// We still want to write the error to stderr if we can't write it to the startup log file.
+	_, _ = fmt.Fprintln(svc.stdout, err)
+	return cli.WithExitCode(exitCode, err)
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceNotRunning(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-service")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	executable := filepath.Join(dir, "postgres")
+	require.NoError(t, ioutil.WriteFile(executable, []byte("#!/bin/sh\n"), 0755))
+	staticFile := filepath.Join(dir, "launcher-static.yml")
+	require.NoError(t, ioutil.WriteFile(staticFile, []byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: `+executable+`
+`), 0644))
+	var stdout bytes.Buffer
+	service, err := NewService(Paths{
+		StaticConfigFile: staticFile,
+		CustomConfigFile: filepath.Join(dir, "launcher-custom.yml"),
+		PidfileFormat:    filepath.Join(dir, "%s.pid"),
+		StateDir:         dir,
+		OutputFile:       filepath.Join(dir, "startup.log"),
+	}, &stdout, nil)
+	require.NoError(t, err)
+
+	report := service.Status()
+	assert.Equal(t, 3, report.ExitCode)
+	require.Len(t, report.Processes, 1)
+	assert.Equal(t, "primary", report.Processes[0].Name)
+	assert.False(t, report.Processes[0].Running)
+
+	require.NoError(t, service.Stop())
+	assert.Contains(t, stdout.String(), "Failed to read custom config file")
+}
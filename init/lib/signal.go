@@ -0,0 +1,49 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"syscall"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// Signal sends the signal to the running processes of the given names, or all processes if none are given, as 'go-init
+// signal' does.
+func (svc *Service) Signal(sig syscall.Signal, processes ...string) error {
+	return svc.executeWithLoggers(NewAlwaysAppending(), func(svc *Service, loggers launchlib.ServiceLoggers) error {
+		return svc.sendSignal(loggers, sig, processes)
+	})
+}
+
+func (svc *Service) sendSignal(loggers launchlib.ServiceLoggers, sig syscall.Signal, names []string) error {
+	allCmds, err := svc.getConfiguredCommands(loggers)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(
+			errors.Wrap(err, "failed to get commands from static and custom configuration files"), 4)
+	}
+	cmds, err := selectNamedCommands(names, allCmds)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 4)
+	}
+
+	signals := make(map[string]syscall.Signal, len(cmds))
+	for name := range cmds {
+		signals[name] = sig
+	}
+	return svc.signalProcesses(signals)
+}
@@ -0,0 +1,474 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// Start starts the processes of the given names, or all processes if none are given, that are not running, and waits
+// for them to pass their startup windows and health checks, as 'go-init start' does.
+func (svc *Service) Start(processes ...string) error {
+	return svc.executeWithLoggers(NewTruncatingFirst(), func(svc *Service, loggers launchlib.ServiceLoggers) error {
+		return svc.startProcesses(loggers, processes)
+	})
+}
+
+// startProcesses starts the processes of the given names, or all processes if none are given, that are not running.
+func (svc *Service) startProcesses(loggers launchlib.ServiceLoggers, names []string) error {
+	unlock, err := svc.lockService(svc.stdout)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+	defer unlock()
+
+	serviceStatus, err := svc.getSelectedServiceStatus(loggers, names)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(
+			errors.Wrap(err, "failed to determine service status to determine what commands to run"), 1)
+	}
+	svc.appendExtraArgs(svc.stdout, serviceStatus.notRunningCmds)
+	if len(serviceStatus.notRunningCmds) > 0 {
+		if err := launchlib.VerifyFiles(svc.verifiedFiles, svc.stdout); err != nil {
+			return svc.logErrorAndReturnWithExitCode(err, 1)
+		}
+		if err := svc.runPreflightChecks(svc.stdout, serviceStatus.notRunningCmds); err != nil {
+			return svc.logErrorAndReturnWithExitCode(err, 1)
+		}
+	}
+	if err := svc.withStartupTimeout(func() error {
+		if err := svc.startService(serviceStatus.notRunningCmds); err != nil {
+			return svc.logErrorAndReturnWithExitCode(errors.Wrap(err, "failed to start service"), 1)
+		}
+		if err := svc.waitForStartupWindows(serviceStatus.notRunningCmds); err != nil {
+			return svc.logErrorAndReturnWithExitCode(err, 1)
+		}
+		if err := svc.waitForStartupPatterns(serviceStatus.notRunningCmds); err != nil {
+			return svc.logErrorAndReturnWithExitCode(err, healthCheckFailedExitCode)
+		}
+		if err := svc.waitForServiceToBeHealthy(serviceStatus.notRunningCmds); err != nil {
+			return svc.logErrorAndReturnWithExitCode(err, healthCheckExitCode(err))
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	svc.reportStartupTimings(serviceStatus.notRunningCmds)
+	for name, cmd := range serviceStatus.notRunningCmds {
+		if err := svc.runHooks("postStart", name, cmd, cmd.Hooks.PostStart); err != nil {
+			return svc.logErrorAndReturnWithExitCode(err, 1)
+		}
+	}
+	for name, cmd := range serviceStatus.notRunningCmds {
+		svc.notify(svc.stdout, notification{Event: launchlib.NotificationEventStart, Process: name,
+			Pid: cmd.Command.Process.Pid})
+	}
+
+	// go-init exits once the service has started, so systemd is told to track the primary process instead.
+	for _, cmd := range serviceStatus.notRunningCmds {
+		if cmd.Primary {
+			svc.notifySystemd(fmt.Sprintf("READY=1\nMAINPID=%d", cmd.Command.Process.Pid))
+		}
+	}
+	return nil
+}
+
+// DryRunStart prints the command line, working directory and environment of each of the processes of the given names,
+// or of all processes if none are given, instead of starting it, as 'go-init start --dry-run' does. It must not
+// truncate the output files of running processes, so bypasses their loggers entirely.
+func (svc *Service) DryRunStart(processes ...string) error {
+	cmds, err := svc.getConfiguredCommands(&DevNullLoggers{})
+	if err != nil {
+		return cli.WithExitCode(1,
+			errors.Wrap(err, "failed to get commands from static and custom configuration files"))
+	}
+	selected, err := selectNamedCommands(processes, cmds)
+	if err != nil {
+		return cli.WithExitCode(1, err)
+	}
+	svc.appendExtraArgs(ioutil.Discard, selected)
+
+	names := commandNames(selected)
+	// The primary process is printed first, followed by the subProcesses by name.
+	sort.Slice(names, func(i, j int) bool {
+		if selected[names[i]].Primary != selected[names[j]].Primary {
+			return selected[names[i]].Primary
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		launchlib.WriteDryRun(svc.stdout, name, selected[name].Command, selected[name].Redactor)
+	}
+	return nil
+}
+
+// withStartupTimeout calls start, returning an error if it does not return within startupTimeout, if set. start is
+// left running in the background if it times out, which go-init exits without waiting for.
+func (svc *Service) withStartupTimeout(start func() error) error {
+	if svc.startupTimeout == 0 {
+		return start()
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- start()
+	}()
+	timer := Clock.NewTimer(svc.startupTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.Chan():
+		return svc.logErrorAndReturnWithExitCode(
+			errors.Errorf("service did not start within its startupTimeout of %v", svc.startupTimeout), 1)
+	}
+}
+
+// startService starts the commands in the order of their process dependencies, starting each wave of the commands
+// whose dependencies have started in parallel. The commands that others depend on must match their startup patterns and
+// pass their health checks, if they have any, before the commands that depend on them are started.
+func (svc *Service) startService(notRunningCmds map[string]CommandContext) error {
+	waves, err := startWaves(notRunningCmds)
+	if err != nil {
+		return err
+	}
+	for _, wave := range waves {
+		if err := svc.startInParallel(wave, func(name string) error {
+			cmd := notRunningCmds[name]
+			if err := svc.startCommand(name, cmd); err != nil {
+				return errors.Wrapf(err, "failed to start command '%s'", name)
+			}
+			return svc.writeCommandPidfile(name, cmd, cmd.Command.Process.Pid)
+		}); err != nil {
+			return err
+		}
+		for _, name := range wave {
+			cmd := notRunningCmds[name]
+			cmd.exit = waitInBackground(cmd.Command)
+			notRunningCmds[name] = cmd
+		}
+		if err := svc.waitForDependedUpon(notRunningCmds, wave); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (svc *Service) writePidfile(name string, pid int) error {
+	pidfile := fmt.Sprintf(svc.pidfileFormat, name)
+	if err := os.MkdirAll(filepath.Dir(pidfile), svc.dirMode); err != nil {
+		return errors.Wrapf(err, "unable to create pidfile directory.")
+	}
+
+	if err := svc.writeFileAtomically(pidfile, []byte(strconv.Itoa(pid))); err != nil {
+		return errors.Wrapf(err, "failed to save pid to file for command '%s'", name)
+	}
+	return svc.recordProcessIdentity(name, pid)
+}
+
+// writeCommandPidfile writes the pidfile of the started command along with the hash of its configuration.
+func (svc *Service) writeCommandPidfile(name string, cmdCtx CommandContext, pid int) error {
+	if err := svc.writePidfile(name, pid); err != nil {
+		return err
+	}
+	return svc.recordStartedCommand(name, cmdCtx)
+}
+
+// recordProcessIdentity records the start time of the process alongside its pid in its state file, which
+// getCmdProcess verifies before reporting it running. Nothing is recorded where the start time is not available.
+func (svc *Service) recordProcessIdentity(name string, pid int) error {
+	startTicks, err := processStartTicks(pid)
+	if err != nil {
+		return nil
+	}
+	return svc.updateProcessState(name, func(state *processState) {
+		state.Pid = pid
+		state.StartTicks = startTicks
+	})
+}
+
+func (svc *Service) startCommand(name string, cmdCtx CommandContext) error {
+	if err := svc.runPreStartPlugins(svc.stdout, name, cmdCtx.Command); err != nil {
+		return err
+	}
+	if err := launchlib.MkDirs(cmdCtx.Dirs, svc.stdout); err != nil {
+		return errors.Wrap(err, "failed to create directories")
+	}
+	if err := launchlib.ChownDirs(cmdCtx.Dirs, cmdCtx.Command); err != nil {
+		return err
+	}
+	if err := launchlib.PrepareDiagnostics(cmdCtx.Diagnostics, cmdCtx.WorkingDirectory, cmdCtx.Command,
+		svc.stdout); err != nil {
+		return err
+	}
+	if err := launchlib.CollectCoreDumps(cmdCtx.CoreDumps, cmdCtx.WorkingDirectory, cmdCtx.Command,
+		svc.stdout); err != nil {
+		return err
+	}
+	if err := launchlib.PrepareTmpDir(cmdCtx.TmpDir, cmdCtx.WorkingDirectory, cmdCtx.Command,
+		svc.stdout); err != nil {
+		return err
+	}
+	if err := launchlib.WaitForDependencies(cmdCtx.DependsOn, svc.stdout); err != nil {
+		return err
+	}
+	if err := svc.runHooks("preStart", name, cmdCtx, cmdCtx.Hooks.PreStart); err != nil {
+		return err
+	}
+
+	outputs, err := openOutputs(name, cmdCtx)
+	if err != nil {
+		return err
+	}
+	streams, err := launchlib.OpenOutputStreams(cmdCtx.OutputStreams, cmdCtx.Command, svc.fileMode, svc.dirMode)
+	if err != nil {
+		for _, output := range outputs {
+			_ = output.Close()
+		}
+		return err
+	}
+	for _, stream := range streams {
+		outputs = append(outputs, stream)
+	}
+	// The process keeps its own copies of the outputs once it has started.
+	defer func() {
+		for _, output := range outputs {
+			if cErr := output.Close(); cErr != nil {
+				fmt.Fprintln(svc.stdout, "failed to close output of command:", cErr)
+			}
+		}
+	}()
+
+	if err := svc.startWithInheritedAttributes(cmdCtx); err != nil {
+		return err
+	}
+	// The cgroup, priority and CPU affinity are those of the process left running by a command that daemonizes.
+	if cmdCtx.Daemonizes {
+		if err := adoptDaemon(cmdCtx.Command); err != nil {
+			stopStartedCommand(cmdCtx)
+			return errors.Wrap(err, "failed to find the process left running by command")
+		}
+	}
+	if err := launchlib.JoinCgroup(cmdCtx.Command.Process.Pid, cmdCtx.Cgroup); err != nil {
+		stopStartedCommand(cmdCtx)
+		return errors.Wrap(err, "failed to move command into its cgroup")
+	}
+	if err := launchlib.SetPriority(cmdCtx.Command.Process.Pid, cmdCtx.Priority); err != nil {
+		stopStartedCommand(cmdCtx)
+		return errors.Wrap(err, "failed to set the priority of command")
+	}
+	if err := launchlib.SetCPUAffinity(cmdCtx.Command.Process.Pid, cmdCtx.CPUSet); err != nil {
+		stopStartedCommand(cmdCtx)
+		return errors.Wrap(err, "failed to set the CPU affinity of command")
+	}
+	return nil
+}
+
+// inheritedAttributesMutex serializes starting commands, which inherit the umask, resource limits and subreaper
+// setting of go-init, so that commands started in parallel do not start with each other's.
+var inheritedAttributesMutex sync.Mutex
+
+// startWithInheritedAttributes starts the command with the umask and resource limits of its config, restoring those of
+// go-init once it has started.
+func (svc *Service) startWithInheritedAttributes(cmdCtx CommandContext) error {
+	inheritedAttributesMutex.Lock()
+	defer inheritedAttributesMutex.Unlock()
+	defer launchlib.SetUmask(cmdCtx.Umask)()
+	restoreRlimits, err := launchlib.SetRlimits(cmdCtx.Rlimits)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if rErr := restoreRlimits(); rErr != nil {
+			fmt.Fprintln(svc.stdout, "failed to restore resource limits of go-init:", rErr)
+		}
+	}()
+	if cmdCtx.Daemonizes {
+		if err := becomeSubreaper(); err != nil {
+			return err
+		}
+	}
+	inOwnProcessGroup(cmdCtx.Command)
+	started := Clock.Now()
+	if err := launchlib.StartIsolated(cmdCtx.Command, cmdCtx.Isolation); err != nil {
+		return errors.Wrap(err, "failed to start command")
+	}
+	if cmdCtx.Timings != nil {
+		cmdCtx.Timings.ForkExecMillis = millis(Clock.Now().Sub(started))
+		cmdCtx.Timings.started = started
+	}
+	return nil
+}
+
+// commandExit is the exit of a started command. A command can only be waited for once, so it is waited for in the
+// background and everything that watches for it to exit shares its commandExit.
+type commandExit struct {
+	// exited is closed once the command has exited, after which err is the error returned when waiting for it.
+	exited chan struct{}
+	err    error
+}
+
+// waitInBackground waits for the started command in the background, returning its exit.
+func waitInBackground(cmd *exec.Cmd) *commandExit {
+	exit := &commandExit{exited: make(chan struct{})}
+	go func() {
+		exit.err = cmd.Wait()
+		close(exit.exited)
+	}()
+	return exit
+}
+
+// stopStartedCommand kills a command that was started but could not be set up.
+func stopStartedCommand(cmdCtx CommandContext) {
+	_ = signalProcessGroup(cmdCtx.Command.Process, syscall.SIGKILL)
+	_ = cmdCtx.Command.Wait()
+}
+
+// startupLogTailLines is the number of lines of a process's output that are reported when it exits during its startup
+// window.
+const startupLogTailLines = 20
+
+type startupWindowExit struct {
+	name string
+	err  error
+}
+
+// waitForStartupWindows watches each of the started processes that has a startup window until it has passed, returning
+// an error if any of them exits within it more than its configured number of start retries. Processes that are started
+// again are updated in startedCmds.
+func (svc *Service) waitForStartupWindows(startedCmds map[string]CommandContext) error {
+	exited := make(chan startupWindowExit, len(startedCmds))
+	watch := func(name string, cmd CommandContext) {
+		go func() {
+			exited <- startupWindowExit{name: name, err: svc.watchStartupWindow(name, cmd)}
+		}()
+	}
+	watching := 0
+	for name, cmd := range startedCmds {
+		if cmd.StartupWindow == 0 {
+			continue
+		}
+		watching++
+		watch(name, cmd)
+	}
+
+	// Processes are started again one at a time, as starting one changes the umask and resource limits of go-init.
+	retries := make(map[string]int)
+	var failures []string
+	for ; watching > 0; watching-- {
+		exit := <-exited
+		if exit.err == nil {
+			continue
+		}
+		svc.notify(svc.stdout, notification{Event: launchlib.NotificationEventCrash, Process: exit.name,
+			Message: exit.err.Error()})
+		cmd := startedCmds[exit.name]
+		if retries[exit.name] >= cmd.StartRetries {
+			failures = append(failures, exit.err.Error())
+			continue
+		}
+		retries[exit.name]++
+		fmt.Fprintf(svc.stdout, "%v\nStarting process '%s' again in %v, retry %d of %d\n", exit.err, exit.name,
+			cmd.RetryDelay, retries[exit.name], cmd.StartRetries)
+		Clock.Sleep(cmd.RetryDelay)
+		cmd.Command = cloneCommand(cmd.Command)
+		if err := svc.startCommand(exit.name, cmd); err != nil {
+			failures = append(failures, errors.Wrapf(err, "failed to start command '%s' again", exit.name).Error())
+			continue
+		}
+		// The health check and notifications that follow are of the process that was started again.
+		cmd.exit = waitInBackground(cmd.Command)
+		startedCmds[exit.name] = cmd
+		if err := svc.writeCommandPidfile(exit.name, cmd, cmd.Command.Process.Pid); err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		svc.notify(svc.stdout, notification{Event: launchlib.NotificationEventRestart, Process: exit.name,
+			Pid: cmd.Command.Process.Pid})
+		watching++
+		watch(exit.name, cmd)
+	}
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+func (svc *Service) watchStartupWindow(name string, cmd CommandContext) error {
+	fmt.Fprintf(svc.stdout, "Waiting %v for process '%s' to remain running\n", cmd.StartupWindow, name)
+	timer := Clock.NewTimer(cmd.StartupWindow)
+	defer timer.Stop()
+	started := Clock.Now()
+
+	select {
+	case <-timer.Chan():
+		return nil
+	case <-cmd.exit.exited:
+		waitErr := cmd.exit.err
+		// The pidfile would otherwise be left pointing at a process that no longer exists.
+		_ = os.Remove(fmt.Sprintf(svc.pidfileFormat, name))
+		_ = svc.recordProcessExit(name, waitErr)
+		err := errors.Errorf("process '%s' exited after %v, within its startup window of %v: %v", name,
+			Clock.Now().Sub(started).Round(time.Millisecond), cmd.StartupWindow, exitDescription(waitErr))
+		for _, outputFile := range []string{cmd.OutputFile, cmd.ErrorOutputFile} {
+			if outputFile == "" {
+				continue
+			}
+			if tail, tErr := tailFile(outputFile, startupLogTailLines); tErr == nil && tail != "" {
+				err = errors.Errorf("%v\nlast lines of %s:\n%s", err, outputFile, tail)
+			}
+		}
+		return err
+	}
+}
+
+func exitDescription(waitErr error) string {
+	if waitErr == nil {
+		return "exit status 0"
+	}
+	return waitErr.Error()
+}
+
+// tailFile returns at most the last n lines of the file at path.
+func tailFile(path string, n int) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return tailLines(string(content), n), nil
+}
+
+// tailLines returns at most the last n lines of content.
+func tailLines(content string, n int) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
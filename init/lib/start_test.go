@@ -0,0 +1,152 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// To prevent accidental changes to parameter default values
+func TestTailFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-start")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := filepath.Join(dir, "startup.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("one\ntwo\nthree\n"), 0644))
+
+	tail, err := tailFile(path, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "two\nthree", tail)
+
+	tail, err = tailFile(path, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\nthree", tail)
+}
+
+func TestWaitForStartupWindows_EarlyExit(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-start")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc.pidfileFormat, svc.statefileFormat = filepath.Join(dir, "%s.pid"), filepath.Join(dir, "%s.state")
+	output, err := os.Create(filepath.Join(dir, "startup.log"))
+	require.NoError(t, err)
+	defer func() {
+		_ = output.Close()
+	}()
+
+	cmd := exec.Command("/bin/sh", "-c", "echo 'Error: Could not find or load main class'; exit 1")
+	cmd.Stdout = output
+	require.NoError(t, cmd.Start())
+
+	svc.stdout = ioutil.Discard
+	err = svc.waitForStartupWindows(map[string]CommandContext{
+		"primary": {Command: cmd, OutputFile: output.Name(), StartupWindow: time.Minute, exit: waitInBackground(cmd)},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "process 'primary' exited after")
+	assert.Contains(t, err.Error(), "exit status 1")
+	assert.Contains(t, err.Error(), "Error: Could not find or load main class")
+
+	state, err := svc.readProcessState("primary")
+	require.NoError(t, err)
+	require.NotNil(t, state.LastExitCode)
+	assert.Equal(t, 1, *state.LastExitCode)
+	assert.Empty(t, state.LastExitSignal)
+	assert.NotNil(t, state.LastExitTime)
+}
+
+func TestWaitForStartupWindows_Retry(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-start")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc.pidfileFormat, svc.statefileFormat = filepath.Join(dir, "%s.pid"), filepath.Join(dir, "%s.state")
+
+	// The process exits the first time it is started, as if its port were still in use, and remains running after.
+	marker := filepath.Join(dir, "started")
+	cmd := exec.Command("/bin/sh", "-c", "if [ -e "+marker+" ]; then exec sleep 10; fi; touch "+marker+"; exit 1")
+	require.NoError(t, cmd.Start())
+	cmds := map[string]CommandContext{
+		"primary": {
+			Command:       cmd,
+			Logger:        launchlib.NewSimpleWriterLogger(ioutil.Discard).SubProcessLogger("primary"),
+			StartupWindow: 500 * time.Millisecond,
+			StartRetries:  1,
+			exit:          waitInBackground(cmd),
+		},
+	}
+
+	svc.stdout = ioutil.Discard
+	require.NoError(t, svc.waitForStartupWindows(cmds))
+	restarted := cmds["primary"].Command
+	// The process is still waited for in the background, so is only killed.
+	defer func() {
+		_ = restarted.Process.Kill()
+	}()
+	assert.NotEqual(t, cmd, restarted)
+	pid, err := ioutil.ReadFile(filepath.Join(dir, "primary.pid"))
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(restarted.Process.Pid), string(pid))
+}
+
+func TestStartCommand_SeparateStderr(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-start")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	loggers := &FileLoggers{service: svc, flags: NewTruncatingFirst()}
+	outputFile := filepath.Join(dir, "startup.log")
+	errorOutputFile := errorOutputFile(outputFile)
+	assert.Equal(t, filepath.Join(dir, "startup-error.log"), errorOutputFile)
+
+	cmd := CommandContext{
+		Command:         exec.Command("/bin/sh", "-c", "echo out; echo err >&2"),
+		Logger:          func() (io.WriteCloser, error) { return loggers.OpenFile(outputFile) },
+		OutputFile:      outputFile,
+		ErrorLogger:     errorLogger(loggers, errorOutputFile),
+		ErrorOutputFile: errorOutputFile,
+	}
+	svc.stdout = ioutil.Discard
+	require.NoError(t, svc.startCommand("primary", cmd))
+	require.NoError(t, cmd.Command.Wait())
+
+	output, err := ioutil.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "out\n", string(output))
+	errorOutput, err := ioutil.ReadFile(errorOutputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "err\n", string(errorOutput))
+}
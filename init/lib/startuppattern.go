@@ -0,0 +1,136 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// startupPatternInterval is how often the output files of a process are read for a line matching its startup pattern.
+var startupPatternInterval = 250 * time.Millisecond
+
+// startupPatternTimeout returns the StartupPatternTimeout of the config, or its default if unset.
+func startupPatternTimeout(config launchlib.StaticLauncherConfig) time.Duration {
+	if config.StartupPatternTimeout == 0 {
+		return launchlib.DefaultStartupPatternTimeout
+	}
+	return config.StartupPatternTimeout
+}
+
+// waitForStartupPatterns waits for the output of each of the started processes that has a startup pattern to match it.
+func (svc *Service) waitForStartupPatterns(startedCmds map[string]CommandContext) error {
+	for name, cmd := range startedCmds {
+		if cmd.StartupPattern == nil {
+			continue
+		}
+		if err := svc.waitForStartupPattern(name, cmd); err != nil {
+			return errors.Wrapf(err, "process '%s' did not report that it started", name)
+		}
+	}
+	return nil
+}
+
+// waitForStartupPattern reads the output files of the command as they are written until a line matches its startup
+// pattern. The output files are truncated when the command is started, so earlier runs are not matched.
+func (svc *Service) waitForStartupPattern(name string, cmd CommandContext) error {
+	fmt.Fprintf(svc.stdout, "Waiting up to %v for the output of process '%s' to match '%s'\n",
+		cmd.PatternTimeout, name, cmd.StartupPattern)
+	timer := Clock.NewTimer(cmd.PatternTimeout)
+	defer timer.Stop()
+	ticker := Clock.NewTicker(startupPatternInterval)
+	defer ticker.Stop()
+
+	var readers []*lineReader
+	for _, outputFile := range []string{cmd.OutputFile, cmd.ErrorOutputFile} {
+		if outputFile != "" {
+			readers = append(readers, &lineReader{path: outputFile})
+		}
+	}
+	for {
+		select {
+		case <-ticker.Chan():
+			for _, reader := range readers {
+				line, err := reader.match(cmd.StartupPattern)
+				if err != nil {
+					return err
+				}
+				if line != "" {
+					fmt.Fprintf(svc.stdout, "Process '%s' started: %s\n", name, line)
+					return nil
+				}
+			}
+		case <-timer.Chan():
+			return errors.Errorf("no line of its output matched '%s' within %v", cmd.StartupPattern,
+				cmd.PatternTimeout)
+		}
+	}
+}
+
+// lineReader reads the lines appended to a file since it was last read.
+type lineReader struct {
+	path   string
+	offset int64
+	// partial is the last line read, which may not have been completely written.
+	partial string
+}
+
+// match returns the first of the lines written since the file was last read that matches the pattern, or "" if none
+// do. A file that does not exist yet has no lines.
+func (r *lineReader) match(pattern *regexp.Regexp) (string, error) {
+	file, err := os.Open(r.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", errors.Wrapf(err, "failed to read output file %s", r.path)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	if _, err := file.Seek(r.offset, io.SeekStart); err != nil {
+		return "", errors.Wrapf(err, "failed to read output file %s", r.path)
+	}
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := file.Read(buf)
+		r.offset += int64(n)
+		lines := strings.Split(r.partial+string(buf[:n]), "\n")
+		r.partial = lines[len(lines)-1]
+		for _, line := range lines[:len(lines)-1] {
+			if pattern.MatchString(line) {
+				return line, nil
+			}
+		}
+		if err == io.EOF || n == 0 {
+			break
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to read output file %s", r.path)
+		}
+	}
+	if pattern.MatchString(r.partial) {
+		return r.partial, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "go-init-startuppattern")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := filepath.Join(dir, "startup.log")
+	pattern := regexp.MustCompile(`Started \w+ in [0-9.]+s`)
+	reader := &lineReader{path: path}
+
+	// A file that does not exist yet has no lines.
+	line, err := reader.match(pattern)
+	require.NoError(t, err)
+	assert.Empty(t, line)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("Starting Service\nStarted Serv"), 0644))
+	line, err = reader.match(pattern)
+	require.NoError(t, err)
+	assert.Empty(t, line)
+
+	// The line that was partially written is matched once it is complete.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = file.WriteString("ice in 12.3s\nListening on 8080\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	line, err = reader.match(pattern)
+	require.NoError(t, err)
+	assert.Equal(t, "Started Service in 12.3s", line)
+}
+
+func TestWaitForStartupPatterns(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-startuppattern")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	outputFile := filepath.Join(dir, "startup.log")
+	require.NoError(t, ioutil.WriteFile(outputFile, []byte("Starting Service\n"), 0644))
+	interval := startupPatternInterval
+	startupPatternInterval = 10 * time.Millisecond
+	defer func() {
+		startupPatternInterval = interval
+	}()
+
+	svc.stdout = ioutil.Discard
+	cmds := map[string]CommandContext{
+		"primary": {
+			OutputFile:     outputFile,
+			StartupPattern: regexp.MustCompile(`^Started`),
+			PatternTimeout: 100 * time.Millisecond,
+		},
+	}
+	assert.EqualError(t, svc.waitForStartupPatterns(cmds), "process 'primary' did not report that it started: no "+
+		"line of its output matched '^Started' within 100ms")
+
+	require.NoError(t, ioutil.WriteFile(outputFile, []byte("Starting Service\nStarted Service in 1.2s\n"), 0644))
+	assert.NoError(t, svc.waitForStartupPatterns(cmds))
+}
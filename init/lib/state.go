@@ -0,0 +1,215 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// stateVersion is the version of the format of the state files that this go-init writes. State files written by a
+// later go-init are not read, as they may record fields that would be lost when rewritten, while those without a
+// version were written before the format was versioned and are read as version 1.
+const stateVersion = 1
+
+// processState is persisted in the state directory to record information about the previous runs of a process. The
+// pidfile itself is still written on its own for the tools that read it.
+type processState struct {
+	Version  int `json:"version"`
+	Restarts int `json:"restarts"`
+	// LivenessRestarts is how many of the restarts were of the process having hung, failing its liveness check.
+	LivenessRestarts int  `json:"livenessRestarts,omitempty"`
+	LastExitCode     *int `json:"lastExitCode,omitempty"`
+	// LastExitSignal is the name of the signal that terminated the process when it last exited, if any.
+	LastExitSignal string     `json:"lastExitSignal,omitempty"`
+	LastExitTime   *time.Time `json:"lastExitTime,omitempty"`
+	// Pid and StartTicks identify the process that the pidfile was written for, so that a later process that reuses
+	// its pid is not mistaken for it.
+	Pid        int    `json:"pid,omitempty"`
+	StartTicks uint64 `json:"startTicks,omitempty"`
+	// StartTime is when go-init last started the process.
+	StartTime *time.Time `json:"startTime,omitempty"`
+	// ConfigHash is the hash of the configuration the process was started with, so that 'status' can report that it
+	// has since changed.
+	ConfigHash string `json:"configHash,omitempty"`
+	// StartupTimings are how long each step of starting the process took.
+	StartupTimings *StartupTimings `json:"startupTimings,omitempty"`
+	// CrashLooping is whether 'supervise' gave up restarting the process as it was restarted too often, until the
+	// process is next started.
+	CrashLooping bool `json:"crashLooping,omitempty"`
+}
+
+func (svc *Service) readProcessState(name string) (processState, error) {
+	var state processState
+	stateBytes, err := ioutil.ReadFile(fmt.Sprintf(svc.statefileFormat, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return state, errors.Wrapf(err, "failed to read state file for '%s'", name)
+	}
+	if err := json.Unmarshal(stateBytes, &state); err != nil {
+		return state, errors.Wrapf(err, "failed to parse state file for '%s'", name)
+	}
+	if state.Version > stateVersion {
+		return processState{}, errors.Errorf("state file for '%s' has version %d, but this go-init only reads "+
+			"version %d", name, state.Version, stateVersion)
+	}
+	return state, nil
+}
+
+func (svc *Service) writeProcessState(name string, state processState) error {
+	statefile := fmt.Sprintf(svc.statefileFormat, name)
+	if err := os.MkdirAll(filepath.Dir(statefile), svc.dirMode); err != nil {
+		return errors.Wrap(err, "unable to create state file directory")
+	}
+	state.Version = stateVersion
+	stateBytes, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrapf(err, "failed to serialize state for '%s'", name)
+	}
+	if err := svc.writeFileAtomically(statefile, stateBytes); err != nil {
+		return errors.Wrapf(err, "failed to save state to file for '%s'", name)
+	}
+	return nil
+}
+
+// updateProcessState applies the update to the state of the named process and writes it, holding an exclusive lock on
+// the state file so that concurrent invocations of go-init, such as 'supervise' and 'status', do not lose each other's
+// updates. Readers need not lock, as the state file is replaced atomically.
+func (svc *Service) updateProcessState(name string, update func(state *processState)) error {
+	unlock, err := svc.lockProcessState(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	state, err := svc.readProcessState(name)
+	if err != nil {
+		return err
+	}
+	update(&state)
+	return svc.writeProcessState(name, state)
+}
+
+// lockProcessState takes an exclusive lock on the lock file of the state file of the named process, waiting for any
+// other holder, and returns a function that releases it.
+func (svc *Service) lockProcessState(name string) (func(), error) {
+	unlock, err := svc.lockFile(fmt.Sprintf(svc.statefileFormat, name)+".lock", func() {})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to lock state file for '%s'", name)
+	}
+	return unlock, nil
+}
+
+// recordExit records the exit of the process given the error returned when waiting for it.
+func (state *processState) recordExit(waitErr error) {
+	code := exitCode(waitErr)
+	now := Clock.Now()
+	state.LastExitCode = &code
+	state.LastExitSignal = ""
+	state.LastExitTime = &now
+	if signal, ok := exitSignal(waitErr); ok {
+		state.LastExitSignal = launchlib.SignalName(signal)
+	}
+}
+
+// recordProcessExit records the exit of the named process in its state file, so that 'status' can report it.
+func (svc *Service) recordProcessExit(name string, waitErr error) error {
+	return svc.updateProcessState(name, func(state *processState) {
+		state.recordExit(waitErr)
+	})
+}
+
+// exitSignal returns the signal that terminated a process given the error returned when waiting for it.
+func exitSignal(waitErr error) (syscall.Signal, bool) {
+	if exitErr, ok := waitErr.(*exec.ExitError); ok {
+		if waitStatus, ok := exitErr.Sys().(syscall.WaitStatus); ok && waitStatus.Signaled() {
+			return waitStatus.Signal(), true
+		}
+	}
+	return 0, false
+}
+
+// configHash returns the hash of the static and custom configuration of a process, which changes whenever either does.
+func configHash(staticConfig launchlib.StaticLauncherConfig, customConfig launchlib.CustomLauncherConfig) (string,
+	error) {
+	configBytes, err := json.Marshal([]interface{}{staticConfig, customConfig})
+	if err != nil {
+		return "", errors.Wrap(err, "failed to serialize configuration")
+	}
+	sum := sha256.Sum256(configBytes)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// processConfigHashes returns the hash of the configuration of each process, by name.
+func processConfigHashes(staticConfig *launchlib.PrimaryStaticLauncherConfig,
+	customConfig *launchlib.PrimaryCustomLauncherConfig) (map[string]string, error) {
+	hashes := make(map[string]string, len(staticConfig.SubProcesses)+1)
+	hash, err := configHash(staticConfig.StaticLauncherConfig, customConfig.CustomLauncherConfig)
+	if err != nil {
+		return nil, err
+	}
+	hashes[staticConfig.ServiceName] = hash
+	for name, subStatic := range staticConfig.SubProcesses {
+		if hashes[name], err = configHash(subStatic, customConfig.SubProcesses[name]); err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+// recordStartedCommand records the hash of the configuration the named process was started with, and the timings of
+// starting it so far, in its state file.
+func (svc *Service) recordStartedCommand(name string, cmdCtx CommandContext) error {
+	return svc.updateProcessState(name, func(state *processState) {
+		now := Clock.Now()
+		state.StartTime = &now
+		state.ConfigHash = cmdCtx.ConfigHash
+		state.StartupTimings = cmdCtx.Timings
+		state.CrashLooping = false
+	})
+}
+
+// recordStartupTimings records the timings of starting the named process in its state file once it is ready.
+func (svc *Service) recordStartupTimings(name string, timings *StartupTimings) error {
+	return svc.updateProcessState(name, func(state *processState) {
+		state.StartupTimings = timings
+	})
+}
+
+// configChanged returns whether the configuration of the named process has changed since it was started, which is
+// never the case for a process whose configuration was not recorded, such as one started by an older go-init.
+func (svc *Service) configChanged(name string, hash string) bool {
+	state, err := svc.readProcessState(name)
+	return err == nil && state.ConfigHash != "" && hash != "" && state.ConfigHash != hash
+}
+
+// crashLooping returns whether 'supervise' gave up restarting the named process since it was last started.
+func (svc *Service) crashLooping(name string) bool {
+	state, err := svc.readProcessState(name)
+	return err == nil && state.CrashLooping
+}
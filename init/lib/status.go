@@ -0,0 +1,381 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// statusExitCodeSchemes map the LSB exit codes of status to those of each other scheme.
+var statusExitCodeSchemes = map[string]map[int]int{
+	launchlib.StatusExitCodesMonit: {3: 1, 4: 1, 150: 1},
+	launchlib.StatusExitCodesS6:    {3: 1, 4: 111, 150: 1},
+}
+
+// statusExitCode returns the exit code of status in the configured scheme given its LSB exit code.
+func (svc *Service) statusExitCode(code int) int {
+	if mapped, ok := statusExitCodeSchemes[svc.statusExitCodes][code]; ok {
+		return mapped
+	}
+	return code
+}
+
+var (
+	Running = ServiceState{
+		Description: "Running",
+		Applicable: func(serviceStatus *serviceStatus, err error) bool {
+			return err == nil && len(serviceStatus.notRunningCmds) == 0
+		},
+		ExitStatus: func(serviceStatus *serviceStatus, err error) (int, error) {
+			return 0, nil
+		},
+	}
+	Dead = ServiceState{
+		Description: "Process dead but pidfile exists.",
+		Applicable: func(serviceStatus *serviceStatus, err error) bool {
+			return err == nil && len(serviceStatus.notRunningCmds) > 0 && len(serviceStatus.writtenPids) > 0
+		},
+		ExitStatus: func(serviceStatus *serviceStatus, err error) (int, error) {
+			return 1, errors.Errorf("commands '%v' are not running but there is a record of commands '%v' "+
+				"having been started", commandNames(serviceStatus.notRunningCmds), serviceStatus.writtenPids)
+		},
+	}
+	// CrashLooping is distinguished from the service having been stopped by an exit code in the range reserved by LSB
+	// for applications.
+	CrashLooping = ServiceState{
+		Description: "Service crash-looping",
+		Applicable: func(serviceStatus *serviceStatus, err error) bool {
+			return err == nil && len(serviceStatus.crashLoopingCmds) > 0
+		},
+		ExitStatus: func(serviceStatus *serviceStatus, err error) (int, error) {
+			return 150, errors.Errorf("commands '%v' are crash-looping and are no longer restarted",
+				sortedNames(serviceStatus.crashLoopingCmds))
+		},
+	}
+	NotRunning = ServiceState{
+		Description: "Service not running",
+		Applicable: func(serviceStatus *serviceStatus, err error) bool {
+			return err == nil && len(serviceStatus.notRunningCmds) > 0 && len(serviceStatus.writtenPids) == 0
+		},
+		ExitStatus: func(serviceStatus *serviceStatus, err error) (int, error) {
+			return 3, errors.Errorf("commands '%v' are not running", commandNames(serviceStatus.notRunningCmds))
+		},
+	}
+	ErrorState = ServiceState{
+		Description: "Failed to determine service status",
+		Applicable: func(serviceStatus *serviceStatus, err error) bool {
+			return err != nil
+		},
+		ExitStatus: func(serviceStatus *serviceStatus, err error) (int, error) {
+			if err != nil {
+				return 4, errors.Wrap(err, "failed to determine service status")
+			}
+			return 4, errors.Errorf("failed to determine service status")
+		},
+	}
+)
+
+// StatusOptions are the options of PrintStatus, as given by the flags of 'go-init status'.
+type StatusOptions struct {
+	// JSON prints the status of each process as a JSON document.
+	JSON bool
+	// Verbose also prints the resource usage of each running process.
+	Verbose bool
+}
+
+// Status returns the status of the processes of the given names, or of all processes if none are given, as printed
+// by 'go-init status --json'.
+func (svc *Service) Status(processes ...string) StatusReport {
+	serviceStatus, err := svc.getSelectedServiceStatus(&DevNullLoggers{}, processes)
+	matched, code, err := svc.matchServiceState(serviceStatus, err)
+	return svc.newStatusReport(matched, serviceStatus, code, err)
+}
+
+// PrintStatus prints the status of the processes of the given names, or of all processes if none are given, as
+// 'go-init status' does, returning an error with its exit code if it is nonzero.
+func (svc *Service) PrintStatus(options StatusOptions, processes ...string) error {
+	return svc.executeWithLoggers(NewAlwaysAppending(), func(svc *Service, loggers launchlib.ServiceLoggers) error {
+		return svc.printStatus(options, processes)
+	})
+}
+
+func (svc *Service) printStatus(options StatusOptions, names []string) error {
+	// Executed with logging for errors, however we discard the verbose logging of getServiceStatus
+	serviceStatus, err := svc.getSelectedServiceStatus(&DevNullLoggers{}, names)
+	matched, code, err := svc.matchServiceState(serviceStatus, err)
+	if options.JSON {
+		if jsonErr := svc.printStatusReport(svc.newStatusReport(matched, serviceStatus, code, err)); jsonErr != nil {
+			return svc.logErrorAndReturnWithExitCode(errors.Wrap(jsonErr, "failed to print status report"),
+				svc.statusExitCode(4))
+		}
+		if code != 0 {
+			if err != nil {
+				_, _ = fmt.Fprintln(svc.stdout, err)
+			}
+			return cli.WithExitCode(code, errors.New(""))
+		}
+		return nil
+	}
+	if options.Verbose {
+		for _, process := range svc.newStatusReport(matched, serviceStatus, code, err).Processes {
+			fmt.Fprintln(svc.stdout, process.summary())
+		}
+	}
+	if serviceStatus != nil {
+		for _, name := range sortedNames(serviceStatus.changedConfigs) {
+			fmt.Fprintf(svc.stderr, "Warning: the configuration of process '%s' has changed since it was started, "+
+				"restart it to apply the changes\n", name)
+		}
+	}
+
+	if code != 0 {
+		fmt.Fprintln(svc.stderr, matched.Description)
+		if !options.Verbose {
+			// How the processes that are not running last exited is reported even without --verbose.
+			for _, process := range svc.newStatusReport(matched, serviceStatus, code, err).Processes {
+				if !process.Running && process.LastExitCode != nil {
+					fmt.Fprintln(svc.stderr, process.summary())
+				}
+			}
+		}
+		if err != nil {
+			return svc.logErrorAndReturnWithExitCode(err, code)
+		}
+		// Non-zero exit codes can only be reported through cli.WithExitCode which must include an errors, though that
+		// error can be empty.  Returning no error gives an exit code of 0
+		return cli.WithExitCode(code, errors.New(""))
+	}
+
+	fmt.Fprintln(svc.out, matched.Description)
+	return nil
+}
+
+// matchServiceState returns the state of the service given its status, or the error determining it, along with the
+// exit code of status in the configured scheme and the reason for it if nonzero.
+func (svc *Service) matchServiceState(serviceStatus *serviceStatus, err error) (*ServiceState, int, error) {
+	var matched *ServiceState
+	for _, state := range []ServiceState{ErrorState, CrashLooping, NotRunning, Dead, Running} {
+		if state.Applicable(serviceStatus, err) {
+			matched = &state
+			break
+		}
+	}
+
+	// If no state has matched, default to error state
+	if matched == nil {
+		matched = &ErrorState
+	}
+
+	code, err := matched.ExitStatus(serviceStatus, err)
+	return matched, svc.statusExitCode(code), err
+}
+
+type ServiceState struct {
+	Description string
+	Applicable  func(serviceStatus *serviceStatus, err error) bool
+	ExitStatus  func(serviceStatus *serviceStatus, err error) (int, error)
+}
+
+// sortedNames returns the names that are set in the map, in order.
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// commandNames returns the names of the commands, in order.
+func commandNames(commands map[string]CommandContext) []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StatusReport is the document printed by 'status --json'.
+type StatusReport struct {
+	State     string          `json:"state"`
+	ExitCode  int             `json:"exitCode"`
+	Reason    string          `json:"reason,omitempty"`
+	Processes []ProcessStatus `json:"processes"`
+}
+
+type ProcessStatus struct {
+	Name          string `json:"name"`
+	Pid           int    `json:"pid,omitempty"`
+	Pidfile       string `json:"pidfile"`
+	Running       bool   `json:"running"`
+	UptimeSeconds int64  `json:"uptimeSeconds,omitempty"`
+	// StartTime is when go-init started the running process, where it was recorded.
+	StartTime *time.Time `json:"startTime,omitempty"`
+	// ResidentBytes, CPUPercent, Threads and OpenFiles are the resource usage of a running process, where available.
+	// CPUPercent is the average since the process started, of a single CPU.
+	ResidentBytes uint64  `json:"residentBytes,omitempty"`
+	CPUPercent    float64 `json:"cpuPercent,omitempty"`
+	Threads       int     `json:"threads,omitempty"`
+	OpenFiles     int     `json:"openFiles,omitempty"`
+	Restarts      int     `json:"restarts,omitempty"`
+	// LivenessRestarts is how many of the restarts were of the process having failed its liveness check.
+	LivenessRestarts int `json:"livenessRestarts,omitempty"`
+	// LastExitCode, LastExitSignal and LastExitTime describe the last exit of the process, where it was recorded.
+	LastExitCode   *int       `json:"lastExitCode,omitempty"`
+	LastExitSignal string     `json:"lastExitSignal,omitempty"`
+	LastExitTime   *time.Time `json:"lastExitTime,omitempty"`
+	// ConfigChanged is whether the configuration of the running process has changed since it was started, so that it
+	// must be restarted to apply the changes.
+	ConfigChanged bool `json:"configChanged,omitempty"`
+	// CrashLooping is whether the process is not running as 'supervise' gave up restarting it.
+	CrashLooping bool `json:"crashLooping,omitempty"`
+	// StartupTimings are how long each step of starting the process took when it was last started, of which the time
+	// until it was ready is only measured by 'go-init start'.
+	StartupTimings *StartupTimings `json:"startupTimings,omitempty"`
+}
+
+const exitTimeFormat = "2006-01-02 15:04:05 MST"
+
+// summary describes the process on a single line, such as
+// "primary: running, pid 12345, up 42s, 256 MiB resident, 3.5% CPU, 40 threads, 120 open files".
+func (process ProcessStatus) summary() string {
+	if !process.Running {
+		summary := process.Name + ": not running"
+		if process.LastExitCode != nil {
+			summary += fmt.Sprintf(", exited with code %d", *process.LastExitCode)
+		}
+		if process.LastExitSignal != "" {
+			summary += fmt.Sprintf(" (%s)", process.LastExitSignal)
+		}
+		if process.LastExitTime != nil {
+			summary += " at " + process.LastExitTime.Format(exitTimeFormat)
+		}
+		if process.CrashLooping {
+			summary += ", crash-looping"
+		}
+		return summary
+	}
+	parts := []string{process.Name + ": running", fmt.Sprintf("pid %d", process.Pid)}
+	if process.UptimeSeconds > 0 {
+		parts = append(parts, fmt.Sprintf("up %v", time.Duration(process.UptimeSeconds)*time.Second))
+	}
+	if process.ResidentBytes > 0 {
+		parts = append(parts, fmt.Sprintf("%d MiB resident", process.ResidentBytes>>20))
+	}
+	if process.Threads > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f%% CPU", process.CPUPercent), fmt.Sprintf("%d threads",
+			process.Threads))
+	}
+	if process.OpenFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d open files", process.OpenFiles))
+	}
+	if process.ConfigChanged {
+		parts = append(parts, "configuration changed since started")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (svc *Service) newStatusReport(state *ServiceState, serviceStatus *serviceStatus, code int,
+	err error) StatusReport {
+	report := StatusReport{
+		State:     state.Description,
+		ExitCode:  code,
+		Processes: []ProcessStatus{},
+	}
+	if err != nil {
+		report.Reason = err.Error()
+	}
+	if serviceStatus == nil {
+		return report
+	}
+
+	names := commandNames(serviceStatus.notRunningCmds)
+	for name := range serviceStatus.runningProcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		process := ProcessStatus{
+			Name:    name,
+			Pid:     serviceStatus.writtenPids[name],
+			Pidfile: fmt.Sprintf(svc.pidfileFormat, name),
+		}
+		// Restarts are only recorded when supervised, and exits when observed by go-init, so are reported when
+		// available.
+		state, stateErr := svc.readProcessState(name)
+		if stateErr == nil {
+			process.Restarts = state.Restarts
+			process.LivenessRestarts = state.LivenessRestarts
+			process.LastExitCode = state.LastExitCode
+			process.LastExitSignal = state.LastExitSignal
+			process.LastExitTime = state.LastExitTime
+			process.StartupTimings = state.StartupTimings
+		}
+		if proc, ok := serviceStatus.runningProcs[name]; ok {
+			process.Running = true
+			process.ConfigChanged = serviceStatus.changedConfigs[name]
+			if stateErr == nil && (state.Pid == 0 || state.Pid == proc.Pid) {
+				process.StartTime = state.StartTime
+			}
+			addProcessUsage(&process, proc.Pid)
+		} else {
+			process.CrashLooping = serviceStatus.crashLoopingCmds[name]
+		}
+		report.Processes = append(report.Processes, process)
+	}
+	return report
+}
+
+// addProcessUsage adds the uptime and resource usage of the running process with the pid to its status. Each is best
+// effort, as they are not available on all platforms nor for the processes of other users.
+func addProcessUsage(process *ProcessStatus, pid int) {
+	var uptime time.Duration
+	if startTime, err := processStartTime(pid); err == nil {
+		uptime = Clock.Now().Sub(startTime)
+		process.UptimeSeconds = int64(uptime / time.Second)
+	}
+	if residentBytes, err := processResidentBytes(pid); err == nil {
+		process.ResidentBytes = residentBytes
+	}
+	if cpuTime, err := processCPUTime(pid); err == nil && uptime > 0 {
+		process.CPUPercent = math.Round(1000*float64(cpuTime)/float64(uptime)) / 10
+	}
+	if threads, err := processThreads(pid); err == nil {
+		process.Threads = threads
+	}
+	if openFiles, err := processOpenFiles(pid); err == nil {
+		process.OpenFiles = openFiles
+	}
+}
+
+func (svc *Service) printStatusReport(report StatusReport) error {
+	reportBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(svc.out, string(reportBytes))
+	return nil
+}
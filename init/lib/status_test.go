@@ -0,0 +1,240 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// To prevent accidental changes to parameter default values
+func TestAddProcessUsage(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process resource usage is only available on Linux")
+	}
+	process := ProcessStatus{Name: "primary", Pid: os.Getpid(), Running: true}
+	addProcessUsage(&process, os.Getpid())
+	assert.NotZero(t, process.ResidentBytes)
+	assert.NotZero(t, process.Threads)
+	assert.NotZero(t, process.OpenFiles)
+	assert.Contains(t, process.summary(), "primary: running, pid ")
+}
+
+func TestProcessStatusSummary(t *testing.T) {
+	exitCode := 137
+	exitTime := time.Date(2020, 1, 2, 12, 3, 42, 0, time.UTC)
+	for i, currCase := range []struct {
+		process ProcessStatus
+		want    string
+	}{
+		{
+			process: ProcessStatus{
+				Name:          "primary",
+				Pid:           12345,
+				Running:       true,
+				UptimeSeconds: 90,
+				ResidentBytes: 256 << 20,
+				CPUPercent:    3.5,
+				Threads:       40,
+				OpenFiles:     120,
+			},
+			want: "primary: running, pid 12345, up 1m30s, 256 MiB resident, 3.5% CPU, 40 threads, 120 open files",
+		},
+		{
+			process: ProcessStatus{Name: "primary", Pid: 12345, Running: true, ConfigChanged: true},
+			want:    "primary: running, pid 12345, configuration changed since started",
+		},
+		{
+			process: ProcessStatus{
+				Name:           "sidecar",
+				LastExitCode:   &exitCode,
+				LastExitSignal: "SIGKILL",
+				LastExitTime:   &exitTime,
+			},
+			want: "sidecar: not running, exited with code 137 (SIGKILL) at 2020-01-02 12:03:42 UTC",
+		},
+		{
+			process: ProcessStatus{Name: "sidecar"},
+			want:    "sidecar: not running",
+		},
+		{
+			process: ProcessStatus{Name: "sidecar", LastExitCode: &exitCode, CrashLooping: true},
+			want:    "sidecar: not running, exited with code 137, crash-looping",
+		},
+	} {
+		assert.Equal(t, currCase.want, currCase.process.summary(), "Case %d", i)
+	}
+}
+
+func TestStatusExitCode(t *testing.T) {
+	svc := newTestService(t)
+	for i, currCase := range []struct {
+		scheme string
+		want   map[int]int
+	}{
+		{scheme: launchlib.StatusExitCodesLSB, want: map[int]int{0: 0, 1: 1, 3: 3, 4: 4, 150: 150}},
+		{scheme: launchlib.StatusExitCodesMonit, want: map[int]int{0: 0, 1: 1, 3: 1, 4: 1, 150: 1}},
+		{scheme: launchlib.StatusExitCodesS6, want: map[int]int{0: 0, 1: 1, 3: 1, 4: 111, 150: 1}},
+	} {
+		svc.statusExitCodes = currCase.scheme
+		for code, want := range currCase.want {
+			assert.Equal(t, want, svc.statusExitCode(code), "Case %d: exit code %d", i, code)
+		}
+	}
+}
+
+func TestMatchServiceState(t *testing.T) {
+	svc := newTestService(t)
+	for i, currCase := range []struct {
+		status    *serviceStatus
+		wantState string
+		wantCode  int
+	}{
+		{
+			status:    &serviceStatus{runningProcs: map[string]*os.Process{"primary": nil}},
+			wantState: Running.Description,
+			wantCode:  0,
+		},
+		{
+			status: &serviceStatus{
+				notRunningCmds: map[string]CommandContext{"primary": {}},
+				writtenPids:    servicePids{"primary": 12345},
+			},
+			wantState: Dead.Description,
+			wantCode:  1,
+		},
+		{
+			status:    &serviceStatus{notRunningCmds: map[string]CommandContext{"primary": {}}},
+			wantState: NotRunning.Description,
+			wantCode:  3,
+		},
+		{
+			status: &serviceStatus{
+				notRunningCmds:   map[string]CommandContext{"primary": {}},
+				crashLoopingCmds: map[string]bool{"primary": true},
+			},
+			wantState: CrashLooping.Description,
+			wantCode:  150,
+		},
+	} {
+		state, code, _ := svc.matchServiceState(currCase.status, nil)
+		assert.Equal(t, currCase.wantState, state.Description, "Case %d", i)
+		assert.Equal(t, currCase.wantCode, code, "Case %d", i)
+	}
+
+	svc.statusExitCodes = launchlib.StatusExitCodesS6
+	_, code, err := svc.matchServiceState(&serviceStatus{
+		notRunningCmds:   map[string]CommandContext{"primary": {}},
+		crashLoopingCmds: map[string]bool{"primary": true},
+	}, nil)
+	assert.Equal(t, 1, code)
+	assert.EqualError(t, err, "commands '[primary]' are crash-looping and are no longer restarted")
+}
+
+func TestStatusReport_JSON(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	exitCode := 137
+
+	for i, currCase := range []struct {
+		name string
+		// setUp records the processes of the service as started or exited.
+		setUp func(t *testing.T, svc *Service)
+		want  string
+	}{
+		{
+			name: "running",
+			setUp: func(t *testing.T, svc *Service) {
+				for _, name := range []string{"primary", "sidecar"} {
+					require.NoError(t, svc.writeCommandPidfile(name, CommandContext{Command: cmd}, cmd.Process.Pid))
+				}
+			},
+			want: `{"state":"Running","exitCode":0,"processes":[` +
+				`{"name":"primary","pid":PID,"pidfile":"DIR/run/primary.pid","running":true},` +
+				`{"name":"sidecar","pid":PID,"pidfile":"DIR/run/sidecar.pid","running":true}]}`,
+		},
+		{
+			name:  "not running",
+			setUp: func(t *testing.T, svc *Service) {},
+			want: `{"state":"Service not running","exitCode":3,` +
+				`"reason":"commands '[primary sidecar]' are not running","processes":[` +
+				`{"name":"primary","pidfile":"DIR/run/primary.pid","running":false},` +
+				`{"name":"sidecar","pidfile":"DIR/run/sidecar.pid","running":false}]}`,
+		},
+		{
+			name: "partially running",
+			setUp: func(t *testing.T, svc *Service) {
+				require.NoError(t, svc.writeCommandPidfile("primary", CommandContext{Command: cmd}, cmd.Process.Pid))
+				require.NoError(t, svc.writeProcessState("sidecar", processState{LastExitCode: &exitCode,
+					LastExitSignal: "SIGKILL"}))
+			},
+			want: `{"state":"Process dead but pidfile exists.","exitCode":1,` +
+				`"reason":"commands '[sidecar]' are not running but there is a record of commands ` +
+				`'map[primary:PID]' having been started","processes":[` +
+				`{"name":"primary","pid":PID,"pidfile":"DIR/run/primary.pid","running":true},` +
+				`{"name":"sidecar","pidfile":"DIR/run/sidecar.pid","running":false,"lastExitCode":137,` +
+				`"lastExitSignal":"SIGKILL"}]}`,
+		},
+	} {
+		func() {
+			dir, err := ioutil.TempDir("", "go-init-status")
+			require.NoError(t, err)
+			defer func() {
+				_ = os.RemoveAll(dir)
+			}()
+			svc := newTestService(t)
+			svc.setPaths(Paths{
+				StaticConfigFile: svc.launcherStaticFile,
+				CustomConfigFile: svc.launcherCustomFile,
+				PidfileFormat:    filepath.Join(dir, "run", "%s.pid"),
+				StateDir:         filepath.Join(dir, "state"),
+				OutputFile:       filepath.Join(dir, "startup.log"),
+			})
+			currCase.setUp(t, svc)
+
+			serviceStatus, err := svc.getCommandsStatus(map[string]CommandContext{"primary": {}, "sidecar": {}})
+			require.NoError(t, err, "Case %d: %s", i, currCase.name)
+			state, code, err := svc.matchServiceState(serviceStatus, err)
+			report := svc.newStatusReport(state, serviceStatus, code, err)
+			// The start time, uptime and resource usage of running processes vary, so are not compared.
+			for j := range report.Processes {
+				process := &report.Processes[j]
+				process.StartTime, process.UptimeSeconds, process.ResidentBytes, process.CPUPercent = nil, 0, 0, 0
+				process.Threads, process.OpenFiles = 0, 0
+			}
+			reportBytes, err := json.Marshal(report)
+			require.NoError(t, err)
+			want := strings.NewReplacer("DIR", dir, "PID", strconv.Itoa(cmd.Process.Pid)).Replace(currCase.want)
+			assert.JSONEq(t, want, string(reportBytes), "Case %d: %s", i, currCase.name)
+		}()
+	}
+}
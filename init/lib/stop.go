@@ -0,0 +1,447 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	time2 "github.com/palantir/go-java-launcher/init/lib/time"
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+var (
+	// Clock is overridden in the tests to be a fake clock
+	Clock = time2.NewRealClock()
+)
+
+// Stop stops the processes of the given names, or all processes if none are given, that are running, as 'go-init stop'
+// does.
+func (svc *Service) Stop(processes ...string) error {
+	return svc.executeWithLoggers(NewAlwaysAppending(), func(svc *Service, loggers launchlib.ServiceLoggers) error {
+		return svc.stopProcesses(loggers, processes)
+	})
+}
+
+// stopProcesses stops the processes of the given names, or all processes if none are given, that are running.
+func (svc *Service) stopProcesses(loggers launchlib.ServiceLoggers, names []string) error {
+	unlock, err := svc.lockService(svc.stdout)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+	defer unlock()
+
+	allCmds, err := svc.getConfiguredCommands(loggers)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(
+			errors.Wrap(err, "failed to get commands from static and custom configuration files"), 1)
+	}
+	cmds, err := selectNamedCommands(names, allCmds)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+
+	svc.notifySystemd("STOPPING=1")
+
+	// A supervisor would restart the processes as they stop, so it must be stopped first.
+	if _, supervisor, err := svc.getCmdProcess(supervisorPidName); err != nil {
+		return svc.logErrorAndReturnWithExitCode(errors.Wrap(err, "failed to determine supervisor status"), 1)
+	} else if supervisor != nil {
+		if len(cmds) != len(allCmds) {
+			return svc.logErrorAndReturnWithExitCode(errors.New("individual processes cannot be stopped while "+
+				"the service is supervised, stop all processes instead"), 1)
+		}
+		// The supervisor stops the processes itself once sent SIGTERM, so is given as long as they may take.
+		report := newStopReport()
+		svc.stopService(map[string]*os.Process{supervisorPidName: supervisor},
+			map[string]time.Duration{supervisorPidName: serviceStopTimeout(allCmds, svc.stopOrder)}, report)
+		if failed := report.failed(); len(failed) > 0 {
+			return svc.logErrorAndReturnWithExitCode(errors.Errorf("failed to stop supervisor: %v",
+				report.outcomes[supervisorPidName].err), 1)
+		}
+	}
+
+	runningProcs := map[string]*os.Process{}
+	for name := range cmds {
+		_, proc, err := svc.getCmdProcess(name)
+		if err != nil {
+			return svc.logErrorAndReturnWithExitCode(errors.Wrap(err, "failed to determine process status"), 1)
+		}
+
+		if proc != nil {
+			runningProcs[name] = proc
+		}
+	}
+
+	report := newStopReport()
+	for _, wave := range svc.stopWaves(cmds, runningProcs) {
+		procs := make(map[string]*os.Process, len(wave))
+		for _, name := range wave {
+			procs[name] = runningProcs[name]
+			report.outcome(name)
+		}
+		svc.runPreStopHooks(cmds, wave)
+		for _, name := range svc.requestStops(cmds, procs) {
+			report.outcome(name).requestFailed = true
+		}
+		svc.stopService(procs, stopTimeouts(cmds, wave), report)
+	}
+	report.write(svc.stdout)
+	if failed := report.failed(); len(failed) > 0 {
+		return svc.logErrorAndReturnWithExitCode(errors.Errorf("failed to stop service: failed to stop processes "+
+			"'%v'", failed), 1)
+	}
+	for name, proc := range runningProcs {
+		svc.notify(svc.stdout, notification{Event: launchlib.NotificationEventStop, Process: name, Pid: proc.Pid})
+	}
+
+	var errs bool
+	for _, name := range append(commandNames(cmds), supervisorPidName) {
+		if err := os.Remove(fmt.Sprintf(svc.pidfileFormat, name)); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(svc.stderr, "failed to remove stopped process pidfile for '%s'\n", name)
+			errs = true
+		}
+	}
+
+	if errs {
+		return svc.logErrorAndReturnWithExitCode(errors.New("error removing stopped service pidfiles"), 1)
+	}
+	return nil
+}
+
+// stopPollInterval is how often a process that was requested to stop is checked for having exited.
+var stopPollInterval = time.Second
+
+// requestStops requests each of the running processes that has a stop URL to stop, in parallel, waiting for them to
+// exit for up to their timeouts. Failures are reported rather than returned, as the processes that have not exited are
+// then sent SIGTERM regardless. Returns the sorted names of the processes whose requests failed.
+func (svc *Service) requestStops(cmds map[string]CommandContext, procs map[string]*os.Process) []string {
+	stdout := &syncWriter{writer: svc.stdout}
+	var mu sync.Mutex
+	var failed []string
+	var wg sync.WaitGroup
+	for name, proc := range procs {
+		cmd, ok := cmds[name]
+		if !ok || cmd.Stop == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(name string, proc *os.Process, config launchlib.StopConfig) {
+			defer wg.Done()
+			if err := requestStop(stdout, name, proc, config); err != nil {
+				fmt.Fprintf(stdout, "%v, so it is sent SIGTERM\n", err)
+				mu.Lock()
+				failed = append(failed, name)
+				mu.Unlock()
+			}
+		}(name, proc, cmd.Stop.WithDefaults())
+	}
+	wg.Wait()
+	sort.Strings(failed)
+	return failed
+}
+
+// requestStop POSTs to the stop URL of the process and waits for it to exit within the remainder of the timeout.
+func requestStop(stdout io.Writer, name string, proc *os.Process, config launchlib.StopConfig) error {
+	fmt.Fprintf(stdout, "Requesting process '%s' to stop at %s\n", name, config.URL)
+	timer := Clock.NewTimer(config.Timeout)
+	defer timer.Stop()
+	req, err := http.NewRequest(http.MethodPost, config.URL, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to request process '%s' to stop", name)
+	}
+	client := http.Client{Timeout: config.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to request process '%s' to stop", name)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("failed to request process '%s' to stop: %s responded with status %d", name,
+			config.URL, resp.StatusCode)
+	}
+
+	ticker := Clock.NewTicker(stopPollInterval)
+	defer ticker.Stop()
+	for isProcRunning(proc) {
+		select {
+		case <-ticker.Chan():
+		case <-timer.Chan():
+			return errors.Errorf("process '%s' did not exit within %v of being requested to stop", name,
+				config.Timeout)
+		}
+	}
+	fmt.Fprintf(stdout, "Process '%s' exited after being requested to stop\n", name)
+	return nil
+}
+
+// stopService sends SIGTERM to the process group of each of the processes and waits for them to stop, sending SIGKILL
+// to those that have not stopped within their timeouts, and records how each of them stopped in the report.
+func (svc *Service) stopService(procs map[string]*os.Process, timeouts map[string]time.Duration, report *stopReport) {
+	// Every process is signalled and waited for even if signalling one of them fails, so that as much of the service
+	// as possible is stopped.
+	// The process groups are recorded before the processes are signalled, as they cannot be determined once the
+	// processes have exited, to kill whatever they spawned that outlives them.
+	pgids := make([]int, 0, len(procs))
+	for _, proc := range procs {
+		pgids = append(pgids, processGroup(proc))
+	}
+	for name, proc := range procs {
+		if err := signalProcessGroup(proc, syscall.SIGTERM); err != nil && !strings.Contains(err.Error(),
+			"os: process already finished") {
+			fmt.Fprintf(svc.stdout, "failed to stop '%s' process: %v\n", name, err)
+			report.outcome(name).err = err
+			delete(procs, name)
+		}
+	}
+
+	svc.waitForServiceToStop(procs, timeouts, report)
+	for _, pgid := range pgids {
+		killProcessGroupRemnants(pgid)
+	}
+}
+
+// numSecondsToWait is how long processes are given to stop after a SIGTERM before they are sent a SIGKILL, unless their
+// stopTimeout is set.
+const numSecondsToWait = 240
+
+// waitForServiceToStop waits for the processes to stop, sending SIGKILL to each that has not stopped within its
+// timeout.
+func (svc *Service) waitForServiceToStop(procs map[string]*os.Process, timeouts map[string]time.Duration,
+	report *stopReport) {
+	ticker := Clock.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	// The processes are killed as each of their distinct timeouts elapses, with a single timer at a time.
+	var elapsed time.Duration
+	for _, timeout := range distinctTimeouts(procs, timeouts) {
+		timer := Clock.NewTimer(timeout - elapsed)
+		if !waitForProcsOrTimer(procs, ticker, timer) {
+			// The ticker is stopped before the timer, as a fake clock may be blocked ticking it until then.
+			ticker.Stop()
+			timer.Stop()
+			return
+		}
+		elapsed = timeout
+
+		var killedProcs []string
+		for name, remainingProc := range procs {
+			if timeouts[name] != timeout {
+				continue
+			}
+			delete(procs, name)
+			if !isProcRunning(remainingProc) {
+				continue
+			}
+			if err := signalProcessGroup(remainingProc, syscall.SIGKILL); err != nil {
+				// If this actually errors, something is probably seriously wrong.
+				report.outcome(name).err = errors.Wrapf(err, "failed to kill process with pid %d", remainingProc.Pid)
+				continue
+			}
+			report.outcome(name).killedAfter = timeout
+			killedProcs = append(killedProcs, name)
+		}
+		if len(killedProcs) > 0 {
+			sort.Strings(killedProcs)
+			fmt.Fprintf(svc.stdout, "processes '%v' did not stop within %v seconds, so a SIGKILL was sent\n",
+				killedProcs, timeout.Seconds())
+		}
+	}
+}
+
+// waitForProcsOrTimer waits for every one of the processes to stop, returning false once they have, or for the timer,
+// returning true once it has expired. The processes that have stopped are removed.
+func waitForProcsOrTimer(procs map[string]*os.Process, ticker time2.Ticker, timer time2.Timer) bool {
+	for len(procs) > 0 {
+		select {
+		case <-ticker.Chan():
+			for name, remainingProc := range procs {
+				if !isProcRunning(remainingProc) {
+					delete(procs, name)
+				}
+			}
+		case <-timer.Chan():
+			return true
+		}
+	}
+	return false
+}
+
+// stopWaves returns the names of the given processes grouped into the waves they are stopped in, in order: all of them
+// at once, unless the stopOrder is StopOrderReverseDependencies, in which case the reverse of the waves in which the
+// commands are started, so that no process is stopped before the processes that depend on it. Each wave is sorted.
+func (svc *Service) stopWaves(cmds map[string]CommandContext, procs map[string]*os.Process) [][]string {
+	names := processNames(procs)
+	sort.Strings(names)
+	if len(names) == 0 {
+		return nil
+	}
+	if svc.stopOrder != launchlib.StopOrderReverseDependencies {
+		return [][]string{names}
+	}
+	waves, err := startWaves(cmds)
+	if err != nil {
+		// The dependencies were validated along with the configuration.
+		return [][]string{names}
+	}
+	var stopWaves [][]string
+	for i := len(waves) - 1; i >= 0; i-- {
+		var wave []string
+		for _, name := range waves[i] {
+			if _, ok := procs[name]; ok {
+				wave = append(wave, name)
+			}
+		}
+		if len(wave) > 0 {
+			stopWaves = append(stopWaves, wave)
+		}
+	}
+	return stopWaves
+}
+
+// stopTimeout returns the time the command is given to exit after SIGTERM before it is sent SIGKILL.
+func stopTimeout(cmd CommandContext) time.Duration {
+	if cmd.StopTimeout > 0 {
+		return cmd.StopTimeout
+	}
+	return numSecondsToWait * time.Second
+}
+
+// stopTimeouts returns the stop timeouts of the named commands.
+func stopTimeouts(cmds map[string]CommandContext, names []string) map[string]time.Duration {
+	timeouts := make(map[string]time.Duration, len(names))
+	for _, name := range names {
+		timeouts[name] = stopTimeout(cmds[name])
+	}
+	return timeouts
+}
+
+// distinctTimeouts returns the distinct timeouts of the processes in increasing order.
+func distinctTimeouts(procs map[string]*os.Process, timeouts map[string]time.Duration) []time.Duration {
+	seen := make(map[time.Duration]struct{}, len(procs))
+	var distinct []time.Duration
+	for name := range procs {
+		timeout := timeouts[name]
+		if _, ok := seen[timeout]; !ok {
+			seen[timeout] = struct{}{}
+			distinct = append(distinct, timeout)
+		}
+	}
+	sort.Slice(distinct, func(i, j int) bool {
+		return distinct[i] < distinct[j]
+	})
+	return distinct
+}
+
+// serviceStopTimeout returns the longest that stopping every one of the commands in the given stop order may take:
+// the longest stop request and stop timeout of the commands stopped at once, added up over the waves they are stopped
+// in.
+func serviceStopTimeout(cmds map[string]CommandContext, order string) time.Duration {
+	waves := [][]string{commandNames(cmds)}
+	if order == launchlib.StopOrderReverseDependencies {
+		if startOrder, err := startWaves(cmds); err == nil {
+			waves = startOrder
+		}
+	}
+	var total time.Duration
+	for _, wave := range waves {
+		var longest time.Duration
+		for _, name := range wave {
+			timeout := stopTimeout(cmds[name])
+			if stop := cmds[name].Stop; stop != nil {
+				timeout += stop.WithDefaults().Timeout
+			}
+			if timeout > longest {
+				longest = timeout
+			}
+		}
+		total += longest
+	}
+	return total
+}
+
+// stopOutcome is how a process stopped, as reported once the service has been stopped.
+type stopOutcome struct {
+	// requestFailed is set if the process did not exit after being requested to stop at its stop URL.
+	requestFailed bool
+	// killedAfter is the stop timeout of the process if it was sent SIGKILL for not exiting within it.
+	killedAfter time.Duration
+	// err is set if the process could not be signalled.
+	err error
+}
+
+func (o stopOutcome) String() string {
+	switch {
+	case o.err != nil:
+		return fmt.Sprintf("failed to stop: %v", o.err)
+	case o.killedAfter > 0:
+		return fmt.Sprintf("killed after not stopping within %v", o.killedAfter)
+	case o.requestFailed:
+		return "stopped on SIGTERM after its stop request failed"
+	}
+	return "stopped cleanly"
+}
+
+// stopReport records how each of the processes stopped, in the order they were stopped in.
+type stopReport struct {
+	names    []string
+	outcomes map[string]*stopOutcome
+}
+
+func newStopReport() *stopReport {
+	return &stopReport{outcomes: map[string]*stopOutcome{}}
+}
+
+// outcome returns the outcome of the named process, adding it to the report if it is not yet.
+func (r *stopReport) outcome(name string) *stopOutcome {
+	outcome, ok := r.outcomes[name]
+	if !ok {
+		outcome = &stopOutcome{}
+		r.outcomes[name] = outcome
+		r.names = append(r.names, name)
+	}
+	return outcome
+}
+
+// failed returns the names of the processes that could not be signalled.
+func (r *stopReport) failed() []string {
+	var failed []string
+	for _, name := range r.names {
+		if r.outcomes[name].err != nil {
+			failed = append(failed, name)
+		}
+	}
+	return failed
+}
+
+// write writes how each of the processes stopped to w, if any were stopped.
+func (r *stopReport) write(w io.Writer) {
+	if len(r.names) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Stop report:")
+	for _, name := range r.names {
+		fmt.Fprintf(w, "- %s: %v\n", name, r.outcomes[name])
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// To prevent accidental changes to parameter default values
+func TestRequestStops(t *testing.T) {
+	svc := newTestService(t)
+	interval := stopPollInterval
+	stopPollInterval = 10 * time.Millisecond
+	defer func() {
+		stopPollInterval = interval
+	}()
+
+	for i, currCase := range []struct {
+		status int
+		exits  bool
+		want   string
+	}{
+		{status: http.StatusOK, exits: true, want: "Process 'primary' exited after being requested to stop"},
+		{status: http.StatusOK, want: "process 'primary' did not exit within 100ms of being requested to stop, so " +
+			"it is sent SIGTERM"},
+		{status: http.StatusServiceUnavailable, want: "responded with status 503, so it is sent SIGTERM"},
+	} {
+		cmd := exec.Command("sleep", "10")
+		require.NoError(t, cmd.Start(), "Case %d", i)
+		var requests []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method)
+			if currCase.exits {
+				_ = cmd.Process.Kill()
+				_ = cmd.Wait()
+			}
+			w.WriteHeader(currCase.status)
+		}))
+
+		stdout := &bytes.Buffer{}
+		svc.stdout = stdout
+		svc.requestStops(map[string]CommandContext{
+			"primary": {Stop: &launchlib.StopConfig{URL: server.URL + "/shutdown", Timeout: 100 * time.Millisecond}},
+			"sidecar": {},
+		}, map[string]*os.Process{"primary": cmd.Process})
+		server.Close()
+		if !currCase.exits {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		}
+
+		assert.Equal(t, []string{http.MethodPost}, requests, "Case %d", i)
+		assert.Contains(t, stdout.String(), currCase.want, "Case %d", i)
+	}
+}
+
+func TestStopWaves(t *testing.T) {
+	svc := newTestService(t)
+	cmds := map[string]CommandContext{
+		"primary": dependsOnProcesses("db", "cache"),
+		"db":      {},
+		"cache":   dependsOnProcesses("db"),
+		"metrics": {},
+	}
+	procs := map[string]*os.Process{"primary": {}, "db": {}, "cache": {}, "metrics": {}}
+
+	svc.stopOrder = launchlib.StopOrderParallel
+	assert.Equal(t, [][]string{{"cache", "db", "metrics", "primary"}}, svc.stopWaves(cmds, procs))
+
+	svc.stopOrder = launchlib.StopOrderReverseDependencies
+	assert.Equal(t, [][]string{{"primary"}, {"cache"}, {"db", "metrics"}}, svc.stopWaves(cmds, procs))
+
+	// Processes that are not running are skipped.
+	delete(procs, "cache")
+	assert.Equal(t, [][]string{{"primary"}, {"db", "metrics"}}, svc.stopWaves(cmds, procs))
+}
+
+func TestServiceStopTimeout(t *testing.T) {
+	primary := dependsOnProcesses("db")
+	primary.Stop = &launchlib.StopConfig{URL: "http://localhost:8081/drain"}
+	cmds := map[string]CommandContext{
+		"primary": primary,
+		"db":      {StopTimeout: 10 * time.Minute},
+		"cache":   {StopTimeout: 30 * time.Second},
+	}
+
+	assert.Equal(t, 10*time.Minute, serviceStopTimeout(cmds, launchlib.StopOrderParallel))
+	// The primary is given a minute to stop when requested and 240 seconds after SIGTERM before the db is stopped.
+	assert.Equal(t, 15*time.Minute, serviceStopTimeout(cmds, launchlib.StopOrderReverseDependencies))
+}
+
+func TestStopService_PerProcessTimeouts(t *testing.T) {
+	svc := newTestService(t)
+	clean := exec.Command("sleep", "10")
+	inOwnProcessGroup(clean)
+	require.NoError(t, clean.Start())
+	cleanExited := make(chan struct{})
+	go func() {
+		_ = clean.Wait()
+		close(cleanExited)
+	}()
+
+	stdout, writer, err := os.Pipe()
+	require.NoError(t, err)
+	defer func() {
+		_ = stdout.Close()
+	}()
+	stubborn := exec.Command("sh", "-c", `trap "" TERM; echo ready; sleep 10`)
+	stubborn.Stdout = writer
+	inOwnProcessGroup(stubborn)
+	require.NoError(t, stubborn.Start())
+	_ = writer.Close()
+	go func() {
+		_ = stubborn.Wait()
+	}()
+	// The stubborn process ignores SIGTERM once it is ready.
+	_, err = stdout.Read(make([]byte, 6))
+	require.NoError(t, err)
+
+	output := &bytes.Buffer{}
+	svc.stdout = output
+	report := newStopReport()
+	started := time.Now()
+	svc.stopService(map[string]*os.Process{"clean": clean.Process, "stubborn": stubborn.Process},
+		map[string]time.Duration{"clean": 300 * time.Millisecond, "stubborn": 600 * time.Millisecond}, report)
+	assert.True(t, time.Since(started) >= 600*time.Millisecond)
+	<-cleanExited
+
+	assert.Equal(t, "stopped cleanly", report.outcome("clean").String())
+	assert.Equal(t, "killed after not stopping within 600ms", report.outcome("stubborn").String())
+	assert.Empty(t, report.failed())
+	assert.Contains(t, output.String(), "processes '[stubborn]' did not stop within 0.6 seconds, so a SIGKILL was "+
+		"sent")
+}
+
+func TestStopReport(t *testing.T) {
+	report := newStopReport()
+	report.outcome("primary").requestFailed = true
+	report.outcome("sidecar").killedAfter = 30 * time.Second
+	report.outcome("metrics")
+	report.outcome("cache").err = errors.New("operation not permitted")
+
+	output := &bytes.Buffer{}
+	report.write(output)
+	assert.Equal(t, `Stop report:
+- primary: stopped on SIGTERM after its stop request failed
+- sidecar: killed after not stopping within 30s
+- metrics: stopped cleanly
+- cache: failed to stop: operation not permitted
+`, output.String())
+	assert.Equal(t, []string{"cache"}, report.failed())
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// prSetChildSubreaper is PR_SET_CHILD_SUBREAPER of prctl(2).
+const prSetChildSubreaper = 36
+
+// becomeSubreaper makes go-init the child subreaper of its descendants, so that the processes left running by commands
+// that daemonize are re-parented to go-init rather than to init once their parents exit.
+func becomeSubreaper() error {
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0); errno != 0 {
+		return errors.Wrap(errno, "failed to make go-init a child subreaper")
+	}
+	return nil
+}
+
+// orphanedChildren returns the running children of go-init started no earlier than startTicks, which include the
+// processes re-parented to it as a child subreaper, reaping those that have exited.
+func orphanedChildren(startTicks uint64) ([]int, error) {
+	entries, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list processes")
+	}
+	self := strconv.Itoa(os.Getpid())
+	var children []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		// Processes may exit while we iterate, so failing to read one is expected.
+		fields, err := readProcessStat(pid)
+		if err != nil || fields[statParentPid] != self {
+			continue
+		}
+		if ticks, err := strconv.ParseUint(fields[statStartTime], 10, 64); err != nil || ticks < startTicks {
+			continue
+		}
+		if fields[statState] == "Z" {
+			var waitStatus syscall.WaitStatus
+			_, _ = syscall.Wait4(pid, &waitStatus, syscall.WNOHANG, nil)
+			continue
+		}
+		children = append(children, pid)
+	}
+	return children, nil
+}
@@ -0,0 +1,30 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package lib
+
+import (
+	"github.com/pkg/errors"
+)
+
+func becomeSubreaper() error {
+	return errors.New("daemonizes is only supported on linux")
+}
+
+func orphanedChildren(startTicks uint64) ([]int, error) {
+	return nil, errors.New("daemonizes is only supported on linux")
+}
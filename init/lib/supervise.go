@@ -0,0 +1,392 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// supervisorPidName is the name of the pidfile of a running supervisor, which contains an underscore so that it cannot
+// clash with the name of a process.
+const supervisorPidName = "go-init_supervisor"
+
+type supervisor struct {
+	service      *Service
+	config       launchlib.SupervisionConfig
+	serviceName  string
+	metrics      launchlib.MetricsConfig
+	resourceLog  launchlib.ResourceLogConfig
+	resources    *resourceSampler
+	cmds         map[string]CommandContext
+	outputs      *reopeningLoggers
+	running      map[string]*os.Process
+	states       map[string]processState
+	restartTimes map[string][]time.Time
+	exits        chan processExit
+	hangs        chan processHang
+	restarts     chan string
+	done         chan struct{}
+}
+
+// Supervise runs the processes in the foreground, restarting any process that exits, until stopped by SIGTERM, SIGINT
+// or Stop, as 'go-init supervise' does.
+func (svc *Service) Supervise() error {
+	return svc.executeWithLoggers(NewTruncatingFirst(), func(svc *Service, loggers launchlib.ServiceLoggers) error {
+		return svc.supervise(loggers)
+	})
+}
+
+func (svc *Service) supervise(loggers launchlib.ServiceLoggers) error {
+	staticConfig, customConfig, err := svc.readConfigs()
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+	// The outputs of the processes are written through go-init, so that 'rotate-logs' can make it reopen them.
+	var outputs *reopeningLoggers
+	if files, ok := loggers.(errorLoggers); ok {
+		outputs = svc.newReopeningLoggers(files)
+		defer outputs.close()
+		if primary, err := outputs.PrimaryLogger(); err == nil {
+			svc = svc.withStdout(primary)
+		}
+		loggers = outputs
+	}
+	cmds, err := svc.compileCommands(&staticConfig, &customConfig, loggers)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+	serviceStatus, err := svc.getCommandsStatus(cmds)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(
+			errors.Wrap(err, "failed to determine service status to determine what commands to run"), 1)
+	}
+	if len(serviceStatus.runningProcs) > 0 {
+		return svc.logErrorAndReturnWithExitCode(errors.Errorf("commands '%v' are already running",
+			processNames(serviceStatus.runningProcs)), 1)
+	}
+	if err := launchlib.VerifyFiles(staticConfig.Verify, svc.stdout); err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+	if err := svc.runPreflightChecks(svc.stdout, cmds); err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+
+	signals, stopSignals := captureSignals()
+	defer stopSignals()
+	reopens := make(chan os.Signal, 1)
+	signal.Notify(reopens, reopenSignal)
+	defer signal.Stop(reopens)
+
+	if err := svc.writePidfile(supervisorPidName, os.Getpid()); err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+	defer svc.removePidfile(supervisorPidName)
+
+	s := &supervisor{
+		service:      svc,
+		config:       staticConfig.Supervision.WithDefaults(),
+		serviceName:  staticConfig.ServiceName,
+		metrics:      staticConfig.Metrics.WithDefaults(),
+		resourceLog:  staticConfig.ResourceLog,
+		cmds:         cmds,
+		outputs:      outputs,
+		running:      map[string]*os.Process{},
+		states:       map[string]processState{},
+		restartTimes: map[string][]time.Time{},
+		exits:        make(chan processExit, len(cmds)),
+		hangs:        make(chan processHang),
+		restarts:     make(chan string),
+		done:         make(chan struct{}),
+	}
+	defer close(s.done)
+	if err := s.run(signals, reopens); err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+	return nil
+}
+
+func (s *supervisor) run(signals, reopens <-chan os.Signal) error {
+	var metricsTicks <-chan time.Time
+	if s.metrics.TextfilePath != "" {
+		ticker := Clock.NewTicker(s.metrics.Interval)
+		defer ticker.Stop()
+		metricsTicks = ticker.Chan()
+		// The processes have stopped by the time the supervisor returns, which the final metrics report.
+		defer s.writeMetrics()
+	}
+
+	var resourceTicks <-chan time.Time
+	if s.resourceLog.Interval > 0 {
+		ticker := Clock.NewTicker(s.resourceLog.Interval)
+		defer ticker.Stop()
+		resourceTicks = ticker.Chan()
+		s.resources = s.service.newResourceSampler(s.resourceLog)
+	}
+
+	var heartbeats <-chan time.Time
+	if s.config.HeartbeatFile != "" {
+		ticker := Clock.NewTicker(s.config.HeartbeatInterval)
+		defer ticker.Stop()
+		heartbeats = ticker.Chan()
+		s.touchHeartbeat()
+		defer func() {
+			if err := os.Remove(s.config.HeartbeatFile); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintln(s.service.stdout, "failed to remove heartbeat file:", err)
+			}
+		}()
+	}
+
+	waves, err := startWaves(s.cmds)
+	if err != nil {
+		return err
+	}
+	for _, wave := range waves {
+		for _, name := range wave {
+			s.states[name] = processState{}
+			if err := s.start(name); err != nil {
+				s.stopAll()
+				return errors.Wrapf(err, "failed to start command '%s'", name)
+			}
+		}
+		if err := s.service.waitForDependedUpon(s.cmds, wave); err != nil {
+			s.stopAll()
+			return err
+		}
+	}
+
+	s.service.notifySystemd("READY=1")
+	for name, proc := range s.running {
+		s.service.notify(s.service.stdout, notification{Event: launchlib.NotificationEventStart, Process: name,
+			Pid: proc.Pid})
+	}
+	if metricsTicks != nil {
+		s.writeMetrics()
+	}
+
+	for {
+		select {
+		case <-metricsTicks:
+			s.writeMetrics()
+		case <-resourceTicks:
+			if err := s.resources.sample(s.running); err != nil {
+				fmt.Fprintln(s.service.stdout, err)
+			}
+		case <-heartbeats:
+			s.touchHeartbeat()
+		case hang := <-s.hangs:
+			// The process may have exited and been restarted since it was found to have hung.
+			if proc, ok := s.running[hang.name]; ok && proc.Pid == hang.pid {
+				s.killHung(hang, proc)
+			}
+		case sig := <-signals:
+			s.service.notifySystemd("STOPPING=1")
+			fmt.Fprintf(s.service.stdout, "Received signal %v, stopping processes '%v'\n", sig,
+				processNames(s.running))
+			stopped := make(map[string]*os.Process, len(s.running))
+			for name, proc := range s.running {
+				stopped[name] = proc
+			}
+			s.service.runPreStopHooks(s.cmds, processNames(s.running))
+			s.stopAll()
+			for name, proc := range stopped {
+				s.service.notify(s.service.stdout, notification{Event: launchlib.NotificationEventStop, Process: name,
+					Pid: proc.Pid})
+			}
+			return nil
+		case <-reopens:
+			if s.outputs == nil {
+				continue
+			}
+			if err := s.outputs.reopen(); err != nil {
+				fmt.Fprintln(s.service.stdout, "failed to reopen output files:", err)
+			} else {
+				fmt.Fprintln(s.service.stdout, "Reopened output files")
+			}
+		case exit := <-s.exits:
+			delete(s.running, exit.name)
+			s.service.removePidfile(exit.name)
+			if err := s.handleExit(exit); err != nil {
+				s.stopAll()
+				return err
+			}
+		case name := <-s.restarts:
+			if err := s.start(name); err != nil {
+				if err := s.handleExit(processExit{name: name, err: err}); err != nil {
+					s.stopAll()
+					return err
+				}
+				continue
+			}
+			s.service.notify(s.service.stdout, notification{Event: launchlib.NotificationEventRestart, Process: name,
+				Pid: s.running[name].Pid})
+		}
+	}
+}
+
+func (s *supervisor) start(name string) error {
+	cmd := s.cmds[name]
+	if cmd.Command.Process != nil {
+		// An exec.Cmd can only be started once, so restarts use a fresh copy.
+		cmd.Command = cloneCommand(cmd.Command)
+		s.cmds[name] = cmd
+	}
+	if err := s.service.startCommand(name, cmd); err != nil {
+		return err
+	}
+	s.running[name] = cmd.Command.Process
+	cmd.exit = waitInBackground(cmd.Command)
+	s.cmds[name] = cmd
+	go func(exit *commandExit) {
+		<-exit.exited
+		s.exits <- processExit{name: name, err: exit.err}
+	}(cmd.exit)
+	if cmd.LivenessCheck != nil {
+		go watchLiveness(name, cmd.Command.Process.Pid, cmd.LivenessCheck.WithDefaults(), s.hangs, cmd.exit.exited)
+	}
+	if err := s.service.writeCommandPidfile(name, cmd, cmd.Command.Process.Pid); err != nil {
+		return err
+	}
+	// The process is running and supervised even if a hook fails, so the failure is only reported.
+	if err := s.service.runHooks("postStart", name, cmd, cmd.Hooks.PostStart); err != nil {
+		fmt.Fprintln(s.service.stdout, err)
+	}
+	return nil
+}
+
+// handleExit records the exit of a process and schedules it to be restarted, returning an error if it has been
+// restarted too many times.
+func (s *supervisor) handleExit(exit processExit) error {
+	code := exitCode(exit.err)
+	fmt.Fprintf(s.service.stdout, "Process '%s' exited with exit code %d\n", exit.name, code)
+
+	now := Clock.Now()
+	var recentRestarts []time.Time
+	for _, restart := range s.restartTimes[exit.name] {
+		if now.Sub(restart) < s.config.RestartWindow {
+			recentRestarts = append(recentRestarts, restart)
+		}
+	}
+	state := s.states[exit.name]
+	state.recordExit(exit.err)
+	if len(recentRestarts) < *s.config.MaxRestarts {
+		state.Restarts++
+	} else {
+		state.CrashLooping = true
+	}
+	s.states[exit.name] = state
+	// Only the fields kept by the supervisor are written, so as not to undo those recorded when the process started.
+	if err := s.service.updateProcessState(exit.name, func(recorded *processState) {
+		recorded.Restarts = state.Restarts
+		recorded.LivenessRestarts = state.LivenessRestarts
+		recorded.CrashLooping = state.CrashLooping
+		recorded.LastExitCode = state.LastExitCode
+		recorded.LastExitSignal = state.LastExitSignal
+		recorded.LastExitTime = state.LastExitTime
+	}); err != nil {
+		fmt.Fprintln(s.service.stdout, "failed to record process state:", err)
+	}
+
+	crash := exitNotification(launchlib.NotificationEventCrash, exit.name, exit.err)
+	if len(recentRestarts) >= *s.config.MaxRestarts {
+		err := errors.Errorf("process '%s' was restarted %d times within %v, giving up", exit.name,
+			len(recentRestarts), s.config.RestartWindow)
+		crash.Message = err.Error()
+		s.service.notify(s.service.stdout, crash)
+		return err
+	}
+	s.restartTimes[exit.name] = append(recentRestarts, now)
+
+	backoff := s.config.InitialBackoff << uint(len(recentRestarts))
+	if backoff > s.config.MaxBackoff || backoff <= 0 {
+		backoff = s.config.MaxBackoff
+	}
+	fmt.Fprintf(s.service.stdout, "Restarting process '%s' in %v\n", exit.name, backoff)
+	crash.Message = fmt.Sprintf("restarting in %v", backoff)
+	s.service.notify(s.service.stdout, crash)
+	go s.restartAfter(exit.name, backoff)
+	return nil
+}
+
+func (s *supervisor) restartAfter(name string, backoff time.Duration) {
+	timer := Clock.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-timer.Chan():
+		select {
+		case s.restarts <- name:
+		case <-s.done:
+		}
+	case <-s.done:
+	}
+}
+
+// killHung kills a process that has not passed its liveness check, which is then restarted as any other process that
+// exits, recording that it was restarted because it hung.
+func (s *supervisor) killHung(hang processHang, proc *os.Process) {
+	fmt.Fprintf(s.service.stdout, "Process '%s' did not pass its liveness check within %v, so a SIGKILL was sent: %v\n",
+		hang.name, s.cmds[hang.name].LivenessCheck.WithDefaults().MaxWait, hang.err)
+	state := s.states[hang.name]
+	state.LivenessRestarts++
+	s.states[hang.name] = state
+	// Errors are only possible if the process has already exited, which is reported on exits.
+	_ = signalProcessGroup(proc, syscall.SIGKILL)
+}
+
+func (s *supervisor) touchHeartbeat() {
+	if err := s.service.touchHeartbeat(s.config.HeartbeatFile); err != nil {
+		fmt.Fprintln(s.service.stdout, err)
+	}
+}
+
+// writeMetrics writes the metrics of each process to the configured textfile.
+func (s *supervisor) writeMetrics() {
+	processes := make([]ProcessStatus, 0, len(s.cmds))
+	for name := range s.cmds {
+		state := s.states[name]
+		process := ProcessStatus{Name: name, Restarts: state.Restarts, LastExitCode: state.LastExitCode}
+		if proc, ok := s.running[name]; ok {
+			process.Running = true
+			addProcessUsage(&process, proc.Pid)
+		}
+		processes = append(processes, process)
+	}
+	if err := s.service.writeMetrics(s.metrics.TextfilePath, s.serviceName, processes); err != nil {
+		fmt.Fprintln(s.service.stdout, err)
+	}
+}
+
+func (s *supervisor) stopAll() {
+	s.service.stopRunningProcesses(s.cmds, s.running, s.exits, false)
+}
+
+func cloneCommand(cmd *exec.Cmd) *exec.Cmd {
+	return &exec.Cmd{
+		Path:        cmd.Path,
+		Args:        cmd.Args,
+		Env:         cmd.Env,
+		Dir:         cmd.Dir,
+		ExtraFiles:  cmd.ExtraFiles,
+		SysProcAttr: cmd.SysProcAttr,
+	}
+}
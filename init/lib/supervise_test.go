@@ -0,0 +1,171 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	time2 "github.com/palantir/go-java-launcher/init/lib/time"
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestHandleExitCrashLooping(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-supervise")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	svc.setPaths(Paths{
+		StaticConfigFile: svc.launcherStaticFile,
+		CustomConfigFile: svc.launcherCustomFile,
+		PidfileFormat:    filepath.Join(dir, "%s.pid"),
+		StateDir:         dir,
+		OutputFile:       filepath.Join(dir, "startup.log"),
+	})
+
+	svc.stdout = &bytes.Buffer{}
+	s := &supervisor{
+		service:      svc,
+		config:       launchlib.SupervisionConfig{MaxRestarts: intPtr(1), RestartWindow: time.Hour},
+		states:       map[string]processState{"primary": {}},
+		restartTimes: map[string][]time.Time{"primary": {Clock.Now()}},
+	}
+	require.NoError(t, svc.recordStartedCommand("primary", CommandContext{ConfigHash: "abc"}))
+	assert.EqualError(t, s.handleExit(processExit{name: "primary"}),
+		"process 'primary' was restarted 1 times within 1h0m0s, giving up")
+	assert.True(t, svc.crashLooping("primary"))
+	state, err := svc.readProcessState("primary")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", state.ConfigHash)
+	assert.NotNil(t, state.StartTime)
+
+	require.NoError(t, svc.recordStartedCommand("primary", CommandContext{}))
+	assert.False(t, svc.crashLooping("primary"))
+}
+
+func TestHandleExitBacksOffThenGivesUp(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-supervise")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	svc.setPaths(Paths{
+		StaticConfigFile: svc.launcherStaticFile,
+		CustomConfigFile: svc.launcherCustomFile,
+		PidfileFormat:    filepath.Join(dir, "%s.pid"),
+		StateDir:         dir,
+		OutputFile:       filepath.Join(dir, "startup.log"),
+	})
+	defer func(clock time2.Clock) {
+		Clock = clock
+	}(Clock)
+	clock := time2.NewFakeClock()
+	Clock = clock
+
+	output := &bytes.Buffer{}
+	svc.stdout = output
+	s := &supervisor{
+		service: svc,
+		config: launchlib.SupervisionConfig{MaxRestarts: intPtr(3), RestartWindow: time.Hour,
+			InitialBackoff: time.Second, MaxBackoff: 3 * time.Second},
+		states:       map[string]processState{"primary": {}},
+		restartTimes: map[string][]time.Time{},
+		restarts:     make(chan string),
+		done:         make(chan struct{}),
+	}
+	defer close(s.done)
+
+	// The backoff doubles on each restart up to maxBackoff.
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second} {
+		output.Reset()
+		require.NoError(t, s.handleExit(processExit{name: "primary"}))
+		assert.Contains(t, output.String(), fmt.Sprintf("Restarting process 'primary' in %v\n", backoff))
+		clock.BlockUntil(1)
+		clock.Advance(backoff - time.Millisecond)
+		select {
+		case <-s.restarts:
+			t.Fatalf("process restarted before its backoff of %v", backoff)
+		default:
+		}
+		clock.Advance(time.Millisecond)
+		assert.Equal(t, "primary", <-s.restarts)
+	}
+	assert.EqualError(t, s.handleExit(processExit{name: "primary"}),
+		"process 'primary' was restarted 3 times within 1h0m0s, giving up")
+	assert.Equal(t, 3, s.states["primary"].Restarts)
+	assert.True(t, s.states["primary"].CrashLooping)
+}
+
+func TestHandleExitWithoutRestarts(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-supervise")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	svc.setPaths(Paths{
+		StaticConfigFile: svc.launcherStaticFile,
+		CustomConfigFile: svc.launcherCustomFile,
+		PidfileFormat:    filepath.Join(dir, "%s.pid"),
+		StateDir:         dir,
+		OutputFile:       filepath.Join(dir, "startup.log"),
+	})
+
+	svc.stdout = &bytes.Buffer{}
+	s := &supervisor{
+		service:      svc,
+		config:       launchlib.SupervisionConfig{MaxRestarts: intPtr(0)}.WithDefaults(),
+		states:       map[string]processState{"primary": {}},
+		restartTimes: map[string][]time.Time{},
+	}
+	assert.EqualError(t, s.handleExit(processExit{name: "primary"}),
+		"process 'primary' was restarted 0 times within 5m0s, giving up")
+	assert.True(t, s.states["primary"].CrashLooping)
+}
+
+func TestRestartAfterStopsWithSupervisor(t *testing.T) {
+	defer func(clock time2.Clock) {
+		Clock = clock
+	}(Clock)
+	clock := time2.NewFakeClock()
+	Clock = clock
+
+	s := &supervisor{restarts: make(chan string), done: make(chan struct{})}
+	returned := make(chan struct{})
+	go func() {
+		s.restartAfter("primary", time.Second)
+		close(returned)
+	}()
+	clock.BlockUntil(1)
+	close(s.done)
+	<-returned
+	// The timer is stopped once the supervisor is done, so no restart is pending.
+	clock.BlockUntil(0)
+}
@@ -0,0 +1,187 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+const (
+	// dumpTimeFormat is the format of the time in the names of dump files, which sort chronologically.
+	dumpTimeFormat = "20060102T150405Z"
+	// threadDumpTimeout is how long the output file of a process is watched for the thread dump it writes on SIGQUIT.
+	threadDumpTimeout = 10 * time.Second
+	// threadDumpEnd is the start of the last line of a thread dump written by the JVM on SIGQUIT.
+	threadDumpEnd = "JNI global ref"
+)
+
+// ThreadDump captures a thread dump of each running JVM of the given names, or of all JVMs if none are given, into the
+// log directory and prints the path of each dump, as 'go-init threaddump' does.
+func (svc *Service) ThreadDump(processes ...string) error {
+	return svc.executeWithLoggers(NewAlwaysAppending(), func(svc *Service, loggers launchlib.ServiceLoggers) error {
+		return svc.threadDump(loggers, processes)
+	})
+}
+
+func (svc *Service) threadDump(loggers launchlib.ServiceLoggers, names []string) error {
+	procs, cmds, err := svc.getRunningJavaProcesses(loggers, names)
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, name := range processNames(procs) {
+		path, err := svc.captureThreadDump(name, cmds[name], procs[name])
+		if err != nil {
+			fmt.Fprintf(svc.stdout, "failed to capture a thread dump of '%s' process: %v\n", name, err)
+			failed = append(failed, name)
+			continue
+		}
+		fmt.Fprintf(svc.stdout, "Wrote thread dump of process '%s' to %s\n", name, path)
+		fmt.Fprintln(svc.out, path)
+	}
+	if len(failed) > 0 {
+		return svc.logErrorAndReturnWithExitCode(errors.Errorf("failed to dump processes '%v'", failed), 1)
+	}
+	return nil
+}
+
+// getRunningJavaProcesses returns the running processes of the given names that are JVMs, defaulting to all of the
+// configured JVMs, along with their commands. Returns an error with the exit code of the diagnostic commands if
+// any of them is not a JVM or is not running.
+func (svc *Service) getRunningJavaProcesses(loggers launchlib.ServiceLoggers, names []string) (
+	map[string]*os.Process, map[string]CommandContext, error) {
+	allCmds, err := svc.getConfiguredCommands(loggers)
+	if err != nil {
+		return nil, nil, svc.logErrorAndReturnWithExitCode(
+			errors.Wrap(err, "failed to get commands from static and custom configuration files"), 4)
+	}
+	cmds, err := selectNamedCommands(names, allCmds)
+	if err != nil {
+		return nil, nil, svc.logErrorAndReturnWithExitCode(err, 4)
+	}
+
+	explicit := len(names) > 0
+	procs := make(map[string]*os.Process, len(cmds))
+	var notRunning []string
+	for _, name := range commandNames(cmds) {
+		if !cmds[name].Java {
+			if explicit {
+				return nil, nil, svc.logErrorAndReturnWithExitCode(
+					errors.Errorf("process '%s' is not a java process", name), 4)
+			}
+			continue
+		}
+		_, proc, err := svc.getCmdProcess(name)
+		if err != nil {
+			return nil, nil, svc.logErrorAndReturnWithExitCode(
+				errors.Wrap(err, "failed to determine process status"), 4)
+		}
+		if proc == nil {
+			notRunning = append(notRunning, name)
+			continue
+		}
+		procs[name] = proc
+	}
+	if len(notRunning) > 0 {
+		sort.Strings(notRunning)
+		return nil, nil, svc.logErrorAndReturnWithExitCode(
+			errors.Errorf("commands '%v' are not running", notRunning), 3)
+	}
+	if len(procs) == 0 {
+		return nil, nil, svc.logErrorAndReturnWithExitCode(errors.New("no java processes are configured"), 4)
+	}
+	return procs, cmds, nil
+}
+
+// captureThreadDump writes a thread dump of the process to a timestamped file in the log directory, returning its path.
+func (svc *Service) captureThreadDump(name string, cmd CommandContext, proc *os.Process) (string, error) {
+	var dump []byte
+	var err error
+	if jcmd := jdkToolPath(cmd, "jcmd"); jcmd != "" {
+		dump, err = runJDKTool(jcmd, cmd, strconv.Itoa(proc.Pid), "Thread.print")
+	} else {
+		dump, err = signalThreadDump(cmd.OutputFile, proc)
+	}
+	if err != nil {
+		return "", err
+	}
+	path := svc.dumpFile("threaddump", name, "txt")
+	if err := ioutil.WriteFile(path, dump, svc.fileMode); err != nil {
+		return "", errors.Wrap(err, "failed to write thread dump")
+	}
+	return path, nil
+}
+
+// dumpFile returns the path of a timestamped file in the log directory for a dump of the given kind of a process.
+func (svc *Service) dumpFile(kind, name, extension string) string {
+	return filepath.Join(svc.logDir, fmt.Sprintf("%s-%s-%s.%s", kind, name, Clock.Now().UTC().Format(dumpTimeFormat),
+		extension))
+}
+
+// signalThreadDump sends the process SIGQUIT, on which the JVM writes a thread dump to its stdout, and returns the
+// dump once it has been written to the output file of the process.
+func signalThreadDump(outputFile string, proc *os.Process) ([]byte, error) {
+	output, err := os.Open(outputFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open output file")
+	}
+	defer func() {
+		_ = output.Close()
+	}()
+	// Only the output written after the signal is part of the dump.
+	if _, err := output.Seek(0, io.SeekEnd); err != nil {
+		return nil, errors.Wrap(err, "failed to seek to the end of output file")
+	}
+	if err := proc.Signal(syscall.SIGQUIT); err != nil {
+		return nil, errors.Wrap(err, "failed to send SIGQUIT")
+	}
+
+	timer := Clock.NewTimer(threadDumpTimeout)
+	defer timer.Stop()
+	ticker := Clock.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	var dump []byte
+	for {
+		written, err := ioutil.ReadAll(output)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read output file")
+		}
+		dump = append(dump, written...)
+		if end := bytes.Index(dump, []byte(threadDumpEnd)); end >= 0 {
+			if newline := bytes.IndexByte(dump[end:], '\n'); newline >= 0 {
+				return dump[:end+newline+1], nil
+			}
+		}
+		select {
+		case <-ticker.Chan():
+		case <-timer.Chan():
+			return nil, errors.Errorf("no complete thread dump was written to %s within %v", outputFile,
+				threadDumpTimeout)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// To prevent accidental changes to parameter default values
+func TestCaptureThreadDump_Jcmd(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-threaddump")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc.logDir = dir
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "jcmd"), []byte("#!/bin/sh\necho \"$@\"\n"), 0755))
+
+	cmd := CommandContext{Command: exec.Command(filepath.Join(dir, "java")), Java: true}
+	path, err := svc.captureThreadDump("primary", cmd, &os.Process{Pid: 1234})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(filepath.Base(path), "threaddump-primary-"))
+	dump, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "1234 Thread.print\n", string(dump))
+}
+
+func TestCaptureThreadDump_Signal(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-threaddump")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	svc.logDir = dir
+	output, err := os.Create(filepath.Join(dir, "startup.log"))
+	require.NoError(t, err)
+	defer func() {
+		_ = output.Close()
+	}()
+
+	// Writes a dump on SIGQUIT like the JVM, without a jcmd alongside its executable.
+	proc := exec.Command("/bin/sh", "-c", "trap 'echo Full thread dump; echo JNI global refs: 7, weak refs: 0' QUIT; "+
+		"echo started; while true; do sleep 0.1; done")
+	proc.Stdout = output
+	require.NoError(t, proc.Start())
+	defer func() {
+		_ = proc.Process.Kill()
+		_ = proc.Wait()
+	}()
+	for i := 0; i < 50; i++ {
+		if started, _ := ioutil.ReadFile(output.Name()); len(started) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cmd := CommandContext{Command: proc, OutputFile: output.Name(), Java: true}
+	path, err := svc.captureThreadDump("primary", cmd, proc.Process)
+	require.NoError(t, err)
+	dump, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Full thread dump\nJNI global refs: 7, weak refs: 0\n", string(dump))
+}
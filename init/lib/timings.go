@@ -0,0 +1,89 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// StartupTimings are how long 'go-init start' took in each step of starting a process, which it reports in its output
+// and records for 'status --json', so that the overhead of the launcher can be told apart from the time the process
+// takes to boot.
+type StartupTimings struct {
+	// ConfigMillis is the time spent reading and parsing the static and custom configurations of the service.
+	ConfigMillis int64 `json:"configMillis"`
+	// CompileMillis is the time spent compiling the command of the process, of which JavaHomeMillis and
+	// ClasspathMillis were spent resolving its java installation and classpath.
+	CompileMillis   int64 `json:"compileMillis"`
+	JavaHomeMillis  int64 `json:"javaHomeMillis"`
+	ClasspathMillis int64 `json:"classpathMillis"`
+	// ForkExecMillis is the time spent starting the process.
+	ForkExecMillis int64 `json:"forkExecMillis"`
+	// ReadyMillis is the time from starting the process until it passed its startup window, startup pattern and
+	// health check, if it has them.
+	ReadyMillis int64 `json:"readyMillis"`
+	// started is when the process was started, from which ReadyMillis is measured.
+	started time.Time
+}
+
+func newStartupTimings(compile launchlib.CompileTimings) *StartupTimings {
+	return &StartupTimings{
+		CompileMillis:   millis(compile.Total),
+		JavaHomeMillis:  millis(compile.JavaHome),
+		ClasspathMillis: millis(compile.Classpath),
+	}
+}
+
+func millis(duration time.Duration) int64 {
+	return int64(duration / time.Millisecond)
+}
+
+// summary describes the timings on a single line, such as
+// "config 5ms, compile 21ms (javaHome 12ms, classpath 4ms), fork/exec 1ms, ready after 2.3s".
+func (t StartupTimings) summary() string {
+	parts := []string{
+		fmt.Sprintf("config %dms", t.ConfigMillis),
+		fmt.Sprintf("compile %dms", t.CompileMillis),
+	}
+	if t.JavaHomeMillis > 0 || t.ClasspathMillis > 0 {
+		parts[1] += fmt.Sprintf(" (javaHome %dms, classpath %dms)", t.JavaHomeMillis, t.ClasspathMillis)
+	}
+	parts = append(parts, fmt.Sprintf("fork/exec %dms", t.ForkExecMillis),
+		fmt.Sprintf("ready after %v", time.Duration(t.ReadyMillis)*time.Millisecond))
+	return strings.Join(parts, ", ")
+}
+
+// reportStartupTimings completes the timings of each of the started commands with the time until they were ready,
+// then prints and records them.
+func (svc *Service) reportStartupTimings(cmds map[string]CommandContext) {
+	names := commandNames(cmds)
+	sort.Strings(names)
+	for _, name := range names {
+		timings := cmds[name].Timings
+		if timings == nil || timings.started.IsZero() {
+			continue
+		}
+		timings.ReadyMillis = millis(Clock.Now().Sub(timings.started))
+		fmt.Fprintf(svc.stdout, "Process '%s' started: %s\n", name, timings.summary())
+		if err := svc.recordStartupTimings(name, timings); err != nil {
+			fmt.Fprintln(svc.stdout, "failed to record startup timings:", err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestStartupTimingsSummary(t *testing.T) {
+	timings := newStartupTimings(launchlib.CompileTimings{
+		JavaHome:  12 * time.Millisecond,
+		Classpath: 4 * time.Millisecond,
+		Total:     21 * time.Millisecond,
+	})
+	timings.ConfigMillis = 5
+	timings.ForkExecMillis = 1
+	timings.ReadyMillis = 2300
+	assert.Equal(t, "config 5ms, compile 21ms (javaHome 12ms, classpath 4ms), fork/exec 1ms, ready after 2.3s",
+		timings.summary())
+
+	assert.Equal(t, "config 0ms, compile 3ms, fork/exec 0ms, ready after 0s",
+		newStartupTimings(launchlib.CompileTimings{Total: 3 * time.Millisecond}).summary())
+}
+
+func TestReportStartupTimings(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-timings")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	svc.setPaths(Paths{
+		StaticConfigFile: svc.launcherStaticFile,
+		CustomConfigFile: svc.launcherCustomFile,
+		PidfileFormat:    filepath.Join(dir, "%s.pid"),
+		StateDir:         dir,
+		OutputFile:       filepath.Join(dir, "startup.log"),
+	})
+
+	stdout := &bytes.Buffer{}
+	svc.stdout = stdout
+	svc.reportStartupTimings(map[string]CommandContext{
+		"primary": {Timings: &StartupTimings{ConfigMillis: 5, CompileMillis: 20, ForkExecMillis: 1,
+			started: Clock.Now().Add(-2 * time.Second)}},
+		"sidecar": {Timings: &StartupTimings{}},
+	})
+
+	assert.Contains(t, stdout.String(),
+		"Process 'primary' started: config 5ms, compile 20ms, fork/exec 1ms, ready after 2")
+	assert.NotContains(t, stdout.String(), "sidecar")
+	state, err := svc.readProcessState("primary")
+	require.NoError(t, err)
+	require.NotNil(t, state.StartupTimings)
+	assert.True(t, state.StartupTimings.ReadyMillis >= 2000, "%d", state.StartupTimings.ReadyMillis)
+	assert.Equal(t, int64(5), state.StartupTimings.ConfigMillis)
+}
@@ -0,0 +1,41 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// Validate checks the static and custom configurations without starting anything, as 'go-init validate' does.
+func (svc *Service) Validate() error {
+	problems := launchlib.ValidateConfigFiles(svc.launcherStaticFile, svc.launcherCustomFile)
+	if len(problems) == 0 {
+		fmt.Fprintln(svc.stdout, "Configuration is valid")
+		return nil
+	}
+
+	messages := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = "- " + problem.Error()
+	}
+	return cli.WithExitCode(1, errors.Errorf("found %d problems with the configuration:\n%s", len(problems),
+		strings.Join(messages, "\n")))
+}
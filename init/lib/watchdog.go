@@ -0,0 +1,75 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// processHang is reported when a supervised process has not passed its liveness check within its maxWait.
+type processHang struct {
+	name string
+	pid  int
+	err  error
+}
+
+// watchLiveness checks the liveness of the process with the pid every interval until it has exited, reporting it on
+// hangs once it has not passed the check for maxWait since it last did, or since it was started.
+func watchLiveness(name string, pid int, config launchlib.HealthCheckConfig, hangs chan<- processHang,
+	exited <-chan struct{}) {
+	ticker := Clock.NewTicker(config.Interval)
+	defer ticker.Stop()
+	lastLive := Clock.Now()
+	for {
+		select {
+		case <-exited:
+			return
+		case <-ticker.Chan():
+			err := checkHealth(config)
+			if err == nil {
+				lastLive = Clock.Now()
+				continue
+			}
+			if Clock.Now().Sub(lastLive) < config.MaxWait {
+				continue
+			}
+			select {
+			case hangs <- processHang{name: name, pid: pid, err: err}:
+			case <-exited:
+			}
+			return
+		}
+	}
+}
+
+// touchHeartbeat creates the heartbeat file or updates its modification time.
+func (svc *Service) touchHeartbeat(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, svc.fileMode)
+	if err != nil {
+		return errors.Wrapf(err, "failed to touch heartbeat file '%s'", path)
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrapf(err, "failed to touch heartbeat file '%s'", path)
+	}
+	now := Clock.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		return errors.Wrapf(err, "failed to touch heartbeat file '%s'", path)
+	}
+	return nil
+}
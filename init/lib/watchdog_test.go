@@ -0,0 +1,81 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+func TestWatchLiveness(t *testing.T) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	config := launchlib.HealthCheckConfig{
+		Port:     port,
+		Interval: 10 * time.Millisecond,
+		Timeout:  10 * time.Millisecond,
+		MaxWait:  100 * time.Millisecond,
+	}
+	hangs := make(chan processHang)
+	exited := make(chan struct{})
+	go watchLiveness("primary", 1234, config, hangs, exited)
+
+	// A process that passes its liveness check is not reported.
+	select {
+	case hang := <-hangs:
+		require.Fail(t, "process reported as hung while live", "%v", hang.err)
+	case <-time.After(3 * config.MaxWait):
+	}
+
+	// Once it stops passing, it is reported after maxWait.
+	require.NoError(t, listener.Close())
+	select {
+	case hang := <-hangs:
+		assert.Equal(t, "primary", hang.name)
+		assert.Equal(t, 1234, hang.pid)
+		assert.Error(t, hang.err)
+	case <-time.After(10 * config.MaxWait):
+		require.Fail(t, "hung process not reported")
+	}
+	close(exited)
+}
+
+func TestTouchHeartbeat(t *testing.T) {
+	svc := newTestService(t)
+	dir, err := ioutil.TempDir("", "go-init-heartbeat")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+	path := filepath.Join(dir, "heartbeat")
+
+	require.NoError(t, svc.touchHeartbeat(path))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+	require.NoError(t, svc.touchHeartbeat(path))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.True(t, time.Since(info.ModTime()) < time.Minute, "heartbeat file was not touched")
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lib
+
+import (
+	"os"
+
+	"github.com/palantir/pkg/cli"
+	"github.com/pkg/errors"
+
+	"github.com/palantir/go-java-launcher/launchlib"
+)
+
+// RunWindowsService runs the processes as a Windows service under the service control manager, until the Windows
+// service is stopped or the primary process exits, as 'go-init windows-service' does.
+func (svc *Service) RunWindowsService() error {
+	return svc.executeWithLoggers(NewTruncatingFirst(), func(svc *Service, loggers launchlib.ServiceLoggers) error {
+		return svc.windowsService(loggers)
+	})
+}
+
+func (svc *Service) windowsService(loggers launchlib.ServiceLoggers) error {
+	serviceStatus, err := svc.getServiceStatus(loggers)
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(
+			errors.Wrap(err, "failed to determine service status to determine what commands to run"), 1)
+	}
+	if len(serviceStatus.runningProcs) > 0 {
+		return svc.logErrorAndReturnWithExitCode(errors.Errorf("commands '%v' are already running",
+			processNames(serviceStatus.runningProcs)), 1)
+	}
+	svc.appendExtraArgs(svc.stdout, serviceStatus.notRunningCmds)
+	if err := launchlib.VerifyFiles(svc.verifiedFiles, svc.stdout); err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+	if err := svc.runPreflightChecks(svc.stdout, serviceStatus.notRunningCmds); err != nil {
+		return svc.logErrorAndReturnWithExitCode(err, 1)
+	}
+
+	var serviceName string
+	for name, cmd := range serviceStatus.notRunningCmds {
+		// go-init remains to stop the processes when the Windows service is stopped, so the primary process is never
+		// exec'd.
+		cmd.Exec = false
+		serviceStatus.notRunningCmds[name] = cmd
+		if cmd.Primary {
+			serviceName = name
+		}
+	}
+
+	code, err := runWindowsService(serviceName, func(stops <-chan os.Signal) (int, error) {
+		return svc.runService(serviceStatus.notRunningCmds, stops, true)
+	})
+	if err != nil {
+		return svc.logErrorAndReturnWithExitCode(errors.Wrap(err, "failed to run windows service"), 1)
+	}
+	if code != 0 {
+		return cli.WithExitCode(code, errors.New(""))
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package lib
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/windows/svc"
+)
+
+// runWindowsService runs the service under the service control manager, which run does until the stop signal is sent
+// on stops, returning the exit code of the primary process.
+func runWindowsService(name string, run func(stops <-chan os.Signal) (int, error)) (int, error) {
+	interactive, err := svc.IsAnInteractiveSession()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to determine whether go-init is run by the service control manager")
+	}
+	if interactive {
+		return 0, errors.New("windows-service must be run by the service control manager, use 'run' to run the " +
+			"service in the foreground instead")
+	}
+	handler := &windowsServiceHandler{run: run}
+	if err := svc.Run(name, handler); err != nil {
+		return 0, errors.Wrap(err, "failed to run as a windows service")
+	}
+	return handler.code, handler.err
+}
+
+// windowsServiceHandler handles the requests of the service control manager to the service.
+type windowsServiceHandler struct {
+	run  func(stops <-chan os.Signal) (int, error)
+	code int
+	err  error
+}
+
+// Execute runs the service until the primary process exits, or until the service control manager requests it to stop,
+// in which case it is sent SIGTERM to stop it as 'stop' does. Any exit code of the primary process other than 0 is
+// reported as the service-specific exit code.
+func (h *windowsServiceHandler) Execute(args []string, requests <-chan svc.ChangeRequest,
+	changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+	stops := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	go func() {
+		h.code, h.err = h.run(stops)
+		close(done)
+	}()
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case <-done:
+			changes <- svc.Status{State: svc.StopPending}
+			switch {
+			case h.err != nil:
+				return true, 1
+			case h.code != 0:
+				return true, uint32(h.code)
+			}
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				changes <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending}
+				select {
+				case stops <- syscall.SIGTERM:
+				default:
+					// The service is already stopping.
+				}
+			}
+		}
+	}
+}
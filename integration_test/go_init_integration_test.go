@@ -34,7 +34,8 @@ import (
 	"github.com/stretchr/testify/require"
 
 	cli2 "github.com/palantir/go-java-launcher/init/cli"
-	time2 "github.com/palantir/go-java-launcher/init/cli/time"
+	"github.com/palantir/go-java-launcher/init/lib"
+	time2 "github.com/palantir/go-java-launcher/init/lib/time"
 	"github.com/palantir/go-java-launcher/launchlib"
 )
 
@@ -845,7 +846,7 @@ func runInit(t *testing.T, args ...string) initResult {
 
 func runInitWithClock(t *testing.T, clock time2.Clock, args ...string) <-chan initResult {
 	var errbuf bytes.Buffer
-	cli2.Clock = clock
+	lib.Clock = clock
 	app := cli2.App()
 	app.Stderr = &errbuf
 
@@ -0,0 +1,152 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	jsonLogFlag = "--json-log"
+	// logFormatEnvVar selects the format of the messages of the launcher itself when set to logFormatJSON, which is
+	// inherited by the process monitor.
+	logFormatEnvVar = "GO_JAVA_LAUNCHER_LOG_FORMAT"
+	logFormatJSON   = "json"
+)
+
+// launcherLogger writes the messages of the launcher itself, either as free text or as JSON lines so that log
+// pipelines can parse launcher failures the same way as the logs of the service.
+type launcherLogger struct {
+	out io.Writer
+	// errOut is written to by Exit1WithMessage.
+	errOut           io.Writer
+	json             bool
+	staticConfigFile string
+	customConfigFile string
+	// quiet suppresses every message but failures.
+	quiet bool
+}
+
+type logRecord struct {
+	Time         string `json:"time"`
+	Level        string `json:"level"`
+	Event        string `json:"event"`
+	Message      string `json:"message"`
+	Pid          int    `json:"pid"`
+	StaticConfig string `json:"staticConfig,omitempty"`
+	CustomConfig string `json:"customConfig,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Info logs the event with its message, which is printed as is in free text.
+func (l *launcherLogger) Info(event, message string) {
+	if l.quiet {
+		return
+	}
+	l.write(l.out, "info", event, message, nil)
+}
+
+// Error logs the failed event with its message and error, which are printed one after the other in free text.
+func (l *launcherLogger) Error(event, message string, err error) {
+	l.write(l.out, "error", event, message, err)
+}
+
+// loggedError is panicked with by Fail, so that a panic with an error that has already been logged can be told apart
+// from any other.
+type loggedError struct {
+	error
+}
+
+// Fail logs the failed event as Error does, then panics with the error so that deferred cleanup runs.
+func (l *launcherLogger) Fail(event, message string, err error) {
+	l.Error(event, message, err)
+	panic(loggedError{err})
+}
+
+// Exit1WithMessage writes the message of the failed event to errOut and exits 1.
+func (l *launcherLogger) Exit1WithMessage(event, message string) {
+	l.write(l.errOut, "error", event, message, nil)
+	os.Exit(1)
+}
+
+func (l *launcherLogger) write(out io.Writer, level, event, message string, err error) {
+	if !l.json {
+		if err != nil {
+			fmt.Fprintln(out, message, err)
+		} else {
+			fmt.Fprintln(out, message)
+		}
+		return
+	}
+	record := logRecord{
+		Time:         time.Now().UTC().Format(time.RFC3339Nano),
+		Level:        level,
+		Event:        event,
+		Message:      message,
+		Pid:          os.Getpid(),
+		StaticConfig: l.staticConfigFile,
+		CustomConfig: l.customConfigFile,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	recordBytes, marshalErr := json.Marshal(record)
+	if marshalErr != nil {
+		fmt.Fprintln(out, message, err)
+		return
+	}
+	fmt.Fprintln(out, string(recordBytes))
+}
+
+// Writer returns a writer for the messages printed by launchlib, which logs each line written to it as the event.
+func (l *launcherLogger) Writer(event string) io.Writer {
+	if l.quiet {
+		return ioutil.Discard
+	}
+	if !l.json {
+		return l.out
+	}
+	return &lineLogger{logger: l, event: event}
+}
+
+// lineLogger logs each complete line written to it, buffering any partial line until it is completed.
+type lineLogger struct {
+	logger  *launcherLogger
+	event   string
+	pending bytes.Buffer
+}
+
+func (w *lineLogger) Write(p []byte) (int, error) {
+	w.pending.Write(p)
+	for {
+		line, err := w.pending.ReadString('\n')
+		if err != nil {
+			// The partial line is kept until the rest of it is written.
+			w.pending.Reset()
+			w.pending.WriteString(line)
+			return len(p), nil
+		}
+		if message := strings.TrimRight(line, "\r\n"); message != "" {
+			w.logger.Info(w.event, message)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readRecords(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n") {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record), line)
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLauncherLogger_JSONRecord(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := &launcherLogger{out: out, json: true, staticConfigFile: "service/bin/launcher-static.yml",
+		customConfigFile: "var/conf/launcher-custom.yml"}
+	logger.Error("config_read_failed", "Failed to read config files", errors.New("failed to read static config file"))
+	logger.Info("startup_timings", "Read config in 1ms")
+
+	records := readRecords(t, out)
+	require.Len(t, records, 2)
+	recordTime, err := time.Parse(time.RFC3339Nano, records[0]["time"].(string))
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), recordTime, time.Minute)
+	delete(records[0], "time")
+	assert.Equal(t, map[string]interface{}{
+		"level":        "error",
+		"event":        "config_read_failed",
+		"message":      "Failed to read config files",
+		"pid":          float64(os.Getpid()),
+		"staticConfig": "service/bin/launcher-static.yml",
+		"customConfig": "var/conf/launcher-custom.yml",
+		"error":        "failed to read static config file",
+	}, records[0])
+	assert.Equal(t, "info", records[1]["level"])
+	assert.NotContains(t, records[1], "error")
+}
+
+func TestLauncherLogger_JSONWriterBuffersPartialLines(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := &launcherLogger{out: out, json: true}
+	w := logger.Writer("launcher_message")
+
+	for _, write := range []string{"first li", "ne\nsecond\r\n\nthi"} {
+		n, err := fmt.Fprint(w, write)
+		require.NoError(t, err)
+		assert.Equal(t, len(write), n)
+	}
+	records := readRecords(t, out)
+	require.Len(t, records, 2)
+	assert.Equal(t, "first line", records[0]["message"])
+	assert.Equal(t, "second", records[1]["message"])
+	assert.Equal(t, "launcher_message", records[1]["event"])
+
+	out.Reset()
+	_, err := fmt.Fprint(w, "rd\n")
+	require.NoError(t, err)
+	records = readRecords(t, out)
+	require.Len(t, records, 1)
+	assert.Equal(t, "third", records[0]["message"])
+}
+
+func TestLauncherLogger_Text(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := &launcherLogger{out: out}
+	logger.Info("startup_timings", "Read config in 1ms")
+	logger.Error("config_read_failed", "Failed to read config files", errors.New("no such file"))
+	_, err := fmt.Fprint(logger.Writer("launcher_message"), "partial")
+	require.NoError(t, err)
+	assert.Equal(t, "Read config in 1ms\nFailed to read config files no such file\npartial", out.String())
+
+	// Only failures are printed when quiet.
+	out.Reset()
+	logger.quiet = true
+	logger.Info("startup_timings", "Read config in 1ms")
+	_, err = fmt.Fprintln(logger.Writer("launcher_message"), "message")
+	require.NoError(t, err)
+	logger.Error("config_read_failed", "Failed to read config files", errors.New("no such file"))
+	assert.Equal(t, "Failed to read config files no such file\n", out.String())
+}
+
+func TestLauncherLogger_FailPanicsWithLoggedError(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := &launcherLogger{out: out, json: true}
+	err := errors.New("no such file")
+	defer func() {
+		assert.Equal(t, loggedError{err}, recover())
+		records := readRecords(t, out)
+		require.Len(t, records, 1)
+		assert.Equal(t, "no such file", records[0]["error"])
+	}()
+	logger.Fail("config_read_failed", "Failed to read config files", err)
+}
+
+func TestLauncherLogger_Exit1WithMessageJSON(t *testing.T) {
+	if os.Getenv("GO_JAVA_LAUNCHER_TEST_EXIT") == "1" {
+		logger := &launcherLogger{out: os.Stdout, errOut: os.Stderr, json: true}
+		logger.Exit1WithMessage("usage", "Usage: go-java-launcher")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestLauncherLogger_Exit1WithMessageJSON")
+	cmd.Env = append(os.Environ(), "GO_JAVA_LAUNCHER_TEST_EXIT=1")
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	require.IsType(t, &exec.ExitError{}, err)
+	assert.Equal(t, 1, err.(*exec.ExitError).Sys().(syscall.WaitStatus).ExitStatus())
+	records := readRecords(t, stderr)
+	require.Len(t, records, 1)
+	assert.Equal(t, "error", records[0]["level"])
+	assert.Equal(t, "usage", records[0]["event"])
+	assert.Equal(t, "Usage: go-java-launcher", records[0]["message"])
+}
@@ -15,11 +15,16 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
+	"runtime"
+	"sort"
 	"strconv"
-	"syscall"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -28,11 +33,25 @@ import (
 
 const (
 	monitorFlag = "--group-monitor"
+	dryRunFlag  = "--dry-run"
+	strictFlag  = "--strict"
+	// verboseFlag traces the resolution of the configuration and the final command to stderr, as does setting
+	// launchlib.DebugEnvVar to 1, while quietFlag only prints failures.
+	verboseFlag = "--verbose"
+	quietFlag   = "--quiet"
+	// versionFlag prints the launchlib.BuildInfo of the launcher instead of launching, as JSON with jsonLogFlag.
+	versionFlag = "--version"
+	// profileFlag is followed by the name of the profile of the static configuration to launch with, overriding
+	// launchlib.ProfileEnvVar.
+	profileFlag = "--profile"
 )
 
-func Exit1WithMessage(message string) {
-	fmt.Fprintln(os.Stderr, message)
-	os.Exit(1)
+func isLauncherFlag(arg string) bool {
+	switch arg {
+	case dryRunFlag, strictFlag, jsonLogFlag, verboseFlag, quietFlag, versionFlag, profileFlag:
+		return true
+	}
+	return false
 }
 
 func CreateMonitorFromArgs(primaryPID string, subPIDs []string) (*launchlib.ProcessMonitor, error) {
@@ -62,60 +81,205 @@ func GenerateMonitorArgs(monitor *launchlib.ProcessMonitor) []string {
 	return args
 }
 
+func printVersion(w io.Writer, logger *launcherLogger) {
+	info := launchlib.GetBuildInfo()
+	if !logger.json {
+		info.Write(w, "go-java-launcher")
+		return
+	}
+	if err := json.NewEncoder(w).Encode(info); err != nil {
+		logger.Exit1WithMessage("version_print_failed", fmt.Sprintf("Failed to print version: %v", err))
+	}
+}
+
 func main() {
 	staticConfigFile := "launcher-static.yml"
 	customConfigFile := "launcher-custom.yml"
-	stdout := os.Stdout
+	logger := &launcherLogger{out: os.Stdout, errOut: os.Stderr, json: os.Getenv(logFormatEnvVar) == logFormatJSON}
 
-	switch numArgs := len(os.Args); {
-	case numArgs > 3 && os.Args[1] == monitorFlag:
-		monitor, err := CreateMonitorFromArgs(os.Args[2], os.Args[3:])
+	args := os.Args
+	var dryRun, strict, verbose, version bool
+	profile := os.Getenv(launchlib.ProfileEnvVar)
+	for len(args) > 1 && isLauncherFlag(args[1]) {
+		if args[1] == profileFlag {
+			if len(args) < 3 {
+				logger.Exit1WithMessage("profile_missing", profileFlag+" must be followed by the name of a profile")
+			}
+			profile = args[2]
+			args = append([]string{args[0]}, args[3:]...)
+			continue
+		}
+		version = version || args[1] == versionFlag
+		dryRun = dryRun || args[1] == dryRunFlag
+		strict = strict || args[1] == strictFlag
+		logger.json = logger.json || args[1] == jsonLogFlag
+		verbose = verbose || args[1] == verboseFlag
+		logger.quiet = logger.quiet || args[1] == quietFlag
+		args = append([]string{args[0]}, args[2:]...)
+	}
+	if version {
+		printVersion(os.Stdout, logger)
+		return
+	}
+	if verbose && logger.quiet {
+		logger.Exit1WithMessage("flags_conflicting", verboseFlag+" and "+quietFlag+" cannot be given together")
+	}
+	if verbose || launchlib.DebugFromEnv() {
+		launchlib.TraceOutput = os.Stderr
+	}
+	if logger.json {
+		// Failures are logged before panicking, so the panic is not printed to keep the output parseable, though the
+		// deferred cleanup of sub-processes still runs. Any other panic is a bug, so is printed as usual.
+		defer func() {
+			if r := recover(); r != nil {
+				if _, ok := r.(loggedError); ok {
+					os.Exit(1)
+				}
+				panic(r)
+			}
+		}()
+	}
+
+	switch numArgs := len(args); {
+	case numArgs > 3 && args[1] == monitorFlag && !dryRun:
+		monitor, err := CreateMonitorFromArgs(args[2], args[3:])
 
 		if err != nil {
-			fmt.Println("error parsing monitor args", err)
-			Exit1WithMessage(fmt.Sprintf("Usage: go-java-launcher %s <primary pid> <sub-process pids...>", monitorFlag))
+			logger.Error("monitor_args_invalid", "error parsing monitor args", err)
+			logger.Exit1WithMessage("usage", fmt.Sprintf("Usage: go-java-launcher %s <primary pid> <sub-process pids...>",
+				monitorFlag))
 		}
 
 		if err = monitor.Run(); err != nil {
-			fmt.Println("error running process monitor", err)
-			Exit1WithMessage("process monitor failed")
+			logger.Error("monitor_failed", "error running process monitor", err)
+			logger.Exit1WithMessage("monitor_failed", "process monitor failed")
 		}
 		return
 	case numArgs == 2:
-		staticConfigFile = os.Args[1]
+		staticConfigFile = args[1]
 	case numArgs == 3:
-		staticConfigFile = os.Args[1]
-		customConfigFile = os.Args[2]
+		staticConfigFile = args[1]
+		customConfigFile = args[2]
 	default:
-		Exit1WithMessage("Usage: go-java-launcher <path to PrimaryStaticLauncherConfig> " +
-			"[<path to PrimaryCustomLauncherConfig>]")
+		logger.Exit1WithMessage("usage", "Usage: go-java-launcher ["+dryRunFlag+"] ["+strictFlag+"] ["+jsonLogFlag+
+			"] ["+verboseFlag+" | "+quietFlag+"] ["+profileFlag+" <profile>] "+
+			"<path to PrimaryStaticLauncherConfig> [<path to PrimaryCustomLauncherConfig>]\n"+
+			"       go-java-launcher "+versionFlag+" ["+jsonLogFlag+"]")
+	}
+	launchlib.StrictKeys = strict
+	launchlib.Profile = profile
+	stdout := logger.Writer("launcher_message")
+
+	// Resolve relative paths against the service root rather than the directory the launcher is invoked from
+	if err := launchlib.ChdirServiceRoot(os.Getenv(launchlib.ServiceRootEnvVar)); err != nil {
+		logger.Fail("service_root_invalid", "Failed to change to the service root", err)
 	}
+	for _, configFile := range []*string{&staticConfigFile, &customConfigFile} {
+		expanded, err := launchlib.ExpandHome(*configFile)
+		if err != nil {
+			logger.Fail("config_path_invalid", "Failed to expand the path of a config file", err)
+		}
+		*configFile = expanded
+	}
+	logger.staticConfigFile, logger.customConfigFile = staticConfigFile, customConfigFile
 
 	// Read configuration
+	configStarted := time.Now()
 	staticConfig, customConfig, err := launchlib.GetConfigsFromFiles(staticConfigFile, customConfigFile, stdout)
 	if err != nil {
-		fmt.Println("Failed to read config files", err)
-		panic(err)
+		logger.Fail("config_read_failed", "Failed to read config files", err)
+	}
+	configDuration := time.Since(configStarted)
+	if strict {
+		staticConfig.StrictJvmOpts = true
+		for name, subStatic := range staticConfig.SubProcesses {
+			subStatic.StrictJvmOpts = true
+			staticConfig.SubProcesses[name] = subStatic
+		}
+	}
+
+	if dryRun {
+		cmds, err := launchlib.CompileCmdsFromConfig(&staticConfig, &customConfig,
+			launchlib.NewSimpleWriterLogger(ioutil.Discard))
+		if err != nil {
+			logger.Exit1WithMessage("command_compile_failed",
+				fmt.Sprintf("Failed to assemble executable metadata: %v", err))
+		}
+		launchlib.WriteDryRun(os.Stdout, staticConfig.ServiceName, cmds.Primary, staticConfig.Redactor())
+		// The subProcesses are printed by name, so that the output is the same each time.
+		names := make([]string, 0, len(cmds.SubProcesses))
+		for name := range cmds.SubProcesses {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			launchlib.WriteDryRun(os.Stdout, name, cmds.SubProcesses[name], staticConfig.SubProcesses[name].Redactor())
+		}
+		return
+	}
+
+	if err := launchlib.VerifyFiles(staticConfig.Verify, stdout); err != nil {
+		logger.Fail("verification_failed", "Failed to verify files", err)
+	}
+	// The launcher replaces itself with the service process, so cannot pass it files other than its own outputs.
+	if len(staticConfig.OutputStreams) > 0 {
+		err := errors.New("outputStreams of the service process are only supported when it is started by go-init")
+		logger.Fail("output_streams_unsupported", "Failed to open output streams", err)
 	}
 
 	// Create configured directories
 	if err := launchlib.MkDirs(staticConfig.Dirs, stdout); err != nil {
-		fmt.Println("Failed to create directories", err)
-		panic(err)
+		logger.Fail("dirs_create_failed", "Failed to create directories", err)
 	}
 
 	for name, subProcStatic := range staticConfig.SubProcesses {
 		if err := launchlib.MkDirs(subProcStatic.Dirs, stdout); err != nil {
-			fmt.Println("Failed to create directories for subProcess ", name, err)
-			panic(err)
+			logger.Fail("dirs_create_failed", "Failed to create directories for subProcess "+name, err)
 		}
 	}
 
 	// Compile commands
-	cmds, err := launchlib.CompileCmdsFromConfig(&staticConfig, &customConfig, launchlib.NewSimpleWriterLogger(os.Stdout))
+	cmds, err := launchlib.CompileCmdsFromConfig(&staticConfig, &customConfig, launchlib.NewSimpleWriterLogger(stdout))
 	if err != nil {
-		fmt.Println("Failed to assemble executable metadata", cmds, err)
-		panic(err)
+		logger.Fail("command_compile_failed", "Failed to assemble executable metadata", err)
+	}
+	logger.Info("startup_timings", fmt.Sprintf("Read config in %v, compiled primary command in %v (javaHome %v, "+
+		"classpath %v)", configDuration, cmds.PrimaryTimings.Total, cmds.PrimaryTimings.JavaHome,
+		cmds.PrimaryTimings.Classpath))
+
+	if err := launchlib.ChownDirs(staticConfig.Dirs, cmds.Primary); err != nil {
+		logger.Fail("dirs_chown_failed", "Failed to change the owner of directories", err)
+	}
+	if err := launchlib.PrepareDiagnostics(staticConfig.Diagnostics, staticConfig.WorkingDirectory, cmds.Primary,
+		stdout); err != nil {
+		logger.Fail("diagnostics_prepare_failed", "Failed to prepare the diagnostics directory", err)
+	}
+	if err := launchlib.CollectCoreDumps(staticConfig.CoreDumps, staticConfig.WorkingDirectory, cmds.Primary,
+		stdout); err != nil {
+		logger.Fail("core_dumps_collect_failed", "Failed to collect core dumps", err)
+	}
+	if err := launchlib.PrepareTmpDir(staticConfig.TmpDir, staticConfig.WorkingDirectory, cmds.Primary,
+		stdout); err != nil {
+		logger.Fail("tmpdir_prepare_failed", "Failed to prepare the temporary directory", err)
+	}
+	for name, subProcess := range cmds.SubProcesses {
+		subStatic := staticConfig.SubProcesses[name]
+		if err := launchlib.ChownDirs(subStatic.Dirs, subProcess); err != nil {
+			logger.Fail("dirs_chown_failed", "Failed to change the owner of directories for subProcess "+name, err)
+		}
+		if err := launchlib.PrepareDiagnostics(subStatic.Diagnostics, subStatic.WorkingDirectory, subProcess,
+			stdout); err != nil {
+			logger.Fail("diagnostics_prepare_failed",
+				"Failed to prepare the diagnostics directory for subProcess "+name, err)
+		}
+		if err := launchlib.CollectCoreDumps(subStatic.CoreDumps, subStatic.WorkingDirectory, subProcess,
+			stdout); err != nil {
+			logger.Fail("core_dumps_collect_failed", "Failed to collect core dumps for subProcess "+name, err)
+		}
+		if err := launchlib.PrepareTmpDir(subStatic.TmpDir, subStatic.WorkingDirectory, subProcess,
+			stdout); err != nil {
+			logger.Fail("tmpdir_prepare_failed", "Failed to prepare the temporary directory for subProcess "+name, err)
+		}
 	}
 
 	if len(cmds.SubProcesses) != 0 {
@@ -129,7 +293,7 @@ func main() {
 		defer func() {
 			if err := monitor.KillSubProcesses(); err != nil {
 				// Defer only called if failure complete exec of the primary process, so already panicking
-				fmt.Println("error cleaning up sub-processes", err)
+				logger.Error("subprocess_cleanup_failed", "error cleaning up sub-processes", err)
 			}
 		}()
 
@@ -137,33 +301,100 @@ func main() {
 			subProcess.Stdout = os.Stdout
 			subProcess.Stderr = os.Stderr
 
-			fmt.Println("Starting subProcesses ", name, subProcess.Path)
-			if execErr := subProcess.Start(); execErr != nil {
+			subStatic := staticConfig.SubProcesses[name]
+			if err := launchlib.WaitForDependencies(subStatic.DependsOn, stdout); err != nil {
+				logger.Fail("dependencies_unreachable", "Dependencies of subProcess not reachable "+name, err)
+			}
+			logger.Info("subprocess_starting", fmt.Sprintf("Starting subProcesses %s %s", name, subProcess.Path))
+			restoreUmask := launchlib.SetUmask(subStatic.Umask)
+			restoreRlimits, err := launchlib.SetRlimits(subStatic.Rlimits)
+			if err != nil {
+				logger.Fail("rlimits_failed", "Failed to set resource limits for subProcess "+name, err)
+			}
+			streams, err := launchlib.OpenOutputStreams(subStatic.OutputStreams, subProcess,
+				launchlib.DefaultFileMode, launchlib.DefaultDirMode)
+			if err != nil {
+				logger.Fail("output_streams_failed", "Failed to open output streams for subProcess "+name, err)
+			}
+			if execErr := launchlib.StartIsolated(subProcess, subStatic.Isolation()); execErr != nil {
 				if os.IsNotExist(execErr) {
-					fmt.Printf("Executable not found for subProcess %s at: %s\n", name, subProcess.Path)
+					logger.Fail("executable_not_found", fmt.Sprintf("Executable not found for subProcess %s at: %s",
+						name, subProcess.Path), execErr)
 				}
-				panic(execErr)
+				logger.Fail("subprocess_start_failed", "Failed to start subProcess "+name, execErr)
+			}
+			if err := restoreRlimits(); err != nil {
+				logger.Fail("rlimits_failed", "Failed to restore resource limits after starting subProcess "+name, err)
+			}
+			restoreUmask()
+			for _, stream := range streams {
+				_ = stream.Close()
+			}
+			if err := launchlib.JoinCgroup(subProcess.Process.Pid, subStatic.Cgroup); err != nil {
+				logger.Fail("cgroup_failed", "Failed to move subProcess into its cgroup "+name, err)
+			}
+			if err := launchlib.SetPriority(subProcess.Process.Pid, subStatic.Priority()); err != nil {
+				logger.Fail("priority_failed", "Failed to set the priority of subProcess "+name, err)
+			}
+			if err := launchlib.SetCPUAffinity(subProcess.Process.Pid, subStatic.CPUSet); err != nil {
+				logger.Fail("cpu_affinity_failed", "Failed to set the CPU affinity of subProcess "+name, err)
 			}
 			monitor.SubProcessPIDs = append(monitor.SubProcessPIDs, subProcess.Process.Pid)
-			fmt.Printf("Started subProcess %s under process pid %d\n", name, subProcess.Process.Pid)
+			logger.Info("subprocess_started", fmt.Sprintf("Started subProcess %s under process pid %d", name,
+				subProcess.Process.Pid))
 		}
 
 		monitorCmd := exec.Command(os.Args[0], GenerateMonitorArgs(monitor)...)
 		monitorCmd.Stdout = os.Stdout
 		monitorCmd.Stderr = os.Stderr
+		if logger.json {
+			monitorCmd.Env = append(os.Environ(), logFormatEnvVar+"="+logFormatJSON)
+		}
 
-		fmt.Println("Starting process monitor for service process ", monitor.PrimaryPID)
+		logger.Info("monitor_starting", fmt.Sprintf("Starting process monitor for service process %d",
+			monitor.PrimaryPID))
 		if err := monitorCmd.Start(); err != nil {
-			fmt.Println("Failed to start process monitor for service process")
-			panic(err)
+			logger.Fail("monitor_start_failed", "Failed to start process monitor for service process", err)
 		}
 	}
 
-	execErr := syscall.Exec(cmds.Primary.Path, cmds.Primary.Args, cmds.Primary.Env)
+	// The dependencies of the service process may include its subProcesses, so are waited for once they have started.
+	if err := launchlib.WaitForDependencies(staticConfig.DependsOn, stdout); err != nil {
+		logger.Fail("dependencies_unreachable", "Dependencies of the service process not reachable", err)
+	}
+
+	// Resource limits, cgroup and priority are set before dropping privileges, as they usually require root.
+	if _, err := launchlib.SetRlimits(staticConfig.Rlimits); err != nil {
+		logger.Fail("rlimits_failed", "Failed to set resource limits of the service process", err)
+	}
+	launchlib.SetUmask(staticConfig.Umask)
+	if err := launchlib.JoinCgroup(0, staticConfig.Cgroup); err != nil {
+		logger.Fail("cgroup_failed", "Failed to move the service process into its cgroup", err)
+	}
+	if err := launchlib.SetPriority(0, staticConfig.Priority()); err != nil {
+		logger.Fail("priority_failed", "Failed to set the priority of the service process", err)
+	}
+	if err := launchlib.SetCPUAffinity(0, staticConfig.CPUSet); err != nil {
+		logger.Fail("cpu_affinity_failed", "Failed to set the CPU affinity of the service process", err)
+	}
+	// Capabilities and mounts are isolated and raised per thread, so the thread that execs the service process is the
+	// one that isolates them.
+	runtime.LockOSThread()
+	if err := launchlib.Isolate(staticConfig.Isolation()); err != nil {
+		logger.Fail("isolation_failed", "Failed to isolate the service process", err)
+	}
+	if err := launchlib.EnterSandbox(cmds.Primary); err != nil {
+		logger.Fail("sandbox_failed", "Failed to enter the chroot and working directory of the service process", err)
+	}
+	if err := launchlib.DropPrivileges(cmds.Primary); err != nil {
+		logger.Fail("privileges_drop_failed", "Failed to change the user of the service process", err)
+	}
+
+	execErr := execPrimary(cmds.Primary)
 	if execErr != nil {
 		if os.IsNotExist(execErr) {
-			fmt.Println("Executable not found at:", cmds.Primary.Path)
+			logger.Fail("executable_not_found", "Executable not found at: "+cmds.Primary.Path, execErr)
 		}
-		panic(execErr)
+		logger.Fail("exec_failed", "Failed to execute the service process", execErr)
 	}
 }
@@ -0,0 +1,80 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// maxCPUs is the number of CPUs that a cpuSet may refer to, the CPU_SETSIZE of sched_setaffinity(2).
+const maxCPUs = 1024
+
+// parseCPUSet parses a list of CPUs such as 0-3,8 as accepted by taskset --cpu-list, returning the sorted CPUs.
+func parseCPUSet(cpuSet string) ([]int, error) {
+	seen := make(map[int]struct{})
+	for _, part := range strings.Split(cpuSet, ",") {
+		part = strings.TrimSpace(part)
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := parseCPU(bounds[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cpuSet '%s'", cpuSet)
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = parseCPU(bounds[1]); err != nil {
+				return nil, errors.Wrapf(err, "invalid cpuSet '%s'", cpuSet)
+			}
+			if last < first {
+				return nil, errors.Errorf("invalid cpuSet '%s': range %s is decreasing", cpuSet, part)
+			}
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			seen[cpu] = struct{}{}
+		}
+	}
+
+	cpus := make([]int, 0, len(seen))
+	for cpu := range seen {
+		cpus = append(cpus, cpu)
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
+
+func parseCPU(value string) (int, error) {
+	cpu, err := strconv.Atoi(value)
+	if err != nil || cpu < 0 || cpu >= maxCPUs {
+		return 0, errors.Errorf("'%s' is not a CPU number between 0 and %d", value, maxCPUs-1)
+	}
+	return cpu, nil
+}
+
+// SetCPUAffinity pins every thread of the process with the given pid, or of the launcher itself if pid is 0 so that
+// the affinity is kept by the process it execs, to the CPUs of cpuSet. Threads created later inherit the affinity of
+// the thread that creates them. Does nothing if cpuSet is empty.
+func SetCPUAffinity(pid int, cpuSet string) error {
+	if cpuSet == "" {
+		return nil
+	}
+	cpus, err := parseCPUSet(cpuSet)
+	if err != nil {
+		return err
+	}
+	return setCPUAffinity(pid, cpus)
+}
@@ -0,0 +1,67 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCPUSet(t *testing.T) {
+	for i, currCase := range []struct {
+		cpuSet string
+		want   []int
+		err    string
+	}{
+		{cpuSet: "0", want: []int{0}},
+		{cpuSet: "0-3,8", want: []int{0, 1, 2, 3, 8}},
+		{cpuSet: "4, 2-3, 3", want: []int{2, 3, 4}},
+		{cpuSet: "3-1", err: "invalid cpuSet '3-1': range 3-1 is decreasing"},
+		{cpuSet: "0,", err: "invalid cpuSet '0,': '' is not a CPU number between 0 and 1023"},
+		{cpuSet: "1024", err: "invalid cpuSet '1024': '1024' is not a CPU number between 0 and 1023"},
+	} {
+		cpus, err := parseCPUSet(currCase.cpuSet)
+		if currCase.err != "" {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, cpus, "Case %d", i)
+	}
+}
+
+func TestSetCPUAffinity(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("cpuSet is only supported on linux")
+	}
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	// CPU 0 is assumed to be available to the tests.
+	require.NoError(t, SetCPUAffinity(cmd.Process.Pid, "0"))
+	status, err := ioutil.ReadFile("/proc/" + strconv.Itoa(cmd.Process.Pid) + "/status")
+	require.NoError(t, err)
+	assert.Contains(t, string(status), "Cpus_allowed_list:\t0\n")
+}
@@ -0,0 +1,82 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"encoding/hex"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var sha256Pattern = regexp.MustCompile("^[0-9a-fA-F]{64}$")
+
+// JavaAgentConfig configures a java agent, passed to the java command as -javaagent:<jar>[=<args>]. If SHA256 is set,
+// the launcher refuses to launch unless it is the checksum of the jar.
+type JavaAgentConfig struct {
+	Jar    string `yaml:"jar"`
+	Args   string `yaml:"args"`
+	SHA256 string `yaml:"sha256"`
+}
+
+func (agent JavaAgentConfig) validate() error {
+	if agent.Jar == "" {
+		return errors.New("jar must be set")
+	}
+	if agent.SHA256 != "" && !sha256Pattern.MatchString(agent.SHA256) {
+		return errors.Errorf("sha256 must be 64 hexadecimal characters, found '%s'", agent.SHA256)
+	}
+	return nil
+}
+
+// getAgentArgs returns the -javaagent options of the given agents, with jars relative to workingDir unless absolute,
+// verifying the checksum of each jar that has one.
+func getAgentArgs(agents []JavaAgentConfig, workingDir string) ([]string, error) {
+	var args []string
+	for _, agent := range agents {
+		jar := agent.Jar
+		if !path.IsAbs(jar) {
+			jar = path.Join(workingDir, jar)
+		}
+		if agent.SHA256 != "" {
+			if err := verifySHA256(jar, agent.SHA256); err != nil {
+				return nil, errors.Wrapf(err, "failed to verify java agent %s", agent.Jar)
+			}
+		} else if _, err := os.Stat(jar); err != nil {
+			return nil, errors.Wrapf(err, "java agent %s does not exist", agent.Jar)
+		}
+
+		arg := "-javaagent:" + jar
+		if agent.Args != "" {
+			arg += "=" + agent.Args
+		}
+		args = append(args, arg)
+	}
+	return args, nil
+}
+
+func verifySHA256(file, expected string) error {
+	digest, err := sha256Digest(file)
+	if err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(digest); actual != strings.ToLower(expected) {
+		return errors.Errorf("sha256 checksum %s does not match expected %s", actual, expected)
+	}
+	return nil
+}
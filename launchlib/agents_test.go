@@ -0,0 +1,57 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// sha256 of "agent"
+	agentSHA256 = "d4f0bc5a29de06b510f9aa428f1eedba926012b591fef7a518e776a7c9bd1824"
+	otherSHA256 = "0000000000000000000000000000000000000000000000000000000000000000"
+)
+
+func TestGetAgentArgs(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "agents")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(workingDir))
+	}()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workingDir, "agent.jar"), []byte("agent"), 0644))
+
+	args, err := getAgentArgs([]JavaAgentConfig{
+		{Jar: "agent.jar", SHA256: agentSHA256},
+		{Jar: filepath.Join(workingDir, "agent.jar"), Args: "key=value"},
+	}, workingDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"-javaagent:" + filepath.Join(workingDir, "agent.jar"),
+		"-javaagent:" + filepath.Join(workingDir, "agent.jar") + "=key=value",
+	}, args)
+
+	_, err = getAgentArgs([]JavaAgentConfig{{Jar: "agent.jar", SHA256: otherSHA256}}, workingDir)
+	assert.EqualError(t, err, "failed to verify java agent agent.jar: sha256 checksum "+agentSHA256+
+		" does not match expected "+otherSHA256)
+
+	_, err = getAgentArgs([]JavaAgentConfig{{Jar: "missing.jar"}}, workingDir)
+	assert.Error(t, err)
+}
@@ -0,0 +1,300 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// generatedArgsDir is the directory, relative to the working directory of the launcher, that the argument files
+	// and pathing jars of the commands are written to. It holds nothing else, so that those no longer used can be
+	// removed.
+	generatedArgsDir = "var/generated-args"
+	// argFileMinJavaVersion is the first java version that reads arguments from @argfiles.
+	argFileMinJavaVersion = 9
+	// manifestLineLength is the longest line of a jar manifest in bytes, excluding the line break.
+	manifestLineLength = 72
+)
+
+// commandLineLength returns the number of bytes the arguments and environment of the command take when it is executed,
+// which the platform limits to maxCommandLineLength.
+func commandLineLength(cmd *exec.Cmd) int {
+	length := 0
+	for _, values := range [][]string{cmd.Args, cmd.Env} {
+		for _, value := range values {
+			// Each value is terminated by a null byte and referenced by a pointer.
+			length += len(value) + 1 + 8
+		}
+	}
+	return length
+}
+
+// commandLineTooLong returns whether the platform would refuse to execute the command as its command line, or one of
+// its arguments, is too long.
+func commandLineTooLong(cmd *exec.Cmd) bool {
+	for _, arg := range cmd.Args {
+		if len(arg) > maxArgLength {
+			return true
+		}
+	}
+	return commandLineLength(cmd) > maxCommandLineLength
+}
+
+// shortenClasspath replaces the -classpath argument of the command, if the command line is too long to be executed,
+// with an @argfile holding it for java 9 and later, or with a pathing jar whose manifest lists the classpath entries
+// for earlier versions, written to generatedArgsDir. It is not applied to chrooted processes, which could not read the
+// files written outside of their chroot.
+func shortenClasspath(cmd *exec.Cmd, classpath, javaHome string, logger io.Writer) error {
+	if classpath == "" || !commandLineTooLong(cmd) {
+		return nil
+	}
+	index := -1
+	for i := 0; i+1 < len(cmd.Args); i++ {
+		if cmd.Args[i] == "-classpath" && cmd.Args[i+1] == classpath {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+	majorVersion, err := getJavaMajorVersion(javaHome)
+	if err != nil {
+		return errors.Wrap(err, "failed to determine java version to shorten the classpath")
+	}
+
+	var replacement []string
+	if majorVersion >= argFileMinJavaVersion {
+		argFile, err := writeGeneratedArgsFile("classpath", ".args",
+			[]byte(argFileLines([]string{"-classpath", classpath})), 0644)
+		if err != nil {
+			return errors.Wrap(err, "failed to write classpath argument file")
+		}
+		replacement = []string{"@" + argFile}
+	} else {
+		manifest, err := pathingJarManifest(filepath.SplitList(classpath))
+		if err != nil {
+			return err
+		}
+		jar, err := pathingJar(manifest)
+		if err != nil {
+			return errors.Wrap(err, "failed to create pathing jar")
+		}
+		jarFile, err := writeGeneratedArgsFile("classpath", ".jar", jar, 0644)
+		if err != nil {
+			return errors.Wrap(err, "failed to write pathing jar")
+		}
+		replacement = []string{"-classpath", jarFile}
+	}
+	fmt.Fprintf(logger, "Command line is too long, passing the classpath as %s\n", strings.Join(replacement, " "))
+	args := append(append(append([]string{}, cmd.Args[:index]...), replacement...), cmd.Args[index+2:]...)
+	cmd.Args = args
+	return nil
+}
+
+// argFileLines returns the contents of an @argfile passing the given arguments, each on its own line and quoted so
+// that spaces, quotes, backslashes and # are read as they are.
+func argFileLines(args []string) string {
+	var lines bytes.Buffer
+	for _, arg := range args {
+		quoted := strings.Replace(strings.Replace(arg, `\`, `\\`, -1), `"`, `\"`, -1)
+		fmt.Fprintf(&lines, "\"%s\"\n", quoted)
+	}
+	return lines.String()
+}
+
+// pathingJarManifest returns a jar manifest whose Class-Path lists the given classpath entries as absolute file URLs.
+func pathingJarManifest(entries []string) (string, error) {
+	urls := make([]string, len(entries))
+	for i, entry := range entries {
+		abs, err := filepath.Abs(entry)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to resolve classpath entry %s", entry)
+		}
+		entryPath := filepath.ToSlash(abs)
+		if !strings.HasPrefix(entryPath, "/") {
+			entryPath = "/" + entryPath
+		}
+		// Directories are only searched for classes if their URLs end with a slash.
+		if info, err := os.Stat(entry); err == nil && info.IsDir() {
+			entryPath += "/"
+		}
+		urls[i] = (&url.URL{Scheme: "file", Path: entryPath}).String()
+	}
+	return "Manifest-Version: 1.0\r\n" + manifestHeader("Class-Path", strings.Join(urls, " ")) +
+		"Created-By: go-java-launcher\r\n\r\n", nil
+}
+
+// manifestHeader returns the manifest header of the given name and value, wrapped into continuation lines that begin
+// with a space so that no line is longer than manifestLineLength.
+func manifestHeader(name, value string) string {
+	line := name + ": " + value
+	var header bytes.Buffer
+	for len(line) > manifestLineLength {
+		header.WriteString(line[:manifestLineLength] + "\r\n")
+		line = " " + line[manifestLineLength:]
+	}
+	header.WriteString(line + "\r\n")
+	return header.String()
+}
+
+func pathingJar(manifest string) ([]byte, error) {
+	var jar bytes.Buffer
+	writer := zip.NewWriter(&jar)
+	entry, err := writer.Create("META-INF/MANIFEST.MF")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := entry.Write([]byte(manifest)); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return jar.Bytes(), nil
+}
+
+// writeJvmArgFile writes the given JVM arguments to an @argfile of generatedArgsDir readable only by the launcher,
+// returning its absolute path, and fails if the java version does not read @argfiles.
+func writeJvmArgFile(jvmArgs []string, majorVersion int) (string, error) {
+	if majorVersion < argFileMinJavaVersion {
+		return "", errors.Errorf("argFile requires java %d or later, found java %d", argFileMinJavaVersion,
+			majorVersion)
+	}
+	argFile, err := writeGeneratedArgsFile("jvm", ".args", []byte(argFileLines(jvmArgs)), 0600)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to write JVM argument file")
+	}
+	return argFile, nil
+}
+
+func validateSecretProperties(config JavaConfig, chroot string) error {
+	if len(config.SecretProperties) == 0 {
+		return nil
+	}
+	if config.NativeImage != "" {
+		return errors.New("secretProperties is not supported with nativeImage")
+	}
+	if chroot != "" {
+		return errors.New("secretProperties is not supported with chroot")
+	}
+	for _, name := range config.SecretProperties {
+		if name == "" || strings.Contains(name, "=") {
+			return errors.Errorf("invalid secretProperties name '%s'", name)
+		}
+	}
+	return nil
+}
+
+// extractSecretProperties returns the given JVM arguments without the -D options of the secret properties, along with
+// those options and the index of the first of them, or -1 if there are none.
+func extractSecretProperties(jvmArgs, secretProperties []string) ([]string, []string, int) {
+	secret := make(map[string]struct{}, len(secretProperties))
+	for _, name := range secretProperties {
+		secret[name] = struct{}{}
+	}
+	var remaining, extracted []string
+	first := -1
+	for _, arg := range jvmArgs {
+		if strings.HasPrefix(arg, "-D") {
+			name := strings.SplitN(strings.TrimPrefix(arg, "-D"), "=", 2)[0]
+			if _, ok := secret[name]; ok {
+				if first < 0 {
+					first = len(remaining)
+				}
+				extracted = append(extracted, arg)
+				continue
+			}
+		}
+		remaining = append(remaining, arg)
+	}
+	return remaining, extracted, first
+}
+
+// writeGeneratedArgsFile writes the data to a file of generatedArgsDir with the given mode, named by the prefix, the
+// hash of the data and the suffix so that commands with the same arguments share a file, and returns its absolute
+// path. The file is only written if it does not exist.
+func writeGeneratedArgsFile(prefix, suffix string, data []byte, mode os.FileMode) (string, error) {
+	dir, err := filepath.Abs(generatedArgsDir)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(data)
+	path := filepath.Join(dir, prefix+"-"+hex.EncodeToString(hash[:])[:16]+suffix)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := ioutil.TempFile(dir, prefix)
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	return path, os.Rename(tmp.Name(), path)
+}
+
+// pruneGeneratedArgsFiles removes the files of generatedArgsDir that none of the commands use, which were written for
+// earlier configurations. Java reads them as it starts, so running processes no longer need them. Files that cannot be
+// removed are left for a later launch.
+func pruneGeneratedArgsFiles(cmds []*exec.Cmd) {
+	dir, err := filepath.Abs(generatedArgsDir)
+	if err != nil {
+		return
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	used := make(map[string]struct{})
+	for _, cmd := range cmds {
+		for _, arg := range cmd.Args {
+			used[strings.TrimPrefix(arg, "@")] = struct{}{}
+		}
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file.Name())
+		if _, ok := used[path]; !ok {
+			_ = os.Remove(path)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArgFileLines(t *testing.T) {
+	assert.Equal(t, "\"-classpath\"\n\"/opt/my service/lib/a.jar\"\n\"C:\\\\lib\\\\b.jar\"\n\"-Dq=\\\"#\\\"\"\n",
+		argFileLines([]string{"-classpath", "/opt/my service/lib/a.jar", `C:\lib\b.jar`, `-Dq="#"`}))
+}
+
+func TestManifestHeader(t *testing.T) {
+	header := manifestHeader("Class-Path", strings.Repeat("a", 150))
+	lines := strings.Split(strings.TrimSuffix(header, "\r\n"), "\r\n")
+	require.Len(t, lines, 3)
+	for _, line := range lines {
+		assert.True(t, len(line) <= manifestLineLength, line)
+	}
+	assert.Equal(t, "Class-Path: "+strings.Repeat("a", 150), lines[0]+lines[1][1:]+lines[2][1:])
+}
+
+func TestPathingJarManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pathing-jar")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "classes"), 0755))
+
+	manifest, err := pathingJarManifest([]string{filepath.Join(dir, "my lib.jar"), filepath.Join(dir, "classes")})
+	require.NoError(t, err)
+	unwrapped := strings.Replace(manifest, "\r\n ", "", -1)
+	assert.Contains(t, unwrapped, "Class-Path: file://"+dir+"/my%20lib.jar file://"+dir+"/classes/\r\n")
+	assert.True(t, strings.HasPrefix(manifest, "Manifest-Version: 1.0\r\n"))
+}
+
+func TestShortenClasspath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shorten-classpath")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+
+	var entries []string
+	for i := 0; len(strings.Join(entries, ":")) <= maxArgLength; i++ {
+		entries = append(entries, filepath.Join(dir, "service/lib", strings.Repeat("x", 100)+string(rune('a'+i%26))+
+			".jar"))
+	}
+	classpath := strings.Join(entries, string(os.PathListSeparator))
+
+	for i, currCase := range []struct {
+		javaVersion string
+		classpath   string
+		wantArgs    func(args []string) bool
+	}{
+		{"11.0.2", "service/lib/a.jar", func(args []string) bool {
+			return args[2] == "service/lib/a.jar"
+		}},
+		{"11.0.2", classpath, func(args []string) bool {
+			if len(args) != 3 || !strings.HasPrefix(args[1], "@"+filepath.Join(dir, "var/generated-args/classpath-")) {
+				return false
+			}
+			content, err := ioutil.ReadFile(args[1][1:])
+			return err == nil && string(content) == argFileLines([]string{"-classpath", classpath})
+		}},
+		{"1.8.0_202", classpath, func(args []string) bool {
+			if len(args) != 4 || args[1] != "-classpath" || !strings.HasSuffix(args[2], ".jar") {
+				return false
+			}
+			jar, err := zip.OpenReader(args[2])
+			if err != nil {
+				return false
+			}
+			defer func() {
+				_ = jar.Close()
+			}()
+			return len(jar.File) == 1 && jar.File[0].Name == "META-INF/MANIFEST.MF"
+		}},
+	} {
+		javaHome := filepath.Join(dir, "jdk-"+currCase.javaVersion)
+		require.NoError(t, os.MkdirAll(javaHome, 0755), "Case %d", i)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(javaHome, "release"),
+			[]byte("JAVA_VERSION=\""+currCase.javaVersion+"\"\n"), 0644), "Case %d", i)
+
+		cmd := exec.Command("java", "-classpath", currCase.classpath, "com.example.Main")
+		require.NoError(t, shortenClasspath(cmd, currCase.classpath, javaHome, ioutil.Discard), "Case %d", i)
+		assert.True(t, currCase.wantArgs(cmd.Args), "Case %d: %.200v", i, cmd.Args)
+		assert.Equal(t, "com.example.Main", cmd.Args[len(cmd.Args)-1], "Case %d", i)
+	}
+}
+
+func TestCompileCmdArgFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "argfile")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+	require.NoError(t, os.MkdirAll("jdk/bin", 0755))
+	require.NoError(t, ioutil.WriteFile("jdk/bin/java", nil, 0755))
+	require.NoError(t, os.Mkdir("lib", 0755))
+	require.NoError(t, ioutil.WriteFile("lib/a.jar", nil, 0644))
+
+	for i, currCase := range []struct {
+		javaVersion string
+		wantErr     string
+	}{
+		{javaVersion: "11.0.2"},
+		{javaVersion: "1.8.0_202", wantErr: "argFile requires java 9 or later, found java 8"},
+	} {
+		require.NoError(t, ioutil.WriteFile("jdk/release", []byte("JAVA_VERSION=\""+currCase.javaVersion+"\"\n"),
+			0644), "Case %d", i)
+		cmd, err := compileCmdFromConfig(&StaticLauncherConfig{
+			TypedConfig: TypedConfig{Type: "java"},
+			JavaConfig: JavaConfig{
+				JavaHome:                    filepath.Join(dir, "jdk"),
+				MainClass:                   "com.example.Main",
+				JvmOpts:                     []string{"-Dpassword=secret"},
+				Classpath:                   []string{"lib/a.jar"},
+				DisableActiveProcessorCount: true,
+				ArgFile:                     true,
+			},
+		}, &CustomLauncherConfig{}, NewSimpleWriterLogger(ioutil.Discard).PrimaryLogger)
+		if currCase.wantErr != "" {
+			assert.EqualError(t, err, currCase.wantErr, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		require.Len(t, cmd.Args, 3, "Case %d", i)
+		assert.Equal(t, []string{filepath.Join(dir, "jdk/bin/java"), "com.example.Main"},
+			[]string{cmd.Args[0], cmd.Args[2]}, "Case %d", i)
+		require.True(t, strings.HasPrefix(cmd.Args[1], "@"), "Case %d", i)
+
+		content, err := ioutil.ReadFile(cmd.Args[1][1:])
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, argFileLines([]string{"-Dpassword=secret", "-classpath", filepath.Join(dir, "lib/a.jar")}),
+			string(content), "Case %d", i)
+		info, err := os.Stat(cmd.Args[1][1:])
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm(), "Case %d", i)
+	}
+}
+
+func TestCompileCmdsFromConfigPrunesGeneratedArgsFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "argfile")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+	require.NoError(t, os.MkdirAll("jdk/bin", 0755))
+	require.NoError(t, ioutil.WriteFile("jdk/bin/java", nil, 0755))
+	require.NoError(t, ioutil.WriteFile("jdk/release", []byte("JAVA_VERSION=\"11.0.2\"\n"), 0644))
+	require.NoError(t, os.MkdirAll(generatedArgsDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(generatedArgsDir, "classpath-0123456789abcdef.jar"), nil,
+		0644))
+
+	// Each launch only keeps the files of its own commands.
+	for i, jvmOpt := range []string{"-Dpassword=secret", "-Dpassword=rotated"} {
+		cmds, err := CompileCmdsFromConfig(&PrimaryStaticLauncherConfig{
+			ServiceName: "primary",
+			StaticLauncherConfig: StaticLauncherConfig{
+				TypedConfig: TypedConfig{Type: "java"},
+				JavaConfig: JavaConfig{
+					JavaHome:                    filepath.Join(dir, "jdk"),
+					MainClass:                   "com.example.Main",
+					JvmOpts:                     []string{jvmOpt},
+					DisableActiveProcessorCount: true,
+					ArgFile:                     true,
+				},
+			},
+		}, &PrimaryCustomLauncherConfig{}, NewSimpleWriterLogger(ioutil.Discard))
+		require.NoError(t, err, "Case %d", i)
+		files, err := ioutil.ReadDir(generatedArgsDir)
+		require.NoError(t, err, "Case %d", i)
+		require.Len(t, files, 1, "Case %d", i)
+		assert.Equal(t, "@"+filepath.Join(dir, generatedArgsDir, files[0].Name()), cmds.Primary.Args[1], "Case %d", i)
+	}
+}
+
+func TestParseStaticConfigArgFile(t *testing.T) {
+	for i, currCase := range []struct {
+		yaml string
+		err  string
+	}{
+		{yaml: "mainClass: com.example.Main\nclasspath: [lib/*]\n"},
+		{yaml: "nativeImage: service/bin/my-service\n", err: "argFile is not supported with nativeImage"},
+		{yaml: "mainClass: com.example.Main\nclasspath: [lib/*]\nchroot: /srv/root\n",
+			err: "argFile is not supported with chroot"},
+		{yaml: "mainClass: com.example.Main\nclasspath: [lib/*]\nsecretProperties: [db.password]\n"},
+		{yaml: "mainClass: com.example.Main\nclasspath: [lib/*]\nsecretProperties: [db.password=x]\n",
+			err: "invalid secretProperties name 'db.password=x'"},
+	} {
+		config, err := parseStaticConfig([]byte(`
+configType: java
+configVersion: 2
+serviceName: primary
+argFile: true
+` + currCase.yaml))
+		if currCase.err != "" {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.True(t, config.ArgFile, "Case %d", i)
+	}
+}
+
+func TestExtractSecretProperties(t *testing.T) {
+	for i, currCase := range []struct {
+		args          []string
+		wantRemaining []string
+		wantExtracted []string
+		wantIndex     int
+	}{
+		{[]string{"-Xmx1g", "-Dname=value"}, []string{"-Xmx1g", "-Dname=value"}, nil, -1},
+		{
+			[]string{"-Xmx1g", "-Ddb.password=hunter2", "-Dname=value", "-Dapi.key=abc", "-classpath", "a.jar"},
+			[]string{"-Xmx1g", "-Dname=value", "-classpath", "a.jar"},
+			[]string{"-Ddb.password=hunter2", "-Dapi.key=abc"},
+			1,
+		},
+	} {
+		remaining, extracted, index := extractSecretProperties(currCase.args, []string{"db.password", "api.key"})
+		assert.Equal(t, currCase.wantRemaining, remaining, "Case %d", i)
+		assert.Equal(t, currCase.wantExtracted, extracted, "Case %d", i)
+		assert.Equal(t, currCase.wantIndex, index, "Case %d", i)
+	}
+}
+
+func TestCompileCmdSecretProperties(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secret-properties")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+	require.NoError(t, os.MkdirAll("jdk/bin", 0755))
+	require.NoError(t, ioutil.WriteFile("jdk/bin/java", nil, 0755))
+	require.NoError(t, ioutil.WriteFile("jdk/release", []byte("JAVA_VERSION=\"17.0.2\"\n"), 0644))
+	require.NoError(t, os.Mkdir("lib", 0755))
+	require.NoError(t, ioutil.WriteFile("lib/a.jar", nil, 0644))
+
+	cmd, err := compileCmdFromConfig(&StaticLauncherConfig{
+		TypedConfig: TypedConfig{Type: "java"},
+		JavaConfig: JavaConfig{
+			JavaHome:                    filepath.Join(dir, "jdk"),
+			MainClass:                   "com.example.Main",
+			JvmOpts:                     []string{"-Xmx1g", "-Ddb.url=jdbc:postgresql://db/app"},
+			Classpath:                   []string{"lib/a.jar"},
+			DisableActiveProcessorCount: true,
+			SecretProperties:            []string{"db.url"},
+		},
+	}, &CustomLauncherConfig{}, NewSimpleWriterLogger(ioutil.Discard).PrimaryLogger)
+	require.NoError(t, err)
+	require.Len(t, cmd.Args, 6)
+	assert.Equal(t, []string{"-Xmx1g", "-classpath", filepath.Join(dir, "lib/a.jar"), "com.example.Main"},
+		[]string{cmd.Args[1], cmd.Args[3], cmd.Args[4], cmd.Args[5]})
+	require.True(t, strings.HasPrefix(cmd.Args[2], "@"))
+	content, err := ioutil.ReadFile(cmd.Args[2][1:])
+	require.NoError(t, err)
+	assert.Equal(t, argFileLines([]string{"-Ddb.url=jdbc:postgresql://db/app"}), string(content))
+
+	redactor := StaticLauncherConfig{JavaConfig: JavaConfig{SecretProperties: []string{"db.url"}}}.Redactor()
+	assert.Equal(t, []string{"-Ddb.url=<redacted>"}, redactor.RedactArgs([]string{"-Ddb.url=jdbc:postgresql://db/app"}))
+}
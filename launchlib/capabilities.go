@@ -0,0 +1,118 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// capabilityNumbers are the Linux capabilities by name, without their CAP_ prefix.
+var capabilityNumbers = map[string]uintptr{
+	"CHOWN":              0,
+	"DAC_OVERRIDE":       1,
+	"DAC_READ_SEARCH":    2,
+	"FOWNER":             3,
+	"FSETID":             4,
+	"KILL":               5,
+	"SETGID":             6,
+	"SETUID":             7,
+	"SETPCAP":            8,
+	"LINUX_IMMUTABLE":    9,
+	"NET_BIND_SERVICE":   10,
+	"NET_BROADCAST":      11,
+	"NET_ADMIN":          12,
+	"NET_RAW":            13,
+	"IPC_LOCK":           14,
+	"IPC_OWNER":          15,
+	"SYS_MODULE":         16,
+	"SYS_RAWIO":          17,
+	"SYS_CHROOT":         18,
+	"SYS_PTRACE":         19,
+	"SYS_PACCT":          20,
+	"SYS_ADMIN":          21,
+	"SYS_BOOT":           22,
+	"SYS_NICE":           23,
+	"SYS_RESOURCE":       24,
+	"SYS_TIME":           25,
+	"SYS_TTY_CONFIG":     26,
+	"MKNOD":              27,
+	"LEASE":              28,
+	"AUDIT_WRITE":        29,
+	"AUDIT_CONTROL":      30,
+	"SETFCAP":            31,
+	"MAC_OVERRIDE":       32,
+	"MAC_ADMIN":          33,
+	"SYSLOG":             34,
+	"WAKE_ALARM":         35,
+	"BLOCK_SUSPEND":      36,
+	"AUDIT_READ":         37,
+	"PERFMON":            38,
+	"BPF":                39,
+	"CHECKPOINT_RESTORE": 40,
+}
+
+// lastCapability is the highest capability of capabilityNumbers.
+const lastCapability = 40
+
+// CapabilitiesConfig limits the Linux capabilities of a process, by either keeping only those of Keep or dropping
+// those of Drop. Capabilities are named as in capabilities(7), with or without their CAP_ prefix.
+type CapabilitiesConfig struct {
+	Keep []string `yaml:"keep"`
+	Drop []string `yaml:"drop"`
+}
+
+func (config CapabilitiesConfig) validate() error {
+	if (config.Keep == nil) == (config.Drop == nil) {
+		return errors.New("exactly one of keep and drop must be set")
+	}
+	_, err := parseCapabilities(append(append([]string{}, config.Keep...), config.Drop...))
+	return err
+}
+
+func parseCapabilities(names []string) ([]uintptr, error) {
+	capabilities := make([]uintptr, 0, len(names))
+	for _, name := range names {
+		capability, ok := capabilityNumbers[strings.TrimPrefix(strings.ToUpper(name), "CAP_")]
+		if !ok {
+			return nil, errors.Errorf("unknown capability '%s'", name)
+		}
+		capabilities = append(capabilities, capability)
+	}
+	sort.Slice(capabilities, func(i, j int) bool {
+		return capabilities[i] < capabilities[j]
+	})
+	return capabilities, nil
+}
+
+// keptCapabilities returns the capabilities that a process keeps under the config, given the highest capability
+// supported by the kernel.
+func (config CapabilitiesConfig) keptCapabilities(last uintptr) []uintptr {
+	listed, _ := parseCapabilities(append(append([]string{}, config.Keep...), config.Drop...))
+	isListed := make(map[uintptr]bool, len(listed))
+	for _, capability := range listed {
+		isListed[capability] = true
+	}
+	var kept []uintptr
+	for capability := uintptr(0); capability <= last; capability++ {
+		// Keep lists the capabilities that are kept, and Drop those that are not.
+		if isListed[capability] == (config.Keep != nil) {
+			kept = append(kept, capability)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,135 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	prCapbsetDrop       = 24
+	prCapAmbient        = 47
+	prCapAmbientRaise   = 2
+	linuxCapabilityVer3 = 0x20080522
+)
+
+type capabilityHeader struct {
+	version uint32
+	pid     int32
+}
+
+type capabilityData struct {
+	effective   uint32
+	permitted   uint32
+	inheritable uint32
+}
+
+// LimitCapabilities limits the capability bounding set of the calling thread, which must be locked to its goroutine,
+// to the capabilities kept by config. The processes the thread starts or execs cannot gain any other capability.
+// Requires the launcher to run as root. Does nothing if config is nil.
+func LimitCapabilities(config *CapabilitiesConfig) error {
+	if config == nil {
+		return nil
+	}
+	if os.Geteuid() != 0 {
+		return errors.New("the launcher must run as root to limit the capabilities of processes")
+	}
+	last := lastKernelCapability()
+	kept := make(map[uintptr]bool)
+	for _, capability := range config.keptCapabilities(last) {
+		kept[capability] = true
+	}
+	for capability := uintptr(0); capability <= last; capability++ {
+		if kept[capability] {
+			continue
+		}
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prCapbsetDrop, capability, 0); errno != 0 {
+			return errors.Wrapf(errno, "failed to drop capability %d", capability)
+		}
+	}
+	return nil
+}
+
+// lastKernelCapability returns the highest capability supported by the kernel, or that of capabilityNumbers if it
+// cannot be read.
+func lastKernelCapability() uintptr {
+	data, err := ioutil.ReadFile("/proc/sys/kernel/cap_last_cap")
+	if err != nil {
+		return lastCapability
+	}
+	last, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return lastCapability
+	}
+	return uintptr(last)
+}
+
+// setAmbientCapabilities makes the command keep the capabilities kept by config when it runs as another user than the
+// launcher, which would otherwise clear them.
+func setAmbientCapabilities(cmd *exec.Cmd, config *CapabilitiesConfig) {
+	if config == nil || config.Keep == nil || cmd.SysProcAttr == nil {
+		return
+	}
+	cmd.SysProcAttr.AmbientCaps = config.keptCapabilities(lastCapability)
+}
+
+// keepCapabilitiesOnSetuid makes the calling thread keep its permitted capabilities when it changes its user, if the
+// command has ambient capabilities to raise afterwards.
+func keepCapabilitiesOnSetuid(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr == nil || len(cmd.SysProcAttr.AmbientCaps) == 0 {
+		return nil
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_SET_KEEPCAPS, 1, 0); errno != 0 {
+		return errors.Wrap(errno, "failed to keep capabilities")
+	}
+	return nil
+}
+
+// raiseAmbientCapabilities raises the ambient capabilities of the command on the calling thread, so that they are kept
+// by the process it execs.
+func raiseAmbientCapabilities(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr == nil || len(cmd.SysProcAttr.AmbientCaps) == 0 {
+		return nil
+	}
+	header := capabilityHeader{version: linuxCapabilityVer3}
+	var data [2]capabilityData
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPGET, uintptr(unsafe.Pointer(&header)),
+		uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return errors.Wrap(errno, "failed to get capabilities")
+	}
+	// A capability must be permitted and inheritable to be raised as an ambient capability.
+	for _, capability := range cmd.SysProcAttr.AmbientCaps {
+		data[capability/32].inheritable |= 1 << (capability % 32)
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&header)),
+		uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return errors.Wrap(errno, "failed to set capabilities")
+	}
+	for _, capability := range cmd.SysProcAttr.AmbientCaps {
+		if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRCTL, prCapAmbient, prCapAmbientRaise, capability, 0, 0,
+			0); errno != 0 {
+			return errors.Wrapf(errno, "failed to raise ambient capability %d", capability)
+		}
+	}
+	return nil
+}
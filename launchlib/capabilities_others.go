@@ -0,0 +1,42 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+// +build !linux
+
+package launchlib
+
+import (
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// LimitCapabilities returns an error if config is set, as capabilities are only supported on linux.
+func LimitCapabilities(config *CapabilitiesConfig) error {
+	if config == nil {
+		return nil
+	}
+	return errors.New("capabilities are only supported on linux")
+}
+
+func setAmbientCapabilities(cmd *exec.Cmd, config *CapabilitiesConfig) {}
+
+func keepCapabilitiesOnSetuid(cmd *exec.Cmd) error {
+	return nil
+}
+
+func raiseAmbientCapabilities(cmd *exec.Cmd) error {
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package launchlib
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCapabilities(t *testing.T) {
+	for i, currCase := range []struct {
+		config CapabilitiesConfig
+		err    string
+	}{
+		{config: CapabilitiesConfig{Keep: []string{"CAP_NET_BIND_SERVICE", "chown"}}},
+		{config: CapabilitiesConfig{Keep: []string{}}},
+		{config: CapabilitiesConfig{Drop: []string{"SYS_ADMIN"}}},
+		{config: CapabilitiesConfig{}, err: "exactly one of keep and drop must be set"},
+		{config: CapabilitiesConfig{Keep: []string{"CHOWN"}, Drop: []string{"KILL"}},
+			err: "exactly one of keep and drop must be set"},
+		{config: CapabilitiesConfig{Drop: []string{"CAP_EVERYTHING"}}, err: "unknown capability 'CAP_EVERYTHING'"},
+	} {
+		err := currCase.config.validate()
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestKeptCapabilities(t *testing.T) {
+	keep := CapabilitiesConfig{Keep: []string{"NET_BIND_SERVICE", "CAP_CHOWN"}}
+	assert.Equal(t, []uintptr{0, 10}, keep.keptCapabilities(40))
+	assert.Nil(t, CapabilitiesConfig{Keep: []string{}}.keptCapabilities(40))
+	assert.Equal(t, []uintptr{0, 1, 3}, CapabilitiesConfig{Drop: []string{"DAC_READ_SEARCH"}}.keptCapabilities(3))
+}
+
+func TestStartIsolatedWithCapabilities(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Skip("limiting capabilities requires root on linux")
+	}
+	cmd := exec.Command("grep", "CapBnd", "/proc/self/status")
+	out := &bytes.Buffer{}
+	cmd.Stdout = out
+	require.NoError(t, StartIsolated(cmd, Isolation{
+		Capabilities: &CapabilitiesConfig{Keep: []string{"NET_BIND_SERVICE"}},
+	}))
+	require.NoError(t, cmd.Wait())
+	assert.Equal(t, "CapBnd:\t0000000000000400\n", out.String())
+
+	// A process running as another user keeps the capabilities as ambient capabilities.
+	config := &CapabilitiesConfig{Keep: []string{"NET_BIND_SERVICE"}}
+	cmd = exec.Command("grep", "CapAmb", "/proc/self/status")
+	out.Reset()
+	cmd.Stdout = out
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: &syscall.Credential{Uid: 65534, Gid: 65534}}
+	setAmbientCapabilities(cmd, config)
+	require.NoError(t, StartIsolated(cmd, Isolation{Capabilities: config}))
+	require.NoError(t, cmd.Wait())
+	assert.Equal(t, "CapAmb:\t0000000000000400\n", out.String())
+}
@@ -0,0 +1,115 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// cgroupRoot is where the cgroup hierarchy of the container is mounted, overridden in tests.
+	cgroupRoot = "/sys/fs/cgroup"
+	// cgroupV1UnlimitedThreshold is the value above which a cgroup v1 limit is treated as no limit, since an unset
+	// limit is reported as the largest page-aligned int64.
+	cgroupV1UnlimitedThreshold int64 = 1 << 62
+)
+
+// readCgroupInts reads the space-separated integer values of the cgroup file at the given path relative to cgroupRoot.
+// Returns false if the file does not exist or, as cgroup v2 reports an unset limit, its first value is "max".
+func readCgroupInts(relativePath string) ([]int64, bool, error) {
+	content, err := ioutil.ReadFile(filepath.Join(cgroupRoot, relativePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, errors.Wrapf(err, "failed to read cgroup file %s", relativePath)
+	}
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 || fields[0] == "max" {
+		return nil, false, nil
+	}
+	values := make([]int64, len(fields))
+	for i, field := range fields {
+		if values[i], err = strconv.ParseInt(field, 10, 64); err != nil {
+			return nil, false, errors.Wrapf(err, "cgroup file %s did not contain integers", relativePath)
+		}
+	}
+	return values, true, nil
+}
+
+// readCgroupInt is readCgroupInts for files containing a single value.
+func readCgroupInt(relativePath string) (int64, bool, error) {
+	values, ok, err := readCgroupInts(relativePath)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	return values[0], true, nil
+}
+
+// getCgroupMemoryLimit returns the memory limit in bytes of the cgroup of the container, checking cgroup v2 before v1.
+// Returns false if no limit is set.
+func getCgroupMemoryLimit() (int64, bool, error) {
+	if limit, ok, err := readCgroupInt("memory.max"); err != nil || ok {
+		return limit, ok, err
+	}
+	limit, ok, err := readCgroupInt("memory/memory.limit_in_bytes")
+	if err != nil || !ok || limit >= cgroupV1UnlimitedThreshold {
+		return 0, false, err
+	}
+	return limit, true, nil
+}
+
+// getCgroupProcessorCount returns the number of processors available to the cgroup of the container, rounding its CPU
+// quota up to a whole processor, or falling back to its cgroup v1 CPU shares if no quota is set. Returns false if
+// neither limits the container.
+func getCgroupProcessorCount() (int, bool, error) {
+	if quota, ok, err := readCgroupInts("cpu.max"); err != nil {
+		return 0, false, err
+	} else if ok && len(quota) == 2 {
+		return processorsForQuota(quota[0], quota[1]), true, nil
+	}
+
+	quota, ok, err := readCgroupInt("cpu/cpu.cfs_quota_us")
+	if err != nil {
+		return 0, false, err
+	}
+	if ok && quota > 0 {
+		period, ok, err := readCgroupInt("cpu/cpu.cfs_period_us")
+		if err != nil {
+			return 0, false, err
+		}
+		if ok && period > 0 {
+			return processorsForQuota(quota, period), true, nil
+		}
+	}
+
+	// 1024 shares are the equivalent of one processor and the default, which does not limit the container.
+	shares, ok, err := readCgroupInt("cpu/cpu.shares")
+	if err != nil || !ok || shares == 1024 {
+		return 0, false, err
+	}
+	return processorsForQuota(shares, 1024), true, nil
+}
+
+func processorsForQuota(quota, period int64) int {
+	return int(math.Ceil(float64(quota) / float64(period)))
+}
@@ -0,0 +1,163 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// cpuMaxPeriod is the period in microseconds of the cpu.max quota written for cpuMax.
+const cpuMaxPeriod = 100000
+
+var (
+	cgroupNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+(/[A-Za-z0-9_.-]+)*$`)
+	memorySizePattern = regexp.MustCompile(`^([0-9]+)([KMGT]?)$`)
+	memorySizeUnits   = map[string]int64{"": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40}
+)
+
+// CgroupConfig configures the cgroup v2 that a process is moved into, created under the cgroup hierarchy of the host
+// with the memory and cpu controllers enabled if it does not exist.
+type CgroupConfig struct {
+	// Name is the path of the cgroup relative to the root of the hierarchy, e.g. services/my-service.
+	Name string `yaml:"name"`
+	// MemoryMax and MemoryHigh are the hard and throttling memory limits, in bytes with an optional K, M, G or T
+	// suffix, or max for no limit.
+	MemoryMax  string `yaml:"memoryMax"`
+	MemoryHigh string `yaml:"memoryHigh"`
+	// CPUMax is the number of processors the cgroup may use, which may be fractional.
+	CPUMax float64 `yaml:"cpuMax"`
+	// CPUWeight is the share of CPU time of the cgroup relative to its siblings, from 1 to 10000.
+	CPUWeight int `yaml:"cpuWeight"`
+}
+
+func (config CgroupConfig) validate() error {
+	if !cgroupNamePattern.MatchString(config.Name) || hasDotSegment(config.Name) {
+		return errors.Errorf("name must be a relative path of [A-Za-z0-9_.-] segments, found '%s'", config.Name)
+	}
+	if _, err := parseMemorySize("memoryMax", config.MemoryMax); err != nil {
+		return err
+	}
+	if _, err := parseMemorySize("memoryHigh", config.MemoryHigh); err != nil {
+		return err
+	}
+	if config.CPUMax < 0 {
+		return errors.Errorf("cpuMax must not be negative, found %v", config.CPUMax)
+	}
+	if config.CPUWeight != 0 && (config.CPUWeight < 1 || config.CPUWeight > 10000) {
+		return errors.Errorf("cpuWeight must be between 1 and 10000, found %d", config.CPUWeight)
+	}
+	return nil
+}
+
+func hasDotSegment(name string) bool {
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "." || segment == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMemorySize returns the value of the named memory limit to write to its cgroup file, which is empty if the limit
+// is not set.
+func parseMemorySize(name, size string) (string, error) {
+	if size == "" || size == "max" {
+		return size, nil
+	}
+	value, ok := parseByteSize(size)
+	if !ok {
+		return "", errors.Errorf("%s must be a number of bytes with an optional K, M, G or T suffix, or max, "+
+			"found '%s'", name, size)
+	}
+	return strconv.FormatInt(value, 10), nil
+}
+
+// parseByteSize returns the number of bytes of a size with an optional K, M, G or T suffix, and whether it is one.
+func parseByteSize(size string) (int64, bool) {
+	match := memorySizePattern.FindStringSubmatch(strings.ToUpper(size))
+	if match == nil {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value * memorySizeUnits[match[2]], true
+}
+
+// files returns the contents of the cgroup files that set the limits of the config.
+func (config CgroupConfig) files() map[string]string {
+	files := make(map[string]string)
+	// The sizes were validated along with the config.
+	if memoryMax, _ := parseMemorySize("memoryMax", config.MemoryMax); memoryMax != "" {
+		files["memory.max"] = memoryMax
+	}
+	if memoryHigh, _ := parseMemorySize("memoryHigh", config.MemoryHigh); memoryHigh != "" {
+		files["memory.high"] = memoryHigh
+	}
+	if config.CPUMax > 0 {
+		files["cpu.max"] = fmt.Sprintf("%d %d", int64(config.CPUMax*cpuMaxPeriod), cpuMaxPeriod)
+	}
+	if config.CPUWeight > 0 {
+		files["cpu.weight"] = strconv.Itoa(config.CPUWeight)
+	}
+	return files
+}
+
+// JoinCgroup moves the process with the given pid, or the launcher itself if pid is 0 so that the process it execs
+// stays in the cgroup, into the cgroup of config, creating it and setting its limits. The memory and cpu controllers
+// are enabled for the cgroup in each of its ancestors. Requires the launcher to be allowed to write to the cgroup
+// hierarchy, usually as root. Does nothing if config is nil.
+func JoinCgroup(pid int, config *CgroupConfig) error {
+	if config == nil {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return errors.Errorf("cgroup v2 is not mounted at %s", cgroupRoot)
+	}
+
+	dir := cgroupRoot
+	for _, segment := range strings.Split(config.Name, "/") {
+		if err := writeCgroupFile(dir, "cgroup.subtree_control", "+memory +cpu"); err != nil {
+			return err
+		}
+		dir = filepath.Join(dir, segment)
+		if err := os.Mkdir(dir, 0755); err != nil && !os.IsExist(err) {
+			return errors.Wrapf(err, "failed to create cgroup %s", config.Name)
+		}
+	}
+	for file, content := range config.files() {
+		if err := writeCgroupFile(dir, file, content); err != nil {
+			return err
+		}
+	}
+	// Writing 0 moves the writing process.
+	return writeCgroupFile(dir, "cgroup.procs", strconv.Itoa(pid))
+}
+
+func writeCgroupFile(dir, file, content string) error {
+	if err := ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+		return errors.Wrapf(err, "failed to write '%s' to cgroup file %s", content, filepath.Join(dir, file))
+	}
+	return nil
+}
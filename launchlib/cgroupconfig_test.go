@@ -0,0 +1,84 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCgroupConfig(t *testing.T) {
+	for i, currCase := range []struct {
+		config CgroupConfig
+		err    string
+	}{
+		{config: CgroupConfig{
+			Name: "services/my-service", MemoryMax: "2G", MemoryHigh: "max", CPUMax: 1.5, CPUWeight: 100}},
+		{config: CgroupConfig{Name: ""}, err: "name must be a relative path of [A-Za-z0-9_.-] segments, found ''"},
+		{config: CgroupConfig{Name: "/services"},
+			err: "name must be a relative path of [A-Za-z0-9_.-] segments, found '/services'"},
+		{config: CgroupConfig{Name: "services/../system"},
+			err: "name must be a relative path of [A-Za-z0-9_.-] segments, found 'services/../system'"},
+		{config: CgroupConfig{Name: "service", MemoryMax: "2GB"},
+			err: "memoryMax must be a number of bytes with an optional K, M, G or T suffix, or max, found '2GB'"},
+		{config: CgroupConfig{Name: "service", CPUMax: -1}, err: "cpuMax must not be negative, found -1"},
+		{config: CgroupConfig{Name: "service", CPUWeight: 10001},
+			err: "cpuWeight must be between 1 and 10000, found 10001"},
+	} {
+		err := currCase.config.validate()
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestJoinCgroup(t *testing.T) {
+	defer withCgroupFiles(t, map[string]string{"cgroup.controllers": "cpu memory"})()
+
+	require.NoError(t, JoinCgroup(1234, &CgroupConfig{
+		Name:      "services/my-service",
+		MemoryMax: "512m",
+		CPUMax:    1.5,
+		CPUWeight: 50,
+	}))
+	for file, want := range map[string]string{
+		"cgroup.subtree_control":           "+memory +cpu",
+		"services/cgroup.subtree_control":  "+memory +cpu",
+		"services/my-service/memory.max":   "536870912",
+		"services/my-service/cpu.max":      "150000 100000",
+		"services/my-service/cpu.weight":   "50",
+		"services/my-service/cgroup.procs": "1234",
+	} {
+		content, err := ioutil.ReadFile(filepath.Join(cgroupRoot, file))
+		require.NoError(t, err, file)
+		assert.Equal(t, want, string(content), file)
+	}
+	_, err := os.Stat(filepath.Join(cgroupRoot, "services/my-service/memory.high"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestJoinCgroupWithoutCgroupV2(t *testing.T) {
+	defer withCgroupFiles(t, nil)()
+
+	err := JoinCgroup(0, &CgroupConfig{Name: "service"})
+	assert.EqualError(t, err, "cgroup v2 is not mounted at "+cgroupRoot)
+}
@@ -0,0 +1,77 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package launchlib
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// chrootCmd makes the command run chrooted into root, translating its executable, arguments and working directory,
+// which are resolved outside of root, to paths within it. The executable must be within root.
+func chrootCmd(cmd *exec.Cmd, root string) error {
+	executable, ok := pathInChroot(root, cmd.Path)
+	if !ok {
+		return errors.Errorf("executable %s is not within chroot %s", cmd.Path, root)
+	}
+	cmd.Path = executable
+	prefix := strings.TrimSuffix(root, "/") + "/"
+	for i, arg := range cmd.Args {
+		cmd.Args[i] = strings.Replace(arg, prefix, "/", -1)
+	}
+	dir, ok := pathInChroot(root, cmd.Dir)
+	if !ok {
+		dir = "/"
+	}
+	cmd.Dir = dir
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Chroot = root
+	return nil
+}
+
+// pathInChroot returns the path within root of the given absolute path, and false if it is not within root.
+func pathInChroot(root, path string) (string, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return filepath.Join("/", rel), true
+}
+
+// EnterSandbox applies the chroot and working directory of the command to the launcher, for commands that replace the
+// launcher process with exec rather than starting a child process. Must be called on the thread that execs.
+func EnterSandbox(cmd *exec.Cmd) error {
+	if cmd.SysProcAttr != nil && cmd.SysProcAttr.Chroot != "" {
+		if err := syscall.Chroot(cmd.SysProcAttr.Chroot); err != nil {
+			return errors.Wrapf(err, "failed to chroot into %s", cmd.SysProcAttr.Chroot)
+		}
+	}
+	if cmd.Dir != "" {
+		if err := os.Chdir(cmd.Dir); err != nil {
+			return errors.Wrapf(err, "failed to change the working directory to %s", cmd.Dir)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package launchlib
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+func chrootCmd(cmd *exec.Cmd, root string) error {
+	return errors.New("chroot is not supported on windows")
+}
+
+// EnterSandbox applies the working directory of the command to the launcher, as windows has no chroot.
+func EnterSandbox(cmd *exec.Cmd) error {
+	if cmd.Dir != "" {
+		if err := os.Chdir(cmd.Dir); err != nil {
+			return errors.Wrapf(err, "failed to change the working directory to %s", cmd.Dir)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+func validateCommandPrefix(prefix []string) error {
+	if len(prefix) > 0 && strings.TrimSpace(prefix[0]) == "" {
+		return errors.New("the first element of commandPrefix must be the command to run")
+	}
+	return nil
+}
+
+// prefixCommand returns the executable and arguments that run the command given by args under the commandPrefix, such
+// as numactl or strace, or the executable and args unchanged if prefix is empty. The first element of the prefix is
+// looked up in the PATH unless it contains a path separator, in which case it is relative to the working directory
+// unless absolute.
+func prefixCommand(prefix []string, executable string, args []string, workingDir string) (string, []string, error) {
+	if len(prefix) == 0 {
+		return executable, args, nil
+	}
+	prefixExecutable := prefix[0]
+	if !strings.ContainsRune(prefixExecutable, filepath.Separator) && !strings.ContainsRune(prefixExecutable, '/') {
+		path, err := exec.LookPath(prefixExecutable)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "failed to find the command of commandPrefix")
+		}
+		prefixExecutable = path
+	} else if !filepath.IsAbs(prefixExecutable) {
+		prefixExecutable = filepath.Join(workingDir, prefixExecutable)
+	}
+	prefixExecutable, err := verifyPathIsSafeForExec(prefixExecutable)
+	if err != nil {
+		return "", nil, err
+	}
+	prefixedArgs := append([]string{prefixExecutable}, prefix[1:]...)
+	// The 0th argument of args is the executable itself, which the prefix command is given by its full path.
+	prefixedArgs = append(prefixedArgs, executable)
+	prefixedArgs = append(prefixedArgs, args[1:]...)
+	return prefixExecutable, prefixedArgs, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixCommand(t *testing.T) {
+	workingDir, err := ioutil.TempDir("", "launchlib-prefix")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(workingDir)
+	}()
+	wrapper := filepath.Join(workingDir, "bin", "wrapper")
+	require.NoError(t, os.MkdirAll(filepath.Dir(wrapper), 0755))
+	require.NoError(t, ioutil.WriteFile(wrapper, []byte("#!/bin/sh\nexec \"$@\"\n"), 0755))
+	sh, err := exec.LookPath("sh")
+	require.NoError(t, err)
+	args := []string{"/opt/java/bin/java", "-Xmx1g", "Main"}
+
+	for i, currCase := range []struct {
+		prefix         []string
+		wantExecutable string
+		wantArgs       []string
+	}{
+		{prefix: nil, wantExecutable: "/opt/java/bin/java", wantArgs: args},
+		{
+			prefix:         []string{"bin/wrapper", "--cpunodebind=0"},
+			wantExecutable: wrapper,
+			wantArgs:       []string{wrapper, "--cpunodebind=0", "/opt/java/bin/java", "-Xmx1g", "Main"},
+		},
+		{
+			prefix:         []string{"sh", "-c", `exec "$0" "$@"`},
+			wantExecutable: sh,
+			wantArgs:       []string{sh, "-c", `exec "$0" "$@"`, "/opt/java/bin/java", "-Xmx1g", "Main"},
+		},
+	} {
+		executable, prefixedArgs, err := prefixCommand(currCase.prefix, "/opt/java/bin/java", args, workingDir)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.wantExecutable, executable, "Case %d", i)
+		assert.Equal(t, currCase.wantArgs, prefixedArgs, "Case %d", i)
+	}
+
+	_, _, err = prefixCommand([]string{"no-such-prefix-command"}, "/opt/java/bin/java", args, workingDir)
+	assert.EqualError(t, err, `failed to find the command of commandPrefix: exec: "no-such-prefix-command": `+
+		"executable file not found in $PATH")
+}
+
+func TestValidateCommandPrefix(t *testing.T) {
+	assert.NoError(t, validateCommandPrefix(nil))
+	assert.NoError(t, validateCommandPrefix([]string{"numactl", "--interleave=all"}))
+	assert.EqualError(t, validateCommandPrefix([]string{" ", "--interleave=all"}),
+		"the first element of commandPrefix must be the command to run")
+}
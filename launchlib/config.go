@@ -18,10 +18,16 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/url"
+	"os"
 	"path"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"gopkg.in/validator.v2"
@@ -33,6 +39,7 @@ var (
 )
 
 type VersionedConfig struct {
+	// Version is the configVersion the config was written in, which is migrated to LatestConfigVersion when read.
 	Version int `yaml:"configVersion"`
 }
 
@@ -45,15 +52,253 @@ type JavaConfig struct {
 	MainClass string   `yaml:"mainClass" validate:"nonzero"`
 	JvmOpts   []string `yaml:"jvmOpts"`
 	Classpath []string `yaml:"classpath" validate:"nonzero"`
+	// JavaVersion selects the newest java installation in JdkDir with a matching major version instead of JavaHome.
+	JavaVersion string `yaml:"javaVersion"`
+	JdkDir      string `yaml:"jdkDir"`
+	// JavaExecutable is the path of the executable within the java installation that launches the process, bin/java
+	// by default, for java installations whose vendors provide another launcher.
+	JavaExecutable string `yaml:"javaExecutable"`
+	// MainModule is launched with -m <module>[/<main class>] instead of MainClass, using ModulePath over Classpath.
+	MainModule string         `yaml:"mainModule"`
+	ModulePath []string       `yaml:"modulePath"`
+	AddModules []string       `yaml:"addModules"`
+	AddOpens   []ModuleAccess `yaml:"addOpens"`
+	AddExports []ModuleAccess `yaml:"addExports"`
+	// JarPath is launched with -jar instead of MainClass, taking its main class and classpath from its manifest.
+	JarPath string `yaml:"jarPath"`
+	// NativeImage is the path of a GraalVM native image of the service, relative to the working directory unless
+	// absolute, that is launched with the jvmOpts instead of a JVM, without a java installation or classpath.
+	NativeImage string `yaml:"nativeImage"`
+	// Agents are passed as -javaagent options before JvmOpts.
+	Agents []JavaAgentConfig `yaml:"agents"`
+	// HeapPercentage is the percentage of the memory limit of the container used to size the heap, unless the jvmOpts
+	// already size it. Zero disables automatic heap sizing.
+	HeapPercentage float64 `yaml:"heapPercentage"`
+	// Memory sizes the heap relative to the memory available on any host, in place of HeapPercentage.
+	Memory *MemoryConfig `yaml:"memory,omitempty"`
+	// VersionedJvmOpts are the options of the jvmOpts-java-* blocks, passed after JvmOpts if the java version matches.
+	VersionedJvmOpts []VersionedJvmOpts `yaml:"-"`
+	// DisableActiveProcessorCount disables setting -XX:ActiveProcessorCount from the CPU limit of the container.
+	DisableActiveProcessorCount bool `yaml:"disableActiveProcessorCount"`
+	// Diagnostics makes the JVM write heap dumps and fatal error logs to a directory that is pruned at launch.
+	Diagnostics *DiagnosticsConfig `yaml:"diagnostics,omitempty"`
+	// GCLogging logs garbage collections to var/log/gc.log with rotation, using the options of the java version,
+	// unless the jvmOpts already configure GC logging.
+	GCLogging bool `yaml:"gcLogging"`
+	// Debug enables remote debugging of the JVM, which DebugPortEnvVar also enables for the primary process.
+	Debug *DebugConfig `yaml:"debug,omitempty"`
+	// JMX enables the remote JMX agent of the JVM.
+	JMX *JMXConfig `yaml:"jmx,omitempty"`
+	// StrictJvmOpts fails the launch if the jvmOpts contain options of the same family with different values, such as
+	// two -Xmx values, rather than only warning that the last one wins.
+	StrictJvmOpts bool `yaml:"strictJvmOpts"`
+	// UnsafeJvmOptsDenylist are the prefixes of the options that the jvmOpts of the custom configuration must not
+	// contain, DefaultUnsafeJvmOptsDenylist unless set. If JvmOptsAllowlist is set, they may only contain the options
+	// with one of its prefixes.
+	UnsafeJvmOptsDenylist []string `yaml:"unsafeJvmOptsDenylist"`
+	JvmOptsAllowlist      []string `yaml:"jvmOptsAllowlist"`
+	// JvmOptsEnvVar is the name of an environment variable of the launcher, such as JAVA_OPTS, whose whitespace-separated
+	// options are appended to the jvmOpts after the custom jvmOpts, subject to the same checks, and which is not passed
+	// on to the process.
+	JvmOptsEnvVar string `yaml:"jvmOptsEnvVar"`
+	// ArgFile passes the JVM options, module options and classpath to java 9 or later in an @argfile readable only by
+	// the process, rather than on its command line where any user of the host can read them.
+	ArgFile bool `yaml:"argFile"`
+	// SecretProperties are the names of the system properties set by the jvmOpts whose -D options are passed to java
+	// 9 or later in an @argfile readable only by the process, rather than on its command line, and are redacted.
+	SecretProperties []string `yaml:"secretProperties"`
 }
 
 type StaticLauncherConfig struct {
 	TypedConfig `yaml:",inline"`
 	JavaConfig  `yaml:",inline"`
-	Env         map[string]string `yaml:"env"`
-	Executable  string            `yaml:"executable,omitempty"`
-	Args        []string          `yaml:"args"`
-	Dirs        []string          `yaml:"dirs"`
+	Env         map[string]string  `yaml:"env"`
+	Executable  string             `yaml:"executable,omitempty"`
+	Args        []string           `yaml:"args"`
+	Dirs        []DirConfig        `yaml:"dirs"`
+	HealthCheck *HealthCheckConfig `yaml:"healthCheck,omitempty"`
+	// CommandPrefix is the command, with its arguments, that the process is run under, such as numactl or strace,
+	// which is given the assembled invocation of the process as its last arguments. Tracking the pid of the process
+	// requires that the prefix command executes it in its place, as numactl, chrt and taskset do.
+	CommandPrefix []string `yaml:"commandPrefix"`
+	// TmpDir is the temporary directory of the process, passed to it as TMPDIR and, for java, -Djava.io.tmpdir.
+	TmpDir *TmpDirConfig `yaml:"tmpDir,omitempty"`
+	// Locale is the timezone, locale and file encoding of the process, in place of those of the host.
+	Locale *LocaleConfig `yaml:"locale,omitempty"`
+	// LivenessCheck is checked every Interval by 'go-init supervise' while the process runs. A process that does not
+	// pass it for MaxWait, including once started, is considered hung, so is killed and restarted.
+	LivenessCheck *HealthCheckConfig `yaml:"livenessCheck,omitempty"`
+	// Stop is how 'go-init stop' asks the process to stop before sending it SIGTERM, if set.
+	Stop *StopConfig `yaml:"stop,omitempty"`
+	// StopTimeout is the time 'go-init' gives the process to exit after sending it SIGTERM before it sends SIGKILL, 240
+	// seconds if zero.
+	StopTimeout time.Duration `yaml:"stopTimeout"`
+	// OutputMode is where 'go-init' writes the stdout and stderr of the process, one of the OutputMode constants,
+	// defaulting to OutputModeFile.
+	OutputMode string `yaml:"outputMode"`
+	// SeparateStderr makes 'go-init' write the stderr of the process to its own file alongside its output file, such
+	// as var/log/startup-error.log, or to StderrFile if set, rather than interleaving it with its stdout.
+	SeparateStderr bool   `yaml:"separateStderr"`
+	StderrFile     string `yaml:"stderrFile"`
+	// OutputFile is the file 'go-init' writes the output of a subProcess to in place of var/log/<name>-startup.log.
+	// The output file of the primary process is set by the --output-file flag of 'go-init' instead.
+	OutputFile string `yaml:"outputFile"`
+	// StartupWindow is how long 'go-init start' watches the process after starting it, failing if it exits within
+	// that time. Zero disables the check.
+	StartupWindow time.Duration `yaml:"startupWindow"`
+	// StartRetries is how many times 'go-init start' starts the process again, RetryDelay after it exits, if it exits
+	// within its StartupWindow. RetryDelay replaces retryDelaySeconds of configVersion 1.
+	StartRetries int           `yaml:"startRetries"`
+	RetryDelay   time.Duration `yaml:"retryDelay"`
+	// StartupPattern is a regular expression that 'go-init start' waits up to StartupPatternTimeout, or
+	// DefaultStartupPatternTimeout if unset, for a line of the output of the process to match before reporting it
+	// started, such as a "Started in 12.3s" line.
+	StartupPattern        string        `yaml:"startupPattern"`
+	StartupPatternTimeout time.Duration `yaml:"startupPatternTimeout"`
+	// Daemonizes is whether the process forks the service process and exits, as wrapper scripts that double-fork do,
+	// in which case 'go-init' tracks the process left running as the process.
+	Daemonizes bool `yaml:"daemonizes"`
+	// CleanEnv starts the process with only the environment variables matching one of the EnvPassthrough patterns,
+	// along with those of Env, rather than the whole environment of the launcher.
+	CleanEnv       bool     `yaml:"cleanEnv"`
+	EnvPassthrough []string `yaml:"envPassthrough"`
+	// EnvFiles are files of KEY=VALUE lines whose variables are set in the environment, overridden by those of Env.
+	EnvFiles []string `yaml:"envFiles"`
+	// EnvFromFiles maps environment variables to files, such as mounted secrets, whose content is read when launching
+	// and takes precedence over Env and EnvFiles. The values are redacted from dry runs.
+	EnvFromFiles map[string]string `yaml:"envFromFiles"`
+	// SensitiveKeys are patterns of the names of environment variables, system properties and key=value arguments
+	// whose values are redacted from the output of the launcher, in addition to DefaultSensitiveKeys.
+	SensitiveKeys []string `yaml:"sensitiveKeys"`
+	// User and Group are the user and group, as names or numeric ids, to run the process as if the launcher runs as
+	// root. The group defaults to the primary group of the user.
+	User  string `yaml:"user"`
+	Group string `yaml:"group"`
+	// Rlimits are the resource limits of the process by name, e.g. nofile, each of the form <limit> or
+	// <soft limit>:<hard limit> where a limit is an integer or "unlimited".
+	Rlimits map[string]string `yaml:"rlimits"`
+	// CoreDumps sets the core rlimit of the process and collects the core files of its crashes at launch.
+	CoreDumps *CoreDumpsConfig `yaml:"coreDumps,omitempty"`
+	// Umask is the umask of the process, e.g. 027, which is inherited from the launcher if unset.
+	Umask *os.FileMode `yaml:"umask"`
+	// Nice is the CPU niceness of the process, from -20 to 19. IOClass and IOPriority are its IO scheduling class, one
+	// of realtime, best-effort or idle, and its priority within the class from 0 to 7. OOMScoreAdj, from -1000 to 1000,
+	// makes the OOM killer more or less likely to kill it. Each is inherited from the launcher if unset.
+	Nice        *int   `yaml:"nice"`
+	IOClass     string `yaml:"ioClass"`
+	IOPriority  *int   `yaml:"ioPriority"`
+	OOMScoreAdj *int   `yaml:"oomScoreAdj"`
+	// CPUSet is the list of CPUs the process is pinned to, e.g. 0-3,8, which is inherited from the launcher if unset.
+	CPUSet string `yaml:"cpuSet"`
+	// Capabilities limits the Linux capabilities of the process, which requires the launcher to run as root.
+	Capabilities *CapabilitiesConfig `yaml:"capabilities,omitempty"`
+	// Cgroup is the cgroup v2 the process is moved into, which is created with the given limits.
+	Cgroup *CgroupConfig `yaml:"cgroup,omitempty"`
+	// WorkingDirectory is the directory the process runs in, relative to that of the launcher unless absolute, against
+	// which the relative paths of the config other than dirs are resolved.
+	WorkingDirectory string `yaml:"workingDirectory"`
+	// Chroot is the directory, relative to WorkingDirectory unless absolute, that the process is chrooted into. Its
+	// executable must be within it.
+	Chroot         string                `yaml:"chroot"`
+	MountNamespace *MountNamespaceConfig `yaml:"mountNamespace,omitempty"`
+	// Hooks are the commands that 'go-init' runs before and after starting the process and before stopping it.
+	Hooks HooksConfig `yaml:"hooks"`
+	// DependsOn are the endpoints that must be reachable, and the processes of the service that must have started,
+	// before the process is launched.
+	DependsOn []DependencyConfig `yaml:"dependsOn"`
+	// OutputStreams are the files, such as an audit log, passed to the process as file descriptors from 3 up. They are
+	// not taken from defaults, as each process writes its own.
+	OutputStreams []OutputStreamConfig `yaml:"outputStreams"`
+	// Ports are the TCP ports the process listens on, which 'go-init' checks are free before starting it. They are not
+	// taken from defaults, as no two processes may listen on the same port.
+	Ports []int `yaml:"ports"`
+	// ReloadSignal is the signal, e.g. SIGHUP, that 'go-init reload' sends the process to reload its configuration.
+	ReloadSignal string `yaml:"reloadSignal"`
+}
+
+const (
+	// OutputModeFile writes the output of the process to its output file, such as var/log/startup.log.
+	OutputModeFile = "file"
+	// OutputModeConsole writes the output of the process to that of 'go-init'.
+	OutputModeConsole = "console"
+	// OutputModeSyslog writes the output of the process to syslog with the 'logger' command.
+	OutputModeSyslog = "syslog"
+	// OutputModeJournald writes the output of the process to the systemd journal.
+	OutputModeJournald = "journald"
+)
+
+// validateOutputMode validates that the outputMode is known, and that stderr is only separated into a file when
+// the output is written to files.
+func validateOutputMode(config *StaticLauncherConfig) error {
+	switch config.OutputMode {
+	case "", OutputModeFile:
+		return nil
+	case OutputModeConsole, OutputModeSyslog, OutputModeJournald:
+		if config.SeparateStderr || config.StderrFile != "" {
+			return errors.Errorf("separateStderr and stderrFile can only be set with outputMode %s, as stderr is "+
+				"always separated with outputMode %s", OutputModeFile, config.OutputMode)
+		}
+		if config.OutputFile != "" {
+			return errors.Errorf("outputFile can only be set with outputMode %s", OutputModeFile)
+		}
+		return nil
+	}
+	return errors.Errorf("outputMode must be one of %s, %s, %s or %s, found '%s'", OutputModeFile,
+		OutputModeConsole, OutputModeSyslog, OutputModeJournald, config.OutputMode)
+}
+
+// DirConfig is a directory that is created before the process is launched, given in the config as either its path or
+// a mapping of its path, mode and owner. The path must be relative to the working directory and over [A-Za-z0-9].
+type DirConfig struct {
+	Path string `yaml:"path"`
+	// Mode is the permissions the directory is created with, or changed to if it exists, such as 0750. New directories
+	// are created with 0700 if it is zero.
+	Mode os.FileMode `yaml:"mode"`
+	// Owner is the user and, after a colon, the group that own the directory, as names or numeric ids, such as
+	// my-service:adm. The group defaults to the primary group of the user. If unset, the directory is owned by the
+	// user and group the process runs as.
+	Owner string `yaml:"owner"`
+}
+
+// UnmarshalYAML reads the DirConfig from either its path or a mapping of its fields.
+func (config *DirConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&config.Path); err == nil {
+		return nil
+	}
+	type plainDirConfig DirConfig
+	return unmarshal((*plainDirConfig)(config))
+}
+
+func (config DirConfig) String() string {
+	var attributes []string
+	if config.Mode != 0 {
+		attributes = append(attributes, fmt.Sprintf("mode %04o", uint32(config.Mode)))
+	}
+	if config.Owner != "" {
+		attributes = append(attributes, "owner "+config.Owner)
+	}
+	if len(attributes) == 0 {
+		return config.Path
+	}
+	return fmt.Sprintf("%s (%s)", config.Path, strings.Join(attributes, ", "))
+}
+
+func (config DirConfig) validate() error {
+	if config.Path == "" {
+		return errors.New("dirs must have a path")
+	}
+	return validateMode(fmt.Sprintf("mode of directory %s", config.Path), config.Mode)
+}
+
+// HealthCheckConfig configures how 'go-init start' determines that a process has started successfully, by either
+// requesting a URL until it responds with a 2xx status or connecting to a TCP port on localhost until it accepts
+// connections. Zero durations are replaced by the defaults in DefaultHealthCheckConfig.
+type HealthCheckConfig struct {
+	URL      string        `yaml:"url"`
+	Port     int           `yaml:"port"`
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+	MaxWait  time.Duration `yaml:"maxWait"`
 }
 
 type PrimaryStaticLauncherConfig struct {
@@ -61,8 +306,142 @@ type PrimaryStaticLauncherConfig struct {
 	ServiceName          string `yaml:"serviceName"`
 	StaticLauncherConfig `yaml:",inline"`
 	SubProcesses         map[string]StaticLauncherConfig `yaml:"subProcesses"`
+	Supervision          SupervisionConfig               `yaml:"supervision"`
+	OutputRotation       OutputRotationConfig            `yaml:"outputRotation"`
+	Metrics              MetricsConfig                   `yaml:"metrics"`
+	ResourceLog          ResourceLogConfig               `yaml:"resourceLog"`
+	Notifications        NotificationsConfig             `yaml:"notifications"`
+	// Defaults provides values for the primary process and each subProcess that they do not set themselves.
+	Defaults StaticLauncherConfig `yaml:"defaults"`
+	// Profiles are the profiles of the service by name, of which the one selected by Profile is applied when the
+	// config is read.
+	Profiles map[string]ProfileConfig `yaml:"profiles"`
+	// Profile is the name of the profile that was applied, if any.
+	Profile string `yaml:"-"`
+	// FileMode and DirMode are the permissions of the pid, state and output files, and of their directories, created
+	// by 'go-init'. Zero values are replaced by DefaultFileMode and DefaultDirMode.
+	FileMode os.FileMode `yaml:"fileMode"`
+	DirMode  os.FileMode `yaml:"dirMode"`
+	// StatusExitCodes is the scheme of the exit codes of 'go-init status', one of the StatusExitCodes constants,
+	// defaulting to StatusExitCodesLSB.
+	StatusExitCodes string `yaml:"statusExitCodes"`
+	// ExecMode is how 'go-init run' runs the primary process, one of the ExecMode constants, defaulting to
+	// ExecModeFork.
+	ExecMode string `yaml:"execMode"`
+	// StartupTimeout bounds the time 'go-init start' takes to start the processes in the order of their dependencies
+	// and wait for their health checks, which is unbounded if zero.
+	StartupTimeout time.Duration `yaml:"startupTimeout"`
+	// AllowedExecutables are the file names of the executables, such as node, that the processes of configType
+	// executable may run in addition to those allowed by default.
+	AllowedExecutables []string `yaml:"allowedExecutables"`
+	// Verify are the files whose checksums or signatures are verified before the service is launched, which is refused
+	// if any of them does not match.
+	Verify []VerifyConfig `yaml:"verify"`
+	// Preflight are the checks of the host made before the service is started.
+	Preflight PreflightConfig `yaml:"preflight"`
+	// StopOrder is the order in which 'go-init' stops the processes, one of the StopOrder constants, defaulting to
+	// StopOrderParallel.
+	StopOrder string `yaml:"stopOrder"`
+	// Plugins are run by 'go-init' on the launch events of the service.
+	Plugins PluginsConfig `yaml:"plugins"`
+}
+
+const (
+	// StatusExitCodesLSB are the exit codes of the status action of LSB init scripts: 0 if the service is running, 1
+	// if it is dead but a pidfile exists, 3 if it is not running and 4 if its status is unknown.
+	StatusExitCodesLSB = "lsb"
+	// StatusExitCodesMonit are 0 if the service is running and 1 otherwise, as the program checks of monit only tell
+	// zero from nonzero exit codes.
+	StatusExitCodesMonit = "monit"
+	// StatusExitCodesS6 are those of s6-svstat: 0 if the service is running, 1 if it is not and 111 if its status is
+	// unknown.
+	StatusExitCodesS6 = "s6"
+)
+
+const (
+	// ExecModeFork runs the primary process as a child of 'go-init run', which forwards signals to it.
+	ExecModeFork = "fork"
+	// ExecModeExec replaces 'go-init run' with the primary process, so that the process supervisor sees and signals
+	// the process directly. Not supported with subProcesses, which nothing would stop once the primary process exits.
+	ExecModeExec = "exec"
+)
+
+// SupervisionConfig configures how processes are restarted when run under 'go-init supervise'. Unset values are
+// replaced by the defaults in DefaultSupervisionConfig.
+type SupervisionConfig struct {
+	// MaxRestarts is the number of restarts of a single process allowed within RestartWindow before giving up. It is a
+	// pointer so that an explicit 0, which gives up as soon as a process first exits, is told apart from unset.
+	MaxRestarts   *int          `yaml:"maxRestarts"`
+	RestartWindow time.Duration `yaml:"restartWindow"`
+	// InitialBackoff is the delay before the first restart, which doubles on each restart within RestartWindow up to
+	// MaxBackoff.
+	InitialBackoff time.Duration `yaml:"initialBackoff"`
+	MaxBackoff     time.Duration `yaml:"maxBackoff"`
+	// HeartbeatFile is touched every HeartbeatInterval while the supervisor runs, so that external monitors can tell
+	// that it has not hung, and removed once it exits. No heartbeat is written unless it is set.
+	HeartbeatFile     string        `yaml:"heartbeatFile"`
+	HeartbeatInterval time.Duration `yaml:"heartbeatInterval"`
+}
+
+// OutputRotationConfig configures how 'go-init' rotates the output files of the processes, keeping the rotated files
+// alongside them as <file>.1, the most recent, to <file>.<MaxFiles>. Rotation is disabled unless MaxFiles is set.
+type OutputRotationConfig struct {
+	MaxFiles int `yaml:"maxFiles"`
+	// MaxSize is the size, e.g. 100M, above which an output file is rotated whenever 'go-init' runs, in addition to
+	// being rotated each time its process is started.
+	MaxSize string `yaml:"maxSize"`
+	// MaxAge is the age after which rotated files are removed, even if fewer than MaxFiles are kept.
+	MaxAge time.Duration `yaml:"maxAge"`
+}
+
+// MetricsConfig configures the metrics of the processes that 'go-init supervise' writes in the textfile format of the
+// node_exporter. Metrics are disabled unless TextfilePath is set.
+type MetricsConfig struct {
+	// TextfilePath is the file the metrics are written to, which must have the .prom extension to be collected.
+	TextfilePath string `yaml:"textfilePath"`
+	// Interval is how often the metrics are written, replaced by DefaultMetricsConfig.Interval if zero.
+	Interval time.Duration `yaml:"interval"`
+}
+
+// ResourceLogConfig configures the resource usage of the processes that 'go-init supervise' samples every Interval into
+// a log alongside the output files, for hosts without a metrics agent. Sampling is disabled unless Interval is set.
+type ResourceLogConfig struct {
+	Interval time.Duration `yaml:"interval"`
+	// Format is the format of the log, one of the ResourceLogFormat constants, defaulting to ResourceLogFormatCSV.
+	Format string `yaml:"format"`
+}
+
+const (
+	// ResourceLogFormatCSV logs the samples to resources.csv, with a header naming the columns.
+	ResourceLogFormatCSV = "csv"
+	// ResourceLogFormatJSON logs each sample to resources.json as a JSON object on its own line.
+	ResourceLogFormatJSON = "json"
+)
+
+// NotificationsConfig configures the notifications that 'go-init' sends as it starts, stops and restarts the processes
+// and sees them crash, for hosts without a monitoring stack. Notifications are disabled unless URL or Command is set.
+type NotificationsConfig struct {
+	// URL is the webhook that the JSON payload of each event is POSTed to.
+	URL string `yaml:"url"`
+	// Command is run with the JSON payload of each event on its stdin, as an alternative to URL.
+	Command []string `yaml:"command"`
+	// Events are the events that are notified, of the NotificationEvent constants, defaulting to all of them.
+	Events []string `yaml:"events"`
+	// Timeout bounds the delivery of each notification, replaced by DefaultNotificationsConfig.Timeout if zero.
+	Timeout time.Duration `yaml:"timeout"`
 }
 
+const (
+	// NotificationEventStart is notified when a process has been started.
+	NotificationEventStart = "start"
+	// NotificationEventStop is notified when a process has been stopped.
+	NotificationEventStop = "stop"
+	// NotificationEventCrash is notified when a process exits without having been stopped.
+	NotificationEventCrash = "crash"
+	// NotificationEventRestart is notified when a process that crashed has been started again.
+	NotificationEventRestart = "restart"
+)
+
 type CustomLauncherConfig struct {
 	TypedConfig `yaml:",inline"`
 	JvmOpts     []string          `yaml:"jvmOpts"`
@@ -73,6 +452,9 @@ type PrimaryCustomLauncherConfig struct {
 	VersionedConfig      `yaml:",inline"`
 	CustomLauncherConfig `yaml:",inline"`
 	SubProcesses         map[string]CustomLauncherConfig `yaml:"subProcesses"`
+	// Includes are the paths of further custom configs, relative to the including file unless absolute, that are
+	// merged before the including file so that its own values take precedence.
+	Includes []string `yaml:"includes"`
 }
 
 type AllowedLauncherConfigValues struct {
@@ -83,7 +465,7 @@ type AllowedLauncherConfigValues struct {
 
 var allowedLauncherConfigs = AllowedLauncherConfigValues{
 	ConfigTypes:    map[string]struct{}{"java": {}, "executable": {}},
-	ConfigVersions: map[int]struct{}{1: {}},
+	ConfigVersions: map[int]struct{}{1: {}, 2: {}},
 	Executables: map[string]struct{}{
 		"java":           {},
 		"postgres":       {},
@@ -92,6 +474,44 @@ var allowedLauncherConfigs = AllowedLauncherConfigValues{
 		"envoy":          {}},
 }
 
+// StrictKeys makes reading a config of configVersion 1 fail on keys that are not part of the configuration format, such
+// as misspelled keys, as reading a config of a later configVersion always does.
+var StrictKeys = false
+
+// strictKeysConfigVersion is the first configVersion whose configs are read as if StrictKeys were set.
+const strictKeysConfigVersion = 2
+
+var defaultMaxRestarts = 5
+
+var DefaultSupervisionConfig = SupervisionConfig{
+	MaxRestarts:       &defaultMaxRestarts,
+	RestartWindow:     5 * time.Minute,
+	InitialBackoff:    time.Second,
+	MaxBackoff:        time.Minute,
+	HeartbeatInterval: 10 * time.Second,
+}
+
+var DefaultMetricsConfig = MetricsConfig{
+	Interval: 15 * time.Second,
+}
+
+var DefaultNotificationsConfig = NotificationsConfig{
+	Events: []string{NotificationEventStart, NotificationEventStop, NotificationEventCrash,
+		NotificationEventRestart},
+	Timeout: 5 * time.Second,
+}
+
+const (
+	DefaultFileMode os.FileMode = 0644
+	DefaultDirMode  os.FileMode = 0755
+)
+
+var DefaultHealthCheckConfig = HealthCheckConfig{
+	Interval: time.Second,
+	Timeout:  time.Second,
+	MaxWait:  time.Minute,
+}
+
 func GetConfigsFromFiles(
 	staticConfigFile string, customConfigFile string, stdout io.Writer) (
 	PrimaryStaticLauncherConfig, PrimaryCustomLauncherConfig, error) {
@@ -123,13 +543,6 @@ func GetConfigsFromFiles(
 	return staticConfig, customConfig, verifyStaticWithCustomConfig(staticConfig, customConfig)
 }
 
-func validateSubProcessLimit(numberSubProcesses int) error {
-	if numberSubProcesses > 1 {
-		return errors.New("only one named subProcesses is currently allowed")
-	}
-	return nil
-}
-
 func validateProcessName(name string) error {
 	if !processNamePattern.MatchString(name) {
 		return errors.Errorf("process name '%s' does not match required pattern '%s'", name, processNamePattern)
@@ -138,14 +551,47 @@ func validateProcessName(name string) error {
 }
 
 func parseStaticConfig(yamlString []byte) (PrimaryStaticLauncherConfig, error) {
+	var versioned VersionedConfig
+	if err := yaml.Unmarshal(yamlString, &versioned); err != nil {
+		return PrimaryStaticLauncherConfig{},
+			errors.Wrap(err, "Failed to deserialize Static Launcher Config, please check the syntax of "+
+				"your configuration file")
+	}
+	if err := versioned.validateVersion(allowedLauncherConfigs.ConfigVersions); err != nil {
+		return PrimaryStaticLauncherConfig{}, err
+	}
+	if versioned.Version >= strictKeysConfigVersion || StrictKeys {
+		if err := unknownKeysError(yamlString, staticConfigMigrations,
+			reflect.TypeOf(PrimaryStaticLauncherConfig{})); err != nil {
+			return PrimaryStaticLauncherConfig{}, errors.Wrap(err, "invalid static config")
+		}
+	}
+	yamlString, err := upgradeConfig(yamlString, versioned.Version, staticConfigMigrations)
+	if err != nil {
+		return PrimaryStaticLauncherConfig{}, err
+	}
+
 	var config PrimaryStaticLauncherConfig
 	if err := yaml.Unmarshal(yamlString, &config); err != nil {
 		return PrimaryStaticLauncherConfig{},
 			errors.Wrap(err, "Failed to deserialize Static Launcher Config, please check the syntax of "+
 				"your configuration file")
 	}
+	config.VersionedConfig = versioned
 
-	if err := config.VersionedConfig.validateVersion(allowedLauncherConfigs.ConfigVersions); err != nil {
+	if err := parseVersionedJvmOpts(yamlString, &config); err != nil {
+		return PrimaryStaticLauncherConfig{}, err
+	}
+
+	config.StaticLauncherConfig.applyDefaults(config.Defaults)
+	for name, subProcess := range config.SubProcesses {
+		subProcess.applyDefaults(config.Defaults)
+		config.SubProcesses[name] = subProcess
+	}
+	if err := validateProfiles(config); err != nil {
+		return PrimaryStaticLauncherConfig{}, errors.Wrap(err, "invalid profiles")
+	}
+	if err := config.applyProfile(Profile); err != nil {
 		return PrimaryStaticLauncherConfig{}, err
 	}
 
@@ -154,13 +600,66 @@ func parseStaticConfig(yamlString []byte) (PrimaryStaticLauncherConfig, error) {
 			errors.Wrapf(err, "invalid service name '%s' in static config", config.ServiceName)
 	}
 
-	if err := validateStaticConfig(&config.StaticLauncherConfig); err != nil {
+	if err := validateAllowedExecutables(config.AllowedExecutables); err != nil {
+		return PrimaryStaticLauncherConfig{}, err
+	}
+	// The output file of the primary process is shared with the go-init commands through --output-file, and one taken
+	// from the defaults would be shared by every subProcess.
+	if config.OutputFile != "" || config.Defaults.OutputFile != "" {
+		return PrimaryStaticLauncherConfig{}, errors.New("outputFile can only be set for subProcesses, the output " +
+			"file of the primary process is set by the --output-file flag of go-init")
+	}
+	if err := validateStaticConfig(&config.StaticLauncherConfig, config.AllowedExecutables); err != nil {
 		return PrimaryStaticLauncherConfig{}, err
 	}
 
-	if err := validateSubProcessLimit(len(config.SubProcesses)); err != nil {
+	if err := config.Supervision.validate(); err != nil {
+		return PrimaryStaticLauncherConfig{}, errors.Wrap(err, "invalid supervision config")
+	}
+	if err := config.OutputRotation.validate(); err != nil {
+		return PrimaryStaticLauncherConfig{}, errors.Wrap(err, "invalid outputRotation config")
+	}
+	if err := config.Metrics.validate(); err != nil {
+		return PrimaryStaticLauncherConfig{}, errors.Wrap(err, "invalid metrics config")
+	}
+	if err := config.ResourceLog.validate(); err != nil {
+		return PrimaryStaticLauncherConfig{}, errors.Wrap(err, "invalid resourceLog config")
+	}
+	if err := config.Notifications.validate(); err != nil {
+		return PrimaryStaticLauncherConfig{}, errors.Wrap(err, "invalid notifications config")
+	}
+
+	if err := validateMode("fileMode", config.FileMode); err != nil {
+		return PrimaryStaticLauncherConfig{}, err
+	}
+	if err := validateMode("dirMode", config.DirMode); err != nil {
 		return PrimaryStaticLauncherConfig{}, err
 	}
+	switch config.StatusExitCodes {
+	case "", StatusExitCodesLSB, StatusExitCodesMonit, StatusExitCodesS6:
+	default:
+		return PrimaryStaticLauncherConfig{}, errors.Errorf("statusExitCodes must be one of %s, %s or %s, found '%s'",
+			StatusExitCodesLSB, StatusExitCodesMonit, StatusExitCodesS6, config.StatusExitCodes)
+	}
+	switch config.ExecMode {
+	case "", ExecModeFork:
+	case ExecModeExec:
+		if len(config.SubProcesses) > 0 {
+			return PrimaryStaticLauncherConfig{}, errors.Errorf("execMode %s is not supported with subProcesses",
+				ExecModeExec)
+		}
+		if config.Daemonizes {
+			return PrimaryStaticLauncherConfig{}, errors.Errorf("execMode %s is not supported with daemonizes",
+				ExecModeExec)
+		}
+		if len(config.OutputStreams) > 0 {
+			return PrimaryStaticLauncherConfig{}, errors.Errorf("execMode %s is not supported with outputStreams",
+				ExecModeExec)
+		}
+	default:
+		return PrimaryStaticLauncherConfig{}, errors.Errorf("execMode must be one of %s or %s, found '%s'",
+			ExecModeFork, ExecModeExec, config.ExecMode)
+	}
 
 	for name, subProcess := range config.SubProcesses {
 		if err := validateProcessName(name); err != nil {
@@ -173,30 +672,598 @@ func parseStaticConfig(yamlString []byte) (PrimaryStaticLauncherConfig, error) {
 				errors.Errorf("subProcess name '%s' cannot be the same as ServiceName", name)
 		}
 
-		if err := validateStaticConfig(&subProcess); err != nil {
+		if err := validateStaticConfig(&subProcess, config.AllowedExecutables); err != nil {
 			return PrimaryStaticLauncherConfig{},
 				errors.Wrapf(err, "failed to validate subProcess launcher configuration '%s'", name)
 		}
+		config.SubProcesses[name] = subProcess
+	}
+	if err := validateCleanedTmpDirs(config); err != nil {
+		return PrimaryStaticLauncherConfig{}, err
+	}
+	if err := validateOutputFiles(config.SubProcesses); err != nil {
+		return PrimaryStaticLauncherConfig{}, err
+	}
+	if err := validatePorts(config); err != nil {
+		return PrimaryStaticLauncherConfig{}, err
+	}
+	if config.StartupTimeout < 0 {
+		return PrimaryStaticLauncherConfig{}, errors.New("startupTimeout must not be negative")
+	}
+	if err := validateProcessDependencies(config); err != nil {
+		return PrimaryStaticLauncherConfig{}, err
+	}
+	for _, verify := range config.Verify {
+		if err := verify.validate(); err != nil {
+			return PrimaryStaticLauncherConfig{}, errors.Wrap(err, "invalid verify config")
+		}
+	}
+	if err := config.Preflight.validate(); err != nil {
+		return PrimaryStaticLauncherConfig{}, errors.Wrap(err, "invalid preflight config")
+	}
+	switch config.StopOrder {
+	case "", StopOrderParallel, StopOrderReverseDependencies:
+	default:
+		return PrimaryStaticLauncherConfig{}, errors.Errorf("stopOrder must be one of %s or %s, found '%s'",
+			StopOrderParallel, StopOrderReverseDependencies, config.StopOrder)
+	}
+	if err := config.Plugins.validate(); err != nil {
+		return PrimaryStaticLauncherConfig{}, errors.Wrap(err, "invalid plugins config")
 	}
 	return config, nil
 }
 
-func validateStaticConfig(config *StaticLauncherConfig) error {
+// validateProcessDependencies validates that the processes that the processes depend on exist, and that they do not
+// depend on each other in a cycle, which could never be started.
+func validateProcessDependencies(config PrimaryStaticLauncherConfig) error {
+	dependencies := map[string][]string{
+		config.ServiceName: ProcessDependencies(config.DependsOn),
+	}
+	for name, subProcess := range config.SubProcesses {
+		dependencies[name] = ProcessDependencies(subProcess.DependsOn)
+	}
+	names := make([]string, 0, len(dependencies))
+	for name, dependsOn := range dependencies {
+		for _, dependency := range dependsOn {
+			if dependency == name {
+				return errors.Errorf("process '%s' must not depend on itself", name)
+			}
+			if _, ok := dependencies[dependency]; !ok {
+				return errors.Errorf("process '%s' depends on process '%s', which is not configured", name,
+					dependency)
+			}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Each process is visited depth-first, with the processes on the current path marked as visiting.
+	const visiting, visited = 1, 2
+	state := map[string]int{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		path = append(path, name)
+		switch state[name] {
+		case visiting:
+			return errors.Errorf("processes must not depend on each other in a cycle, found %s",
+				strings.Join(path, " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dependency := range dependencies[name] {
+			if err := visit(dependency, path); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateOutputFiles validates that no two subProcesses write their output to the same outputFile.
+func validateOutputFiles(subProcesses map[string]StaticLauncherConfig) error {
+	names := make([]string, 0, len(subProcesses))
+	for name := range subProcesses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	writers := map[string]string{}
+	for _, name := range names {
+		outputFile := subProcesses[name].OutputFile
+		if outputFile == "" {
+			continue
+		}
+		outputFile = filepath.Clean(outputFile)
+		if other, ok := writers[outputFile]; ok {
+			return errors.Errorf("subProcesses %s and %s must not share the outputFile %s", other, name, outputFile)
+		}
+		writers[outputFile] = name
+	}
+	return nil
+}
+
+// validateCleanedTmpDirs validates that no process cleans a temporary directory that another process also uses, which
+// would remove the files of the other process whenever it is launched.
+func validateCleanedTmpDirs(config PrimaryStaticLauncherConfig) error {
+	processes := map[string]StaticLauncherConfig{config.ServiceName: config.StaticLauncherConfig}
+	for name, subProcess := range config.SubProcesses {
+		processes[name] = subProcess
+	}
+	owners := map[string][]string{}
+	cleaned := map[string]bool{}
+	for name, process := range processes {
+		if process.TmpDir == nil {
+			continue
+		}
+		dir := process.TmpDir.WithDefaults().dir(process.WorkingDirectory)
+		owners[dir] = append(owners[dir], name)
+		cleaned[dir] = cleaned[dir] || process.TmpDir.CleanOnStart
+	}
+	for dir, names := range owners {
+		if cleaned[dir] && len(names) > 1 {
+			sort.Strings(names)
+			return errors.Errorf("processes %s share the tmpDir %s, so it must not be cleaned on start",
+				strings.Join(names, ", "), dir)
+		}
+	}
+	return nil
+}
+
+// applyDefaults sets each value not set in the config to that of defaults. Environment variables, including those of
+// envFromFiles, and rlimits are merged, with those of the config taking precedence, and the core rlimit of coreDumps is
+// added to the rlimits unless they set it.
+func (config *StaticLauncherConfig) applyDefaults(defaults StaticLauncherConfig) {
+	if config.Type == "" {
+		config.Type = defaults.Type
+	}
+	// javaHome and javaVersion are alternatives, so neither is taken from the defaults if either is set.
+	if config.JavaHome == "" && config.JavaVersion == "" {
+		config.JavaHome = defaults.JavaHome
+		config.JavaVersion = defaults.JavaVersion
+	}
+	if config.JdkDir == "" {
+		config.JdkDir = defaults.JdkDir
+	}
+	if config.JavaExecutable == "" {
+		config.JavaExecutable = defaults.JavaExecutable
+	}
+	// mainClass, mainModule, jarPath and nativeImage are alternatives, so none is taken from the defaults if any is
+	// set.
+	if config.MainClass == "" && config.MainModule == "" && config.JarPath == "" && config.NativeImage == "" {
+		config.MainClass = defaults.MainClass
+		config.MainModule = defaults.MainModule
+		config.JarPath = defaults.JarPath
+		config.NativeImage = defaults.NativeImage
+	}
+	if config.JvmOpts == nil {
+		config.JvmOpts = defaults.JvmOpts
+	}
+	if config.Classpath == nil {
+		config.Classpath = defaults.Classpath
+	}
+	if config.ModulePath == nil {
+		config.ModulePath = defaults.ModulePath
+	}
+	if config.AddModules == nil {
+		config.AddModules = defaults.AddModules
+	}
+	if config.AddOpens == nil {
+		config.AddOpens = defaults.AddOpens
+	}
+	if config.AddExports == nil {
+		config.AddExports = defaults.AddExports
+	}
+	if config.Agents == nil {
+		config.Agents = defaults.Agents
+	}
+	if config.VersionedJvmOpts == nil {
+		config.VersionedJvmOpts = defaults.VersionedJvmOpts
+	}
+	// heapPercentage and memory are alternatives, so neither is taken from the defaults if either is set.
+	if config.HeapPercentage == 0 && config.Memory == nil {
+		config.HeapPercentage = defaults.HeapPercentage
+		config.Memory = defaults.Memory
+	}
+	if config.Diagnostics == nil {
+		config.Diagnostics = defaults.Diagnostics
+	}
+	if !config.GCLogging {
+		config.GCLogging = defaults.GCLogging
+	}
+	if config.Debug == nil {
+		config.Debug = defaults.Debug
+	}
+	if config.JMX == nil {
+		config.JMX = defaults.JMX
+	}
+	if !config.StrictJvmOpts {
+		config.StrictJvmOpts = defaults.StrictJvmOpts
+	}
+	if config.UnsafeJvmOptsDenylist == nil {
+		config.UnsafeJvmOptsDenylist = defaults.UnsafeJvmOptsDenylist
+	}
+	if config.JvmOptsAllowlist == nil {
+		config.JvmOptsAllowlist = defaults.JvmOptsAllowlist
+	}
+	if !config.DisableActiveProcessorCount {
+		config.DisableActiveProcessorCount = defaults.DisableActiveProcessorCount
+	}
+	if !config.ArgFile {
+		config.ArgFile = defaults.ArgFile
+	}
+	if config.SecretProperties == nil {
+		config.SecretProperties = defaults.SecretProperties
+	}
+	if config.Executable == "" {
+		config.Executable = defaults.Executable
+	}
+	if config.Args == nil {
+		config.Args = defaults.Args
+	}
+	if config.CommandPrefix == nil {
+		config.CommandPrefix = defaults.CommandPrefix
+	}
+	if config.Dirs == nil {
+		config.Dirs = defaults.Dirs
+	}
+	if config.TmpDir == nil {
+		config.TmpDir = defaults.TmpDir
+	}
+	if config.Locale == nil {
+		config.Locale = defaults.Locale
+	}
+	if !config.SeparateStderr {
+		config.SeparateStderr = defaults.SeparateStderr
+	}
+	if config.StderrFile == "" {
+		config.StderrFile = defaults.StderrFile
+	}
+	if config.OutputMode == "" {
+		config.OutputMode = defaults.OutputMode
+	}
+	if config.StartupWindow == 0 {
+		config.StartupWindow = defaults.StartupWindow
+	}
+	if config.StartRetries == 0 {
+		config.StartRetries = defaults.StartRetries
+	}
+	if config.RetryDelay == 0 {
+		config.RetryDelay = defaults.RetryDelay
+	}
+	if config.StartupPattern == "" {
+		config.StartupPattern = defaults.StartupPattern
+	}
+	if config.StartupPatternTimeout == 0 {
+		config.StartupPatternTimeout = defaults.StartupPatternTimeout
+	}
+	if !config.Daemonizes {
+		config.Daemonizes = defaults.Daemonizes
+	}
+	if !config.CleanEnv {
+		config.CleanEnv = defaults.CleanEnv
+	}
+	if config.EnvPassthrough == nil {
+		config.EnvPassthrough = defaults.EnvPassthrough
+	}
+	if config.EnvFiles == nil {
+		config.EnvFiles = defaults.EnvFiles
+	}
+	// user and group are taken from the defaults together, as the group depends on the user.
+	if config.User == "" && config.Group == "" {
+		config.User = defaults.User
+		config.Group = defaults.Group
+	}
+	if config.Umask == nil {
+		config.Umask = defaults.Umask
+	}
+	if config.Nice == nil {
+		config.Nice = defaults.Nice
+	}
+	// ioClass and ioPriority are taken from the defaults together, as the priority depends on the class.
+	if config.IOClass == "" && config.IOPriority == nil {
+		config.IOClass = defaults.IOClass
+		config.IOPriority = defaults.IOPriority
+	}
+	if config.OOMScoreAdj == nil {
+		config.OOMScoreAdj = defaults.OOMScoreAdj
+	}
+	if config.CPUSet == "" {
+		config.CPUSet = defaults.CPUSet
+	}
+	if config.Capabilities == nil {
+		config.Capabilities = defaults.Capabilities
+	}
+	if config.Cgroup == nil {
+		config.Cgroup = defaults.Cgroup
+	}
+	if config.WorkingDirectory == "" {
+		config.WorkingDirectory = defaults.WorkingDirectory
+	}
+	if config.Chroot == "" {
+		config.Chroot = defaults.Chroot
+	}
+	if config.MountNamespace == nil {
+		config.MountNamespace = defaults.MountNamespace
+	}
+	if config.Hooks.PreStart == nil {
+		config.Hooks.PreStart = defaults.Hooks.PreStart
+	}
+	if config.Hooks.PostStart == nil {
+		config.Hooks.PostStart = defaults.Hooks.PostStart
+	}
+	if config.Hooks.PreStop == nil {
+		config.Hooks.PreStop = defaults.Hooks.PreStop
+	}
+	if config.DependsOn == nil {
+		config.DependsOn = defaults.DependsOn
+	}
+	if config.ReloadSignal == "" {
+		config.ReloadSignal = defaults.ReloadSignal
+	}
+	if config.StopTimeout == 0 {
+		config.StopTimeout = defaults.StopTimeout
+	}
+	if config.SensitiveKeys == nil {
+		config.SensitiveKeys = defaults.SensitiveKeys
+	}
+	config.Env = merge(defaults.Env, config.Env)
+	config.EnvFromFiles = merge(defaults.EnvFromFiles, config.EnvFromFiles)
+	config.Rlimits = merge(defaults.Rlimits, config.Rlimits)
+	if config.CoreDumps == nil {
+		config.CoreDumps = defaults.CoreDumps
+	}
+	config.Rlimits = coreRlimits(config.CoreDumps, config.Rlimits)
+}
+
+func validateStaticConfig(config *StaticLauncherConfig, allowedExecutables []string) error {
 	if err := config.TypedConfig.validateType(allowedLauncherConfigs.ConfigTypes); err != nil {
 		return err
 	}
 
+	if config.StartupWindow < 0 {
+		return errors.New("startupWindow must not be negative")
+	}
+
+	if err := validateCommandPrefix(config.CommandPrefix); err != nil {
+		return err
+	}
+
+	for _, dir := range config.Dirs {
+		if err := dir.validate(); err != nil {
+			return err
+		}
+	}
+
+	if config.TmpDir != nil {
+		if err := config.TmpDir.validate(); err != nil {
+			return errors.Wrap(err, "invalid tmpDir config")
+		}
+	}
+
+	if config.Locale != nil {
+		if err := config.Locale.validate(); err != nil {
+			return errors.Wrap(err, "invalid locale config")
+		}
+	}
+
+	if err := validateOutputStreams(config.OutputStreams); err != nil {
+		return errors.Wrap(err, "invalid outputStreams config")
+	}
+
+	if config.StartRetries < 0 || config.RetryDelay < 0 {
+		return errors.New("startRetries and retryDelay must not be negative")
+	}
+
+	if err := validateEnvPassthrough(config.CleanEnv, config.EnvPassthrough); err != nil {
+		return err
+	}
+
+	if err := validateSensitiveKeys(config.SensitiveKeys); err != nil {
+		return err
+	}
+
+	if _, err := parseRlimits(config.Rlimits); err != nil {
+		return err
+	}
+
+	if config.CoreDumps != nil {
+		if err := config.CoreDumps.validate(); err != nil {
+			return errors.Wrap(err, "invalid coreDumps config")
+		}
+	}
+
+	if config.Umask != nil {
+		if err := validateMode("umask", *config.Umask); err != nil {
+			return err
+		}
+	}
+
+	if err := config.Priority().validate(); err != nil {
+		return err
+	}
+
+	if config.CPUSet != "" {
+		if _, err := parseCPUSet(config.CPUSet); err != nil {
+			return err
+		}
+	}
+
+	for name := range config.EnvFromFiles {
+		if !envFileKeyPattern.MatchString(name) {
+			return errors.Errorf("invalid environment variable name '%s' in envFromFiles", name)
+		}
+	}
+
+	if config.Capabilities != nil {
+		if err := config.Capabilities.validate(); err != nil {
+			return errors.Wrap(err, "invalid capabilities config")
+		}
+	}
+
+	if config.Cgroup != nil {
+		if err := config.Cgroup.validate(); err != nil {
+			return errors.Wrap(err, "invalid cgroup config")
+		}
+	}
+
+	for _, dependency := range config.DependsOn {
+		if err := dependency.validate(); err != nil {
+			return errors.Wrap(err, "invalid dependsOn config")
+		}
+	}
+
+	if err := config.Hooks.validate(); err != nil {
+		return errors.Wrap(err, "invalid hooks config")
+	}
+
+	if config.ReloadSignal != "" {
+		if _, err := ParseSignal(config.ReloadSignal); err != nil {
+			return errors.Wrap(err, "invalid reloadSignal")
+		}
+	}
+
+	if err := validateOutputMode(config); err != nil {
+		return err
+	}
+	if err := validateStartupPattern(config); err != nil {
+		return err
+	}
+
+	if config.HealthCheck != nil {
+		if err := config.HealthCheck.validate(); err != nil {
+			return errors.Wrap(err, "invalid healthCheck config")
+		}
+	}
+	if config.LivenessCheck != nil {
+		if err := config.LivenessCheck.validate(); err != nil {
+			return errors.Wrap(err, "invalid livenessCheck config")
+		}
+	}
+	if config.Stop != nil {
+		if err := config.Stop.validate(); err != nil {
+			return errors.Wrap(err, "invalid stop config")
+		}
+	}
+	if config.StopTimeout < 0 {
+		return errors.New("stopTimeout must not be negative")
+	}
+
 	if config.Type == "java" {
 		config.Executable = "java"
-		if err := validator.Validate(config.JavaConfig); err != nil {
+		if config.NativeImage != "" {
+			if err := validateNativeImage(config.JavaConfig); err != nil {
+				return err
+			}
+		} else if err := validateJavaMain(config.JavaConfig); err != nil {
+			return err
+		}
+		if err := validateJavaExecutable(config.JavaExecutable); err != nil {
+			return err
+		}
+		if err := validateModuleConfig(config.JavaConfig); err != nil {
+			return err
+		}
+		if config.ArgFile && config.NativeImage != "" {
+			return errors.New("argFile is not supported with nativeImage")
+		}
+		if config.ArgFile && config.Chroot != "" {
+			return errors.New("argFile is not supported with chroot")
+		}
+		if err := validateSecretProperties(config.JavaConfig, config.Chroot); err != nil {
 			return err
 		}
+		for _, agent := range config.Agents {
+			if err := agent.validate(); err != nil {
+				return errors.Wrap(err, "invalid agents")
+			}
+		}
+		if config.JavaVersion != "" {
+			if config.JavaHome != "" {
+				return errors.New("only one of javaHome and javaVersion may be set")
+			}
+			if _, err := parseJavaVersionSpec(config.JavaVersion); err != nil {
+				return err
+			}
+		}
+		if config.HeapPercentage < 0 || config.HeapPercentage > 100 {
+			return errors.Errorf("heapPercentage must be between 0 and 100, found %v", config.HeapPercentage)
+		}
+		if config.Memory != nil {
+			if config.HeapPercentage != 0 {
+				return errors.New("only one of heapPercentage and memory may be set")
+			}
+			if err := config.Memory.validate(); err != nil {
+				return errors.Wrap(err, "invalid memory config")
+			}
+		}
+		if config.Diagnostics != nil {
+			if err := config.Diagnostics.validate(); err != nil {
+				return errors.Wrap(err, "invalid diagnostics config")
+			}
+		}
+		if config.Debug != nil {
+			if err := config.Debug.validate(); err != nil {
+				return errors.Wrap(err, "invalid debug config")
+			}
+		}
+		if config.JMX != nil {
+			if err := config.JMX.validate(); err != nil {
+				return errors.Wrap(err, "invalid jmx config")
+			}
+		}
+		if err := validateJvmOptsLists(config.UnsafeJvmOptsDenylist, config.JvmOptsAllowlist); err != nil {
+			return err
+		}
+		if config.JvmOptsEnvVar != "" && !envFileKeyPattern.MatchString(config.JvmOptsEnvVar) {
+			return errors.Errorf("jvmOptsEnvVar %s is not a valid environment variable name", config.JvmOptsEnvVar)
+		}
+	}
+
+	return validateExecutableConfig(config.Executable, allowedExecutables)
+}
+
+// validateMode validates that the named mode only has permission bits, which are written in octal such as 0640.
+func validateMode(name string, mode os.FileMode) error {
+	if mode&^os.ModePerm != 0 {
+		return errors.Errorf("%s must be an octal permission mode such as 0640, with a leading 0, found %d", name,
+			uint32(mode))
 	}
+	return nil
+}
 
-	return validateExecutableConfig(config.Executable)
+// validateJavaMain validates that exactly one of mainClass, mainModule and jarPath is set, along with the classpath
+// that a main class requires.
+func validateJavaMain(config JavaConfig) error {
+	set := 0
+	for _, main := range []string{config.MainClass, config.MainModule, config.JarPath} {
+		if main != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return errors.New("only one of mainClass, mainModule and jarPath may be set")
+	}
+	if config.JarPath != "" {
+		if len(config.Classpath) > 0 {
+			return errors.New("classpath cannot be set along with jarPath, whose manifest provides the classpath")
+		}
+		return nil
+	}
+	if config.MainModule != "" {
+		return nil
+	}
+	return validator.Validate(config)
 }
 
 func getStaticConfigFromFile(staticConfigFile string) (PrimaryStaticLauncherConfig, error) {
+	tracef("Reading static config file %s", absPath(staticConfigFile))
 	if staticData, err := ioutil.ReadFile(staticConfigFile); err != nil {
 		return PrimaryStaticLauncherConfig{},
 			errors.Wrap(err, "Failed to read static config file: "+staticConfigFile)
@@ -230,49 +1297,81 @@ func verifyStaticWithCustomConfig(staticConfig PrimaryStaticLauncherConfig,
 }
 
 func parseCustomConfig(yamlString []byte) (PrimaryCustomLauncherConfig, error) {
+	config, err := decodeCustomConfig(yamlString)
+	if err != nil {
+		return PrimaryCustomLauncherConfig{}, err
+	}
+	return config, validateCustomConfig(config)
+}
+
+func decodeCustomConfig(yamlString []byte) (PrimaryCustomLauncherConfig, error) {
 	var config PrimaryCustomLauncherConfig
 	if err := yaml.Unmarshal(yamlString, &config); err != nil {
 		return PrimaryCustomLauncherConfig{},
 			errors.Wrap(err, "Failed to deserialize Custom Launcher Config, please check the syntax of "+
 				"your configuration file")
 	}
-
-	if err := config.VersionedConfig.validateVersion(allowedLauncherConfigs.ConfigVersions); err != nil {
+	if config.Version >= strictKeysConfigVersion || StrictKeys {
+		if err := unknownKeysError(yamlString, customConfigMigrations,
+			reflect.TypeOf(PrimaryCustomLauncherConfig{})); err != nil {
+			return PrimaryCustomLauncherConfig{}, errors.Wrap(err, "invalid custom config")
+		}
+	}
+	// Unknown versions, including that of overlays without a configVersion, are decoded as they are and rejected, if
+	// at all, once merged.
+	if _, ok := allowedLauncherConfigs.ConfigVersions[config.Version]; !ok || config.Version == LatestConfigVersion {
+		return config, nil
+	}
+	migrated, err := upgradeConfig(yamlString, config.Version, customConfigMigrations)
+	if err != nil {
 		return PrimaryCustomLauncherConfig{}, err
 	}
+	versioned := config.VersionedConfig
+	config = PrimaryCustomLauncherConfig{}
+	if err := yaml.Unmarshal(migrated, &config); err != nil {
+		return PrimaryCustomLauncherConfig{},
+			errors.Wrap(err, "Failed to deserialize Custom Launcher Config, please check the syntax of "+
+				"your configuration file")
+	}
+	config.VersionedConfig = versioned
+	return config, nil
+}
 
-	if err := config.TypedConfig.validateType(allowedLauncherConfigs.ConfigTypes); err != nil {
-		return PrimaryCustomLauncherConfig{}, err
+func validateCustomConfig(config PrimaryCustomLauncherConfig) error {
+	if err := config.VersionedConfig.validateVersion(allowedLauncherConfigs.ConfigVersions); err != nil {
+		return err
 	}
 
-	if err := validateSubProcessLimit(len(config.SubProcesses)); err != nil {
-		return PrimaryCustomLauncherConfig{}, err
+	if err := config.TypedConfig.validateType(allowedLauncherConfigs.ConfigTypes); err != nil {
+		return err
 	}
 
 	for name, subProcess := range config.SubProcesses {
 		if err := validateProcessName(name); err != nil {
-			return PrimaryCustomLauncherConfig{}, errors.Wrapf(err, "invalid subProcess name '%s' in "+
-				"custom config", name)
+			return errors.Wrapf(err, "invalid subProcess name '%s' in custom config", name)
 		}
 
 		if err := subProcess.TypedConfig.validateType(allowedLauncherConfigs.ConfigTypes); err != nil {
-			return PrimaryCustomLauncherConfig{}, errors.Wrapf(err, "invalid launch config in custom "+
-				"subProcess config %s", name)
+			return errors.Wrapf(err, "invalid launch config in custom subProcess config %s", name)
 		}
 	}
-	return config, nil
+	return nil
 }
 
 func getCustomConfigFromFile(customConfigFile string, stdout io.Writer) (PrimaryCustomLauncherConfig, error) {
-	if customData, err := ioutil.ReadFile(customConfigFile); err != nil {
+	customConfig, files, err := loadCustomConfigOverlays(customConfigFile)
+	if err != nil {
+		return PrimaryCustomLauncherConfig{}, err
+	}
+	if len(files) == 0 {
 		fmt.Fprintln(stdout, "Failed to read custom config file, assuming no custom config:",
 			customConfigFile)
 		return PrimaryCustomLauncherConfig{}, nil
-	} else if customConfig, err := parseCustomConfig(customData); err != nil {
+	}
+	if err := validateCustomConfig(customConfig); err != nil {
 		return PrimaryCustomLauncherConfig{}, err
-	} else {
-		return customConfig, nil
 	}
+	return customConfig, nil
 }
 
 func (config *VersionedConfig) validateVersion(allowedVersions map[int]struct{}) error {
@@ -291,13 +1390,188 @@ func (config *TypedConfig) validateType(allowedTypes map[string]struct{}) error
 	return nil
 }
 
-func validateExecutableConfig(executable string) error {
+func (config *SupervisionConfig) validate() error {
+	if config.MaxRestarts != nil && *config.MaxRestarts < 0 || config.RestartWindow < 0 || config.InitialBackoff < 0 ||
+		config.MaxBackoff < 0 || config.HeartbeatInterval < 0 {
+		return errors.New("maxRestarts, restartWindow, initialBackoff, maxBackoff and heartbeatInterval must not be " +
+			"negative")
+	}
+	return nil
+}
+
+func (config *OutputRotationConfig) validate() error {
+	if config.MaxFiles < 0 || config.MaxAge < 0 {
+		return errors.New("maxFiles and maxAge must not be negative")
+	}
+	_, err := config.MaxSizeBytes()
+	return err
+}
+
+// MaxSizeBytes returns MaxSize in bytes, or 0 if output files are not rotated by size.
+func (config OutputRotationConfig) MaxSizeBytes() (int64, error) {
+	size, err := parseMemorySize("maxSize", config.MaxSize)
+	if err != nil || size == "" || size == "max" {
+		return 0, err
+	}
+	return strconv.ParseInt(size, 10, 64)
+}
+
+func (config *MetricsConfig) validate() error {
+	if config.Interval < 0 {
+		return errors.New("interval must not be negative")
+	}
+	if config.TextfilePath != "" && path.Ext(config.TextfilePath) != ".prom" {
+		return errors.Errorf("textfilePath must have the .prom extension, found '%s'", config.TextfilePath)
+	}
+	return nil
+}
+
+func (config *ResourceLogConfig) validate() error {
+	if config.Interval < 0 {
+		return errors.New("interval must not be negative")
+	}
+	switch config.Format {
+	case "", ResourceLogFormatCSV, ResourceLogFormatJSON:
+		return nil
+	default:
+		return errors.Errorf("format must be one of %s or %s, found '%s'", ResourceLogFormatCSV,
+			ResourceLogFormatJSON, config.Format)
+	}
+}
+
+// WithDefaults returns a copy of the config with an unset format replaced by ResourceLogFormatCSV.
+func (config ResourceLogConfig) WithDefaults() ResourceLogConfig {
+	if config.Format == "" {
+		config.Format = ResourceLogFormatCSV
+	}
+	return config
+}
+
+func (config *NotificationsConfig) validate() error {
+	if config.URL != "" && len(config.Command) > 0 {
+		return errors.New("only one of url and command may be set")
+	}
+	if config.URL != "" {
+		parsed, err := url.Parse(config.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errors.Errorf("url must be an http or https URL, found '%s'", config.URL)
+		}
+	}
+	for _, event := range config.Events {
+		switch event {
+		case NotificationEventStart, NotificationEventStop, NotificationEventCrash, NotificationEventRestart:
+		default:
+			return errors.Errorf("events must be of %s, found '%s'",
+				strings.Join(DefaultNotificationsConfig.Events, ", "), event)
+		}
+	}
+	if config.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	return nil
+}
+
+// Enabled returns whether the config sends notifications.
+func (config NotificationsConfig) Enabled() bool {
+	return config.URL != "" || len(config.Command) > 0
+}
+
+// WithDefaults returns a copy of the config with unset events and timeout replaced by their defaults.
+func (config NotificationsConfig) WithDefaults() NotificationsConfig {
+	if config.Events == nil {
+		config.Events = DefaultNotificationsConfig.Events
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultNotificationsConfig.Timeout
+	}
+	return config
+}
+
+// WithDefaults returns a copy of the config with an unset interval replaced by its default.
+func (config MetricsConfig) WithDefaults() MetricsConfig {
+	if config.Interval == 0 {
+		config.Interval = DefaultMetricsConfig.Interval
+	}
+	return config
+}
+
+// WithDefaults returns a copy of the config with each unset value replaced by its default.
+func (config SupervisionConfig) WithDefaults() SupervisionConfig {
+	if config.MaxRestarts == nil {
+		config.MaxRestarts = DefaultSupervisionConfig.MaxRestarts
+	}
+	if config.RestartWindow == 0 {
+		config.RestartWindow = DefaultSupervisionConfig.RestartWindow
+	}
+	if config.InitialBackoff == 0 {
+		config.InitialBackoff = DefaultSupervisionConfig.InitialBackoff
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = DefaultSupervisionConfig.MaxBackoff
+	}
+	if config.HeartbeatInterval == 0 {
+		config.HeartbeatInterval = DefaultSupervisionConfig.HeartbeatInterval
+	}
+	return config
+}
+
+func (config *HealthCheckConfig) validate() error {
+	if (config.URL == "") == (config.Port == 0) {
+		return errors.New("exactly one of url and port must be set")
+	}
+	if config.URL != "" && !strings.HasPrefix(config.URL, "http://") && !strings.HasPrefix(config.URL, "https://") {
+		return errors.Errorf("url must be an http or https URL, found %s", config.URL)
+	}
+	if config.Port < 0 || config.Port > 65535 {
+		return errors.Errorf("port must be between 1 and 65535, found %d", config.Port)
+	}
+	if config.Interval < 0 || config.Timeout < 0 || config.MaxWait < 0 {
+		return errors.New("interval, timeout and maxWait must not be negative")
+	}
+	return nil
+}
+
+// WithDefaults returns a copy of the config with each unset duration replaced by its default.
+func (config HealthCheckConfig) WithDefaults() HealthCheckConfig {
+	if config.Interval == 0 {
+		config.Interval = DefaultHealthCheckConfig.Interval
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultHealthCheckConfig.Timeout
+	}
+	if config.MaxWait == 0 {
+		config.MaxWait = DefaultHealthCheckConfig.MaxWait
+	}
+	return config
+}
+
+func validateExecutableConfig(executable string, allowedExecutables []string) error {
 	if executable == "" {
 		return errors.New("Config type \"executable\" requires top-level \"executable:\" value")
 	}
-	if _, ok := allowedLauncherConfigs.Executables[path.Base(executable)]; !ok {
-		return fmt.Errorf("Can handle executable=%v only, found %v",
-			toString(allowedLauncherConfigs.Executables), executable)
+	allowed := allowedLauncherConfigs.Executables
+	if len(allowedExecutables) > 0 {
+		allowed = make(map[string]struct{}, len(allowedLauncherConfigs.Executables)+len(allowedExecutables))
+		for name := range allowedLauncherConfigs.Executables {
+			allowed[name] = struct{}{}
+		}
+		for _, name := range allowedExecutables {
+			allowed[name] = struct{}{}
+		}
+	}
+	if _, ok := allowed[path.Base(executable)]; !ok {
+		return fmt.Errorf("Can handle executable=%v only, found %v", toString(allowed), executable)
+	}
+	return nil
+}
+
+// validateAllowedExecutables validates that the allowedExecutables are file names, which the executables are matched
+// against regardless of their directory.
+func validateAllowedExecutables(allowedExecutables []string) error {
+	for _, name := range allowedExecutables {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return errors.Errorf("allowedExecutables must be file names without a directory, found '%s'", name)
+		}
 	}
 	return nil
 }
@@ -307,6 +1581,7 @@ func toString(inputMap map[string]struct{}) string {
 	for k := range inputMap {
 		collection = append(collection, k)
 	}
+	sort.Strings(collection)
 	return "{" + strings.Join(collection, ", ") + "}"
 }
 
@@ -16,10 +16,16 @@ package launchlib
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func intPtr(i int) *int {
+	return &i
+}
+
 func TestParseStaticConfig(t *testing.T) {
 	for i, currCase := range []struct {
 		name string
@@ -150,6 +156,256 @@ subProcesses:
 				},
 			},
 		},
+		{
+			name: "with supervision config",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /usr/bin/postgres
+supervision:
+  maxRestarts: 3
+  restartWindow: 10m
+  initialBackoff: 500ms
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "executable",
+					},
+					Executable: "/usr/bin/postgres",
+				},
+				Supervision: SupervisionConfig{
+					MaxRestarts:    intPtr(3),
+					RestartWindow:  10 * time.Minute,
+					InitialBackoff: 500 * time.Millisecond,
+				},
+			},
+		},
+		{
+			name: "with multiple subProcesses",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /usr/bin/postgres
+subProcesses:
+  envoy:
+    configType: executable
+    executable: /etc/envoy/envoy
+  metrics:
+    configType: executable
+    executable: /usr/bin/influxd
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "executable",
+					},
+					Executable: "/usr/bin/postgres",
+				},
+				SubProcesses: map[string]StaticLauncherConfig{
+					"envoy": {
+						TypedConfig: TypedConfig{
+							Type: "executable",
+						},
+						Executable: "/etc/envoy/envoy",
+					},
+					"metrics": {
+						TypedConfig: TypedConfig{
+							Type: "executable",
+						},
+						Executable: "/usr/bin/influxd",
+					},
+				},
+			},
+		},
+		{
+			name: "with defaults",
+			data: `
+configVersion: 1
+serviceName: primary
+defaults:
+  configType: java
+  mainClass: mainClass
+  classpath:
+    - classpath1
+  env:
+    SHARED_VAR: shared
+    OTHER_VAR: default
+jvmOpts:
+  - jvmOpt1
+subProcesses:
+  sidecar:
+    mainClass: sidecarClass
+    env:
+      OTHER_VAR: sidecar
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "java",
+					},
+					Env: map[string]string{
+						"SHARED_VAR": "shared",
+						"OTHER_VAR":  "default",
+					},
+					Executable: "java",
+					JavaConfig: JavaConfig{
+						MainClass: "mainClass",
+						Classpath: []string{"classpath1"},
+						JvmOpts:   []string{"jvmOpt1"},
+					},
+				},
+				SubProcesses: map[string]StaticLauncherConfig{
+					"sidecar": {
+						TypedConfig: TypedConfig{
+							Type: "java",
+						},
+						Env: map[string]string{
+							"SHARED_VAR": "shared",
+							"OTHER_VAR":  "sidecar",
+						},
+						Executable: "java",
+						JavaConfig: JavaConfig{
+							MainClass: "sidecarClass",
+							Classpath: []string{"classpath1"},
+						},
+					},
+				},
+				Defaults: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "java",
+					},
+					Env: map[string]string{
+						"SHARED_VAR": "shared",
+						"OTHER_VAR":  "default",
+					},
+					JavaConfig: JavaConfig{
+						MainClass: "mainClass",
+						Classpath: []string{"classpath1"},
+					},
+				},
+			},
+		},
+		{
+			name: "with health check",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /usr/bin/postgres
+healthCheck:
+  port: 5432
+  maxWait: 2m
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "executable",
+					},
+					Executable: "/usr/bin/postgres",
+					HealthCheck: &HealthCheckConfig{
+						Port:    5432,
+						MaxWait: 2 * time.Minute,
+					},
+				},
+			},
+		},
+		{
+			name: "with jar path",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+jarPath: service/lib/app.jar
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "java",
+					},
+					Executable: "java",
+					JavaConfig: JavaConfig{
+						JarPath: "service/lib/app.jar",
+					},
+				},
+			},
+		},
+		{
+			name: "with native image",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+nativeImage: service/bin/app
+jvmOpts:
+  - -Xmx1g
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "java",
+					},
+					Executable: "java",
+					JavaConfig: JavaConfig{
+						NativeImage: "service/bin/app",
+						JvmOpts:     []string{"-Xmx1g"},
+					},
+				},
+			},
+		},
+		{
+			name: "with startup window from defaults",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /usr/bin/postgres
+defaults:
+  startupWindow: 10s
+`,
+			want: PrimaryStaticLauncherConfig{
+				VersionedConfig: VersionedConfig{
+					Version: 1,
+				},
+				ServiceName: "primary",
+				StaticLauncherConfig: StaticLauncherConfig{
+					TypedConfig: TypedConfig{
+						Type: "executable",
+					},
+					Executable:    "/usr/bin/postgres",
+					StartupWindow: 10 * time.Second,
+				},
+				Defaults: StaticLauncherConfig{
+					StartupWindow: 10 * time.Second,
+				},
+			},
+		},
 	} {
 		got, _ := parseStaticConfig([]byte(currCase.data))
 		assert.Equal(t, currCase.want, got, "Case %d: %s", i, currCase.name)
@@ -334,10 +590,10 @@ executable: postgres
 		},
 		{
 			name: "invalid config version",
-			msg:  `Can handle configVersion\=\{1\} only, found 2`,
+			msg:  `Can handle configVersion\=\{1, 2\} only, found 3`,
 			data: `
 configType: executable
-configVersion: 2
+configVersion: 3
 serviceName: primary
 executable: postgres
 `,
@@ -381,6 +637,30 @@ serviceName: primary
 args:
   - "-rf"
   - "/"
+`,
+		},
+		{
+			name: "invalid allowed executable",
+			msg:  `allowedExecutables must be file names without a directory, found '/usr/bin/node'`,
+			data: `
+configType: executable
+configVersion: 1
+executable: /usr/bin/node
+serviceName: primary
+allowedExecutables:
+  - /usr/bin/node
+`,
+		},
+		{
+			name: "executable not in allowed executables",
+			msg:  `Can handle executable\=\{.*node.*\} only, found /bin/rm`,
+			data: `
+configType: executable
+configVersion: 1
+executable: /bin/rm
+serviceName: primary
+allowedExecutables:
+  - node
 `,
 		},
 		{
@@ -465,10 +745,884 @@ subProcesses:
     configType: java
 `,
 		},
-	} {
-		_, err := parseStaticConfig([]byte(currCase.data))
-		assert.NotEqual(t, err, nil, "Case %d: %s had no errors", i, currCase.name)
-		assert.Regexp(t, currCase.msg, err.Error(), "Case %d: %s had the wrong error message", i, currCase.name)
-	}
+		{
+			name: "negative supervision value",
+			msg: "invalid supervision config: maxRestarts, restartWindow, initialBackoff, maxBackoff and " +
+				"heartbeatInterval must not be negative",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+supervision:
+  maxRestarts: -1
+`,
+		},
+		{
+			name: "health check with url and port",
+			msg:  "invalid healthCheck config: exactly one of url and port must be set",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+healthCheck:
+  url: http://localhost:8080/health
+  port: 8080
+`,
+		},
+		{
+			name: "health check with non-http url",
+			msg:  "invalid healthCheck config: url must be an http or https URL, found ftp://localhost",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+healthCheck:
+  url: ftp://localhost
+`,
+		},
+		{
+			name: "unknown output mode",
+			msg:  "outputMode must be one of file, console, syslog or journald, found 'kafka'",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+outputMode: kafka
+`,
+		},
+		{
+			name: "separate stderr with journald output mode",
+			msg:  "separateStderr and stderrFile can only be set with outputMode file",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+outputMode: journald
+separateStderr: true
+`,
+		},
+		{
+			name: "negative startup window",
+			msg:  "startupWindow must not be negative",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+startupWindow: -1s
+`,
+		},
+		{
+			name: "heap percentage above 100",
+			msg:  "heapPercentage must be between 0 and 100, found 150",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: mainClass
+classpath:
+  - classpath1
+heapPercentage: 150
+`,
+		},
+		{
+			name: "empty jvm opts allowlist entry",
+			msg:  "jvmOptsAllowlist must not contain empty entries",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: mainClass
+classpath:
+  - classpath1
+jvmOptsAllowlist:
+  - -Xmx
+  - ""
+`,
+		},
+		{
+			name: "java home and java version",
+			msg:  "only one of javaHome and javaVersion may be set",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: mainClass
+classpath:
+  - classpath1
+javaHome: /opt/java
+javaVersion: "11"
+`,
+		},
+		{
+			name: "jar path and classpath",
+			msg:  "classpath cannot be set along with jarPath",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+jarPath: service/lib/app.jar
+classpath:
+  - classpath1
+`,
+		},
+		{
+			name: "jar path and main class",
+			msg:  "only one of mainClass, mainModule and jarPath may be set",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+jarPath: service/lib/app.jar
+mainClass: mainClass
+`,
+		},
+		{
+			name: "native image and main class",
+			msg:  "mainClass cannot be set along with nativeImage",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+nativeImage: service/bin/app
+mainClass: mainClass
+`,
+		},
+		{
+			name: "java executable outside java installation",
+			msg:  "javaExecutable must be a path within the java installation",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: mainClass
+classpath:
+  - classpath1
+javaExecutable: ../bin/java
+`,
+		},
+		{
+			name: "heap percentage and memory",
+			msg:  "only one of heapPercentage and memory may be set",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: mainClass
+classpath:
+  - classpath1
+heapPercentage: 75
+memory:
+  heapPercentage: 75
+`,
+		},
+		{
+			name: "memory without heap percentage",
+			msg:  "invalid memory config: heapPercentage must be greater than 0 and at most 100, found 0",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: mainClass
+classpath:
+  - classpath1
+memory:
+  offHeapReserveMb: 512
+`,
+		},
+		{
+			name: "env passthrough without clean env",
+			msg:  "envPassthrough can only be set along with cleanEnv",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /bin/ls
+envPassthrough:
+  - PATH
+`,
+		},
+		{
+			name: "invalid env passthrough pattern",
+			msg:  "invalid envPassthrough pattern 'LC_\\['",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /bin/ls
+cleanEnv: true
+envPassthrough:
+  - LC_[
+`,
+		},
+		{
+			name: "invalid env from files name",
+			msg:  "invalid environment variable name 'DB-PASSWORD' in envFromFiles",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /bin/ls
+envFromFiles:
+  DB-PASSWORD: /secrets/db
+`,
+		},
+		{
+			name: "invalid sensitive keys pattern",
+			msg:  "invalid sensitiveKeys pattern",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /bin/ls
+sensitiveKeys:
+  - "("
+`,
+		},
+		{
+			name: "invalid rlimit",
+			msg:  "invalid rlimit nofile: soft limit 65536 exceeds hard limit 1024",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: /bin/ls
+rlimits:
+  nofile: "65536:1024"
+`,
+		},
+		{
+			name: "decimal file mode",
+			msg:  "fileMode must be an octal permission mode such as 0640, with a leading 0, found 640",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+fileMode: 640
+`,
+		},
+		{
+			name: "invalid umask",
+			msg:  "umask must be an octal permission mode",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+umask: 01000
+`,
+		},
+		{
+			name: "io priority without class",
+			msg:  "ioPriority requires an ioClass of realtime or best-effort",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+ioPriority: 7
+`,
+		},
+		{
+			name: "invalid cpu set",
+			msg:  "invalid cpuSet '0-3,x': 'x' is not a CPU number between 0 and 1023",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+cpuSet: 0-3,x
+`,
+		},
+		{
+			name: "unknown capability",
+			msg:  "invalid capabilities config: unknown capability 'CAP_NET_BIND'",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+capabilities:
+  keep:
+    - CAP_NET_BIND
+`,
+		},
+		{
+			name: "invalid cgroup name",
+			msg:  "invalid cgroup config: name must be a relative path of .+ segments, found '../system'",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+cgroup:
+  name: ../system
+`,
+		},
+		{
+			name: "hook without command",
+			msg:  "invalid hooks config: preStop hook 0 must have a command",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+hooks:
+  preStop:
+    - timeout: 30s
+`,
+		},
+		{
+			name: "negative start retries",
+			msg:  "startRetries and retryDelay must not be negative",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+startRetries: -1
+`,
+		},
+		{
+			name: "unknown reload signal",
+			msg:  "invalid reloadSignal: unknown signal 'SIGRELOAD'",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+reloadSignal: SIGRELOAD
+`,
+		},
+		{
+			name: "metrics textfile without .prom extension",
+			msg:  "invalid metrics config: textfilePath must have the .prom extension, found 'var/metrics/service.txt'",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+metrics:
+  textfilePath: var/metrics/service.txt
+`,
+		},
+		{
+			name: "unknown resourceLog format",
+			msg:  "invalid resourceLog config: format must be one of csv or json, found 'xml'",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+resourceLog:
+  interval: 1m
+  format: xml
+`,
+		},
+		{
+			name: "notifications with url and command",
+			msg:  "invalid notifications config: only one of url and command may be set",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+notifications:
+  url: https://hooks.example.com/go-init
+  command: [notify-send]
+`,
+		},
+		{
+			name: "notifications with invalid url",
+			msg:  "invalid notifications config: url must be an http or https URL, found 'hooks.example.com'",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+notifications:
+  url: hooks.example.com
+`,
+		},
+		{
+			name: "unknown notifications event",
+			msg:  "invalid notifications config: events must be of start, stop, crash, restart, found 'reload'",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+notifications:
+  url: https://hooks.example.com/go-init
+  events: [start, reload]
+`,
+		},
+		{
+			name: "unknown statusExitCodes",
+			msg:  "statusExitCodes must be one of lsb, monit or s6, found 'upstart'",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+statusExitCodes: upstart
+`,
+		},
+		{
+			name: "unknown execMode",
+			msg:  "execMode must be one of fork or exec, found 'spawn'",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+execMode: spawn
+`,
+		},
+		{
+			name: "execMode exec with daemonizes",
+			msg:  "execMode exec is not supported with daemonizes",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+execMode: exec
+daemonizes: true
+`,
+		},
+		{
+			name: "execMode exec with subProcesses",
+			msg:  "execMode exec is not supported with subProcesses",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+execMode: exec
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: envoy
+`,
+		},
+		{
+			name: "negative diagnostics maxFiles",
+			msg:  "invalid diagnostics config: maxFiles must not be negative, found -1",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: com.palantir.Main
+classpath:
+  - ./foo
+diagnostics:
+  maxFiles: -1
+`,
+		},
+		{
+			name: "dependency without port",
+			msg:  "invalid dependsOn config: address must be of the form host:port, found db",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+dependsOn:
+  - address: db
+`,
+		},
+		{
+			name: "invalid dir mode",
+			msg:  "mode of directory var/data must be an octal permission mode such as 0640, with a leading 0, found 750",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+dirs:
+  - path: var/data
+    mode: 750
+`,
+		},
+	} {
+		_, err := parseStaticConfig([]byte(currCase.data))
+		assert.NotEqual(t, err, nil, "Case %d: %s had no errors", i, currCase.name)
+		assert.Regexp(t, currCase.msg, err.Error(), "Case %d: %s had the wrong error message", i, currCase.name)
+	}
+
+}
+
+func TestParseStaticConfigOutputFile(t *testing.T) {
+	for i, currCase := range []struct {
+		yaml string
+		err  string
+	}{
+		{yaml: `
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: envoy
+    outputFile: var/log/envoy.log
+`},
+		{yaml: `
+outputFile: var/log/service.log
+`, err: "outputFile can only be set for subProcesses, the output file of the primary process is set by the " +
+			"--output-file flag of go-init"},
+		{yaml: `
+subProcesses:
+  envoy-a:
+    configType: executable
+    executable: envoy
+    outputFile: var/log/envoy.log
+  envoy-b:
+    configType: executable
+    executable: envoy
+    outputFile: var/log/./envoy.log
+`, err: "subProcesses envoy-a and envoy-b must not share the outputFile var/log/envoy.log"},
+		{yaml: `
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: envoy
+    outputMode: console
+    outputFile: var/log/envoy.log
+`, err: "failed to validate subProcess launcher configuration 'sidecar': outputFile can only be set with " +
+			"outputMode file"},
+	} {
+		_, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+` + currCase.yaml))
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestParseStaticConfigStartupPattern(t *testing.T) {
+	for i, currCase := range []struct {
+		yaml string
+		err  string
+	}{
+		{yaml: `
+startupPattern: 'Started \w+ in [0-9.]+s'
+startupPatternTimeout: 2m
+`},
+		{yaml: `
+defaults:
+  startupPattern: ready to accept connections
+`},
+		{yaml: `
+startupPattern: 'Started ('
+`, err: "invalid startupPattern: error parsing regexp: missing closing ): `Started (`"},
+		{yaml: `
+startupPattern: Started
+startupPatternTimeout: -1s
+`, err: "startupPatternTimeout must not be negative"},
+		{yaml: `
+startupPattern: Started
+outputMode: console
+`, err: "startupPattern can only be set with outputMode file, as it is matched against the output file of the " +
+			"process"},
+	} {
+		_, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+` + currCase.yaml))
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestParseStaticConfigStop(t *testing.T) {
+	for i, currCase := range []struct {
+		yaml string
+		err  string
+	}{
+		{yaml: `
+stop:
+  url: http://localhost:8081/admin/drain
+  timeout: 2m
+`},
+		{yaml: `
+stop:
+  timeout: 2m
+`, err: "invalid stop config: url must be an http or https URL, found ''"},
+		{yaml: `
+stop:
+  url: https://localhost:8081/admin/drain
+  timeout: -1s
+`, err: "invalid stop config: timeout must not be negative"},
+		{yaml: `
+stopTimeout: 30s
+stopOrder: reverseDependencies
+`},
+		{yaml: `
+stopTimeout: -1s
+`, err: "stopTimeout must not be negative"},
+		{yaml: `
+stopOrder: dependencies
+`, err: "stopOrder must be one of parallel or reverseDependencies, found 'dependencies'"},
+	} {
+		_, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+` + currCase.yaml))
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestParseStaticConfigPlugins(t *testing.T) {
+	for i, currCase := range []struct {
+		yaml string
+		err  string
+	}{
+		{yaml: `
+plugins:
+  dir: /opt/platform/plugins
+  timeout: 10s
+  envAllowlist: [LICENSE_*, INVENTORY_ID]
+`},
+		{yaml: `
+plugins:
+  timeout: -1s
+`, err: "invalid plugins config: timeout must not be negative"},
+		{yaml: `
+plugins:
+  envAllowlist: ["LICENSE_["]
+`, err: "invalid plugins config: invalid envAllowlist pattern 'LICENSE_[': syntax error in pattern"},
+	} {
+		_, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+` + currCase.yaml))
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestParseStaticConfigVerify(t *testing.T) {
+	for i, currCase := range []struct {
+		yaml string
+		err  string
+	}{
+		{yaml: `
+verify:
+  - file: service/lib/service.jar
+    sha256: d4f0bc5a29de06b510f9aa428f1eedba926012b591fef7a518e776a7c9bd1824
+  - file: service/bin/launcher-static.yml
+    signature: service/bin/launcher-static.yml.sig
+    publicKey: /etc/pki/release.pem
+`},
+		{yaml: `
+verify:
+  - sha256: d4f0bc5a29de06b510f9aa428f1eedba926012b591fef7a518e776a7c9bd1824
+`, err: "invalid verify config: file must be set"},
+		{yaml: `
+verify:
+  - file: service/lib/service.jar
+`, err: "invalid verify config: exactly one of sha256 and signature must be set for service/lib/service.jar"},
+		{yaml: `
+verify:
+  - file: service/lib/service.jar
+    sha256: d4f0bc5a
+`, err: "invalid verify config: sha256 must be 64 hexadecimal characters, found 'd4f0bc5a'"},
+		{yaml: `
+verify:
+  - file: service/lib/service.jar
+    signature: service/lib/service.jar.sig
+`, err: "invalid verify config: signature and publicKey must be set together for service/lib/service.jar"},
+	} {
+		_, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+` + currCase.yaml))
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestParseStaticConfigProcessDependencies(t *testing.T) {
+	for i, currCase := range []struct {
+		yaml string
+		err  string
+	}{
+		{yaml: `
+dependsOn:
+  - process: database
+subProcesses:
+  database:
+    configType: executable
+    executable: postgres
+`},
+		{yaml: `
+dependsOn:
+  - process: cache
+`, err: "process 'primary' depends on process 'cache', which is not configured"},
+		{yaml: `
+subProcesses:
+  database:
+    configType: executable
+    executable: postgres
+    dependsOn:
+      - process: database
+`, err: "process 'database' must not depend on itself"},
+		{yaml: `
+dependsOn:
+  - process: database
+subProcesses:
+  database:
+    configType: executable
+    executable: postgres
+    dependsOn:
+      - process: primary
+`, err: "processes must not depend on each other in a cycle, found database -> primary -> database"},
+		{yaml: `
+startupTimeout: -1s
+`, err: "startupTimeout must not be negative"},
+	} {
+		_, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+` + currCase.yaml))
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestParseStaticConfigAllowedExecutables(t *testing.T) {
+	config, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: "{{CWD}}/service/bin/helper"
+allowedExecutables:
+  - helper
+  - node
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: /usr/bin/node
+    args:
+      - service/lib/sidecar.js
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"helper", "node"}, config.AllowedExecutables)
+	assert.Equal(t, "/usr/bin/node", config.SubProcesses["sidecar"].Executable)
+}
+
+func TestParseStaticConfigDirs(t *testing.T) {
+	config, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+dirs:
+  - var/log
+  - path: var/data
+    mode: 0750
+    owner: my-service:adm
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []DirConfig{
+		{Path: "var/log"},
+		{Path: "var/data", Mode: 0750, Owner: "my-service:adm"},
+	}, config.Dirs)
+	assert.Equal(t, "var/data (mode 0750, owner my-service:adm)", config.Dirs[1].String())
+}
+
+func TestParseStaticConfigTmpDir(t *testing.T) {
+	for i, currCase := range []struct {
+		yaml string
+		err  string
+	}{
+		{yaml: `
+tmpDir:
+  cleanOnStart: true
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: envoy
+    tmpDir:
+      dir: var/data/sidecar-tmp
+`},
+		{yaml: `
+tmpDir:
+  cleanOnStart: true
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: envoy
+    tmpDir: {}
+`, err: "processes primary, sidecar share the tmpDir var/data/tmp, so it must not be cleaned on start"},
+		{yaml: `
+tmpDir:
+  dir: /var/tmp
+  cleanOnStart: true
+`, err: "invalid tmpDir config: cleanOnStart must not be set for the shared directory /var/tmp"},
+	} {
+		_, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+` + currCase.yaml))
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
 
+func TestSupervisionConfigWithDefaults(t *testing.T) {
+	assert.Equal(t, DefaultSupervisionConfig, SupervisionConfig{}.WithDefaults())
+	assert.Equal(t, SupervisionConfig{
+		MaxRestarts:       intPtr(2),
+		RestartWindow:     DefaultSupervisionConfig.RestartWindow,
+		InitialBackoff:    DefaultSupervisionConfig.InitialBackoff,
+		MaxBackoff:        time.Hour,
+		HeartbeatFile:     "var/run/heartbeat",
+		HeartbeatInterval: DefaultSupervisionConfig.HeartbeatInterval,
+	}, SupervisionConfig{MaxRestarts: intPtr(2), MaxBackoff: time.Hour, HeartbeatFile: "var/run/heartbeat"}.
+		WithDefaults())
+	// An explicit maxRestarts of 0 is kept rather than replaced by the default.
+	assert.Equal(t, 0, *SupervisionConfig{MaxRestarts: intPtr(0)}.WithDefaults().MaxRestarts)
 }
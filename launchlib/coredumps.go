@@ -0,0 +1,252 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CoreDumpsConfig enables the core dumps of a process and collects those left in its working directory by a crash into
+// a directory, alongside the fatal error logs of the JVM by default, which is pruned when it is launched.
+type CoreDumpsConfig struct {
+	// Limit is the core rlimit of the process, of the same form as rlimits, which takes precedence if it sets core.
+	Limit string `yaml:"limit"`
+	// Pattern is the glob of the names of the core files that kernel.core_pattern has the kernel write to the working
+	// directory of the process. Only ELF files matching it are collected.
+	Pattern string `yaml:"pattern"`
+	// Dir is the directory the core files are moved to, relative to the working directory unless absolute.
+	Dir string `yaml:"dir"`
+	// Compress gzips the core files as they are moved.
+	Compress bool `yaml:"compress"`
+	// MaxFiles is the number of the most recent core files that are kept.
+	MaxFiles int `yaml:"maxFiles"`
+	// MaxSize is the total size of the core files that are kept, e.g. 10G, which is unlimited if unset.
+	MaxSize string `yaml:"maxSize"`
+}
+
+var DefaultCoreDumpsConfig = CoreDumpsConfig{
+	Limit:    "unlimited",
+	Pattern:  "core*",
+	Dir:      DefaultDiagnosticsConfig.Dir,
+	MaxFiles: 2,
+}
+
+// collectedCorePrefix is the prefix of the names of collected core files, which tells them from the other diagnostics
+// artifacts in the same directory.
+const collectedCorePrefix = "core_"
+
+// corePatternFile is where the kernel sets the names of core files.
+var corePatternFile = "/proc/sys/kernel/core_pattern"
+
+var elfMagic = []byte{0x7f, 'E', 'L', 'F'}
+
+func (config CoreDumpsConfig) WithDefaults() CoreDumpsConfig {
+	if config.Limit == "" {
+		config.Limit = DefaultCoreDumpsConfig.Limit
+	}
+	if config.Pattern == "" {
+		config.Pattern = DefaultCoreDumpsConfig.Pattern
+	}
+	if config.Dir == "" {
+		config.Dir = DefaultCoreDumpsConfig.Dir
+	}
+	if config.MaxFiles == 0 {
+		config.MaxFiles = DefaultCoreDumpsConfig.MaxFiles
+	}
+	return config
+}
+
+func (config *CoreDumpsConfig) validate() error {
+	if strings.ContainsRune(config.Pattern, '/') {
+		return errors.Errorf("pattern must be a file name, found '%s'", config.Pattern)
+	}
+	if _, err := filepath.Match(config.Pattern, ""); err != nil {
+		return errors.Wrapf(err, "invalid pattern '%s'", config.Pattern)
+	}
+	if config.MaxFiles < 0 {
+		return errors.Errorf("maxFiles must not be negative, found %d", config.MaxFiles)
+	}
+	_, err := config.maxSizeBytes()
+	return err
+}
+
+// maxSizeBytes returns MaxSize in bytes, or 0 if it is unlimited.
+func (config CoreDumpsConfig) maxSizeBytes() (int64, error) {
+	return DiagnosticsConfig{MaxSize: config.MaxSize}.maxSizeBytes()
+}
+
+func (config CoreDumpsConfig) dir(workingDir string) string {
+	return DiagnosticsConfig{Dir: config.Dir}.dir(workingDir)
+}
+
+// coreRlimits returns the rlimits with the core rlimit of the config added, unless they already set it.
+func coreRlimits(config *CoreDumpsConfig, rlimits map[string]string) map[string]string {
+	if config == nil {
+		return rlimits
+	}
+	if _, ok := rlimits["core"]; ok {
+		return rlimits
+	}
+	return merge(rlimits, map[string]string{"core": config.WithDefaults().Limit})
+}
+
+// CollectCoreDumps moves the core files that a crash of the command left in its working directory to the core dumps
+// directory of the config, owned by the user the command runs as, and removes the oldest collected core files beyond
+// the configured number and total size. Does nothing if config is nil.
+func CollectCoreDumps(config *CoreDumpsConfig, workingDirectory string, cmd *exec.Cmd, stdout io.Writer) error {
+	if config == nil {
+		return nil
+	}
+	coreDumps := config.WithDefaults()
+	workingDir, err := resolveWorkingDir(workingDirectory)
+	if err != nil {
+		return err
+	}
+	if warning := corePatternWarning(); warning != "" {
+		fmt.Fprintln(stdout, warning)
+	}
+	dir := coreDumps.dir(workingDir)
+	// Core files contain the memory of the process, so are only readable by its user.
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "failed to create core dumps directory %s", dir)
+	}
+	if err := ChownDirs([]DirConfig{{Path: dir}}, cmd); err != nil {
+		return err
+	}
+
+	// The pattern was validated along with the config.
+	cores, _ := filepath.Glob(filepath.Join(workingDir, coreDumps.Pattern))
+	for _, core := range cores {
+		if !isCoreFile(core) {
+			continue
+		}
+		collected, err := collectCoreDump(core, dir, coreDumps.Compress)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Collected core dump %s to %s\n", core, collected)
+	}
+
+	maxSize, err := coreDumps.maxSizeBytes()
+	if err != nil {
+		return err
+	}
+	return pruneDiagnostics(dir, isCollectedCoreDump, coreDumps.MaxFiles, maxSize, stdout)
+}
+
+// corePatternWarning returns a warning if kernel.core_pattern does not have the kernel write core files to the working
+// directory of the crashed process, where they are collected from.
+func corePatternWarning() string {
+	patternBytes, err := ioutil.ReadFile(corePatternFile)
+	if err != nil {
+		return ""
+	}
+	pattern := strings.TrimSpace(string(patternBytes))
+	if strings.HasPrefix(pattern, "|") || strings.ContainsRune(pattern, '/') {
+		return fmt.Sprintf("Warning: kernel.core_pattern '%s' does not write core files to the working directory, so "+
+			"they are not collected", pattern)
+	}
+	return ""
+}
+
+// isCoreFile returns whether the path is a regular ELF file, as core files are.
+func isCoreFile(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	magic := make([]byte, len(elfMagic))
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return false
+	}
+	return bytes.Equal(magic, elfMagic)
+}
+
+func isCollectedCoreDump(name string) bool {
+	return strings.HasPrefix(name, collectedCorePrefix)
+}
+
+// collectCoreDump moves the core file into dir, named after the time it was written so that core files of the same
+// name do not overwrite each other, and gzipped if compress is set. Returns the path of the collected file.
+func collectCoreDump(core string, dir string, compress bool) (string, error) {
+	info, err := os.Stat(core)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to collect core dump %s", core)
+	}
+	collected := filepath.Join(dir, collectedCorePrefix+info.ModTime().Format("20060102T150405")+"_"+
+		filepath.Base(core))
+	if !compress {
+		// The core file is copied instead where it cannot be renamed, such as across file systems.
+		if err := os.Rename(core, collected); err == nil {
+			return collected, nil
+		}
+	} else {
+		collected += ".gz"
+	}
+	if err := copyCoreDump(core, collected, compress); err != nil {
+		_ = os.Remove(collected)
+		return "", errors.Wrapf(err, "failed to collect core dump %s", core)
+	}
+	if err := os.Remove(core); err != nil {
+		return "", errors.Wrapf(err, "failed to remove collected core dump %s", core)
+	}
+	return collected, nil
+}
+
+func copyCoreDump(core string, collected string, compress bool) error {
+	src, err := os.Open(core)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+	dest, err := os.OpenFile(collected, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	var writer io.WriteCloser = dest
+	if compress {
+		writer = gzip.NewWriter(dest)
+	}
+	if _, err := io.Copy(writer, src); err != nil {
+		_ = dest.Close()
+		return err
+	}
+	if compress {
+		if err := writer.Close(); err != nil {
+			_ = dest.Close()
+			return err
+		}
+	}
+	return dest.Close()
+}
@@ -0,0 +1,144 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoreRlimits(t *testing.T) {
+	for i, currCase := range []struct {
+		config  *CoreDumpsConfig
+		rlimits map[string]string
+		want    map[string]string
+	}{
+		{config: nil, rlimits: map[string]string{"nofile": "1024"}, want: map[string]string{"nofile": "1024"}},
+		{config: &CoreDumpsConfig{}, want: map[string]string{"core": "unlimited"}},
+		{
+			config:  &CoreDumpsConfig{Limit: "1073741824"},
+			rlimits: map[string]string{"nofile": "1024"},
+			want:    map[string]string{"nofile": "1024", "core": "1073741824"},
+		},
+		{
+			config:  &CoreDumpsConfig{Limit: "1073741824"},
+			rlimits: map[string]string{"core": "0:unlimited"},
+			want:    map[string]string{"core": "0:unlimited"},
+		},
+	} {
+		assert.Equal(t, currCase.want, coreRlimits(currCase.config, currCase.rlimits), "Case %d", i)
+	}
+
+	config := StaticLauncherConfig{Rlimits: map[string]string{"nofile": "1024"}}
+	config.applyDefaults(StaticLauncherConfig{CoreDumps: &CoreDumpsConfig{}})
+	assert.Equal(t, map[string]string{"nofile": "1024", "core": "unlimited"}, config.Rlimits)
+}
+
+func TestValidateCoreDumpsConfig(t *testing.T) {
+	for i, currCase := range []struct {
+		config CoreDumpsConfig
+		err    string
+	}{
+		{config: CoreDumpsConfig{Pattern: "core.*", MaxFiles: 3, MaxSize: "10G"}},
+		{config: CoreDumpsConfig{Pattern: "cores/core"}, err: "pattern must be a file name, found 'cores/core'"},
+		{config: CoreDumpsConfig{Pattern: "core["}, err: "invalid pattern 'core[': syntax error in pattern"},
+		{config: CoreDumpsConfig{MaxFiles: -1}, err: "maxFiles must not be negative, found -1"},
+		{config: CoreDumpsConfig{MaxSize: "lots"},
+			err: "maxSize must be a number of bytes with an optional K, M, G or T suffix, or max, found 'lots'"},
+	} {
+		err := currCase.config.validate()
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestCollectCoreDumps(t *testing.T) {
+	originalCorePatternFile := corePatternFile
+	defer func() {
+		corePatternFile = originalCorePatternFile
+	}()
+	workingDir, err := ioutil.TempDir("", "launchlib-coredumps")
+	require.NoError(t, err)
+	defer func() {
+		_ = os.RemoveAll(workingDir)
+	}()
+	corePatternFile = filepath.Join(workingDir, "core_pattern")
+	require.NoError(t, ioutil.WriteFile(corePatternFile, []byte("|/usr/lib/systemd/systemd-coredump %P\n"), 0644))
+
+	core := append([]byte{0x7f, 'E', 'L', 'F'}, make([]byte, 100)...)
+	written := time.Date(2020, 1, 2, 12, 3, 42, 0, time.Local)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workingDir, "core.1234"), core, 0600))
+	require.NoError(t, os.Chtimes(filepath.Join(workingDir, "core.1234"), written, written))
+	// Files matching the pattern that are not core files are left in place.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workingDir, "core-site.xml"), []byte("<configuration/>"),
+		0644))
+	dir := filepath.Join(workingDir, "var", "log", "crash")
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	for age, name := range []string{"core_20191231T000000_core.1000.gz", "core_20191230T000000_core.999.gz",
+		"hs_err_pid999.log"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte("old"), 0600))
+		modTime := written.Add(-time.Duration(age+1) * time.Hour)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+
+	stdout := &bytes.Buffer{}
+	require.NoError(t, CollectCoreDumps(&CoreDumpsConfig{Compress: true}, workingDir, exec.Command("true"),
+		stdout))
+
+	collected := filepath.Join(dir, "core_20200102T120342_core.1234.gz")
+	assert.Contains(t, stdout.String(), "kernel.core_pattern '|/usr/lib/systemd/systemd-coredump %P' does not "+
+		"write core files to the working directory")
+	assert.Contains(t, stdout.String(), "Collected core dump "+filepath.Join(workingDir, "core.1234")+" to "+
+		collected)
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	sort.Strings(names)
+	// The oldest collected core file is pruned beyond the two most recent, while the error log is not.
+	assert.Equal(t, []string{"core_20191231T000000_core.1000.gz", "core_20200102T120342_core.1234.gz",
+		"hs_err_pid999.log"}, names)
+	_, err = os.Stat(filepath.Join(workingDir, "core.1234"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(workingDir, "core-site.xml"))
+	assert.NoError(t, err)
+
+	file, err := os.Open(collected)
+	require.NoError(t, err)
+	defer func() {
+		_ = file.Close()
+	}()
+	reader, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	uncompressed, err := ioutil.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, core, uncompressed)
+}
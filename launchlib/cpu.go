@@ -0,0 +1,51 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+const activeProcessorCountFlag = "-XX:ActiveProcessorCount="
+
+// numCPU returns the number of processors of the host, overridden in tests.
+var numCPU = runtime.NumCPU
+
+// getProcessorCountJvmOpts returns an -XX:ActiveProcessorCount option matching the CPU limit of the container, or none
+// if disabled, the given options already set it or no CPU limit below the number of processors of the host is set.
+func getProcessorCountJvmOpts(disabled bool, jvmOpts []string) ([]string, error) {
+	if disabled || hasActiveProcessorCountFlag(jvmOpts) {
+		return nil, nil
+	}
+	processors, ok, err := getCgroupProcessorCount()
+	if err != nil || !ok || processors >= numCPU() {
+		return nil, err
+	}
+	if processors < 1 {
+		processors = 1
+	}
+	return []string{fmt.Sprintf("%s%d", activeProcessorCountFlag, processors)}, nil
+}
+
+func hasActiveProcessorCountFlag(jvmOpts []string) bool {
+	for _, opt := range jvmOpts {
+		if strings.HasPrefix(opt, activeProcessorCountFlag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetProcessorCountJvmOpts(t *testing.T) {
+	originalNumCPU := numCPU
+	numCPU = func() int { return 8 }
+	defer func() { numCPU = originalNumCPU }()
+
+	for i, currCase := range []struct {
+		name     string
+		files    map[string]string
+		disabled bool
+		jvmOpts  []string
+		want     []string
+	}{
+		{
+			name:  "cgroup v2 quota",
+			files: map[string]string{"cpu.max": "150000 100000\n"},
+			want:  []string{"-XX:ActiveProcessorCount=2"},
+		},
+		{
+			name:  "cgroup v2 without quota",
+			files: map[string]string{"cpu.max": "max 100000\n"},
+		},
+		{
+			name: "cgroup v1 quota",
+			files: map[string]string{
+				"cpu/cpu.cfs_quota_us":  "300000\n",
+				"cpu/cpu.cfs_period_us": "100000\n",
+				"cpu/cpu.shares":        "1024\n",
+			},
+			want: []string{"-XX:ActiveProcessorCount=3"},
+		},
+		{
+			name: "cgroup v1 shares",
+			files: map[string]string{
+				"cpu/cpu.cfs_quota_us":  "-1\n",
+				"cpu/cpu.cfs_period_us": "100000\n",
+				"cpu/cpu.shares":        "512\n",
+			},
+			want: []string{"-XX:ActiveProcessorCount=1"},
+		},
+		{
+			name: "cgroup v1 default shares",
+			files: map[string]string{
+				"cpu/cpu.cfs_quota_us":  "-1\n",
+				"cpu/cpu.cfs_period_us": "100000\n",
+				"cpu/cpu.shares":        "1024\n",
+			},
+		},
+		{
+			name:  "quota above host processors",
+			files: map[string]string{"cpu.max": "1600000 100000\n"},
+		},
+		{
+			name:     "disabled",
+			files:    map[string]string{"cpu.max": "150000 100000\n"},
+			disabled: true,
+		},
+		{
+			name:    "explicit flag",
+			files:   map[string]string{"cpu.max": "150000 100000\n"},
+			jvmOpts: []string{"-XX:ActiveProcessorCount=4"},
+		},
+	} {
+		cleanup := withCgroupFiles(t, currCase.files)
+		got, err := getProcessorCountJvmOpts(currCase.disabled, currCase.jvmOpts)
+		cleanup()
+		require.NoError(t, err, "Case %d: %s", i, currCase.name)
+		assert.Equal(t, currCase.want, got, "Case %d: %s", i, currCase.name)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package launchlib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// getCredential returns the credential with which to run a process as the given user and group, each a name or a
+// numeric id. The group defaults to the primary group of the user, and the supplementary groups are those of the user.
+// Returns nil if neither is set or the launcher already runs as them, and an error if the launcher would have to
+// change to them but does not run as root.
+func getCredential(userName, groupName string) (*syscall.Credential, error) {
+	if userName == "" && groupName == "" {
+		return nil, nil
+	}
+
+	credential := &syscall.Credential{
+		Uid: uint32(os.Getuid()),
+		Gid: uint32(os.Getgid()),
+	}
+	var groupIds []string
+	if userName != "" {
+		u, err := lookupUser(userName)
+		if err != nil {
+			return nil, err
+		}
+		uid, err := strconv.ParseUint(u.Uid, 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid uid of user %s", userName)
+		}
+		gid, err := strconv.ParseUint(u.Gid, 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid gid of user %s", userName)
+		}
+		credential.Uid, credential.Gid = uint32(uid), uint32(gid)
+		// Without a group database the process only gets the primary group.
+		groupIds, _ = u.GroupIds()
+	}
+	if groupName != "" {
+		g, err := lookupGroup(groupName)
+		if err != nil {
+			return nil, err
+		}
+		gid, err := strconv.ParseUint(g.Gid, 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid gid of group %s", groupName)
+		}
+		credential.Gid = uint32(gid)
+	}
+	for _, groupID := range groupIds {
+		if gid, err := strconv.ParseUint(groupID, 10, 32); err == nil {
+			credential.Groups = append(credential.Groups, uint32(gid))
+		}
+	}
+
+	if credential.Uid == uint32(os.Getuid()) && credential.Gid == uint32(os.Getgid()) {
+		return nil, nil
+	}
+	if os.Geteuid() != 0 {
+		return nil, errors.Errorf("the launcher must run as root to run processes as user '%s' and group '%s'",
+			userName, groupName)
+	}
+	return credential, nil
+}
+
+// setCredential makes the command run as the given user and group, if the launcher does not already run as them.
+func setCredential(cmd *exec.Cmd, userName, groupName string, logger io.Writer) error {
+	credential, err := getCredential(userName, groupName)
+	if err != nil || credential == nil {
+		return err
+	}
+	fmt.Fprintf(logger, "Running as uid %d and gid %d\n", credential.Uid, credential.Gid)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Credential: credential}
+	return nil
+}
+
+func lookupUser(name string) (*user.User, error) {
+	if _, err := strconv.Atoi(name); err == nil {
+		if u, err := user.LookupId(name); err == nil {
+			return u, nil
+		}
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find user %s", name)
+	}
+	return u, nil
+}
+
+func lookupGroup(name string) (*user.Group, error) {
+	if _, err := strconv.Atoi(name); err == nil {
+		if g, err := user.LookupGroupId(name); err == nil {
+			return g, nil
+		}
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to find group %s", name)
+	}
+	return g, nil
+}
+
+func credentialOf(cmd *exec.Cmd) *syscall.Credential {
+	if cmd.SysProcAttr == nil {
+		return nil
+	}
+	return cmd.SysProcAttr.Credential
+}
+
+// ChownDirs changes the owner of each of the given directories to its owner if set, or otherwise to the user and group
+// the command runs as if they differ from those of the launcher, so that directories created by a launcher running as
+// root are writable by the process.
+func ChownDirs(dirs []DirConfig, cmd *exec.Cmd) error {
+	credential := credentialOf(cmd)
+	for _, dir := range dirs {
+		if dir.Owner != "" {
+			uid, gid, err := lookupOwner(dir.Owner)
+			if err != nil {
+				return errors.Wrapf(err, "invalid owner of directory %s", dir.Path)
+			}
+			if err := os.Chown(dir.Path, uid, gid); err != nil {
+				return errors.Wrapf(err, "failed to change the owner of directory %s", dir.Path)
+			}
+			continue
+		}
+		if credential == nil {
+			continue
+		}
+		if err := os.Chown(dir.Path, int(credential.Uid), int(credential.Gid)); err != nil {
+			return errors.Wrapf(err, "failed to change the owner of directory %s", dir.Path)
+		}
+	}
+	return nil
+}
+
+// chownToCommand changes the owner of the given file to the user and group the command runs as, if they differ from
+// those of the launcher, so that a file readable only by its owner is readable by the process.
+func chownToCommand(path string, cmd *exec.Cmd) error {
+	credential := credentialOf(cmd)
+	if credential == nil {
+		return nil
+	}
+	if err := os.Chown(path, int(credential.Uid), int(credential.Gid)); err != nil {
+		return errors.Wrapf(err, "failed to change the owner of %s", path)
+	}
+	return nil
+}
+
+// lookupOwner returns the uid and gid of an owner of the form <user>[:<group>], in which the group defaults to the
+// primary group of the user.
+func lookupOwner(owner string) (int, int, error) {
+	parts := strings.SplitN(owner, ":", 2)
+	u, err := lookupUser(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid uid of user %s", parts[0])
+	}
+	gidString := u.Gid
+	if len(parts) == 2 {
+		g, err := lookupGroup(parts[1])
+		if err != nil {
+			return 0, 0, err
+		}
+		gidString = g.Gid
+	}
+	gid, err := strconv.Atoi(gidString)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "invalid gid of owner %s", owner)
+	}
+	return uid, gid, nil
+}
+
+// DropPrivileges changes the user, group and supplementary groups of the launcher to those the command runs as, for
+// commands that replace the launcher process with exec rather than starting a child process. The capabilities the
+// command keeps are raised on the calling thread, which must be locked to its goroutine until the exec.
+func DropPrivileges(cmd *exec.Cmd) error {
+	credential := credentialOf(cmd)
+	if credential == nil {
+		return nil
+	}
+	groups := make([]int, len(credential.Groups))
+	for i, gid := range credential.Groups {
+		groups[i] = int(gid)
+	}
+	if err := keepCapabilitiesOnSetuid(cmd); err != nil {
+		return err
+	}
+	// The groups must be changed while still root, before the user.
+	if err := syscall.Setgroups(groups); err != nil {
+		return errors.Wrap(err, "failed to set supplementary groups")
+	}
+	if err := syscall.Setgid(int(credential.Gid)); err != nil {
+		return errors.Wrapf(err, "failed to set group to %d", credential.Gid)
+	}
+	if err := syscall.Setuid(int(credential.Uid)); err != nil {
+		return errors.Wrapf(err, "failed to set user to %d", credential.Uid)
+	}
+	return raiseAmbientCapabilities(cmd)
+}
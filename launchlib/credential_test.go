@@ -0,0 +1,96 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCredential(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing the user of processes requires root")
+	}
+
+	for i, currCase := range []struct {
+		user  string
+		group string
+		want  *syscall.Credential
+	}{
+		{user: "", group: "", want: nil},
+		{user: "root", group: "", want: nil},
+		{user: "daemon", group: "", want: &syscall.Credential{Uid: 1, Gid: 1, Groups: []uint32{1}}},
+		{user: "1", group: "", want: &syscall.Credential{Uid: 1, Gid: 1, Groups: []uint32{1}}},
+		{user: "daemon", group: "nogroup", want: &syscall.Credential{Uid: 1, Gid: 65534, Groups: []uint32{1}}},
+		{user: "", group: "daemon", want: &syscall.Credential{Uid: 0, Gid: 1}},
+	} {
+		credential, err := getCredential(currCase.user, currCase.group)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, credential, "Case %d", i)
+	}
+
+	_, err := getCredential("no-such-user", "")
+	assert.EqualError(t, err, "failed to find user no-such-user: user: unknown user no-such-user")
+}
+
+func TestCompileCmdRunsAsUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing the user of processes requires root")
+	}
+
+	cmd, err := compileCmdFromConfig(&StaticLauncherConfig{
+		TypedConfig: TypedConfig{Type: "executable"},
+		Executable:  "/usr/bin/id",
+		User:        "daemon",
+	}, &CustomLauncherConfig{}, NewSimpleWriterLogger(ioutil.Discard).PrimaryLogger)
+	require.NoError(t, err)
+
+	output, err := cmd.Output()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(output), "uid=1(daemon) gid=1(daemon)"), string(output))
+}
+
+func TestLookupOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("the users and groups of the test are those of a root environment")
+	}
+
+	for i, currCase := range []struct {
+		owner   string
+		wantUID int
+		wantGID int
+	}{
+		{owner: "daemon", wantUID: 1, wantGID: 1},
+		{owner: "1:nogroup", wantUID: 1, wantGID: 65534},
+		{owner: "root:daemon", wantUID: 0, wantGID: 1},
+	} {
+		uid, gid, err := lookupOwner(currCase.owner)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.wantUID, uid, "Case %d", i)
+		assert.Equal(t, currCase.wantGID, gid, "Case %d", i)
+	}
+
+	_, _, err := lookupOwner("daemon:no-such-group")
+	assert.EqualError(t, err, "failed to find group no-such-group: group: unknown group no-such-group")
+}
@@ -0,0 +1,55 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package launchlib
+
+import (
+	"io"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// setCredential fails if a user or group is given, as processes cannot be started as other users on windows without
+// their passwords.
+func setCredential(cmd *exec.Cmd, userName, groupName string, logger io.Writer) error {
+	if userName != "" || groupName != "" {
+		return errors.New("user and group are not supported on windows")
+	}
+	return nil
+}
+
+// ChownDirs fails if a directory has an owner, and otherwise does nothing, as commands always run as the user of the
+// launcher on windows.
+func ChownDirs(dirs []DirConfig, cmd *exec.Cmd) error {
+	for _, dir := range dirs {
+		if dir.Owner != "" {
+			return errors.Errorf("owner of directory %s is not supported on windows", dir.Path)
+		}
+	}
+	return nil
+}
+
+// chownToCommand does nothing, as commands always run as the user of the launcher on windows.
+func chownToCommand(path string, cmd *exec.Cmd) error {
+	return nil
+}
+
+// DropPrivileges does nothing, as commands always run as the user of the launcher on windows.
+func DropPrivileges(cmd *exec.Cmd) error {
+	return nil
+}
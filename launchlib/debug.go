@@ -0,0 +1,90 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DebugPortEnvVar is the environment variable of the launcher that enables remote debugging of the primary process on
+// the given port, overriding the port of its debug config, so that debugging does not require editing the config.
+const DebugPortEnvVar = "GO_JAVA_LAUNCHER_DEBUG_PORT"
+
+// DebugConfig enables remote debugging of the JVM with the JDWP agent.
+type DebugConfig struct {
+	Port int `yaml:"port"`
+	// Suspend makes the JVM wait for a debugger to attach before running the main class.
+	Suspend bool `yaml:"suspend"`
+	// Address is the address the agent binds to, e.g. * for all interfaces, which defaults to localhost on java 9+
+	// and all interfaces on java 8.
+	Address string `yaml:"address"`
+}
+
+func (config *DebugConfig) validate() error {
+	if config.Port < 1 || config.Port > 65535 {
+		return errors.Errorf("port must be between 1 and 65535, found %d", config.Port)
+	}
+	if strings.ContainsAny(config.Address, ",:") {
+		return errors.Errorf("address must be a host name, IP address or *, found '%s'", config.Address)
+	}
+	return nil
+}
+
+// debugConfigFromEnv returns the debug config overridden by DebugPortEnvVar, if it is set.
+func debugConfigFromEnv(config *DebugConfig) (*DebugConfig, error) {
+	portValue := os.Getenv(DebugPortEnvVar)
+	if portValue == "" {
+		return config, nil
+	}
+	port, err := strconv.Atoi(portValue)
+	if err != nil {
+		return nil, errors.Errorf("%s must be a port, found '%s'", DebugPortEnvVar, portValue)
+	}
+	debug := DebugConfig{}
+	if config != nil {
+		debug = *config
+	}
+	debug.Port = port
+	if err := debug.validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", DebugPortEnvVar)
+	}
+	return &debug, nil
+}
+
+// getDebugJvmOpts returns the option that loads the JDWP agent as configured, unless the jvmOpts already load it.
+func getDebugJvmOpts(config *DebugConfig, jvmOpts []string) []string {
+	if config == nil {
+		return nil
+	}
+	for _, opt := range jvmOpts {
+		if strings.HasPrefix(opt, "-agentlib:jdwp") || strings.HasPrefix(opt, "-Xrunjdwp") {
+			return nil
+		}
+	}
+	suspend := "n"
+	if config.Suspend {
+		suspend = "y"
+	}
+	address := strconv.Itoa(config.Port)
+	if config.Address != "" {
+		address = config.Address + ":" + address
+	}
+	return []string{fmt.Sprintf("-agentlib:jdwp=transport=dt_socket,server=y,suspend=%s,address=%s", suspend, address)}
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDebugJvmOpts(t *testing.T) {
+	for i, currCase := range []struct {
+		config  *DebugConfig
+		jvmOpts []string
+		want    []string
+	}{
+		{config: nil, want: nil},
+		{
+			config: &DebugConfig{Port: 5005},
+			want:   []string{"-agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=5005"},
+		},
+		{
+			config: &DebugConfig{Port: 5005, Suspend: true, Address: "*"},
+			want:   []string{"-agentlib:jdwp=transport=dt_socket,server=y,suspend=y,address=*:5005"},
+		},
+		{
+			config:  &DebugConfig{Port: 5005},
+			jvmOpts: []string{"-agentlib:jdwp=transport=dt_socket,server=y,address=8000"},
+			want:    nil,
+		},
+	} {
+		assert.Equal(t, currCase.want, getDebugJvmOpts(currCase.config, currCase.jvmOpts), "Case %d", i)
+	}
+}
+
+func TestValidateDebugConfig(t *testing.T) {
+	assert.NoError(t, (&DebugConfig{Port: 5005, Address: "0.0.0.0"}).validate())
+	assert.EqualError(t, (&DebugConfig{}).validate(), "port must be between 1 and 65535, found 0")
+	assert.EqualError(t, (&DebugConfig{Port: 5005, Address: "localhost:5006"}).validate(),
+		"address must be a host name, IP address or *, found 'localhost:5006'")
+}
+
+func TestDebugConfigFromEnv(t *testing.T) {
+	defer func() {
+		_ = os.Unsetenv(DebugPortEnvVar)
+	}()
+	configured := &DebugConfig{Port: 5005, Suspend: true}
+
+	debug, err := debugConfigFromEnv(configured)
+	require.NoError(t, err)
+	assert.Equal(t, configured, debug)
+
+	require.NoError(t, os.Setenv(DebugPortEnvVar, "8000"))
+	debug, err = debugConfigFromEnv(configured)
+	require.NoError(t, err)
+	assert.Equal(t, &DebugConfig{Port: 8000, Suspend: true}, debug)
+	assert.Equal(t, 5005, configured.Port)
+
+	debug, err = debugConfigFromEnv(nil)
+	require.NoError(t, err)
+	assert.Equal(t, &DebugConfig{Port: 8000}, debug)
+
+	require.NoError(t, os.Setenv(DebugPortEnvVar, "debug"))
+	_, err = debugConfigFromEnv(nil)
+	assert.EqualError(t, err, "GO_JAVA_LAUNCHER_DEBUG_PORT must be a port, found 'debug'")
+}
@@ -0,0 +1,153 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultDependencyConfig holds the defaults of the unset durations of a DependencyConfig.
+var DefaultDependencyConfig = DependencyConfig{
+	Interval: time.Second,
+	Timeout:  5 * time.Minute,
+}
+
+// DependencyConfig is an endpoint that must be reachable before a process is launched, either a TCP address of the
+// form host:port that accepts connections or an HTTP URL that responds with a 2xx status. It is polled every Interval,
+// which also bounds each attempt, for up to Timeout. A dependency may instead be another Process of the service, which
+// 'go-init' starts first and waits for to pass its health check, if it has one.
+type DependencyConfig struct {
+	Address  string        `yaml:"address"`
+	URL      string        `yaml:"url"`
+	Process  string        `yaml:"process"`
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+func (config DependencyConfig) validate() error {
+	set := 0
+	for _, value := range []string{config.Address, config.URL, config.Process} {
+		if value != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("exactly one of address, url and process must be set")
+	}
+	if config.Address != "" {
+		if _, _, err := net.SplitHostPort(config.Address); err != nil {
+			return errors.Errorf("address must be of the form host:port, found %s", config.Address)
+		}
+	}
+	if config.URL != "" && !strings.HasPrefix(config.URL, "http://") && !strings.HasPrefix(config.URL, "https://") {
+		return errors.Errorf("url must be an http or https URL, found %s", config.URL)
+	}
+	if config.Interval < 0 || config.Timeout < 0 {
+		return errors.New("interval and timeout must not be negative")
+	}
+	return nil
+}
+
+// WithDefaults returns a copy of the config with each unset duration replaced by its default.
+func (config DependencyConfig) WithDefaults() DependencyConfig {
+	if config.Interval == 0 {
+		config.Interval = DefaultDependencyConfig.Interval
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultDependencyConfig.Timeout
+	}
+	return config
+}
+
+func (config DependencyConfig) String() string {
+	if config.Process != "" {
+		return "process " + config.Process
+	}
+	if config.URL != "" {
+		return config.URL
+	}
+	return config.Address
+}
+
+func (config DependencyConfig) check() error {
+	if config.URL != "" {
+		client := http.Client{Timeout: config.Interval}
+		resp, err := client.Get(config.URL)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return errors.Errorf("%s responded with status %d", config.URL, resp.StatusCode)
+		}
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", config.Address, config.Interval)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// WaitForDependencies waits for the given dependencies in parallel to be reachable, returning an error listing every
+// dependency that is not reachable within its timeout. Dependencies on processes are skipped, as they are ordered by
+// whoever starts the processes.
+func WaitForDependencies(dependencies []DependencyConfig, stdout io.Writer) error {
+	var probed []DependencyConfig
+	for _, dependency := range dependencies {
+		if dependency.Process == "" {
+			probed = append(probed, dependency.WithDefaults())
+		}
+	}
+	writer := &syncWriter{w: stdout}
+	errs := runParallel(len(probed), func(i int) error {
+		return waitForDependency(probed[i], writer)
+	})
+	return joinErrors("%d dependencies were not reachable", errs)
+}
+
+// ProcessDependencies returns the names of the processes in dependencies.
+func ProcessDependencies(dependencies []DependencyConfig) []string {
+	var names []string
+	for _, dependency := range dependencies {
+		if dependency.Process != "" {
+			names = append(names, dependency.Process)
+		}
+	}
+	return names
+}
+
+func waitForDependency(dependency DependencyConfig, stdout io.Writer) error {
+	fmt.Fprintf(stdout, "Waiting up to %v for dependency %s to be reachable\n", dependency.Timeout, dependency)
+	deadline := time.Now().Add(dependency.Timeout)
+	for {
+		err := dependency.check()
+		if err == nil {
+			fmt.Fprintf(stdout, "Dependency %s is reachable\n", dependency)
+			return nil
+		}
+		if !time.Now().Add(dependency.Interval).Before(deadline) {
+			return errors.Wrapf(err, "dependency %s was not reachable within %v", dependency, dependency.Timeout)
+		}
+		time.Sleep(dependency.Interval)
+	}
+}
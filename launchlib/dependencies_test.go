@@ -0,0 +1,101 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateDependencyConfig(t *testing.T) {
+	for i, currCase := range []struct {
+		config DependencyConfig
+		err    string
+	}{
+		{config: DependencyConfig{Address: "db:5432", Interval: time.Second, Timeout: time.Minute}},
+		{config: DependencyConfig{URL: "https://config-service/health"}},
+		{config: DependencyConfig{Process: "database"}},
+		{config: DependencyConfig{}, err: "exactly one of address, url and process must be set"},
+		{config: DependencyConfig{Address: "db", URL: "http://db"},
+			err: "exactly one of address, url and process must be set"},
+		{config: DependencyConfig{Address: "db:5432", Process: "database"},
+			err: "exactly one of address, url and process must be set"},
+		{config: DependencyConfig{Address: "db"}, err: "address must be of the form host:port, found db"},
+		{config: DependencyConfig{URL: "db:5432"}, err: "url must be an http or https URL, found db:5432"},
+		{config: DependencyConfig{Address: "db:5432", Timeout: -time.Second},
+			err: "interval and timeout must not be negative"},
+	} {
+		err := currCase.config.validate()
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestWaitForDependencies(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() {
+		_ = listener.Close()
+	}()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	assert.NoError(t, WaitForDependencies([]DependencyConfig{
+		{Address: listener.Addr().String()},
+		{URL: server.URL},
+		{Process: "database"},
+	}, ioutil.Discard))
+}
+
+func TestWaitForDependencies_Unreachable(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	err := WaitForDependencies([]DependencyConfig{
+		{URL: failing.URL, Interval: 10 * time.Millisecond, Timeout: 50 * time.Millisecond},
+	}, ioutil.Discard)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dependency "+failing.URL+" was not reachable within 50ms")
+	assert.Contains(t, err.Error(), "responded with status 503")
+}
+
+func TestWaitForDependencies_WaitsInParallel(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	started := time.Now()
+	err := WaitForDependencies([]DependencyConfig{
+		{URL: failing.URL, Interval: 50 * time.Millisecond, Timeout: 500 * time.Millisecond},
+		{URL: failing.URL + "/other", Interval: 50 * time.Millisecond, Timeout: 500 * time.Millisecond},
+	}, ioutil.Discard)
+	require.Error(t, err)
+	assert.True(t, time.Since(started) < 900*time.Millisecond, "dependencies were waited for in turn")
+	assert.Contains(t, err.Error(), "2 dependencies were not reachable:\n- dependency "+failing.URL+" was not")
+	assert.Contains(t, err.Error(), "\n- dependency "+failing.URL+"/other was not")
+}
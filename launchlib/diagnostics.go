@@ -0,0 +1,165 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DiagnosticsConfig configures the collection of the heap dumps and fatal error logs written by the JVM when it runs
+// out of memory or crashes, which are pruned when it is launched so that they do not fill the disk.
+type DiagnosticsConfig struct {
+	// Dir is the directory the artifacts are written to, relative to the working directory unless absolute.
+	Dir string `yaml:"dir"`
+	// MaxFiles is the number of the most recent artifacts that are kept.
+	MaxFiles int `yaml:"maxFiles"`
+	// MaxSize is the total size of the artifacts that are kept, e.g. 10G, which is unlimited if unset.
+	MaxSize string `yaml:"maxSize"`
+}
+
+var DefaultDiagnosticsConfig = DiagnosticsConfig{
+	Dir:      "var/log/crash",
+	MaxFiles: 5,
+}
+
+// diagnosticsFlagPrefixes are the prefixes of the jvmOpts that configure the artifacts themselves, which take
+// precedence over the diagnostics config.
+var diagnosticsFlagPrefixes = []string{"-XX:+HeapDumpOnOutOfMemoryError", "-XX:-HeapDumpOnOutOfMemoryError",
+	"-XX:HeapDumpPath=", "-XX:ErrorFile="}
+
+func (config DiagnosticsConfig) WithDefaults() DiagnosticsConfig {
+	if config.Dir == "" {
+		config.Dir = DefaultDiagnosticsConfig.Dir
+	}
+	if config.MaxFiles == 0 {
+		config.MaxFiles = DefaultDiagnosticsConfig.MaxFiles
+	}
+	return config
+}
+
+func (config *DiagnosticsConfig) validate() error {
+	if config.MaxFiles < 0 {
+		return errors.Errorf("maxFiles must not be negative, found %d", config.MaxFiles)
+	}
+	_, err := config.maxSizeBytes()
+	return err
+}
+
+// maxSizeBytes returns MaxSize in bytes, or 0 if it is unlimited.
+func (config DiagnosticsConfig) maxSizeBytes() (int64, error) {
+	size, err := parseMemorySize("maxSize", config.MaxSize)
+	if err != nil || size == "" || size == "max" {
+		return 0, err
+	}
+	return strconv.ParseInt(size, 10, 64)
+}
+
+func (config DiagnosticsConfig) dir(workingDir string) string {
+	if filepath.IsAbs(config.Dir) {
+		return filepath.Clean(config.Dir)
+	}
+	return filepath.Join(workingDir, config.Dir)
+}
+
+// getDiagnosticsJvmOpts returns the options that make the JVM write heap dumps and fatal error logs to the diagnostics
+// directory, unless the jvmOpts already configure them.
+func getDiagnosticsJvmOpts(config *DiagnosticsConfig, workingDir string, jvmOpts []string) []string {
+	if config == nil {
+		return nil
+	}
+	for _, opt := range jvmOpts {
+		for _, prefix := range diagnosticsFlagPrefixes {
+			if strings.HasPrefix(opt, prefix) {
+				return nil
+			}
+		}
+	}
+	dir := config.WithDefaults().dir(workingDir)
+	return []string{
+		"-XX:+HeapDumpOnOutOfMemoryError",
+		"-XX:HeapDumpPath=" + dir,
+		"-XX:ErrorFile=" + filepath.Join(dir, "hs_err_pid%p.log"),
+	}
+}
+
+// PrepareDiagnostics creates the diagnostics directory of the config, owned by the user the command runs as, and
+// removes the oldest artifacts in it beyond the configured number and total size. Does nothing if config is nil.
+func PrepareDiagnostics(config *DiagnosticsConfig, workingDirectory string, cmd *exec.Cmd, stdout io.Writer) error {
+	if config == nil {
+		return nil
+	}
+	diagnostics := config.WithDefaults()
+	workingDir, err := resolveWorkingDir(workingDirectory)
+	if err != nil {
+		return err
+	}
+	dir := diagnostics.dir(workingDir)
+	// Heap dumps contain the data of the process, so are only readable by its user.
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "failed to create diagnostics directory %s", dir)
+	}
+	if err := ChownDirs([]DirConfig{{Path: dir}}, cmd); err != nil {
+		return err
+	}
+	maxSize, err := diagnostics.maxSizeBytes()
+	if err != nil {
+		return err
+	}
+	// Core dumps collected into the same directory are pruned by their own limits.
+	return pruneDiagnostics(dir, func(name string) bool {
+		return !isCollectedCoreDump(name)
+	}, diagnostics.MaxFiles, maxSize, stdout)
+}
+
+// pruneDiagnostics removes the oldest of the files in dir whose names match, keeping at most maxFiles of the most
+// recent files whose total size is at most maxSize if it is positive.
+func pruneDiagnostics(dir string, match func(name string) bool, maxFiles int, maxSize int64, stdout io.Writer) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list diagnostics directory %s", dir)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().After(files[j].ModTime())
+	})
+
+	kept := 0
+	var keptSize int64
+	for _, file := range files {
+		if !file.Mode().IsRegular() || !match(file.Name()) {
+			continue
+		}
+		if kept < maxFiles && (maxSize <= 0 || keptSize+file.Size() <= maxSize) {
+			kept++
+			keptSize += file.Size()
+			continue
+		}
+		path := filepath.Join(dir, file.Name())
+		if err := os.Remove(path); err != nil {
+			return errors.Wrapf(err, "failed to remove diagnostics file %s", path)
+		}
+		fmt.Fprintln(stdout, "Removed old diagnostics file", path)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetDiagnosticsJvmOpts(t *testing.T) {
+	for i, currCase := range []struct {
+		config  *DiagnosticsConfig
+		jvmOpts []string
+		want    []string
+	}{
+		{config: nil, want: nil},
+		{
+			config: &DiagnosticsConfig{},
+			want: []string{"-XX:+HeapDumpOnOutOfMemoryError", "-XX:HeapDumpPath=/opt/service/var/log/crash",
+				"-XX:ErrorFile=/opt/service/var/log/crash/hs_err_pid%p.log"},
+		},
+		{
+			config: &DiagnosticsConfig{Dir: "/data/crash"},
+			want: []string{"-XX:+HeapDumpOnOutOfMemoryError", "-XX:HeapDumpPath=/data/crash",
+				"-XX:ErrorFile=/data/crash/hs_err_pid%p.log"},
+		},
+		{config: &DiagnosticsConfig{}, jvmOpts: []string{"-Xmx1g", "-XX:HeapDumpPath=/tmp"}, want: nil},
+		{config: &DiagnosticsConfig{}, jvmOpts: []string{"-XX:-HeapDumpOnOutOfMemoryError"}, want: nil},
+	} {
+		assert.Equal(t, currCase.want, getDiagnosticsJvmOpts(currCase.config, "/opt/service", currCase.jvmOpts),
+			"Case %d", i)
+	}
+}
+
+func TestValidateDiagnosticsConfig(t *testing.T) {
+	for i, currCase := range []struct {
+		config DiagnosticsConfig
+		err    string
+	}{
+		{config: DiagnosticsConfig{MaxFiles: 3, MaxSize: "10G"}},
+		{config: DiagnosticsConfig{MaxFiles: -1}, err: "maxFiles must not be negative, found -1"},
+		{config: DiagnosticsConfig{MaxSize: "lots"},
+			err: "maxSize must be a number of bytes with an optional K, M, G or T suffix, or max, found 'lots'"},
+	} {
+		err := currCase.config.validate()
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestPruneDiagnostics(t *testing.T) {
+	for i, currCase := range []struct {
+		maxFiles int
+		maxSize  int64
+		want     []string
+	}{
+		{maxFiles: 5, want: []string{"hs_err_pid1.log", "hs_err_pid2.log", "java_pid3.hprof"}},
+		{maxFiles: 2, want: []string{"hs_err_pid2.log", "java_pid3.hprof"}},
+		// The most recent dump exceeds the total size by itself, so only the older error logs are kept.
+		{maxFiles: 5, maxSize: 20, want: []string{"hs_err_pid1.log", "hs_err_pid2.log"}},
+	} {
+		dir, err := ioutil.TempDir("", "launchlib-diagnostics")
+		require.NoError(t, err, "Case %d", i)
+		defer func() {
+			_ = os.RemoveAll(dir)
+		}()
+		now := time.Now()
+		for age, file := range []struct {
+			name string
+			size int
+		}{{"java_pid3.hprof", 100}, {"hs_err_pid2.log", 10}, {"hs_err_pid1.log", 10}} {
+			path := filepath.Join(dir, file.name)
+			require.NoError(t, ioutil.WriteFile(path, make([]byte, file.size), 0644), "Case %d", i)
+			modTime := now.Add(-time.Duration(age) * time.Hour)
+			require.NoError(t, os.Chtimes(path, modTime, modTime), "Case %d", i)
+		}
+
+		require.NoError(t, pruneDiagnostics(dir, func(string) bool {
+			return true
+		}, currCase.maxFiles, currCase.maxSize, ioutil.Discard), "Case %d", i)
+		files, err := ioutil.ReadDir(dir)
+		require.NoError(t, err, "Case %d", i)
+		var names []string
+		for _, file := range files {
+			names = append(names, file.Name())
+		}
+		sort.Strings(names)
+		assert.Equal(t, currCase.want, names, "Case %d", i)
+	}
+}
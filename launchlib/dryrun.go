@@ -0,0 +1,123 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// WriteDryRun writes the command line, working directory and environment of the named command to w, as printed in place
+// of running it by --dry-run, with sensitive values redacted by redactor.
+func WriteDryRun(w io.Writer, name string, cmd *exec.Cmd, redactor Redactor) {
+	workingDir := cmd.Dir
+	if workingDir == "" {
+		workingDir = getWorkingDir()
+	}
+	env := redactor.redactArgs(cmd.Env)
+	sort.Strings(env)
+
+	fmt.Fprintf(w, "Process: %s\n", name)
+	fmt.Fprintf(w, "Working directory: %s\n", workingDir)
+	fmt.Fprintf(w, "Command: %s\n", quoteArgs(redactor.redactArgs(cmd.Args)))
+	fmt.Fprintln(w, "Environment:")
+	for _, variable := range env {
+		fmt.Fprintf(w, "  %s\n", variable)
+	}
+	fmt.Fprintln(w)
+}
+
+// CompiledCommand is the command line, working directory and environment with which a process is launched, as printed
+// by 'go-init env'.
+type CompiledCommand struct {
+	Name string            `json:"name"`
+	Path string            `json:"path"`
+	Args []string          `json:"args"`
+	Dir  string            `json:"dir"`
+	Env  map[string]string `json:"env"`
+}
+
+// NewCompiledCommand returns the CompiledCommand of the named command, with sensitive values redacted by redactor
+// unless it is nil.
+func NewCompiledCommand(name string, cmd *exec.Cmd, redactor *Redactor) CompiledCommand {
+	compiled := CompiledCommand{
+		Name: name,
+		Path: cmd.Path,
+		Args: cmd.Args,
+		Dir:  cmd.Dir,
+		Env:  make(map[string]string, len(cmd.Env)),
+	}
+	if compiled.Dir == "" {
+		compiled.Dir = getWorkingDir()
+	}
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	if redactor != nil {
+		compiled.Args = redactor.redactArgs(compiled.Args)
+		env = redactor.redactArgs(env)
+	}
+	for _, variable := range env {
+		if parts := strings.SplitN(variable, "=", 2); len(parts) == 2 {
+			compiled.Env[parts[0]] = parts[1]
+		}
+	}
+	return compiled
+}
+
+// WriteShell writes the command to w as a shell script that exports its environment, changes to its working directory
+// and sets the positional parameters to its command line, such that evaluating the script followed by 'exec "$@"'
+// runs the command as the launcher would.
+func (c CompiledCommand) WriteShell(w io.Writer) {
+	names := make([]string, 0, len(c.Env))
+	for name := range c.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "export %s=%s\n", name, quoteArg(c.Env[name]))
+	}
+	fmt.Fprintf(w, "cd %s\n", quoteArg(c.Dir))
+	args := []string{c.Path}
+	if len(c.Args) > 1 {
+		args = append(args, c.Args[1:]...)
+	}
+	fmt.Fprintf(w, "set -- %s\n", quoteArgs(args))
+}
+
+// quoteArgs joins args with spaces, quoting each as by quoteArg.
+func quoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// quoteArg single-quotes arg unless it consists only of characters that a shell neither splits on nor expands.
+func quoteArg(arg string) string {
+	if arg != "" && strings.Trim(arg, shellSafeChars) == "" {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+}
+
+const shellSafeChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./_-"
@@ -0,0 +1,81 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"os/exec"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteDryRun(t *testing.T) {
+	cmd := &exec.Cmd{
+		Path: "/opt/java/bin/java",
+		Args: []string{"/opt/java/bin/java", "-Dname=it's", "-classpath", "/service/lib/a.jar", "Main", ""},
+		Env:  []string{"SOME_VAR=value", "JAVA_HOME=/opt/java", "DB_PASSWORD=hunter2"},
+		Dir:  "/service",
+	}
+
+	var buf bytes.Buffer
+	WriteDryRun(&buf, "primary", cmd, StaticLauncherConfig{}.Redactor())
+	assert.Equal(t, `Process: primary
+Working directory: /service
+Command: /opt/java/bin/java '-Dname=it'\''s' -classpath /service/lib/a.jar Main ''
+Environment:
+  DB_PASSWORD=<redacted>
+  JAVA_HOME=/opt/java
+  SOME_VAR=value
+
+`, buf.String())
+}
+
+func TestCompiledCommandWriteShell(t *testing.T) {
+	cmd := &exec.Cmd{
+		Path: "/opt/java/bin/java",
+		Args: []string{"java", "-Dname=it's", "-classpath", "/service/lib/a.jar", "Main", "$HOME"},
+		Env:  []string{"SOME_VAR=a b", "JAVA_HOME=/opt/java", "DB_PASSWORD=hunter2"},
+		Dir:  "/service",
+	}
+
+	for i, currCase := range []struct {
+		redactor *Redactor
+		want     string
+	}{
+		{
+			want: `export DB_PASSWORD=hunter2
+export JAVA_HOME=/opt/java
+export SOME_VAR='a b'
+cd /service
+set -- /opt/java/bin/java '-Dname=it'\''s' -classpath /service/lib/a.jar Main '$HOME'
+`,
+		},
+		{
+			redactor: &Redactor{patterns: []*regexp.Regexp{regexp.MustCompile("PASSWORD")}},
+			want: `export DB_PASSWORD='<redacted>'
+export JAVA_HOME=/opt/java
+export SOME_VAR='a b'
+cd /service
+set -- /opt/java/bin/java '-Dname=it'\''s' -classpath /service/lib/a.jar Main '$HOME'
+`,
+		},
+	} {
+		var buf bytes.Buffer
+		NewCompiledCommand("primary", cmd, currCase.redactor).WriteShell(&buf)
+		assert.Equal(t, currCase.want, buf.String(), "Case %d", i)
+	}
+}
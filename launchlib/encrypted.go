@@ -0,0 +1,128 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	aesKeyPrefix = "AES:"
+	gcmNonceSize = 12
+)
+
+var (
+	// encryptedValueKeyFile is the file of the key that decrypts encrypted values, relative to the working directory
+	// unless absolute.
+	encryptedValueKeyFile = "var/conf/encrypted-config-value.key"
+
+	// encryptedValuePattern matches encrypted values of the form ${enc:<base64 ciphertext>}.
+	encryptedValuePattern = regexp.MustCompile(`\$\{enc:([^}]*)\}`)
+
+	// decryptedValues holds every value decrypted by the launcher, so that they can be redacted from its output.
+	decryptedValues     = map[string]struct{}{}
+	decryptedValuesLock sync.Mutex
+)
+
+// decryptValues replaces each encrypted value in value with its plaintext, decrypted with the key of
+// encryptedValueKeyFile. The key file has the form AES:<base64 key>, and each encrypted value is the base64 encoding of
+// a 12 byte nonce followed by the AES-GCM ciphertext and tag, as produced by encrypted-config-value tooling.
+func decryptValues(value string) (string, error) {
+	if !encryptedValuePattern.MatchString(value) {
+		return value, nil
+	}
+	gcm, err := loadEncryptedValueCipher()
+	if err != nil {
+		return "", err
+	}
+
+	var decryptErr error
+	decrypted := encryptedValuePattern.ReplaceAllStringFunc(value, func(encrypted string) string {
+		plaintext, err := decryptValue(gcm, encryptedValuePattern.FindStringSubmatch(encrypted)[1])
+		if err != nil && decryptErr == nil {
+			decryptErr = err
+		}
+		return plaintext
+	})
+	if decryptErr != nil {
+		return "", decryptErr
+	}
+	return decrypted, nil
+}
+
+func loadEncryptedValueCipher() (cipher.AEAD, error) {
+	keyFile := encryptedValueKeyFile
+	if !filepath.IsAbs(keyFile) {
+		keyFile = filepath.Join(getWorkingDir(), keyFile)
+	}
+	keyData, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the key of encrypted values")
+	}
+	encodedKey := strings.TrimSpace(string(keyData))
+	if !strings.HasPrefix(encodedKey, aesKeyPrefix) {
+		return nil, errors.Errorf("key of encrypted values in %s must be of the form %s<base64 key>", keyFile,
+			aesKeyPrefix)
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encodedKey, aesKeyPrefix))
+	if err != nil {
+		return nil, errors.Wrapf(err, "key of encrypted values in %s is not valid base64", keyFile)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid key of encrypted values in %s", keyFile)
+	}
+	return cipher.NewGCM(block)
+}
+
+func decryptValue(gcm cipher.AEAD, encoded string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.Wrap(err, "encrypted value is not valid base64")
+	}
+	if len(ciphertext) < gcmNonceSize+gcm.Overhead() {
+		return "", errors.New("encrypted value is too short")
+	}
+	plaintext, err := gcm.Open(nil, ciphertext[:gcmNonceSize], ciphertext[gcmNonceSize:], nil)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to decrypt encrypted value, it may have been encrypted with another key")
+	}
+
+	decryptedValuesLock.Lock()
+	defer decryptedValuesLock.Unlock()
+	if len(plaintext) > 0 {
+		decryptedValues[string(plaintext)] = struct{}{}
+	}
+	return string(plaintext), nil
+}
+
+// redactDecryptedValues replaces each value decrypted by the launcher in value.
+func redactDecryptedValues(value string) string {
+	decryptedValuesLock.Lock()
+	defer decryptedValuesLock.Unlock()
+	for plaintext := range decryptedValues {
+		value = strings.Replace(value, plaintext, redactedValue, -1)
+	}
+	return value
+}
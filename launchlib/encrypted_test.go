@@ -0,0 +1,103 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testEncryptionKey = []byte("0123456789abcdef0123456789abcdef")
+
+func encryptTestValue(t *testing.T, plaintext string) string {
+	block, err := aes.NewCipher(testEncryptionKey)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	nonce := []byte("fixed-nonce!")
+	return "${enc:" + base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(plaintext), nil)) + "}"
+}
+
+func withTestKeyFile(t *testing.T, content string) func() {
+	dir, err := ioutil.TempDir("", "encrypted")
+	require.NoError(t, err)
+	keyFile := filepath.Join(dir, "encrypted-config-value.key")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte(content), 0600))
+	original := encryptedValueKeyFile
+	encryptedValueKeyFile = keyFile
+	return func() {
+		encryptedValueKeyFile = original
+		require.NoError(t, os.RemoveAll(dir))
+	}
+}
+
+func TestDecryptValues(t *testing.T) {
+	defer withTestKeyFile(t, "AES:"+base64.StdEncoding.EncodeToString(testEncryptionKey)+"\n")()
+
+	decrypted, err := decryptValues("-Dpassword=" + encryptTestValue(t, "hunter2"))
+	require.NoError(t, err)
+	assert.Equal(t, "-Dpassword=hunter2", decrypted)
+
+	plain, err := decryptValues("no encrypted values")
+	require.NoError(t, err)
+	assert.Equal(t, "no encrypted values", plain)
+
+	invalid := base64.StdEncoding.EncodeToString([]byte("not a valid ciphertext of sufficient length"))
+	_, err = decryptValues("${enc:" + invalid + "}")
+	assert.EqualError(t, err, "failed to decrypt encrypted value, it may have been encrypted with another key: "+
+		"cipher: message authentication failed")
+}
+
+func TestDecryptValuesInvalidKey(t *testing.T) {
+	defer withTestKeyFile(t, "RSA:abc")()
+
+	_, err := decryptValues(encryptTestValue(t, "hunter2"))
+	assert.Contains(t, err.Error(), "must be of the form AES:<base64 key>")
+}
+
+func TestCompileCmdDecryptsValues(t *testing.T) {
+	defer withTestKeyFile(t, "AES:"+base64.StdEncoding.EncodeToString(testEncryptionKey))()
+
+	var log bytes.Buffer
+	cmd, err := compileCmdFromConfig(&StaticLauncherConfig{
+		TypedConfig: TypedConfig{Type: "executable"},
+		Executable:  "/bin/ls",
+		Args:        []string{"--connect"},
+		Env:         map[string]string{"DB_URL": encryptTestValue(t, "postgres://db")},
+		CleanEnv:    true,
+	}, &CustomLauncherConfig{
+		Env: map[string]string{"API_KEY": encryptTestValue(t, "abc123")},
+	}, NewSimpleWriterLogger(&log).PrimaryLogger)
+	require.NoError(t, err)
+
+	sort.Strings(cmd.Env)
+	assert.Equal(t, []string{"API_KEY=abc123", "DB_URL=postgres://db"}, cmd.Env)
+	assert.NotContains(t, log.String(), "abc123")
+
+	var dryRun bytes.Buffer
+	WriteDryRun(&dryRun, "primary", cmd, StaticLauncherConfig{}.Redactor())
+	assert.Contains(t, dryRun.String(), "API_KEY=<redacted>")
+	assert.Contains(t, dryRun.String(), "DB_URL=<redacted>")
+}
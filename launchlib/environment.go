@@ -0,0 +1,130 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// validateEnvPassthrough validates that envPassthrough is only set along with cleanEnv, and that each of its entries is
+// a valid pattern.
+func validateEnvPassthrough(cleanEnv bool, envPassthrough []string) error {
+	if len(envPassthrough) > 0 && !cleanEnv {
+		return errors.New("envPassthrough can only be set along with cleanEnv, as the whole environment is " +
+			"inherited otherwise")
+	}
+	for _, pattern := range envPassthrough {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return errors.Wrapf(err, "invalid envPassthrough pattern '%s'", pattern)
+		}
+	}
+	return nil
+}
+
+// getInheritedEnv returns the environment of the launcher that a process inherits, of the form KEY=VALUE: all of it
+// unless cleanEnv is set, in which case only the variables whose names match one of the envPassthrough patterns.
+func getInheritedEnv(cleanEnv bool, envPassthrough []string) []string {
+	environ := os.Environ()
+	if !cleanEnv {
+		return environ
+	}
+
+	inherited := []string{}
+	for _, entry := range environ {
+		name := strings.SplitN(entry, "=", 2)[0]
+		for _, pattern := range envPassthrough {
+			// The patterns were validated along with the config.
+			if matched, _ := filepath.Match(pattern, name); matched {
+				inherited = append(inherited, entry)
+				break
+			}
+		}
+	}
+	return inherited
+}
+
+var envFileKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// loadEnvFiles returns the variables of the given env files, relative to workingDir unless absolute, with those of
+// later files taking precedence.
+func loadEnvFiles(workingDir string, envFiles []string) (map[string]string, error) {
+	env := map[string]string{}
+	for _, envFile := range envFiles {
+		if !filepath.IsAbs(envFile) {
+			envFile = filepath.Join(workingDir, envFile)
+		}
+		data, err := ioutil.ReadFile(envFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read env file")
+		}
+		fileEnv, err := parseEnvFile(data)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid env file %s", envFile)
+		}
+		for key, value := range fileEnv {
+			env[key] = value
+		}
+	}
+	return env, nil
+}
+
+// parseEnvFile parses lines of the form KEY=VALUE, optionally prefixed with 'export ' and with the value optionally
+// enclosed in single or double quotes. Blank lines and lines starting with # are ignored.
+func parseEnvFile(data []byte) (map[string]string, error) {
+	env := map[string]string{}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(line, "export "), "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || !envFileKeyPattern.MatchString(key) {
+			return nil, errors.Errorf("line %d is not of the form KEY=VALUE", i+1)
+		}
+		env[key] = unquote(strings.TrimSpace(parts[1]))
+	}
+	return env, nil
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// loadEnvFromFiles returns the value of each of the given environment variables read from its file, relative to
+// workingDir unless absolute, with trailing newlines removed.
+func loadEnvFromFiles(workingDir string, envFromFiles map[string]string) (map[string]string, error) {
+	env := make(map[string]string, len(envFromFiles))
+	for name, file := range envFromFiles {
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(workingDir, file)
+		}
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read file of environment variable %s", name)
+		}
+		env[name] = strings.TrimRight(string(data), "\r\n")
+	}
+	return env, nil
+}
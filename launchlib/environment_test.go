@@ -0,0 +1,165 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetInheritedEnv(t *testing.T) {
+	for key, value := range map[string]string{
+		"LAUNCHER_TEST_KEPT":     "kept",
+		"LAUNCHER_TEST_LC_ONE":   "one",
+		"LAUNCHER_TEST_LC_TWO":   "two",
+		"LAUNCHER_TEST_DROPPED":  "dropped",
+		"LAUNCHER_TEST_KEPT_NOT": "dropped",
+	} {
+		original, ok := os.LookupEnv(key)
+		require.NoError(t, os.Setenv(key, value))
+		defer func(key string) {
+			if ok {
+				require.NoError(t, os.Setenv(key, original))
+			} else {
+				require.NoError(t, os.Unsetenv(key))
+			}
+		}(key)
+	}
+
+	assert.Equal(t, os.Environ(), getInheritedEnv(false, nil))
+	assert.Equal(t, []string{}, getInheritedEnv(true, nil))
+
+	inherited := getInheritedEnv(true, []string{"LAUNCHER_TEST_KEPT", "LAUNCHER_TEST_LC_*"})
+	sort.Strings(inherited)
+	assert.Equal(t, []string{
+		"LAUNCHER_TEST_KEPT=kept",
+		"LAUNCHER_TEST_LC_ONE=one",
+		"LAUNCHER_TEST_LC_TWO=two",
+	}, inherited)
+}
+
+func TestCleanEnvCommand(t *testing.T) {
+	original, ok := os.LookupEnv("LAUNCHER_TEST_STRAY")
+	require.NoError(t, os.Setenv("LAUNCHER_TEST_STRAY", "stray"))
+	defer func() {
+		if ok {
+			require.NoError(t, os.Setenv("LAUNCHER_TEST_STRAY", original))
+		} else {
+			require.NoError(t, os.Unsetenv("LAUNCHER_TEST_STRAY"))
+		}
+	}()
+
+	cmd, err := compileCmdFromConfig(&StaticLauncherConfig{
+		TypedConfig:    TypedConfig{Type: "executable"},
+		Executable:     "/bin/ls",
+		Env:            map[string]string{"CONFIGURED": "value"},
+		CleanEnv:       true,
+		EnvPassthrough: []string{"PATH"},
+	}, &CustomLauncherConfig{}, NewSimpleWriterLogger(ioutil.Discard).PrimaryLogger)
+	require.NoError(t, err)
+
+	sort.Strings(cmd.Env)
+	assert.Equal(t, []string{"CONFIGURED=value", "PATH=" + os.Getenv("PATH")}, cmd.Env)
+}
+
+func TestParseEnvFile(t *testing.T) {
+	env, err := parseEnvFile([]byte(`
+# deploy-time values
+PLAIN=value
+export EXPORTED=exported
+DOUBLE_QUOTED="with spaces"
+SINGLE_QUOTED='single'
+EMPTY=
+WITH_EQUALS=a=b
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PLAIN":         "value",
+		"EXPORTED":      "exported",
+		"DOUBLE_QUOTED": "with spaces",
+		"SINGLE_QUOTED": "single",
+		"EMPTY":         "",
+		"WITH_EQUALS":   "a=b",
+	}, env)
+
+	_, err = parseEnvFile([]byte("VALID=value\nnot a variable\n"))
+	assert.EqualError(t, err, "line 2 is not of the form KEY=VALUE")
+}
+
+func TestEnvFilesCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envfiles")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	first := filepath.Join(dir, "first.env")
+	second := filepath.Join(dir, "second.env")
+	require.NoError(t, ioutil.WriteFile(first, []byte("A=first\nB=first\nC=first\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(second, []byte("B=second\nC=second\n"), 0644))
+
+	cmd, err := compileCmdFromConfig(&StaticLauncherConfig{
+		TypedConfig: TypedConfig{Type: "executable"},
+		Executable:  "/bin/ls",
+		Env:         map[string]string{"C": "configured"},
+		CleanEnv:    true,
+		EnvFiles:    []string{first, second},
+	}, &CustomLauncherConfig{}, NewSimpleWriterLogger(ioutil.Discard).PrimaryLogger)
+	require.NoError(t, err)
+	sort.Strings(cmd.Env)
+	assert.Equal(t, []string{"A=first", "B=second", "C=configured"}, cmd.Env)
+
+	_, err = compileCmdFromConfig(&StaticLauncherConfig{
+		TypedConfig: TypedConfig{Type: "executable"},
+		Executable:  "/bin/ls",
+		EnvFiles:    []string{filepath.Join(dir, "missing.env")},
+	}, &CustomLauncherConfig{}, NewSimpleWriterLogger(ioutil.Discard).PrimaryLogger)
+	assert.Error(t, err)
+}
+
+func TestEnvFromFilesCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "envfromfiles")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "password"), []byte("hunter2\n"), 0600))
+	envFile := filepath.Join(dir, "deploy.env")
+	require.NoError(t, ioutil.WriteFile(envFile, []byte("DB_PASSWORD=from-env-file\n"), 0644))
+
+	var log bytes.Buffer
+	staticConfig := StaticLauncherConfig{
+		TypedConfig:  TypedConfig{Type: "executable"},
+		Executable:   "/bin/ls",
+		Env:          map[string]string{"DB_PASSWORD": "configured"},
+		CleanEnv:     true,
+		EnvFiles:     []string{envFile},
+		EnvFromFiles: map[string]string{"DB_PASSWORD": filepath.Join(dir, "password")},
+	}
+	cmd, err := compileCmdFromConfig(&staticConfig, &CustomLauncherConfig{}, NewSimpleWriterLogger(&log).PrimaryLogger)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"DB_PASSWORD=hunter2"}, cmd.Env)
+	assert.NotContains(t, log.String(), "hunter2")
+
+	staticConfig.EnvFromFiles = map[string]string{"DB_PASSWORD": filepath.Join(dir, "missing")}
+	_, err = compileCmdFromConfig(&staticConfig, &CustomLauncherConfig{}, NewSimpleWriterLogger(&log).PrimaryLogger)
+	assert.Error(t, err)
+}
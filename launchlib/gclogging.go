@@ -0,0 +1,57 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// gcLogFile is the file GC logs are written to, relative to the working directory.
+	gcLogFile = "var/log/gc.log"
+	// gcLogFileCount and gcLogFileSizeMegabytes bound the rotated GC logs to 100MB.
+	gcLogFileCount         = 10
+	gcLogFileSizeMegabytes = 10
+)
+
+// gcLoggingFlagPrefixes are the prefixes of the jvmOpts that configure GC logging, on java 9+ and java 8 respectively.
+var gcLoggingFlagPrefixes = []string{"-Xlog:gc", "-Xloggc:"}
+
+// getGCLoggingJvmOpts returns the options that make a JVM of the given major version log garbage collections to
+// gcLogFile with rotation, unless the jvmOpts already configure GC logging.
+func getGCLoggingJvmOpts(majorVersion int, workingDir string, jvmOpts []string) []string {
+	for _, opt := range jvmOpts {
+		for _, prefix := range gcLoggingFlagPrefixes {
+			if strings.HasPrefix(opt, prefix) {
+				return nil
+			}
+		}
+	}
+	logFile := filepath.Join(workingDir, gcLogFile)
+	if majorVersion >= 9 {
+		return []string{fmt.Sprintf("-Xlog:gc*:file=%s:time,uptime,level,tags:filecount=%d,filesize=%dm", logFile,
+			gcLogFileCount, gcLogFileSizeMegabytes)}
+	}
+	return []string{
+		"-Xloggc:" + logFile,
+		"-XX:+PrintGCDetails",
+		"-XX:+PrintGCDateStamps",
+		"-XX:+UseGCLogFileRotation",
+		fmt.Sprintf("-XX:NumberOfGCLogFiles=%d", gcLogFileCount),
+		fmt.Sprintf("-XX:GCLogFileSize=%dM", gcLogFileSizeMegabytes),
+	}
+}
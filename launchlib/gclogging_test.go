@@ -0,0 +1,45 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGCLoggingJvmOpts(t *testing.T) {
+	for i, currCase := range []struct {
+		majorVersion int
+		jvmOpts      []string
+		want         []string
+	}{
+		{
+			majorVersion: 8,
+			want: []string{"-Xloggc:/opt/service/var/log/gc.log", "-XX:+PrintGCDetails", "-XX:+PrintGCDateStamps",
+				"-XX:+UseGCLogFileRotation", "-XX:NumberOfGCLogFiles=10", "-XX:GCLogFileSize=10M"},
+		},
+		{
+			majorVersion: 11,
+			want: []string{
+				"-Xlog:gc*:file=/opt/service/var/log/gc.log:time,uptime,level,tags:filecount=10,filesize=10m"},
+		},
+		{majorVersion: 17, jvmOpts: []string{"-Xmx1g", "-Xlog:gc:stdout"}, want: nil},
+		{majorVersion: 8, jvmOpts: []string{"-Xloggc:var/log/custom-gc.log"}, want: nil},
+	} {
+		assert.Equal(t, currCase.want, getGCLoggingJvmOpts(currCase.majorVersion, "/opt/service", currCase.jvmOpts),
+			"Case %d", i)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultHookTimeout is how long a hook may run if it does not set a timeout.
+const DefaultHookTimeout = time.Minute
+
+// HooksConfig configures the commands that 'go-init' runs around starting and stopping a process.
+type HooksConfig struct {
+	// PreStart hooks run before the process is started, which is not started if any of them fails.
+	PreStart []HookConfig `yaml:"preStart"`
+	// PostStart hooks run once the process has started, passed its health check and outlived its startup window.
+	PostStart []HookConfig `yaml:"postStart"`
+	// PreStop hooks run before the process is stopped, which is stopped even if any of them fails.
+	PreStop []HookConfig `yaml:"preStop"`
+}
+
+// HookConfig is a command run by a hook, which is killed if it runs for longer than its timeout.
+type HookConfig struct {
+	Command []string      `yaml:"command"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func (config HooksConfig) validate() error {
+	for phase, hooks := range map[string][]HookConfig{
+		"preStart":  config.PreStart,
+		"postStart": config.PostStart,
+		"preStop":   config.PreStop,
+	} {
+		for i, hook := range hooks {
+			if len(hook.Command) == 0 || hook.Command[0] == "" {
+				return errors.Errorf("%s hook %d must have a command", phase, i)
+			}
+			if hook.Timeout < 0 {
+				return errors.Errorf("%s hook %d must not have a negative timeout", phase, i)
+			}
+		}
+	}
+	return nil
+}
+
+// TimeoutOrDefault returns the timeout of the hook, or DefaultHookTimeout if it is not set.
+func (config HookConfig) TimeoutOrDefault() time.Duration {
+	if config.Timeout == 0 {
+		return DefaultHookTimeout
+	}
+	return config.Timeout
+}
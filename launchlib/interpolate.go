@@ -0,0 +1,184 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"os"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// envReferencePattern matches references to environment variables of the form ${VAR} or ${VAR:-default}.
+var envReferencePattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolateEnv replaces each reference to an environment variable in value with its value, or with the default given
+// by the reference if the variable is unset or empty. Returns an error if such a variable has no default.
+func interpolateEnv(value string) (string, error) {
+	var err error
+	interpolated := envReferencePattern.ReplaceAllStringFunc(value, func(reference string) string {
+		match := envReferencePattern.FindStringSubmatch(reference)
+		if envValue := os.Getenv(match[1]); envValue != "" {
+			tracef("Expanded %s from the environment", reference)
+			return envValue
+		}
+		tracef("Expanded %s to its default, as %s is not set", reference, match[1])
+		if match[2] == "" && err == nil {
+			err = errors.Errorf("environment variable %s referenced by '%s' is not set and has no default",
+				match[1], value)
+		}
+		return match[3]
+	})
+	return interpolated, err
+}
+
+// expandValue replaces the references to environment variables and then to template variables in value.
+func expandValue(value string) (string, error) {
+	interpolated, err := interpolateEnv(value)
+	if err != nil {
+		return "", err
+	}
+	return expandTemplates(interpolated)
+}
+
+// expandSecretValue is expandValue followed by the decryption of encrypted values, for values that may hold secrets.
+func expandSecretValue(value string) (string, error) {
+	expanded, err := expandValue(value)
+	if err != nil {
+		return "", err
+	}
+	return decryptValues(expanded)
+}
+
+// interpolateSecretEnv is interpolateEnv followed by the decryption of encrypted values.
+func interpolateSecretEnv(value string) (string, error) {
+	interpolated, err := interpolateEnv(value)
+	if err != nil {
+		return "", err
+	}
+	return decryptValues(interpolated)
+}
+
+func expandSlice(values []string, expand func(string) (string, error)) ([]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	expanded := make([]string, len(values))
+	for i, value := range values {
+		var err error
+		if expanded[i], err = expand(value); err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+func expandMap(values map[string]string, expand func(string) (string, error)) (map[string]string, error) {
+	if values == nil {
+		return nil, nil
+	}
+	expanded := make(map[string]string, len(values))
+	for key, value := range values {
+		var err error
+		if expanded[key], err = expand(value); err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+// interpolated returns a copy of the config with environment and template variable references replaced in its paths,
+// jvmOpts, args and classpath, and environment variable references replaced in its env, whose template variables are
+// replaced by replaceEnvironmentVariables once merged with the custom env. Encrypted values are decrypted in its
+// jvmOpts and env.
+func (config StaticLauncherConfig) interpolated() (StaticLauncherConfig, error) {
+	var err error
+	for _, field := range []struct {
+		name  string
+		value *string
+	}{
+		{"javaHome", &config.JavaHome},
+		{"jdkDir", &config.JdkDir},
+		{"javaExecutable", &config.JavaExecutable},
+		{"jarPath", &config.JarPath},
+		{"nativeImage", &config.NativeImage},
+		{"executable", &config.Executable},
+	} {
+		if *field.value, err = expandValue(*field.value); err != nil {
+			return StaticLauncherConfig{}, errors.Wrapf(err, "invalid %s", field.name)
+		}
+	}
+	if config.JvmOpts, err = expandSlice(config.JvmOpts, expandSecretValue); err != nil {
+		return StaticLauncherConfig{}, errors.Wrap(err, "invalid jvmOpts")
+	}
+	if config.VersionedJvmOpts != nil {
+		versionedOpts := make([]VersionedJvmOpts, len(config.VersionedJvmOpts))
+		for i, versioned := range config.VersionedJvmOpts {
+			if versioned.JvmOpts, err = expandSlice(versioned.JvmOpts, expandSecretValue); err != nil {
+				return StaticLauncherConfig{}, errors.Wrap(err, "invalid versioned jvmOpts")
+			}
+			versionedOpts[i] = versioned
+		}
+		config.VersionedJvmOpts = versionedOpts
+	}
+	if config.Agents != nil {
+		agents := make([]JavaAgentConfig, len(config.Agents))
+		for i, agent := range config.Agents {
+			if agent.Jar, err = expandValue(agent.Jar); err != nil {
+				return StaticLauncherConfig{}, errors.Wrap(err, "invalid agents")
+			}
+			if agent.Args, err = expandValue(agent.Args); err != nil {
+				return StaticLauncherConfig{}, errors.Wrap(err, "invalid agents")
+			}
+			agents[i] = agent
+		}
+		config.Agents = agents
+	}
+	if config.Args, err = expandSlice(config.Args, expandValue); err != nil {
+		return StaticLauncherConfig{}, errors.Wrap(err, "invalid args")
+	}
+	if config.CommandPrefix, err = expandSlice(config.CommandPrefix, expandValue); err != nil {
+		return StaticLauncherConfig{}, errors.Wrap(err, "invalid commandPrefix")
+	}
+	if config.Env, err = expandMap(config.Env, interpolateSecretEnv); err != nil {
+		return StaticLauncherConfig{}, errors.Wrap(err, "invalid env")
+	}
+	if config.Classpath, err = expandSlice(config.Classpath, expandValue); err != nil {
+		return StaticLauncherConfig{}, errors.Wrap(err, "invalid classpath")
+	}
+	if config.ModulePath, err = expandSlice(config.ModulePath, expandValue); err != nil {
+		return StaticLauncherConfig{}, errors.Wrap(err, "invalid modulePath")
+	}
+	if config.EnvFiles, err = expandSlice(config.EnvFiles, expandValue); err != nil {
+		return StaticLauncherConfig{}, errors.Wrap(err, "invalid envFiles")
+	}
+	if config.EnvFromFiles, err = expandMap(config.EnvFromFiles, expandValue); err != nil {
+		return StaticLauncherConfig{}, errors.Wrap(err, "invalid envFromFiles")
+	}
+	return config, nil
+}
+
+// interpolated returns a copy of the config with environment and template variable references replaced in its jvmOpts,
+// and environment variable references replaced in its env. Encrypted values are decrypted in both.
+func (config CustomLauncherConfig) interpolated() (CustomLauncherConfig, error) {
+	var err error
+	if config.JvmOpts, err = expandSlice(config.JvmOpts, expandSecretValue); err != nil {
+		return CustomLauncherConfig{}, errors.Wrap(err, "invalid custom jvmOpts")
+	}
+	if config.Env, err = expandMap(config.Env, interpolateSecretEnv); err != nil {
+		return CustomLauncherConfig{}, errors.Wrap(err, "invalid custom env")
+	}
+	return config, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterpolateEnv(t *testing.T) {
+	require.NoError(t, os.Setenv("INTERPOLATE_HOST", "host1"))
+	defer func() {
+		require.NoError(t, os.Unsetenv("INTERPOLATE_HOST"))
+	}()
+	require.NoError(t, os.Unsetenv("INTERPOLATE_UNSET"))
+
+	for value, want := range map[string]string{
+		"-Dhost=${INTERPOLATE_HOST}":                         "-Dhost=host1",
+		"-Dhost=${INTERPOLATE_HOST:-default}":                "-Dhost=host1",
+		"${INTERPOLATE_UNSET:-var/data}/${INTERPOLATE_HOST}": "var/data/host1",
+		"${INTERPOLATE_UNSET:-}":                             "",
+		"$INTERPOLATE_HOST {{CWD}}":                          "$INTERPOLATE_HOST {{CWD}}",
+	} {
+		got, err := interpolateEnv(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+
+	_, err := interpolateEnv("-Dhost=${INTERPOLATE_UNSET}")
+	assert.EqualError(t, err, "environment variable INTERPOLATE_UNSET referenced by '-Dhost=${INTERPOLATE_UNSET}' "+
+		"is not set and has no default")
+}
+
+func TestStaticLauncherConfigInterpolated(t *testing.T) {
+	require.NoError(t, os.Setenv("INTERPOLATE_DIR", "/data"))
+	defer func() {
+		require.NoError(t, os.Unsetenv("INTERPOLATE_DIR"))
+	}()
+
+	config := StaticLauncherConfig{
+		JavaConfig: JavaConfig{
+			JvmOpts:   []string{"-Djava.io.tmpdir=${INTERPOLATE_DIR}/tmp"},
+			Classpath: []string{"${INTERPOLATE_DIR}/lib"},
+		},
+		Args: []string{"--data=${INTERPOLATE_DIR}"},
+		Env:  map[string]string{"DATA": "${INTERPOLATE_DIR}"},
+	}
+	interpolated, err := config.interpolated()
+	require.NoError(t, err)
+	assert.Equal(t, StaticLauncherConfig{
+		JavaConfig: JavaConfig{
+			JvmOpts:   []string{"-Djava.io.tmpdir=/data/tmp"},
+			Classpath: []string{"/data/lib"},
+		},
+		Args: []string{"--data=/data"},
+		Env:  map[string]string{"DATA": "/data"},
+	}, interpolated)
+	assert.Equal(t, []string{"-Djava.io.tmpdir=${INTERPOLATE_DIR}/tmp"}, config.JvmOpts)
+}
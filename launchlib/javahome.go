@@ -0,0 +1,205 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultJdkDir is the directory searched for an installation matching javaVersion if jdkDir is not set.
+const DefaultJdkDir = "/usr/lib/jvm"
+
+var javaVersionSpecPattern = regexp.MustCompile(`^([0-9]+)(\+|-([0-9]+))?$`)
+
+var (
+	// bundledJdkDir is the directory, relative to the working directory, of a jdk bundled with the service.
+	bundledJdkDir = "jdk"
+	// macJavaHomeHelper is the macOS helper that prints the path of the default java installation.
+	macJavaHomeHelper = "/usr/libexec/java_home"
+)
+
+// javaHomeCandidate is one of the places in which discoverJavaHome looks for a java installation, returning its path or
+// an error describing why none was found there.
+type javaHomeCandidate struct {
+	name string
+	find func(workingDir string) (string, error)
+}
+
+var javaHomeCandidates = []javaHomeCandidate{
+	{name: "JAVA_HOME environment variable", find: func(string) (string, error) {
+		return loadEnvVar("JAVA_HOME")
+	}},
+	{name: "bundled jdk directory", find: findBundledJavaHome},
+	{name: "java on PATH", find: findPathJavaHome},
+	{name: macJavaHomeHelper, find: findHelperJavaHome},
+}
+
+// discoverJavaHome returns the first java installation found by javaHomeCandidates, or an error listing each of the
+// places that were tried if there is none.
+func discoverJavaHome(workingDir string) (string, error) {
+	var tried []string
+	for _, candidate := range javaHomeCandidates {
+		javaHome, err := candidate.find(workingDir)
+		if err == nil {
+			return javaHome, nil
+		}
+		tried = append(tried, fmt.Sprintf("%s: %v", candidate.name, err))
+	}
+	return "", fmt.Errorf("javaHome is not set and no java installation was found, tried:\n  %s",
+		strings.Join(tried, "\n  "))
+}
+
+// javaExecutable returns the path of the java executable of the java installation at javaHome.
+func javaExecutable(javaHome string) string {
+	return filepath.Join(javaHome, "bin", "java"+executableSuffix)
+}
+
+// launchExecutable returns the path of the executable of the java installation at javaHome that launches the process,
+// which is the javaExecutable of the config if set rather than bin/java.
+func launchExecutable(config JavaConfig, javaHome string) string {
+	if config.JavaExecutable == "" {
+		return javaExecutable(javaHome)
+	}
+	return filepath.Join(javaHome, filepath.FromSlash(config.JavaExecutable))
+}
+
+// validateJavaExecutable validates that the javaExecutable is a relative path within the java installation.
+func validateJavaExecutable(javaExecutable string) error {
+	if javaExecutable == "" {
+		return nil
+	}
+	clean := path.Clean(javaExecutable)
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return errors.Errorf("javaExecutable must be a path within the java installation, such as bin/java, found '%s'",
+			javaExecutable)
+	}
+	return nil
+}
+
+func findBundledJavaHome(workingDir string) (string, error) {
+	javaHome := path.Join(workingDir, bundledJdkDir)
+	if _, err := os.Stat(javaExecutable(javaHome)); err != nil {
+		return "", err
+	}
+	return javaHome, nil
+}
+
+func findPathJavaHome(string) (string, error) {
+	java, err := exec.LookPath("java")
+	if err != nil {
+		return "", err
+	}
+	// java on the PATH is typically a symlink, for example /usr/bin/java to /usr/lib/jvm/<jdk>/bin/java.
+	java, err = filepath.EvalSymlinks(java)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Dir(filepath.Dir(java)), nil
+}
+
+func findHelperJavaHome(string) (string, error) {
+	if _, err := os.Stat(macJavaHomeHelper); err != nil {
+		return "", err
+	}
+	output, err := exec.Command(macJavaHomeHelper).Output()
+	if err != nil {
+		return "", err
+	}
+	javaHome := strings.TrimSpace(string(output))
+	if javaHome == "" {
+		return "", fmt.Errorf("printed no path")
+	}
+	return javaHome, nil
+}
+
+// javaVersionSpec is a range of java major versions, given as <version> for a single major version, <version>+ for it
+// and any later one or <min>-<max> for those between min and max inclusive. A max of 0 is unbounded.
+type javaVersionSpec struct {
+	min int
+	max int
+}
+
+func parseJavaVersionSpec(spec string) (javaVersionSpec, error) {
+	match := javaVersionSpecPattern.FindStringSubmatch(spec)
+	if match == nil {
+		return javaVersionSpec{}, errors.Errorf("javaVersion must be of the form <version>, <version>+ or "+
+			"<min>-<max>, found '%s'", spec)
+	}
+	parsed := javaVersionSpec{}
+	parsed.min, _ = strconv.Atoi(match[1])
+	switch {
+	case match[2] == "":
+		parsed.max = parsed.min
+	case match[3] != "":
+		parsed.max, _ = strconv.Atoi(match[3])
+		if parsed.max < parsed.min {
+			return javaVersionSpec{}, errors.Errorf("javaVersion range '%s' ends before it starts", spec)
+		}
+	}
+	return parsed, nil
+}
+
+func (spec javaVersionSpec) matches(majorVersion int) bool {
+	return majorVersion >= spec.min && (spec.max == 0 || majorVersion <= spec.max)
+}
+
+// selectJavaHome returns the newest java installation in jdkDir, relative to workingDir unless absolute, whose major
+// version matches the given spec.
+func selectJavaHome(versionSpec, jdkDir, workingDir string) (string, error) {
+	spec, err := parseJavaVersionSpec(versionSpec)
+	if err != nil {
+		return "", err
+	}
+	if jdkDir == "" {
+		jdkDir = DefaultJdkDir
+	}
+	if !path.IsAbs(jdkDir) {
+		jdkDir = path.Join(workingDir, jdkDir)
+	}
+	entries, err := ioutil.ReadDir(jdkDir)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list java installations in %s", jdkDir)
+	}
+
+	var selected string
+	var selectedVersion []int
+	for _, entry := range entries {
+		javaHome := path.Join(jdkDir, entry.Name())
+		if _, err := os.Stat(javaExecutable(javaHome)); err != nil {
+			continue
+		}
+		version, err := getJavaVersion(javaHome)
+		if err != nil || !spec.matches(version[0]) {
+			continue
+		}
+		if selected == "" || compareJavaVersions(version, selectedVersion) > 0 {
+			selected, selectedVersion = javaHome, version
+		}
+	}
+	if selected == "" {
+		return "", errors.Errorf("no java installation in %s matches javaVersion '%s'", jdkDir, versionSpec)
+	}
+	return selected, nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoverJavaHome(t *testing.T) {
+	originalJavaHome := os.Getenv("JAVA_HOME")
+	originalPath := os.Getenv("PATH")
+	originalHelper := macJavaHomeHelper
+	defer func() {
+		require.NoError(t, os.Setenv("JAVA_HOME", originalJavaHome))
+		require.NoError(t, os.Setenv("PATH", originalPath))
+		macJavaHomeHelper = originalHelper
+	}()
+
+	workingDir, err := ioutil.TempDir("", "discover-java-home")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(workingDir))
+	}()
+	// The temporary directory may itself be behind a symlink, which java on the PATH is resolved through.
+	workingDir, err = filepath.EvalSymlinks(workingDir)
+	require.NoError(t, err)
+	require.NoError(t, os.Unsetenv("JAVA_HOME"))
+	require.NoError(t, os.Setenv("PATH", workingDir))
+	macJavaHomeHelper = filepath.Join(workingDir, "java_home")
+
+	_, err = discoverJavaHome(workingDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "javaHome is not set and no java installation was found")
+	assert.Contains(t, err.Error(), "JAVA_HOME environment variable: JAVA_HOME environment variable not set")
+	assert.Contains(t, err.Error(), "bundled jdk directory:")
+	assert.Contains(t, err.Error(), "java on PATH:")
+
+	// java on the PATH is resolved to the installation it links to
+	jdk := filepath.Join(workingDir, "jdk-11")
+	require.NoError(t, os.MkdirAll(filepath.Join(jdk, "bin"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(jdk, "bin", "java"), []byte("#!/bin/sh\n"), 0755))
+	pathDir := filepath.Join(workingDir, "bin")
+	require.NoError(t, os.MkdirAll(pathDir, 0755))
+	require.NoError(t, os.Symlink(filepath.Join(jdk, "bin", "java"), filepath.Join(pathDir, "java")))
+	require.NoError(t, os.Setenv("PATH", pathDir))
+	javaHome, err := discoverJavaHome(workingDir)
+	require.NoError(t, err)
+	assert.Equal(t, jdk, javaHome)
+
+	// a bundled jdk takes precedence over java on the PATH
+	require.NoError(t, os.MkdirAll(filepath.Join(workingDir, "jdk", "bin"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(workingDir, "jdk", "bin", "java"), []byte("#!/bin/sh\n"), 0755))
+	javaHome, err = discoverJavaHome(workingDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(workingDir, "jdk"), javaHome)
+
+	require.NoError(t, os.Setenv("JAVA_HOME", "/opt/java"))
+	javaHome, err = discoverJavaHome(workingDir)
+	require.NoError(t, err)
+	assert.Equal(t, "/opt/java", javaHome)
+}
+
+func TestSelectJavaHome(t *testing.T) {
+	jdkDir, err := ioutil.TempDir("", "jdks")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(jdkDir))
+	}()
+	for name, version := range map[string]string{
+		"jdk8":       "1.8.0_292",
+		"jdk-11.0.2": "11.0.2",
+		"jdk-11.0.9": "11.0.9",
+		"jdk-17":     "17.0.1",
+	} {
+		require.NoError(t, os.MkdirAll(filepath.Join(jdkDir, name, "bin"), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(jdkDir, name, "bin", "java"), []byte("#!/bin/sh\n"), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(jdkDir, name, "release"),
+			[]byte("JAVA_VERSION=\""+version+"\"\n"), 0644))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(jdkDir, "not-a-jdk"), 0755))
+
+	for spec, want := range map[string]string{
+		"8":     "jdk8",
+		"11":    "jdk-11.0.9",
+		"11+":   "jdk-17",
+		"8-11":  "jdk-11.0.9",
+		"12-16": "",
+		"21+":   "",
+	} {
+		javaHome, err := selectJavaHome(spec, jdkDir, "/")
+		if want == "" {
+			assert.EqualError(t, err, "no java installation in "+jdkDir+" matches javaVersion '"+spec+"'")
+			continue
+		}
+		require.NoError(t, err, spec)
+		assert.Equal(t, filepath.Join(jdkDir, want), javaHome, spec)
+	}
+
+	_, err = selectJavaHome("eleven", jdkDir, "/")
+	assert.EqualError(t, err, "javaVersion must be of the form <version>, <version>+ or <min>-<max>, found 'eleven'")
+}
+
+func TestLaunchExecutable(t *testing.T) {
+	assert.Equal(t, filepath.Join("/opt/jdk", "bin", "java"+executableSuffix),
+		launchExecutable(JavaConfig{}, "/opt/jdk"))
+	assert.Equal(t, filepath.Join("/opt/jdk", "jre", "bin", "vendor-java"),
+		launchExecutable(JavaConfig{JavaExecutable: "jre/bin/vendor-java"}, "/opt/jdk"))
+
+	for i, currCase := range []struct {
+		javaExecutable string
+		valid          bool
+	}{
+		{"", true},
+		{"bin/java", true},
+		{"jre/../bin/java", true},
+		{"/usr/bin/java", false},
+		{"../other/bin/java", false},
+		{"bin/../..", false},
+	} {
+		err := validateJavaExecutable(currCase.javaExecutable)
+		if currCase.valid {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.Error(t, err, "Case %d", i)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	versionedJvmOptsKeyPattern = regexp.MustCompile(`^jvmOpts-java-([0-9]+)(\+?)$`)
+	javaVersionPattern         = regexp.MustCompile(`version "([^"]+)"`)
+	releaseJavaVersionPattern  = regexp.MustCompile(`^JAVA_VERSION="([^"]+)"`)
+)
+
+// VersionedJvmOpts are JVM options that are only passed to the java command if its major version is MinVersion or,
+// unless MaxVersion is 0, at most MaxVersion. They are configured by blocks of the form jvmOpts-java-<version>, which
+// match a single major version, or jvmOpts-java-<version>+, which match that version and any later one.
+type VersionedJvmOpts struct {
+	MinVersion int
+	MaxVersion int
+	JvmOpts    []string
+}
+
+func (opts VersionedJvmOpts) matches(majorVersion int) bool {
+	return majorVersion >= opts.MinVersion && (opts.MaxVersion == 0 || majorVersion <= opts.MaxVersion)
+}
+
+// versionedJvmOptsBlocks captures the jvmOpts-java-* blocks of a static config, which cannot be decoded as fields of
+// StaticLauncherConfig as its keys are not known in advance and yaml does not support inline maps in inlined structs.
+type versionedJvmOptsBlocks struct {
+	Blocks       map[string]interface{}            `yaml:",inline"`
+	SubProcesses map[string]versionedJvmOptsBlocks `yaml:"subProcesses"`
+	Defaults     *versionedJvmOptsBlocks           `yaml:"defaults"`
+}
+
+// parseVersionedJvmOpts sets the VersionedJvmOpts of the primary process, each subProcess and the defaults of the
+// config from the jvmOpts-java-* blocks of the given yaml.
+func parseVersionedJvmOpts(yamlString []byte, config *PrimaryStaticLauncherConfig) error {
+	var blocks versionedJvmOptsBlocks
+	if err := yaml.Unmarshal(yamlString, &blocks); err != nil {
+		return errors.Wrap(err, "Failed to deserialize Static Launcher Config, please check the syntax of "+
+			"your configuration file")
+	}
+
+	var err error
+	if config.VersionedJvmOpts, err = blocks.versionedJvmOpts(); err != nil {
+		return err
+	}
+	if blocks.Defaults != nil {
+		if config.Defaults.VersionedJvmOpts, err = blocks.Defaults.versionedJvmOpts(); err != nil {
+			return errors.Wrap(err, "invalid defaults")
+		}
+	}
+	for name, subBlocks := range blocks.SubProcesses {
+		subProcess, ok := config.SubProcesses[name]
+		if !ok {
+			continue
+		}
+		if subProcess.VersionedJvmOpts, err = subBlocks.versionedJvmOpts(); err != nil {
+			return errors.Wrapf(err, "invalid subProcess '%s'", name)
+		}
+		config.SubProcesses[name] = subProcess
+	}
+	return nil
+}
+
+func (blocks versionedJvmOptsBlocks) versionedJvmOpts() ([]VersionedJvmOpts, error) {
+	var versionedOpts []VersionedJvmOpts
+	for key, value := range blocks.Blocks {
+		match := versionedJvmOptsKeyPattern.FindStringSubmatch(key)
+		if match == nil {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid java version in %s", key)
+		}
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("%s must be a list of JVM options", key)
+		}
+		opts := VersionedJvmOpts{MinVersion: version, MaxVersion: version}
+		if match[2] == "+" {
+			opts.MaxVersion = 0
+		}
+		for _, value := range values {
+			opt, ok := value.(string)
+			if !ok {
+				return nil, errors.Errorf("%s must be a list of JVM options, found %v", key, value)
+			}
+			opts.JvmOpts = append(opts.JvmOpts, opt)
+		}
+		versionedOpts = append(versionedOpts, opts)
+	}
+	// Blocks are applied from the lowest version up so that the order of the resulting options is deterministic.
+	sort.Slice(versionedOpts, func(i, j int) bool {
+		return versionedOpts[i].MinVersion < versionedOpts[j].MinVersion ||
+			versionedOpts[i].MinVersion == versionedOpts[j].MinVersion && versionedOpts[i].MaxVersion != 0
+	})
+	return versionedOpts, nil
+}
+
+// getVersionedJvmOpts returns the options of each of the given versioned options that match the major version of java.
+func getVersionedJvmOpts(versionedOpts []VersionedJvmOpts, majorVersion int) []string {
+	var opts []string
+	for _, versioned := range versionedOpts {
+		if versioned.matches(majorVersion) {
+			opts = append(opts, versioned.JvmOpts...)
+		}
+	}
+	return opts
+}
+
+// getJavaMajorVersion returns the major version of the java installation at javaHome.
+func getJavaMajorVersion(javaHome string) (int, error) {
+	version, err := getJavaVersion(javaHome)
+	if err != nil {
+		return 0, err
+	}
+	return version[0], nil
+}
+
+// getJavaVersion returns the version components of the java installation at javaHome, read from its release file or,
+// if it has none, from the output of 'java -version'.
+func getJavaVersion(javaHome string) ([]int, error) {
+	version, err := readReleaseJavaVersion(javaHome)
+	if err != nil {
+		return nil, err
+	}
+	if version == "" {
+		if version, err = probeJavaVersion(javaHome); err != nil {
+			return nil, err
+		}
+	}
+	return parseJavaVersion(version)
+}
+
+func readReleaseJavaVersion(javaHome string) (string, error) {
+	release, err := ioutil.ReadFile(path.Join(javaHome, "release"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", errors.Wrap(err, "failed to read java release file")
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(release))
+	for scanner.Scan() {
+		if match := releaseJavaVersionPattern.FindStringSubmatch(scanner.Text()); match != nil {
+			return match[1], nil
+		}
+	}
+	return "", nil
+}
+
+func probeJavaVersion(javaHome string) (string, error) {
+	output, err := exec.Command(javaExecutable(javaHome), "-version").CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to run java -version: %s", output)
+	}
+	match := javaVersionPattern.FindSubmatch(output)
+	if match == nil {
+		return "", errors.Errorf("failed to find version in output of java -version: %s", output)
+	}
+	return string(match[1]), nil
+}
+
+// parseJavaMajorVersion returns the major version of a java version string.
+func parseJavaMajorVersion(version string) (int, error) {
+	components, err := parseJavaVersion(version)
+	if err != nil {
+		return 0, err
+	}
+	return components[0], nil
+}
+
+// parseJavaVersion returns the numeric components of a java version string, of the form 1.<major>.0_<update> up to
+// java 8 and <major>[.<minor>.<security>] from java 9 on, starting with the major version. Parsing stops at the first
+// non-numeric component, such as that of a pre-release.
+func parseJavaVersion(version string) ([]int, error) {
+	fields := strings.FieldsFunc(version, func(r rune) bool {
+		return r == '.' || r == '_' || r == '-' || r == '+'
+	})
+	if len(fields) > 1 && fields[0] == "1" {
+		fields = fields[1:]
+	}
+	var components []int
+	for _, field := range fields {
+		component, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		components = append(components, component)
+	}
+	if len(components) == 0 {
+		return nil, fmt.Errorf("invalid java version '%s'", version)
+	}
+	return components, nil
+}
+
+// compareJavaVersions returns a negative number if version a is older than b, a positive one if it is newer and 0 if
+// they are the same.
+func compareJavaVersions(a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] - b[i]
+		}
+	}
+	return len(a) - len(b)
+}
@@ -0,0 +1,115 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStaticConfig_VersionedJvmOpts(t *testing.T) {
+	config, err := parseStaticConfig([]byte(`
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: mainClass
+classpath:
+  - classpath1
+jvmOpts-java-11+:
+  - -Xlog:gc
+jvmOpts-java-8:
+  - -XX:+PrintGCDetails
+subProcesses:
+  sub:
+    configType: java
+    mainClass: mainClass
+    classpath:
+      - classpath1
+defaults:
+  jvmOpts-java-17+:
+    - -XX:+UseZGC
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []VersionedJvmOpts{
+		{MinVersion: 8, MaxVersion: 8, JvmOpts: []string{"-XX:+PrintGCDetails"}},
+		{MinVersion: 11, JvmOpts: []string{"-Xlog:gc"}},
+	}, config.VersionedJvmOpts)
+	assert.Equal(t, []VersionedJvmOpts{
+		{MinVersion: 17, JvmOpts: []string{"-XX:+UseZGC"}},
+	}, config.SubProcesses["sub"].VersionedJvmOpts)
+
+	assert.Equal(t, []string{"-XX:+PrintGCDetails"}, getVersionedJvmOpts(config.VersionedJvmOpts, 8))
+	assert.Equal(t, []string(nil), getVersionedJvmOpts(config.VersionedJvmOpts, 9))
+	assert.Equal(t, []string{"-Xlog:gc"}, getVersionedJvmOpts(config.VersionedJvmOpts, 17))
+}
+
+func TestParseStaticConfig_InvalidVersionedJvmOpts(t *testing.T) {
+	_, err := parseStaticConfig([]byte(`
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: mainClass
+classpath:
+  - classpath1
+jvmOpts-java-11: -Xlog:gc
+`))
+	assert.EqualError(t, err, "jvmOpts-java-11 must be a list of JVM options")
+}
+
+func TestParseJavaMajorVersion(t *testing.T) {
+	for version, want := range map[string]int{
+		"1.8.0_292":  8,
+		"1.7.0":      7,
+		"11.0.2":     11,
+		"17":         17,
+		"21-ea":      21,
+		"17.0.1+12":  17,
+		"9-internal": 9,
+	} {
+		got, err := parseJavaMajorVersion(version)
+		require.NoError(t, err, version)
+		assert.Equal(t, want, got, version)
+	}
+
+	_, err := parseJavaMajorVersion("unknown")
+	assert.Error(t, err)
+}
+
+func TestGetJavaMajorVersion(t *testing.T) {
+	javaHome, err := ioutil.TempDir("", "java-home")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(javaHome))
+	}()
+	require.NoError(t, os.MkdirAll(filepath.Join(javaHome, "bin"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(javaHome, "bin", "java"),
+		[]byte("#!/bin/sh\necho 'openjdk version \"1.8.0_292\"' >&2\n"), 0755))
+
+	version, err := getJavaMajorVersion(javaHome)
+	require.NoError(t, err)
+	assert.Equal(t, 8, version)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(javaHome, "release"),
+		[]byte("IMPLEMENTOR=\"Eclipse Adoptium\"\nJAVA_VERSION=\"17.0.2\"\n"), 0644))
+	version, err = getJavaMajorVersion(javaHome)
+	require.NoError(t, err)
+	assert.Equal(t, 17, version)
+}
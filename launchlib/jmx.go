@@ -0,0 +1,101 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// JMXConfig enables the remote JMX agent of the JVM.
+type JMXConfig struct {
+	Port int `yaml:"port"`
+	// RMIPort is the port of the RMI server the JMX client connects to after the registry on Port, which defaults to
+	// Port so that only one port needs to be reachable.
+	RMIPort int `yaml:"rmiPort"`
+	// Hostname is the name of the host that RMI stubs point clients to, which must be reachable by them, defaulting to
+	// localhost.
+	Hostname     string `yaml:"hostname"`
+	Authenticate bool   `yaml:"authenticate"`
+	// PasswordFile and AccessFile configure the users that may connect if Authenticate is set, relative to the
+	// working directory unless absolute, defaulting to those of the java installation.
+	PasswordFile string `yaml:"passwordFile"`
+	AccessFile   string `yaml:"accessFile"`
+	SSL          bool   `yaml:"ssl"`
+}
+
+const defaultJMXHostname = "localhost"
+
+func (config *JMXConfig) validate() error {
+	if config.Port < 1 || config.Port > 65535 {
+		return errors.Errorf("port must be between 1 and 65535, found %d", config.Port)
+	}
+	if config.RMIPort < 0 || config.RMIPort > 65535 {
+		return errors.Errorf("rmiPort must be between 1 and 65535, found %d", config.RMIPort)
+	}
+	if !config.Authenticate && (config.PasswordFile != "" || config.AccessFile != "") {
+		return errors.New("passwordFile and accessFile require authenticate")
+	}
+	return nil
+}
+
+// getJMXJvmOpts returns the system properties that enable the remote JMX agent as configured, unless the jvmOpts
+// already configure it.
+func getJMXJvmOpts(config *JMXConfig, workingDir string, jvmOpts []string) []string {
+	if config == nil {
+		return nil
+	}
+	for _, opt := range jvmOpts {
+		if strings.HasPrefix(opt, "-Dcom.sun.management.jmxremote") {
+			return nil
+		}
+	}
+	rmiPort := config.RMIPort
+	if rmiPort == 0 {
+		rmiPort = config.Port
+	}
+	hostname := config.Hostname
+	if hostname == "" {
+		hostname = defaultJMXHostname
+	}
+	opts := []string{
+		"-Dcom.sun.management.jmxremote",
+		fmt.Sprintf("-Dcom.sun.management.jmxremote.port=%d", config.Port),
+		fmt.Sprintf("-Dcom.sun.management.jmxremote.rmi.port=%d", rmiPort),
+		fmt.Sprintf("-Dcom.sun.management.jmxremote.authenticate=%t", config.Authenticate),
+		fmt.Sprintf("-Dcom.sun.management.jmxremote.ssl=%t", config.SSL),
+		"-Djava.rmi.server.hostname=" + hostname,
+	}
+	for _, file := range []struct {
+		property string
+		path     string
+	}{
+		{property: "com.sun.management.jmxremote.password.file", path: config.PasswordFile},
+		{property: "com.sun.management.jmxremote.access.file", path: config.AccessFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+		path := file.path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workingDir, path)
+		}
+		opts = append(opts, fmt.Sprintf("-D%s=%s", file.property, path))
+	}
+	return opts
+}
@@ -0,0 +1,80 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetJMXJvmOpts(t *testing.T) {
+	for i, currCase := range []struct {
+		config  *JMXConfig
+		jvmOpts []string
+		want    []string
+	}{
+		{config: nil, want: nil},
+		{
+			config: &JMXConfig{Port: 9010},
+			want: []string{
+				"-Dcom.sun.management.jmxremote",
+				"-Dcom.sun.management.jmxremote.port=9010",
+				"-Dcom.sun.management.jmxremote.rmi.port=9010",
+				"-Dcom.sun.management.jmxremote.authenticate=false",
+				"-Dcom.sun.management.jmxremote.ssl=false",
+				"-Djava.rmi.server.hostname=localhost",
+			},
+		},
+		{
+			config: &JMXConfig{Port: 9010, RMIPort: 9011, Hostname: "service.internal", Authenticate: true,
+				PasswordFile: "var/conf/jmx.password", AccessFile: "/etc/jmx.access", SSL: true},
+			want: []string{
+				"-Dcom.sun.management.jmxremote",
+				"-Dcom.sun.management.jmxremote.port=9010",
+				"-Dcom.sun.management.jmxremote.rmi.port=9011",
+				"-Dcom.sun.management.jmxremote.authenticate=true",
+				"-Dcom.sun.management.jmxremote.ssl=true",
+				"-Djava.rmi.server.hostname=service.internal",
+				"-Dcom.sun.management.jmxremote.password.file=/opt/service/var/conf/jmx.password",
+				"-Dcom.sun.management.jmxremote.access.file=/etc/jmx.access",
+			},
+		},
+		{config: &JMXConfig{Port: 9010}, jvmOpts: []string{"-Dcom.sun.management.jmxremote.port=9999"}, want: nil},
+	} {
+		assert.Equal(t, currCase.want, getJMXJvmOpts(currCase.config, "/opt/service", currCase.jvmOpts),
+			"Case %d", i)
+	}
+}
+
+func TestValidateJMXConfig(t *testing.T) {
+	for i, currCase := range []struct {
+		config JMXConfig
+		err    string
+	}{
+		{config: JMXConfig{Port: 9010, Authenticate: true, PasswordFile: "jmx.password"}},
+		{config: JMXConfig{}, err: "port must be between 1 and 65535, found 0"},
+		{config: JMXConfig{Port: 9010, RMIPort: 70000}, err: "rmiPort must be between 1 and 65535, found 70000"},
+		{config: JMXConfig{Port: 9010, PasswordFile: "jmx.password"},
+			err: "passwordFile and accessFile require authenticate"},
+	} {
+		err := currCase.config.validate()
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultUnsafeJvmOptsDenylist are the prefixes of the options that the custom configuration may not set unless the
+// static configuration sets its own unsafeJvmOptsDenylist: those that replace classes of the JDK, disable its security
+// checks or load options from elsewhere, and those that disable the diagnostics of the static configuration.
+var DefaultUnsafeJvmOptsDenylist = []string{
+	"-Xbootclasspath",
+	"-Xverify:none",
+	"-noverify",
+	"-Djava.security.manager",
+	"-Djava.security.policy",
+	"-Djava.security.properties",
+	"-XX:Flags",
+	"-XX:VMOptionsFile",
+	"@",
+	"-XX:+DisableExplicitGC",
+	"-XX:+DisableAttachMechanism",
+	"-XX:-HeapDumpOnOutOfMemoryError",
+	"-XX:-UsePerfData",
+	"-Xlog:disable",
+}
+
+// validateJvmOptsLists validates that none of the entries of the unsafeJvmOptsDenylist and jvmOptsAllowlist are empty,
+// as they would match every option.
+func validateJvmOptsLists(denylist, allowlist []string) error {
+	for _, prefix := range denylist {
+		if prefix == "" {
+			return errors.New("unsafeJvmOptsDenylist must not contain empty entries")
+		}
+	}
+	for _, prefix := range allowlist {
+		if prefix == "" {
+			return errors.New("jvmOptsAllowlist must not contain empty entries")
+		}
+	}
+	return nil
+}
+
+// checkCustomJvmOpts returns an error naming each of the custom jvmOpts that has a prefix of the unsafeJvmOptsDenylist
+// of the static configuration, or, if it has a jvmOptsAllowlist, none of its prefixes.
+func checkCustomJvmOpts(config JavaConfig, customJvmOpts []string) error {
+	denylist := config.UnsafeJvmOptsDenylist
+	if denylist == nil {
+		denylist = DefaultUnsafeJvmOptsDenylist
+	}
+	var rejected []string
+	for _, opt := range customJvmOpts {
+		if prefix, ok := matchPrefix(opt, denylist); ok {
+			rejected = append(rejected, fmt.Sprintf("%s is denied by unsafeJvmOptsDenylist entry %s", opt, prefix))
+			continue
+		}
+		if _, ok := matchPrefix(opt, config.JvmOptsAllowlist); len(config.JvmOptsAllowlist) > 0 && !ok {
+			rejected = append(rejected, fmt.Sprintf("%s is not in jvmOptsAllowlist", opt))
+		}
+	}
+	if len(rejected) > 0 {
+		return errors.Errorf("custom jvmOpts not allowed by the static configuration: %s",
+			strings.Join(rejected, ", "))
+	}
+	return nil
+}
+
+// implicitJvmOptsEnvVars are the environment variables whose options java reads by itself, bypassing the checks of the
+// jvmOpts, unless they are the jvmOptsEnvVar of the static configuration.
+var implicitJvmOptsEnvVars = []string{"_JAVA_OPTIONS", "JAVA_TOOL_OPTIONS"}
+
+// getEnvJvmOpts returns the options of the jvmOptsEnvVar of the config in the environment of the launcher, split at
+// whitespace and subject to the same checks as the custom jvmOpts.
+func getEnvJvmOpts(config JavaConfig, logger io.Writer, redactor Redactor) ([]string, error) {
+	if config.JvmOptsEnvVar == "" {
+		return nil, nil
+	}
+	envJvmOpts := strings.Fields(os.Getenv(config.JvmOptsEnvVar))
+	if len(envJvmOpts) == 0 {
+		return nil, nil
+	}
+	if err := checkCustomJvmOpts(config, envJvmOpts); err != nil {
+		return nil, errors.Wrapf(err, "invalid jvmOpts of environment variable %s", config.JvmOptsEnvVar)
+	}
+	fmt.Fprintf(logger, "Appending jvmOpts from environment variable %s: %v\n", config.JvmOptsEnvVar,
+		redactor.redactArgs(envJvmOpts))
+	return envJvmOpts, nil
+}
+
+// removeJvmOptsEnvVars returns the inherited environment without the jvmOptsEnvVar of the config, whose options are
+// already part of the jvmOpts, warning about the implicitJvmOptsEnvVars that java would read by itself.
+func removeJvmOptsEnvVars(config JavaConfig, inheritedEnv []string, logger io.Writer) []string {
+	env := make([]string, 0, len(inheritedEnv))
+	for _, entry := range inheritedEnv {
+		name := strings.SplitN(entry, "=", 2)[0]
+		if name == config.JvmOptsEnvVar {
+			continue
+		}
+		for _, implicit := range implicitJvmOptsEnvVars {
+			if name == implicit {
+				fmt.Fprintf(logger, "Warning: java reads the options of environment variable %s without checking "+
+					"them, set jvmOptsEnvVar: %s to merge them into the jvmOpts\n", name, name)
+			}
+		}
+		env = append(env, entry)
+	}
+	return env
+}
+
+// matchPrefix returns the first of the prefixes that opt starts with.
+func matchPrefix(opt string, prefixes []string) (string, bool) {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(opt, prefix) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// garbageCollectors are the options that select the garbage collector, of which the JVM refuses to start with more
+// than one, so form a single family.
+var garbageCollectors = map[string]struct{}{
+	"-XX:+UseSerialGC":        {},
+	"-XX:+UseParallelGC":      {},
+	"-XX:+UseParallelOldGC":   {},
+	"-XX:+UseConcMarkSweepGC": {},
+	"-XX:+UseG1GC":            {},
+	"-XX:+UseZGC":             {},
+	"-XX:+UseShenandoahGC":    {},
+	"-XX:+UseEpsilonGC":       {},
+	"-XX:+UseParNewGC":        {},
+}
+
+// sizeFlagPrefixes are the prefixes of the options that take their value without a separator.
+var sizeFlagPrefixes = []string{"-Xmx", "-Xms", "-Xmn", "-Xss"}
+
+// jvmOptFamily returns the family of the JVM option, of which only the last option takes effect, or the empty string if
+// the option may be repeated, such as -javaagent.
+func jvmOptFamily(opt string) string {
+	if _, ok := garbageCollectors[opt]; ok {
+		return "garbage collector"
+	}
+	switch {
+	case strings.HasPrefix(opt, "-XX:+") || strings.HasPrefix(opt, "-XX:-"):
+		return "-XX:" + opt[len("-XX:+"):]
+	case strings.HasPrefix(opt, "-XX:") || strings.HasPrefix(opt, "-D"):
+		return strings.SplitN(opt, "=", 2)[0]
+	}
+	for _, prefix := range sizeFlagPrefixes {
+		if strings.HasPrefix(opt, prefix) {
+			return prefix
+		}
+	}
+	return ""
+}
+
+// dedupeJvmOpts removes every JVM option that is followed by another of the same family, so that the last option of
+// each family wins regardless of how the JVM would treat repeated options. Returns the options that were overridden by
+// a different option of their family.
+func dedupeJvmOpts(jvmOpts []string) ([]string, []string) {
+	last := make(map[string]int)
+	for i, opt := range jvmOpts {
+		if family := jvmOptFamily(opt); family != "" {
+			last[family] = i
+		}
+	}
+	deduped := make([]string, 0, len(jvmOpts))
+	var conflicts []string
+	for i, opt := range jvmOpts {
+		family := jvmOptFamily(opt)
+		if family == "" || last[family] == i {
+			deduped = append(deduped, opt)
+			continue
+		}
+		if winner := jvmOpts[last[family]]; winner != opt {
+			conflicts = append(conflicts, fmt.Sprintf("%s is overridden by %s", opt, winner))
+		}
+	}
+	return deduped, conflicts
+}
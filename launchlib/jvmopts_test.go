@@ -0,0 +1,161 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDedupeJvmOpts(t *testing.T) {
+	for i, currCase := range []struct {
+		jvmOpts   []string
+		want      []string
+		conflicts []string
+	}{
+		{
+			jvmOpts: []string{"-Xmx1g", "-javaagent:a.jar", "-javaagent:b.jar", "-Dfoo=bar"},
+			want:    []string{"-Xmx1g", "-javaagent:a.jar", "-javaagent:b.jar", "-Dfoo=bar"},
+		},
+		{
+			jvmOpts:   []string{"-Xmx1g", "-Xms1g", "-Xmx2g"},
+			want:      []string{"-Xms1g", "-Xmx2g"},
+			conflicts: []string{"-Xmx1g is overridden by -Xmx2g"},
+		},
+		{
+			jvmOpts: []string{"-XX:+UseG1GC", "-Dfoo=bar", "-XX:+UseParallelGC", "-Dfoo=baz"},
+			want:    []string{"-XX:+UseParallelGC", "-Dfoo=baz"},
+			conflicts: []string{
+				"-XX:+UseG1GC is overridden by -XX:+UseParallelGC",
+				"-Dfoo=bar is overridden by -Dfoo=baz",
+			},
+		},
+		{
+			jvmOpts:   []string{"-XX:+AlwaysPreTouch", "-XX:MaxMetaspaceSize=256m", "-XX:-AlwaysPreTouch"},
+			want:      []string{"-XX:MaxMetaspaceSize=256m", "-XX:-AlwaysPreTouch"},
+			conflicts: []string{"-XX:+AlwaysPreTouch is overridden by -XX:-AlwaysPreTouch"},
+		},
+		// Repeating the same option is not a conflict.
+		{
+			jvmOpts: []string{"-XX:+UseG1GC", "-Xss512k", "-XX:+UseG1GC"},
+			want:    []string{"-Xss512k", "-XX:+UseG1GC"},
+		},
+	} {
+		deduped, conflicts := dedupeJvmOpts(currCase.jvmOpts)
+		assert.Equal(t, currCase.want, deduped, "Case %d", i)
+		assert.Equal(t, currCase.conflicts, conflicts, "Case %d", i)
+	}
+}
+
+func TestCheckCustomJvmOpts(t *testing.T) {
+	for i, currCase := range []struct {
+		config        JavaConfig
+		customJvmOpts []string
+		msg           string
+	}{
+		{
+			customJvmOpts: []string{"-Xmx4g", "-XX:+UseG1GC", "-Dfoo=bar"},
+		},
+		{
+			customJvmOpts: []string{"-Xmx4g", "-Xbootclasspath/a:evil.jar", "-XX:-HeapDumpOnOutOfMemoryError"},
+			msg: "custom jvmOpts not allowed by the static configuration: -Xbootclasspath/a:evil.jar is denied by " +
+				"unsafeJvmOptsDenylist entry -Xbootclasspath, -XX:-HeapDumpOnOutOfMemoryError is denied by " +
+				"unsafeJvmOptsDenylist entry -XX:-HeapDumpOnOutOfMemoryError",
+		},
+		// An empty denylist allows every option.
+		{
+			config:        JavaConfig{UnsafeJvmOptsDenylist: []string{}},
+			customJvmOpts: []string{"-Xbootclasspath/a:evil.jar"},
+		},
+		{
+			config:        JavaConfig{JvmOptsAllowlist: []string{"-Xmx", "-Dfeature."}},
+			customJvmOpts: []string{"-Xmx4g", "-Dfeature.enabled=true", "-XX:+UseG1GC"},
+			msg: "custom jvmOpts not allowed by the static configuration: -XX:+UseG1GC is not in " +
+				"jvmOptsAllowlist",
+		},
+		// The denylist applies to allowed options.
+		{
+			config:        JavaConfig{JvmOptsAllowlist: []string{"-XX:"}},
+			customJvmOpts: []string{"-XX:+DisableAttachMechanism"},
+			msg: "custom jvmOpts not allowed by the static configuration: -XX:+DisableAttachMechanism is denied by " +
+				"unsafeJvmOptsDenylist entry -XX:+DisableAttachMechanism",
+		},
+	} {
+		err := checkCustomJvmOpts(currCase.config, currCase.customJvmOpts)
+		if currCase.msg == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.msg, "Case %d", i)
+		}
+	}
+}
+
+func TestGetEnvJvmOpts(t *testing.T) {
+	require.NoError(t, os.Setenv("LAUNCHER_TEST_JAVA_OPTS", " -Xmx2g  -Dfoo=bar\t-XX:+UseG1GC "))
+	defer func() {
+		require.NoError(t, os.Unsetenv("LAUNCHER_TEST_JAVA_OPTS"))
+	}()
+
+	for i, currCase := range []struct {
+		config JavaConfig
+		want   []string
+		msg    string
+	}{
+		{},
+		{
+			config: JavaConfig{JvmOptsEnvVar: "LAUNCHER_TEST_UNSET"},
+		},
+		{
+			config: JavaConfig{JvmOptsEnvVar: "LAUNCHER_TEST_JAVA_OPTS"},
+			want:   []string{"-Xmx2g", "-Dfoo=bar", "-XX:+UseG1GC"},
+		},
+		{
+			config: JavaConfig{JvmOptsEnvVar: "LAUNCHER_TEST_JAVA_OPTS", JvmOptsAllowlist: []string{"-Xmx", "-D"}},
+			msg: "invalid jvmOpts of environment variable LAUNCHER_TEST_JAVA_OPTS: custom jvmOpts not allowed by " +
+				"the static configuration: -XX:+UseG1GC is not in jvmOptsAllowlist",
+		},
+	} {
+		var logs bytes.Buffer
+		jvmOpts, err := getEnvJvmOpts(currCase.config, &logs, Redactor{})
+		if currCase.msg != "" {
+			assert.EqualError(t, err, currCase.msg, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, jvmOpts, "Case %d", i)
+		if len(currCase.want) > 0 {
+			assert.Contains(t, logs.String(), "Appending jvmOpts from environment variable LAUNCHER_TEST_JAVA_OPTS",
+				"Case %d", i)
+		}
+	}
+}
+
+func TestRemoveJvmOptsEnvVars(t *testing.T) {
+	inheritedEnv := []string{"PATH=/bin", "JAVA_OPTS=-Xmx1g", "_JAVA_OPTIONS=-Xmx2g"}
+
+	var logs bytes.Buffer
+	env := removeJvmOptsEnvVars(JavaConfig{JvmOptsEnvVar: "JAVA_OPTS"}, inheritedEnv, &logs)
+	assert.Equal(t, []string{"PATH=/bin", "_JAVA_OPTIONS=-Xmx2g"}, env)
+	assert.Contains(t, logs.String(), "Warning: java reads the options of environment variable _JAVA_OPTIONS")
+
+	logs.Reset()
+	env = removeJvmOptsEnvVars(JavaConfig{JvmOptsEnvVar: "_JAVA_OPTIONS"}, inheritedEnv, &logs)
+	assert.Equal(t, []string{"PATH=/bin", "JAVA_OPTS=-Xmx1g"}, env)
+	assert.Empty(t, logs.String())
+}
@@ -20,8 +20,10 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -36,16 +38,33 @@ const (
 type ServiceCmds struct {
 	Primary      *exec.Cmd
 	SubProcesses map[string]*exec.Cmd
+	// PrimaryTimings and SubProcessTimings are how long compiling each of the commands took.
+	PrimaryTimings    CompileTimings
+	SubProcessTimings map[string]CompileTimings
+}
+
+// CompileTimings are how long compiling the command of a process took, and how much of that was spent resolving its
+// java installation and classpath, which search the filesystem.
+type CompileTimings struct {
+	JavaHome  time.Duration
+	Classpath time.Duration
+	Total     time.Duration
 }
 
 func CompileCmdsFromConfig(
 	staticConfig *PrimaryStaticLauncherConfig, customConfig *PrimaryCustomLauncherConfig, loggers ServiceLoggers) (
 	serviceCmds *ServiceCmds, err error) {
 	serviceCmds = &ServiceCmds{
-		SubProcesses: make(map[string]*exec.Cmd),
+		SubProcesses:      make(map[string]*exec.Cmd),
+		SubProcessTimings: make(map[string]CompileTimings),
 	}
 
-	serviceCmds.Primary, err = compileCmdFromConfig(&staticConfig.StaticLauncherConfig, &customConfig.CustomLauncherConfig, loggers.PrimaryLogger)
+	primaryStatic := staticConfig.StaticLauncherConfig
+	if primaryStatic.Debug, err = debugConfigFromEnv(primaryStatic.Debug); err != nil {
+		return nil, err
+	}
+	serviceCmds.Primary, err = compileTimedCmd(&primaryStatic, &customConfig.CustomLauncherConfig,
+		loggers.PrimaryLogger, &serviceCmds.PrimaryTimings)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to compile command for primary command")
 	}
@@ -55,15 +74,33 @@ func CompileCmdsFromConfig(
 			return nil, errors.Errorf("no custom launcher config exists for subProcess config '%s'", name)
 		}
 
-		serviceCmds.SubProcesses[name], err = compileCmdFromConfig(&subProcStatic, &subProcCustom, loggers.SubProcessLogger(name))
+		var timings CompileTimings
+		serviceCmds.SubProcesses[name], err = compileTimedCmd(&subProcStatic, &subProcCustom,
+			loggers.SubProcessLogger(name), &timings)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to compile command for subProcess %s", name)
 		}
+		serviceCmds.SubProcessTimings[name] = timings
+	}
+	cmds := []*exec.Cmd{serviceCmds.Primary}
+	for _, cmd := range serviceCmds.SubProcesses {
+		cmds = append(cmds, cmd)
 	}
+	pruneGeneratedArgsFiles(cmds)
 	return serviceCmds, nil
 }
 
-func compileCmdFromConfig(staticConfig *StaticLauncherConfig, customConfig *CustomLauncherConfig, createLogger CreateLogger) (cmd *exec.Cmd, err error) {
+func compileCmdFromConfig(staticConfig *StaticLauncherConfig, customConfig *CustomLauncherConfig, createLogger CreateLogger) (*exec.Cmd, error) {
+	return compileTimedCmd(staticConfig, customConfig, createLogger, &CompileTimings{})
+}
+
+// compileTimedCmd is compileCmdFromConfig, recording how long compiling the command took in timings.
+func compileTimedCmd(staticConfig *StaticLauncherConfig, customConfig *CustomLauncherConfig, createLogger CreateLogger,
+	timings *CompileTimings) (cmd *exec.Cmd, err error) {
+	started := time.Now()
+	defer func() {
+		timings.Total = time.Since(started)
+	}()
 	logger, err := createLogger()
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to create command compilation logger")
@@ -73,36 +110,192 @@ func compileCmdFromConfig(staticConfig *StaticLauncherConfig, customConfig *Cust
 			err = errors.Wrapf(err, "unable to close command compilation logger")
 		}
 	}()
+	redactor := staticConfig.Redactor()
 	fmt.Fprintf(logger, "Launching with static configuration %v and custom configuration %v\n",
-		*staticConfig, *customConfig)
+		redactor.redactStaticConfig(*staticConfig), redactor.redactCustomConfig(*customConfig))
+
+	interpolatedStatic, err := staticConfig.interpolated()
+	if err != nil {
+		return nil, err
+	}
+	staticConfig = &interpolatedStatic
+	interpolatedCustom, err := customConfig.interpolated()
+	if err != nil {
+		return nil, err
+	}
+	customConfig = &interpolatedCustom
 
-	workingDir := getWorkingDir()
+	workingDir, err := resolveWorkingDir(staticConfig.WorkingDirectory)
+	if err != nil {
+		return nil, err
+	}
 	fmt.Fprintln(logger, "Working directory:", workingDir)
 
 	var args []string
 	var executable string
 	var executableErr error
+	var javaHome, classpath, argFile string
 
-	if staticConfig.Type == "java" {
-		javaHome, javaHomeErr := getJavaHome(staticConfig.JavaConfig.JavaHome)
+	if staticConfig.Type == "java" && staticConfig.JavaConfig.NativeImage != "" {
+		executable, args, err = getNativeImageArgs(staticConfig.JavaConfig, customConfig.JvmOpts, staticConfig.TmpDir,
+			staticConfig.Locale, workingDir, logger, redactor)
+		if err != nil {
+			return nil, err
+		}
+	} else if staticConfig.Type == "java" {
+		javaHomeStarted := time.Now()
+		var javaHomeErr error
+		javaHome, javaHomeErr = resolveJavaHome(staticConfig.JavaConfig, workingDir)
+		timings.JavaHome = time.Since(javaHomeStarted)
 		if javaHomeErr != nil {
 			return nil, javaHomeErr
 		}
 		fmt.Fprintln(logger, "Using JAVA_HOME:", javaHome)
 
-		classpath := joinClasspathEntries(absolutizeClasspathEntries(workingDir,
+		classpathStarted := time.Now()
+		classpathEntries, classpathErr := resolveClasspathEntries(absolutizeClasspathEntries(workingDir,
 			staticConfig.JavaConfig.Classpath))
+		timings.Classpath = time.Since(classpathStarted)
+		if classpathErr != nil {
+			return nil, classpathErr
+		}
+		classpath = joinClasspathEntries(classpathEntries)
 		fmt.Fprintln(logger, "Classpath:", classpath)
 
-		executable, executableErr = verifyPathIsSafeForExec(path.Join(javaHome, "/bin/java"))
+		moduleArgs, moduleErr := getModuleArgs(staticConfig.JavaConfig, workingDir)
+		if moduleErr != nil {
+			return nil, moduleErr
+		}
+		agentArgs, agentErr := getAgentArgs(staticConfig.JavaConfig.Agents, workingDir)
+		if agentErr != nil {
+			return nil, agentErr
+		}
+		jar, jarErr := getJar(staticConfig.JavaConfig.JarPath, workingDir)
+		if jarErr != nil {
+			return nil, jarErr
+		}
+
+		executable, executableErr = verifyPathIsSafeForExec(launchExecutable(staticConfig.JavaConfig, javaHome))
 		if executableErr != nil {
 			return nil, executableErr
 		}
+		jvmOpts := append([]string{}, staticConfig.JavaConfig.JvmOpts...)
+		var majorVersion int
+		if len(staticConfig.JavaConfig.VersionedJvmOpts) > 0 || staticConfig.JavaConfig.GCLogging {
+			var versionErr error
+			majorVersion, versionErr = getJavaMajorVersion(javaHome)
+			if versionErr != nil {
+				return nil, errors.Wrap(versionErr, "failed to determine java version for versioned jvmOpts and "+
+					"gcLogging")
+			}
+			fmt.Fprintln(logger, "Java major version:", majorVersion)
+			jvmOpts = append(jvmOpts, getVersionedJvmOpts(staticConfig.JavaConfig.VersionedJvmOpts, majorVersion)...)
+		}
+		if err := checkCustomJvmOpts(staticConfig.JavaConfig, customConfig.JvmOpts); err != nil {
+			return nil, err
+		}
+		if len(customConfig.JvmOpts) > 0 {
+			tracef("Appending custom jvmOpts %v to static jvmOpts %v", redactor.redactArgs(customConfig.JvmOpts),
+				redactor.redactArgs(jvmOpts))
+		}
+		jvmOpts = append(jvmOpts, customConfig.JvmOpts...)
+		envJvmOpts, envJvmOptsErr := getEnvJvmOpts(staticConfig.JavaConfig, logger, redactor)
+		if envJvmOptsErr != nil {
+			return nil, envJvmOptsErr
+		}
+		jvmOpts = append(jvmOpts, envJvmOpts...)
+		jvmOpts, conflicts := dedupeJvmOpts(jvmOpts)
+		if len(conflicts) > 0 {
+			if staticConfig.JavaConfig.StrictJvmOpts {
+				return nil, errors.Errorf("conflicting jvmOpts: %s", strings.Join(conflicts, ", "))
+			}
+			for _, conflict := range conflicts {
+				fmt.Fprintln(logger, "Warning: jvmOpt", conflict)
+			}
+		}
+		jvmOpts, unlocks := addUnlockJvmOpts(jvmOpts)
+		for _, unlock := range unlocks {
+			fmt.Fprintln(logger, "Warning: jvmOpt", unlock)
+		}
+		heapOpts, heapErr := getHeapSizingJvmOpts(staticConfig.JavaConfig, jvmOpts)
+		if heapErr != nil {
+			return nil, errors.Wrap(heapErr, "failed to size heap from available memory")
+		}
+		if len(heapOpts) > 0 {
+			fmt.Fprintln(logger, "Heap options from available memory:", heapOpts)
+		}
+		processorOpts, processorErr := getProcessorCountJvmOpts(staticConfig.JavaConfig.DisableActiveProcessorCount,
+			jvmOpts)
+		if processorErr != nil {
+			return nil, errors.Wrap(processorErr, "failed to determine processor count from container CPU limit")
+		}
+		if len(processorOpts) > 0 {
+			fmt.Fprintln(logger, "Processor options from container CPU limit:", processorOpts)
+		}
+		diagnosticsOpts := getDiagnosticsJvmOpts(staticConfig.JavaConfig.Diagnostics, workingDir, jvmOpts)
+		tmpDirOpts := getTmpDirJvmOpts(staticConfig.TmpDir, workingDir, jvmOpts)
+		localeOpts := getLocaleJvmOpts(staticConfig.Locale, jvmOpts)
+		debugOpts := getDebugJvmOpts(staticConfig.JavaConfig.Debug, jvmOpts)
+		if len(debugOpts) > 0 {
+			fmt.Fprintln(logger, "Remote debugging enabled:", debugOpts)
+		}
+		jmxOpts := getJMXJvmOpts(staticConfig.JavaConfig.JMX, workingDir, jvmOpts)
+		var gcLoggingOpts []string
+		if staticConfig.JavaConfig.GCLogging {
+			gcLoggingOpts = getGCLoggingJvmOpts(majorVersion, workingDir, jvmOpts)
+		}
+
 		args = append(args, executable) // 0th argument is the command itself
-		args = append(args, staticConfig.JavaConfig.JvmOpts...)
-		args = append(args, customConfig.JvmOpts...)
-		args = append(args, "-classpath", classpath)
-		args = append(args, staticConfig.JavaConfig.MainClass)
+		args = append(args, heapOpts...)
+		args = append(args, processorOpts...)
+		args = append(args, diagnosticsOpts...)
+		args = append(args, tmpDirOpts...)
+		args = append(args, localeOpts...)
+		args = append(args, gcLoggingOpts...)
+		args = append(args, debugOpts...)
+		args = append(args, jmxOpts...)
+		args = append(args, agentArgs...)
+		args = append(args, jvmOpts...)
+		args = append(args, moduleArgs...)
+		if classpath != "" {
+			args = append(args, "-classpath", classpath)
+		}
+		if staticConfig.JavaConfig.ArgFile {
+			if majorVersion == 0 {
+				if majorVersion, err = getJavaMajorVersion(javaHome); err != nil {
+					return nil, errors.Wrap(err, "failed to determine java version for argFile")
+				}
+			}
+			if argFile, err = writeJvmArgFile(args[1:], majorVersion); err != nil {
+				return nil, err
+			}
+			fmt.Fprintln(logger, "Passing JVM options in argument file", argFile)
+			args = []string{executable, "@" + argFile}
+		} else if remaining, secretArgs, index := extractSecretProperties(args[1:],
+			staticConfig.JavaConfig.SecretProperties); len(secretArgs) > 0 {
+			if majorVersion == 0 {
+				if majorVersion, err = getJavaMajorVersion(javaHome); err != nil {
+					return nil, errors.Wrap(err, "failed to determine java version for secretProperties")
+				}
+			}
+			if majorVersion < argFileMinJavaVersion {
+				return nil, errors.Errorf("secretProperties requires java %d or later, found java %d",
+					argFileMinJavaVersion, majorVersion)
+			}
+			if argFile, err = writeJvmArgFile(secretArgs, majorVersion); err != nil {
+				return nil, err
+			}
+			fmt.Fprintln(logger, "Passing secret properties in argument file", argFile)
+			args = append(append(append([]string{executable}, remaining[:index]...), "@"+argFile),
+				remaining[index:]...)
+		}
+		if staticConfig.JavaConfig.MainModule != "" {
+			args = append(args, "-m", staticConfig.JavaConfig.MainModule)
+		} else if jar != "" {
+			args = append(args, "-jar", jar)
+		} else {
+			args = append(args, staticConfig.JavaConfig.MainClass)
+		}
 	} else if staticConfig.Type == "executable" {
 		executable, executableErr = verifyPathIsSafeForExec(staticConfig.Executable)
 		if executableErr != nil {
@@ -115,31 +308,113 @@ func compileCmdFromConfig(staticConfig *StaticLauncherConfig, customConfig *Cust
 	}
 
 	args = append(args, staticConfig.Args...)
-	fmt.Fprintf(logger, "Argument list to executable binary: %v\n\n", args)
+	if len(staticConfig.CommandPrefix) > 0 {
+		executable, args, err = prefixCommand(staticConfig.CommandPrefix, executable, args, workingDir)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(logger, "Running under command prefix:", staticConfig.CommandPrefix)
+	}
+	fmt.Fprintf(logger, "Argument list to executable binary: %v\n\n", redactor.redactArgs(args))
 
-	env := replaceEnvironmentVariables(merge(staticConfig.Env, customConfig.Env))
+	fileEnv, err := loadEnvFiles(workingDir, staticConfig.EnvFiles)
+	if err != nil {
+		return nil, err
+	}
+	for key := range customConfig.Env {
+		if _, ok := staticConfig.Env[key]; ok {
+			tracef("Custom env %s overrides the static env", key)
+		}
+	}
+	env, err := replaceEnvironmentVariables(merge(staticConfig.Env, customConfig.Env))
+	if err != nil {
+		return nil, err
+	}
+	secretEnv, err := loadEnvFromFiles(workingDir, staticConfig.EnvFromFiles)
+	if err != nil {
+		return nil, err
+	}
+	env = merge(merge(merge(merge(getTmpDirEnv(staticConfig.TmpDir, workingDir), getLocaleEnv(staticConfig.Locale)),
+		fileEnv), env), secretEnv)
 
-	return createCmd(executable, args, env)
+	inheritedEnv := getInheritedEnv(staticConfig.CleanEnv, staticConfig.EnvPassthrough)
+	if staticConfig.Type == "java" {
+		inheritedEnv = removeJvmOptsEnvVars(staticConfig.JavaConfig, inheritedEnv, logger)
+	}
+	cmd, err = createCmd(executable, args, inheritedEnv, env)
+	if err != nil {
+		return nil, err
+	}
+	if err := setCredential(cmd, staticConfig.User, staticConfig.Group, logger); err != nil {
+		return nil, err
+	}
+	if argFile != "" {
+		if err := chownToCommand(argFile, cmd); err != nil {
+			return nil, err
+		}
+	}
+	setAmbientCapabilities(cmd, staticConfig.Capabilities)
+	if staticConfig.WorkingDirectory != "" {
+		cmd.Dir = workingDir
+	}
+	if staticConfig.Chroot != "" {
+		root := staticConfig.Chroot
+		if !filepath.IsAbs(root) {
+			root = filepath.Join(workingDir, root)
+		}
+		cmd.Dir = workingDir
+		if err := chrootCmd(cmd, filepath.Clean(root)); err != nil {
+			return nil, err
+		}
+		fmt.Fprintln(logger, "Running chrooted into", root)
+	} else if err := shortenClasspath(cmd, classpath, javaHome, logger); err != nil {
+		return nil, err
+	}
+	traceCmd(cmd, redactor)
+	return cmd, nil
 }
 
-func MkDirs(dirs []string, stdout io.Writer) error {
+// MkDirs creates each of the dirs in parallel, returning an error listing every dir that could not be created. No dir
+// is created if any of their paths is invalid.
+func MkDirs(dirs []DirConfig, stdout io.Writer) error {
 	isDirMatcher := regexp.MustCompile(`^[A-Za-z0-9]+(/[A-Za-z0-9]+)*$`).MatchString
+	var invalid []error
 	for _, dir := range dirs {
-		if !isDirMatcher(dir) {
-			return fmt.Errorf("Cannot create directory with non [A-Za-z0-9] characters: %s", dir)
+		if !isDirMatcher(dir.Path) {
+			invalid = append(invalid, fmt.Errorf("Cannot create directory with non [A-Za-z0-9] characters: %s",
+				dir.Path))
 		}
+	}
+	if err := joinErrors("%d directories are invalid", invalid); err != nil {
+		return err
+	}
 
+	for _, dir := range dirs {
 		fmt.Fprintf(stdout, "Creating directory: %s\n", dir)
-		if err := os.MkdirAll(dir, 0700); err != nil {
+	}
+	errs := runParallel(len(dirs), func(i int) error {
+		dir := dirs[i]
+		mode := dir.Mode
+		if mode == 0 {
+			mode = 0700
+		}
+		if err := os.MkdirAll(dir.Path, mode); err != nil {
 			return err
 		}
-	}
-	return nil
+		// The mode is applied whether or not the directory existed, and regardless of the umask.
+		if dir.Mode != 0 {
+			if err := os.Chmod(dir.Path, dir.Mode); err != nil {
+				return errors.Wrapf(err, "failed to change the mode of directory %s", dir.Path)
+			}
+		}
+		return nil
+	})
+	return joinErrors("%d directories could not be created", errs)
 }
 
 // Returns true iff the given path is safe to be passed to exec(): must not contain funky characters and be a valid file
 func verifyPathIsSafeForExec(execPath string) (string, error) {
-	if unsafe, err := regexp.MatchString(ExecPathBlackListRegex, execPath); err != nil {
+	if unsafe, err := regexp.MatchString(execPathDenylistRegex, execPath); err != nil {
 		return "", err
 	} else if unsafe {
 		return "", fmt.Errorf("Unsafe execution path: %q ", execPath)
@@ -150,11 +425,21 @@ func verifyPathIsSafeForExec(execPath string) (string, error) {
 	return execPath, nil
 }
 
-// Returns explicitJavaHome if it is not the empty string, or the value of the JAVA_HOME environment variable otherwise.
-// Panics if neither of them is set.
+// Returns the java installation selected by javaVersion if it is set, or that given by javaHome otherwise.
+func resolveJavaHome(config JavaConfig, workingDir string) (string, error) {
+	if config.JavaVersion != "" {
+		return selectJavaHome(config.JavaVersion, config.JdkDir, workingDir)
+	}
+	if config.JavaHome == "" {
+		return discoverJavaHome(workingDir)
+	}
+	return getJavaHome(config.JavaHome)
+}
+
+// Returns explicitJavaHome if it is not the empty string, or the java installation found by discoverJavaHome otherwise.
 func getJavaHome(explicitJavaHome string) (string, error) {
 	if explicitJavaHome == "" {
-		return loadEnvVar("JAVA_HOME")
+		return discoverJavaHome(getWorkingDir())
 	}
 
 	if explicitJavaHome[0] == '$' {
@@ -193,12 +478,77 @@ func absolutizeClasspathEntries(workingDir string, relativeClasspathEntries []st
 	return absoluteClasspathEntries
 }
 
+// Returns the absolute path of the given jar, relative to workingDir unless absolute, which may be a glob pattern that
+// must match exactly one file such as service/lib/my-service-*.jar. Returns the empty string if jarPath is.
+func getJar(jarPath, workingDir string) (string, error) {
+	if jarPath == "" {
+		return "", nil
+	}
+	if !path.IsAbs(jarPath) {
+		jarPath = path.Join(workingDir, jarPath)
+	}
+	jars, err := resolveClasspathEntries([]string{jarPath})
+	if err != nil {
+		return "", errors.Wrap(err, "invalid jarPath")
+	}
+	if len(jars) > 1 {
+		return "", errors.Errorf("jarPath %s matches more than one file: %s", jarPath, strings.Join(jars, ", "))
+	}
+	return jars[0], nil
+}
+
+// Expands each of the given absolute classpath entries that is a glob pattern, such as /service/lib/*.jar, to the files
+// it matches and removes repeated entries. The entries are checked in parallel. Returns an error listing every entry
+// that does not exist or matches no files.
+func resolveClasspathEntries(entries []string) ([]string, error) {
+	entryMatches := make([][]string, len(entries))
+	errs := runParallel(len(entries), func(i int) error {
+		entry := entries[i]
+		if strings.ContainsAny(entry, "*?[") {
+			matches, err := filepath.Glob(entry)
+			if err != nil {
+				return errors.Wrapf(err, "invalid classpath pattern %s", entry)
+			}
+			entryMatches[i] = matches
+		} else if _, err := os.Stat(entry); err == nil {
+			entryMatches[i] = []string{entry}
+		}
+		return nil
+	})
+	if err := joinErrors("%d classpath patterns are invalid", errs); err != nil {
+		return nil, err
+	}
+
+	var resolved, missing []string
+	seen := make(map[string]struct{})
+	for i, entry := range entries {
+		matches := entryMatches[i]
+		if strings.ContainsAny(entry, "*?[") {
+			tracef("Expanded classpath pattern %s to %v", entry, matches)
+		}
+		if len(matches) == 0 {
+			missing = append(missing, entry)
+		}
+		for _, match := range matches {
+			if _, ok := seen[match]; !ok {
+				seen[match] = struct{}{}
+				resolved = append(resolved, match)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return nil, errors.Errorf("classpath entries do not exist: %s", strings.Join(missing, ", "))
+	}
+	return resolved, nil
+}
+
 func joinClasspathEntries(classpathEntries []string) string {
-	return strings.Join(classpathEntries, ":")
+	return strings.Join(classpathEntries, string(os.PathListSeparator))
 }
 
-func createCmd(executable string, args []string, customEnv map[string]string) (*exec.Cmd, error) {
-	env := os.Environ()
+func createCmd(executable string, args []string, inheritedEnv []string, customEnv map[string]string) (*exec.Cmd,
+	error) {
+	env := inheritedEnv
 	for key, value := range customEnv {
 		env = append(env, fmt.Sprintf("%s=%s", key, value))
 	}
@@ -214,15 +564,17 @@ func createCmd(executable string, args []string, customEnv map[string]string) (*
 
 // Performs replacement of all replaceable values in env, returning a new
 // map, with the same keys as env, but possibly changed values.
-func replaceEnvironmentVariables(env map[string]string) map[string]string {
-	replacer := createReplacer()
-
+func replaceEnvironmentVariables(env map[string]string) (map[string]string, error) {
 	returnMap := make(map[string]string)
 	for key, value := range env {
-		returnMap[key] = replacer.Replace(value)
+		replaced, err := expandTemplates(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to replace variables in value of environment variable %s", key)
+		}
+		returnMap[key] = replaced
 	}
 
-	return returnMap
+	return returnMap, nil
 }
 
 // copy all the keys and values from overrideMap into origMap. If a key already
@@ -241,13 +593,3 @@ func merge(origMap, overrideMap map[string]string) map[string]string {
 	}
 	return returnMap
 }
-
-func createReplacer() *strings.Replacer {
-	return strings.NewReplacer(
-		delim("CWD"), getWorkingDir(),
-	)
-}
-
-func delim(str string) string {
-	return fmt.Sprintf("%s%s%s", TemplateDelimsOpen, str, TemplateDelimsClose)
-}
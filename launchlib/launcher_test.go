@@ -16,7 +16,9 @@ package launchlib
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
@@ -56,7 +58,8 @@ func TestSetCustomEnvironment(t *testing.T) {
 		"SOME_VAR":  "CUSTOM_VAR",
 	}
 
-	env := replaceEnvironmentVariables(merge(originalEnv, customEnv))
+	env, err := replaceEnvironmentVariables(merge(originalEnv, customEnv))
+	require.NoError(t, err)
 	cwd := getWorkingDir()
 
 	if got, ok := env["SOME_PATH"]; ok {
@@ -73,7 +76,7 @@ func TestSetCustomEnvironment(t *testing.T) {
 	}
 
 	args := []string{"arg1", "arg2"}
-	cmd, err := createCmd("my-command", args, env)
+	cmd, err := createCmd("my-command", args, os.Environ(), env)
 	assert.NoError(t, err)
 
 	assert.Equal(t, "my-command", cmd.Path, "Command to be run was incorrect")
@@ -96,7 +99,8 @@ func TestUnknownVariablesAreNotExpanded(t *testing.T) {
 		"SOME_VAR": "{{FOO}}",
 	}
 
-	env := replaceEnvironmentVariables(merge(originalEnv, customEnv))
+	env, err := replaceEnvironmentVariables(merge(originalEnv, customEnv))
+	require.NoError(t, err)
 	if got, ok := env["SOME_VAR"]; ok {
 		assert.Equal(t, "{{FOO}}", got, "SOME_VAR environment variable incorrect")
 	} else {
@@ -110,7 +114,8 @@ func TestKeysAreNotExpanded(t *testing.T) {
 		"{{CWD}}": "Value",
 	}
 
-	env := replaceEnvironmentVariables(merge(originalEnv, customEnv))
+	env, err := replaceEnvironmentVariables(merge(originalEnv, customEnv))
+	require.NoError(t, err)
 	if got, ok := env["{{CWD}}"]; ok {
 		assert.Equal(t, "Value", got, "%%CWD%% environment variable incorrect")
 	} else {
@@ -119,10 +124,10 @@ func TestKeysAreNotExpanded(t *testing.T) {
 }
 
 func TestMkdirChecksDirectorySyntax(t *testing.T) {
-	err := MkDirs([]string{"abc/def1"}, os.Stdout)
+	err := MkDirs([]DirConfig{{Path: "abc/def1"}}, os.Stdout)
 	assert.NoError(t, err)
 
-	err = MkDirs([]string{"abc"}, os.Stdout)
+	err = MkDirs([]DirConfig{{Path: "abc"}}, os.Stdout)
 	assert.NoError(t, err)
 
 	require.NoError(t, os.RemoveAll("abc"))
@@ -133,7 +138,86 @@ func TestMkdirChecksDirectorySyntax(t *testing.T) {
 		"abc/../def",
 	}
 	for _, dir := range badCases {
-		err = MkDirs([]string{dir}, os.Stdout)
+		err = MkDirs([]DirConfig{{Path: dir}}, os.Stdout)
 		assert.EqualError(t, err, "Cannot create directory with non [A-Za-z0-9] characters: "+dir)
 	}
 }
+
+func TestMkDirsAppliesMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mkdirs")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+	require.NoError(t, os.Mkdir("existing", 0700))
+
+	require.NoError(t, MkDirs([]DirConfig{{Path: "var/data", Mode: 0750}, {Path: "existing", Mode: 0755}},
+		ioutil.Discard))
+	for path, want := range map[string]os.FileMode{"var/data": 0750, "existing": 0755} {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, want, info.Mode().Perm(), path)
+	}
+}
+
+func TestResolveClasspathEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "classpath")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0755))
+	for _, jar := range []string{"b.jar", "a.jar", "notes.txt"} {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "lib", jar), nil, 0644))
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "conf"), 0755))
+
+	entries, err := resolveClasspathEntries([]string{
+		filepath.Join(dir, "conf"),
+		filepath.Join(dir, "lib", "b.jar"),
+		filepath.Join(dir, "lib", "*.jar"),
+		filepath.Join(dir, "conf"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "conf"),
+		filepath.Join(dir, "lib", "b.jar"),
+		filepath.Join(dir, "lib", "a.jar"),
+	}, entries)
+
+	_, err = resolveClasspathEntries([]string{
+		filepath.Join(dir, "lib", "a.jar"),
+		filepath.Join(dir, "lib", "typo.jar"),
+		filepath.Join(dir, "other", "*.jar"),
+	})
+	assert.EqualError(t, err, fmt.Sprintf("classpath entries do not exist: %s, %s",
+		filepath.Join(dir, "lib", "typo.jar"), filepath.Join(dir, "other", "*.jar")))
+}
+
+func TestGetJar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jar")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "app-1.0.0.jar"), nil, 0644))
+
+	jar, err := getJar("app-*.jar", dir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "app-1.0.0.jar"), jar)
+
+	jar, err = getJar("", dir)
+	require.NoError(t, err)
+	assert.Equal(t, "", jar)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "app-2.0.0.jar"), nil, 0644))
+	_, err = getJar("app-*.jar", dir)
+	assert.EqualError(t, err, fmt.Sprintf("jarPath %s matches more than one file: %s, %s",
+		filepath.Join(dir, "app-*.jar"), filepath.Join(dir, "app-1.0.0.jar"), filepath.Join(dir, "app-2.0.0.jar")))
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// LocaleConfig sets the timezone, locale and file encoding of the process, which otherwise default to those of the host
+// it runs on and so differ between environments.
+type LocaleConfig struct {
+	// Timezone, such as UTC or Europe/London, is passed to the process as TZ and, for java, -Duser.timezone.
+	Timezone string `yaml:"timezone"`
+	// Lang, such as en_US.UTF-8 or C.UTF-8, is passed to the process as LANG and LC_ALL.
+	Lang string `yaml:"lang"`
+	// FileEncoding, such as UTF-8, is passed to java as -Dfile.encoding.
+	FileEncoding string `yaml:"fileEncoding"`
+}
+
+const (
+	timezoneJvmOptPrefix     = "-Duser.timezone="
+	fileEncodingJvmOptPrefix = "-Dfile.encoding="
+)
+
+var localeValuePattern = regexp.MustCompile(`^[A-Za-z0-9_./:@+-]+$`)
+
+func (config *LocaleConfig) validate() error {
+	for _, value := range []struct {
+		name, value string
+	}{
+		{name: "timezone", value: config.Timezone},
+		{name: "lang", value: config.Lang},
+		{name: "fileEncoding", value: config.FileEncoding},
+	} {
+		if value.value != "" && !localeValuePattern.MatchString(value.value) {
+			return errors.Errorf("%s must consist of letters, digits and the characters _./:@+-, found '%s'",
+				value.name, value.value)
+		}
+	}
+	return nil
+}
+
+// getLocaleJvmOpts returns the options that set the timezone and file encoding of the JVM, except those that the
+// jvmOpts already set.
+func getLocaleJvmOpts(config *LocaleConfig, jvmOpts []string) []string {
+	if config == nil {
+		return nil
+	}
+	var opts []string
+	for _, opt := range []struct {
+		prefix, value string
+	}{
+		{prefix: timezoneJvmOptPrefix, value: config.Timezone},
+		{prefix: fileEncodingJvmOptPrefix, value: config.FileEncoding},
+	} {
+		if opt.value != "" && !hasJvmOptPrefix(jvmOpts, opt.prefix) {
+			opts = append(opts, opt.prefix+opt.value)
+		}
+	}
+	return opts
+}
+
+func hasJvmOptPrefix(jvmOpts []string, prefix string) bool {
+	for _, opt := range jvmOpts {
+		if strings.HasPrefix(opt, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// getLocaleEnv returns the TZ, LANG and LC_ALL environment variables that set the timezone and locale of the process,
+// and of the tools it runs, in place of those it would inherit.
+func getLocaleEnv(config *LocaleConfig) map[string]string {
+	if config == nil {
+		return nil
+	}
+	env := map[string]string{}
+	if config.Timezone != "" {
+		env["TZ"] = config.Timezone
+	}
+	if config.Lang != "" {
+		env["LANG"] = config.Lang
+		env["LC_ALL"] = config.Lang
+	}
+	return env
+}
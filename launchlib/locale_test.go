@@ -0,0 +1,67 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLocaleJvmOpts(t *testing.T) {
+	for i, currCase := range []struct {
+		config  *LocaleConfig
+		jvmOpts []string
+		want    []string
+	}{
+		{config: nil, want: nil},
+		{config: &LocaleConfig{Lang: "C.UTF-8"}, want: nil},
+		{config: &LocaleConfig{Timezone: "UTC", FileEncoding: "UTF-8"},
+			want: []string{"-Duser.timezone=UTC", "-Dfile.encoding=UTF-8"}},
+		{config: &LocaleConfig{Timezone: "UTC", FileEncoding: "UTF-8"},
+			jvmOpts: []string{"-Xmx1g", "-Duser.timezone=Europe/London"}, want: []string{"-Dfile.encoding=UTF-8"}},
+	} {
+		assert.Equal(t, currCase.want, getLocaleJvmOpts(currCase.config, currCase.jvmOpts), "Case %d", i)
+	}
+}
+
+func TestGetLocaleEnv(t *testing.T) {
+	assert.Nil(t, getLocaleEnv(nil))
+	assert.Equal(t, map[string]string{"TZ": "UTC"}, getLocaleEnv(&LocaleConfig{Timezone: "UTC", FileEncoding: "UTF-8"}))
+	assert.Equal(t, map[string]string{"TZ": "Europe/London", "LANG": "en_GB.UTF-8", "LC_ALL": "en_GB.UTF-8"},
+		getLocaleEnv(&LocaleConfig{Timezone: "Europe/London", Lang: "en_GB.UTF-8"}))
+}
+
+func TestValidateLocaleConfig(t *testing.T) {
+	for i, currCase := range []struct {
+		config LocaleConfig
+		err    string
+	}{
+		{config: LocaleConfig{Timezone: "America/Argentina/Buenos_Aires", Lang: "sr_RS.UTF-8@latin",
+			FileEncoding: "ISO-8859-1"}},
+		{config: LocaleConfig{Timezone: "UTC+1"}},
+		{config: LocaleConfig{Lang: "en US"},
+			err: "lang must consist of letters, digits and the characters _./:@+-, found 'en US'"},
+		{config: LocaleConfig{FileEncoding: "UTF-8 -Xmx1g"},
+			err: "fileEncoding must consist of letters, digits and the characters _./:@+-, found 'UTF-8 -Xmx1g'"},
+	} {
+		err := currCase.config.validate()
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
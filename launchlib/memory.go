@@ -0,0 +1,149 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MemoryConfig sizes the heap relative to the memory available to the process, the memory limit of the container or
+// otherwise the physical memory of the host, so that the same config fits hosts of any size.
+type MemoryConfig struct {
+	// HeapPercentage is the percentage of the available memory, less OffHeapReserveMb, used as the heap.
+	HeapPercentage float64 `yaml:"heapPercentage"`
+	// OffHeapReserveMb is the memory in megabytes kept for the metaspace, thread stacks, direct buffers and other
+	// memory outside the heap.
+	OffHeapReserveMb int64 `yaml:"offHeapReserveMb"`
+	// MinHeapMb is the size in megabytes below which the heap is not sized, however little memory is available.
+	MinHeapMb int64 `yaml:"minHeapMb"`
+}
+
+// meminfoFile is where the physical memory of the host is read from, overridden in tests.
+var meminfoFile = "/proc/meminfo"
+
+// heapFlagPrefixes are the prefixes of the JVM options that size the heap, any of which disables automatic heap sizing.
+var heapFlagPrefixes = []string{
+	"-Xmx",
+	"-Xms",
+	"-XX:MaxHeapSize=",
+	"-XX:InitialHeapSize=",
+	"-XX:MaxRAM=",
+	"-XX:MaxRAMPercentage=",
+	"-XX:InitialRAMPercentage=",
+	"-XX:MinRAMPercentage=",
+}
+
+func (config *MemoryConfig) validate() error {
+	if config.HeapPercentage <= 0 || config.HeapPercentage > 100 {
+		return errors.Errorf("heapPercentage must be greater than 0 and at most 100, found %v", config.HeapPercentage)
+	}
+	if config.OffHeapReserveMb < 0 {
+		return errors.Errorf("offHeapReserveMb must not be negative, found %d", config.OffHeapReserveMb)
+	}
+	if config.MinHeapMb < 0 {
+		return errors.Errorf("minHeapMb must not be negative, found %d", config.MinHeapMb)
+	}
+	return nil
+}
+
+// getHeapSizingJvmOpts returns -Xmx and -Xms options sizing the heap as the memory config, or otherwise the
+// heapPercentage of the memory limit of the container, of the given config does. Returns none if neither is set, the
+// given options already size the heap or the memory to size it from is unknown.
+func getHeapSizingJvmOpts(config JavaConfig, jvmOpts []string) ([]string, error) {
+	if (config.Memory == nil && config.HeapPercentage == 0) || hasHeapFlag(jvmOpts) {
+		return nil, nil
+	}
+	if config.Memory != nil {
+		return getMemoryJvmOpts(*config.Memory)
+	}
+	limit, ok, err := getCgroupMemoryLimit()
+	if err != nil || !ok {
+		return nil, err
+	}
+	heapMegabytes := int64(float64(limit)*config.HeapPercentage/100) / (1024 * 1024)
+	return heapSizeJvmOpts(heapMegabytes), nil
+}
+
+// getMemoryJvmOpts returns the options sizing the heap to the heapPercentage of the available memory that remains
+// after the offHeapReserveMb, and at least minHeapMb.
+func getMemoryJvmOpts(config MemoryConfig) ([]string, error) {
+	available, ok, err := getAvailableMemory()
+	if err != nil || !ok {
+		return nil, err
+	}
+	availableMegabytes := available / (1024 * 1024)
+	heapMegabytes := int64(float64(availableMegabytes-config.OffHeapReserveMb) * config.HeapPercentage / 100)
+	if heapMegabytes < config.MinHeapMb {
+		heapMegabytes = config.MinHeapMb
+	}
+	if heapMegabytes <= 0 {
+		return nil, errors.Errorf("offHeapReserveMb of %d leaves no memory for the heap of the %dMB available, "+
+			"set minHeapMb", config.OffHeapReserveMb, availableMegabytes)
+	}
+	tracef("Sizing heap to %dMB of the %dMB available", heapMegabytes, availableMegabytes)
+	return heapSizeJvmOpts(heapMegabytes), nil
+}
+
+func heapSizeJvmOpts(heapMegabytes int64) []string {
+	return []string{fmt.Sprintf("-Xmx%dm", heapMegabytes), fmt.Sprintf("-Xms%dm", heapMegabytes)}
+}
+
+// getAvailableMemory returns the memory in bytes available to the process: the memory limit of the container if set,
+// or the physical memory of the host otherwise. Returns false if neither is known, as is the case outside Linux.
+func getAvailableMemory() (int64, bool, error) {
+	if limit, ok, err := getCgroupMemoryLimit(); err != nil || ok {
+		return limit, ok, err
+	}
+	file, err := os.Open(meminfoFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, false, nil
+		}
+		return 0, false, errors.Wrap(err, "failed to read the physical memory of the host")
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[0] != "MemTotal:" || fields[2] != "kB" {
+			continue
+		}
+		kilobytes, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, false, errors.Wrapf(err, "invalid MemTotal in %s", meminfoFile)
+		}
+		return kilobytes * 1024, true, nil
+	}
+	return 0, false, errors.Wrapf(scanner.Err(), "no MemTotal in %s", meminfoFile)
+}
+
+func hasHeapFlag(jvmOpts []string) bool {
+	for _, opt := range jvmOpts {
+		for _, prefix := range heapFlagPrefixes {
+			if strings.HasPrefix(opt, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withCgroupFiles(t *testing.T, files map[string]string) func() {
+	dir, err := ioutil.TempDir("", "cgroup")
+	require.NoError(t, err)
+	for name, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	original := cgroupRoot
+	cgroupRoot = dir
+	return func() {
+		cgroupRoot = original
+		require.NoError(t, os.RemoveAll(dir))
+	}
+}
+
+func TestGetHeapSizingJvmOpts(t *testing.T) {
+	for i, currCase := range []struct {
+		name           string
+		files          map[string]string
+		heapPercentage float64
+		jvmOpts        []string
+		want           []string
+	}{
+		{
+			name:           "cgroup v2 limit",
+			files:          map[string]string{"memory.max": "2147483648\n"},
+			heapPercentage: 75,
+			want:           []string{"-Xmx1536m", "-Xms1536m"},
+		},
+		{
+			name:           "cgroup v1 limit",
+			files:          map[string]string{"memory/memory.limit_in_bytes": "1073741824\n"},
+			heapPercentage: 50,
+			want:           []string{"-Xmx512m", "-Xms512m"},
+		},
+		{
+			name:           "cgroup v2 without limit",
+			files:          map[string]string{"memory.max": "max\n"},
+			heapPercentage: 75,
+		},
+		{
+			name:           "cgroup v1 without limit",
+			files:          map[string]string{"memory/memory.limit_in_bytes": "9223372036854771712\n"},
+			heapPercentage: 75,
+		},
+		{
+			name:           "no cgroup",
+			heapPercentage: 75,
+		},
+		{
+			name:  "disabled",
+			files: map[string]string{"memory.max": "2147483648\n"},
+		},
+		{
+			name:           "explicit heap flag",
+			files:          map[string]string{"memory.max": "2147483648\n"},
+			heapPercentage: 75,
+			jvmOpts:        []string{"-XX:+UseG1GC", "-XX:MaxRAMPercentage=50"},
+		},
+	} {
+		cleanup := withCgroupFiles(t, currCase.files)
+		got, err := getHeapSizingJvmOpts(JavaConfig{HeapPercentage: currCase.heapPercentage}, currCase.jvmOpts)
+		cleanup()
+		require.NoError(t, err, "Case %d: %s", i, currCase.name)
+		assert.Equal(t, currCase.want, got, "Case %d: %s", i, currCase.name)
+	}
+}
+
+func TestGetHeapSizingJvmOptsFromMemoryConfig(t *testing.T) {
+	meminfo, err := ioutil.TempFile("", "meminfo")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.Remove(meminfo.Name()))
+	}()
+	_, err = meminfo.WriteString("MemTotal:       16777216 kB\nMemFree:         1048576 kB\n")
+	require.NoError(t, err)
+	require.NoError(t, meminfo.Close())
+	original := meminfoFile
+	meminfoFile = meminfo.Name()
+	defer func() {
+		meminfoFile = original
+	}()
+
+	for i, currCase := range []struct {
+		name    string
+		files   map[string]string
+		memory  MemoryConfig
+		jvmOpts []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "physical memory of the host",
+			memory: MemoryConfig{HeapPercentage: 50, OffHeapReserveMb: 1024},
+			want:   []string{"-Xmx7680m", "-Xms7680m"},
+		},
+		{
+			name:   "memory limit of the container",
+			files:  map[string]string{"memory.max": "4294967296\n"},
+			memory: MemoryConfig{HeapPercentage: 75, OffHeapReserveMb: 1024},
+			want:   []string{"-Xmx2304m", "-Xms2304m"},
+		},
+		{
+			name:   "minimum heap",
+			files:  map[string]string{"memory.max": "1073741824\n"},
+			memory: MemoryConfig{HeapPercentage: 50, OffHeapReserveMb: 768, MinHeapMb: 256},
+			want:   []string{"-Xmx256m", "-Xms256m"},
+		},
+		{
+			name:    "reserve exceeds available memory",
+			files:   map[string]string{"memory.max": "1073741824\n"},
+			memory:  MemoryConfig{HeapPercentage: 50, OffHeapReserveMb: 2048},
+			wantErr: "offHeapReserveMb of 2048 leaves no memory for the heap of the 1024MB available, set minHeapMb",
+		},
+		{
+			name:    "explicit heap flag",
+			memory:  MemoryConfig{HeapPercentage: 50},
+			jvmOpts: []string{"-Xmx2g"},
+		},
+	} {
+		cleanup := withCgroupFiles(t, currCase.files)
+		memory := currCase.memory
+		got, err := getHeapSizingJvmOpts(JavaConfig{Memory: &memory}, currCase.jvmOpts)
+		cleanup()
+		if currCase.wantErr != "" {
+			assert.EqualError(t, err, currCase.wantErr, "Case %d: %s", i, currCase.name)
+			continue
+		}
+		require.NoError(t, err, "Case %d: %s", i, currCase.name)
+		assert.Equal(t, currCase.want, got, "Case %d: %s", i, currCase.name)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// LatestConfigVersion is the configVersion of the current configuration format. Configs of earlier versions are
+// migrated to it when they are read, and can be rewritten in it by 'go-init migrate-config'.
+const LatestConfigVersion = 2
+
+// configMigration migrates a config document from the preceding configVersion.
+type configMigration struct {
+	// migrate migrates the decoded document in place.
+	migrate func(document map[interface{}]interface{}) error
+	// rewrites migrate the lines of the document in the same way where possible, preserving its comments and
+	// formatting.
+	rewrites []lineRewrite
+}
+
+type lineRewrite struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// staticConfigMigrations and customConfigMigrations are the migrations of the static and custom configs to each
+// configVersion after the first. A version without migrations only changes the configVersion.
+var (
+	staticConfigMigrations = map[int]configMigration{
+		// configVersion 2 replaces retryDelaySeconds with retryDelay, a duration like the others of the config.
+		2: {
+			migrate: migrateRetryDelay,
+			rewrites: []lineRewrite{{
+				pattern:     regexp.MustCompile(`^(\s*)retryDelaySeconds:(\s*)(\d+)(\s*(#.*)?)$`),
+				replacement: "${1}retryDelay:${2}${3}s${4}",
+			}},
+		},
+	}
+	customConfigMigrations = map[int]configMigration{}
+)
+
+var configVersionLinePattern = regexp.MustCompile(`^configVersion:(\s*)\d+(\s*(#.*)?)$`)
+
+// MigrateStaticConfig returns the given static config rewritten in LatestConfigVersion, along with whether its comments
+// and formatting were preserved. Configs already in LatestConfigVersion are returned as they are.
+func MigrateStaticConfig(data []byte) ([]byte, bool, error) {
+	return migrateConfigFile(data, staticConfigMigrations)
+}
+
+// MigrateCustomConfig returns the given custom config rewritten in LatestConfigVersion, along with whether its comments
+// and formatting were preserved. Configs already in LatestConfigVersion are returned as they are.
+func MigrateCustomConfig(data []byte) ([]byte, bool, error) {
+	return migrateConfigFile(data, customConfigMigrations)
+}
+
+// ConfigVersion returns the configVersion of the given config, which is zero if it is not set.
+func ConfigVersion(data []byte) (int, error) {
+	var config VersionedConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return 0, errors.Wrap(err, "Failed to deserialize Launcher Config, please check the syntax of your "+
+			"configuration file")
+	}
+	return config.Version, nil
+}
+
+func migrateConfigFile(data []byte, migrations map[int]configMigration) ([]byte, bool, error) {
+	version, err := ConfigVersion(data)
+	if err != nil {
+		return nil, false, err
+	}
+	if version == LatestConfigVersion {
+		return data, true, nil
+	}
+	document, err := migrateConfigDocument(data, version, migrations)
+	if err != nil {
+		return nil, false, err
+	}
+	if rewritten, ok := rewriteConfigLines(data, version, migrations, document); ok {
+		return rewritten, true, nil
+	}
+	migrated, err := yaml.Marshal(document)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "failed to serialize migrated config")
+	}
+	return migrated, false, nil
+}
+
+// rewriteConfigLines returns the lines of the given config of the given version rewritten by the migrations to
+// LatestConfigVersion, preserving its comments, formatting and the values of its scalars. The lines are only used if
+// they decode to the same document as the migrated one, as they may not be rewritten where they are not in block style.
+func rewriteConfigLines(data []byte, version int, migrations map[int]configMigration,
+	document map[interface{}]interface{}) ([]byte, bool) {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		lines[i] = configVersionLinePattern.ReplaceAllString(line, fmt.Sprintf("configVersion:${1}%d${2}",
+			LatestConfigVersion))
+		for v := version + 1; v <= LatestConfigVersion; v++ {
+			for _, rewrite := range migrations[v].rewrites {
+				lines[i] = rewrite.pattern.ReplaceAllString(lines[i], rewrite.replacement)
+			}
+		}
+	}
+	rewritten := []byte(strings.Join(lines, "\n"))
+	var rewrittenDocument map[interface{}]interface{}
+	if err := yaml.Unmarshal(rewritten, &rewrittenDocument); err != nil || !reflect.DeepEqual(document,
+		rewrittenDocument) {
+		return nil, false
+	}
+	return rewritten, true
+}
+
+// upgradeConfig returns the given config of the given, allowed configVersion migrated to LatestConfigVersion, so that
+// configs of every version are parsed in the current format. Configs are only re-serialized if their lines cannot be
+// rewritten, as that changes the values of the unquoted scalars that are parsed as strings, such as 0022 or yes.
+func upgradeConfig(data []byte, version int, migrations map[int]configMigration) ([]byte, error) {
+	if version == LatestConfigVersion {
+		return data, nil
+	}
+	document, err := migrateConfigDocument(data, version, migrations)
+	if err != nil {
+		return nil, err
+	}
+	// Configs that the migrations do not change but for their configVersion are parsed as they are.
+	var original map[interface{}]interface{}
+	if err := yaml.Unmarshal(data, &original); err == nil && original != nil {
+		original["configVersion"] = LatestConfigVersion
+		if reflect.DeepEqual(original, document) {
+			return data, nil
+		}
+	}
+	if rewritten, ok := rewriteConfigLines(data, version, migrations, document); ok {
+		return rewritten, nil
+	}
+	migrated, err := yaml.Marshal(document)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to migrate config from configVersion %d", version)
+	}
+	return migrated, nil
+}
+
+// currentConfig returns the given config migrated to LatestConfigVersion if it can be, or as it is otherwise, leaving
+// its problems to be reported when it is parsed.
+func currentConfig(data []byte, migrations map[int]configMigration) []byte {
+	version, err := ConfigVersion(data)
+	if err != nil {
+		return data
+	}
+	if _, ok := allowedLauncherConfigs.ConfigVersions[version]; !ok {
+		return data
+	}
+	if migrated, err := upgradeConfig(data, version, migrations); err == nil {
+		return migrated
+	}
+	return data
+}
+
+// migrateConfigDocument decodes the given config of the given version and migrates it to LatestConfigVersion.
+func migrateConfigDocument(data []byte, version int, migrations map[int]configMigration) (
+	map[interface{}]interface{}, error) {
+	if _, ok := allowedLauncherConfigs.ConfigVersions[version]; !ok {
+		return nil, fmt.Errorf("Can handle configVersion=%v only, found %d",
+			toString(convertMap(allowedLauncherConfigs.ConfigVersions)), version)
+	}
+	var document map[interface{}]interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, errors.Wrap(err, "Failed to deserialize Launcher Config, please check the syntax of your "+
+			"configuration file")
+	}
+	for v := version + 1; v <= LatestConfigVersion; v++ {
+		if migration, ok := migrations[v]; ok {
+			if err := migration.migrate(document); err != nil {
+				return nil, errors.Wrapf(err, "failed to migrate config to configVersion %d", v)
+			}
+		}
+	}
+	document["configVersion"] = LatestConfigVersion
+	return document, nil
+}
+
+// migrateRetryDelay replaces the retryDelaySeconds of the process, its defaults and its subProcesses with retryDelay.
+func migrateRetryDelay(document map[interface{}]interface{}) error {
+	processes := []map[interface{}]interface{}{document}
+	if defaults, ok := document["defaults"].(map[interface{}]interface{}); ok {
+		processes = append(processes, defaults)
+	}
+	if subProcesses, ok := document["subProcesses"].(map[interface{}]interface{}); ok {
+		for _, subProcess := range subProcesses {
+			if subProcess, ok := subProcess.(map[interface{}]interface{}); ok {
+				processes = append(processes, subProcess)
+			}
+		}
+	}
+	for _, process := range processes {
+		seconds, ok := process["retryDelaySeconds"]
+		if !ok {
+			continue
+		}
+		if _, ok := seconds.(int); !ok {
+			return errors.Errorf("retryDelaySeconds must be an integer, found '%v'", seconds)
+		}
+		delete(process, "retryDelaySeconds")
+		process["retryDelay"] = fmt.Sprintf("%ds", seconds)
+	}
+	return nil
+}
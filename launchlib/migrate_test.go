@@ -0,0 +1,181 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateStaticConfig(t *testing.T) {
+	for i, currCase := range []struct {
+		name          string
+		data          string
+		want          string
+		wantFormatted bool
+	}{
+		{
+			name: "rewrites lines",
+			data: `configType: executable
+configVersion: 1 # the version
+serviceName: primary
+executable: postgres
+fileMode: 0640
+retryDelaySeconds: 5 # between retries
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: envoy
+    retryDelaySeconds: 10
+`,
+			want: `configType: executable
+configVersion: 2 # the version
+serviceName: primary
+executable: postgres
+fileMode: 0640
+retryDelay: 5s # between retries
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: envoy
+    retryDelay: 10s
+`,
+			wantFormatted: true,
+		},
+		{
+			name: "falls back to serializing flow style",
+			data: `configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+defaults: {retryDelaySeconds: 5}
+`,
+			want: `configType: executable
+configVersion: 2
+defaults:
+  retryDelay: 5s
+executable: postgres
+serviceName: primary
+`,
+		},
+		{
+			name: "keeps latest version",
+			data: `configType: executable
+configVersion: 2
+serviceName: primary
+executable: postgres
+retryDelay: 5s
+`,
+			want: `configType: executable
+configVersion: 2
+serviceName: primary
+executable: postgres
+retryDelay: 5s
+`,
+			wantFormatted: true,
+		},
+	} {
+		migrated, formatted, err := MigrateStaticConfig([]byte(currCase.data))
+		require.NoError(t, err, "Case %d: %s", i, currCase.name)
+		assert.Equal(t, currCase.want, string(migrated), "Case %d: %s", i, currCase.name)
+		assert.Equal(t, currCase.wantFormatted, formatted, "Case %d: %s", i, currCase.name)
+	}
+}
+
+func TestMigrateStaticConfigFailures(t *testing.T) {
+	for i, currCase := range []struct {
+		name string
+		data string
+		msg  string
+	}{
+		{
+			name: "unknown version",
+			data: "configType: executable\nconfigVersion: 3\n",
+			msg:  "Can handle configVersion={1, 2} only, found 3",
+		},
+		{
+			name: "invalid retry delay",
+			data: "configType: executable\nconfigVersion: 1\nretryDelaySeconds: soon\n",
+			msg:  "failed to migrate config to configVersion 2: retryDelaySeconds must be an integer, found 'soon'",
+		},
+	} {
+		_, _, err := MigrateStaticConfig([]byte(currCase.data))
+		require.Error(t, err, "Case %d: %s", i, currCase.name)
+		assert.Equal(t, currCase.msg, err.Error(), "Case %d: %s", i, currCase.name)
+	}
+}
+
+func TestParseStaticConfigMigratesVersion1(t *testing.T) {
+	config, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+defaults:
+  retryDelaySeconds: 5
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: envoy
+    retryDelaySeconds: 10
+`))
+	require.NoError(t, err)
+	assert.Equal(t, 1, config.Version)
+	assert.Equal(t, 5*time.Second, config.RetryDelay)
+	assert.Equal(t, 10*time.Second, config.SubProcesses["sidecar"].RetryDelay)
+}
+
+func TestParseStaticConfigMigrationPreservesScalars(t *testing.T) {
+	for i, currCase := range []struct {
+		name string
+		data string
+	}{
+		{
+			name: "no migration needed",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+env:
+  UMASK: 0022
+  FLAG: yes
+  VER: 1.10
+`,
+		},
+		{
+			name: "lines rewritten",
+			data: `
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+retryDelaySeconds: 5
+env:
+  UMASK: 0022
+  FLAG: yes
+  VER: 1.10
+`,
+		},
+	} {
+		config, err := parseStaticConfig([]byte(currCase.data))
+		require.NoError(t, err, "Case %d: %s", i, currCase.name)
+		assert.Equal(t, map[string]string{"UMASK": "0022", "FLAG": "yes", "VER": "1.10"}, config.Env,
+			"Case %d: %s", i, currCase.name)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ModuleAccess grants the Targets modules access to Package of Module, passed to the java command as
+// --add-opens or --add-exports <module>/<package>=<target>[,<target>...]. ALL-UNNAMED targets the classpath.
+type ModuleAccess struct {
+	Module  string   `yaml:"module"`
+	Package string   `yaml:"package"`
+	Targets []string `yaml:"targets"`
+}
+
+func (access ModuleAccess) validate() error {
+	if access.Module == "" || access.Package == "" || len(access.Targets) == 0 {
+		return errors.Errorf("module, package and targets must be set, found %+v", access)
+	}
+	return nil
+}
+
+func (access ModuleAccess) arg(option string) string {
+	return fmt.Sprintf("%s=%s/%s=%s", option, access.Module, access.Package, strings.Join(access.Targets, ","))
+}
+
+// validateModuleConfig validates the module options of the config, which require a module path if a main module is set.
+func validateModuleConfig(config JavaConfig) error {
+	if config.MainModule != "" && len(config.ModulePath) == 0 {
+		return errors.New("modulePath must be set along with mainModule")
+	}
+	for _, access := range config.AddOpens {
+		if err := access.validate(); err != nil {
+			return errors.Wrap(err, "invalid addOpens")
+		}
+	}
+	for _, access := range config.AddExports {
+		if err := access.validate(); err != nil {
+			return errors.Wrap(err, "invalid addExports")
+		}
+	}
+	return nil
+}
+
+// getModuleArgs returns the module options of the java command for the config, with the module path resolved relative
+// to workingDir like the classpath.
+func getModuleArgs(config JavaConfig, workingDir string) ([]string, error) {
+	var args []string
+	if len(config.ModulePath) > 0 {
+		modulePath, err := resolveClasspathEntries(absolutizeClasspathEntries(workingDir, config.ModulePath))
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid modulePath")
+		}
+		args = append(args, "--module-path", joinClasspathEntries(modulePath))
+	}
+	if len(config.AddModules) > 0 {
+		args = append(args, "--add-modules", strings.Join(config.AddModules, ","))
+	}
+	for _, access := range config.AddOpens {
+		args = append(args, access.arg("--add-opens"))
+	}
+	for _, access := range config.AddExports {
+		args = append(args, access.arg("--add-exports"))
+	}
+	return args, nil
+}
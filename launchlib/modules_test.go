@@ -0,0 +1,117 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStaticConfig_Modules(t *testing.T) {
+	config, err := parseStaticConfig([]byte(`
+configType: java
+configVersion: 1
+serviceName: primary
+mainModule: com.example.app/com.example.app.Main
+modulePath:
+  - service/lib
+addModules:
+  - jdk.incubator.foreign
+addOpens:
+  - module: java.base
+    package: java.lang
+    targets:
+      - ALL-UNNAMED
+addExports:
+  - module: java.base
+    package: sun.nio.ch
+    targets:
+      - com.example.app
+      - ALL-UNNAMED
+`))
+	require.NoError(t, err)
+
+	workingDir, err := ioutil.TempDir("", "modules")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(workingDir))
+	}()
+	require.NoError(t, os.MkdirAll(filepath.Join(workingDir, "service", "lib"), 0755))
+
+	args, err := getModuleArgs(config.JavaConfig, workingDir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"--module-path", filepath.Join(workingDir, "service", "lib"),
+		"--add-modules", "jdk.incubator.foreign",
+		"--add-opens=java.base/java.lang=ALL-UNNAMED",
+		"--add-exports=java.base/sun.nio.ch=com.example.app,ALL-UNNAMED",
+	}, args)
+}
+
+func TestParseStaticConfig_ModuleFailures(t *testing.T) {
+	for i, currCase := range []struct {
+		name string
+		msg  string
+		data string
+	}{
+		{
+			name: "main class and main module",
+			msg:  "only one of mainClass, mainModule and jarPath may be set",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: com.example.app.Main
+mainModule: com.example.app
+modulePath:
+  - service/lib
+`,
+		},
+		{
+			name: "main module without module path",
+			msg:  "modulePath must be set along with mainModule",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+mainModule: com.example.app
+`,
+		},
+		{
+			name: "add opens without targets",
+			msg:  "invalid addOpens: module, package and targets must be set",
+			data: `
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: com.example.app.Main
+classpath:
+  - service/lib/app.jar
+addOpens:
+  - module: java.base
+    package: java.lang
+`,
+		},
+	} {
+		_, err := parseStaticConfig([]byte(currCase.data))
+		require.Error(t, err, "Case %d: %s had no errors", i, currCase.name)
+		assert.Contains(t, err.Error(), currCase.msg, "Case %d: %s had the wrong error message", i, currCase.name)
+	}
+}
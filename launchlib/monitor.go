@@ -18,7 +18,6 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
@@ -76,7 +75,7 @@ func (m *ProcessMonitor) TermProcessGroupOnDeath() error {
 }
 
 func (m *ProcessMonitor) KillSubProcesses() error {
-	return m.SignalSubProcesses(syscall.SIGTERM)
+	return m.SignalSubProcesses(terminateSignal)
 }
 
 func (m *ProcessMonitor) SignalSubProcesses(sign os.Signal) error {
@@ -114,16 +113,6 @@ func IsPidAlive(pid int) bool {
 	return IsProcessAlive(process)
 }
 
-func IsProcessAlive(process *os.Process) bool {
-	// Sending a signal of 0 checks the process exists, without actually sending a signal,
-	// see https://linux.die.net/man/2/kill
-	err := process.Signal(syscall.Signal(0))
-	if err != nil {
-		return false
-	}
-	return true
-}
-
 func SignalPid(pid int, sign os.Signal) error {
 	process, err := os.FindProcess(pid)
 	if err != nil || !IsProcessAlive(process) {
@@ -0,0 +1,36 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package launchlib
+
+import (
+	"os"
+	"syscall"
+)
+
+// terminateSignal is sent to the sub-processes once the service process has died.
+var terminateSignal os.Signal = syscall.SIGTERM
+
+func IsProcessAlive(process *os.Process) bool {
+	// Sending a signal of 0 checks the process exists, without actually sending a signal,
+	// see https://linux.die.net/man/2/kill
+	err := process.Signal(syscall.Signal(0))
+	if err != nil {
+		return false
+	}
+	return true
+}
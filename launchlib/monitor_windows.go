@@ -0,0 +1,50 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package launchlib
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	processQueryLimitedInformation = 0x1000
+	// stillActive is the exit code of processes that have not exited.
+	stillActive = 259
+)
+
+// terminateSignal is sent to the sub-processes once the service process has died, which is the only signal that can
+// be sent to other processes on windows.
+var terminateSignal = os.Kill
+
+// IsProcessAlive checks whether the process has not exited by its exit code, as a process can still be opened once it
+// has exited until every handle to it has been closed.
+func IsProcessAlive(process *os.Process) bool {
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(process.Pid))
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = syscall.CloseHandle(handle)
+	}()
+	var exitCode uint32
+	if err := syscall.GetExitCodeProcess(handle, &exitCode); err != nil {
+		return false
+	}
+	return exitCode == stillActive
+}
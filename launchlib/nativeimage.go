@@ -0,0 +1,103 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// validateNativeImage validates that a nativeImage is not set along with a mainClass, mainModule or jarPath, which are
+// launched by a JVM instead.
+func validateNativeImage(config JavaConfig) error {
+	for _, main := range []struct {
+		name  string
+		value string
+	}{
+		{"mainClass", config.MainClass},
+		{"mainModule", config.MainModule},
+		{"jarPath", config.JarPath},
+	} {
+		if main.value != "" {
+			return errors.Errorf("%s cannot be set along with nativeImage, which is launched without a JVM", main.name)
+		}
+	}
+	return nil
+}
+
+// getNativeImageArgs returns the path of the native image of the config and the arguments it is launched with: the
+// heap, processor count, temporary directory and locale options, as for a JVM, followed by the static, custom and
+// environment jvmOpts, which native images accept at run time. The java installation, classpath, modules and agents of
+// the config are not used.
+func getNativeImageArgs(config JavaConfig, customJvmOpts []string, tmpDir *TmpDirConfig, locale *LocaleConfig,
+	workingDir string, logger io.Writer, redactor Redactor) (string, []string, error) {
+	nativeImage := config.NativeImage
+	if !filepath.IsAbs(nativeImage) {
+		nativeImage = filepath.Join(workingDir, nativeImage)
+	}
+	executable, err := verifyPathIsSafeForExec(nativeImage)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "invalid nativeImage")
+	}
+	fmt.Fprintln(logger, "Using native image:", executable)
+
+	if err := checkCustomJvmOpts(config, customJvmOpts); err != nil {
+		return "", nil, err
+	}
+	if len(customJvmOpts) > 0 {
+		tracef("Appending custom jvmOpts %v to static jvmOpts %v", redactor.redactArgs(customJvmOpts),
+			redactor.redactArgs(config.JvmOpts))
+	}
+	envJvmOpts, err := getEnvJvmOpts(config, logger, redactor)
+	if err != nil {
+		return "", nil, err
+	}
+	jvmOpts, conflicts := dedupeJvmOpts(append(append(append([]string{}, config.JvmOpts...), customJvmOpts...),
+		envJvmOpts...))
+	if len(conflicts) > 0 {
+		if config.StrictJvmOpts {
+			return "", nil, errors.Errorf("conflicting jvmOpts: %s", strings.Join(conflicts, ", "))
+		}
+		for _, conflict := range conflicts {
+			fmt.Fprintln(logger, "Warning: jvmOpt", conflict)
+		}
+	}
+	heapOpts, err := getHeapSizingJvmOpts(config, jvmOpts)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to size heap from available memory")
+	}
+	if len(heapOpts) > 0 {
+		fmt.Fprintln(logger, "Heap options from available memory:", heapOpts)
+	}
+	processorOpts, err := getProcessorCountJvmOpts(config.DisableActiveProcessorCount, jvmOpts)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to determine processor count from container CPU limit")
+	}
+	if len(processorOpts) > 0 {
+		fmt.Fprintln(logger, "Processor options from container CPU limit:", processorOpts)
+	}
+
+	args := []string{executable} // 0th argument is the command itself
+	args = append(args, heapOpts...)
+	args = append(args, processorOpts...)
+	args = append(args, getTmpDirJvmOpts(tmpDir, workingDir, jvmOpts)...)
+	args = append(args, getLocaleJvmOpts(locale, jvmOpts)...)
+	args = append(args, jvmOpts...)
+	return executable, args, nil
+}
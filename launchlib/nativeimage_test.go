@@ -0,0 +1,67 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileCmdNativeImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "native-image")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	nativeImage := filepath.Join(dir, "service", "bin", "app")
+	require.NoError(t, os.MkdirAll(filepath.Dir(nativeImage), 0755))
+	require.NoError(t, ioutil.WriteFile(nativeImage, []byte("#!/bin/sh\n"), 0755))
+
+	var log bytes.Buffer
+	cmd, err := compileCmdFromConfig(&StaticLauncherConfig{
+		TypedConfig: TypedConfig{Type: "java"},
+		JavaConfig: JavaConfig{
+			JavaHome:                    "/nonexistent/jdk",
+			Classpath:                   []string{"/nonexistent/lib/*.jar"},
+			NativeImage:                 "service/bin/app",
+			JvmOpts:                     []string{"-Xmx1g", "-Dstatic=true"},
+			DisableActiveProcessorCount: true,
+		},
+		Args:             []string{"server"},
+		WorkingDirectory: dir,
+	}, &CustomLauncherConfig{
+		JvmOpts: []string{"-Dcustom=true"},
+	}, NewSimpleWriterLogger(&log).PrimaryLogger)
+	require.NoError(t, err)
+
+	assert.Equal(t, nativeImage, cmd.Path)
+	assert.Equal(t, []string{nativeImage, "-Xmx1g", "-Dstatic=true", "-Dcustom=true", "server"}, cmd.Args)
+	assert.Contains(t, log.String(), "Using native image: "+nativeImage)
+	assert.NotContains(t, log.String(), "JAVA_HOME")
+
+	_, err = compileCmdFromConfig(&StaticLauncherConfig{
+		TypedConfig:      TypedConfig{Type: "java"},
+		JavaConfig:       JavaConfig{NativeImage: "service/bin/missing"},
+		WorkingDirectory: dir,
+	}, &CustomLauncherConfig{}, NewSimpleWriterLogger(ioutil.Discard).PrimaryLogger)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid nativeImage")
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// OutputStreamConfig is an additional output of the process, such as an audit or access log: a file that is opened for
+// appending and passed to the process as the file descriptor FD, so that the process can write to it without opening
+// the file itself, and go-init rotates it along with the output files.
+type OutputStreamConfig struct {
+	// FD is the file descriptor of the stream in the process, from 3 up, as 0 to 2 are its stdin, stdout and stderr.
+	FD int `yaml:"fd"`
+	// File is the file of the stream, relative to the directory the launcher runs in unless absolute.
+	File string `yaml:"file"`
+}
+
+const (
+	minOutputStreamFD = 3
+	// maxOutputStreamFD bounds the file descriptors of the streams, as the process is passed every file descriptor
+	// below the highest, closed unless it is a stream.
+	maxOutputStreamFD = 255
+)
+
+func validateOutputStreams(streams []OutputStreamConfig) error {
+	fds := map[int]bool{}
+	files := map[string]bool{}
+	for _, stream := range streams {
+		if stream.FD < minOutputStreamFD || stream.FD > maxOutputStreamFD {
+			return errors.Errorf("fd must be between %d and %d, found %d", minOutputStreamFD, maxOutputStreamFD,
+				stream.FD)
+		}
+		if stream.File == "" {
+			return errors.Errorf("file must be set for fd %d", stream.FD)
+		}
+		if fds[stream.FD] {
+			return errors.Errorf("fd %d must not be listed more than once", stream.FD)
+		}
+		if file := filepath.Clean(stream.File); files[file] {
+			return errors.Errorf("file %s must not be listed more than once", file)
+		}
+		fds[stream.FD] = true
+		files[filepath.Clean(stream.File)] = true
+	}
+	return nil
+}
+
+// Path returns the path of the file of the stream.
+func (stream OutputStreamConfig) Path() string {
+	if filepath.IsAbs(stream.File) {
+		return filepath.Clean(stream.File)
+	}
+	return filepath.Join(getWorkingDir(), stream.File)
+}
+
+// OpenOutputStreams opens the file of each of the streams for appending, creating it and its directory with the given
+// permissions and owned by the user the command runs as, and passes them to the command as its ExtraFiles. The
+// returned files are to be closed once the command has started, as it keeps its own copies.
+func OpenOutputStreams(streams []OutputStreamConfig, cmd *exec.Cmd, fileMode, dirMode os.FileMode) ([]*os.File,
+	error) {
+	if len(streams) == 0 {
+		return nil, nil
+	}
+	var files []*os.File
+	closeFiles := func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}
+	var extraFiles []*os.File
+	for _, stream := range streams {
+		path := stream.Path()
+		if err := os.MkdirAll(filepath.Dir(path), dirMode); err != nil {
+			closeFiles()
+			return nil, errors.Wrapf(err, "failed to create the directory of output stream %s", path)
+		}
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, fileMode)
+		if err != nil {
+			closeFiles()
+			return nil, errors.Wrapf(err, "failed to open output stream %s", path)
+		}
+		files = append(files, file)
+		if err := chownToCommand(path, cmd); err != nil {
+			closeFiles()
+			return nil, err
+		}
+		// ExtraFiles are passed as the file descriptors from 3 up, those without a file being closed.
+		for len(extraFiles) <= stream.FD-minOutputStreamFD {
+			extraFiles = append(extraFiles, nil)
+		}
+		extraFiles[stream.FD-minOutputStreamFD] = file
+	}
+	cmd.ExtraFiles = extraFiles
+	return files, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateOutputStreams(t *testing.T) {
+	for i, currCase := range []struct {
+		streams []OutputStreamConfig
+		err     string
+	}{
+		{streams: []OutputStreamConfig{{FD: 3, File: "var/log/audit.log"}, {FD: 4, File: "var/log/access.log"}}},
+		{streams: []OutputStreamConfig{{FD: 2, File: "var/log/audit.log"}}, err: "fd must be between 3 and 255, found 2"},
+		{streams: []OutputStreamConfig{{FD: 3}}, err: "file must be set for fd 3"},
+		{streams: []OutputStreamConfig{{FD: 3, File: "var/log/audit.log"}, {FD: 3, File: "var/log/access.log"}},
+			err: "fd 3 must not be listed more than once"},
+		{streams: []OutputStreamConfig{{FD: 3, File: "var/log/audit.log"}, {FD: 4, File: "var/log/../log/audit.log"}},
+			err: "file var/log/audit.log must not be listed more than once"},
+	} {
+		err := validateOutputStreams(currCase.streams)
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestOpenOutputStreams(t *testing.T) {
+	dir, err := ioutil.TempDir("", "launchlib-output-streams")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	audit := filepath.Join(dir, "log", "audit.log")
+	access := filepath.Join(dir, "log", "access.log")
+	require.NoError(t, os.MkdirAll(filepath.Dir(access), 0755))
+	require.NoError(t, ioutil.WriteFile(access, []byte("previous\n"), 0644))
+
+	cmd := exec.Command("/bin/sh", "-c", "echo audited >&4; echo accessed >&5")
+	files, err := OpenOutputStreams([]OutputStreamConfig{{FD: 5, File: access}, {FD: 4, File: audit}}, cmd,
+		DefaultFileMode, DefaultDirMode)
+	require.NoError(t, err)
+	require.Len(t, cmd.ExtraFiles, 3)
+	assert.Nil(t, cmd.ExtraFiles[0])
+	require.NoError(t, cmd.Run())
+	for _, file := range files {
+		require.NoError(t, file.Close())
+	}
+
+	auditBytes, err := ioutil.ReadFile(audit)
+	require.NoError(t, err)
+	assert.Equal(t, "audited\n", string(auditBytes))
+	accessBytes, err := ioutil.ReadFile(access)
+	require.NoError(t, err)
+	assert.Equal(t, "previous\naccessed\n", string(accessBytes))
+}
+
+func TestParseStaticConfigOutputStreams(t *testing.T) {
+	for i, currCase := range []struct {
+		yaml string
+		err  string
+	}{
+		{yaml: `
+outputStreams:
+  - fd: 3
+    file: var/log/audit.log
+`},
+		{yaml: `
+outputStreams:
+  - fd: 1
+    file: var/log/audit.log
+`, err: "invalid outputStreams config: fd must be between 3 and 255, found 1"},
+		{yaml: `
+execMode: exec
+outputStreams:
+  - fd: 3
+    file: var/log/audit.log
+`, err: "execMode exec is not supported with outputStreams"},
+	} {
+		_, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+` + currCase.yaml))
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
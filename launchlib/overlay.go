@@ -0,0 +1,214 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const customConfigOverlayExtension = ".yml"
+
+// loadCustomConfigOverlays reads the custom configuration at customConfigPath and merges it with the configurations it
+// includes and overlays, returning the merged configuration and the files it was read from in the order they were
+// merged. No files are returned if none exist. The merge order, in which later files take precedence, is:
+//
+//  1. if customConfigPath is a directory, each of its *.yml files in lexical order, otherwise the files listed under
+//     its includes, each preceded by its own includes, followed by customConfigPath itself;
+//  2. each *.yml file of the directory named after customConfigPath without its extension plus ".d", in lexical
+//     order, e.g. var/conf/launcher-custom.d/*.yml for var/conf/launcher-custom.yml.
+func loadCustomConfigOverlays(customConfigPath string) (PrimaryCustomLauncherConfig, []string, error) {
+	loader := customConfigLoader{loading: map[string]bool{}}
+	if info, err := os.Stat(customConfigPath); err == nil && info.IsDir() {
+		if err := loader.loadDir(customConfigPath); err != nil {
+			return PrimaryCustomLauncherConfig{}, nil, err
+		}
+	} else if err == nil {
+		if err := loader.loadFile(customConfigPath); err != nil {
+			return PrimaryCustomLauncherConfig{}, nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return PrimaryCustomLauncherConfig{}, nil, errors.Wrap(err, "Failed to read custom config file: "+
+			customConfigPath)
+	} else {
+		tracef("Custom config file %s does not exist", absPath(customConfigPath))
+	}
+
+	overlayDir := strings.TrimSuffix(customConfigPath, filepath.Ext(customConfigPath)) + ".d"
+	if overlayDir != customConfigPath {
+		if info, err := os.Stat(overlayDir); err == nil && info.IsDir() {
+			tracef("Reading custom config overlays from %s", absPath(overlayDir))
+			if err := loader.loadDir(overlayDir); err != nil {
+				return PrimaryCustomLauncherConfig{}, nil, err
+			}
+		}
+	}
+	return loader.merged, loader.files, nil
+}
+
+// CustomConfigFiles returns the files that the custom configuration at customConfigPath is read from, including those
+// it includes and overlays, in the order they are merged.
+func CustomConfigFiles(customConfigPath string) ([]string, error) {
+	_, files, err := loadCustomConfigOverlays(customConfigPath)
+	return files, err
+}
+
+type customConfigLoader struct {
+	merged PrimaryCustomLauncherConfig
+	files  []string
+	// loading holds the files whose includes are being loaded, to detect include cycles.
+	loading map[string]bool
+}
+
+func (l *customConfigLoader) loadDir(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read custom config directory: "+dir)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == customConfigOverlayExtension {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := l.loadFile(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *customConfigLoader) loadFile(file string) error {
+	if l.loading[file] {
+		return errors.Errorf("custom config file %s includes itself", file)
+	}
+	tracef("Reading custom config file %s", absPath(file))
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return errors.Wrap(err, "Failed to read custom config file: "+file)
+	}
+	config, err := decodeCustomConfig(data)
+	if err != nil {
+		return errors.Wrapf(err, "invalid custom config file %s", file)
+	}
+
+	l.loading[file] = true
+	for _, include := range config.Includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(file), include)
+		}
+		tracef("Custom config file %s includes %s", file, include)
+		if err := l.loadFile(include); err != nil {
+			return errors.Wrapf(err, "failed to include custom config file from %s", file)
+		}
+	}
+	delete(l.loading, file)
+
+	merged, err := mergeCustomConfigs(l.merged, config)
+	if err != nil {
+		return errors.Wrapf(err, "failed to merge custom config file %s", file)
+	}
+	traceMerge(file, config)
+	l.merged = merged
+	l.files = append(l.files, file)
+	return nil
+}
+
+// traceMerge traces the values that the custom config of the file contributes to the merged custom config.
+func traceMerge(file string, config PrimaryCustomLauncherConfig) {
+	if TraceOutput == nil {
+		return
+	}
+	redactor := StaticLauncherConfig{}.Redactor()
+	trace := func(process string, config CustomLauncherConfig) {
+		if len(config.JvmOpts) > 0 {
+			tracef("Custom config file %s appends jvmOpts %v for %s", file, redactor.redactArgs(config.JvmOpts),
+				process)
+		}
+		keys := make([]string, 0, len(config.Env))
+		for key := range config.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		if len(keys) > 0 {
+			tracef("Custom config file %s sets env %v for %s, overriding earlier files", file, keys, process)
+		}
+	}
+	trace("the primary process", config.CustomLauncherConfig)
+	for name, subProcess := range config.SubProcesses {
+		trace("subProcess "+name, subProcess)
+	}
+}
+
+// mergeCustomConfigs returns base overlaid with overlay: the configVersion is overridden if set, jvmOpts are appended,
+// env variables are overridden individually and subProcesses are merged by name in the same way. The configType of
+// the two must agree where both are set.
+func mergeCustomConfigs(base, overlay PrimaryCustomLauncherConfig) (PrimaryCustomLauncherConfig, error) {
+	merged := PrimaryCustomLauncherConfig{
+		VersionedConfig: base.VersionedConfig,
+	}
+	if overlay.Version != 0 {
+		merged.Version = overlay.Version
+	}
+	var err error
+	if merged.CustomLauncherConfig, err = mergeCustomLauncherConfigs(base.CustomLauncherConfig,
+		overlay.CustomLauncherConfig); err != nil {
+		return PrimaryCustomLauncherConfig{}, err
+	}
+	for name, subProcess := range base.SubProcesses {
+		if merged.SubProcesses == nil {
+			merged.SubProcesses = map[string]CustomLauncherConfig{}
+		}
+		merged.SubProcesses[name] = subProcess
+	}
+	for name, subProcess := range overlay.SubProcesses {
+		if merged.SubProcesses == nil {
+			merged.SubProcesses = map[string]CustomLauncherConfig{}
+		}
+		if merged.SubProcesses[name], err = mergeCustomLauncherConfigs(merged.SubProcesses[name],
+			subProcess); err != nil {
+			return PrimaryCustomLauncherConfig{}, errors.Wrapf(err, "subProcess %s", name)
+		}
+	}
+	return merged, nil
+}
+
+func mergeCustomLauncherConfigs(base, overlay CustomLauncherConfig) (CustomLauncherConfig, error) {
+	if base.Type != "" && overlay.Type != "" && base.Type != overlay.Type {
+		return CustomLauncherConfig{}, errors.Errorf("conflicting configType %s and %s", base.Type, overlay.Type)
+	}
+	merged := CustomLauncherConfig{TypedConfig: base.TypedConfig}
+	if overlay.Type != "" {
+		merged.Type = overlay.Type
+	}
+	merged.JvmOpts = append(append([]string(nil), base.JvmOpts...), overlay.JvmOpts...)
+	if len(base.Env) > 0 || len(overlay.Env) > 0 {
+		merged.Env = make(map[string]string, len(base.Env)+len(overlay.Env))
+		for key, value := range base.Env {
+			merged.Env[key] = value
+		}
+		for key, value := range overlay.Env {
+			merged.Env[key] = value
+		}
+	}
+	return merged, nil
+}
@@ -0,0 +1,234 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCustomConfigFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+}
+
+func TestGetCustomConfigFromFileMergesOverlays(t *testing.T) {
+	for i, currCase := range []struct {
+		name     string
+		files    map[string]string
+		path     string
+		want     PrimaryCustomLauncherConfig
+		wantLoad []string
+	}{
+		{
+			name: "includes are merged before the including file, overlays after it",
+			files: map[string]string{
+				"common.yml": `
+configType: java
+configVersion: 1
+jvmOpts:
+  - -Xmx1g
+env:
+  A: common
+  B: common
+`,
+				"launcher-custom.yml": `
+includes:
+  - common.yml
+jvmOpts:
+  - -Xmx2g
+env:
+  A: custom
+subProcesses:
+  sidecar:
+    configType: executable
+    env:
+      C: custom
+`,
+				"launcher-custom.d/20-second.yml": `
+jvmOpts:
+  - -Xmx4g
+`,
+				"launcher-custom.d/10-first.yml": `
+jvmOpts:
+  - -Xmx3g
+subProcesses:
+  sidecar:
+    env:
+      C: first
+      D: first
+`,
+				"launcher-custom.d/ignored.txt": `jvmOpts: [-Xmx5g]`,
+			},
+			path: "launcher-custom.yml",
+			want: PrimaryCustomLauncherConfig{
+				VersionedConfig: VersionedConfig{Version: 1},
+				CustomLauncherConfig: CustomLauncherConfig{
+					TypedConfig: TypedConfig{Type: "java"},
+					JvmOpts:     []string{"-Xmx1g", "-Xmx2g", "-Xmx3g", "-Xmx4g"},
+					Env:         map[string]string{"A": "custom", "B": "common"},
+				},
+				SubProcesses: map[string]CustomLauncherConfig{
+					"sidecar": {
+						TypedConfig: TypedConfig{Type: "executable"},
+						Env:         map[string]string{"C": "first", "D": "first"},
+					},
+				},
+			},
+			wantLoad: []string{"common.yml", "launcher-custom.yml", "launcher-custom.d/10-first.yml",
+				"launcher-custom.d/20-second.yml"},
+		},
+		{
+			name: "directory is merged in lexical order",
+			files: map[string]string{
+				"conf/b.yml": `
+jvmOpts:
+  - -Xmx2g
+`,
+				"conf/a.yml": `
+configType: java
+configVersion: 1
+jvmOpts:
+  - -Xmx1g
+`,
+			},
+			path: "conf",
+			want: PrimaryCustomLauncherConfig{
+				VersionedConfig: VersionedConfig{Version: 1},
+				CustomLauncherConfig: CustomLauncherConfig{
+					TypedConfig: TypedConfig{Type: "java"},
+					JvmOpts:     []string{"-Xmx1g", "-Xmx2g"},
+				},
+			},
+			wantLoad: []string{"conf/a.yml", "conf/b.yml"},
+		},
+		{
+			name: "overlays apply without a custom config file",
+			files: map[string]string{
+				"launcher-custom.d/a.yml": `
+configType: java
+configVersion: 1
+jvmOpts:
+  - -Xmx1g
+`,
+			},
+			path: "launcher-custom.yml",
+			want: PrimaryCustomLauncherConfig{
+				VersionedConfig: VersionedConfig{Version: 1},
+				CustomLauncherConfig: CustomLauncherConfig{
+					TypedConfig: TypedConfig{Type: "java"},
+					JvmOpts:     []string{"-Xmx1g"},
+				},
+			},
+			wantLoad: []string{"launcher-custom.d/a.yml"},
+		},
+	} {
+		func() {
+			dir, err := ioutil.TempDir("", "overlay")
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, os.RemoveAll(dir))
+			}()
+			writeCustomConfigFiles(t, dir, currCase.files)
+
+			config, err := getCustomConfigFromFile(filepath.Join(dir, currCase.path), ioutil.Discard)
+			require.NoError(t, err, "Case %d: %s", i, currCase.name)
+			assert.Equal(t, currCase.want, config, "Case %d: %s", i, currCase.name)
+
+			_, files, err := loadCustomConfigOverlays(filepath.Join(dir, currCase.path))
+			require.NoError(t, err, "Case %d: %s", i, currCase.name)
+			for j := range currCase.wantLoad {
+				currCase.wantLoad[j] = filepath.Join(dir, currCase.wantLoad[j])
+			}
+			assert.Equal(t, currCase.wantLoad, files, "Case %d: %s", i, currCase.name)
+		}()
+	}
+}
+
+func TestGetCustomConfigFromFileOverlayFailures(t *testing.T) {
+	for i, currCase := range []struct {
+		name  string
+		files map[string]string
+		msg   string
+	}{
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"launcher-custom.yml": `
+includes:
+  - other.yml
+`,
+				"other.yml": `
+includes:
+  - launcher-custom.yml
+`,
+			},
+			msg: "includes itself",
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				"launcher-custom.yml": `
+includes:
+  - missing.yml
+`,
+			},
+			msg: "Failed to read custom config file",
+		},
+		{
+			name: "conflicting configType",
+			files: map[string]string{
+				"launcher-custom.yml": `
+configType: java
+configVersion: 1
+`,
+				"launcher-custom.d/a.yml": `
+configType: executable
+`,
+			},
+			msg: "conflicting configType java and executable",
+		},
+		{
+			name: "merged config is invalid",
+			files: map[string]string{
+				"launcher-custom.yml": `
+configType: java
+`,
+			},
+			msg: "Can handle configVersion",
+		},
+	} {
+		func() {
+			dir, err := ioutil.TempDir("", "overlay")
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, os.RemoveAll(dir))
+			}()
+			writeCustomConfigFiles(t, dir, currCase.files)
+
+			_, err = getCustomConfigFromFile(filepath.Join(dir, "launcher-custom.yml"), ioutil.Discard)
+			require.Error(t, err, "Case %d: %s", i, currCase.name)
+			assert.Contains(t, err.Error(), currCase.msg, "Case %d: %s", i, currCase.name)
+		}()
+	}
+}
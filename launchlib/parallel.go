@@ -0,0 +1,83 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// maxParallelChecks bounds the number of files, directories or dependencies that are checked at once, so that the
+// latency of checking the thousands of jars of a large dist on a network filesystem overlaps without exhausting the
+// file descriptors of the launcher.
+const maxParallelChecks = 16
+
+// runParallel calls check with each index below n, at most maxParallelChecks of them at once, and returns the errors
+// of the calls that failed in the order of their indices.
+func runParallel(n int, check func(i int) error) []error {
+	errs := make([]error, n)
+	slots := make(chan struct{}, maxParallelChecks)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = check(i)
+			<-slots
+		}(i)
+	}
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return failed
+}
+
+// joinErrors returns nil if there are no errors and the error itself if there is one, and otherwise an error listing
+// each of them under the summary, whose %d verb is replaced with their number, so that every failure is reported at
+// once.
+func joinErrors(summary string, errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = "- " + err.Error()
+	}
+	return errors.Errorf(summary+":\n%s", len(errs), strings.Join(lines, "\n"))
+}
+
+// syncWriter serializes the writes to a writer of the checks that run in parallel, such as their progress messages.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
@@ -0,0 +1,56 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunParallel(t *testing.T) {
+	var running, maxRunning int32
+	errs := runParallel(4*maxParallelChecks, func(i int) error {
+		curr := atomic.AddInt32(&running, 1)
+		for {
+			prev := atomic.LoadInt32(&maxRunning)
+			if curr <= prev || atomic.CompareAndSwapInt32(&maxRunning, prev, curr) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		if i%20 == 0 {
+			return errors.Errorf("check %d failed", i)
+		}
+		return nil
+	})
+	assert.True(t, maxRunning <= maxParallelChecks, "ran %d checks at once", maxRunning)
+	var msgs []string
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	assert.Equal(t, []string{"check 0 failed", "check 20 failed", "check 40 failed", "check 60 failed"}, msgs)
+}
+
+func TestJoinErrors(t *testing.T) {
+	assert.NoError(t, joinErrors("%d checks failed", nil))
+	assert.EqualError(t, joinErrors("%d checks failed", []error{errors.New("a")}), "a")
+	assert.EqualError(t, joinErrors("%d checks failed", []error{errors.New("a"), errors.New("b")}),
+		"2 checks failed:\n- a\n- b")
+}
@@ -0,0 +1,29 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package launchlib
+
+const (
+	// executableSuffix is the suffix of the file names of executables, such as java.
+	executableSuffix = ".exe"
+	// execPathDenylistRegex matches characters disallowed in paths we allow to be passed to CreateProcess, allowing
+	// the backslashes and drive letter colons of windows paths.
+	execPathDenylistRegex = `[^\w.\/\\:_\-]`
+	// maxArgLength and maxCommandLineLength are the length of the longest command line that CreateProcess accepts.
+	maxArgLength         = 32767
+	maxCommandLineLength = 32767
+)
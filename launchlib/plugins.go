@@ -0,0 +1,79 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PluginEventPreStart is the event of the plugins before a process is started, which any of them may veto or add
+// environment variables to. The plugins are also passed the payload of each of the NotificationEvent events.
+const PluginEventPreStart = "preStart"
+
+// PluginsConfig configures the plugins that 'go-init' runs on the launch events of the service, so that the owners of a
+// platform can layer their own policy, such as license checks or inventory registration, on top of the launcher. The
+// plugins are the executable files of Dir, run in the order of their names with the JSON payload of the event on their
+// stdin.
+type PluginsConfig struct {
+	// Dir is the directory of the plugins, relative to the directory the launcher runs in unless absolute,
+	// DefaultPluginsConfig.Dir if empty.
+	Dir string `yaml:"dir"`
+	// Timeout bounds each run of a plugin, DefaultPluginsConfig.Timeout if zero.
+	Timeout time.Duration `yaml:"timeout"`
+	// EnvAllowlist are the patterns, in the syntax of filepath.Match, of the names of the environment variables that
+	// the plugins may set for a process on its preStart event. Plugins may not set any variable unless it is set.
+	EnvAllowlist []string `yaml:"envAllowlist"`
+}
+
+var DefaultPluginsConfig = PluginsConfig{
+	Dir:     "service/plugins",
+	Timeout: 30 * time.Second,
+}
+
+func (config PluginsConfig) WithDefaults() PluginsConfig {
+	if config.Dir == "" {
+		config.Dir = DefaultPluginsConfig.Dir
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultPluginsConfig.Timeout
+	}
+	return config
+}
+
+func (config PluginsConfig) validate() error {
+	if config.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	for _, pattern := range config.EnvAllowlist {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return errors.Wrapf(err, "invalid envAllowlist pattern '%s'", pattern)
+		}
+	}
+	return nil
+}
+
+// AllowsEnv returns whether the plugins may set the named environment variable.
+func (config PluginsConfig) AllowsEnv(name string) bool {
+	for _, pattern := range config.EnvAllowlist {
+		// The patterns were validated along with the config.
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
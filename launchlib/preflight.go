@@ -0,0 +1,95 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// PreflightConfig are the checks of the host that 'go-init' makes before starting the service, which it refuses to
+// start with a descriptive error if any of them fails rather than letting the processes start and crash once they
+// cannot write.
+type PreflightConfig struct {
+	// MinFreeSpace is the minimum free space of the volume of each path, such as var/log, var/data or the tmpDir of a
+	// process, as a number of bytes with an optional K, M, G or T suffix. Paths are relative to the directory the
+	// launcher runs in unless absolute, and are checked on the volume of their closest existing ancestor.
+	MinFreeSpace map[string]string `yaml:"minFreeSpace"`
+	// WritablePidfileDir checks that a file can be created in the directory of the pidfiles.
+	WritablePidfileDir bool `yaml:"writablePidfileDir"`
+}
+
+func (config PreflightConfig) validate() error {
+	for _, path := range config.paths() {
+		if path == "" {
+			return errors.New("minFreeSpace paths must not be empty")
+		}
+		if _, ok := parseByteSize(config.MinFreeSpace[path]); !ok {
+			return errors.Errorf("minFreeSpace of %s must be a number of bytes with an optional K, M, G or T suffix, "+
+				"found '%s'", path, config.MinFreeSpace[path])
+		}
+	}
+	return nil
+}
+
+// MinFreeBytes returns the minimum free space of each path of MinFreeSpace in bytes, which must have been validated.
+func (config PreflightConfig) MinFreeBytes() map[string]uint64 {
+	minFreeBytes := make(map[string]uint64, len(config.MinFreeSpace))
+	for path, size := range config.MinFreeSpace {
+		value, _ := parseByteSize(size)
+		minFreeBytes[path] = uint64(value)
+	}
+	return minFreeBytes
+}
+
+// validatePorts validates that the ports of the processes are valid TCP ports, and that no two processes listen on the
+// same port.
+func validatePorts(config PrimaryStaticLauncherConfig) error {
+	names := make([]string, 0, len(config.SubProcesses))
+	for name := range config.SubProcesses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	names = append([]string{config.ServiceName}, names...)
+	listeners := map[int]string{}
+	for _, name := range names {
+		ports := config.Ports
+		if name != config.ServiceName {
+			ports = config.SubProcesses[name].Ports
+		}
+		for _, port := range ports {
+			if port < 1 || port > 65535 {
+				return errors.Errorf("ports of process '%s' must be between 1 and 65535, found %d", name, port)
+			}
+			if other, ok := listeners[port]; ok {
+				return errors.Errorf("port %d must not be listed by both processes '%s' and '%s'", port, other,
+					name)
+			}
+			listeners[port] = name
+		}
+	}
+	return nil
+}
+
+// paths returns the paths of MinFreeSpace in order, so that the first invalid one is reported consistently.
+func (config PreflightConfig) paths() []string {
+	paths := make([]string, 0, len(config.MinFreeSpace))
+	for path := range config.MinFreeSpace {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
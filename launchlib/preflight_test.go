@@ -0,0 +1,100 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStaticConfigPreflight(t *testing.T) {
+	for i, currCase := range []struct {
+		yaml string
+		want map[string]uint64
+		err  string
+	}{
+		{yaml: `
+preflight:
+  minFreeSpace:
+    var/log: 1G
+    var/data: 512m
+  writablePidfileDir: true
+`, want: map[string]uint64{"var/log": 1 << 30, "var/data": 512 << 20}},
+		{yaml: `
+preflight:
+  minFreeSpace:
+    var/log: 1GB
+`, err: "invalid preflight config: minFreeSpace of var/log must be a number of bytes with an optional K, M, G or " +
+			"T suffix, found '1GB'"},
+		{yaml: `
+preflight:
+  minFreeSpace:
+    "": 1G
+`, err: "invalid preflight config: minFreeSpace paths must not be empty"},
+	} {
+		config, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+` + currCase.yaml))
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+			assert.Equal(t, currCase.want, config.Preflight.MinFreeBytes(), "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestParseStaticConfigPorts(t *testing.T) {
+	for i, currCase := range []struct {
+		yaml string
+		err  string
+	}{
+		{yaml: `
+ports: [8443, 8444]
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: postgres
+    ports: [5432]
+`},
+		{yaml: `
+ports: [0]
+`, err: "ports of process 'primary' must be between 1 and 65535, found 0"},
+		{yaml: `
+ports: [8443]
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: postgres
+    ports: [8443]
+`, err: "port 8443 must not be listed by both processes 'primary' and 'sidecar'"},
+	} {
+		_, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+` + currCase.yaml))
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
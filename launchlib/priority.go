@@ -0,0 +1,92 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import "github.com/pkg/errors"
+
+// ioClasses are the IO scheduling classes of ioClass by name, with the values of ioprio_set(2).
+var ioClasses = map[string]int{
+	"realtime":    1,
+	"best-effort": 2,
+	"idle":        3,
+}
+
+// Priority is the CPU, IO and OOM killer priority of a process. Each unset value is inherited from the launcher.
+type Priority struct {
+	Nice        *int
+	IOClass     string
+	IOPriority  *int
+	OOMScoreAdj *int
+}
+
+// Priority returns the priority of the process launched with the config.
+func (config StaticLauncherConfig) Priority() Priority {
+	return Priority{
+		Nice:        config.Nice,
+		IOClass:     config.IOClass,
+		IOPriority:  config.IOPriority,
+		OOMScoreAdj: config.OOMScoreAdj,
+	}
+}
+
+func (p Priority) isSet() bool {
+	return p.Nice != nil || p.IOClass != "" || p.OOMScoreAdj != nil
+}
+
+func (p Priority) validate() error {
+	if p.Nice != nil && (*p.Nice < -20 || *p.Nice > 19) {
+		return errors.Errorf("nice must be between -20 and 19, found %d", *p.Nice)
+	}
+	if p.IOClass != "" {
+		if _, ok := ioClasses[p.IOClass]; !ok {
+			return errors.Errorf("ioClass must be one of realtime, best-effort or idle, found '%s'", p.IOClass)
+		}
+	}
+	if p.IOPriority != nil {
+		if p.IOClass != "realtime" && p.IOClass != "best-effort" {
+			return errors.New("ioPriority requires an ioClass of realtime or best-effort")
+		}
+		if *p.IOPriority < 0 || *p.IOPriority > 7 {
+			return errors.Errorf("ioPriority must be between 0 and 7, found %d", *p.IOPriority)
+		}
+	}
+	if p.OOMScoreAdj != nil && (*p.OOMScoreAdj < -1000 || *p.OOMScoreAdj > 1000) {
+		return errors.Errorf("oomScoreAdj must be between -1000 and 1000, found %d", *p.OOMScoreAdj)
+	}
+	return nil
+}
+
+// ioprio returns the IO priority of ioprio_set(2), of which the class is held in the bits above the priority.
+func (p Priority) ioprio() int {
+	level := 4
+	if p.IOPriority != nil {
+		level = *p.IOPriority
+	}
+	if p.IOClass == "idle" {
+		level = 0
+	}
+	return ioClasses[p.IOClass]<<13 | level
+}
+
+// SetPriority sets the priority of every thread of the process with the given pid, or of the launcher itself if pid
+// is 0 so that the priority is kept by the process it execs. Threads created later inherit the priority of the thread
+// that creates them. Lowering nice or oomScoreAdj below their current values, or using the realtime ioClass, requires
+// the launcher to run as root.
+func SetPriority(pid int, priority Priority) error {
+	if !priority.isSet() {
+		return nil
+	}
+	return setPriority(pid, priority)
+}
@@ -0,0 +1,87 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+const ioprioWhoProcess = 1
+
+func setPriority(pid int, priority Priority) error {
+	if priority.Nice != nil || priority.IOClass != "" {
+		if err := forEachThread(pid, func(tid int) error {
+			return setThreadPriority(tid, priority)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if priority.OOMScoreAdj != nil {
+		if err := ioutil.WriteFile(filepath.Join(procDir(pid), "oom_score_adj"),
+			[]byte(strconv.Itoa(*priority.OOMScoreAdj)), 0644); err != nil {
+			return errors.Wrapf(err, "failed to set oomScoreAdj to %d", *priority.OOMScoreAdj)
+		}
+	}
+	return nil
+}
+
+func setThreadPriority(tid int, priority Priority) error {
+	// A thread that exits while the threads are being changed no longer needs its priority set.
+	if priority.Nice != nil {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, tid, *priority.Nice); err != nil && err != syscall.ESRCH {
+			return errors.Wrapf(err, "failed to set nice to %d", *priority.Nice)
+		}
+	}
+	if priority.IOClass != "" {
+		_, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid),
+			uintptr(priority.ioprio()))
+		if errno != 0 && errno != syscall.ESRCH {
+			return errors.Wrapf(errno, "failed to set the IO priority to class %s", priority.IOClass)
+		}
+	}
+	return nil
+}
+
+// procDir returns the /proc directory of the process with the given pid, or of the launcher if pid is 0.
+func procDir(pid int) string {
+	if pid == 0 {
+		return "/proc/self"
+	}
+	return filepath.Join("/proc", strconv.Itoa(pid))
+}
+
+// forEachThread calls fn with the id of each thread of the process with the given pid, or of the launcher if pid is 0.
+func forEachThread(pid int, fn func(tid int) error) error {
+	tasks, err := ioutil.ReadDir(filepath.Join(procDir(pid), "task"))
+	if err != nil {
+		return errors.Wrap(err, "failed to list the threads of the process")
+	}
+	for _, task := range tasks {
+		tid, err := strconv.Atoi(task.Name())
+		if err != nil {
+			continue
+		}
+		if err := fn(tid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePriority(t *testing.T) {
+	for i, currCase := range []struct {
+		priority Priority
+		err      string
+	}{
+		{priority: Priority{
+			Nice: intPtr(19), IOClass: "best-effort", IOPriority: intPtr(7), OOMScoreAdj: intPtr(-1000)}},
+		{priority: Priority{IOClass: "idle"}},
+		{priority: Priority{Nice: intPtr(-21)}, err: "nice must be between -20 and 19, found -21"},
+		{priority: Priority{IOClass: "low"}, err: "ioClass must be one of realtime, best-effort or idle, found 'low'"},
+		{priority: Priority{IOClass: "idle", IOPriority: intPtr(0)},
+			err: "ioPriority requires an ioClass of realtime or best-effort"},
+		{priority: Priority{IOClass: "realtime", IOPriority: intPtr(8)},
+			err: "ioPriority must be between 0 and 7, found 8"},
+		{priority: Priority{OOMScoreAdj: intPtr(1001)}, err: "oomScoreAdj must be between -1000 and 1000, found 1001"},
+	} {
+		err := currCase.priority.validate()
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestIoprio(t *testing.T) {
+	assert.Equal(t, 2<<13|4, Priority{IOClass: "best-effort"}.ioprio())
+	assert.Equal(t, 1<<13|0, Priority{IOClass: "realtime", IOPriority: intPtr(0)}.ioprio())
+	assert.Equal(t, 3<<13, Priority{IOClass: "idle"}.ioprio())
+}
+
+func TestSetPriority(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("priority is only supported on linux")
+	}
+	cmd := exec.Command("sleep", "10")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	pid := cmd.Process.Pid
+
+	// Raising nice and oomScoreAdj does not require root.
+	require.NoError(t, SetPriority(pid, Priority{Nice: intPtr(19), OOMScoreAdj: intPtr(1000)}))
+	nice, err := syscall.Getpriority(syscall.PRIO_PROCESS, pid)
+	require.NoError(t, err)
+	// getpriority(2) returns 20 - nice.
+	assert.Equal(t, 1, nice)
+	oomScoreAdj, err := ioutil.ReadFile("/proc/" + strconv.Itoa(pid) + "/oom_score_adj")
+	require.NoError(t, err)
+	assert.Equal(t, "1000", strings.TrimSpace(string(oomScoreAdj)))
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ProfileEnvVar selects the profile of the static configuration to launch with, as the --profile flag of the launcher
+// and of go-init do.
+const ProfileEnvVar = "GO_JAVA_LAUNCHER_PROFILE"
+
+// Profile is the name of the profile of the static configuration that is applied when it is read, or empty to apply
+// none. Static configurations that do not define profiles ignore it, while those that do fail to be read if it is not
+// one of them.
+var Profile = ""
+
+// ProfileConfig is a profile of the static configuration, such as dev or prod, whose jvmOpts and env are applied to
+// the primary process and its subProcesses when the profile is selected.
+type ProfileConfig struct {
+	ProcessProfileConfig `yaml:",inline"`
+	SubProcesses         map[string]ProcessProfileConfig `yaml:"subProcesses"`
+}
+
+// ProcessProfileConfig are the settings of a profile for a single process. JvmOpts are passed after the jvmOpts of
+// the process, and Env overrides the variables of the same names in its env.
+type ProcessProfileConfig struct {
+	JvmOpts []string          `yaml:"jvmOpts"`
+	Env     map[string]string `yaml:"env"`
+}
+
+func validateProfiles(config PrimaryStaticLauncherConfig) error {
+	for name, profile := range config.Profiles {
+		if name == "" {
+			return errors.New("profile names must not be empty")
+		}
+		for subProcess := range profile.SubProcesses {
+			if _, ok := config.SubProcesses[subProcess]; !ok {
+				return errors.Errorf("profile '%s' sets subProcess '%s' that does not exist in the static config",
+					name, subProcess)
+			}
+		}
+	}
+	return nil
+}
+
+// applyProfile applies the profile of the given name to the processes of the config, if it defines profiles.
+func (config *PrimaryStaticLauncherConfig) applyProfile(name string) error {
+	if name == "" || len(config.Profiles) == 0 {
+		return nil
+	}
+	profile, ok := config.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(config.Profiles))
+		for profileName := range config.Profiles {
+			names = append(names, profileName)
+		}
+		sort.Strings(names)
+		return errors.Errorf("profile '%s' is not defined in the static config, expected one of %s", name,
+			strings.Join(names, ", "))
+	}
+	tracef("Applying profile %s", name)
+	config.StaticLauncherConfig.applyProcessProfile(profile.ProcessProfileConfig)
+	for subProcessName, subProfile := range profile.SubProcesses {
+		subProcess := config.SubProcesses[subProcessName]
+		subProcess.applyProcessProfile(subProfile)
+		config.SubProcesses[subProcessName] = subProcess
+	}
+	config.Profile = name
+	return nil
+}
+
+// applyProcessProfile applies the profile to the process, copying its jvmOpts and env as they may be shared with other
+// processes through the defaults.
+func (config *StaticLauncherConfig) applyProcessProfile(profile ProcessProfileConfig) {
+	if len(profile.JvmOpts) > 0 {
+		config.JvmOpts = append(append([]string(nil), config.JvmOpts...), profile.JvmOpts...)
+	}
+	if len(profile.Env) > 0 {
+		env := make(map[string]string, len(config.Env)+len(profile.Env))
+		for key, value := range config.Env {
+			env[key] = value
+		}
+		for key, value := range profile.Env {
+			env[key] = value
+		}
+		config.Env = env
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const profilesStaticConfig = `
+configType: java
+configVersion: 2
+serviceName: primary
+mainClass: com.example.Main
+classpath:
+  - service/lib/*
+jvmOpts:
+  - -Xmx1g
+env:
+  LOG_LEVEL: info
+  REGION: us-east-1
+profiles:
+  dev:
+    jvmOpts:
+      - -Xmx256m
+    env:
+      LOG_LEVEL: debug
+  prod:
+    subProcesses:
+      sidecar:
+        env:
+          SIDECAR_MODE: strict
+subProcesses:
+  sidecar:
+    configType: executable
+    executable: envoy
+`
+
+func TestParseStaticConfigProfiles(t *testing.T) {
+	defer func(profile string) {
+		Profile = profile
+	}(Profile)
+
+	for i, currCase := range []struct {
+		profile    string
+		jvmOpts    []string
+		env        map[string]string
+		sidecarEnv map[string]string
+	}{
+		{"", []string{"-Xmx1g"}, map[string]string{"LOG_LEVEL": "info", "REGION": "us-east-1"}, nil},
+		{"dev", []string{"-Xmx1g", "-Xmx256m"}, map[string]string{"LOG_LEVEL": "debug", "REGION": "us-east-1"}, nil},
+		{"prod", []string{"-Xmx1g"}, map[string]string{"LOG_LEVEL": "info", "REGION": "us-east-1"},
+			map[string]string{"SIDECAR_MODE": "strict"}},
+	} {
+		Profile = currCase.profile
+		config, err := parseStaticConfig([]byte(profilesStaticConfig))
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.profile, config.Profile, "Case %d", i)
+		assert.Equal(t, currCase.jvmOpts, config.JvmOpts, "Case %d", i)
+		assert.Equal(t, currCase.env, config.Env, "Case %d", i)
+		assert.Equal(t, currCase.sidecarEnv, config.SubProcesses["sidecar"].Env, "Case %d", i)
+	}
+
+	Profile = "staging"
+	_, err := parseStaticConfig([]byte(profilesStaticConfig))
+	assert.EqualError(t, err, "profile 'staging' is not defined in the static config, expected one of dev, prod")
+}
+
+func TestParseStaticConfigProfileIgnoredWithoutProfiles(t *testing.T) {
+	defer func(profile string) {
+		Profile = profile
+	}(Profile)
+
+	Profile = "prod"
+	config, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "", config.Profile)
+}
+
+func TestParseStaticConfigInvalidProfiles(t *testing.T) {
+	_, err := parseStaticConfig([]byte(`
+configType: executable
+configVersion: 1
+serviceName: primary
+executable: postgres
+profiles:
+  prod:
+    subProcesses:
+      sidecar:
+        env:
+          MODE: strict
+`))
+	assert.EqualError(t, err, "invalid profiles: profile 'prod' sets subProcess 'sidecar' that does not exist in the "+
+		"static config")
+}
+
+func TestApplyProcessProfileCopiesDefaults(t *testing.T) {
+	defaults := StaticLauncherConfig{Env: map[string]string{"A": "1"}}
+	defaults.JvmOpts = []string{"-Xmx1g"}
+	var config StaticLauncherConfig
+	config.applyDefaults(defaults)
+	config.applyProcessProfile(ProcessProfileConfig{
+		JvmOpts: []string{"-Xmx2g"},
+		Env:     map[string]string{"A": "2"},
+	})
+	assert.Equal(t, []string{"-Xmx1g", "-Xmx2g"}, config.JvmOpts)
+	assert.Equal(t, map[string]string{"A": "2"}, config.Env)
+	assert.Equal(t, []string{"-Xmx1g"}, defaults.JvmOpts)
+	assert.Equal(t, map[string]string{"A": "1"}, defaults.Env)
+}
@@ -0,0 +1,152 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// redactedValue replaces sensitive values in the output of the launcher.
+const redactedValue = "<redacted>"
+
+// DefaultSensitiveKeys are the patterns of the keys whose values are always redacted, in addition to sensitiveKeys.
+var DefaultSensitiveKeys = []string{"PASSWORD", "TOKEN", "SECRET"}
+
+// Redactor replaces the values of sensitive environment variables, system properties and key=value arguments in the
+// output of the launcher. A key is sensitive if it is read from envFromFiles, is one of secretProperties or matches one
+// of DefaultSensitiveKeys or sensitiveKeys, case-insensitively. Values decrypted by the launcher are redacted wherever
+// they appear.
+type Redactor struct {
+	secretKeys map[string]struct{}
+	patterns   []*regexp.Regexp
+}
+
+// validateSensitiveKeys validates that each of the sensitiveKeys is a valid regular expression.
+func validateSensitiveKeys(sensitiveKeys []string) error {
+	for _, pattern := range sensitiveKeys {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return errors.Wrapf(err, "invalid sensitiveKeys pattern '%s'", pattern)
+		}
+	}
+	return nil
+}
+
+// Redactor returns the Redactor of the output of the process launched with the config.
+func (config StaticLauncherConfig) Redactor() Redactor {
+	redactor := Redactor{secretKeys: make(map[string]struct{}, len(config.EnvFromFiles))}
+	for name := range config.EnvFromFiles {
+		redactor.secretKeys[name] = struct{}{}
+	}
+	for _, name := range config.SecretProperties {
+		redactor.secretKeys[name] = struct{}{}
+	}
+	for _, pattern := range append(append([]string{}, DefaultSensitiveKeys...), config.SensitiveKeys...) {
+		// The patterns were validated along with the config.
+		if compiled, err := regexp.Compile("(?i)" + pattern); err == nil {
+			redactor.patterns = append(redactor.patterns, compiled)
+		}
+	}
+	return redactor
+}
+
+func (r Redactor) isSensitive(key string) bool {
+	if _, ok := r.secretKeys[key]; ok {
+		return true
+	}
+	for _, pattern := range r.patterns {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactArg redacts the value of an argument of the form -D<key>=<value>, --<key>=<value> or <key>=<value>, such as a
+// system property or an environment variable, if its key is sensitive.
+func (r Redactor) redactArg(arg string) string {
+	arg = redactDecryptedValues(arg)
+	parts := strings.SplitN(arg, "=", 2)
+	if len(parts) != 2 {
+		return arg
+	}
+	key := parts[0]
+	if strings.HasPrefix(key, "-D") {
+		key = strings.TrimPrefix(key, "-D")
+	} else {
+		key = strings.TrimLeft(key, "-")
+	}
+	if !r.isSensitive(key) {
+		return arg
+	}
+	return parts[0] + "=" + redactedValue
+}
+
+// RedactArgs returns a copy of args with the values of their sensitive key=value arguments and system properties
+// redacted.
+func (r Redactor) RedactArgs(args []string) []string {
+	return r.redactArgs(args)
+}
+
+func (r Redactor) redactArgs(args []string) []string {
+	if args == nil {
+		return nil
+	}
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = r.redactArg(arg)
+	}
+	return redacted
+}
+
+func (r Redactor) redactEnv(env map[string]string) map[string]string {
+	if env == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(env))
+	for key, value := range env {
+		if r.isSensitive(key) {
+			value = redactedValue
+		}
+		value = redactDecryptedValues(value)
+		redacted[key] = value
+	}
+	return redacted
+}
+
+// redactStaticConfig returns a copy of the config with the sensitive values of its env, jvmOpts and args redacted.
+func (r Redactor) redactStaticConfig(config StaticLauncherConfig) StaticLauncherConfig {
+	config.Env = r.redactEnv(config.Env)
+	config.JvmOpts = r.redactArgs(config.JvmOpts)
+	if config.VersionedJvmOpts != nil {
+		versionedOpts := make([]VersionedJvmOpts, len(config.VersionedJvmOpts))
+		for i, versioned := range config.VersionedJvmOpts {
+			versioned.JvmOpts = r.redactArgs(versioned.JvmOpts)
+			versionedOpts[i] = versioned
+		}
+		config.VersionedJvmOpts = versionedOpts
+	}
+	config.Args = r.redactArgs(config.Args)
+	return config
+}
+
+// redactCustomConfig returns a copy of the config with the sensitive values of its env and jvmOpts redacted.
+func (r Redactor) redactCustomConfig(config CustomLauncherConfig) CustomLauncherConfig {
+	config.Env = r.redactEnv(config.Env)
+	config.JvmOpts = r.redactArgs(config.JvmOpts)
+	return config
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactArg(t *testing.T) {
+	redactor := StaticLauncherConfig{
+		SensitiveKeys: []string{"^credentials\\.", "API_KEY"},
+		EnvFromFiles:  map[string]string{"DB_URL": "/secrets/db-url"},
+	}.Redactor()
+	for i, currCase := range []struct {
+		arg  string
+		want string
+	}{
+		{arg: "-Ddb.password=hunter2", want: "-Ddb.password=<redacted>"},
+		{arg: "--auth-token=abc", want: "--auth-token=<redacted>"},
+		{arg: "CLIENT_SECRET=abc", want: "CLIENT_SECRET=<redacted>"},
+		{arg: "-Dcredentials.user=admin", want: "-Dcredentials.user=<redacted>"},
+		{arg: "my_api_key=abc", want: "my_api_key=<redacted>"},
+		{arg: "DB_URL=postgres://user:pass@db", want: "DB_URL=<redacted>"},
+		{arg: "-Dlog.level=INFO", want: "-Dlog.level=INFO"},
+		{arg: "-XX:MaxRAMPercentage=75", want: "-XX:MaxRAMPercentage=75"},
+		{arg: "password", want: "password"},
+	} {
+		assert.Equal(t, currCase.want, redactor.redactArg(currCase.arg), "Case %d", i)
+	}
+}
+
+func TestCompileCmdRedactsLog(t *testing.T) {
+	var log bytes.Buffer
+	_, err := compileCmdFromConfig(&StaticLauncherConfig{
+		TypedConfig: TypedConfig{Type: "executable"},
+		Executable:  "/bin/ls",
+		Env:         map[string]string{"DB_PASSWORD": "static-secret"},
+		Args:        []string{"--token=arg-secret"},
+	}, &CustomLauncherConfig{
+		Env: map[string]string{"API_TOKEN": "custom-secret"},
+	}, NewSimpleWriterLogger(&log).PrimaryLogger)
+	require.NoError(t, err)
+
+	assert.Contains(t, log.String(), "--token=<redacted>")
+	for _, secret := range []string{"static-secret", "arg-secret", "custom-secret"} {
+		assert.NotContains(t, log.String(), secret)
+	}
+}
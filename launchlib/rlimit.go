@@ -0,0 +1,141 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package launchlib
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+const unlimitedRlimit = "unlimited"
+
+type rlimit struct {
+	name     string
+	resource int
+	limit    syscall.Rlimit
+}
+
+// parseRlimits parses the given resource limits, each of the form <limit> or <soft limit>:<hard limit> where a limit is
+// an integer or "unlimited", sorted by name.
+func parseRlimits(rlimits map[string]string) ([]rlimit, error) {
+	names := make([]string, 0, len(rlimits))
+	for name := range rlimits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parsed := make([]rlimit, 0, len(rlimits))
+	for _, name := range names {
+		resource, ok := rlimitResources[name]
+		if !ok {
+			supported := make([]string, 0, len(rlimitResources))
+			for supportedName := range rlimitResources {
+				supported = append(supported, supportedName)
+			}
+			sort.Strings(supported)
+			return nil, errors.Errorf("unknown rlimit '%s', expected one of %v", name, supported)
+		}
+		limit, err := parseRlimit(rlimits[name])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid rlimit %s", name)
+		}
+		parsed = append(parsed, rlimit{name: name, resource: resource, limit: limit})
+	}
+	return parsed, nil
+}
+
+func parseRlimit(value string) (syscall.Rlimit, error) {
+	parts := strings.SplitN(value, ":", 2)
+	soft, err := parseRlimitValue(parts[0])
+	if err != nil {
+		return syscall.Rlimit{}, err
+	}
+	hard := soft
+	if len(parts) == 2 {
+		if hard, err = parseRlimitValue(parts[1]); err != nil {
+			return syscall.Rlimit{}, err
+		}
+	}
+	if soft > hard {
+		return syscall.Rlimit{}, errors.Errorf("soft limit %s exceeds hard limit %s", parts[0], parts[1])
+	}
+	return newRlimit(soft, hard), nil
+}
+
+func parseRlimitValue(value string) (uint64, error) {
+	if value == unlimitedRlimit {
+		return rlimInfinity, nil
+	}
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("'%s' is not a non-negative integer or %s", value, unlimitedRlimit)
+	}
+	return limit, nil
+}
+
+// SetRlimits sets the given resource limits of the launcher, which are inherited by the processes it starts or execs,
+// returning a function that restores the previous limits. Hard limits can only be raised when running as root; the
+// previous limits cannot be restored if a hard limit was lowered without running as root.
+func SetRlimits(rlimits map[string]string) (func() error, error) {
+	parsed, err := parseRlimits(rlimits)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous []rlimit
+	restore := func() error {
+		for _, prev := range previous {
+			if err := syscall.Setrlimit(prev.resource, &prev.limit); err != nil {
+				return errors.Wrapf(err, "failed to restore rlimit %s", prev.name)
+			}
+		}
+		return nil
+	}
+	for _, limit := range parsed {
+		var current syscall.Rlimit
+		if err := syscall.Getrlimit(limit.resource, &current); err != nil {
+			return nil, errors.Wrapf(err, "failed to get rlimit %s", limit.name)
+		}
+		soft, hard := rlimitValues(limit.limit)
+		_, currentHard := rlimitValues(current)
+		if hard > currentHard && os.Geteuid() != 0 {
+			_ = restore()
+			return nil, errors.Errorf("rlimit %s of %s exceeds the hard limit of %s, which only root can raise",
+				limit.name, formatRlimitValue(hard), formatRlimitValue(currentHard))
+		}
+		if err := syscall.Setrlimit(limit.resource, &limit.limit); err != nil {
+			_ = restore()
+			return nil, errors.Wrapf(err, "failed to set rlimit %s to %s:%s", limit.name,
+				formatRlimitValue(soft), formatRlimitValue(hard))
+		}
+		previous = append([]rlimit{{name: limit.name, resource: limit.resource, limit: current}}, previous...)
+	}
+	return restore, nil
+}
+
+func formatRlimitValue(value uint64) string {
+	if value == rlimInfinity {
+		return unlimitedRlimit
+	}
+	return strconv.FormatUint(value, 10)
+}
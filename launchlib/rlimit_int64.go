@@ -0,0 +1,30 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build freebsd || dragonfly
+// +build freebsd dragonfly
+
+package launchlib
+
+import "syscall"
+
+// newRlimit returns the limit with the given soft and hard values, which are signed on this platform.
+func newRlimit(soft, hard uint64) syscall.Rlimit {
+	return syscall.Rlimit{Cur: int64(soft), Max: int64(hard)}
+}
+
+// rlimitValues returns the soft and hard values of the given limit.
+func rlimitValues(limit syscall.Rlimit) (soft, hard uint64) {
+	return uint64(limit.Cur), uint64(limit.Max)
+}
@@ -0,0 +1,47 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import "syscall"
+
+const rlimInfinity = ^uint64(0)
+
+// The resources syscall has no constants for, whose numbers are the same on every architecture.
+const (
+	rlimitLocks      = 10
+	rlimitSigpending = 11
+	rlimitMsgqueue   = 12
+	rlimitNice       = 13
+	rlimitRtprio     = 14
+)
+
+// rlimitResources are the resources whose limits can be configured, as named by ulimit and limits.conf.
+var rlimitResources = map[string]int{
+	"cpu":        syscall.RLIMIT_CPU,
+	"fsize":      syscall.RLIMIT_FSIZE,
+	"data":       syscall.RLIMIT_DATA,
+	"stack":      syscall.RLIMIT_STACK,
+	"core":       syscall.RLIMIT_CORE,
+	"rss":        rlimitRSS,
+	"nproc":      rlimitNproc,
+	"nofile":     syscall.RLIMIT_NOFILE,
+	"memlock":    rlimitMemlock,
+	"as":         syscall.RLIMIT_AS,
+	"locks":      rlimitLocks,
+	"sigpending": rlimitSigpending,
+	"msgqueue":   rlimitMsgqueue,
+	"nice":       rlimitNice,
+	"rtprio":     rlimitRtprio,
+}
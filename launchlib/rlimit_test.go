@@ -0,0 +1,72 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package launchlib
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRlimit(t *testing.T) {
+	for i, currCase := range []struct {
+		value string
+		want  syscall.Rlimit
+		err   string
+	}{
+		{value: "1024", want: syscall.Rlimit{Cur: 1024, Max: 1024}},
+		{value: "1024:4096", want: syscall.Rlimit{Cur: 1024, Max: 4096}},
+		{value: "unlimited", want: newRlimit(rlimInfinity, rlimInfinity)},
+		{value: "0:unlimited", want: newRlimit(0, rlimInfinity)},
+		{value: "4096:1024", err: "soft limit 4096 exceeds hard limit 1024"},
+		{value: "-1", err: "'-1' is not a non-negative integer or unlimited"},
+		{value: "1024:lots", err: "'lots' is not a non-negative integer or unlimited"},
+	} {
+		limit, err := parseRlimit(currCase.value)
+		if currCase.err != "" {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+			continue
+		}
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, limit, "Case %d", i)
+	}
+
+	_, err := parseRlimits(map[string]string{"files": "1024"})
+	assert.Contains(t, err.Error(), "unknown rlimit 'files', expected one of")
+}
+
+func TestSetRlimits(t *testing.T) {
+	var original syscall.Rlimit
+	require.NoError(t, syscall.Getrlimit(syscall.RLIMIT_CORE, &original))
+	if original.Max == 0 {
+		t.Skip("the core hard limit is already 0")
+	}
+
+	_, hard := rlimitValues(original)
+	restore, err := SetRlimits(map[string]string{"core": "0:" + formatRlimitValue(hard)})
+	require.NoError(t, err)
+	var limit syscall.Rlimit
+	require.NoError(t, syscall.Getrlimit(syscall.RLIMIT_CORE, &limit))
+	assert.Equal(t, syscall.Rlimit{Cur: 0, Max: original.Max}, limit)
+
+	require.NoError(t, restore())
+	require.NoError(t, syscall.Getrlimit(syscall.RLIMIT_CORE, &limit))
+	assert.Equal(t, original, limit)
+}
@@ -0,0 +1,39 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package launchlib
+
+import "github.com/pkg/errors"
+
+type rlimit struct{}
+
+func parseRlimits(rlimits map[string]string) ([]rlimit, error) {
+	if len(rlimits) > 0 {
+		return nil, errors.New("rlimits are not supported on windows")
+	}
+	return nil, nil
+}
+
+// SetRlimits fails if any resource limits are given, as windows has none.
+func SetRlimits(rlimits map[string]string) (func() error, error) {
+	if _, err := parseRlimits(rlimits); err != nil {
+		return nil, err
+	}
+	return func() error {
+		return nil
+	}, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+// MountNamespaceConfig configures the private mount namespace that a process runs in.
+type MountNamespaceConfig struct {
+	// ReadOnlyRoot makes the root filesystem read-only for the process, except for its dirs and WritablePaths.
+	ReadOnlyRoot bool `yaml:"readOnlyRoot"`
+	// WritablePaths are the paths that remain writable, relative to the working directory of the launcher unless
+	// absolute, in addition to dirs.
+	WritablePaths []string `yaml:"writablePaths"`
+}
+
+// Isolation is the isolation of a process that the launcher applies to a single thread, as it cannot be undone.
+type Isolation struct {
+	Capabilities   *CapabilitiesConfig
+	MountNamespace *MountNamespaceConfig
+	// WritablePaths are the paths that remain writable under a read-only root.
+	WritablePaths []string
+}
+
+// Isolation returns the isolation of the process launched with the config.
+func (config StaticLauncherConfig) Isolation() Isolation {
+	isolation := Isolation{
+		Capabilities:   config.Capabilities,
+		MountNamespace: config.MountNamespace,
+	}
+	if config.MountNamespace != nil {
+		for _, dir := range config.Dirs {
+			isolation.WritablePaths = append(isolation.WritablePaths, dir.Path)
+		}
+		isolation.WritablePaths = append(isolation.WritablePaths, config.MountNamespace.WritablePaths...)
+	}
+	return isolation
+}
+
+// Isolate applies the isolation to the calling thread, which must be locked to its goroutine, so that it applies to the
+// processes the thread starts or execs. Requires the launcher to run as root if any isolation is set.
+func Isolate(isolation Isolation) error {
+	if isolation.MountNamespace != nil {
+		if err := unshareMounts(*isolation.MountNamespace, isolation.WritablePaths); err != nil {
+			return err
+		}
+	}
+	return LimitCapabilities(isolation.Capabilities)
+}
+
+// StartIsolated starts the command with the given isolation, on a thread of the launcher that is dedicated to starting
+// it and discarded afterwards. Starts the command as is if no isolation is set.
+func StartIsolated(cmd *exec.Cmd, isolation Isolation) error {
+	if isolation.Capabilities == nil && isolation.MountNamespace == nil {
+		return cmd.Start()
+	}
+	started := make(chan error, 1)
+	go func() {
+		// The thread is not unlocked, so that it exits along with the goroutine rather than running other goroutines
+		// with its isolation.
+		runtime.LockOSThread()
+		if err := Isolate(isolation); err != nil {
+			started <- err
+			return
+		}
+		started <- cmd.Start()
+	}()
+	return <-started
+}
+
+// resolveWorkingDir returns the absolute path of the working directory of a process, relative to that of the launcher
+// unless absolute, or that of the launcher if unset.
+func resolveWorkingDir(workingDirectory string) (string, error) {
+	launcherDir := getWorkingDir()
+	if workingDirectory == "" {
+		return launcherDir, nil
+	}
+	if !filepath.IsAbs(workingDirectory) {
+		workingDirectory = filepath.Join(launcherDir, workingDirectory)
+		tracef("Resolved the relative workingDirectory against the launcher directory %s", launcherDir)
+	}
+	if info, err := os.Stat(workingDirectory); err != nil || !info.IsDir() {
+		return "", errors.Errorf("workingDirectory %s is not a directory", workingDirectory)
+	}
+	return filepath.Clean(workingDirectory), nil
+}
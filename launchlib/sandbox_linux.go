@@ -0,0 +1,52 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"path/filepath"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// unshareMounts moves the calling thread into a private mount namespace, with the root filesystem read-only except for
+// writablePaths if config requires it.
+func unshareMounts(config MountNamespaceConfig, writablePaths []string) error {
+	if err := syscall.Unshare(syscall.CLONE_NEWNS); err != nil {
+		return errors.Wrap(err, "failed to create a mount namespace, which requires root")
+	}
+	// Mounts are otherwise shared with the namespace of the launcher.
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return errors.Wrap(err, "failed to make mounts private")
+	}
+	if !config.ReadOnlyRoot {
+		return nil
+	}
+
+	// Bind mounts of the writable paths are separate mounts, which keep their own flags when the root is remounted.
+	workingDir := getWorkingDir()
+	for _, path := range writablePaths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(workingDir, path)
+		}
+		if err := syscall.Mount(path, path, "", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+			return errors.Wrapf(err, "failed to keep %s writable", path)
+		}
+	}
+	if err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_BIND|syscall.MS_RDONLY, ""); err != nil {
+		return errors.Wrap(err, "failed to make the root filesystem read-only")
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChrootCmd(t *testing.T) {
+	cmd := &exec.Cmd{
+		Path: "/opt/service/jdk/bin/java",
+		Args: []string{"/opt/service/jdk/bin/java", "-classpath", "/opt/service/lib/a.jar:/opt/service/lib/b.jar",
+			"-javaagent:/opt/service/lib/agent.jar=opt", "/opt/services", "Main"},
+		Dir: "/opt/service/var",
+	}
+	require.NoError(t, chrootCmd(cmd, "/opt/service"))
+	assert.Equal(t, "/jdk/bin/java", cmd.Path)
+	assert.Equal(t, []string{"/jdk/bin/java", "-classpath", "/lib/a.jar:/lib/b.jar", "-javaagent:/lib/agent.jar=opt",
+		"/opt/services", "Main"}, cmd.Args)
+	assert.Equal(t, "/var", cmd.Dir)
+	assert.Equal(t, "/opt/service", cmd.SysProcAttr.Chroot)
+
+	err := chrootCmd(&exec.Cmd{Path: "/usr/bin/java"}, "/opt/service")
+	assert.EqualError(t, err, "executable /usr/bin/java is not within chroot /opt/service")
+}
+
+func TestPathInChroot(t *testing.T) {
+	for i, currCase := range []struct {
+		path string
+		want string
+		ok   bool
+	}{
+		{path: "/opt/service", want: "/", ok: true},
+		{path: "/opt/service/bin/run", want: "/bin/run", ok: true},
+		{path: "/opt/service-other/bin/run"},
+		{path: "/opt"},
+	} {
+		path, ok := pathInChroot("/opt/service", currCase.path)
+		assert.Equal(t, currCase.ok, ok, "Case %d", i)
+		assert.Equal(t, currCase.want, path, "Case %d", i)
+	}
+}
+
+func TestResolveWorkingDir(t *testing.T) {
+	launcherDir := getWorkingDir()
+	dir, err := resolveWorkingDir("")
+	require.NoError(t, err)
+	assert.Equal(t, launcherDir, dir)
+
+	dir, err = resolveWorkingDir("..")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Dir(launcherDir), dir)
+
+	_, err = resolveWorkingDir("/nonexistent")
+	assert.EqualError(t, err, "workingDirectory /nonexistent is not a directory")
+}
+
+func TestStartIsolatedWithReadOnlyRoot(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Skip("mount namespaces require root on linux")
+	}
+	writable, err := ioutil.TempDir("", "writable")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(writable))
+	}()
+	readOnly, err := ioutil.TempDir("", "read-only")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(readOnly))
+	}()
+
+	isolation := StaticLauncherConfig{
+		Dirs:           []DirConfig{{Path: writable}},
+		MountNamespace: &MountNamespaceConfig{ReadOnlyRoot: true},
+	}.Isolation()
+	cmd := exec.Command("touch", filepath.Join(writable, "file"))
+	if err := StartIsolated(cmd, isolation); err != nil {
+		t.Skipf("mount namespaces are not available: %v", err)
+	}
+	require.NoError(t, cmd.Wait())
+
+	cmd = exec.Command("touch", filepath.Join(readOnly, "file"))
+	require.NoError(t, StartIsolated(cmd, isolation))
+	assert.Error(t, cmd.Wait())
+	_, err = os.Stat(filepath.Join(readOnly, "file"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ServiceRootEnvVar is the directory the launcher resolves relative paths against, rather than the directory it is
+// invoked from.
+const ServiceRootEnvVar = "GO_JAVA_LAUNCHER_SERVICE_ROOT"
+
+// ExpandHome replaces a leading ~ of the given path with the home directory of the current user, as a shell would for
+// a path that is not given on the command line. Paths that refer to the home directory of another user, as in ~user,
+// are returned unchanged.
+func ExpandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path, nil
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		currUser, err := user.Current()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to determine the home directory to expand '%s'", path)
+		}
+		home = currUser.HomeDir
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// ChdirServiceRoot changes the working directory to the given service root, after expanding ~, so that relative paths
+// of the configuration, the pid and output files and the working directory of processes are resolved against it. The
+// working directory is kept if root is empty.
+func ChdirServiceRoot(root string) error {
+	if root == "" {
+		return nil
+	}
+	expanded, err := ExpandHome(root)
+	if err != nil {
+		return err
+	}
+	if err := os.Chdir(expanded); err != nil {
+		return errors.Wrapf(err, "failed to change to the service root '%s'", root)
+	}
+	tracef("resolving relative paths against the service root %s", absPath(expanded))
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandHome(t *testing.T) {
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	require.NoError(t, os.Setenv("HOME", "/home/service"))
+
+	for i, currCase := range []struct {
+		path string
+		want string
+	}{
+		{"~", "/home/service"},
+		{"~/var/conf/launcher-custom.yml", "/home/service/var/conf/launcher-custom.yml"},
+		{"~other/launcher-custom.yml", "~other/launcher-custom.yml"},
+		{"var/~/launcher-custom.yml", "var/~/launcher-custom.yml"},
+		{"/etc/launcher-custom.yml", "/etc/launcher-custom.yml"},
+		{"", ""},
+	} {
+		got, err := ExpandHome(currCase.path)
+		require.NoError(t, err, "Case %d", i)
+		assert.Equal(t, currCase.want, got, "Case %d", i)
+	}
+}
+
+func TestChdirServiceRoot(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+	root, err := ioutil.TempDir("", "service-root")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(root))
+	}()
+	root, err = filepath.EvalSymlinks(root)
+	require.NoError(t, err)
+
+	require.NoError(t, ChdirServiceRoot(""))
+	assert.Equal(t, wd, getWorkingDir())
+
+	require.NoError(t, ChdirServiceRoot(root))
+	assert.Equal(t, root, getWorkingDir())
+
+	err = ChdirServiceRoot(filepath.Join(root, "missing"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to change to the service root")
+	assert.Equal(t, root, getWorkingDir())
+}
@@ -0,0 +1,53 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultReloadSignal is the signal sent by 'go-init reload' unless the process configures a reloadSignal.
+const DefaultReloadSignal = syscall.SIGHUP
+
+// ParseSignal parses the name of a signal, e.g. SIGHUP, which may omit the SIG prefix and is case-insensitive.
+func ParseSignal(name string) (syscall.Signal, error) {
+	normalized := strings.ToUpper(name)
+	if !strings.HasPrefix(normalized, "SIG") {
+		normalized = "SIG" + normalized
+	}
+	if signal, ok := signalNumbers[normalized]; ok {
+		return signal, nil
+	}
+	supported := make([]string, 0, len(signalNumbers))
+	for supportedName := range signalNumbers {
+		supported = append(supported, supportedName)
+	}
+	sort.Strings(supported)
+	return 0, errors.Errorf("unknown signal '%s', expected one of %v", name, supported)
+}
+
+// SignalName returns the name of the signal, such as SIGKILL, or its description if it has no known name.
+func SignalName(signal syscall.Signal) string {
+	for name, number := range signalNumbers {
+		if number == signal {
+			return name
+		}
+	}
+	return signal.String()
+}
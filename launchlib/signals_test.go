@@ -0,0 +1,53 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package launchlib
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSignal(t *testing.T) {
+	for i, currCase := range []struct {
+		name   string
+		signal syscall.Signal
+		err    string
+	}{
+		{name: "SIGHUP", signal: syscall.SIGHUP},
+		{name: "USR2", signal: syscall.SIGUSR2},
+		{name: "sigterm", signal: syscall.SIGTERM},
+		{name: "SIGFOO", err: "unknown signal 'SIGFOO', expected one of"},
+		{name: "", err: "unknown signal '', expected one of"},
+	} {
+		signal, err := ParseSignal(currCase.name)
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+			assert.Equal(t, currCase.signal, signal, "Case %d", i)
+		} else {
+			assert.Error(t, err, "Case %d", i)
+			assert.Contains(t, err.Error(), currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestSignalName(t *testing.T) {
+	assert.Equal(t, "SIGKILL", SignalName(syscall.SIGKILL))
+	assert.Equal(t, "SIGHUP", SignalName(syscall.SIGHUP))
+}
@@ -0,0 +1,58 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultStartupPatternTimeout is how long 'go-init start' waits for the output of a process to match its
+// StartupPattern if it does not set a StartupPatternTimeout.
+const DefaultStartupPatternTimeout = time.Minute
+
+// StartupRegexp returns the compiled StartupPattern of the config, or nil if it is unset.
+func (config StaticLauncherConfig) StartupRegexp() *regexp.Regexp {
+	if config.StartupPattern == "" {
+		return nil
+	}
+	// The pattern was validated along with the config.
+	compiled, err := regexp.Compile(config.StartupPattern)
+	if err != nil {
+		return nil
+	}
+	return compiled
+}
+
+// validateStartupPattern validates that the startupPattern is a regular expression, which is matched against the
+// output file of the process so requires that its output is written to files.
+func validateStartupPattern(config *StaticLauncherConfig) error {
+	if config.StartupPatternTimeout < 0 {
+		return errors.New("startupPatternTimeout must not be negative")
+	}
+	if config.StartupPattern == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(config.StartupPattern); err != nil {
+		return errors.Wrap(err, "invalid startupPattern")
+	}
+	if config.OutputMode != "" && config.OutputMode != OutputModeFile {
+		return errors.Errorf("startupPattern can only be set with outputMode %s, as it is matched against the "+
+			"output file of the process", OutputModeFile)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StopConfig configures how 'go-init stop' asks the process to stop gracefully before signalling it, for services that
+// drain their connections when requested by an admin endpoint but not when sent SIGTERM.
+type StopConfig struct {
+	// URL is POSTed to, after which the process is given the remainder of Timeout to exit before it is sent SIGTERM.
+	URL string `yaml:"url"`
+	// Timeout bounds both the request and the wait for the process to exit.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+const (
+	// StopOrderParallel stops every process at once.
+	StopOrderParallel = "parallel"
+	// StopOrderReverseDependencies stops the processes in the reverse of the order of their process dependencies, so
+	// that a process is only stopped once the processes that depend on it have stopped. The processes that do not
+	// depend on each other are stopped in parallel.
+	StopOrderReverseDependencies = "reverseDependencies"
+)
+
+var DefaultStopConfig = StopConfig{
+	Timeout: time.Minute,
+}
+
+func (config StopConfig) WithDefaults() StopConfig {
+	if config.Timeout == 0 {
+		config.Timeout = DefaultStopConfig.Timeout
+	}
+	return config
+}
+
+func (config *StopConfig) validate() error {
+	if !strings.HasPrefix(config.URL, "http://") && !strings.HasPrefix(config.URL, "https://") {
+		return errors.Errorf("url must be an http or https URL, found '%s'", config.URL)
+	}
+	if config.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+var templatePattern = regexp.MustCompile(regexp.QuoteMeta(TemplateDelimsOpen) + `([A-Z_]+)` +
+	regexp.QuoteMeta(TemplateDelimsClose))
+
+// templateVariables are the {{NAME}} variables expanded in config values, each evaluated only if referenced.
+var templateVariables = map[string]func() (string, error){
+	"CWD": func() (string, error) {
+		return getWorkingDir(), nil
+	},
+	"SERVICE_HOME": getServiceHome,
+	"PID_DIR": func() (string, error) {
+		serviceHome, err := getServiceHome()
+		return path.Join(serviceHome, "var", "run"), err
+	},
+	"HOSTNAME": os.Hostname,
+	"IP":       getIP,
+}
+
+// expandTemplates replaces each reference to one of the templateVariables in value with its value. References to
+// unknown variables are left as they are.
+func expandTemplates(value string) (string, error) {
+	var err error
+	expanded := templatePattern.ReplaceAllStringFunc(value, func(reference string) string {
+		name := templatePattern.FindStringSubmatch(reference)[1]
+		variable, ok := templateVariables[name]
+		if !ok {
+			return reference
+		}
+		variableValue, variableErr := variable()
+		if variableErr != nil && err == nil {
+			err = errors.Wrapf(variableErr, "failed to determine value of %s", reference)
+		}
+		return variableValue
+	})
+	return expanded, err
+}
+
+// getServiceHome returns the root of the distribution containing the running executable, which is the parent of the
+// closest enclosing 'service' directory as in service/bin/<os>-<arch>/go-init. Falls back to the working directory if
+// the executable is not inside such a directory.
+func getServiceHome() (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return "", err
+	}
+	for dir := filepath.Dir(executable); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if filepath.Base(dir) == "service" {
+			return filepath.Dir(dir), nil
+		}
+	}
+	return getWorkingDir(), nil
+}
+
+// getIP returns the first IPv4 address of the host that is not a loopback address, or the first such IPv6 address if
+// it has no IPv4 address.
+func getIP() (string, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return "", err
+	}
+	var ipv6 string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipv4 := ipNet.IP.To4(); ipv4 != nil {
+			return ipv4.String(), nil
+		}
+		if ipv6 == "" {
+			ipv6 = ipNet.IP.String()
+		}
+	}
+	if ipv6 == "" {
+		return "", errors.New("host has no non-loopback IP address")
+	}
+	return ipv6, nil
+}
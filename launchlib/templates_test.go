@@ -0,0 +1,59 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandTemplates(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	// The test binary is not inside a service directory, so the service home is the working directory.
+	cwd := getWorkingDir()
+
+	for value, want := range map[string]string{
+		"{{CWD}}/var/data":            cwd + "/var/data",
+		"{{SERVICE_HOME}}/var/conf":   cwd + "/var/conf",
+		"-Dpidfile={{PID_DIR}}/a.pid": "-Dpidfile=" + path.Join(cwd, "var", "run") + "/a.pid",
+		"-Dhost={{HOSTNAME}}":         "-Dhost=" + hostname,
+		"{{UNKNOWN}}":                 "{{UNKNOWN}}",
+		"{{ CWD }}":                   "{{ CWD }}",
+	} {
+		got, err := expandTemplates(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, got, value)
+	}
+}
+
+func TestStaticLauncherConfigInterpolated_Templates(t *testing.T) {
+	config, err := StaticLauncherConfig{
+		Executable: "{{SERVICE_HOME}}/service/bin/envoy",
+		Args:       []string{"--base-dir={{CWD}}"},
+		Env:        map[string]string{"DIR": "{{CWD}}"},
+	}.interpolated()
+	require.NoError(t, err)
+
+	cwd := getWorkingDir()
+	assert.Equal(t, cwd+"/service/bin/envoy", config.Executable)
+	assert.Equal(t, []string{"--base-dir=" + cwd}, config.Args)
+	// template variables in env are replaced once it is merged with the custom env
+	assert.Equal(t, map[string]string{"DIR": "{{CWD}}"}, config.Env)
+}
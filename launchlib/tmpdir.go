@@ -0,0 +1,138 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TmpDirConfig configures the temporary directory of the process, which is created before it is launched and used by
+// it in place of the shared /tmp, so that the files it leaks do not accumulate there.
+type TmpDirConfig struct {
+	// Dir is the temporary directory, relative to the working directory unless absolute.
+	Dir string `yaml:"dir"`
+	// CleanOnStart removes the contents of Dir left by previous runs each time the process is launched.
+	CleanOnStart bool `yaml:"cleanOnStart"`
+}
+
+var DefaultTmpDirConfig = TmpDirConfig{
+	Dir: "var/data/tmp",
+}
+
+const tmpDirJvmOptPrefix = "-Djava.io.tmpdir="
+
+// sharedTmpDirs are the system-wide temporary directories, whose contents belong to other processes so must never be
+// cleaned.
+var sharedTmpDirs = []string{"/", "/tmp", "/var/tmp", "/dev/shm"}
+
+func (config TmpDirConfig) WithDefaults() TmpDirConfig {
+	if config.Dir == "" {
+		config.Dir = DefaultTmpDirConfig.Dir
+	}
+	return config
+}
+
+func (config *TmpDirConfig) validate() error {
+	if !config.CleanOnStart {
+		return nil
+	}
+	dir := filepath.Clean(config.WithDefaults().Dir)
+	for _, shared := range sharedTmpDirs {
+		if dir == shared {
+			return errors.Errorf("cleanOnStart must not be set for the shared directory %s", dir)
+		}
+	}
+	return nil
+}
+
+func (config TmpDirConfig) dir(workingDir string) string {
+	if filepath.IsAbs(config.Dir) {
+		return filepath.Clean(config.Dir)
+	}
+	return filepath.Join(workingDir, config.Dir)
+}
+
+// getTmpDirJvmOpts returns the option that makes the JVM create its temporary files in the temporary directory, unless
+// the jvmOpts already set java.io.tmpdir.
+func getTmpDirJvmOpts(config *TmpDirConfig, workingDir string, jvmOpts []string) []string {
+	if config == nil {
+		return nil
+	}
+	for _, opt := range jvmOpts {
+		if strings.HasPrefix(opt, tmpDirJvmOptPrefix) {
+			return nil
+		}
+	}
+	return []string{tmpDirJvmOptPrefix + config.WithDefaults().dir(workingDir)}
+}
+
+// getTmpDirEnv returns the TMPDIR environment variable that points the process, and the tools it runs, to the
+// temporary directory.
+func getTmpDirEnv(config *TmpDirConfig, workingDir string) map[string]string {
+	if config == nil {
+		return nil
+	}
+	return map[string]string{"TMPDIR": config.WithDefaults().dir(workingDir)}
+}
+
+// PrepareTmpDir creates the temporary directory of the config, owned by the user the command runs as, and removes its
+// contents if CleanOnStart is set. Does nothing if config is nil.
+func PrepareTmpDir(config *TmpDirConfig, workingDirectory string, cmd *exec.Cmd, stdout io.Writer) error {
+	if config == nil {
+		return nil
+	}
+	tmpDir := config.WithDefaults()
+	workingDir, err := resolveWorkingDir(workingDirectory)
+	if err != nil {
+		return err
+	}
+	dir := tmpDir.dir(workingDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "failed to create temporary directory %s", dir)
+	}
+	if err := ChownDirs([]DirConfig{{Path: dir}}, cmd); err != nil {
+		return err
+	}
+	if !tmpDir.CleanOnStart {
+		return nil
+	}
+	return cleanTmpDir(dir, stdout)
+}
+
+// cleanTmpDir removes everything in dir, without following symbolic links out of it.
+func cleanTmpDir(dir string, stdout io.Writer) error {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list temporary directory %s", dir)
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file.Name())
+		if err := os.RemoveAll(path); err != nil {
+			return errors.Wrapf(err, "failed to remove temporary file %s", path)
+		}
+	}
+	if len(files) > 0 {
+		fmt.Fprintf(stdout, "Removed %d stale temporary files from %s\n", len(files), dir)
+	}
+	return nil
+}
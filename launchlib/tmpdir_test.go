@@ -0,0 +1,91 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTmpDirJvmOpts(t *testing.T) {
+	for i, currCase := range []struct {
+		config  *TmpDirConfig
+		jvmOpts []string
+		want    []string
+	}{
+		{config: nil, want: nil},
+		{config: &TmpDirConfig{}, want: []string{"-Djava.io.tmpdir=/opt/service/var/data/tmp"}},
+		{config: &TmpDirConfig{Dir: "/data/tmp/"}, want: []string{"-Djava.io.tmpdir=/data/tmp"}},
+		{config: &TmpDirConfig{}, jvmOpts: []string{"-Xmx1g", "-Djava.io.tmpdir=/tmp"}, want: nil},
+	} {
+		assert.Equal(t, currCase.want, getTmpDirJvmOpts(currCase.config, "/opt/service", currCase.jvmOpts),
+			"Case %d", i)
+	}
+}
+
+func TestValidateTmpDirConfig(t *testing.T) {
+	for i, currCase := range []struct {
+		config TmpDirConfig
+		err    string
+	}{
+		{config: TmpDirConfig{CleanOnStart: true}},
+		{config: TmpDirConfig{Dir: "/tmp"}},
+		{config: TmpDirConfig{Dir: "/tmp/", CleanOnStart: true},
+			err: "cleanOnStart must not be set for the shared directory /tmp"},
+	} {
+		err := currCase.config.validate()
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			assert.EqualError(t, err, currCase.err, "Case %d", i)
+		}
+	}
+}
+
+func TestPrepareTmpDir(t *testing.T) {
+	for i, currCase := range []struct {
+		cleanOnStart bool
+		want         []string
+	}{
+		{cleanOnStart: false, want: []string{"jna-1234", "stale.tmp"}},
+		{cleanOnStart: true, want: nil},
+	} {
+		workingDir, err := ioutil.TempDir("", "launchlib-tmpdir")
+		require.NoError(t, err, "Case %d", i)
+		defer func() {
+			_ = os.RemoveAll(workingDir)
+		}()
+		dir := filepath.Join(workingDir, "var", "data", "tmp")
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "jna-1234"), 0700), "Case %d", i)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "jna-1234", "lib.so"), nil, 0644), "Case %d", i)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "stale.tmp"), nil, 0644), "Case %d", i)
+
+		config := &TmpDirConfig{CleanOnStart: currCase.cleanOnStart}
+		require.NoError(t, PrepareTmpDir(config, workingDir, exec.Command("true"), ioutil.Discard), "Case %d", i)
+		files, err := ioutil.ReadDir(dir)
+		require.NoError(t, err, "Case %d", i)
+		var names []string
+		for _, file := range files {
+			names = append(names, file.Name())
+		}
+		assert.Equal(t, currCase.want, names, "Case %d", i)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DebugEnvVar enables tracing to stderr when set to 1, as the --verbose flag of the launcher does.
+const DebugEnvVar = "GO_JAVA_LAUNCHER_DEBUG"
+
+// TraceOutput is where the launcher traces how it resolves, merges and expands the configuration and the commands it
+// compiles from it, or nil to not trace.
+var TraceOutput io.Writer
+
+// DebugFromEnv returns whether tracing is enabled by DebugEnvVar.
+func DebugFromEnv() bool {
+	return os.Getenv(DebugEnvVar) == "1"
+}
+
+// absPath returns the absolute path of path for tracing, or path itself if it cannot be determined.
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+func tracef(format string, args ...interface{}) {
+	if TraceOutput == nil {
+		return
+	}
+	fmt.Fprintf(TraceOutput, "[debug] "+format+"\n", args...)
+}
+
+// traceCmd traces the parameters the command is executed with, with sensitive values redacted.
+func traceCmd(cmd *exec.Cmd, redactor Redactor) {
+	if TraceOutput == nil {
+		return
+	}
+	workingDir := cmd.Dir
+	if workingDir == "" {
+		workingDir = getWorkingDir()
+	}
+	env := redactor.redactArgs(cmd.Env)
+	sort.Strings(env)
+	tracef("Executing %s with arguments %s in directory %s", cmd.Path, quoteArgs(redactor.redactArgs(cmd.Args)),
+		workingDir)
+	tracef("Executing with environment: %s", strings.Join(env, " "))
+}
@@ -0,0 +1,68 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "trace")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	writeCustomConfigFiles(t, dir, map[string]string{
+		"launcher-custom.yml": `
+configType: executable
+configVersion: 1
+env:
+  PASSWORD: secret
+  REGION: ${LAUNCHER_TEST_UNSET:-eu}
+`,
+	})
+	trace := &bytes.Buffer{}
+	TraceOutput = trace
+	defer func() {
+		TraceOutput = nil
+	}()
+
+	customConfig, err := getCustomConfigFromFile(filepath.Join(dir, "launcher-custom.yml"), ioutil.Discard)
+	require.NoError(t, err)
+	cmd, err := compileCmdFromConfig(&StaticLauncherConfig{
+		TypedConfig: TypedConfig{Type: "executable"},
+		Executable:  "/bin/ls",
+		Args:        []string{"-l"},
+		Env:         map[string]string{"REGION": "us"},
+		CleanEnv:    true,
+	}, &customConfig.CustomLauncherConfig, NewSimpleWriterLogger(ioutil.Discard).PrimaryLogger)
+	require.NoError(t, err)
+
+	assert.Equal(t, "[debug] Reading custom config file "+filepath.Join(dir, "launcher-custom.yml")+"\n"+
+		"[debug] Custom config file "+filepath.Join(dir, "launcher-custom.yml")+" sets env [PASSWORD REGION] for "+
+		"the primary process, overriding earlier files\n"+
+		"[debug] Expanded ${LAUNCHER_TEST_UNSET:-eu} to its default, as LAUNCHER_TEST_UNSET is not set\n"+
+		"[debug] Custom env REGION overrides the static env\n"+
+		"[debug] Executing /bin/ls with arguments /bin/ls -l in directory "+getWorkingDir()+"\n"+
+		"[debug] Executing with environment: PASSWORD=<redacted> REGION=eu\n", trace.String())
+	assert.Equal(t, "/bin/ls", cmd.Path)
+}
@@ -0,0 +1,35 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package launchlib
+
+import (
+	"os"
+	"syscall"
+)
+
+// SetUmask sets the umask of the launcher, which is inherited by the processes it starts or execs, returning a function
+// that restores the previous umask. Does nothing if umask is nil.
+func SetUmask(umask *os.FileMode) func() {
+	if umask == nil {
+		return func() {}
+	}
+	previous := syscall.Umask(int(*umask))
+	return func() {
+		syscall.Umask(previous)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package launchlib
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetUmask(t *testing.T) {
+	original := syscall.Umask(0022)
+	defer syscall.Umask(original)
+
+	umask := os.FileMode(0077)
+	restore := SetUmask(&umask)
+	assert.Equal(t, 0077, syscall.Umask(0077))
+	restore()
+	assert.Equal(t, 0022, syscall.Umask(0022))
+
+	SetUmask(nil)()
+	assert.Equal(t, 0022, syscall.Umask(0022))
+}
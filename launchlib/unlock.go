@@ -0,0 +1,85 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	unlockExperimentalOpt = "-XX:+UnlockExperimentalVMOptions"
+	unlockDiagnosticOpt   = "-XX:+UnlockDiagnosticVMOptions"
+)
+
+// lockedJvmOpts are the names of the -XX options that the JVM refuses to start with unless they follow the option that
+// unlocks them, by the option that does. Some, such as UseZGC, only require it in the java versions in which they are
+// experimental, though the option unlocking them is accepted by every version.
+var lockedJvmOpts = map[string]string{
+	"UseEpsilonGC":                    unlockExperimentalOpt,
+	"UseZGC":                          unlockExperimentalOpt,
+	"UseShenandoahGC":                 unlockExperimentalOpt,
+	"UseCGroupMemoryLimitForHeap":     unlockExperimentalOpt,
+	"EnableJVMCI":                     unlockExperimentalOpt,
+	"UseJVMCICompiler":                unlockExperimentalOpt,
+	"UseFastUnorderedTimeStamps":      unlockExperimentalOpt,
+	"G1NewSizePercent":                unlockExperimentalOpt,
+	"G1MaxNewSizePercent":             unlockExperimentalOpt,
+	"G1MixedGCLiveThresholdPercent":   unlockExperimentalOpt,
+	"G1OldCSetRegionThresholdPercent": unlockExperimentalOpt,
+	"DebugNonSafepoints":              unlockDiagnosticOpt,
+	"PrintInlining":                   unlockDiagnosticOpt,
+	"PrintIntrinsics":                 unlockDiagnosticOpt,
+	"PrintAssembly":                   unlockDiagnosticOpt,
+	"PrintNMethods":                   unlockDiagnosticOpt,
+	"LogCompilation":                  unlockDiagnosticOpt,
+	"LogVMOutput":                     unlockDiagnosticOpt,
+	"LogFile":                         unlockDiagnosticOpt,
+	"GuaranteedSafepointInterval":     unlockDiagnosticOpt,
+	"ShowHiddenFrames":                unlockDiagnosticOpt,
+	"VerifyBeforeGC":                  unlockDiagnosticOpt,
+	"VerifyAfterGC":                   unlockDiagnosticOpt,
+}
+
+// addUnlockJvmOpts adds -XX:+UnlockExperimentalVMOptions or -XX:+UnlockDiagnosticVMOptions ahead of the first of the
+// jvmOpts that requires it, unless it already precedes that option. Returns the options along with a warning for each
+// option that is added.
+func addUnlockJvmOpts(jvmOpts []string) ([]string, []string) {
+	unlocked := make(map[string]bool)
+	withUnlocks := make([]string, 0, len(jvmOpts))
+	var added []string
+	for _, opt := range jvmOpts {
+		if unlock, ok := lockedJvmOpts[xxOptName(opt)]; ok && !unlocked[unlock] {
+			withUnlocks = append(withUnlocks, unlock)
+			unlocked[unlock] = true
+			added = append(added, fmt.Sprintf("%s requires %s, which is added ahead of it", opt, unlock))
+		}
+		if opt == unlockExperimentalOpt || opt == unlockDiagnosticOpt {
+			unlocked[opt] = true
+		}
+		withUnlocks = append(withUnlocks, opt)
+	}
+	return withUnlocks, added
+}
+
+// xxOptName returns the name of the -XX option, e.g. UseZGC for -XX:+UseZGC and LogFile for -XX:LogFile=jvm.log, or
+// the empty string if the option is not a -XX option.
+func xxOptName(opt string) string {
+	if !strings.HasPrefix(opt, "-XX:") {
+		return ""
+	}
+	name := strings.TrimLeft(opt[len("-XX:"):], "+-")
+	return strings.SplitN(name, "=", 2)[0]
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddUnlockJvmOpts(t *testing.T) {
+	for i, currCase := range []struct {
+		jvmOpts   []string
+		want      []string
+		wantAdded []string
+	}{
+		{
+			jvmOpts: []string{"-Xmx1g", "-XX:+UseEpsilonGC"},
+			want:    []string{"-Xmx1g", "-XX:+UnlockExperimentalVMOptions", "-XX:+UseEpsilonGC"},
+			wantAdded: []string{
+				"-XX:+UseEpsilonGC requires -XX:+UnlockExperimentalVMOptions, which is added ahead of it",
+			},
+		},
+		{
+			jvmOpts: []string{"-XX:G1NewSizePercent=20", "-XX:G1MaxNewSizePercent=60", "-XX:+DebugNonSafepoints"},
+			want: []string{"-XX:+UnlockExperimentalVMOptions", "-XX:G1NewSizePercent=20", "-XX:G1MaxNewSizePercent=60",
+				"-XX:+UnlockDiagnosticVMOptions", "-XX:+DebugNonSafepoints"},
+			wantAdded: []string{
+				"-XX:G1NewSizePercent=20 requires -XX:+UnlockExperimentalVMOptions, which is added ahead of it",
+				"-XX:+DebugNonSafepoints requires -XX:+UnlockDiagnosticVMOptions, which is added ahead of it",
+			},
+		},
+		{
+			jvmOpts: []string{"-XX:+UnlockDiagnosticVMOptions", "-XX:+PrintInlining"},
+			want:    []string{"-XX:+UnlockDiagnosticVMOptions", "-XX:+PrintInlining"},
+		},
+		{
+			// The unlock option of the custom jvmOpts, which overrides that of the static jvmOpts, follows the option.
+			jvmOpts: []string{"-XX:+UseZGC", "-XX:+UnlockExperimentalVMOptions"},
+			want:    []string{"-XX:+UnlockExperimentalVMOptions", "-XX:+UseZGC", "-XX:+UnlockExperimentalVMOptions"},
+			wantAdded: []string{
+				"-XX:+UseZGC requires -XX:+UnlockExperimentalVMOptions, which is added ahead of it",
+			},
+		},
+		{
+			jvmOpts: []string{"-XX:+UseG1GC", "-Dlog.file=LogFile"},
+			want:    []string{"-XX:+UseG1GC", "-Dlog.file=LogFile"},
+		},
+	} {
+		got, added := addUnlockJvmOpts(currCase.jvmOpts)
+		assert.Equal(t, currCase.want, got, "Case %d", i)
+		assert.Equal(t, currCase.wantAdded, added, "Case %d", i)
+	}
+}
@@ -0,0 +1,394 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ValidateConfigFiles returns every problem found with the given static and custom config files: keys that are not part
+// of the configuration format, invalid values, invalid env files, and java installations, classpath entries, module
+// path entries, jars and agents or executables that do not exist. Paths are resolved relative to the working
+// directory, as when launching. A missing custom config file is not a problem, as it is optional.
+func ValidateConfigFiles(staticConfigFile, customConfigFile string) []error {
+	var problems []error
+	staticData, err := ioutil.ReadFile(staticConfigFile)
+	if err != nil {
+		return []error{errors.Wrap(err, "Failed to read static config file: "+staticConfigFile)}
+	}
+	problems = append(problems, unknownKeyProblems(staticConfigFile, staticData, staticConfigMigrations,
+		reflect.TypeOf(PrimaryStaticLauncherConfig{}))...)
+	// Errors loading the custom config are reported when the configs are parsed below.
+	if _, customFiles, err := loadCustomConfigOverlays(customConfigFile); err == nil {
+		for _, customFile := range customFiles {
+			customData, err := ioutil.ReadFile(customFile)
+			if err != nil {
+				problems = append(problems, errors.Wrap(err, "Failed to read custom config file: "+customFile))
+				continue
+			}
+			problems = append(problems, unknownKeyProblems(customFile, customData, customConfigMigrations,
+				reflect.TypeOf(PrimaryCustomLauncherConfig{}))...)
+		}
+	}
+
+	staticConfig, _, err := GetConfigsFromFiles(staticConfigFile, customConfigFile, ioutil.Discard)
+	if err != nil {
+		return append(problems, err)
+	}
+
+	workingDir := getWorkingDir()
+	problems = append(problems, launchPathProblems(staticConfig.ServiceName, staticConfig.StaticLauncherConfig,
+		workingDir)...)
+	names := make([]string, 0, len(staticConfig.SubProcesses))
+	for name := range staticConfig.SubProcesses {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		problems = append(problems, launchPathProblems(name, staticConfig.SubProcesses[name], workingDir)...)
+	}
+	return problems
+}
+
+// launchPathProblems returns a problem for each of the paths used to launch the named process that does not exist.
+func launchPathProblems(name string, config StaticLauncherConfig, workingDir string) []error {
+	var problems []error
+	addProblem := func(err error) {
+		if err != nil {
+			problems = append(problems, errors.Wrapf(err, "process '%s'", name))
+		}
+	}
+
+	config, err := config.interpolated()
+	if err != nil {
+		return append(problems, errors.Wrapf(err, "process '%s'", name))
+	}
+	_, err = loadEnvFiles(workingDir, config.EnvFiles)
+	addProblem(err)
+	_, err = loadEnvFromFiles(workingDir, config.EnvFromFiles)
+	addProblem(err)
+	if config.Type != "java" {
+		_, err := verifyPathIsSafeForExec(config.Executable)
+		addProblem(err)
+		return problems
+	}
+	if config.NativeImage != "" {
+		nativeImage := config.NativeImage
+		if !filepath.IsAbs(nativeImage) {
+			nativeImage = filepath.Join(workingDir, nativeImage)
+		}
+		_, err := verifyPathIsSafeForExec(nativeImage)
+		addProblem(errors.Wrap(err, "invalid nativeImage"))
+		return problems
+	}
+
+	if javaHome, err := resolveJavaHome(config.JavaConfig, workingDir); err != nil {
+		addProblem(err)
+	} else {
+		_, err := verifyPathIsSafeForExec(launchExecutable(config.JavaConfig, javaHome))
+		addProblem(errors.Wrapf(err, "invalid java installation %s", javaHome))
+	}
+	_, err = resolveClasspathEntries(absolutizeClasspathEntries(workingDir, config.Classpath))
+	addProblem(err)
+	_, err = getModuleArgs(config.JavaConfig, workingDir)
+	addProblem(err)
+	_, err = getJar(config.JarPath, workingDir)
+	addProblem(err)
+	_, err = getAgentArgs(config.Agents, workingDir)
+	addProblem(err)
+	return problems
+}
+
+// unknownKeyProblems returns a problem for each key of the given config that does not correspond to a field of
+// configType once the config is migrated with migrations, with the line it is on and the key it may be a misspelling
+// of.
+func unknownKeyProblems(file string, data []byte, migrations map[int]configMigration,
+	configType reflect.Type) []error {
+	var problems []error
+	for _, key := range findUnknownKeys(data, migrations, configType) {
+		if key.line > 0 {
+			problems = append(problems, errors.Errorf("%s:%d: %s", file, key.line, key))
+		} else {
+			problems = append(problems, errors.Errorf("%s: %s", file, key))
+		}
+	}
+	return problems
+}
+
+// unknownKeysError returns an error listing the keys of the given config that do not correspond to a field of
+// configType once the config is migrated with migrations, or nil if there are none.
+func unknownKeysError(data []byte, migrations map[int]configMigration, configType reflect.Type) error {
+	keys := findUnknownKeys(data, migrations, configType)
+	if len(keys) == 0 {
+		return nil
+	}
+	descriptions := make([]string, len(keys))
+	for i, key := range keys {
+		descriptions[i] = key.String()
+		if key.line > 0 {
+			descriptions[i] = fmt.Sprintf("line %d: %s", key.line, key)
+		}
+	}
+	return errors.Errorf("found keys that are not part of the configuration format: %s",
+		strings.Join(descriptions, "; "))
+}
+
+// unknownKey is a key of a config that does not correspond to a field of its type.
+type unknownKey struct {
+	path string
+	// line is the line of the key in the config, or zero if it could not be located.
+	line int
+	// suggestion is the key of the field that the key may be a misspelling of, if any.
+	suggestion string
+}
+
+func (k unknownKey) String() string {
+	if k.suggestion == "" {
+		return fmt.Sprintf("unknown key '%s'", k.path)
+	}
+	return fmt.Sprintf("unknown key '%s', did you mean '%s'?", k.path, k.suggestion)
+}
+
+func findUnknownKeys(data []byte, migrations map[int]configMigration, configType reflect.Type) []unknownKey {
+	var document interface{}
+	if err := yaml.Unmarshal(currentConfig(data, migrations), &document); err != nil {
+		// Syntax errors are reported when the config is parsed.
+		return nil
+	}
+	// Migrations only change known keys, so unknown keys are located in the config as it was written.
+	lines := strings.Split(string(data), "\n")
+	keys := unknownKeys("", document, configType)
+	for i := range keys {
+		keys[i].line = keyLine(lines, strings.Split(keys[i].path, "."))
+	}
+	return keys
+}
+
+// unknownKeys returns the keys of value that do not correspond to a field of valueType, by their paths such as
+// subProcesses.sidecar.jvmOpt, descending into nested structs, maps and lists.
+func unknownKeys(prefix string, value interface{}, valueType reflect.Type) []unknownKey {
+	for valueType.Kind() == reflect.Ptr {
+		valueType = valueType.Elem()
+	}
+
+	var unknown []unknownKey
+	switch valueType.Kind() {
+	case reflect.Struct:
+		fields := yamlFields(valueType)
+		mapping, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil
+		}
+		for key, child := range mapping {
+			name := fmt.Sprint(key)
+			fieldType, ok := fields[name]
+			if !ok {
+				if !versionedJvmOptsKeyPattern.MatchString(name) {
+					unknown = append(unknown, unknownKey{path: prefix + name, suggestion: suggestKey(name, fields)})
+				}
+				continue
+			}
+			unknown = append(unknown, unknownKeys(prefix+name+".", child, fieldType)...)
+		}
+	case reflect.Map:
+		mapping, ok := value.(map[interface{}]interface{})
+		if !ok {
+			return nil
+		}
+		for key, child := range mapping {
+			unknown = append(unknown, unknownKeys(prefix+fmt.Sprint(key)+".", child, valueType.Elem())...)
+		}
+	case reflect.Slice:
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, child := range list {
+			unknown = append(unknown, unknownKeys(fmt.Sprintf("%s%d.", prefix, i), child, valueType.Elem())...)
+		}
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].path < unknown[j].path
+	})
+	return unknown
+}
+
+// suggestKey returns the one of the given fields that the key is most likely a misspelling of, ignoring case and
+// allowing for up to two edits, or the empty string if there is none.
+func suggestKey(key string, fields map[string]reflect.Type) string {
+	maxDistance := 2
+	if len(key) <= 3 {
+		maxDistance = 1
+	}
+	suggestion, suggestionDistance := "", maxDistance+1
+	for field := range fields {
+		distance := editDistance(strings.ToLower(key), strings.ToLower(field))
+		if distance < suggestionDistance || (distance == suggestionDistance && field < suggestion) {
+			suggestion, suggestionDistance = field, distance
+		}
+	}
+	return suggestion
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			current[j] = previous[j-1]
+			if a[i-1] != b[j-1] {
+				current[j]++
+			}
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// keyLine returns the line number of the key at the given path, such as subProcesses.sidecar.jvmOpt, in the lines of
+// a block style yaml document, or zero if it cannot be located, such as within a flow style mapping.
+func keyLine(lines []string, path []string) int {
+	// Each segment of the path is searched for in the block of its parent: the lines from pos, whose keys or sequence
+	// items are indented deeper than indent, or, for the sequences of a key, as deep.
+	pos, indent, parentIsKey := 0, -1, true
+	for _, segment := range path {
+		index, err := strconv.Atoi(segment)
+		isIndex := err == nil
+		childCol, items := -1, 0
+		found := false
+		for n := pos; n < len(lines) && !found; n++ {
+			dashes, col, key, ok := parseYAMLLine(lines[n])
+			if !ok {
+				continue
+			}
+			first := col
+			if len(dashes) > 0 {
+				first = dashes[0]
+			}
+			sameLine := n == pos && !parentIsKey
+			if sameLine {
+				// The first line of a sequence item holds its first key after the item's own dash.
+				dashes = dashes[1:]
+			} else if first < indent || (first == indent && !(parentIsKey && len(dashes) > 0)) {
+				return 0
+			}
+
+			if isIndex {
+				if len(dashes) == 0 {
+					continue
+				}
+				if childCol == -1 {
+					childCol = dashes[0]
+				}
+				if dashes[0] != childCol {
+					continue
+				}
+				if items == index {
+					pos, indent, parentIsKey, found = n, dashes[0], false, true
+				}
+				items++
+				continue
+			}
+			if key == "" || len(dashes) > 0 && !sameLine {
+				continue
+			}
+			if childCol == -1 {
+				childCol = col
+			}
+			if col == childCol && key == segment {
+				pos, indent, parentIsKey, found = n+1, col, true, true
+			}
+		}
+		if !found {
+			return 0
+		}
+	}
+	if parentIsKey {
+		return pos
+	}
+	return pos + 1
+}
+
+// yamlKeyPattern matches the key at the start of a line of a block style mapping.
+var yamlKeyPattern = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s#'"\-][^:#]*?|-[^\s:#][^:#]*?)\s*:(\s|$)`)
+
+// parseYAMLLine returns the columns of the sequence indicators at the start of a line of a block style yaml document,
+// and the column of the content that follows them along with its key, if it is a key of a mapping. Blank and comment
+// lines are not ok.
+func parseYAMLLine(line string) ([]int, int, string, bool) {
+	col := len(line) - len(strings.TrimLeft(line, " "))
+	rest := line[col:]
+	if rest == "" || rest[0] == '#' {
+		return nil, 0, "", false
+	}
+	var dashes []int
+	for rest == "-" || strings.HasPrefix(rest, "- ") {
+		dashes = append(dashes, col)
+		trimmed := strings.TrimLeft(rest[1:], " ")
+		col += len(rest) - len(trimmed)
+		rest = trimmed
+	}
+	match := yamlKeyPattern.FindStringSubmatch(rest)
+	if match == nil {
+		return dashes, col, "", true
+	}
+	return dashes, col, strings.Trim(match[1], `"'`), true
+}
+
+// yamlFields returns the types of the fields of the given struct type by their yaml keys, including those of inlined
+// structs.
+func yamlFields(structType reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		if len(tag) > 1 && tag[1] == "inline" {
+			for name, fieldType := range yamlFields(field.Type) {
+				fields[name] = fieldType
+			}
+			continue
+		}
+		name := tag[0]
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
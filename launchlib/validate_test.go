@@ -0,0 +1,188 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	staticFile := filepath.Join(dir, "launcher-static.yml")
+	customFile := filepath.Join(dir, "launcher-custom.yml")
+	require.NoError(t, ioutil.WriteFile(staticFile, []byte(`
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: Main
+javaHome: `+dir+`
+classpath:
+  - .
+jvmOpt:
+  - -Xmx1g
+jvmOpts-java-11+:
+  - -Xlog:gc
+healthCheck:
+  port: 8080
+  maxwait: 1m
+agents:
+  - jar: `+filepath.Join(dir, "agent.jar")+`
+    sha: abc
+`), 0644))
+	require.NoError(t, ioutil.WriteFile(customFile, []byte(`
+configType: java
+configVersion: 1
+jvmOpts:
+  - -Xmx2g
+envs:
+  KEY: value
+`), 0644))
+
+	var messages []string
+	for _, problem := range ValidateConfigFiles(staticFile, customFile) {
+		messages = append(messages, problem.Error())
+	}
+	assert.Equal(t, []string{
+		staticFile + ":18: unknown key 'agents.0.sha'",
+		staticFile + ":15: unknown key 'healthCheck.maxwait', did you mean 'maxWait'?",
+		staticFile + ":9: unknown key 'jvmOpt', did you mean 'jvmOpts'?",
+		customFile + ":6: unknown key 'envs', did you mean 'env'?",
+		"process 'primary': invalid java installation " + dir + ": stat " + filepath.Join(dir, "bin", "java") +
+			": no such file or directory",
+		"process 'primary': java agent " + filepath.Join(dir, "agent.jar") + " does not exist: stat " +
+			filepath.Join(dir, "agent.jar") + ": no such file or directory",
+	}, messages)
+}
+
+func TestValidateConfigFiles_Valid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "validate")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "bin", "java"), []byte("#!/bin/sh\n"), 0755))
+	staticFile := filepath.Join(dir, "launcher-static.yml")
+	require.NoError(t, ioutil.WriteFile(staticFile, []byte(`
+configType: java
+configVersion: 1
+serviceName: primary
+mainClass: Main
+javaHome: `+dir+`
+classpath:
+  - .
+`), 0644))
+
+	assert.Empty(t, ValidateConfigFiles(staticFile, filepath.Join(dir, "launcher-custom.yml")))
+}
+
+func TestKeyLine(t *testing.T) {
+	lines := strings.Split(`configType: java
+configVersion: 2
+# comment: line
+subProcesses:
+  sidecar:
+    jvmOpts:
+    - -Xmx1g
+    agents:
+      - jar: a.jar
+        args: x
+      -   jar: b.jar
+          sha: abc
+    env: {KEY: value}
+jvmOpts: []
+`, "\n")
+	for i, currCase := range []struct {
+		path string
+		want int
+	}{
+		{path: "configVersion", want: 2},
+		{path: "subProcesses.sidecar.jvmOpts", want: 6},
+		{path: "subProcesses.sidecar.agents.0.args", want: 10},
+		{path: "subProcesses.sidecar.agents.1.sha", want: 12},
+		{path: "subProcesses.sidecar.agents.0.sha", want: 0},
+		{path: "subProcesses.sidecar.env.KEY", want: 0},
+		{path: "jvmOpts", want: 14},
+		{path: "comment", want: 0},
+	} {
+		assert.Equal(t, currCase.want, keyLine(lines, strings.Split(currCase.path, ".")), "Case %d", i)
+	}
+}
+
+func TestSuggestKey(t *testing.T) {
+	fields := yamlFields(reflect.TypeOf(PrimaryStaticLauncherConfig{}))
+	for i, currCase := range []struct {
+		key  string
+		want string
+	}{
+		{key: "jvmOps", want: "jvmOpts"},
+		{key: "mainclass", want: "mainClass"},
+		{key: "envs", want: "env"},
+		{key: "subprocesses", want: "subProcesses"},
+		{key: "somethingElse", want: ""},
+		{key: "foo", want: ""},
+	} {
+		assert.Equal(t, currCase.want, suggestKey(currCase.key, fields), "Case %d", i)
+	}
+}
+
+func TestParseConfigStrictKeys(t *testing.T) {
+	defer func(strict bool) {
+		StrictKeys = strict
+	}(StrictKeys)
+	for i, currCase := range []struct {
+		version    int
+		strictKeys bool
+		wantErr    bool
+	}{
+		{version: 1},
+		{version: 1, strictKeys: true, wantErr: true},
+		{version: 2, wantErr: true},
+	} {
+		StrictKeys = currCase.strictKeys
+		_, err := parseStaticConfig([]byte(fmt.Sprintf(`configType: executable
+configVersion: %d
+serviceName: primary
+executable: postgres
+startRetrys: 3
+`, currCase.version)))
+		_, customErr := parseCustomConfig([]byte(fmt.Sprintf("configType: executable\nconfigVersion: %d\nenvs: {}\n",
+			currCase.version)))
+		if !currCase.wantErr {
+			assert.NoError(t, err, "Case %d", i)
+			assert.NoError(t, customErr, "Case %d", i)
+			continue
+		}
+		require.Error(t, err, "Case %d", i)
+		assert.Equal(t, "invalid static config: found keys that are not part of the configuration format: line 5: "+
+			"unknown key 'startRetrys', did you mean 'startRetries'?", err.Error(), "Case %d", i)
+		require.Error(t, customErr, "Case %d", i)
+		assert.Equal(t, "invalid custom config: found keys that are not part of the configuration format: line 3: "+
+			"unknown key 'envs', did you mean 'env'?", customErr.Error(), "Case %d", i)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// VerifyConfig is a file, such as the static config, a jar or an agent, that must be unchanged for the service to be
+// launched, as verified by either its SHA256 checksum or a detached Signature of it made with the private key of
+// PublicKey. Paths are relative to the directory the launcher runs in unless absolute.
+type VerifyConfig struct {
+	File   string `yaml:"file"`
+	SHA256 string `yaml:"sha256"`
+	// Signature is the file of the signature of the SHA-256 digest of File, as made by
+	// 'openssl dgst -sha256 -sign <private key> -out <signature> <file>' with an RSA or ECDSA key.
+	Signature string `yaml:"signature"`
+	// PublicKey is the PEM file of the public key that Signature is verified with.
+	PublicKey string `yaml:"publicKey"`
+}
+
+func (config VerifyConfig) validate() error {
+	if config.File == "" {
+		return errors.New("file must be set")
+	}
+	if (config.SHA256 == "") == (config.Signature == "") {
+		return errors.Errorf("exactly one of sha256 and signature must be set for %s", config.File)
+	}
+	if config.SHA256 != "" && !sha256Pattern.MatchString(config.SHA256) {
+		return errors.Errorf("sha256 must be 64 hexadecimal characters, found '%s'", config.SHA256)
+	}
+	if (config.Signature == "") != (config.PublicKey == "") {
+		return errors.Errorf("signature and publicKey must be set together for %s", config.File)
+	}
+	return nil
+}
+
+// VerifyFiles verifies the checksum or signature of each of the files in parallel, returning an error listing every
+// file that does not match so that the service is not launched.
+func VerifyFiles(configs []VerifyConfig, stdout io.Writer) error {
+	workingDir := getWorkingDir()
+	resolve := func(file string) string {
+		if filepath.IsAbs(file) {
+			return file
+		}
+		return filepath.Join(workingDir, file)
+	}
+	errs := runParallel(len(configs), func(i int) error {
+		config := configs[i]
+		var err error
+		if config.SHA256 != "" {
+			err = verifySHA256(resolve(config.File), config.SHA256)
+		} else {
+			err = verifySignature(resolve(config.File), resolve(config.Signature), resolve(config.PublicKey))
+		}
+		return errors.Wrapf(err, "failed to verify %s", config.File)
+	})
+	if err := joinErrors("%d files failed verification", errs); err != nil {
+		return err
+	}
+	if len(configs) > 0 {
+		fmt.Fprintf(stdout, "Verified %d files\n", len(configs))
+	}
+	return nil
+}
+
+// verifySignature verifies that the signature file is the signature of the SHA-256 digest of file by the private key
+// of the RSA or ECDSA public key in the PEM file.
+func verifySignature(file, signatureFile, publicKeyFile string) error {
+	keyBytes, err := ioutil.ReadFile(publicKeyFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to read public key")
+	}
+	block, _ := pem.Decode(keyBytes)
+	if block == nil {
+		return errors.Errorf("public key %s is not a PEM file", publicKeyFile)
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse public key %s", publicKeyFile)
+	}
+	signature, err := ioutil.ReadFile(signatureFile)
+	if err != nil {
+		return errors.Wrap(err, "failed to read signature")
+	}
+	digest, err := sha256Digest(file)
+	if err != nil {
+		return err
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature); err != nil {
+			return errors.Errorf("signature %s does not match", signatureFile)
+		}
+	case *ecdsa.PublicKey:
+		var ecdsaSignature struct {
+			R, S *big.Int
+		}
+		if _, err := asn1.Unmarshal(signature, &ecdsaSignature); err != nil ||
+			!ecdsa.Verify(key, digest, ecdsaSignature.R, ecdsaSignature.S) {
+			return errors.Errorf("signature %s does not match", signatureFile)
+		}
+	default:
+		return errors.Errorf("public key %s must be an RSA or ECDSA key", publicKeyFile)
+	}
+	return nil
+}
+
+func sha256Digest(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(dir))
+	}()
+	file := filepath.Join(dir, "service.jar")
+	require.NoError(t, ioutil.WriteFile(file, []byte("service"), 0644))
+	digest := sha256.Sum256([]byte("service"))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaSignature, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	writeVerifyFiles(t, dir, "rsa", &rsaKey.PublicKey, rsaSignature)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	r, s, err := ecdsa.Sign(rand.Reader, ecdsaKey, digest[:])
+	require.NoError(t, err)
+	ecdsaSignature, err := asn1.Marshal(struct{ R, S interface{} }{r, s})
+	require.NoError(t, err)
+	writeVerifyFiles(t, dir, "ecdsa", &ecdsaKey.PublicKey, ecdsaSignature)
+
+	for i, currCase := range []struct {
+		config VerifyConfig
+		err    string
+	}{
+		{config: VerifyConfig{File: file,
+			SHA256: "2b7fcfcf1e2b7e8cc6e0c8d1e1e6d6b4b8d1e3f6d0c4e2d1b3f1c8e2a3b4c5d6"},
+			err: "failed to verify " + file + ": sha256 checksum "},
+		{config: VerifyConfig{File: file, Signature: filepath.Join(dir, "rsa.sig"),
+			PublicKey: filepath.Join(dir, "rsa.pem")}},
+		{config: VerifyConfig{File: file, Signature: filepath.Join(dir, "ecdsa.sig"),
+			PublicKey: filepath.Join(dir, "ecdsa.pem")}},
+		{config: VerifyConfig{File: file, Signature: filepath.Join(dir, "rsa.sig"),
+			PublicKey: filepath.Join(dir, "ecdsa.pem")},
+			err: "failed to verify " + file + ": signature " + filepath.Join(dir, "rsa.sig") + " does not match"},
+	} {
+		err := VerifyFiles([]VerifyConfig{currCase.config}, ioutil.Discard)
+		if currCase.err == "" {
+			assert.NoError(t, err, "Case %d", i)
+		} else {
+			require.Error(t, err, "Case %d", i)
+			assert.Contains(t, err.Error(), currCase.err, "Case %d", i)
+		}
+	}
+
+	// A file that has changed since it was signed is not launched.
+	require.NoError(t, ioutil.WriteFile(file, []byte("tampered"), 0644))
+	assert.EqualError(t, VerifyFiles([]VerifyConfig{{File: file, Signature: filepath.Join(dir, "rsa.sig"),
+		PublicKey: filepath.Join(dir, "rsa.pem")}}, ioutil.Discard),
+		"failed to verify "+file+": signature "+filepath.Join(dir, "rsa.sig")+" does not match")
+
+	// Every file that does not match is reported at once.
+	missing := filepath.Join(dir, "missing.jar")
+	err = VerifyFiles([]VerifyConfig{
+		{File: file, Signature: filepath.Join(dir, "rsa.sig"), PublicKey: filepath.Join(dir, "rsa.pem")},
+		{File: missing, SHA256: hex.EncodeToString(digest[:])},
+	}, ioutil.Discard)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "2 files failed verification:\n- failed to verify "+file+": signature ")
+	assert.Contains(t, err.Error(), "\n- failed to verify "+missing+": ")
+}
+
+func writeVerifyFiles(t *testing.T, dir, name string, publicKey interface{}, signature []byte) {
+	keyBytes, err := x509.MarshalPKIXPublicKey(publicKey)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: keyBytes}), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name+".sig"), signature, 0644))
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Version, Commit and BuildDate describe the build of go-java-launcher and go-init. They are set when building with
+// -ldflags "-X github.com/palantir/go-java-launcher/launchlib.Version=<version>", and likewise for the others.
+var (
+	Version   = "unspecified"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// BuildInfo describes the build of the launcher and the configuration formats it reads, so that tooling can check that
+// a launcher supports a configuration before deploying it.
+type BuildInfo struct {
+	Version             string `json:"version"`
+	Commit              string `json:"commit,omitempty"`
+	BuildDate           string `json:"buildDate,omitempty"`
+	GoVersion           string `json:"goVersion"`
+	ConfigVersions      []int  `json:"configVersions"`
+	LatestConfigVersion int    `json:"latestConfigVersion"`
+}
+
+// GetBuildInfo returns the BuildInfo of the running launcher.
+func GetBuildInfo() BuildInfo {
+	configVersions := make([]int, 0, len(allowedLauncherConfigs.ConfigVersions))
+	for version := range allowedLauncherConfigs.ConfigVersions {
+		configVersions = append(configVersions, version)
+	}
+	sort.Ints(configVersions)
+	return BuildInfo{
+		Version:             Version,
+		Commit:              Commit,
+		BuildDate:           BuildDate,
+		GoVersion:           runtime.Version(),
+		ConfigVersions:      configVersions,
+		LatestConfigVersion: LatestConfigVersion,
+	}
+}
+
+// Write writes the build info of the program of the given name as lines of text, omitting the commit and build date
+// if they were not set when building.
+func (info BuildInfo) Write(w io.Writer, program string) {
+	fmt.Fprintf(w, "%s version %s\n", program, info.Version)
+	if info.Commit != "" {
+		fmt.Fprintf(w, "commit: %s\n", info.Commit)
+	}
+	if info.BuildDate != "" {
+		fmt.Fprintf(w, "built: %s\n", info.BuildDate)
+	}
+	fmt.Fprintf(w, "go: %s\n", info.GoVersion)
+	configVersions := make([]string, len(info.ConfigVersions))
+	for i, version := range info.ConfigVersions {
+		configVersions[i] = fmt.Sprint(version)
+	}
+	fmt.Fprintf(w, "configVersions: %s (latest %d)\n", strings.Join(configVersions, ", "),
+		info.LatestConfigVersion)
+}
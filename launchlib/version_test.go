@@ -0,0 +1,69 @@
+// Copyright 2016 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package launchlib
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBuildInfo(t *testing.T) {
+	info := GetBuildInfo()
+	assert.Equal(t, Version, info.Version)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.Equal(t, []int{1, 2}, info.ConfigVersions)
+	assert.Equal(t, LatestConfigVersion, info.LatestConfigVersion)
+}
+
+func TestGetBuildInfo_SetByLdflags(t *testing.T) {
+	version, commit, buildDate := Version, Commit, BuildDate
+	defer func() {
+		Version, Commit, BuildDate = version, commit, buildDate
+	}()
+	// As set by the -ldflags of the dist build configuration.
+	Version, Commit, BuildDate = "1.2.3", "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567", "2020-01-02T12:03:42Z"
+
+	buf := &bytes.Buffer{}
+	GetBuildInfo().Write(buf, "go-init")
+	lines := strings.Split(buf.String(), "\n")
+	assert.Equal(t, []string{
+		"go-init version 1.2.3",
+		"commit: 0a1b2c3d4e5f60718293a4b5c6d7e8f901234567",
+		"built: 2020-01-02T12:03:42Z",
+	}, lines[:3])
+	assert.Regexp(t, `^go: go\S+$`, lines[3])
+}
+
+func TestBuildInfoWrite(t *testing.T) {
+	for i, currCase := range []struct {
+		info BuildInfo
+		want string
+	}{
+		{BuildInfo{Version: "1.2.3", GoVersion: "go1.10", ConfigVersions: []int{1, 2}, LatestConfigVersion: 2},
+			"go-init version 1.2.3\ngo: go1.10\nconfigVersions: 1, 2 (latest 2)\n"},
+		{BuildInfo{Version: "1.2.3", Commit: "abc123", BuildDate: "2018-06-01T00:00:00Z", GoVersion: "go1.10",
+			ConfigVersions: []int{1, 2}, LatestConfigVersion: 2},
+			"go-init version 1.2.3\ncommit: abc123\nbuilt: 2018-06-01T00:00:00Z\ngo: go1.10\n" +
+				"configVersions: 1, 2 (latest 2)\n"},
+	} {
+		buf := &bytes.Buffer{}
+		currCase.info.Write(buf, "go-init")
+		assert.Equal(t, currCase.want, buf.String(), "Case %d", i)
+	}
+}